@@ -589,6 +589,22 @@ DBStatus DBBatch::EnvDeleteDirAndFiles(DBSlice dir) { return FmtStatus("unsuppor
 
 DBStatus DBBatch::EnvLinkFile(DBSlice oldname, DBSlice newname) { return FmtStatus("unsupported"); }
 
+DBStatus DBBatch::EnvMkdirAll(DBSlice dir) { return FmtStatus("unsupported"); }
+
+DBStatus DBBatch::EnvRenameFile(DBSlice oldname, DBSlice newname) {
+  return FmtStatus("unsupported");
+}
+
+DBStatus DBBatch::EnvDeleteDir(DBSlice dir) { return FmtStatus("unsupported"); }
+
+DBStatus DBBatch::EnvListDir(DBSlice dir, DBString* names) { return FmtStatus("unsupported"); }
+
+DBStatus DBBatch::EnvGetFileSize(DBSlice path, uint64_t* size) { return FmtStatus("unsupported"); }
+
+DBStatus DBBatch::EnvNumFileLinks(DBSlice path, uint64_t* count) {
+  return FmtStatus("unsupported");
+}
+
 DBWriteOnlyBatch::DBWriteOnlyBatch(DBEngine* db) : DBEngine(db->rep, db->iters), updates(0) {}
 
 DBWriteOnlyBatch::~DBWriteOnlyBatch() {}
@@ -698,6 +714,26 @@ DBStatus DBWriteOnlyBatch::EnvLinkFile(DBSlice oldname, DBSlice newname) {
   return FmtStatus("unsupported");
 }
 
+DBStatus DBWriteOnlyBatch::EnvMkdirAll(DBSlice dir) { return FmtStatus("unsupported"); }
+
+DBStatus DBWriteOnlyBatch::EnvRenameFile(DBSlice oldname, DBSlice newname) {
+  return FmtStatus("unsupported");
+}
+
+DBStatus DBWriteOnlyBatch::EnvDeleteDir(DBSlice dir) { return FmtStatus("unsupported"); }
+
+DBStatus DBWriteOnlyBatch::EnvListDir(DBSlice dir, DBString* names) {
+  return FmtStatus("unsupported");
+}
+
+DBStatus DBWriteOnlyBatch::EnvGetFileSize(DBSlice path, uint64_t* size) {
+  return FmtStatus("unsupported");
+}
+
+DBStatus DBWriteOnlyBatch::EnvNumFileLinks(DBSlice path, uint64_t* count) {
+  return FmtStatus("unsupported");
+}
+
 rocksdb::WriteBatch::Handler* GetDBBatchInserter(::rocksdb::WriteBatchBase* batch) {
   return new DBBatchInserter(batch);
 }
@@ -258,7 +258,56 @@ DBStatus DBOpen(DBEngine** db, DBSlice dir, DBOptions db_opts) {
   return kSuccess;
 }
 
-DBStatus DBCreateCheckpoint(DBEngine* db, DBSlice dir) {
+namespace {
+
+// restrictCheckpoint deletes the SSTs of the checkpoint in cp_dir which don't
+// overlap any of the given spans (see DBCreateCheckpoint).
+rocksdb::Status restrictCheckpoint(DBEngine* db, const std::string& cp_dir, DBSlice* spans,
+                                   size_t num_spans) {
+  // Open the checkpoint with the engine's options, so that it shares the
+  // engine's comparator and env, but without the event listeners and
+  // statistics, which belong to the engine, and without automatic
+  // compactions, which would only rewrite the files we're about to delete.
+  rocksdb::Options options = db->rep->GetOptions();
+  options.create_if_missing = false;
+  options.disable_auto_compactions = true;
+  options.listeners.clear();
+  options.statistics.reset();
+
+  rocksdb::DB* cp_ptr;
+  auto status = rocksdb::DB::Open(options, cp_dir, &cp_ptr);
+  if (!status.ok()) {
+    return status;
+  }
+  std::unique_ptr<rocksdb::DB> cp(cp_ptr);
+
+  // The SSTs to delete are those which fall entirely into one of the gaps
+  // before, between and after the spans.
+  std::vector<std::string> bounds;
+  bounds.reserve(2 * num_spans);
+  for (size_t i = 0; i < 2 * num_spans; i++) {
+    bounds.push_back(EncodeKey(ToSlice(spans[i]), 0, 0));
+  }
+  std::vector<rocksdb::Slice> slices(bounds.begin(), bounds.end());
+  std::vector<rocksdb::RangePtr> gaps;
+  gaps.reserve(num_spans + 1);
+  gaps.emplace_back(nullptr, &slices[0]);
+  for (size_t i = 1; i < num_spans; i++) {
+    gaps.emplace_back(&slices[2 * i - 1], &slices[2 * i]);
+  }
+  gaps.emplace_back(&slices[2 * num_spans - 1], nullptr);
+
+  status = rocksdb::DeleteFilesInRanges(cp.get(), cp->DefaultColumnFamily(), gaps.data(),
+                                        gaps.size(), false /* include_end */);
+  if (!status.ok()) {
+    return status;
+  }
+  return cp->Close();
+}
+
+}  // namespace
+
+DBStatus DBCreateCheckpoint(DBEngine* db, DBSlice dir, DBSlice* spans, size_t num_spans) {
   const std::string cp_dir = ToString(dir);
 
   rocksdb::Checkpoint* cp_ptr;
@@ -270,7 +319,10 @@ DBStatus DBCreateCheckpoint(DBEngine* db, DBSlice dir) {
   // that the checkpoint is up to date.
   status = cp_ptr->CreateCheckpoint(cp_dir, 0 /* log_size_for_flush */);
   delete(cp_ptr);
-  return ToDBStatus(status);
+  if (!status.ok() || num_spans == 0) {
+    return ToDBStatus(status);
+  }
+  return ToDBStatus(restrictCheckpoint(db, cp_dir, spans, num_spans));
 }
 
 
@@ -441,6 +493,15 @@ DBStatus DBEnableAutoCompaction(DBEngine* db) {
   return ToDBStatus(status);
 }
 
+DBStatus DBSetCompactionConcurrency(DBEngine* db, int concurrency) {
+  // Always allow at least 2 background jobs, otherwise compactions and
+  // flushes may fight with each other (see DBMakeOptions).
+  const int max_background_jobs = std::max(concurrency, 2);
+  auto status =
+      db->rep->SetDBOptions({{"max_background_jobs", std::to_string(max_background_jobs)}});
+  return ToDBStatus(status);
+}
+
 DBStatus DBApproximateDiskBytes(DBEngine* db, DBKey start, DBKey end, uint64_t* size) {
   const std::string start_key(EncodeKey(start));
   const std::string end_key(EncodeKey(end));
@@ -531,6 +592,26 @@ DBStatus DBEnvLinkFile(DBEngine* db, DBSlice oldname, DBSlice newname) {
   return db->EnvLinkFile(oldname, newname);
 }
 
+DBStatus DBEnvMkdirAll(DBEngine* db, DBSlice dir) { return db->EnvMkdirAll(dir); }
+
+DBStatus DBEnvRenameFile(DBEngine* db, DBSlice oldname, DBSlice newname) {
+  return db->EnvRenameFile(oldname, newname);
+}
+
+DBStatus DBEnvDeleteDir(DBEngine* db, DBSlice dir) { return db->EnvDeleteDir(dir); }
+
+DBStatus DBEnvListDir(DBEngine* db, DBSlice dir, DBString* names) {
+  return db->EnvListDir(dir, names);
+}
+
+DBStatus DBEnvGetFileSize(DBEngine* db, DBSlice path, uint64_t* size) {
+  return db->EnvGetFileSize(path, size);
+}
+
+DBStatus DBEnvNumFileLinks(DBEngine* db, DBSlice path, uint64_t* count) {
+  return db->EnvNumFileLinks(path, count);
+}
+
 DBIterator* DBNewIter(DBEngine* db, DBIterOptions iter_options) {
   return db->NewIter(iter_options);
 }
@@ -851,6 +932,14 @@ DBStatus DBSstFileWriterDelete(DBSstFileWriter* fw, DBKey key) {
   return kSuccess;
 }
 
+DBStatus DBSstFileWriterDeleteRange(DBSstFileWriter* fw, DBKey start, DBKey end) {
+  rocksdb::Status status = fw->rep.DeleteRange(EncodeKey(start), EncodeKey(end));
+  if (!status.ok()) {
+    return ToDBStatus(status);
+  }
+  return kSuccess;
+}
+
 DBStatus DBSstFileWriterFinish(DBSstFileWriter* fw, DBString* data) {
   rocksdb::Status status = fw->rep.Finish();
   if (!status.ok()) {
@@ -206,6 +206,9 @@ DBStatus DBImpl::GetStats(DBStatsResult* stats) {
   std::string l0_file_count_str;
   rep->GetProperty("rocksdb.num-files-at-level0", &l0_file_count_str);
 
+  uint64_t running_compactions;
+  rep->GetIntProperty("rocksdb.num-running-compactions", &running_compactions);
+
   stats->block_cache_hits = (int64_t)s->getTickerCount(rocksdb::BLOCK_CACHE_HIT);
   stats->block_cache_misses = (int64_t)s->getTickerCount(rocksdb::BLOCK_CACHE_MISS);
   stats->block_cache_usage = (int64_t)block_cache->GetUsage();
@@ -220,6 +223,7 @@ DBStatus DBImpl::GetStats(DBStatsResult* stats) {
   stats->table_readers_mem_estimate = table_readers_mem_estimate;
   stats->pending_compaction_bytes_estimate = pending_compaction_bytes_estimate;
   stats->l0_file_count = std::atoi(l0_file_count_str.c_str());
+  stats->running_compactions = running_compactions;
   return kSuccess;
 }
 
@@ -349,7 +353,8 @@ DBStatus DBImpl::GetEncryptionRegistries(DBEncryptionRegistries* result) {
   return kSuccess;
 }
 
-// EnvWriteFile writes the given data as a new "file" in the given engine.
+// EnvWriteFile writes the given data as a new "file" in the given engine and
+// syncs it before returning.
 DBStatus DBImpl::EnvWriteFile(DBSlice path, DBSlice contents) {
   rocksdb::Status s;
 
@@ -365,7 +370,12 @@ DBStatus DBImpl::EnvWriteFile(DBSlice path, DBSlice contents) {
     return ToDBStatus(s);
   }
 
-  return kSuccess;
+  s = destfile->Sync();
+  if (!s.ok()) {
+    return ToDBStatus(s);
+  }
+
+  return ToDBStatus(destfile->Close());
 }
 
 // EnvOpenFile opens a new file in the given engine.
@@ -454,4 +464,71 @@ DBStatus DBImpl::EnvLinkFile(DBSlice oldname, DBSlice newname) {
   return ToDBStatus(this->rep->GetEnv()->LinkFile(ToString(oldname), ToString(newname)));
 }
 
+// EnvMkdirAll creates the given directory along with any missing parents.
+DBStatus DBImpl::EnvMkdirAll(DBSlice dir) {
+  const std::string path = ToString(dir);
+  rocksdb::Env* env = this->rep->GetEnv();
+  for (size_t pos = path.find('/', 1);; pos = path.find('/', pos + 1)) {
+    rocksdb::Status status = env->CreateDirIfMissing(path.substr(0, pos));
+    if (!status.ok()) {
+      return ToDBStatus(status);
+    }
+    if (pos == std::string::npos) {
+      return kSuccess;
+    }
+  }
+}
+
+// notFoundStatus returns the status for the given rocksdb status, reporting
+// files that don't exist the same way as the other Env methods.
+static DBStatus notFoundStatus(const rocksdb::Status& status) {
+  if (status.IsNotFound()) {
+    return FmtStatus("No such file or directory");
+  }
+  return ToDBStatus(status);
+}
+
+// EnvRenameFile renames the file 'oldname' to 'newname'.
+DBStatus DBImpl::EnvRenameFile(DBSlice oldname, DBSlice newname) {
+  return notFoundStatus(this->rep->GetEnv()->RenameFile(ToString(oldname), ToString(newname)));
+}
+
+// EnvDeleteDir deletes the given empty directory.
+DBStatus DBImpl::EnvDeleteDir(DBSlice dir) {
+  return notFoundStatus(this->rep->GetEnv()->DeleteDir(ToString(dir)));
+}
+
+// EnvListDir lists the names of the children of the given directory,
+// separated by NUL bytes.
+DBStatus DBImpl::EnvListDir(DBSlice dir, DBString* names) {
+  std::vector<std::string> children;
+  rocksdb::Status status = this->rep->GetEnv()->GetChildren(ToString(dir), &children);
+  if (!status.ok()) {
+    return notFoundStatus(status);
+  }
+  std::string joined;
+  for (auto& child : children) {
+    if (child == "." || child == "..") {
+      continue;
+    }
+    if (!joined.empty()) {
+      joined.push_back('\0');
+    }
+    joined.append(child);
+  }
+  *names = ToDBString(joined);
+  return kSuccess;
+}
+
+// EnvGetFileSize returns the size of the file with the given filename.
+DBStatus DBImpl::EnvGetFileSize(DBSlice path, uint64_t* size) {
+  return notFoundStatus(this->rep->GetEnv()->GetFileSize(ToString(path), size));
+}
+
+// EnvNumFileLinks returns the number of hard links to the file with the given
+// filename. Envs that don't support hard links return an error.
+DBStatus DBImpl::EnvNumFileLinks(DBSlice path, uint64_t* count) {
+  return notFoundStatus(this->rep->GetEnv()->NumFileLinks(ToString(path), count));
+}
+
 }  // namespace cockroach
@@ -54,6 +54,12 @@ struct DBEngine {
   virtual DBStatus EnvDeleteFile(DBSlice path) = 0;
   virtual DBStatus EnvDeleteDirAndFiles(DBSlice dir) = 0;
   virtual DBStatus EnvLinkFile(DBSlice oldname, DBSlice newname) = 0;
+  virtual DBStatus EnvMkdirAll(DBSlice dir) = 0;
+  virtual DBStatus EnvRenameFile(DBSlice oldname, DBSlice newname) = 0;
+  virtual DBStatus EnvDeleteDir(DBSlice dir) = 0;
+  virtual DBStatus EnvListDir(DBSlice dir, DBString* names) = 0;
+  virtual DBStatus EnvGetFileSize(DBSlice path, uint64_t* size) = 0;
+  virtual DBStatus EnvNumFileLinks(DBSlice path, uint64_t* count) = 0;
 
   DBSSTable* GetSSTables(int* n);
   DBStatus GetSortedWALFiles(DBWALFile** out_files, int* n);
@@ -103,6 +109,12 @@ struct DBImpl : public DBEngine {
   virtual DBStatus EnvDeleteFile(DBSlice path);
   virtual DBStatus EnvDeleteDirAndFiles(DBSlice dir);
   virtual DBStatus EnvLinkFile(DBSlice oldname, DBSlice newname);
+  virtual DBStatus EnvMkdirAll(DBSlice dir);
+  virtual DBStatus EnvRenameFile(DBSlice oldname, DBSlice newname);
+  virtual DBStatus EnvDeleteDir(DBSlice dir);
+  virtual DBStatus EnvListDir(DBSlice dir, DBString* names);
+  virtual DBStatus EnvGetFileSize(DBSlice path, uint64_t* size);
+  virtual DBStatus EnvNumFileLinks(DBSlice path, uint64_t* count);
 };
 
 }  // namespace cockroach
@@ -103,8 +103,10 @@ DBStatus DBOpen(DBEngine** db, DBSlice dir, DBOptions options);
 // Creates a RocksDB checkpoint in the specified directory (which must not exist).
 // A checkpoint is a logical copy of the database, though it will hardlink the
 // SSTs references by it (when possible), thus avoiding duplication of any of
-// the actual data.
-DBStatus DBCreateCheckpoint(DBEngine* db, DBSlice dir);
+// the actual data. If num_spans is non-zero, spans holds the start and end
+// keys of num_spans sorted, non-overlapping key spans, and only the SSTs which
+// overlap one of them are retained in the checkpoint.
+DBStatus DBCreateCheckpoint(DBEngine* db, DBSlice dir, DBSlice* spans, size_t num_spans);
 
 // Set a callback to be invoked during DBOpen that can make changes to RocksDB
 // initialization. Used by CCL code to install additional features.
@@ -143,6 +145,12 @@ DBStatus DBCompactRange(DBEngine* db, DBSlice start, DBSlice end, bool force_bot
 DBStatus DBDisableAutoCompaction(DBEngine* db);
 DBStatus DBEnableAutoCompaction(DBEngine* db);
 
+// Sets the maximum number of background compactions and flushes which
+// may run concurrently. Lowering it reduces the IO impact of
+// compactions on foreground traffic, at the cost of higher read
+// amplification if compactions can't keep up with the writes.
+DBStatus DBSetCompactionConcurrency(DBEngine* db, int concurrency);
+
 // Stores the approximate on-disk size of the given key range into the
 // supplied uint64.
 DBStatus DBApproximateDiskBytes(DBEngine* db, DBKey start, DBKey end, uint64_t* size);
@@ -355,6 +363,7 @@ typedef struct {
   int64_t table_readers_mem_estimate;
   int64_t pending_compaction_bytes_estimate;
   int64_t l0_file_count;
+  int64_t running_compactions;
 } DBStatsResult;
 
 typedef struct {
@@ -465,8 +474,14 @@ DBStatus DBSstFileWriterAdd(DBSstFileWriter* fw, DBKey key, DBSlice val);
 // Adds a deletion tombstone to the sstable being built. See DBSstFileWriterAdd for more.
 DBStatus DBSstFileWriterDelete(DBSstFileWriter* fw, DBKey key);
 
+// Adds a range deletion tombstone covering [start, end) to the sstable being
+// built. Unlike point entries, range deletion tombstones can be added in any
+// order.
+DBStatus DBSstFileWriterDeleteRange(DBSstFileWriter* fw, DBKey start, DBKey end);
+
 // Finalizes the writer and stores the constructed file's contents in *data. At
-// least one kv entry must have been added. May only be called once.
+// least one kv entry or range deletion tombstone must have been added. May
+// only be called once.
 DBStatus DBSstFileWriterFinish(DBSstFileWriter* fw, DBString* data);
 
 // Closes the writer and frees memory and other resources. May only be called
@@ -476,7 +491,8 @@ void DBSstFileWriterClose(DBSstFileWriter* fw);
 void DBRunLDB(int argc, char** argv);
 void DBRunSSTDump(int argc, char** argv);
 
-// DBEnvWriteFile writes the given data as a new "file" in the given engine.
+// DBEnvWriteFile writes the given data as a new "file" in the given engine
+// and syncs it.
 DBStatus DBEnvWriteFile(DBEngine* db, DBSlice path, DBSlice contents);
 
 // DBEnvOpenFile opens a DBWritableFile as a new "file" in the given engine.
@@ -505,6 +521,29 @@ DBStatus DBEnvDeleteDirAndFiles(DBEngine* db, DBSlice dir);
 // DBEnvLinkFile creates 'newname' as a hard link to 'oldname using the given engine.
 DBStatus DBEnvLinkFile(DBEngine* db, DBSlice oldname, DBSlice newname);
 
+// DBEnvMkdirAll creates the given directory and any missing parent directories
+// using the given engine.
+DBStatus DBEnvMkdirAll(DBEngine* db, DBSlice dir);
+
+// DBEnvRenameFile renames the file 'oldname' to 'newname' using the given
+// engine.
+DBStatus DBEnvRenameFile(DBEngine* db, DBSlice oldname, DBSlice newname);
+
+// DBEnvDeleteDir deletes the given empty directory using the given engine.
+DBStatus DBEnvDeleteDir(DBEngine* db, DBSlice dir);
+
+// DBEnvListDir lists the names of the children of the given directory using
+// the given engine. The names are separated by NUL bytes.
+DBStatus DBEnvListDir(DBEngine* db, DBSlice dir, DBString* names);
+
+// DBEnvGetFileSize returns the size of the file with the given filename in
+// the given engine.
+DBStatus DBEnvGetFileSize(DBEngine* db, DBSlice path, uint64_t* size);
+
+// DBEnvNumFileLinks returns the number of hard links to the file with the
+// given filename in the given engine.
+DBStatus DBEnvNumFileLinks(DBEngine* db, DBSlice path, uint64_t* count);
+
 // DBFileLock contains various parameters set during DBLockFile and required for DBUnlockFile.
 typedef void* DBFileLock;
 
@@ -9,6 +9,7 @@ const rocksdb::Slice kLocalMax("\x02", 1);
 const rocksdb::Slice kLocalRangeIDPrefix("\x01\x69", 2);
 const rocksdb::Slice kLocalRangeIDReplicatedInfix("\x72", 1);
 const rocksdb::Slice kLocalRangeAppliedStateSuffix("\x72\x61\x73\x6b", 4);
+const rocksdb::Slice kLocalRangeLockTablePrefix("\x01\x7a", 2);
 const rocksdb::Slice kMeta2KeyMax("\x03\xff\xff", 3);
 const rocksdb::Slice kMinKey("", 0);
 const rocksdb::Slice kMaxKey("\xff\xff", 2);
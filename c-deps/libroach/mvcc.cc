@@ -113,6 +113,10 @@ MVCCStatsResult MVCCComputeStatsInternal(::rocksdb::Iterator* const iter_rep, DB
           continue;
         }
       }
+    } else if (decoded_key.starts_with(kLocalRangeLockTablePrefix)) {
+      // Lock table key. Ignore, as each lock table entry mirrors an intent
+      // which is already accounted for.
+      continue;
     }
 
     const bool isSys = (rocksdb::Slice(decoded_key).compare(kLocalMax) < 0);
@@ -209,6 +209,12 @@ template <bool reverse> class mvccScanner {
   }
 
   bool getAndAdvance() {
+    if (cur_key_.starts_with(kLocalRangeLockTablePrefix)) {
+      // Lock table entries mirror intents found in the MVCC data and are
+      // not themselves visible to MVCC reads.
+      return advanceKey();
+    }
+
     const bool is_value = cur_timestamp_ != kZeroTimestamp;
 
     if (is_value) {
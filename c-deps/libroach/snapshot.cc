@@ -92,4 +92,22 @@ DBStatus DBSnapshot::EnvLinkFile(DBSlice oldname, DBSlice newname) {
   return FmtStatus("unsupported");
 }
 
+DBStatus DBSnapshot::EnvMkdirAll(DBSlice dir) { return FmtStatus("unsupported"); }
+
+DBStatus DBSnapshot::EnvRenameFile(DBSlice oldname, DBSlice newname) {
+  return FmtStatus("unsupported");
+}
+
+DBStatus DBSnapshot::EnvDeleteDir(DBSlice dir) { return FmtStatus("unsupported"); }
+
+DBStatus DBSnapshot::EnvListDir(DBSlice dir, DBString* names) { return FmtStatus("unsupported"); }
+
+DBStatus DBSnapshot::EnvGetFileSize(DBSlice path, uint64_t* size) {
+  return FmtStatus("unsupported");
+}
+
+DBStatus DBSnapshot::EnvNumFileLinks(DBSlice path, uint64_t* count) {
+  return FmtStatus("unsupported");
+}
+
 }  // namespace cockroach
@@ -50,6 +50,12 @@ struct DBSnapshot : public DBEngine {
   virtual DBStatus EnvDeleteFile(DBSlice path);
   virtual DBStatus EnvDeleteDirAndFiles(DBSlice dir);
   virtual DBStatus EnvLinkFile(DBSlice oldname, DBSlice newname);
+  virtual DBStatus EnvMkdirAll(DBSlice dir);
+  virtual DBStatus EnvRenameFile(DBSlice oldname, DBSlice newname);
+  virtual DBStatus EnvDeleteDir(DBSlice dir);
+  virtual DBStatus EnvListDir(DBSlice dir, DBString* names);
+  virtual DBStatus EnvGetFileSize(DBSlice path, uint64_t* size);
+  virtual DBStatus EnvNumFileLinks(DBSlice path, uint64_t* count);
 };
 
 }  // namespace cockroach
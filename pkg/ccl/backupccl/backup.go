@@ -11,6 +11,7 @@ package backupccl
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io/ioutil"
 	"sort"
 	"time"
@@ -34,6 +35,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptpb"
 	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/interval"
@@ -42,6 +45,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/cockroach/pkg/util/uint128"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/pkg/errors"
 )
 
@@ -690,6 +695,12 @@ func backup(
 			g.GoCtx(func(ctx context.Context) error {
 				defer func() { <-exportsSem }()
 				header := roachpb.Header{Timestamp: span.end}
+				if !span.start.IsEmpty() {
+					// Time-bound exports only read the revisions written since
+					// the previous backup, which makes them cheap enough to be
+					// serviced ahead of bulk traffic.
+					header.PriorityClass = roachpb.HIGH_PRIORITY
+				}
 				req := &roachpb.ExportRequest{
 					RequestHeader: roachpb.RequestHeaderFromSpan(span.span),
 					Storage:       exportStore.Conf(),
@@ -1143,6 +1154,9 @@ func (b *backupResumer) Resume(
 		// implementations.
 		log.Warningf(ctx, "unable to load backup checkpoint while resuming job %d: %v", *b.job.ID(), err)
 	}
+	if err := protectBackup(ctx, p.ExecCfg().DB, *b.job.ID(), &backupDesc); err != nil {
+		return errors.Wrap(err, "protecting backup spans from GC")
+	}
 	res, err := backup(
 		ctx,
 		p.ExecCfg().DB,
@@ -1158,11 +1172,58 @@ func (b *backupResumer) Resume(
 	return err
 }
 
+// backupProtectedTimestampID returns the ID of the protected timestamp record
+// of a backup job. It is derived from the job ID so that a resumed job finds
+// the record written by a previous attempt.
+func backupProtectedTimestampID(jobID int64) uuid.UUID {
+	return uuid.FromUint128(uint128.FromInts(0, uint64(jobID)))
+}
+
+// protectBackup protects the data read by the backup from garbage collection
+// until the job finishes. Incremental backups read the changes since their
+// start time, so the protection starts there.
+func protectBackup(
+	ctx context.Context, db *client.DB, jobID int64, backupDesc *BackupDescriptor,
+) error {
+	ts := backupDesc.EndTime
+	if backupDesc.StartTime != (hlc.Timestamp{}) {
+		ts = backupDesc.StartTime
+	}
+	r := ptpb.Record{
+		ID:          backupProtectedTimestampID(jobID),
+		Timestamp:   ts,
+		Spans:       backupDesc.Spans,
+		JobID:       jobID,
+		Description: fmt.Sprintf("BACKUP job %d", jobID),
+	}
+	return db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		err := protectedts.Protect(ctx, txn, &r)
+		if err == protectedts.ErrExists {
+			// A previous attempt of the job already protected the spans.
+			return nil
+		}
+		return err
+	})
+}
+
+// releaseBackup releases the protection written by protectBackup, if any.
+func releaseBackup(ctx context.Context, txn *client.Txn, jobID int64) error {
+	err := protectedts.Release(ctx, txn, backupProtectedTimestampID(jobID))
+	if err == protectedts.ErrNotExists {
+		return nil
+	}
+	return err
+}
+
 // OnFailOrCancel is part of the jobs.Resumer interface.
-func (b *backupResumer) OnFailOrCancel(context.Context, *client.Txn) error { return nil }
+func (b *backupResumer) OnFailOrCancel(ctx context.Context, txn *client.Txn) error {
+	return releaseBackup(ctx, txn, *b.job.ID())
+}
 
 // OnSuccess is part of the jobs.Resumer interface.
-func (b *backupResumer) OnSuccess(context.Context, *client.Txn) error { return nil }
+func (b *backupResumer) OnSuccess(ctx context.Context, txn *client.Txn) error {
+	return releaseBackup(ctx, txn, *b.job.ID())
+}
 
 // OnTerminal is part of the jobs.Resumer interface.
 func (b *backupResumer) OnTerminal(
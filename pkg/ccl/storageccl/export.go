@@ -161,6 +161,11 @@ func evalExport(
 	}
 	rows.BulkOpSummary.DataSize = sst.DataSize
 
+	// Account the data read against the store's background IO budget.
+	if err := cArgs.EvalCtx.GetLimiters().ExportReadRate.WaitN(ctx, int(sst.DataSize)); err != nil {
+		return result.Result{}, err
+	}
+
 	sstContents, err := sst.Finish()
 	if err != nil {
 		return result.Result{}, err
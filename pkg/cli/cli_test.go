@@ -2363,11 +2363,14 @@ writing ` + os.DevNull + `
   debug/liveness.json
   debug/settings.json
   debug/reports/problemranges.json
+  debug/crdb_internal.cluster_distsql_flows.txt
   debug/crdb_internal.cluster_queries.txt
   debug/crdb_internal.cluster_sessions.txt
   debug/crdb_internal.cluster_settings.txt
   debug/crdb_internal.jobs.txt
   debug/crdb_internal.kv_node_status.txt
+  debug/crdb_internal.kv_protected_timestamps.txt
+  debug/crdb_internal.kv_statement_diagnostics.txt
   debug/crdb_internal.kv_store_status.txt
   debug/crdb_internal.schema_changes.txt
   debug/crdb_internal.partitions.txt
@@ -2381,6 +2384,7 @@ writing ` + os.DevNull + `
   debug/nodes/1/crdb_internal.leases.txt
   debug/nodes/1/crdb_internal.node_statement_statistics.txt
   debug/nodes/1/crdb_internal.node_build_info.txt
+  debug/nodes/1/crdb_internal.node_gc_progress.txt
   debug/nodes/1/crdb_internal.node_metrics.txt
   debug/nodes/1/crdb_internal.node_queries.txt
   debug/nodes/1/crdb_internal.node_runtime_info.txt
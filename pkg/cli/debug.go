@@ -543,6 +543,7 @@ func runDebugGCCmd(cmd *cobra.Command, args []string) error {
 			snap,
 			hlc.Timestamp{WallTime: timeutil.Now().UnixNano()},
 			config.GCPolicy{TTLSeconds: int32(gcTTLInSeconds)},
+			hlc.Timestamp{}, /* protected */
 			storage.NoopGCer{},
 			func(_ context.Context, _ []roachpb.Intent) error { return nil },
 			func(_ context.Context, _ *roachpb.Transaction, _ []roachpb.Intent) error { return nil },
@@ -1124,7 +1124,7 @@ func getClientGRPCConn(ctx context.Context) (*grpc.ClientConn, *hlc.Clock, func(
 		serverCfg.Config,
 		clock,
 		stopper,
-		&serverCfg.Settings.Version,
+		serverCfg.Settings,
 	)
 	addr, err := addrWithDefaultHost(serverCfg.AdvertiseAddr)
 	if err != nil {
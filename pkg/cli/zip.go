@@ -56,6 +56,7 @@ requires the cluster to be live.
 
 // Tables containing cluster-wide info that are collected in a debug zip.
 var debugZipTablesPerCluster = []string{
+	"crdb_internal.cluster_distsql_flows",
 	"crdb_internal.cluster_queries",
 	"crdb_internal.cluster_sessions",
 	"crdb_internal.cluster_settings",
@@ -63,6 +64,8 @@ var debugZipTablesPerCluster = []string{
 	"crdb_internal.jobs",
 
 	"crdb_internal.kv_node_status",
+	"crdb_internal.kv_protected_timestamps",
+	"crdb_internal.kv_statement_diagnostics",
 	"crdb_internal.kv_store_status",
 
 	"crdb_internal.schema_changes",
@@ -83,6 +86,7 @@ var debugZipTablesPerNode = []string{
 
 	"crdb_internal.node_statement_statistics",
 	"crdb_internal.node_build_info",
+	"crdb_internal.node_gc_progress",
 	"crdb_internal.node_metrics",
 	"crdb_internal.node_queries",
 	"crdb_internal.node_runtime_info",
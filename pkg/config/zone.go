@@ -672,6 +672,33 @@ func (z *ZoneConfig) DeleteIndexSubzones(indexID uint32) {
 	z.Subzones = subzones
 }
 
+// PlacementEqual returns whether the two zone configs place replicas and
+// leases the same way, that is whether they agree on the number of replicas,
+// the replica constraints and the lease preferences. Ranges whose placement
+// changes may have to be moved, for example from stores with fast disks to
+// ones with slow disks when the partition they hold is no longer hot.
+func (z *ZoneConfig) PlacementEqual(other *ZoneConfig) bool {
+	if (z.NumReplicas == nil) != (other.NumReplicas == nil) ||
+		(z.NumReplicas != nil && *z.NumReplicas != *other.NumReplicas) {
+		return false
+	}
+	if len(z.Constraints) != len(other.Constraints) ||
+		len(z.LeasePreferences) != len(other.LeasePreferences) {
+		return false
+	}
+	for i := range z.Constraints {
+		if !z.Constraints[i].Equal(&other.Constraints[i]) {
+			return false
+		}
+	}
+	for i := range z.LeasePreferences {
+		if !z.LeasePreferences[i].Equal(&other.LeasePreferences[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 func (z ZoneConfig) subzoneSplits() []roachpb.RKey {
 	var out []roachpb.RKey
 	for _, span := range z.SubzoneSpans {
@@ -386,6 +386,53 @@ func TestZoneConfigSubzones(t *testing.T) {
 
 // TestZoneConfigMarshalYAML makes sure that ZoneConfig is correctly marshaled
 // to YAML and back.
+func TestZoneConfigPlacementEqual(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	zone := DefaultZoneConfig()
+	other := DefaultZoneConfig()
+	if !zone.PlacementEqual(&other) {
+		t.Fatal("expected identical zone configs to place replicas equally")
+	}
+
+	// Changes which don't affect placement are ignored.
+	other.RangeMaxBytes = proto.Int64(*zone.RangeMaxBytes * 2)
+	other.GC.TTLSeconds++
+	if !zone.PlacementEqual(&other) {
+		t.Fatal("expected zone configs differing in size and GC to place replicas equally")
+	}
+
+	cold := []Constraints{{Constraints: []Constraint{{Type: Constraint_REQUIRED, Value: "cold"}}}}
+	hot := []Constraints{{Constraints: []Constraint{{Type: Constraint_REQUIRED, Value: "nvme"}}}}
+	other.Constraints = cold
+	if zone.PlacementEqual(&other) {
+		t.Fatal("expected added constraints to change placement")
+	}
+	zone.Constraints = hot
+	if zone.PlacementEqual(&other) {
+		t.Fatal("expected changed constraints to change placement")
+	}
+	zone.Constraints = cold
+	if !zone.PlacementEqual(&other) {
+		t.Fatal("expected equal constraints to place replicas equally")
+	}
+
+	other.LeasePreferences = []LeasePreference{{Constraints: hot[0].Constraints}}
+	if zone.PlacementEqual(&other) {
+		t.Fatal("expected added lease preferences to change placement")
+	}
+	zone.LeasePreferences = other.LeasePreferences
+
+	other.NumReplicas = proto.Int32(*zone.NumReplicas + 2)
+	if zone.PlacementEqual(&other) {
+		t.Fatal("expected changed replication factor to change placement")
+	}
+	other.NumReplicas = nil
+	if zone.PlacementEqual(&other) || other.PlacementEqual(&zone) {
+		t.Fatal("expected unset replication factor to change placement")
+	}
+}
+
 func TestZoneConfigMarshalYAML(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -113,6 +113,14 @@ type Config struct {
 	// If MaxMsgsPerBatch <= 0 then no limit is enforced.
 	MaxMsgsPerBatch int
 
+	// MaxKeysPerBatchReq is the maximum number of keys that each batch is
+	// allowed to touch during one of its requests. If the limit is exceeded,
+	// the batch is paginated over a series of individual requests. This limit
+	// corresponds to the MaxSpanRequestKeys assigned to the Header of each
+	// request, so clients must be prepared to handle the ResumeSpans of the
+	// responses. If MaxKeysPerBatchReq <= 0 then no limit is enforced.
+	MaxKeysPerBatchReq int
+
 	// MaxWait is the maximum amount of time a message should wait in a batch
 	// before being sent. If MaxWait is <= 0 then no wait timeout is enforced.
 	// It is inadvisable to disable both MaxIdle and MaxWait.
@@ -266,7 +274,7 @@ func (b *RequestBatcher) sendBatch(ctx context.Context, ba *batch) {
 	var br *roachpb.BatchResponse
 	send := func(ctx context.Context) error {
 		var pErr *roachpb.Error
-		if br, pErr = b.cfg.Sender.Send(ctx, ba.batchRequest()); pErr != nil {
+		if br, pErr = b.cfg.Sender.Send(ctx, ba.batchRequest(&b.cfg)); pErr != nil {
 			return pErr.GoError()
 		}
 		return nil
@@ -461,11 +469,14 @@ func (b *batch) rangeID() roachpb.RangeID {
 	return b.reqs[0].rangeID
 }
 
-func (b *batch) batchRequest() roachpb.BatchRequest {
+func (b *batch) batchRequest(cfg *Config) roachpb.BatchRequest {
 	req := roachpb.BatchRequest{
 		// Preallocate the Requests slice.
 		Requests: make([]roachpb.RequestUnion, 0, len(b.reqs)),
 	}
+	if cfg.MaxKeysPerBatchReq > 0 {
+		req.MaxSpanRequestKeys = int64(cfg.MaxKeysPerBatchReq)
+	}
 	for _, r := range b.reqs {
 		req.Add(r.req)
 	}
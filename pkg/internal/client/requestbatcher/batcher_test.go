@@ -237,6 +237,40 @@ func TestBatcherSend(t *testing.T) {
 	}
 }
 
+func TestMaxKeysPerBatchReq(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.Background())
+	sc := make(chanSender)
+	b := New(Config{
+		MaxIdle:            time.Millisecond,
+		MaxMsgsPerBatch:    2,
+		MaxKeysPerBatchReq: 10,
+		Sender:             sc,
+		Stopper:            stopper,
+	})
+	var g errgroup.Group
+	for i := 0; i < 2; i++ {
+		g.Go(func() error {
+			_, err := b.Send(context.Background(), 1, &roachpb.ResolveIntentRangeRequest{})
+			return err
+		})
+	}
+	s := <-sc
+	assert.Equal(t, int64(10), s.ba.MaxSpanRequestKeys)
+	s.respChan <- batchResp{}
+	// See TestBatcherSendOnSizeWithReset: the batch may have been sent due to
+	// time rather than size constraints.
+	if len(s.ba.Requests) == 1 {
+		s := <-sc
+		assert.Equal(t, int64(10), s.ba.MaxSpanRequestKeys)
+		s.respChan <- batchResp{}
+	}
+	if err := g.Wait(); err != nil {
+		t.Fatalf("expected no errors, got %v", err)
+	}
+}
+
 func TestSendAfterStopped(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	stopper := stop.NewStopper()
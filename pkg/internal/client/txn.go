@@ -768,6 +768,16 @@ func (txn *Txn) IsRetryableErrMeantForTxn(
 	return errTxnID == txn.mu.ID
 }
 
+type statementFingerprintKey struct{}
+
+// ContextWithStatementFingerprint returns a context which makes transactions
+// attach the given SQL statement fingerprint to the batches with writes they
+// send, so that large writes can be traced back to the statement which issued
+// them.
+func ContextWithStatementFingerprint(ctx context.Context, fingerprint string) context.Context {
+	return context.WithValue(ctx, statementFingerprintKey{}, fingerprint)
+}
+
 // Send runs the specified calls synchronously in a single batch and
 // returns any errors. If the transaction is read-only or has already
 // been successfully committed or aborted, a potential trailing
@@ -785,6 +795,9 @@ func (txn *Txn) Send(
 	if txn.gatewayNodeID != 0 {
 		ba.Header.GatewayNodeID = txn.gatewayNodeID
 	}
+	if fp, ok := ctx.Value(statementFingerprintKey{}).(string); ok && !ba.IsReadOnly() {
+		ba.Header.StatementFingerprint = fp
+	}
 
 	txn.mu.Lock()
 	requestTxnID := txn.mu.ID
@@ -228,6 +228,21 @@ func (r *Registry) StartJob(
 	return j, errCh, nil
 }
 
+// CreateAdoptableJobWithTxn creates a job from record in the given
+// transaction. Unlike StartJob, it doesn't run the job: once the transaction
+// commits, the job is adopted and run by the registry of one of the nodes.
+func (r *Registry) CreateAdoptableJobWithTxn(
+	ctx context.Context, record Record, txn *client.Txn,
+) (*Job, error) {
+	j := r.NewJob(record)
+	// An empty lease is considered expired, so the job is adopted by the first
+	// registry which looks for jobs to adopt.
+	if err := j.WithTxn(txn).insert(ctx, r.makeJobID(), &jobspb.Lease{}); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
 // NewJob creates a new Job.
 func (r *Registry) NewJob(record Record) *Job {
 	job := &Job{
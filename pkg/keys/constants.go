@@ -76,6 +76,9 @@ var (
 	// localStoreSuggestedCompactionSuffix stores suggested compactions to
 	// be aggregated and processed on the store.
 	localStoreSuggestedCompactionSuffix = []byte("comp")
+	// localStoreMigrationProgressSuffix stores the progress of the
+	// replica migrations run by the store, one key per migration.
+	localStoreMigrationProgressSuffix = []byte("migr")
 
 	// localRemovedLeakedRaftEntriesSuffix is DEPRECATED and remains to prevent reuse.
 	localRemovedLeakedRaftEntriesSuffix = []byte("dlre")
@@ -175,6 +178,22 @@ var (
 	// LocalQueueLastProcessedSuffix is the suffix for replica queue state keys.
 	LocalQueueLastProcessedSuffix = roachpb.RKey("qlpt")
 
+	// LocalRangeLockTablePrefix specifies the key prefix for the lock table.
+	// The lock table holds an entry for each replicated lock (i.e. each
+	// intent), which is written and removed alongside the intent itself. This
+	// lets readers discover the locks in a span without scanning the MVCC data
+	// in which the intents are interleaved. The prefix is followed by an infix
+	// identifying the kind of entry and by the locked key, encoded using
+	// EncodeBytes. Like the other range-local keys, lock table entries are
+	// addressed by the locked key.
+	//
+	// NOTE: LocalRangeLockTablePrefix must be kept in sync with the value in
+	// c-deps/libroach/keys.h.
+	LocalRangeLockTablePrefix = roachpb.Key(makeKey(localPrefix, roachpb.RKey("z")))
+	// LockTableSingleKeyInfix is the infix of lock table entries for locks on
+	// single keys.
+	LockTableSingleKeyInfix = []byte("k")
+
 	// Meta1Prefix is the first level of key addressing. It is selected such that
 	// all range addressing records sort before any system tables which they
 	// might describe. The value is a RangeDescriptor struct.
@@ -234,6 +253,29 @@ var (
 	// StoreIDGenerator is the global store ID generator sequence.
 	StoreIDGenerator = roachpb.Key(makeKey(SystemPrefix, roachpb.RKey("store-idgen")))
 
+	// ProtectedTimestampPrefix specifies the key prefix for the protected
+	// timestamp records.
+	ProtectedTimestampPrefix = roachpb.Key(makeKey(SystemPrefix, roachpb.RKey("protectedts-")))
+	// ProtectedTimestampKeyMax is the maximum value for any protected
+	// timestamp record key.
+	ProtectedTimestampKeyMax = ProtectedTimestampPrefix.PrefixEnd()
+
+	// StmtDiagnosticsPrefix specifies the key prefix for the statement
+	// diagnostics requests and the bundles collected for them.
+	StmtDiagnosticsPrefix = roachpb.Key(makeKey(SystemPrefix, roachpb.RKey("stmt-diag-")))
+	// StmtDiagnosticsKeyMax is the maximum value for any statement diagnostics
+	// key.
+	StmtDiagnosticsKeyMax = StmtDiagnosticsPrefix.PrefixEnd()
+	// StmtDiagnosticsRequestPrefix specifies the key prefix for the statement
+	// diagnostics requests.
+	StmtDiagnosticsRequestPrefix = roachpb.Key(makeKey(StmtDiagnosticsPrefix, roachpb.RKey("req-")))
+	// StmtDiagnosticsRequestKeyMax is the maximum value for any statement
+	// diagnostics request key.
+	StmtDiagnosticsRequestKeyMax = StmtDiagnosticsRequestPrefix.PrefixEnd()
+	// StmtDiagnosticsBundlePrefix specifies the key prefix for the bundles
+	// collected for the statement diagnostics requests.
+	StmtDiagnosticsBundlePrefix = roachpb.Key(makeKey(StmtDiagnosticsPrefix, roachpb.RKey("bundle-")))
+
 	// StatusPrefix specifies the key prefix to store all status details.
 	StatusPrefix = roachpb.Key(makeKey(SystemPrefix, roachpb.RKey("status-")))
 	// StatusNodePrefix stores all status info for nodes.
@@ -72,6 +72,7 @@ namespace cockroach {
 	genKey(keys.LocalRangeIDPrefix.AsRawKey(), "LocalRangeIDPrefix")
 	genKey(keys.LocalRangeIDReplicatedInfix, "LocalRangeIDReplicatedInfix")
 	genKey(keys.LocalRangeAppliedStateSuffix, "LocalRangeAppliedStateSuffix")
+	genKey(keys.LocalRangeLockTablePrefix, "LocalRangeLockTablePrefix")
 	genKey(keys.Meta2KeyMax, "Meta2KeyMax")
 	genKey(keys.MinKey, "MinKey")
 	genKey(keys.MaxKey, "MaxKey")
@@ -80,6 +80,12 @@ func StoreHLCUpperBoundKey() roachpb.Key {
 	return MakeStoreKey(localHLCUpperBoundSuffix, nil)
 }
 
+// StoreMigrationProgressKey returns a store-local key for the progress of the
+// replica migration with the given name.
+func StoreMigrationProgressKey(name string) roachpb.Key {
+	return MakeStoreKey(localStoreMigrationProgressSuffix, roachpb.RKey(name))
+}
+
 // StoreSuggestedCompactionKey returns a store-local key for a
 // suggested compaction. It combines the specified start and end keys.
 func StoreSuggestedCompactionKey(start, end roachpb.Key) roachpb.Key {
@@ -122,6 +128,30 @@ func NodeLivenessKey(nodeID roachpb.NodeID) roachpb.Key {
 	return key
 }
 
+// ProtectedTimestampKey returns the key for the protected timestamp record
+// with the given ID.
+func ProtectedTimestampKey(id uuid.UUID) roachpb.Key {
+	key := make(roachpb.Key, 0, len(ProtectedTimestampPrefix)+uuid.Size+3)
+	key = append(key, ProtectedTimestampPrefix...)
+	return encoding.EncodeBytesAscending(key, id.GetBytes())
+}
+
+// StmtDiagnosticsRequestKey returns the key for the statement diagnostics
+// request with the given ID.
+func StmtDiagnosticsRequestKey(id uuid.UUID) roachpb.Key {
+	key := make(roachpb.Key, 0, len(StmtDiagnosticsRequestPrefix)+uuid.Size+3)
+	key = append(key, StmtDiagnosticsRequestPrefix...)
+	return encoding.EncodeBytesAscending(key, id.GetBytes())
+}
+
+// StmtDiagnosticsBundleKey returns the key for the bundle collected for the
+// statement diagnostics request with the given ID.
+func StmtDiagnosticsBundleKey(id uuid.UUID) roachpb.Key {
+	key := make(roachpb.Key, 0, len(StmtDiagnosticsBundlePrefix)+uuid.Size+3)
+	key = append(key, StmtDiagnosticsBundlePrefix...)
+	return encoding.EncodeBytesAscending(key, id.GetBytes())
+}
+
 // NodeStatusKey returns the key for accessing the node status for the
 // specified node ID.
 func NodeStatusKey(nodeID roachpb.NodeID) roachpb.Key {
@@ -423,6 +453,36 @@ func QueueLastProcessedKey(key roachpb.RKey, queue string) roachpb.Key {
 	return MakeRangeKey(key, LocalQueueLastProcessedSuffix, roachpb.RKey(queue))
 }
 
+// LockTableSingleKey returns the key of the lock table entry for a lock on
+// the given key. The key may be a global or a range-local key.
+func LockTableSingleKey(key roachpb.Key) roachpb.Key {
+	buf := make(roachpb.Key, 0, len(LocalRangeLockTablePrefix)+len(LockTableSingleKeyInfix)+len(key)+3)
+	buf = append(buf, LocalRangeLockTablePrefix...)
+	buf = append(buf, LockTableSingleKeyInfix...)
+	return encoding.EncodeBytesAscending(buf, key)
+}
+
+// DecodeLockTableSingleKey decodes the locked key from a key returned by
+// LockTableSingleKey.
+func DecodeLockTableSingleKey(key roachpb.Key) (lockedKey roachpb.Key, _ error) {
+	if !bytes.HasPrefix(key, LocalRangeLockTablePrefix) {
+		return nil, errors.Errorf("key %q does not have %q prefix", key, LocalRangeLockTablePrefix)
+	}
+	b := key[len(LocalRangeLockTablePrefix):]
+	if !bytes.HasPrefix(b, LockTableSingleKeyInfix) {
+		return nil, errors.Errorf("key %q is not a single key lock table key", key)
+	}
+	b = b[len(LockTableSingleKeyInfix):]
+	b, lockedKey, err := encoding.DecodeBytesAscending(b, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != 0 {
+		return nil, errors.Errorf("key %q has %d trailing bytes", key, len(b))
+	}
+	return lockedKey, nil
+}
+
 // IsLocal performs a cheap check that returns true iff a range-local key is
 // passed, that is, a key for which `Addr` would return a non-identical RKey
 // (or a decoding error).
@@ -461,6 +521,16 @@ func Addr(k roachpb.Key) (roachpb.RKey, error) {
 	}
 
 	for {
+		if bytes.HasPrefix(k, LocalRangeLockTablePrefix) {
+			var err error
+			if k, err = DecodeLockTableSingleKey(k); err != nil {
+				return nil, err
+			}
+			if !IsLocal(k) {
+				break
+			}
+			continue
+		}
 		if bytes.HasPrefix(k, localStorePrefix) {
 			return nil, errors.Errorf("store-local key %q is not addressable", k)
 		}
@@ -125,6 +125,8 @@ func TestKeyAddress(t *testing.T) {
 		{TransactionKey(roachpb.Key("baz"), uuid.MakeV4()), roachpb.RKey("baz")},
 		{TransactionKey(roachpb.KeyMax, uuid.MakeV4()), roachpb.RKeyMax},
 		{RangeDescriptorKey(roachpb.RKey(TransactionKey(roachpb.Key("doubleBaz"), uuid.MakeV4()))), roachpb.RKey("doubleBaz")},
+		{LockTableSingleKey(roachpb.Key("foo")), roachpb.RKey("foo")},
+		{LockTableSingleKey(RangeDescriptorKey(roachpb.RKey("bar"))), roachpb.RKey("bar")},
 		{nil, nil},
 	}
 	for i, test := range testCases {
@@ -136,6 +138,34 @@ func TestKeyAddress(t *testing.T) {
 	}
 }
 
+func TestLockTableSingleKey(t *testing.T) {
+	for _, key := range []roachpb.Key{
+		roachpb.Key("a"),
+		roachpb.Key("a\x00b"),
+		RangeDescriptorKey(roachpb.RKey("c")),
+		roachpb.KeyMax,
+	} {
+		ltKey := LockTableSingleKey(key)
+		if !bytes.HasPrefix(ltKey, LocalRangeLockTablePrefix) {
+			t.Errorf("lock table key %q for %q lacks prefix", ltKey, key)
+		}
+		decoded, err := DecodeLockTableSingleKey(ltKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.Equal(key) {
+			t.Errorf("expected %q to decode to %q, got %q", ltKey, key, decoded)
+		}
+	}
+	// Lock table keys sort in the order of the keys they lock.
+	if a, b := LockTableSingleKey(roachpb.Key("a")), LockTableSingleKey(roachpb.Key("a\x00")); a.Compare(b) >= 0 {
+		t.Errorf("expected %q < %q", a, b)
+	}
+	if _, err := DecodeLockTableSingleKey(roachpb.Key("a")); !testutils.IsError(err, "does not have") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
 func TestKeyAddressError(t *testing.T) {
 	testCases := map[string][]roachpb.Key{
 		"store-local key .* is not addressable": {
@@ -69,6 +69,8 @@ var (
 				ppFunc: localRangeIDKeyPrint, psFunc: localRangeIDKeyParse},
 			{name: "/Range", prefix: LocalRangePrefix, ppFunc: localRangeKeyPrint,
 				psFunc: parseUnsupported},
+			{name: "/LockTable", prefix: LocalRangeLockTablePrefix, ppFunc: lockTableKeyPrint,
+				psFunc: parseUnsupported},
 		}},
 		{name: "/Meta1", start: Meta1Prefix, end: Meta1KeyMax, entries: []dictEntry{
 			{name: "", prefix: Meta1Prefix, ppFunc: print,
@@ -110,10 +112,18 @@ var (
 				ppFunc: decodeKeyPrint,
 				psFunc: parseUnsupported,
 			},
+			{name: "/ProtectedTimestamp", prefix: ProtectedTimestampPrefix,
+				ppFunc: decodeKeyPrint,
+				psFunc: parseUnsupported,
+			},
 			{name: "/StatusNode", prefix: StatusNodePrefix,
 				ppFunc: decodeKeyPrint,
 				psFunc: parseUnsupported,
 			},
+			{name: "/StmtDiagnostics", prefix: StmtDiagnosticsPrefix,
+				ppFunc: decodeKeyPrint,
+				psFunc: parseUnsupported,
+			},
 			{name: "/tsd", prefix: TimeseriesPrefix,
 				ppFunc: decodeTimeseriesKey,
 				psFunc: parseUnsupported,
@@ -180,6 +190,7 @@ var constSubKeyDict = []struct {
 	{"/gossipBootstrap", localStoreGossipSuffix},
 	{"/clusterVersion", localStoreClusterVersionSuffix},
 	{"/suggestedCompaction", localStoreSuggestedCompactionSuffix},
+	{"/migrationProgress", localStoreMigrationProgressSuffix},
 }
 
 func suggestedCompactionKeyPrint(key roachpb.Key) string {
@@ -198,6 +209,9 @@ func localStoreKeyPrint(_ []encoding.Direction, key roachpb.Key) string {
 					append(roachpb.Key(nil), append(localStorePrefix, key...)...),
 				)
 			}
+			if v.key.Equal(localStoreMigrationProgressSuffix) {
+				return v.name + "/" + string(key[len(v.key):])
+			}
 			return v.name
 		}
 	}
@@ -211,6 +225,10 @@ func localStoreKeyParse(input string) (remainder string, output roachpb.Key) {
 			if s.key.Equal(localStoreSuggestedCompactionSuffix) {
 				panic(&errUglifyUnsupported{errors.New("cannot parse suggested compaction key")})
 			}
+			if s.key.Equal(localStoreMigrationProgressSuffix) {
+				output = StoreMigrationProgressKey(mustShiftSlash(input[len(s.name):]))
+				return
+			}
 			output = MakeStoreKey(s.key, nil)
 			return
 		}
@@ -446,6 +464,18 @@ func localRangeKeyPrint(valDirs []encoding.Direction, key roachpb.Key) string {
 	return buf.String()
 }
 
+func lockTableKeyPrint(valDirs []encoding.Direction, key roachpb.Key) string {
+	if !bytes.HasPrefix(key, LockTableSingleKeyInfix) {
+		return fmt.Sprintf("/%q", []byte(key))
+	}
+	key = key[len(LockTableSingleKeyInfix):]
+	_, lockedKey, err := encoding.DecodeBytesAscending([]byte(key), nil)
+	if err != nil {
+		return fmt.Sprintf("/Intent/%q/err:%v", []byte(key), err)
+	}
+	return fmt.Sprintf("/Intent%s", roachpb.Key(lockedKey))
+}
+
 type errUglifyUnsupported struct {
 	wrapped error
 }
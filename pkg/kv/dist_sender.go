@@ -543,6 +543,12 @@ func (ds *DistSender) initAndVerifyBatch(
 		return roachpb.NewErrorf("empty batch")
 	}
 
+	if ba.TargetBytes != 0 && ba.MaxSpanRequestKeys == 0 {
+		// A byte target relies on the batch being sent to one range at a time,
+		// which is only the case for batches with a key limit.
+		return roachpb.NewErrorf("batch with byte target %d has no key limit", ba.TargetBytes)
+	}
+
 	if ba.MaxSpanRequestKeys != 0 {
 		// Verify that the batch contains only specific range requests or the
 		// Begin/EndTransactionRequest. Verify that a batch with a ReverseScan
@@ -1167,9 +1173,10 @@ func (ds *DistSender) divideAndSendBatchToRanges(
 			mightStopEarly := ba.MaxSpanRequestKeys > 0 || stopAtRangeBoundary
 			// Check whether we've received enough responses to exit query loop.
 			if mightStopEarly {
-				var replyResults int64
+				var replyResults, replyBytes int64
 				for _, r := range resp.reply.Responses {
 					replyResults += r.GetInner().Header().NumKeys
+					replyBytes += r.GetInner().Header().NumBytes
 				}
 				// Do accounting for results. It's important that we update
 				// MaxSpanRequestKeys and ScanOptions.MinResults, as ba might be
@@ -1188,6 +1195,15 @@ func (ds *DistSender) divideAndSendBatchToRanges(
 						return
 					}
 				}
+				if ba.TargetBytes > 0 {
+					ba.TargetBytes -= replyBytes
+					// Exiting; any missing responses will be filled in via defer().
+					if ba.TargetBytes <= 0 {
+						couldHaveSkippedResponses = true
+						resumeReason = roachpb.RESUME_BYTE_LIMIT
+						return
+					}
+				}
 				var minResultsSatisfied bool
 				if !stopAtRangeBoundary {
 					minResultsSatisfied = true
@@ -1573,6 +1589,9 @@ func (ds *DistSender) sendToReplicas(
 	maxSeenLeaseSequence := roachpb.LeaseSequence(-1)
 	inTransferRetry := retry.StartWithCtx(ctx, ds.rpcRetryOptions)
 	inTransferRetry.Next() // The first call to Next does not block.
+	// notLeaseHolderRetries counts the NotLeaseHolderErrors retried on, which
+	// are reported on a successful response.
+	var notLeaseHolderRetries int64
 
 	// This loop will retry operations that fail with errors that reflect
 	// per-replica state and may succeed on other replicas.
@@ -1626,6 +1645,7 @@ func (ds *DistSender) sendToReplicas(
 				if cachedLeaseHolder != curReplica && ba.RequiresLeaseHolder() {
 					ds.leaseHolderCache.Update(ctx, rangeID, curReplica.StoreID)
 				}
+				br.NotLeaseHolderRetries = notLeaseHolderRetries
 				return br, nil
 			case *roachpb.StoreNotFoundError, *roachpb.NodeUnavailableError:
 				// These errors are likely to be unique to the replica that reported
@@ -1640,6 +1660,7 @@ func (ds *DistSender) sendToReplicas(
 				// leaseholder cache.
 			case *roachpb.NotLeaseHolderError:
 				ds.metrics.NotLeaseHolderErrCount.Inc(1)
+				notLeaseHolderRetries++
 				if lh := tErr.LeaseHolder; lh != nil {
 					// Update the leaseholder cache. Naively this would also happen when the
 					// next RPC comes back, but we don't want to wait out the additional RPC
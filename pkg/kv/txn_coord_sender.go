@@ -23,6 +23,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/internal/client/requestbatcher"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
@@ -418,6 +419,12 @@ type TxnCoordSenderFactory struct {
 	stopper           *stop.Stopper
 	metrics           TxnMetrics
 
+	// heartbeatBatcher coalesces the heartbeats of the transactions created by
+	// the factory into shared batches. Nil if the factory has no stopper or
+	// doesn't send to a DistSender, whose range descriptor cache is used to
+	// group the heartbeats by range.
+	heartbeatBatcher *heartbeatBatcher
+
 	testingKnobs ClientTestingKnobs
 }
 
@@ -464,6 +471,18 @@ func NewTxnCoordSenderFactory(
 	if tcf.metrics == (TxnMetrics{}) {
 		tcf.metrics = MakeTxnMetrics(metric.TestSampleInterval)
 	}
+	if ds, ok := wrapped.(*DistSender); ok && tcf.stopper != nil {
+		tcf.heartbeatBatcher = &heartbeatBatcher{
+			RequestBatcher: requestbatcher.New(requestbatcher.Config{
+				Name:            "kv.TxnCoordSender: heartbeat batcher",
+				Sender:          wrapped,
+				Stopper:         tcf.stopper,
+				MaxMsgsPerBatch: heartbeatBatchMaxMsgs,
+				MaxWait:         tcf.heartbeatInterval / heartbeatBatchWaitFraction,
+			}),
+			rangeID: makeHeartbeatRangeIDFunc(ds.RangeDescriptorCache()),
+		}
+	}
 	return tcf
 }
 
@@ -497,6 +516,7 @@ func (tcf *TxnCoordSenderFactory) TransactionalSender(
 			tcf.st,
 			tcs.clock,
 			tcs.heartbeatInterval,
+			tcf.heartbeatBatcher,
 			&tcs.interceptorAlloc.txnLockGatekeeper,
 			&tcs.metrics,
 			tcs.stopper,
@@ -520,6 +540,7 @@ func (tcf *TxnCoordSenderFactory) TransactionalSender(
 	tcs.interceptorAlloc.txnSpanRefresher = txnSpanRefresher{
 		st:    tcf.st,
 		knobs: &tcf.testingKnobs,
+		ri:    ri,
 		// We can only allow refresh span retries on root transactions
 		// because those are the only places where we have all of the
 		// refresh spans. If this is a leaf, as in a distributed sql flow,
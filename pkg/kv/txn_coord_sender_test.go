@@ -305,6 +305,91 @@ func TestTxnCoordSenderCondenseIntentSpans(t *testing.T) {
 	}
 }
 
+// Test that a transaction's refresh spans are condensed along range
+// boundaries once they exceed the maximum size, instead of being dropped.
+func TestTxnCoordSenderCondenseRefreshSpans(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	a := roachpb.Key("a")
+	b := roachpb.Key("b")
+	c := roachpb.Key("c")
+	d := roachpb.Key("dddddd")
+	e := roachpb.Key("e")
+	g := roachpb.Key("g")
+	aToBClosed := roachpb.Span{Key: a, EndKey: b.Next()}
+	cToEClosed := roachpb.Span{Key: c, EndKey: e.Next()}
+	splits := []roachpb.Span{
+		{Key: roachpb.Key("a"), EndKey: roachpb.Key("c")},
+		{Key: roachpb.Key("c"), EndKey: roachpb.Key("f")},
+		{Key: roachpb.Key("f"), EndKey: roachpb.Key("j")},
+	}
+	descs := []roachpb.RangeDescriptor{testMetaRangeDescriptor}
+	for i, s := range splits {
+		descs = append(descs, roachpb.RangeDescriptor{
+			RangeID:          roachpb.RangeID(2 + i),
+			StartKey:         roachpb.RKey(s.Key),
+			EndKey:           roachpb.RKey(s.EndKey),
+			InternalReplicas: []roachpb.ReplicaDescriptor{{NodeID: 1, StoreID: 1}},
+		})
+	}
+	descDB := mockRangeDescriptorDBForDescs(descs...)
+	s := createTestDB(t)
+	st := s.Store.ClusterSettings()
+	MaxTxnRefreshSpansBytes.Override(&st.SV, 10) /* 10 bytes and it will condense */
+	defer s.Stop()
+
+	var sendFn simpleSendFn = func(
+		_ context.Context, _ SendOptions, _ ReplicaSlice, args roachpb.BatchRequest,
+	) (*roachpb.BatchResponse, error) {
+		resp := args.CreateReply()
+		resp.Txn = args.Txn
+		return resp, nil
+	}
+	ambient := log.AmbientContext{Tracer: tracing.NewTracer()}
+	ds := NewDistSender(
+		DistSenderConfig{
+			AmbientCtx: ambient,
+			Clock:      s.Clock,
+			RPCContext: s.Cfg.RPCContext,
+			TestingKnobs: ClientTestingKnobs{
+				TransportFactory: adaptSimpleTransport(sendFn),
+			},
+			RangeDescriptorDB: descDB,
+		},
+		s.Gossip,
+	)
+	tsf := NewTxnCoordSenderFactory(
+		TxnCoordSenderFactoryConfig{
+			AmbientCtx: ambient,
+			Settings:   st,
+			Clock:      s.Clock,
+			Stopper:    s.Stopper,
+		},
+		ds,
+	)
+	db := client.NewDB(ambient, tsf, s.Clock)
+	ctx := context.Background()
+
+	txn := client.NewTxn(ctx, db, 0 /* gatewayNodeID */, client.RootTxn)
+	for _, k := range []roachpb.Key{a, b, c, d, e, g} {
+		if _, err := txn.Get(ctx, k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tcs := txn.Sender().(*TxnCoordSender)
+	sr := &tcs.interceptorAlloc.txnSpanRefresher
+	if sr.refreshInvalid {
+		t.Fatal("expected refresh spans to be condensed, not invalidated")
+	}
+	// The spans in the range with the most bytes are condensed first.
+	expReads := []roachpb.Span{cToEClosed, aToBClosed, {Key: g}}
+	if a, e := sr.refreshReads.asSlice(), expReads; !reflect.DeepEqual(a, e) {
+		t.Errorf("expected refresh reads %+v; got %+v", e, a)
+	}
+	if a, e := sr.refreshSpansBytes(), int64(6); a != e {
+		t.Errorf("expected refresh spans size %d; got %d", e, a)
+	}
+}
+
 // Test that the theartbeat loop detects aborted transactions and stops.
 func TestTxnCoordSenderHeartbeat(t *testing.T) {
 	defer leaktest.AfterTest(t)()
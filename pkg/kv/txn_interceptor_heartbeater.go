@@ -19,7 +19,10 @@ import (
 	"sync"
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/internal/client/requestbatcher"
+	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
@@ -27,6 +30,55 @@ import (
 	opentracing "github.com/opentracing/opentracing-go"
 )
 
+// coalescedHeartbeatsEnabled controls whether the heartbeats of the
+// transactions coordinated by a node are sent together in shared batches
+// rather than each in its own batch. A long-running transaction otherwise
+// costs its range one Raft command per heartbeat interval; coalesced
+// heartbeats of the transactions anchored on the same range share one.
+var coalescedHeartbeatsEnabled = settings.RegisterBoolSetting(
+	"kv.transaction.coalesced_heartbeats.enabled",
+	"if enabled, transaction heartbeats are sent in batches shared between transactions",
+	true,
+)
+
+const (
+	// heartbeatBatchMaxMsgs is the maximum number of heartbeats sent in one
+	// coalesced batch.
+	heartbeatBatchMaxMsgs = 1024
+	// heartbeatBatchWaitFraction is the fraction of the heartbeat interval
+	// for which a heartbeat waits for others to join its batch.
+	heartbeatBatchWaitFraction = 10
+)
+
+// heartbeatBatcher coalesces the heartbeat requests of the transactions
+// coordinated by a TxnCoordSenderFactory into shared batches, one for each of
+// the ranges on which the transactions are anchored.
+type heartbeatBatcher struct {
+	*requestbatcher.RequestBatcher
+
+	// rangeID returns the ID of the range containing the given key, as far as
+	// it is known without a range lookup, or zero if it isn't. The ID is only
+	// used to group heartbeats, which are routed by their keys, so a stale
+	// answer is harmless.
+	rangeID func(roachpb.Key) roachpb.RangeID
+}
+
+// makeHeartbeatRangeIDFunc returns a function which looks up the ID of the
+// range containing a key in the given range descriptor cache.
+func makeHeartbeatRangeIDFunc(rdc *RangeDescriptorCache) func(roachpb.Key) roachpb.RangeID {
+	return func(key roachpb.Key) roachpb.RangeID {
+		rKey, err := keys.Addr(key)
+		if err != nil {
+			return 0
+		}
+		desc, err := rdc.GetCachedRangeDescriptor(rKey, false /* inverted */)
+		if err != nil || desc == nil {
+			return 0
+		}
+		return desc.RangeID
+	}
+}
+
 // txnHeartbeater is a txnInterceptor in charge of a transaction's heartbeat
 // loop. Transaction coordinators heartbeat their transaction record
 // periodically to indicate the liveness of their transaction. Other actors like
@@ -71,6 +123,11 @@ type txnHeartbeater struct {
 	// intents. Note that the async rollbacks that this interceptor sometimes
 	// sends got through `wrapped`, not directly through `gatekeeper`.
 	gatekeeper lockedSender
+	// batcher, if set, coalesces the heartbeat requests of all of the
+	// transactions coordinated by the TxnCoordSenderFactory into shared
+	// batches. Like the gatekeeper, it sends directly to the wrapped sender of
+	// the factory.
+	batcher *heartbeatBatcher
 
 	st                *cluster.Settings
 	clock             *hlc.Clock
@@ -125,6 +182,7 @@ func (h *txnHeartbeater) init(
 	st *cluster.Settings,
 	clock *hlc.Clock,
 	heartbeatInterval time.Duration,
+	batcher *heartbeatBatcher,
 	gatekeeper lockedSender,
 	metrics *TxnMetrics,
 	stopper *stop.Stopper,
@@ -141,6 +199,7 @@ func (h *txnHeartbeater) init(
 	h.mu.txn = txn
 	h.mu.needBeginTxn = true
 	h.gatekeeper = gatekeeper
+	h.batcher = batcher
 	h.asyncAbortCallbackLocked = asyncAbortCallbackLocked
 }
 
@@ -381,19 +440,8 @@ func (h *txnHeartbeater) heartbeat(ctx context.Context) bool {
 	if txn.Key == nil {
 		log.Fatalf(ctx, "attempting to heartbeat txn without anchor key: %v", txn)
 	}
-	ba := roachpb.BatchRequest{}
-	ba.Txn = txn
-	ba.Add(&roachpb.HeartbeatTxnRequest{
-		RequestHeader: roachpb.RequestHeader{
-			Key: txn.Key,
-		},
-		Now: h.clock.Now(),
-	})
-
-	// Send the heartbeat request directly through the gatekeeper interceptor.
-	// See comment on h.gatekeeper for a discussion of why.
 	log.VEvent(ctx, 2, "heartbeat")
-	br, pErr := h.gatekeeper.SendLocked(ctx, ba)
+	br, pErr := h.sendHeartbeatLocked(ctx, txn)
 
 	// If the txn is no longer pending, ignore the result of the heartbeat.
 	if h.mu.txn.Status != roachpb.PENDING {
@@ -463,6 +511,54 @@ func (h *txnHeartbeater) heartbeat(ctx context.Context) bool {
 	return true
 }
 
+// sendHeartbeatLocked sends a HeartbeatTxnRequest for the given transaction.
+// If possible, the request is coalesced with the heartbeats of other
+// transactions anchored on the same range.
+func (h *txnHeartbeater) sendHeartbeatLocked(
+	ctx context.Context, txn *roachpb.Transaction,
+) (*roachpb.BatchResponse, *roachpb.Error) {
+	if h.batcher != nil && coalescedHeartbeatsEnabled.Get(&h.st.SV) &&
+		h.st.Version.IsActive(cluster.VersionCoalescedTxnHeartbeats) {
+		if rangeID := h.batcher.rangeID(txn.Key); rangeID != 0 {
+			hb := &roachpb.HeartbeatTxnRequest{
+				RequestHeader: roachpb.RequestHeader{
+					Key: txn.Key,
+				},
+				Now: h.clock.Now(),
+				Txn: txn,
+			}
+			// Like the gatekeeper, release the lock while the request is in
+			// flight.
+			h.mu.Unlock()
+			resp, err := h.batcher.Send(ctx, rangeID, hb)
+			h.mu.Lock()
+			if err != nil {
+				// A coalesced heartbeat reports the fate of its transaction in
+				// its response, so the error of the batch isn't about this
+				// transaction in particular. Don't interpret it as such; like
+				// any other failed heartbeat, it is retried on the next tick.
+				return nil, roachpb.NewErrorf("coalesced heartbeat failed: %s", err)
+			}
+			br := &roachpb.BatchResponse{}
+			br.Add(resp)
+			return br, nil
+		}
+	}
+
+	ba := roachpb.BatchRequest{}
+	ba.Txn = txn
+	ba.Add(&roachpb.HeartbeatTxnRequest{
+		RequestHeader: roachpb.RequestHeader{
+			Key: txn.Key,
+		},
+		Now: h.clock.Now(),
+	})
+
+	// Send the heartbeat request directly through the gatekeeper interceptor.
+	// See comment on h.gatekeeper for a discussion of why.
+	return h.gatekeeper.SendLocked(ctx, ba)
+}
+
 // abortTxnAsyncLocked send an EndTransaction(commmit=false) asynchronously.
 // The asyncAbortCallbackLocked callback is also called.
 func (h *txnHeartbeater) abortTxnAsyncLocked(ctx context.Context) {
@@ -0,0 +1,200 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package kv
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/internal/client/requestbatcher"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTxnHeartbeaterCoalescedHeartbeats tests that the heartbeats of
+// transactions are coalesced into shared batches by the range of their anchor
+// key, that a transaction found to be aborted by its heartbeat doesn't affect
+// the other transactions in its batch, and that a failed batch isn't followed
+// by a heartbeat of each of its transactions.
+func TestTxnHeartbeaterCoalescedHeartbeats(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+
+	// Transactions anchored on keys starting with "a" are on range 1 and those
+	// starting with "b" on range 2. The range of "z" isn't known.
+	anchors := []string{"a1", "a2", "b1", "b2", "z"}
+	rangeIDs := map[byte]roachpb.RangeID{'a': 1, 'b': 2}
+	const abortedIdx = 2
+
+	var txns []roachpb.Transaction
+	for _, anchor := range anchors {
+		txns = append(txns, roachpb.MakeTransaction(
+			anchor, roachpb.Key(anchor), 0, hlc.Timestamp{WallTime: 10}, 0))
+	}
+
+	var mu syncutil.Mutex
+	var batchesSent int
+	failBatches := false
+	sender := client.SenderFunc(func(
+		_ context.Context, ba roachpb.BatchRequest,
+	) (*roachpb.BatchResponse, *roachpb.Error) {
+		mu.Lock()
+		defer mu.Unlock()
+		batchesSent++
+		require.Nil(t, ba.Txn)
+		var rangeID roachpb.RangeID
+		for _, ru := range ba.Requests {
+			hb := ru.GetInner().(*roachpb.HeartbeatTxnRequest)
+			require.NotNil(t, hb.Txn)
+			require.Equal(t, hb.Txn.Key, hb.Key)
+			if rangeID == 0 {
+				rangeID = rangeIDs[hb.Key[0]]
+			}
+			require.Equal(t, rangeID, rangeIDs[hb.Key[0]])
+		}
+		if failBatches {
+			return nil, roachpb.NewErrorf("injected error")
+		}
+		br := ba.CreateReply()
+		for i, ru := range ba.Requests {
+			hb := ru.GetInner().(*roachpb.HeartbeatTxnRequest)
+			txn := hb.Txn.Clone()
+			if txn.ID == txns[abortedIdx].ID {
+				txn.Status = roachpb.ABORTED
+			} else {
+				txn.LastHeartbeat.Forward(hb.Now)
+			}
+			br.Responses[i].GetInner().(*roachpb.HeartbeatTxnResponse).Txn = txn
+		}
+		return br, nil
+	})
+	batcher := &heartbeatBatcher{
+		RequestBatcher: requestbatcher.New(requestbatcher.Config{
+			Name:            "test heartbeat batcher",
+			Sender:          sender,
+			Stopper:         stopper,
+			MaxMsgsPerBatch: 2,
+			MaxIdle:         10 * time.Millisecond,
+		}),
+		rangeID: func(key roachpb.Key) roachpb.RangeID {
+			return rangeIDs[key[0]]
+		},
+	}
+
+	// Heartbeats of transactions whose range isn't known are sent on their own.
+	var uncoalesced int
+	gatekeeper := &mockLockedSender{}
+	gatekeeper.MockSend(func(ba roachpb.BatchRequest) (*roachpb.BatchResponse, *roachpb.Error) {
+		uncoalesced++
+		require.Len(t, ba.Requests, 1)
+		require.Equal(t, txns[len(txns)-1].ID, ba.Txn.ID)
+		br := ba.CreateReply()
+		br.Responses[0].GetInner().(*roachpb.HeartbeatTxnResponse).Txn = ba.Txn.Clone()
+		return br, nil
+	})
+	// The aborted transaction is rolled back.
+	var rollbacks int
+	wrapped := &mockLockedSender{}
+	wrapped.MockSend(func(ba roachpb.BatchRequest) (*roachpb.BatchResponse, *roachpb.Error) {
+		rollbacks++
+		require.Equal(t, txns[abortedIdx].ID, ba.Txn.ID)
+		_, ok := ba.GetArg(roachpb.EndTransaction)
+		require.True(t, ok)
+		return ba.CreateReply(), nil
+	})
+
+	st := cluster.MakeTestingClusterSettings()
+	clock := hlc.NewClock(hlc.UnixNano, time.Nanosecond)
+	metrics := MakeTxnMetrics(metric.TestSampleInterval)
+	var abortCallbacks int
+	heartbeaters := make([]txnHeartbeater, len(txns))
+	for i := range heartbeaters {
+		h := &heartbeaters[i]
+		h.init(
+			log.AmbientContext{}, &syncutil.Mutex{}, &txns[i], st, clock, time.Second,
+			batcher, gatekeeper, &metrics, stopper,
+			func(context.Context) { abortCallbacks++ },
+		)
+		h.setWrapped(wrapped)
+	}
+	heartbeatAll := func() []bool {
+		res := make([]bool, len(heartbeaters))
+		var wg sync.WaitGroup
+		for i := range heartbeaters {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				res[i] = heartbeaters[i].heartbeat(ctx)
+			}(i)
+		}
+		wg.Wait()
+		return res
+	}
+
+	// Only the aborted transaction stops heartbeating.
+	require.Equal(t, []bool{true, true, false, true, true}, heartbeatAll())
+	for i := range heartbeaters {
+		h := &heartbeaters[i]
+		h.mu.Lock()
+		if i == abortedIdx {
+			require.Equal(t, roachpb.ABORTED, h.mu.txn.Status)
+		} else {
+			require.Equal(t, roachpb.PENDING, h.mu.txn.Status)
+			require.NotEqual(t, hlc.Timestamp{}, h.mu.txn.LastHeartbeat)
+		}
+		h.mu.Unlock()
+	}
+	require.Equal(t, 1, uncoalesced)
+	require.Equal(t, 1, abortCallbacks)
+	testutils.SucceedsSoon(t, func() error {
+		heartbeaters[abortedIdx].mu.Lock()
+		defer heartbeaters[abortedIdx].mu.Unlock()
+		if rollbacks != 1 {
+			return errors.Errorf("expected 1 rollback, got %d", rollbacks)
+		}
+		return nil
+	})
+
+	// When the batches fail, the transactions keep heartbeating and their
+	// heartbeats aren't sent again on their own.
+	mu.Lock()
+	failBatches = true
+	batchesSent = 0
+	mu.Unlock()
+	require.Equal(t, []bool{true, true, false, true, true}, heartbeatAll())
+	require.Equal(t, 2, uncoalesced)
+	mu.Lock()
+	require.True(t, batchesSent > 0)
+	mu.Unlock()
+	for i := range heartbeaters {
+		if i != abortedIdx {
+			require.Equal(t, roachpb.PENDING, heartbeaters[i].mu.txn.Status)
+		}
+	}
+}
@@ -36,7 +36,10 @@ const (
 
 // MaxTxnRefreshSpansBytes is a threshold in bytes for refresh spans stored
 // on the coordinator during the lifetime of a transaction. Refresh spans
-// are used for SERIALIZABLE transactions to avoid client restarts.
+// are used for SERIALIZABLE transactions to avoid client restarts. When the
+// threshold is exceeded, the spans are condensed along range boundaries, so
+// that they're refreshed with one ranged operation per range. If that's not
+// enough, the spans are dropped and the transaction can no longer refresh.
 var MaxTxnRefreshSpansBytes = settings.RegisterIntSetting(
 	"kv.transaction.max_refresh_spans_bytes",
 	"maximum number of bytes used to track refresh spans in serializable transactions",
@@ -49,19 +52,22 @@ var MaxTxnRefreshSpansBytes = settings.RegisterIntSetting(
 // the transaction if all the spans can be updated to the current
 // transaction timestamp.
 type txnSpanRefresher struct {
-	st      *cluster.Settings
-	knobs   *ClientTestingKnobs
+	st    *cluster.Settings
+	knobs *ClientTestingKnobs
+	// Optional; used to condense refresh spans, if provided. If not provided,
+	// a transaction's refresh spans are dropped once they exceed the maximum
+	// size, instead of being condensed.
+	ri      *RangeIterator
 	wrapped lockedSender
 
-	// See TxnCoordMeta.RefreshReads and TxnCoordMeta.RefreshWrites.
-	refreshReads  []roachpb.Span
-	refreshWrites []roachpb.Span
+	// See TxnCoordMeta.RefreshReads and TxnCoordMeta.RefreshWrites. The sets
+	// also keep track of the total size in bytes of the spans encountered
+	// during this transaction that need to be refreshed to avoid serializable
+	// restart.
+	refreshReads  condensableSpanSet
+	refreshWrites condensableSpanSet
 	// See TxnCoordMeta.RefreshInvalid.
 	refreshInvalid bool
-	// refreshSpansBytes is the total size in bytes of the spans
-	// encountered during this transaction that need to be refreshed
-	// to avoid serializable restart.
-	refreshSpansBytes int64
 	// refreshedTimestamp keeps track of the largest timestamp that a
 	// transaction was able to refresh all of its refreshable spans at.
 	// It is updated under lock and used to ensure that concurrent requests
@@ -82,7 +88,7 @@ func (sr *txnSpanRefresher) SendLocked(
 ) (*roachpb.BatchResponse, *roachpb.Error) {
 	if rArgs, hasET := ba.GetArg(roachpb.EndTransaction); hasET {
 		et := rArgs.(*roachpb.EndTransactionRequest)
-		if !sr.refreshInvalid && len(sr.refreshReads) == 0 && len(sr.refreshWrites) == 0 {
+		if !sr.refreshInvalid && sr.refreshReads.empty() && sr.refreshWrites.empty() {
 			et.NoRefreshSpans = true
 		}
 	}
@@ -124,16 +130,43 @@ func (sr *txnSpanRefresher) SendLocked(
 	}
 	// Verify and enforce the size in bytes of all read-only spans
 	// doesn't exceed the max threshold.
-	if sr.refreshSpansBytes > MaxTxnRefreshSpansBytes.Get(&sr.st.SV) {
-		log.VEventf(ctx, 2, "refresh spans max size exceeded; clearing")
-		sr.refreshReads = nil
-		sr.refreshWrites = nil
-		sr.refreshInvalid = true
-		sr.refreshSpansBytes = 0
-	}
+	sr.maybeCondenseRefreshSpans(ctx)
 	return br, nil
 }
 
+// refreshSpansBytes returns the total size in bytes of the refresh spans.
+func (sr *txnSpanRefresher) refreshSpansBytes() int64 {
+	return sr.refreshReads.bytes + sr.refreshWrites.bytes
+}
+
+// maybeCondenseRefreshSpans enforces the maximum size of the refresh spans.
+// The spans are first condensed along range boundaries, which lets the
+// transaction refresh its reads with one RefreshRange request per range
+// instead of one request per key, at the cost of refreshing keys that were
+// not read. If that's not enough, the refresh spans are dropped and the
+// transaction can no longer be refreshed.
+func (sr *txnSpanRefresher) maybeCondenseRefreshSpans(ctx context.Context) {
+	maxBytes := MaxTxnRefreshSpansBytes.Get(&sr.st.SV)
+	if sr.refreshSpansBytes() <= maxBytes {
+		return
+	}
+	// Reads usually make up the bulk of the refresh spans, so they're condensed
+	// first.
+	sr.refreshReads.maybeCondense(ctx, sr.ri, maxBytes-sr.refreshWrites.bytes)
+	if sr.refreshSpansBytes() > maxBytes {
+		sr.refreshWrites.maybeCondense(ctx, sr.ri, maxBytes-sr.refreshReads.bytes)
+	}
+	if sr.refreshSpansBytes() <= maxBytes {
+		log.VEventf(ctx, 2, "refresh spans max size exceeded; condensed to %d bytes",
+			sr.refreshSpansBytes())
+		return
+	}
+	log.VEventf(ctx, 2, "refresh spans max size exceeded; clearing")
+	sr.refreshReads = condensableSpanSet{}
+	sr.refreshWrites = condensableSpanSet{}
+	sr.refreshInvalid = true
+}
+
 // sendLockedWithRefreshAttempts sends the batch through the wrapped sender. It
 // catches serializable errors and attempts to avoid them by refreshing the txn
 // at a larger timestamp. It returns the response, an error, and the largest
@@ -245,7 +278,7 @@ func (sr *txnSpanRefresher) tryUpdatingTxnSpans(
 	if sr.refreshInvalid {
 		log.VEvent(ctx, 2, "can't refresh txn spans; not valid")
 		return false
-	} else if len(sr.refreshReads) == 0 && len(sr.refreshWrites) == 0 {
+	} else if sr.refreshReads.empty() && sr.refreshWrites.empty() {
 		log.VEvent(ctx, 2, "there are no txn spans to refresh")
 		return true
 	}
@@ -272,8 +305,8 @@ func (sr *txnSpanRefresher) tryUpdatingTxnSpans(
 				req.Header().Span(), refreshTxn.OrigTimestamp, refreshTxn.Timestamp)
 		}
 	}
-	addRefreshes(sr.refreshReads, false)
-	addRefreshes(sr.refreshWrites, true)
+	addRefreshes(sr.refreshReads.asSlice(), false)
+	addRefreshes(sr.refreshWrites.asSlice(), true)
 
 	// Send through wrapped lockedSender. Unlocks while sending then re-locks.
 	if _, batchErr := sr.wrapped.SendLocked(ctx, refreshSpanBa); batchErr != nil {
@@ -314,11 +347,10 @@ func (sr *txnSpanRefresher) appendRefreshSpans(
 			log.Infof(ctx, "refresh: %s write=%t", span, write)
 		}
 		if write {
-			sr.refreshWrites = append(sr.refreshWrites, span)
+			sr.refreshWrites.insert(span)
 		} else {
-			sr.refreshReads = append(sr.refreshReads, span)
+			sr.refreshReads.insert(span)
 		}
-		sr.refreshSpansBytes += int64(len(span.Key) + len(span.EndKey))
 		return true
 	}) {
 		log.VEventf(ctx, 2, "txn orig timestamp %s < sender refreshed timestamp %s",
@@ -336,8 +368,8 @@ func (sr *txnSpanRefresher) populateMetaLocked(meta *roachpb.TxnCoordMeta) {
 	meta.RefreshInvalid = sr.refreshInvalid
 	if !sr.refreshInvalid {
 		// Copy mutable state so access is safe for the caller.
-		meta.RefreshReads = append([]roachpb.Span(nil), sr.refreshReads...)
-		meta.RefreshWrites = append([]roachpb.Span(nil), sr.refreshWrites...)
+		meta.RefreshReads = append([]roachpb.Span(nil), sr.refreshReads.asSlice()...)
+		meta.RefreshWrites = append([]roachpb.Span(nil), sr.refreshWrites.asSlice()...)
 	}
 }
 
@@ -346,32 +378,37 @@ func (sr *txnSpanRefresher) augmentMetaLocked(meta roachpb.TxnCoordMeta) {
 	// Do not modify existing span slices when copying.
 	if meta.RefreshInvalid {
 		sr.refreshInvalid = true
-		sr.refreshReads = nil
-		sr.refreshWrites = nil
+		sr.refreshReads = condensableSpanSet{}
+		sr.refreshWrites = condensableSpanSet{}
 	} else if !sr.refreshInvalid {
-		sr.refreshReads, _ = roachpb.MergeSpans(
-			append(append([]roachpb.Span(nil), sr.refreshReads...), meta.RefreshReads...),
-		)
-		sr.refreshWrites, _ = roachpb.MergeSpans(
-			append(append([]roachpb.Span(nil), sr.refreshWrites...), meta.RefreshWrites...),
-		)
-	}
-	// Recompute the size of the refreshes.
-	sr.refreshSpansBytes = 0
-	for _, u := range sr.refreshReads {
-		sr.refreshSpansBytes += int64(len(u.Key) + len(u.EndKey))
-	}
-	for _, u := range sr.refreshWrites {
-		sr.refreshSpansBytes += int64(len(u.Key) + len(u.EndKey))
+		// Copy the existing spans, which may have been handed out by
+		// populateMetaLocked, before merging them with the new ones.
+		reads := sr.refreshReads.asSlice()
+		writes := sr.refreshWrites.asSlice()
+		sr.refreshReads = condensableSpanSet{}
+		sr.refreshWrites = condensableSpanSet{}
+		for _, u := range reads {
+			sr.refreshReads.insert(u)
+		}
+		for _, u := range meta.RefreshReads {
+			sr.refreshReads.insert(u)
+		}
+		for _, u := range writes {
+			sr.refreshWrites.insert(u)
+		}
+		for _, u := range meta.RefreshWrites {
+			sr.refreshWrites.insert(u)
+		}
+		sr.refreshReads.mergeAndSort()
+		sr.refreshWrites.mergeAndSort()
 	}
 }
 
 // epochBumpedLocked implements the txnInterceptor interface.
 func (sr *txnSpanRefresher) epochBumpedLocked() {
-	sr.refreshReads = nil
-	sr.refreshWrites = nil
+	sr.refreshReads = condensableSpanSet{}
+	sr.refreshWrites = condensableSpanSet{}
 	sr.refreshInvalid = false
-	sr.refreshSpansBytes = 0
 }
 
 // closeLocked implements the txnInterceptor interface.
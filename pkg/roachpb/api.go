@@ -235,6 +235,7 @@ func (rh *ResponseHeader) combine(otherRH ResponseHeader) error {
 	rh.ResumeSpan = otherRH.ResumeSpan
 	rh.ResumeReason = otherRH.ResumeReason
 	rh.NumKeys += otherRH.NumKeys
+	rh.NumBytes += otherRH.NumBytes
 	rh.RangeInfos = append(rh.RangeInfos, otherRH.RangeInfos...)
 	return nil
 }
@@ -382,6 +383,8 @@ func (h *BatchResponse_Header) combine(o BatchResponse_Header) error {
 	}
 	h.Now.Forward(o.Now)
 	h.CollectedSpans = append(h.CollectedSpans, o.CollectedSpans...)
+	h.NotLeaseHolderRetries += o.NotLeaseHolderRetries
+	h.FollowerReads += o.FollowerReads
 	return nil
 }
 
@@ -1042,7 +1045,6 @@ func (*AdminMergeRequest) flags() int          { return isAdmin | isAlone }
 func (*AdminTransferLeaseRequest) flags() int  { return isAdmin | isAlone }
 func (*AdminChangeReplicasRequest) flags() int { return isAdmin | isAlone }
 func (*AdminRelocateRangeRequest) flags() int  { return isAdmin | isAlone }
-func (*HeartbeatTxnRequest) flags() int        { return isWrite | isTxn }
 func (*GCRequest) flags() int                  { return isWrite | isRange }
 
 // PushTxnRequest updates the read timestamp cache when pushing a transaction's
@@ -1109,6 +1111,15 @@ func (r *RefreshRangeRequest) flags() int {
 	return isRead | isTxn | isRange | updatesReadTSCache
 }
 
+// HeartbeatTxnRequest is only part of a transaction if it heartbeats the
+// transaction of its batch.
+func (r *HeartbeatTxnRequest) flags() int {
+	if r.Txn != nil {
+		return isWrite
+	}
+	return isWrite | isTxn
+}
+
 func (*SubsumeRequest) flags() int    { return isRead | isAlone | updatesReadTSCache }
 func (*RangeStatsRequest) flags() int { return isRead }
 
@@ -1475,6 +1475,9 @@ func (l *Lease) Equal(that interface{}) bool {
 	if l.Sequence != that1.Sequence {
 		return false
 	}
+	if l.StoreLiveness != that1.StoreLiveness {
+		return false
+	}
 	return true
 }
 
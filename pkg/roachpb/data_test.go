@@ -826,6 +826,8 @@ func TestLeaseEquivalence(t *testing.T) {
 	stasis1 := Lease{Replica: r1, Start: ts1, Epoch: 1, DeprecatedStartStasis: ts1.Clone()}
 	stasis2 := Lease{Replica: r1, Start: ts1, Epoch: 1, DeprecatedStartStasis: ts2.Clone()}
 
+	liveness1 := Lease{Replica: r1, Start: ts1, Epoch: 1, StoreLiveness: true}
+
 	testCases := []struct {
 		l, ol      Lease
 		expSuccess bool
@@ -843,6 +845,7 @@ func TestLeaseEquivalence(t *testing.T) {
 		{proposed1, proposed2, false}, // same proposed timestamps, but diff epochs
 		{proposed1, proposed3, true},  // different proposed timestamps, same lease
 		{stasis1, stasis2, true},      // same lease, different stasis timestamps
+		{epoch1, liveness1, false},    // same epoch lease, but relying on store liveness
 	}
 
 	for i, tc := range testCases {
@@ -883,6 +886,7 @@ func TestLeaseEqual(t *testing.T) {
 		ProposedTS            *hlc.Timestamp
 		Epoch                 int64
 		Sequence              LeaseSequence
+		StoreLiveness         bool
 		XXX_NoUnkeyedLiteral  struct{}
 		XXX_sizecache         int32
 	}
@@ -933,6 +937,7 @@ func TestLeaseEqual(t *testing.T) {
 		{ProposedTS: &ts},
 		{Epoch: 1},
 		{Sequence: 1},
+		{StoreLiveness: true},
 	}
 	for _, c := range testCases {
 		t.Run("", func(t *testing.T) {
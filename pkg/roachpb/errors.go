@@ -21,6 +21,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/util/caller"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 )
 
@@ -771,3 +772,14 @@ func (e *IndeterminateCommitError) message(pErr *Error) string {
 }
 
 var _ ErrorDetailInterface = &IndeterminateCommitError{}
+
+func (e *DiskFullError) Error() string {
+	return e.message(nil)
+}
+
+func (e *DiskFullError) message(_ *Error) string {
+	return fmt.Sprintf("s%d is nearly out of disk space and rejects %s: %s of %s available",
+		e.StoreID, e.Op, humanizeutil.IBytes(e.Available), humanizeutil.IBytes(e.Capacity))
+}
+
+var _ ErrorDetailInterface = &DiskFullError{}
@@ -261,9 +261,20 @@ func (r ReplicaDescriptor) String() string {
 	} else {
 		fmt.Fprintf(&buf, "%d", r.ReplicaID)
 	}
+	if typ := r.GetType(); typ != VOTER {
+		buf.WriteString(typ.String())
+	}
 	return buf.String()
 }
 
+// GetType returns the type of the replica. Replicas without a type are voters.
+func (r ReplicaDescriptor) GetType() ReplicaType {
+	if r.Type == nil {
+		return VOTER
+	}
+	return *r.Type
+}
+
 // Validate performs some basic validation of the contents of a replica descriptor.
 func (r ReplicaDescriptor) Validate() error {
 	if r.NodeID == 0 {
@@ -41,15 +41,40 @@ func (d ReplicaDescriptors) All() []ReplicaDescriptor {
 
 // Voters returns the voter replicas in the set.
 func (d ReplicaDescriptors) Voters() []ReplicaDescriptor {
-	return d.wrapped
+	return d.filter(VOTER)
 }
 
 // Learners returns the learner replicas in the set.
 func (d ReplicaDescriptors) Learners() []ReplicaDescriptor {
-	return d.wrapped
+	return d.filter(LEARNER)
+}
+
+// filter returns the replicas of the given type. Learners only exist while a
+// replica is being added, so the common case of a set holding replicas of a
+// single type doesn't allocate.
+func (d ReplicaDescriptors) filter(typ ReplicaType) []ReplicaDescriptor {
+	n := 0
+	for i := range d.wrapped {
+		if d.wrapped[i].GetType() == typ {
+			n++
+		}
+	}
+	if n == len(d.wrapped) {
+		return d.wrapped
+	}
+	if n == 0 {
+		return nil
+	}
+	filtered := make([]ReplicaDescriptor, 0, n)
+	for i := range d.wrapped {
+		if d.wrapped[i].GetType() == typ {
+			filtered = append(filtered, d.wrapped[i])
+		}
+	}
+	return filtered
 }
 
-var _, _ = ReplicaDescriptors.All, ReplicaDescriptors.Learners
+var _ = ReplicaDescriptors.All
 
 // AsProto returns the protobuf representation of these replicas, suitable for
 // setting the InternalReplicas field of a RangeDescriptor. When possible the
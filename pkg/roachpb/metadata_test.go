@@ -92,6 +92,42 @@ func TestRangeDescriptorMissingReplica(t *testing.T) {
 	}
 }
 
+func TestReplicaDescriptorsByType(t *testing.T) {
+	voter1 := ReplicaDescriptor{NodeID: 1, StoreID: 1, ReplicaID: 1}
+	voter2 := ReplicaDescriptor{NodeID: 2, StoreID: 2, ReplicaID: 2, Type: VOTER.Enum()}
+	learner := ReplicaDescriptor{NodeID: 3, StoreID: 3, ReplicaID: 3, Type: LEARNER.Enum()}
+
+	if typ := voter1.GetType(); typ != VOTER {
+		t.Errorf("expected a replica without a type to be a %s, got %s", VOTER, typ)
+	}
+	if s := voter1.String(); strings.Contains(s, "VOTER") {
+		t.Errorf("expected the type of voters to be omitted, got %s", s)
+	}
+	if s := learner.String(); !strings.Contains(s, "LEARNER") {
+		t.Errorf("expected the type of learners to be included, got %s", s)
+	}
+
+	testCases := []struct {
+		replicas []ReplicaDescriptor
+		voters   []ReplicaDescriptor
+		learners []ReplicaDescriptor
+	}{
+		{nil, nil, nil},
+		{[]ReplicaDescriptor{voter1, voter2}, []ReplicaDescriptor{voter1, voter2}, nil},
+		{[]ReplicaDescriptor{learner}, nil, []ReplicaDescriptor{learner}},
+		{[]ReplicaDescriptor{voter1, learner, voter2}, []ReplicaDescriptor{voter1, voter2}, []ReplicaDescriptor{learner}},
+	}
+	for i, tc := range testCases {
+		r := MakeReplicaDescriptors(tc.replicas)
+		if voters := r.Voters(); !reflect.DeepEqual(voters, tc.voters) {
+			t.Errorf("%d: expected voters %v, got %v", i, tc.voters, voters)
+		}
+		if learners := r.Learners(); !reflect.DeepEqual(learners, tc.learners) {
+			t.Errorf("%d: expected learners %v, got %v", i, tc.learners, learners)
+		}
+	}
+}
+
 // TestLocalityConversions verifies that setting the value from the CLI short
 // hand format works correctly.
 func TestLocalityConversions(t *testing.T) {
@@ -0,0 +1,154 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package rpc
+
+import (
+	"math"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// ConnectionClass identifies a set of connections to a remote node which are
+// dialed with the same options. The connections of a class are not shared
+// with the other classes, so that the streams of a class don't compete with
+// the others for the flow control windows of a connection.
+type ConnectionClass int8
+
+const (
+	// DefaultClass is the class of the connections used by most RPCs.
+	DefaultClass ConnectionClass = iota
+	// DistSQLFlowClass is the class of the connections carrying the streams
+	// between DistSQL flows. Their window sizes and keepalives are configured
+	// by the rpc.distsql_flow.* cluster settings, and their streams can be
+	// spread over a pool of connections to each node.
+	DistSQLFlowClass
+)
+
+// maxDistSQLFlowConnsPerNode bounds the number of DistSQL flow connections to
+// each remote node.
+const maxDistSQLFlowConnsPerNode = 8
+
+func validateWindowSize(v int64) error {
+	if v < defaultWindowSize || v > math.MaxInt32 {
+		return errors.Errorf("window size must be between %d and %d bytes", defaultWindowSize, math.MaxInt32)
+	}
+	return nil
+}
+
+var distSQLFlowInitialWindowSize = settings.RegisterValidatedByteSizeSetting(
+	"rpc.distsql_flow.initial_window_size",
+	"the initial flow control window of each stream on the DistSQL flow connections dialed by a node",
+	initialWindowSize,
+	validateWindowSize,
+)
+
+var distSQLFlowInitialConnWindowSize = settings.RegisterValidatedByteSizeSetting(
+	"rpc.distsql_flow.initial_conn_window_size",
+	"the initial flow control window of the DistSQL flow connections dialed by a node",
+	initialConnWindowSize,
+	validateWindowSize,
+)
+
+var distSQLFlowKeepaliveInterval = settings.RegisterValidatedDurationSetting(
+	"rpc.distsql_flow.keepalive_interval",
+	"the interval between the keepalive pings sent on the DistSQL flow connections",
+	base.NetworkTimeout,
+	func(v time.Duration) error {
+		if v < time.Second {
+			return errors.Errorf("keepalive interval must be at least 1s: %s", v)
+		}
+		return nil
+	},
+)
+
+var distSQLFlowKeepaliveTimeout = settings.RegisterValidatedDurationSetting(
+	"rpc.distsql_flow.keepalive_timeout",
+	"the duration after which a DistSQL flow connection is closed if a keepalive ping is not acknowledged",
+	base.NetworkTimeout,
+	func(v time.Duration) error {
+		if v < time.Second {
+			return errors.Errorf("keepalive timeout must be at least 1s: %s", v)
+		}
+		return nil
+	},
+)
+
+var distSQLFlowMaxConcurrentStreams = settings.RegisterNonNegativeIntSetting(
+	"rpc.distsql_flow.max_concurrent_streams",
+	"the number of concurrent streams on a DistSQL flow connection past which the streams "+
+		"to a node are spread over additional connections (0 uses a single connection)",
+	0,
+)
+
+// dialOptionsForClass returns the window size and keepalive options of the
+// connections of the given class. The settings are read when a connection is
+// dialed, so changes apply to new connections only.
+func (ctx *Context) dialOptionsForClass(class ConnectionClass) []grpc.DialOption {
+	if class != DistSQLFlowClass {
+		return []grpc.DialOption{
+			grpc.WithKeepaliveParams(clientKeepalive),
+			grpc.WithInitialWindowSize(initialWindowSize),
+			grpc.WithInitialConnWindowSize(initialConnWindowSize),
+		}
+	}
+	sv := &ctx.settings.SV
+	return []grpc.DialOption{
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                distSQLFlowKeepaliveInterval.Get(sv),
+			Timeout:             distSQLFlowKeepaliveTimeout.Get(sv),
+			PermitWithoutStream: true,
+		}),
+		grpc.WithInitialWindowSize(int32(distSQLFlowInitialWindowSize.Get(sv))),
+		grpc.WithInitialConnWindowSize(int32(distSQLFlowInitialConnWindowSize.Get(sv))),
+	}
+}
+
+// grpcDialDistSQLFlowNode returns the DistSQL flow connection to the given
+// node which carries the fewest streams. A new connection is added to the pool
+// of the node when all the connections carry at least
+// rpc.distsql_flow.max_concurrent_streams streams.
+func (ctx *Context) grpcDialDistSQLFlowNode(
+	target string, remoteNodeID roachpb.NodeID,
+) *Connection {
+	maxStreams := distSQLFlowMaxConcurrentStreams.Get(&ctx.settings.SV)
+	if maxStreams == 0 {
+		return ctx.grpcDialNodeInternal(target, remoteNodeID, DistSQLFlowClass, 0)
+	}
+	var best *Connection
+	freeSlot := -1
+	for slot := 0; slot < maxDistSQLFlowConnsPerNode; slot++ {
+		value, ok := ctx.conns.Load(connKey{target, remoteNodeID, DistSQLFlowClass, slot})
+		if !ok {
+			if freeSlot == -1 {
+				freeSlot = slot
+			}
+			continue
+		}
+		conn := value.(*Connection)
+		if best == nil || conn.ActiveStreams() < best.ActiveStreams() {
+			best = conn
+		}
+	}
+	if freeSlot != -1 && (best == nil || best.ActiveStreams() >= maxStreams) {
+		return ctx.grpcDialNodeInternal(target, remoteNodeID, DistSQLFlowClass, freeSlot)
+	}
+	return best
+}
@@ -138,6 +138,26 @@ func requireSuperUser(ctx context.Context) error {
 	return nil
 }
 
+// IsNodeRequestContext returns whether the RPC in ctx was issued in-process or
+// by a peer that authenticated as security.NodeUser, that is, by a node of the
+// cluster rather than by an external client. Peers of insecure servers don't
+// authenticate, so their RPCs are never considered to be issued by a node.
+func IsNodeRequestContext(ctx context.Context) bool {
+	if grpcutil.IsLocalRequestContext(ctx) {
+		return true
+	}
+	peer, ok := peer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+	tlsInfo, ok := peer.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return false
+	}
+	certUser, err := security.GetCertificateUser(&tlsInfo.State)
+	return err == nil && certUser == security.NodeUser
+}
+
 // NewServer is a thin wrapper around grpc.NewServer that registers a heartbeat
 // service.
 func NewServer(ctx *Context) *grpc.Server {
@@ -318,6 +338,10 @@ type Connection struct {
 	// the lifetime of a Connection object.
 	remoteNodeID roachpb.NodeID
 
+	// activeStreams is the number of RPCs in flight on the connection, accessed
+	// atomically.
+	activeStreams int64
+
 	initOnce      sync.Once
 	validatedOnce sync.Once
 }
@@ -369,6 +393,12 @@ func (c *Connection) Health() error {
 	return c.heartbeatResult.Load().(heartbeatResult).err
 }
 
+// ActiveStreams returns the number of unary and streaming RPCs in flight on
+// the connection.
+func (c *Connection) ActiveStreams() int64 {
+	return atomic.LoadInt64(&c.activeStreams)
+}
+
 // Context contains the fields required by the rpc framework.
 type Context struct {
 	*base.Config
@@ -394,6 +424,7 @@ type Context struct {
 
 	ClusterID base.ClusterIDContainer
 	NodeID    base.NodeIDContainer
+	settings  *cluster.Settings
 	version   *cluster.ExposedClusterVersion
 
 	metrics Metrics
@@ -408,10 +439,14 @@ type Context struct {
 
 // connKey is used as key in the Context.conns map.  Different remote
 // node IDs get different *Connection objects, to ensure that we don't
-// mis-route RPC requests.
+// mis-route RPC requests. Each connection class has its own connections,
+// and slot distinguishes the connections of a class pooled to the same
+// node.
 type connKey struct {
 	targetAddr string
 	nodeID     roachpb.NodeID
+	class      ConnectionClass
+	slot       int
 }
 
 // NewContext creates an rpc Context with the supplied values.
@@ -420,7 +455,7 @@ func NewContext(
 	baseCtx *base.Config,
 	hlcClock *hlc.Clock,
 	stopper *stop.Stopper,
-	version *cluster.ExposedClusterVersion,
+	st *cluster.Settings,
 ) *Context {
 	if hlcClock == nil {
 		panic("nil clock is forbidden")
@@ -433,7 +468,8 @@ func NewContext(
 			clock: hlcClock,
 		},
 		rpcCompression: enableRPCCompression,
-		version:        version,
+		settings:       st,
+		version:        &st.Version,
 	}
 	var cancel context.CancelFunc
 	ctx.masterCtx, cancel = context.WithCancel(ambient.AnnotateCtx(context.Background()))
@@ -690,19 +726,25 @@ func (ood *onlyOnceDialer) dial(addr string, timeout time.Duration) (net.Conn, e
 // connection. This connection will not be reconnected automatically;
 // the returned channel is closed when a reconnection is attempted.
 func (ctx *Context) GRPCDialRaw(target string) (*grpc.ClientConn, <-chan struct{}, error) {
+	return ctx.grpcDialRaw(target, DefaultClass, nil /* activeStreams */)
+}
+
+// grpcDialRaw is like GRPCDialRaw, but dials a connection of the given class.
+// If activeStreams is not nil, it tracks the number of RPCs in flight on the
+// connection.
+func (ctx *Context) grpcDialRaw(
+	target string, class ConnectionClass, activeStreams *int64,
+) (*grpc.ClientConn, <-chan struct{}, error) {
 	dialOpts, err := ctx.GRPCDialOptions()
 	if err != nil {
 		return nil, nil, err
 	}
 
 	// Add a stats handler to measure client network stats.
-	dialOpts = append(dialOpts, grpc.WithStatsHandler(ctx.stats.newClient(target)))
+	dialOpts = append(dialOpts, grpc.WithStatsHandler(ctx.stats.newClient(target, class, activeStreams)))
 
 	dialOpts = append(dialOpts, grpc.WithBackoffMaxDelay(maxBackoff))
-	dialOpts = append(dialOpts, grpc.WithKeepaliveParams(clientKeepalive))
-	dialOpts = append(dialOpts,
-		grpc.WithInitialWindowSize(initialWindowSize),
-		grpc.WithInitialConnWindowSize(initialConnWindowSize))
+	dialOpts = append(dialOpts, ctx.dialOptionsForClass(class)...)
 
 	dialer := onlyOnceDialer{
 		ctx:        ctx.masterCtx,
@@ -727,7 +769,7 @@ func (ctx *Context) GRPCDialRaw(target string) (*grpc.ClientConn, <-chan struct{
 // used with the gossip client and CLI commands which can talk to any
 // node.
 func (ctx *Context) GRPCUnvalidatedDial(target string) *Connection {
-	return ctx.grpcDialNodeInternal(target, 0)
+	return ctx.grpcDialNodeInternal(target, 0, DefaultClass, 0)
 }
 
 // GRPCDialNode calls grpc.Dial with options appropriate for the context.
@@ -737,18 +779,31 @@ func (ctx *Context) GRPCUnvalidatedDial(target string) *Connection {
 // responsible for ensuring the remote node ID is known prior to using
 // this function.
 func (ctx *Context) GRPCDialNode(target string, remoteNodeID roachpb.NodeID) *Connection {
+	return ctx.GRPCDialNodeClass(target, remoteNodeID, DefaultClass)
+}
+
+// GRPCDialNodeClass is like GRPCDialNode, but returns a connection of the
+// given class.
+func (ctx *Context) GRPCDialNodeClass(
+	target string, remoteNodeID roachpb.NodeID, class ConnectionClass,
+) *Connection {
 	if remoteNodeID == 0 && !ctx.TestingAllowNamedRPCToAnonymousServer {
 		log.Fatalf(context.TODO(), "invalid node ID 0 in GRPCDialNode()")
 	}
-	return ctx.grpcDialNodeInternal(target, remoteNodeID)
+	if class == DistSQLFlowClass {
+		return ctx.grpcDialDistSQLFlowNode(target, remoteNodeID)
+	}
+	return ctx.grpcDialNodeInternal(target, remoteNodeID, class, 0)
 }
 
-func (ctx *Context) grpcDialNodeInternal(target string, remoteNodeID roachpb.NodeID) *Connection {
-	thisConnKeys := []connKey{{target, remoteNodeID}}
+func (ctx *Context) grpcDialNodeInternal(
+	target string, remoteNodeID roachpb.NodeID, class ConnectionClass, slot int,
+) *Connection {
+	thisConnKeys := []connKey{{target, remoteNodeID, class, slot}}
 	value, ok := ctx.conns.Load(thisConnKeys[0])
 	if !ok {
 		value, _ = ctx.conns.LoadOrStore(thisConnKeys[0], newConnectionToNodeID(ctx.Stopper, remoteNodeID))
-		if remoteNodeID != 0 {
+		if remoteNodeID != 0 && class == DefaultClass {
 			// If the first connection established at a target address is
 			// for a specific node ID, then we want to reuse that connection
 			// also for other dials (eg for gossip) which don't require a
@@ -765,7 +820,7 @@ func (ctx *Context) grpcDialNodeInternal(target string, remoteNodeID roachpb.Nod
 			//
 			// See:
 			// https://github.com/cockroachdb/cockroach/issues/37200
-			otherKey := connKey{target, 0}
+			otherKey := connKey{target, 0, DefaultClass, 0}
 			if _, loaded := ctx.conns.LoadOrStore(otherKey, value); !loaded {
 				thisConnKeys = append(thisConnKeys, otherKey)
 			}
@@ -777,7 +832,7 @@ func (ctx *Context) grpcDialNodeInternal(target string, remoteNodeID roachpb.Nod
 		// Either we kick off the heartbeat loop (and clean up when it's done),
 		// or we clean up the connKey entries immediately.
 		var redialChan <-chan struct{}
-		conn.grpcConn, redialChan, conn.dialErr = ctx.GRPCDialRaw(target)
+		conn.grpcConn, redialChan, conn.dialErr = ctx.grpcDialRaw(target, class, &conn.activeStreams)
 		if conn.dialErr == nil {
 			if err := ctx.Stopper.RunTask(
 				ctx.masterCtx, "rpc.Context: grpc heartbeat", func(masterCtx context.Context) {
@@ -927,6 +982,9 @@ func (ctx *Context) runHeartbeat(
 				}
 			}
 
+			if err != nil {
+				ctx.stats.peer(target).recordHeartbeatFailure()
+			}
 			hr := heartbeatResult{
 				everSucceeded: everSucceeded,
 				err:           err,
@@ -16,6 +16,9 @@ package rpc
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"fmt"
 	"math"
 	"net"
@@ -27,6 +30,7 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util"
@@ -47,6 +51,7 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 )
 
@@ -75,7 +80,7 @@ func newTestContext(clusterID uuid.UUID, clock *hlc.Clock, stopper *stop.Stopper
 		testutils.NewNodeTestBaseContext(),
 		clock,
 		stopper,
-		&cluster.MakeTestingClusterSettings().Version,
+		cluster.MakeTestingClusterSettings(),
 	)
 	// Ensure that tests using this test context and restart/shut down
 	// their servers do not inadvertently start talking to servers from
@@ -137,6 +142,75 @@ func TestHeartbeatCB(t *testing.T) {
 	})
 }
 
+// TestDistSQLFlowConnectionPool verifies that the DistSQL flow connections
+// are separate from the default ones and that streams are spread over
+// additional connections once rpc.distsql_flow.max_concurrent_streams is
+// reached.
+func TestDistSQLFlowConnectionPool(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+
+	clock := hlc.NewClock(timeutil.Unix(0, 20).UnixNano, time.Nanosecond)
+	clusterID := uuid.MakeV4()
+
+	serverCtx := newTestContext(clusterID, clock, stopper)
+	const serverNodeID = 1
+	serverCtx.NodeID.Set(context.TODO(), serverNodeID)
+	s := newTestServer(t, serverCtx)
+	RegisterHeartbeatServer(s, &HeartbeatService{
+		clock:              clock,
+		remoteClockMonitor: serverCtx.RemoteClocks,
+		clusterID:          &serverCtx.ClusterID,
+		nodeID:             &serverCtx.NodeID,
+		version:            serverCtx.version,
+	})
+	ln, err := netutil.ListenAndServeGRPC(serverCtx.Stopper, s, util.TestAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remoteAddr := ln.Addr().String()
+
+	clientCtx := newTestContext(clusterID, clock, stopper)
+	distSQLFlowMaxConcurrentStreams.Override(&clientCtx.settings.SV, 1)
+	dial := func(class ConnectionClass) *Connection {
+		t.Helper()
+		conn := clientCtx.GRPCDialNodeClass(remoteAddr, serverNodeID, class)
+		if _, err := conn.Connect(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		return conn
+	}
+
+	defConn := dial(DefaultClass)
+	flowConn1 := dial(DistSQLFlowClass)
+	if flowConn1 == defConn {
+		t.Fatal("expected the DistSQL flow connection to be separate from the default one")
+	}
+	if conn := dial(DistSQLFlowClass); conn != flowConn1 {
+		t.Fatal("expected an idle DistSQL flow connection to be reused")
+	}
+
+	// Once the connection carries max_concurrent_streams streams, a second
+	// connection is dialed.
+	atomic.AddInt64(&flowConn1.activeStreams, 1)
+	flowConn2 := dial(DistSQLFlowClass)
+	if flowConn2 == flowConn1 || flowConn2 == defConn {
+		t.Fatal("expected a new DistSQL flow connection")
+	}
+
+	// When all the connections are busy, the least loaded one is used.
+	atomic.AddInt64(&flowConn1.activeStreams, 1)
+	atomic.AddInt64(&flowConn2.activeStreams, 1)
+	for i := 2; i < maxDistSQLFlowConnsPerNode; i++ {
+		atomic.AddInt64(&dial(DistSQLFlowClass).activeStreams, 1)
+	}
+	if conn := dial(DistSQLFlowClass); conn != flowConn2 {
+		t.Fatal("expected the least loaded DistSQL flow connection")
+	}
+}
+
 type internalServer struct{}
 
 func (*internalServer) Batch(
@@ -178,6 +252,40 @@ func TestInternalServerAddress(t *testing.T) {
 	}
 }
 
+func TestIsNodeRequestContext(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	peerCtx := func(authInfo credentials.AuthInfo) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: authInfo})
+	}
+	certUser := func(user string) credentials.AuthInfo {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: user}}
+		return credentials.TLSInfo{State: tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{cert},
+		}}
+	}
+	testCases := []struct {
+		name string
+		ctx  context.Context
+		exp  bool
+	}{
+		{"no peer", context.Background(), false},
+		{"local", grpcutil.NewLocalRequestContext(context.Background()), true},
+		{"insecure", peerCtx(nil), false},
+		{"no certificate", peerCtx(credentials.TLSInfo{}), false},
+		{"node", peerCtx(certUser(security.NodeUser)), true},
+		{"root", peerCtx(certUser(security.RootUser)), false},
+		{"user", peerCtx(certUser("testuser")), false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if res := IsNodeRequestContext(tc.ctx); res != tc.exp {
+				t.Fatalf("expected %t, got %t", tc.exp, res)
+			}
+		})
+	}
+}
+
 // TestHeartbeatHealth verifies that the health status changes after
 // heartbeats succeed or fail.
 func TestHeartbeatHealth(t *testing.T) {
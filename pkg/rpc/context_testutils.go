@@ -42,7 +42,7 @@ func NewInsecureTestingContextWithClusterID(
 		&base.Config{Insecure: true},
 		clock,
 		stopper,
-		&cluster.MakeTestingClusterSettings().Version,
+		cluster.MakeTestingClusterSettings(),
 	)
 	// Ensure that tests using this test context and restart/shut down
 	// their servers do not inadvertently start talking to servers from
@@ -75,6 +75,13 @@ var _ = (*Dialer).Stopper
 // Dial returns a grpc connection to the given node. It logs whenever the
 // node first becomes unreachable or reachable.
 func (n *Dialer) Dial(ctx context.Context, nodeID roachpb.NodeID) (_ *grpc.ClientConn, err error) {
+	return n.DialClass(ctx, nodeID, rpc.DefaultClass)
+}
+
+// DialClass is like Dial, but returns a connection of the given class.
+func (n *Dialer) DialClass(
+	ctx context.Context, nodeID roachpb.NodeID, class rpc.ConnectionClass,
+) (_ *grpc.ClientConn, err error) {
 	if n == nil || n.resolver == nil {
 		return nil, errors.New("no node dialer configured")
 	}
@@ -89,7 +96,7 @@ func (n *Dialer) Dial(ctx context.Context, nodeID roachpb.NodeID) (_ *grpc.Clien
 		breaker.Fail(err)
 		return nil, err
 	}
-	return n.dial(ctx, nodeID, addr, breaker)
+	return n.dial(ctx, nodeID, addr, breaker, class)
 }
 
 // DialInternalClient is a specialization of Dial for callers that
@@ -118,7 +125,7 @@ func (n *Dialer) DialInternalClient(
 		return localCtx, localClient, nil
 	}
 	log.VEventf(ctx, 2, "sending request to %s", addr)
-	conn, err := n.dial(ctx, nodeID, addr, n.getBreaker(nodeID))
+	conn, err := n.dial(ctx, nodeID, addr, n.getBreaker(nodeID), rpc.DefaultClass)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -127,7 +134,11 @@ func (n *Dialer) DialInternalClient(
 
 // dial performs the dialing of the remote connection.
 func (n *Dialer) dial(
-	ctx context.Context, nodeID roachpb.NodeID, addr net.Addr, breaker *wrappedBreaker,
+	ctx context.Context,
+	nodeID roachpb.NodeID,
+	addr net.Addr,
+	breaker *wrappedBreaker,
+	class rpc.ConnectionClass,
 ) (_ *grpc.ClientConn, err error) {
 	// Don't trip the breaker if we're already canceled.
 	if ctxErr := ctx.Err(); ctxErr != nil {
@@ -143,7 +154,7 @@ func (n *Dialer) dial(
 			log.Infof(ctx, "unable to connect to n%d: %s", nodeID, err)
 		}
 	}()
-	conn, err := n.rpcContext.GRPCDialNode(addr.String(), nodeID).Connect(ctx)
+	conn, err := n.rpcContext.GRPCDialNodeClass(addr.String(), nodeID, class).Connect(ctx)
 	if err != nil {
 		// If we were canceled during the dial, don't trip the breaker.
 		if ctxErr := ctx.Err(); ctxErr != nil {
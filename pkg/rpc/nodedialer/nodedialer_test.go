@@ -242,7 +242,7 @@ func newTestContext(clock *hlc.Clock, stopper *stop.Stopper) *rpc.Context {
 		cfg,
 		clock,
 		stopper,
-		&cluster.MakeTestingClusterSettings().Version,
+		cluster.MakeTestingClusterSettings(),
 	)
 	// Ensure that tests using this test context and restart/shut down
 	// their servers do not inadvertently start talking to servers from
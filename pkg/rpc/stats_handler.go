@@ -30,6 +30,9 @@ type Stats struct {
 	count    int64
 	incoming int64
 	outgoing int64
+
+	heartbeatFailures  int64
+	distSQLFlowStreams int64
 }
 
 // Count returns the total number of RPCs.
@@ -47,6 +50,22 @@ func (s *Stats) Outgoing() int64 {
 	return atomic.LoadInt64(&s.outgoing)
 }
 
+// HeartbeatFailures returns the total number of failed heartbeats on the
+// connections dialed to the remote node.
+func (s *Stats) HeartbeatFailures() int64 {
+	return atomic.LoadInt64(&s.heartbeatFailures)
+}
+
+// DistSQLFlowStreams returns the number of streams in flight on the DistSQL
+// flow connections dialed to the remote node.
+func (s *Stats) DistSQLFlowStreams() int64 {
+	return atomic.LoadInt64(&s.distSQLFlowStreams)
+}
+
+func (s *Stats) recordHeartbeatFailure() {
+	atomic.AddInt64(&s.heartbeatFailures, 1)
+}
+
 func (s *Stats) record(rpcStats stats.RPCStats) {
 	switch v := rpcStats.(type) {
 	case *stats.InHeader:
@@ -71,6 +90,10 @@ func (s *Stats) record(rpcStats stats.RPCStats) {
 
 type clientStatsHandler struct {
 	stats *Stats
+	class ConnectionClass
+	// activeStreams, if set, is incremented when an RPC starts on the
+	// connection and decremented when it ends.
+	activeStreams *int64
 }
 
 var _ stats.Handler = &clientStatsHandler{}
@@ -83,6 +106,21 @@ func (cs *clientStatsHandler) TagRPC(ctx context.Context, rti *stats.RPCTagInfo)
 // HandleRPC implements the grpc.stats.Handler interface.
 func (cs *clientStatsHandler) HandleRPC(ctx context.Context, rpcStats stats.RPCStats) {
 	cs.stats.record(rpcStats)
+	var delta int64
+	switch rpcStats.(type) {
+	case *stats.Begin:
+		delta = 1
+	case *stats.End:
+		delta = -1
+	default:
+		return
+	}
+	if cs.activeStreams != nil {
+		atomic.AddInt64(cs.activeStreams, delta)
+	}
+	if cs.class == DistSQLFlowClass {
+		atomic.AddInt64(&cs.stats.distSQLFlowStreams, delta)
+	}
 }
 
 // TagConn implements the grpc.stats.Handler interface.
@@ -109,14 +147,25 @@ type StatsHandler struct {
 var _ stats.Handler = &StatsHandler{}
 
 // newClient returns a new clientStatsHandler which references the stats
-// object bound to the specified target remote address.
-func (sh *StatsHandler) newClient(target string) stats.Handler {
-	value, _ := sh.stats.LoadOrStore(target, &Stats{})
+// object bound to the specified target remote address. The handler is
+// used by a connection of the given class and tracks the number of RPCs in
+// flight on it in activeStreams, if set.
+func (sh *StatsHandler) newClient(
+	target string, class ConnectionClass, activeStreams *int64,
+) stats.Handler {
 	return &clientStatsHandler{
-		stats: value.(*Stats),
+		stats:         sh.peer(target),
+		class:         class,
+		activeStreams: activeStreams,
 	}
 }
 
+// peer returns the stats object bound to the specified target remote address.
+func (sh *StatsHandler) peer(target string) *Stats {
+	value, _ := sh.stats.LoadOrStore(target, &Stats{})
+	return value.(*Stats)
+}
+
 // TagRPC implements the grpc.stats.Handler interface. This
 // interface is used directly for server-side stats recording.
 func (sh *StatsHandler) TagRPC(ctx context.Context, rti *stats.RPCTagInfo) context.Context {
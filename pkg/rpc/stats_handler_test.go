@@ -61,14 +61,14 @@ func TestStatsHandlerBasic(t *testing.T) {
 	sh.HandleRPC(ctx, &stats.OutTrailer{WireLength: 11})
 	expResults["10.10.1.4:26257"].outgoing += 18
 
-	cStats1 := sh.newClient("10.10.1.3:26257")
+	cStats1 := sh.newClient("10.10.1.3:26257", DefaultClass, nil /* activeStreams */)
 	cStats1.HandleRPC(ctx, &stats.InHeader{WireLength: 13})
 	cStats1.HandleRPC(ctx, &stats.InPayload{WireLength: 17})
 	cStats1.HandleRPC(ctx, &stats.InTrailer{WireLength: 19})
 	// See comment above for why we must add 5 bytes here.
 	expResults["10.10.1.3:26257"].incoming += 54
 
-	cStats2 := sh.newClient("10.10.1.4:26257")
+	cStats2 := sh.newClient("10.10.1.4:26257", DefaultClass, nil /* activeStreams */)
 	cStats2.HandleRPC(ctx, &stats.OutPayload{WireLength: 23})
 	cStats2.HandleRPC(ctx, &stats.OutTrailer{WireLength: 29})
 	expResults["10.10.1.4:26257"].outgoing += 52
@@ -90,6 +90,36 @@ func TestStatsHandlerBasic(t *testing.T) {
 	})
 }
 
+// TestStatsHandlerActiveStreams verifies that the client stats handlers track
+// the RPCs in flight on their connection and, for the DistSQL flow
+// connections, on all the connections to the remote node.
+func TestStatsHandlerActiveStreams(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var sh StatsHandler
+	ctx := context.Background()
+	const target = "10.10.1.3:26257"
+	var defaultStreams, flowStreams1, flowStreams2 int64
+	cDefault := sh.newClient(target, DefaultClass, &defaultStreams)
+	cFlow1 := sh.newClient(target, DistSQLFlowClass, &flowStreams1)
+	cFlow2 := sh.newClient(target, DistSQLFlowClass, &flowStreams2)
+
+	cDefault.HandleRPC(ctx, &stats.Begin{})
+	cFlow1.HandleRPC(ctx, &stats.Begin{})
+	cFlow1.HandleRPC(ctx, &stats.OutPayload{WireLength: 7})
+	cFlow2.HandleRPC(ctx, &stats.Begin{})
+	cFlow2.HandleRPC(ctx, &stats.Begin{})
+	cFlow2.HandleRPC(ctx, &stats.End{})
+
+	if defaultStreams != 1 || flowStreams1 != 1 || flowStreams2 != 1 {
+		t.Fatalf("expected one active stream per connection, got %d, %d and %d",
+			defaultStreams, flowStreams1, flowStreams2)
+	}
+	if a := sh.peer(target).DistSQLFlowStreams(); a != 2 {
+		t.Fatalf("expected 2 DistSQL flow streams, got %d", a)
+	}
+}
+
 // TestStatsHandlerWithHeartbeats verifies the stats handler captures
 // incoming and outgoing traffic with real server and client connections.
 func TestStatsHandlerWithHeartbeats(t *testing.T) {
@@ -36,6 +36,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/server/debug"
 	"github.com/cockroachdb/cockroach/pkg/server/serverpb"
 	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -80,6 +81,8 @@ type adminServer struct {
 	server     *Server
 	memMonitor mon.BytesMonitor
 	memMetrics *sql.MemoryMetrics
+
+	decommissionProgress decommissionProgress
 }
 
 // noteworthyAdminMemoryUsageBytes is the minimum size tracked by the
@@ -1103,10 +1106,12 @@ func (s *adminServer) Settings(
 		if !ok {
 			continue
 		}
+		_, _, overridden := s.server.st.SV.NodeOverride(k)
 		resp.KeyValues[k] = serverpb.SettingsResponse_Value{
-			Type:        v.Typ(),
-			Value:       settings.SanitizedValue(k, &s.server.st.SV),
-			Description: v.Description(),
+			Type:           v.Typ(),
+			Value:          settings.SanitizedValue(k, &s.server.st.SV),
+			Description:    v.Description(),
+			NodeOverridden: overridden,
 		}
 	}
 
@@ -1388,7 +1393,14 @@ func (s *adminServer) Drain(req *serverpb.DrainRequest, stream serverpb.Admin_Dr
 	}
 }
 
+// maxDecommissionBlockedRanges is the maximum number of blocked ranges
+// reported by DecommissionStatus for each node.
+const maxDecommissionBlockedRanges = 100
+
 // DecommissionStatus returns the DecommissionStatus for all or the given nodes.
+// For decommissioning nodes, it also reports the rate at which replicas are
+// moving off the node, the resulting estimated completion time, and the ranges
+// for which the allocator cannot find a replacement target.
 func (s *adminServer) DecommissionStatus(
 	ctx context.Context, req *serverpb.DecommissionStatusRequest,
 ) (*serverpb.DecommissionStatusResponse, error) {
@@ -1407,14 +1419,55 @@ func (s *adminServer) DecommissionStatus(
 		}
 	}
 
+	leaseCounts := make(map[roachpb.NodeID]int64)
+	for _, status := range ns.Nodes {
+		for _, ss := range status.StoreStatuses {
+			leaseCounts[status.Desc.NodeID] += int64(ss.Metrics["replicas.leaseholders"])
+		}
+	}
+
+	livenesses := make(map[roachpb.NodeID]*storagepb.Liveness, len(nodeIDs))
+	for _, nodeID := range nodeIDs {
+		l, err := s.server.nodeLiveness.GetLiveness(nodeID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to get liveness for %d", nodeID)
+		}
+		livenesses[nodeID] = l
+	}
+
+	// Ranges with a replica on a decommissioning node are checked against the
+	// allocator: if it would want to up-replicate the range to replace the
+	// decommissioning replica but can't find a target, the range blocks the
+	// decommissioning from completing.
+	allocator := storage.MakeAllocator(s.server.storePool, s.server.rpcContext.RemoteClocks.Latency)
+	sysCfg := s.server.gossip.GetSystemConfig()
+	blocked := func(desc *roachpb.RangeDescriptor) (string, bool) {
+		if sysCfg == nil {
+			return "", false
+		}
+		zone, err := sysCfg.GetZoneConfigForKey(desc.StartKey)
+		if err != nil {
+			log.Warningf(ctx, "r%d: unable to look up zone config: %v", desc.RangeID, err)
+			return "", false
+		}
+		info := storage.RangeInfo{Desc: desc}
+		if action, _ := allocator.ComputeAction(ctx, zone, info); action != storage.AllocatorAdd {
+			return "", false
+		}
+		if _, _, err := allocator.AllocateTarget(ctx, zone, desc.Replicas().Unwrap(), info); err != nil {
+			return err.Error(), true
+		}
+		return "", false
+	}
+
 	// Compute the replica counts for the target nodes only. This map doubles as
 	// a lookup table to check whether we care about a given node.
-	var replicaCounts map[roachpb.NodeID]int64
+	var statuses map[roachpb.NodeID]*serverpb.DecommissionStatusResponse_Status
 	if err := s.server.db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
 		const pageSize = 10000
-		replicaCounts = make(map[roachpb.NodeID]int64)
+		statuses = make(map[roachpb.NodeID]*serverpb.DecommissionStatusResponse_Status)
 		for _, nodeID := range nodeIDs {
-			replicaCounts[nodeID] = 0
+			statuses[nodeID] = &serverpb.DecommissionStatusResponse_Status{}
 		}
 		return txn.Iterate(ctx, keys.MetaMin, keys.MetaMax, pageSize,
 			func(rows []client.KeyValue) error {
@@ -1423,9 +1476,30 @@ func (s *adminServer) DecommissionStatus(
 					if err := row.ValueProto(&rangeDesc); err != nil {
 						return errors.Wrapf(err, "%s: unable to unmarshal range descriptor", row.Key)
 					}
+					var checked, isBlocked bool
+					var reason string
 					for _, r := range rangeDesc.Replicas().Unwrap() {
-						if _, ok := replicaCounts[r.NodeID]; ok {
-							replicaCounts[r.NodeID]++
+						status, ok := statuses[r.NodeID]
+						if !ok {
+							continue
+						}
+						status.ReplicaCount++
+						if !livenesses[r.NodeID].Decommissioning {
+							continue
+						}
+						if !checked {
+							reason, isBlocked = blocked(&rangeDesc)
+							checked = true
+						}
+						if !isBlocked {
+							continue
+						}
+						if status.BlockedRangeCount == 0 {
+							status.BlockedReason = reason
+						}
+						status.BlockedRangeCount++
+						if len(status.BlockedRangeIDs) < maxDecommissionBlockedRanges {
+							status.BlockedRangeIDs = append(status.BlockedRangeIDs, rangeDesc.RangeID)
 						}
 					}
 				}
@@ -1437,20 +1511,26 @@ func (s *adminServer) DecommissionStatus(
 
 	var res serverpb.DecommissionStatusResponse
 
-	for nodeID := range replicaCounts {
-		l, err := s.server.nodeLiveness.GetLiveness(nodeID)
-		if err != nil {
-			return nil, errors.Wrapf(err, "unable to get liveness for %d", nodeID)
-		}
-		nodeResp := serverpb.DecommissionStatusResponse_Status{
-			NodeID:          l.NodeID,
-			ReplicaCount:    replicaCounts[l.NodeID],
-			Decommissioning: l.Decommissioning,
-			Draining:        l.Draining,
-		}
+	now := s.server.clock.PhysicalTime()
+	for nodeID, status := range statuses {
+		l := livenesses[nodeID]
+		nodeResp := *status
+		nodeResp.NodeID = l.NodeID
+		nodeResp.Decommissioning = l.Decommissioning
+		nodeResp.Draining = l.Draining
+		nodeResp.LeaseCount = leaseCounts[nodeID]
 		if l.IsLive(s.server.clock.Now(), s.server.clock.MaxOffset()) {
 			nodeResp.IsLive = true
 		}
+		if l.Decommissioning {
+			rate, completion := s.decommissionProgress.record(nodeID, now, nodeResp.ReplicaCount)
+			nodeResp.ReplicaMoveRate = rate
+			if !completion.IsZero() {
+				nodeResp.EstimatedCompletion = completion.UnixNano()
+			}
+		} else {
+			s.decommissionProgress.forget(nodeID)
+		}
 
 		res.Status = append(res.Status, nodeResp)
 	}
@@ -1760,6 +1840,123 @@ func (s *adminServer) enqueueRangeLocal(
 	return response, nil
 }
 
+// RecomputeRangeStats recomputes the MVCC stats of the requested range from
+// its data and adjusts the persisted stats by the difference. Unless this is a
+// dry run, the ContainsEstimates flag of the range's stats is cleared as well.
+func (s *adminServer) RecomputeRangeStats(
+	ctx context.Context, req *serverpb.RecomputeRangeStatsRequest,
+) (*serverpb.RecomputeRangeStatsResponse, error) {
+	if !debug.GatewayRemoteAllowed(ctx, s.server.ClusterSettings()) {
+		return nil, remoteDebuggingErr
+	}
+
+	ctx = propagateGatewayMetadata(ctx)
+	ctx = s.server.AnnotateCtx(ctx)
+
+	if req.RangeID <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "range_id must be positive; got %d", req.RangeID)
+	}
+
+	// Find the most recent descriptor of the range known to any node. If it is
+	// stale (i.e. the range was merged away), RecomputeStats returns an error.
+	rangeResp, err := s.server.status.Range(ctx, &serverpb.RangeRequest{RangeId: int64(req.RangeID)})
+	if err != nil {
+		return nil, s.serverError(err)
+	}
+	var desc *roachpb.RangeDescriptor
+	for _, nodeResp := range rangeResp.ResponsesByNodeID {
+		for _, info := range nodeResp.Infos {
+			if d := info.State.Desc; d != nil && (desc == nil || d.GetGeneration() > desc.GetGeneration()) {
+				desc = d
+			}
+		}
+	}
+	if desc == nil {
+		return nil, status.Errorf(codes.NotFound, "no replica of r%d found", req.RangeID)
+	}
+
+	var b client.Batch
+	b.AddRawRequest(&roachpb.RecomputeStatsRequest{
+		RequestHeader: roachpb.RequestHeader{Key: desc.StartKey.AsRawKey()},
+		DryRun:        req.DryRun,
+		ClearEstimates: !req.DryRun &&
+			s.server.ClusterSettings().Version.IsActive(cluster.VersionRecomputeStatsClearEstimates),
+	})
+	if err := s.server.db.Run(ctx, &b); err != nil {
+		return nil, s.serverError(err)
+	}
+	recomputeResp := b.RawResponse().Responses[0].GetInner().(*roachpb.RecomputeStatsResponse)
+	log.Infof(ctx, "recomputed stats of r%d (dry run: %t), adjusted by %+v",
+		req.RangeID, req.DryRun, recomputeResp.AddedDelta)
+	return &serverpb.RecomputeRangeStatsResponse{AddedDelta: recomputeResp.AddedDelta}, nil
+}
+
+// RaftLog returns the decoded entries of the Raft log of the requested range
+// on the requested node, forwarding the request if it is targeted at another
+// node.
+func (s *adminServer) RaftLog(
+	ctx context.Context, req *serverpb.RaftLogRequest,
+) (*serverpb.RaftLogResponse, error) {
+	if !debug.GatewayRemoteAllowed(ctx, s.server.ClusterSettings()) {
+		return nil, remoteDebuggingErr
+	}
+
+	ctx = propagateGatewayMetadata(ctx)
+	ctx = s.server.AnnotateCtx(ctx)
+
+	if req.NodeId < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "node_id must be non-negative; got %d", req.NodeId)
+	}
+	if req.RangeId <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "range_id must be positive; got %d", req.RangeId)
+	}
+	if req.HighIndex != 0 && req.HighIndex <= req.LowIndex {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"high_index must be greater than low_index; got %d and %d", req.HighIndex, req.LowIndex)
+	}
+
+	if nodeID := roachpb.NodeID(req.NodeId); nodeID != 0 && nodeID != s.server.NodeID() {
+		admin, err := s.dialNode(ctx, nodeID)
+		if err != nil {
+			return nil, err
+		}
+		return admin.RaftLog(ctx, req)
+	}
+
+	repl, err := s.server.node.stores.GetReplicaForRangeID(roachpb.RangeID(req.RangeId))
+	if err != nil {
+		if _, ok := err.(*roachpb.RangeNotFoundError); ok {
+			return nil, status.Errorf(codes.NotFound, "n%d has no replica for r%d",
+				s.server.NodeID(), req.RangeId)
+		}
+		return nil, s.serverError(err)
+	}
+	raftLog, err := repl.DebugRaftLog(ctx, req.LowIndex, req.HighIndex)
+	if err != nil {
+		return nil, s.serverError(err)
+	}
+
+	resp := &serverpb.RaftLogResponse{
+		FirstIndex:   raftLog.FirstIndex,
+		LastIndex:    raftLog.LastIndex,
+		AppliedIndex: raftLog.AppliedIndex,
+		CommitIndex:  raftLog.CommitIndex,
+		Entries:      make([]serverpb.RaftLogEntry, 0, len(raftLog.Entries)),
+	}
+	for _, e := range raftLog.Entries {
+		resp.Entries = append(resp.Entries, serverpb.RaftLogEntry{
+			Index:      e.Index,
+			Term:       e.Term,
+			Type:       e.Type.String(),
+			CommandId:  fmt.Sprintf("%x", e.CommandID),
+			Command:    e.Command,
+			WriteBatch: e.WriteBatch,
+			Size_:      int64(e.Size),
+		})
+	}
+	return resp, nil
+}
+
 // sqlQuery allows you to incrementally build a SQL query that uses
 // placeholders. Instead of specific placeholders like $1, you instead use the
 // temporary placeholder $.
@@ -385,6 +385,72 @@ func TestAdminAPINonTableStats(t *testing.T) {
 	assertExpectedStatsResponse(expectedResponse.InternalUseStats, resp.InternalUseStats)
 }
 
+func TestAdminAPIDecommissionStatus(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	testCluster := serverutils.StartTestCluster(t, 3, base.TestClusterArgs{})
+	defer testCluster.Stopper().Stop(context.Background())
+	ctx := context.Background()
+	adminServer := testCluster.Server(0).(*TestServer).Server.admin
+	decommissioningNodeID := testCluster.Server(2).NodeID()
+
+	// Wait for all ranges to be fully replicated, at which point every node has
+	// a replica of every range.
+	testutils.SucceedsSoon(t, func() error {
+		resp, err := adminServer.DecommissionStatus(ctx, &serverpb.DecommissionStatusRequest{})
+		if err != nil {
+			return err
+		}
+		var leaseCount int64
+		for _, status := range resp.Status {
+			if status.ReplicaCount != resp.Status[0].ReplicaCount {
+				return errors.Errorf("replica counts differ: %+v", resp.Status)
+			}
+			leaseCount += status.LeaseCount
+		}
+		if leaseCount == 0 {
+			return errors.New("no leases reported yet")
+		}
+		return nil
+	})
+
+	if _, err := adminServer.Decommission(ctx, &serverpb.DecommissionRequest{
+		NodeIDs:         []roachpb.NodeID{decommissioningNodeID},
+		Decommissioning: true,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// With only two other nodes, no range can find a replacement for its replica
+	// on the decommissioning node.
+	testutils.SucceedsSoon(t, func() error {
+		resp, err := adminServer.DecommissionStatus(ctx, &serverpb.DecommissionStatusRequest{
+			NodeIDs: []roachpb.NodeID{decommissioningNodeID},
+		})
+		if err != nil {
+			return err
+		}
+		status := resp.Status[0]
+		if !status.Decommissioning {
+			return errors.Errorf("n%d is not decommissioning", decommissioningNodeID)
+		}
+		if status.ReplicaCount == 0 || status.BlockedRangeCount != status.ReplicaCount {
+			return errors.Errorf("expected all %d replicas to be blocked, got %d",
+				status.ReplicaCount, status.BlockedRangeCount)
+		}
+		if len(status.BlockedRangeIDs) != int(status.BlockedRangeCount) {
+			return errors.Errorf("expected %d blocked range IDs, got %v",
+				status.BlockedRangeCount, status.BlockedRangeIDs)
+		}
+		if !strings.Contains(status.BlockedReason, "live stores are able to take a new replica") {
+			return errors.Errorf("unexpected blocked reason: %s", status.BlockedReason)
+		}
+		if status.ReplicaMoveRate != 0 || status.EstimatedCompletion != 0 {
+			return errors.Errorf("unexpected progress estimate: %+v", status)
+		}
+		return nil
+	})
+}
+
 // TODO(celia): I expect all the ranges listed on the Database page to equal
 // the RangeCount returned from doing a span on [LocalMax, MaxKey). For a cluster
 // with no user data, all the ranges on the Databases page consist of:
@@ -1704,6 +1770,104 @@ func TestEnqueueRange(t *testing.T) {
 	}
 }
 
+func TestEnqueueRangeBuiltin(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.Background())
+	sqlDB := sqlutils.MakeSQLRunner(db)
+
+	var events int
+	sqlDB.QueryRow(t, `
+SELECT count(*) FROM crdb_internal.enqueue_range(1, 'raftlog', true) WHERE error IS NULL`,
+	).Scan(&events)
+	if events == 0 {
+		t.Fatal("expected trace events from the raftlog queue")
+	}
+
+	var errMsg string
+	sqlDB.QueryRow(t, `
+SELECT error FROM crdb_internal.enqueue_range(1, 'gv') WHERE error IS NOT NULL`,
+	).Scan(&errMsg)
+	if !strings.Contains(errMsg, "unknown queue type") {
+		t.Fatalf("unexpected error: %s", errMsg)
+	}
+}
+
+func TestRecomputeRangeStats(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.Background())
+
+	for _, dryRun := range []bool{true, false} {
+		req := &serverpb.RecomputeRangeStatsRequest{RangeID: 1, DryRun: dryRun}
+		var resp serverpb.RecomputeRangeStatsResponse
+		if err := postAdminJSONProto(s, "recompute_range_stats", req, &resp); err != nil {
+			t.Fatalf("dryRun=%t: %v", dryRun, err)
+		}
+	}
+
+	for _, tc := range []struct {
+		rangeID roachpb.RangeID
+		expErr  string
+	}{
+		{-1, "400 Bad Request"},
+		{999, "404 Not Found"},
+	} {
+		req := &serverpb.RecomputeRangeStatsRequest{RangeID: tc.rangeID}
+		var resp serverpb.RecomputeRangeStatsResponse
+		err := postAdminJSONProto(s, "recompute_range_stats", req, &resp)
+		if !testutils.IsError(err, tc.expErr) {
+			t.Fatalf("r%d: expected error %q, got %v", tc.rangeID, tc.expErr, err)
+		}
+	}
+}
+
+func TestAdminAPIRaftLog(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.Background())
+
+	var resp serverpb.RaftLogResponse
+	if err := getAdminJSONProto(s, "raft_log/1", &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Entries) == 0 {
+		t.Fatalf("expected entries in the Raft log of r1, got %+v", resp)
+	}
+	if last := resp.Entries[len(resp.Entries)-1]; last.Index != resp.LastIndex {
+		t.Fatalf("expected the most recent entry %d to be the last index %d", last.Index, resp.LastIndex)
+	}
+	if resp.AppliedIndex > resp.LastIndex || resp.CommitIndex > resp.LastIndex {
+		t.Fatalf("applied index %d and commit index %d must not exceed last index %d",
+			resp.AppliedIndex, resp.CommitIndex, resp.LastIndex)
+	}
+
+	// Request a window of the log from the node explicitly.
+	lo, hi := resp.Entries[0].Index, resp.Entries[0].Index+2
+	path := fmt.Sprintf("raft_log/1?node_id=%d&low_index=%d&high_index=%d", s.NodeID(), lo, hi)
+	var windowResp serverpb.RaftLogResponse
+	if err := getAdminJSONProto(s, path, &windowResp); err != nil {
+		t.Fatal(err)
+	}
+	if e := windowResp.Entries; len(e) != 2 || e[0].Index != lo || e[1].Index != lo+1 {
+		t.Fatalf("expected entries [%d, %d), got %+v", lo, hi, e)
+	}
+
+	for _, tc := range []struct {
+		path   string
+		expErr string
+	}{
+		{"raft_log/-1", "400 Bad Request"},
+		{"raft_log/1?low_index=10&high_index=5", "400 Bad Request"},
+		{"raft_log/999", "404 Not Found"},
+	} {
+		var resp serverpb.RaftLogResponse
+		if err := getAdminJSONProto(s, tc.path, &resp); !testutils.IsError(err, tc.expErr) {
+			t.Fatalf("%s: expected error %q, got %v", tc.path, tc.expErr, err)
+		}
+	}
+}
+
 func TestStatsforSpanOnLocalMax(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	testCluster := serverutils.StartTestCluster(t, 3, base.TestClusterArgs{})
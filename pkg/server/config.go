@@ -19,6 +19,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"path/filepath"
 	"strings"
 	"text/tabwriter"
 	"time"
@@ -28,9 +29,11 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/gossip/resolver"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/server/status"
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/metering"
 	"github.com/cockroachdb/cockroach/pkg/ts"
 	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/envutil"
@@ -75,10 +78,18 @@ const (
 
 	defaultSQLTableStatCacheSize = 256
 
+	defaultSQLOptTableCacheSize = 4096
+
 	// This comes out to 1024 cache entries.
 	defaultSQLQueryCacheSize = 8 * 1024 * 1024
 )
 
+// storeBallastSize is the size of the ballast file created in each on-disk
+// store, which can be deleted to reclaim space if the disk fills up. See
+// engine.EnsureBallast. No ballast is created by default, since writing it
+// out delays the startup of the node.
+var storeBallastSize = envutil.EnvOrDefaultBytes("COCKROACH_STORE_BALLAST_SIZE", 0)
+
 var productionSettingsWebpage = fmt.Sprintf(
 	"please see %s for more details",
 	base.DocsURL("recommended-production-settings.html"),
@@ -139,6 +150,13 @@ type Config struct {
 	// ephemeral data when processing large queries.
 	TempStorageConfig base.TempStorageConfig
 
+	// SettingOverrides maps the names of cluster settings to node-local values
+	// which shadow the cluster-wide ones. Only settings marked as overridable
+	// (see settings.SetNodeOverridable) may be overridden. Overrides can also
+	// be supplied through the COCKROACH_SETTING_OVERRIDES environment variable
+	// as a comma-separated list of name=value pairs.
+	SettingOverrides map[string]string
+
 	// Attrs specifies a colon-separated list of node topography or machine
 	// capabilities, used to match capabilities or location preferences specified
 	// in zone configs.
@@ -171,6 +189,10 @@ type Config struct {
 	// statistics cache.
 	SQLTableStatCacheSize int
 
+	// SQLOptTableCacheSize is the size (number of tables) of the cache of the
+	// optimizer catalog objects of leased tables.
+	SQLOptTableCacheSize int
+
 	// SQLQueryCacheSize is the memory size (in bytes) of the query plan cache.
 	SQLQueryCacheSize int64
 
@@ -269,6 +291,11 @@ type Config struct {
 	// in a timely fashion, typically 30s after the server starts listening.
 	DelayedBootstrapFn func()
 
+	// RequestMeter, if set, is notified of the KV work done by the node's
+	// stores to serve requests, which allows attributing that work to
+	// keyspaces. See the storage/metering package.
+	RequestMeter metering.Meter
+
 	// EnableWebSessionAuthentication enables session-based authentication for
 	// the Admin API's HTTP endpoints.
 	EnableWebSessionAuthentication bool
@@ -349,6 +376,7 @@ func MakeConfig(ctx context.Context, st *cluster.Settings) Config {
 		CacheSize:                      DefaultCacheSize,
 		SQLMemoryPoolSize:              defaultSQLMemoryPoolSize,
 		SQLTableStatCacheSize:          defaultSQLTableStatCacheSize,
+		SQLOptTableCacheSize:           defaultSQLOptTableCacheSize,
 		SQLQueryCacheSize:              defaultSQLQueryCacheSize,
 		ScanInterval:                   defaultScanInterval,
 		ScanMinIdleTime:                defaultScanMinIdleTime,
@@ -501,6 +529,15 @@ func (cfg *Config) CreateEngines(ctx context.Context) (Engines, error) {
 				return Engines{}, err
 			}
 			engines = append(engines, eng)
+
+			if storeBallastSize > 0 {
+				ballastPath := filepath.Join(eng.GetAuxiliaryDir(), engine.BallastFileName)
+				if created, err := engine.EnsureBallast(ballastPath, storeBallastSize); err != nil {
+					log.Warningf(ctx, "store %d: unable to create ballast: %v", i, err)
+				} else if created {
+					details = append(details, fmt.Sprintf("store %d: created ballast at %s", i, ballastPath))
+				}
+			}
 		}
 	}
 
@@ -519,6 +556,23 @@ func (cfg *Config) CreateEngines(ctx context.Context) (Engines, error) {
 func (cfg *Config) InitNode() error {
 	cfg.readEnvironmentVariables()
 
+	// Apply the node-local setting overrides before anything reads them.
+	if overrides := envutil.EnvOrDefaultString("COCKROACH_SETTING_OVERRIDES", ""); overrides != "" {
+		parsed, err := settings.ParseNodeOverrides(overrides)
+		if err != nil {
+			return err
+		}
+		if cfg.SettingOverrides == nil {
+			cfg.SettingOverrides = make(map[string]string, len(parsed))
+		}
+		for k, v := range parsed {
+			cfg.SettingOverrides[k] = v
+		}
+	}
+	if err := cfg.Settings.SV.SetNodeOverrides(cfg.SettingOverrides); err != nil {
+		return err
+	}
+
 	// Initialize attributes.
 	cfg.NodeAttributes = parseAttributes(cfg.Attrs)
 
@@ -171,7 +171,7 @@ func (spy *logSpy) run(ctx context.Context, w io.Writer, opts logSpyOptions) (er
 			if dropped := atomic.LoadInt32(&countDropped); dropped > 0 {
 				f, l, _ := caller.Lookup(0)
 				entry := log.MakeEntry(
-					log.Severity_WARNING, timeutil.Now().UnixNano(), f, l,
+					ctx, log.Severity_WARNING, timeutil.Now().UnixNano(), f, l,
 					fmt.Sprintf("%d messages were dropped", dropped))
 				err = entry.Format(w) // modify return value
 			}
@@ -187,7 +187,7 @@ func (spy *logSpy) run(ctx context.Context, w io.Writer, opts logSpyOptions) (er
 	{
 		f, l, _ := caller.Lookup(0)
 		entry := log.MakeEntry(
-			log.Severity_INFO, timeutil.Now().UnixNano(), f, l,
+			ctx, log.Severity_INFO, timeutil.Now().UnixNano(), f, l,
 			fmt.Sprintf("intercepting logs with options %+v", opts))
 		entries <- entry
 	}
@@ -0,0 +1,88 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package server
+
+import (
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// decommissionProgressWindow is the period over which the rate at which
+// replicas move off a decommissioning node is computed.
+const decommissionProgressWindow = 10 * time.Minute
+
+type decommissionProgressSample struct {
+	at       time.Time
+	replicas int64
+}
+
+// decommissionProgress tracks the replica counts of decommissioning nodes
+// observed by successive DecommissionStatus calls in order to estimate when
+// they will have been fully drained of replicas. Clients waiting for a
+// decommissioning to complete poll the status periodically, which is what
+// provides the samples.
+type decommissionProgress struct {
+	mu struct {
+		syncutil.Mutex
+		samples map[roachpb.NodeID][]decommissionProgressSample
+	}
+}
+
+// record adds a replica count sample for the node and returns the rate, in
+// replicas per second, at which the count has decreased over the window, as
+// well as the estimated time at which it will reach zero. The returned time
+// is zero if no decrease has been observed.
+func (p *decommissionProgress) record(
+	nodeID roachpb.NodeID, now time.Time, replicas int64,
+) (rate float64, completion time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.mu.samples == nil {
+		p.mu.samples = make(map[roachpb.NodeID][]decommissionProgressSample)
+	}
+	samples := p.mu.samples[nodeID]
+	// A growing replica count means that the node was recommissioned in the
+	// meantime, or that it is receiving snapshots it was sent before it was
+	// marked as decommissioning. Either way the earlier samples don't tell us
+	// anything about the progress from here on.
+	if n := len(samples); n > 0 && samples[n-1].replicas < replicas {
+		samples = samples[:0]
+	}
+	samples = append(samples, decommissionProgressSample{at: now, replicas: replicas})
+	cutoff := now.Add(-decommissionProgressWindow)
+	for len(samples) > 1 && samples[0].at.Before(cutoff) {
+		samples = samples[1:]
+	}
+	p.mu.samples[nodeID] = samples
+
+	first := samples[0]
+	elapsed := now.Sub(first.at).Seconds()
+	if elapsed <= 0 || first.replicas <= replicas {
+		return 0, time.Time{}
+	}
+	rate = float64(first.replicas-replicas) / elapsed
+	remaining := time.Duration(float64(replicas) / rate * float64(time.Second))
+	return rate, now.Add(remaining)
+}
+
+// forget discards the samples for the node, which is called once it is no
+// longer decommissioning.
+func (p *decommissionProgress) forget(nodeID roachpb.NodeID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.mu.samples, nodeID)
+}
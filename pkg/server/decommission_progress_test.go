@@ -0,0 +1,61 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestDecommissionProgress(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var p decommissionProgress
+	start := time.Unix(1000, 0)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	testCases := []struct {
+		now        time.Time
+		replicas   int64
+		rate       float64
+		completion time.Time
+	}{
+		// The first sample doesn't allow any estimate.
+		{at(0), 100, 0, time.Time{}},
+		// 20 replicas moved in 10s, so the remaining 80 take another 40s.
+		{at(10 * time.Second), 80, 2, at(50 * time.Second)},
+		{at(20 * time.Second), 75, 1.25, at(80 * time.Second)},
+		// An increase discards the history.
+		{at(30 * time.Second), 90, 0, time.Time{}},
+		{at(40 * time.Second), 85, 0.5, at(210 * time.Second)},
+		// Samples older than the window are dropped.
+		{at(40*time.Second + decommissionProgressWindow), 85, 0, time.Time{}},
+		{at(50*time.Second + decommissionProgressWindow), 75, 1, at(125*time.Second + decommissionProgressWindow)},
+	}
+	for i, tc := range testCases {
+		rate, completion := p.record(1, tc.now, tc.replicas)
+		if rate != tc.rate || !completion.Equal(tc.completion) {
+			t.Errorf("%d: expected rate %.2f and completion %s, got %.2f and %s",
+				i, tc.rate, tc.completion, rate, completion)
+		}
+	}
+
+	p.forget(1)
+	if rate, _ := p.record(1, at(time.Hour), 10); rate != 0 {
+		t.Errorf("expected no rate after forgetting the node, got %.2f", rate)
+	}
+}
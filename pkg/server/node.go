@@ -29,6 +29,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/rpc"
 	"github.com/cockroachdb/cockroach/pkg/server/status"
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
@@ -407,6 +408,9 @@ func (n *Node) start(
 	// Start the closed timestamp subsystem.
 	n.storeCfg.ClosedTimestamp.Start(n.Descriptor.NodeID)
 
+	// Start polling the protected timestamp records.
+	n.storeCfg.ProtectedTimestampCache.Start(ctx, n.stopper)
+
 	// Create stores from the engines that were already bootstrapped.
 	for _, e := range initializedEngines {
 		s := storage.NewStore(ctx, n.storeCfg, e, &n.Descriptor)
@@ -944,6 +948,16 @@ func (n *Node) Batch(
 	// log tags more expensive and makes local calls differ from remote calls.
 	ctx = n.storeCfg.AmbientCtx.ResetAndAnnotateCtx(ctx)
 
+	// High priority batches bypass the proposal quota pool and jump ahead of
+	// waiting latch acquisitions, so only the nodes of the cluster are allowed
+	// to send them. Insecure clusters can't tell nodes from external clients
+	// and trust both alike.
+	if args.PriorityClass == roachpb.HIGH_PRIORITY &&
+		!n.storeCfg.RPCContext.Insecure && !rpc.IsNodeRequestContext(ctx) {
+		log.VEventf(ctx, 2, "ignoring high priority of batch from external client")
+		args.PriorityClass = roachpb.NORMAL_PRIORITY
+	}
+
 	br, err := n.batchInternal(ctx, args)
 
 	// We always return errors via BatchResponse.Error so structure is
@@ -68,7 +68,7 @@ func createTestNode(
 	stopper := stop.NewStopper()
 	nodeRPCContext := rpc.NewContext(
 		log.AmbientContext{Tracer: cfg.Settings.Tracer}, nodeTestBaseContext, cfg.Clock, stopper,
-		&cfg.Settings.Version)
+		cfg.Settings)
 	cfg.RPCContext = nodeRPCContext
 	cfg.ScanInterval = 10 * time.Hour
 	grpcServer := rpc.NewServer(nodeRPCContext)
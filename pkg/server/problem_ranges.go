@@ -35,6 +35,13 @@ func (s *statusServer) ProblemRanges(
 		ProblemsByNodeID: make(map[roachpb.NodeID]serverpb.ProblemRangesResponse_NodeProblems),
 	}
 
+	// The ranges are filtered by the nodes, so that they only send the
+	// information of the problem ranges.
+	filter := req.Problems
+	if filter.Empty() {
+		filter = serverpb.AllRangeProblems
+	}
+
 	isLiveMap := s.nodeLiveness.GetIsLiveMap()
 	// If there is a specific nodeID requested, limited the responses to
 	// just that node.
@@ -64,8 +71,12 @@ func (s *statusServer) ProblemRanges(
 				status, err := s.dialNode(ctx, nodeID)
 				var rangesResponse *serverpb.RangesResponse
 				if err == nil {
-					req := &serverpb.RangesRequest{}
-					rangesResponse, err = status.Ranges(ctx, req)
+					rangesReq := &serverpb.RangesRequest{
+						StartRangeID: req.StartRangeID,
+						Limit:        req.Limit,
+						Problems:     filter,
+					}
+					rangesResponse, err = status.Ranges(ctx, rangesReq)
 				}
 				response := nodeResponse{
 					nodeID: nodeID,
@@ -93,8 +104,11 @@ func (s *statusServer) ProblemRanges(
 				}
 				continue
 			}
-			var problems serverpb.ProblemRangesResponse_NodeProblems
+			problems := serverpb.ProblemRangesResponse_NodeProblems{
+				NextRangeID: resp.resp.NextRangeID,
+			}
 			for _, info := range resp.resp.Ranges {
+				info.Problems = info.Problems.Intersect(filter)
 				if len(info.ErrorMessage) != 0 {
 					response.ProblemsByNodeID[resp.nodeID] = serverpb.ProblemRangesResponse_NodeProblems{
 						ErrorMessage: info.ErrorMessage,
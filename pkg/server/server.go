@@ -57,11 +57,13 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
 	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+	"github.com/cockroachdb/cockroach/pkg/sql/stmtdiagnostics"
 	"github.com/cockroachdb/cockroach/pkg/sqlmigrations"
 	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/storage/bulk"
 	"github.com/cockroachdb/cockroach/pkg/storage/closedts/container"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
 	"github.com/cockroachdb/cockroach/pkg/ts"
 	"github.com/cockroachdb/cockroach/pkg/ui"
@@ -248,7 +250,7 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 	ctx := s.AnnotateCtx(context.Background())
 
 	s.rpcContext = rpc.NewContext(s.cfg.AmbientCtx, s.cfg.Config, s.clock, s.stopper,
-		&cfg.Settings.Version)
+		cfg.Settings)
 	s.rpcContext.HeartbeatCB = func() {
 		if err := s.rpcContext.RemoteClocks.VerifyClockOffset(ctx); err != nil {
 			log.Fatal(ctx, err)
@@ -349,6 +351,7 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 	s.raftTransport = storage.NewRaftTransport(
 		s.cfg.AmbientCtx, st, s.nodeDialer, s.grpc.Server, s.stopper,
 	)
+	s.registry.AddMetricStruct(s.raftTransport.Metrics())
 
 	// Set up internal memory metrics for use by internal SQL executors.
 	s.internalMemMetrics = sql.MakeMemMetrics("internal", cfg.HistogramWindowInterval())
@@ -440,6 +443,8 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 		LogRangeEvents:          s.cfg.EventLogEnabled,
 		RangeDescriptorCache:    s.distSender.RangeDescriptorCache(),
 		TimeSeriesDataStore:     s.tsDB,
+		RequestMeter:            s.cfg.RequestMeter,
+		ProtectedTimestampCache: protectedts.NewCache(s.db, st),
 
 		// Initialize the closed timestamp subsystem. Note that it won't
 		// be ready until it is .Start()ed, but the grpc server can be
@@ -567,8 +572,10 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 		s.storePool,
 		s.rpcContext,
 		s.node.stores,
+		s.raftTransport,
 		s.stopper,
 		s.sessionRegistry,
+		s.distSQLServer,
 	)
 	s.authentication = newAuthenticationServer(s)
 	for _, gw := range []grpcGatewayServer{s.admin, s.status, s.authentication, &s.tsServer} {
@@ -622,6 +629,7 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 		Clock:                   s.clock,
 		DistSQLSrv:              s.distSQLServer,
 		StatusServer:            s.status,
+		AdminServer:             s.admin,
 		SessionRegistry:         s.sessionRegistry,
 		JobRegistry:             s.jobRegistry,
 		VirtualSchemas:          virtualSchemas,
@@ -661,6 +669,10 @@ func NewServer(cfg Config, stopper *stop.Stopper) (*Server, error) {
 		),
 
 		QueryCache: querycache.New(s.cfg.SQLQueryCacheSize),
+
+		OptTableCache: sql.NewOptTableCache(s.cfg.SQLOptTableCacheSize),
+
+		StmtDiagnosticsRegistry: stmtdiagnostics.NewRegistry(s.db, s.clock, s.st),
 	}
 
 	if sqlSchemaChangerTestingKnobs := s.cfg.TestingKnobs.SQLSchemaChanger; sqlSchemaChangerTestingKnobs != nil {
@@ -1629,6 +1641,9 @@ func (s *Server) Start(ctx context.Context) error {
 		return err
 	}
 
+	// Start the background thread for refreshing statement diagnostics requests.
+	s.execCfg.StmtDiagnosticsRegistry.Start(ctx, s.stopper)
+
 	// Before serving SQL requests, we have to make sure the database is
 	// in an acceptable form for this version of the software.
 	// We have to do this after actually starting up the server to be able to
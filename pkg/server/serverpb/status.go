@@ -0,0 +1,48 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package serverpb
+
+// AllRangeProblems has all the range problem categories set.
+var AllRangeProblems = RangeProblems{
+	Unavailable:            true,
+	LeaderNotLeaseHolder:   true,
+	NoRaftLeader:           true,
+	Underreplicated:        true,
+	Overreplicated:         true,
+	NoLease:                true,
+	QuiescentEqualsTicking: true,
+	RaftLogTooLarge:        true,
+}
+
+// Empty returns whether none of the problems are set.
+func (p RangeProblems) Empty() bool {
+	return !p.Unavailable && !p.LeaderNotLeaseHolder && !p.NoRaftLeader &&
+		!p.Underreplicated && !p.Overreplicated && !p.NoLease &&
+		!p.QuiescentEqualsTicking && !p.RaftLogTooLarge
+}
+
+// Intersect returns the problems set in both p and o.
+func (p RangeProblems) Intersect(o RangeProblems) RangeProblems {
+	return RangeProblems{
+		Unavailable:            p.Unavailable && o.Unavailable,
+		LeaderNotLeaseHolder:   p.LeaderNotLeaseHolder && o.LeaderNotLeaseHolder,
+		NoRaftLeader:           p.NoRaftLeader && o.NoRaftLeader,
+		Underreplicated:        p.Underreplicated && o.Underreplicated,
+		Overreplicated:         p.Overreplicated && o.Overreplicated,
+		NoLease:                p.NoLease && o.NoLease,
+		QuiescentEqualsTicking: p.QuiescentEqualsTicking && o.QuiescentEqualsTicking,
+		RaftLogTooLarge:        p.RaftLogTooLarge && o.RaftLogTooLarge,
+	}
+}
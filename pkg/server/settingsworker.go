@@ -27,6 +27,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
 	"github.com/pkg/errors"
 )
 
@@ -107,6 +108,14 @@ func (s *Server) refreshSettings() {
 	}
 
 	ctx := s.AnnotateCtx(context.Background())
+	// Log every change applied to this node's settings, so that the effect of
+	// a SET CLUSTER SETTING (recorded in the event log) can be traced on each
+	// node.
+	unsubscribe := s.st.SV.Subscribe(func(c settings.SettingChange) {
+		c = c.Sanitized()
+		log.Infof(ctx, "cluster setting %s changed from %q to %q", c.Key, c.OldValue, c.NewValue)
+	})
+	s.stopper.AddCloser(stop.CloserFn(unsubscribe))
 	s.stopper.RunWorker(ctx, func(ctx context.Context) {
 		gossipUpdateC := s.gossip.RegisterSystemConfigChannel()
 		// No new settings can be defined beyond this point.
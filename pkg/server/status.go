@@ -32,6 +32,7 @@ import (
 	"regexp"
 	"runtime"
 	"runtime/pprof"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -51,6 +52,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlrun"
 	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
@@ -136,8 +138,10 @@ type statusServer struct {
 	storePool       *storage.StorePool
 	rpcCtx          *rpc.Context
 	stores          *storage.Stores
+	raftTransport   *storage.RaftTransport
 	stopper         *stop.Stopper
 	sessionRegistry *sql.SessionRegistry
+	distSQLServer   *distsqlrun.ServerImpl
 	si              systemInfoOnce
 }
 
@@ -154,8 +158,10 @@ func newStatusServer(
 	storePool *storage.StorePool,
 	rpcCtx *rpc.Context,
 	stores *storage.Stores,
+	raftTransport *storage.RaftTransport,
 	stopper *stop.Stopper,
 	sessionRegistry *sql.SessionRegistry,
+	distSQLServer *distsqlrun.ServerImpl,
 ) *statusServer {
 	ambient.AddLogTag("status", nil)
 	server := &statusServer{
@@ -170,8 +176,10 @@ func newStatusServer(
 		storePool:       storePool,
 		rpcCtx:          rpcCtx,
 		stores:          stores,
+		raftTransport:   raftTransport,
 		stopper:         stopper,
 		sessionRegistry: sessionRegistry,
+		distSQLServer:   distSQLServer,
 	}
 
 	return server
@@ -814,6 +822,9 @@ func parseInt64WithDefault(s string, defaultValue int64) (int64, error) {
 //   pattern if it exists. Defaults to nil.
 // * "max" query parameter is the hard limit of the number of returned log
 //   entries. Defaults to defaultMaxLogEntries.
+// * "range_id" query parameter filters the log entries to the ones tagged with
+//   the given range ID, which allows assembling a timeline of the events of a
+//   range on the node. Defaults to all entries.
 // To filter the log messages to only retrieve messages from a given level,
 // use a pattern that excludes all messages at the undesired levels.
 // (e.g. "^[^IW]" to only get errors, fatals and panics). An exclusive
@@ -873,7 +884,21 @@ func (s *statusServer) Logs(
 		}
 	}
 
-	entries, err := log.FetchEntriesFromFiles(startTimestamp, endTimestamp, int(maxEntries), regex)
+	rangeID, err := parseInt64WithDefault(req.RangeId, 0)
+	if err != nil {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "RangeID could not be parsed: %s", err)
+	}
+	if rangeID < 0 {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "RangeID: %d should not be negative", rangeID)
+	}
+
+	var entries []log.Entry
+	if rangeID != 0 {
+		entries, err = log.FetchRangeEntriesFromFiles(
+			rangeID, startTimestamp, endTimestamp, int(maxEntries), regex)
+	} else {
+		entries, err = log.FetchEntriesFromFiles(startTimestamp, endTimestamp, int(maxEntries), regex)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -1187,6 +1212,9 @@ func (s *statusServer) Ranges(
 	output := serverpb.RangesResponse{
 		Ranges: make([]serverpb.RangeInfo, 0, s.stores.GetStoreCount()),
 	}
+	if req.Limit < 0 {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "invalid limit %d", req.Limit)
+	}
 
 	convertRaftStatus := func(raftStatus *raft.Status) serverpb.RaftState {
 		if raftStatus == nil {
@@ -1260,19 +1288,29 @@ func (s *statusServer) Ranges(
 				QuiescentEqualsTicking: raftStatus != nil && metrics.Quiescent == metrics.Ticking,
 				RaftLogTooLarge:        metrics.RaftLogTooLarge,
 			},
-			LatchesLocal:  metrics.LatchInfoLocal,
-			LatchesGlobal: metrics.LatchInfoGlobal,
-			LeaseStatus:   metrics.LeaseStatus,
-			Quiescent:     metrics.Quiescent,
-			Ticking:       metrics.Ticking,
+			LatchesLocal:    metrics.LatchInfoLocal,
+			LatchesGlobal:   metrics.LatchInfoGlobal,
+			LeaseStatus:     metrics.LeaseStatus,
+			Quiescent:       metrics.Quiescent,
+			Ticking:         metrics.Ticking,
+			UnquiesceReason: metrics.UnquiesceReason,
+			RaftTicks:       metrics.RaftTicks,
 		}
 	}
 
 	isLiveMap := s.nodeLiveness.GetIsLiveMap()
 	clusterNodes := s.storePool.ClusterNodeCount()
 
+	// Collect the replicas first, which is cheap, so that the pages can be
+	// built in range ID order and the RangeInfos are only constructed for the
+	// ranges that are returned.
+	type candidate struct {
+		desc  roachpb.RangeDescriptor
+		rep   *storage.Replica
+		store *storage.Store
+	}
+	var candidates []candidate
 	err = s.stores.VisitStores(func(store *storage.Store) error {
-		timestamp := store.Clock().Now()
 		if len(req.RangeIDs) == 0 {
 			// All ranges requested.
 
@@ -1280,6 +1318,9 @@ func (s *statusServer) Ranges(
 			// because it's already exported.
 			err := storage.IterateRangeDescriptors(ctx, store.Engine(),
 				func(desc roachpb.RangeDescriptor) (bool, error) {
+					if desc.RangeID < req.StartRangeID {
+						return false, nil
+					}
 					rep, err := store.GetReplica(desc.RangeID)
 					if _, skip := err.(*roachpb.RangeNotFoundError); skip {
 						return true, nil // continue
@@ -1287,13 +1328,7 @@ func (s *statusServer) Ranges(
 					if err != nil {
 						return true, err
 					}
-					output.Ranges = append(output.Ranges,
-						constructRangeInfo(
-							desc,
-							rep,
-							store.Ident.StoreID,
-							rep.Metrics(ctx, timestamp, isLiveMap, clusterNodes),
-						))
+					candidates = append(candidates, candidate{desc: desc, rep: rep, store: store})
 					return false, nil
 				})
 			return err
@@ -1301,25 +1336,49 @@ func (s *statusServer) Ranges(
 
 		// Specific ranges requested:
 		for _, rid := range req.RangeIDs {
+			if rid < req.StartRangeID {
+				continue
+			}
 			rep, err := store.GetReplica(rid)
 			if err != nil {
 				// Not found: continue.
 				continue
 			}
-			desc := rep.Desc()
-			output.Ranges = append(output.Ranges,
-				constructRangeInfo(
-					*desc,
-					rep,
-					store.Ident.StoreID,
-					rep.Metrics(ctx, timestamp, isLiveMap, clusterNodes),
-				))
+			candidates = append(candidates, candidate{desc: *rep.Desc(), rep: rep, store: store})
 		}
 		return nil
 	})
 	if err != nil {
 		return nil, grpcstatus.Errorf(codes.Internal, err.Error())
 	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].desc.RangeID != candidates[j].desc.RangeID {
+			return candidates[i].desc.RangeID < candidates[j].desc.RangeID
+		}
+		return candidates[i].store.StoreID() < candidates[j].store.StoreID()
+	})
+
+	var lastRangeID roachpb.RangeID
+	for _, c := range candidates {
+		// The replicas of a range on multiple stores of the node, if any, are
+		// returned in the same page.
+		if req.Limit > 0 && len(output.Ranges) >= int(req.Limit) && c.desc.RangeID != lastRangeID {
+			output.NextRangeID = c.desc.RangeID
+			break
+		}
+		timestamp := c.store.Clock().Now()
+		info := constructRangeInfo(
+			c.desc,
+			c.rep,
+			c.store.Ident.StoreID,
+			c.rep.Metrics(ctx, timestamp, isLiveMap, clusterNodes),
+		)
+		if !req.Problems.Empty() && info.Problems.Intersect(req.Problems).Empty() {
+			continue
+		}
+		output.Ranges = append(output.Ranges, info)
+		lastRangeID = c.desc.RangeID
+	}
 	return &output, nil
 }
 
@@ -1343,7 +1402,7 @@ func (s *statusServer) HotRanges(
 
 		// Only hot ranges from the local node.
 		if local {
-			response.HotRangesByNodeID[requestedNodeID] = s.localHotRanges(ctx)
+			response.HotRangesByNodeID[requestedNodeID] = s.localHotRanges(ctx, req)
 			return response, nil
 		}
 
@@ -1360,7 +1419,11 @@ func (s *statusServer) HotRanges(
 		client, err := s.dialNode(ctx, nodeID)
 		return client, err
 	}
-	remoteRequest := serverpb.HotRangesRequest{NodeID: "local"}
+	remoteRequest := serverpb.HotRangesRequest{
+		NodeID:              "local",
+		Limit:               req.Limit,
+		MinQueriesPerSecond: req.MinQueriesPerSecond,
+	}
 	nodeFn := func(ctx context.Context, client interface{}, _ roachpb.NodeID) (interface{}, error) {
 		status := client.(serverpb.StatusClient)
 		return status.HotRanges(ctx, &remoteRequest)
@@ -1382,11 +1445,21 @@ func (s *statusServer) HotRanges(
 	return response, nil
 }
 
-func (s *statusServer) localHotRanges(ctx context.Context) serverpb.HotRangesResponse_NodeResponse {
+func (s *statusServer) localHotRanges(
+	ctx context.Context, req *serverpb.HotRangesRequest,
+) serverpb.HotRangesResponse_NodeResponse {
 	var resp serverpb.HotRangesResponse_NodeResponse
 	includeRawKeys := debug.GatewayRemoteAllowed(ctx, s.st)
 	err := s.stores.VisitStores(func(store *storage.Store) error {
 		ranges := store.HottestReplicas()
+		// The ranges are sorted by QPS, so the filtered ranges are a prefix.
+		n := sort.Search(len(ranges), func(i int) bool {
+			return ranges[i].QPS < req.MinQueriesPerSecond
+		})
+		if req.Limit > 0 && n > int(req.Limit) {
+			n = int(req.Limit)
+		}
+		ranges = ranges[:n]
 		storeResp := &serverpb.HotRangesResponse_StoreResponse{
 			StoreID:   store.StoreID(),
 			HotRanges: make([]serverpb.HotRangesResponse_HotRange, len(ranges)),
@@ -1623,6 +1696,73 @@ func (s *statusServer) ListSessions(
 	return response, nil
 }
 
+// ListLocalDistSQLFlows returns a list of the remote DistSQL flows running on
+// this node.
+func (s *statusServer) ListLocalDistSQLFlows(
+	ctx context.Context, req *serverpb.ListDistSQLFlowsRequest,
+) (*serverpb.ListDistSQLFlowsResponse, error) {
+	ctx = propagateGatewayMetadata(ctx)
+	if !debug.GatewayRemoteAllowed(ctx, s.st) {
+		return nil, remoteDebuggingErr
+	}
+
+	nodeID := s.gossip.NodeID.Get()
+	infos := s.distSQLServer.ListFlows()
+	flows := make([]serverpb.DistSQLFlow, len(infos))
+	for i, info := range infos {
+		flows[i] = serverpb.DistSQLFlow{
+			FlowID:        info.FlowID.UUID,
+			NodeID:        nodeID,
+			GatewayNodeID: info.Gateway,
+			Start:         info.Start,
+			Processors:    info.Processors,
+			AllocBytes:    info.AllocBytes,
+		}
+	}
+	return &serverpb.ListDistSQLFlowsResponse{Flows: flows}, nil
+}
+
+// ListDistSQLFlows returns a list of the remote DistSQL flows running on all
+// nodes in the cluster.
+func (s *statusServer) ListDistSQLFlows(
+	ctx context.Context, req *serverpb.ListDistSQLFlowsRequest,
+) (*serverpb.ListDistSQLFlowsResponse, error) {
+	ctx = propagateGatewayMetadata(ctx)
+	if !debug.GatewayRemoteAllowed(ctx, s.st) {
+		return nil, remoteDebuggingErr
+	}
+
+	ctx = s.AnnotateCtx(ctx)
+
+	response := &serverpb.ListDistSQLFlowsResponse{
+		Flows:  make([]serverpb.DistSQLFlow, 0),
+		Errors: make([]serverpb.ListDistSQLFlowsError, 0),
+	}
+
+	dialFn := func(ctx context.Context, nodeID roachpb.NodeID) (interface{}, error) {
+		client, err := s.dialNode(ctx, nodeID)
+		return client, err
+	}
+	nodeFn := func(ctx context.Context, client interface{}, _ roachpb.NodeID) (interface{}, error) {
+		status := client.(serverpb.StatusClient)
+		return status.ListLocalDistSQLFlows(ctx, req)
+	}
+	responseFn := func(_ roachpb.NodeID, nodeResp interface{}) {
+		flows := nodeResp.(*serverpb.ListDistSQLFlowsResponse)
+		response.Flows = append(response.Flows, flows.Flows...)
+	}
+	errorFn := func(nodeID roachpb.NodeID, err error) {
+		errResponse := serverpb.ListDistSQLFlowsError{NodeID: nodeID, Message: err.Error()}
+		response.Errors = append(response.Errors, errResponse)
+	}
+
+	if err := s.iterateNodes(ctx, "distsql flow list", dialFn, nodeFn, responseFn, errorFn); err != nil {
+		err := serverpb.ListDistSQLFlowsError{Message: err.Error()}
+		response.Errors = append(response.Errors, err)
+	}
+	return response, nil
+}
+
 // CancelSession responds to a session cancellation request by canceling the
 // target session's associated context.
 func (s *statusServer) CancelSession(
@@ -1797,6 +1937,329 @@ func (s *statusServer) Stores(
 	return resp, nil
 }
 
+// RaftTransportStats returns the statistics of the outgoing Raft message
+// queues of the given node to each of its peers.
+func (s *statusServer) RaftTransportStats(
+	ctx context.Context, req *serverpb.RaftTransportStatsRequest,
+) (*serverpb.RaftTransportStatsResponse, error) {
+	ctx = propagateGatewayMetadata(ctx)
+	ctx = s.AnnotateCtx(ctx)
+	nodeID, local, err := s.parseNodeID(req.NodeId)
+	if err != nil {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if !local {
+		status, err := s.dialNode(ctx, nodeID)
+		if err != nil {
+			return nil, err
+		}
+		return status.RaftTransportStats(ctx, req)
+	}
+
+	resp := &serverpb.RaftTransportStatsResponse{}
+	for _, ps := range s.raftTransport.PeerStats() {
+		resp.Peers = append(resp.Peers, serverpb.RaftTransportPeerStats{
+			NodeID:           ps.NodeID,
+			QueueLength:      ps.QueueLen,
+			QueueBytes:       ps.QueueBytes,
+			QueueMax:         ps.QueueMax,
+			ClientSent:       ps.ClientSent,
+			ClientRecv:       ps.ClientRecv,
+			ServerSent:       ps.ServerSent,
+			ServerRecv:       ps.ServerRecv,
+			DroppedApp:       ps.DroppedApp,
+			DroppedAppResp:   ps.DroppedAppResp,
+			DroppedVote:      ps.DroppedVote,
+			DroppedHeartbeat: ps.DroppedHeartbeat,
+			DroppedOther:     ps.DroppedOther,
+		})
+	}
+	return resp, nil
+}
+
+// TxnWaits returns the pushes waiting in the txn wait queues of the replicas
+// on the given node, which are the edges of the waits-for graph between
+// transactions. This allows blocked transactions to be inspected before they
+// are aborted by deadlock detection or their pushers time out.
+func (s *statusServer) TxnWaits(
+	ctx context.Context, req *serverpb.TxnWaitsRequest,
+) (*serverpb.TxnWaitsResponse, error) {
+	ctx = propagateGatewayMetadata(ctx)
+	ctx = s.AnnotateCtx(ctx)
+	nodeID, local, err := s.parseNodeID(req.NodeId)
+	if err != nil {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if !local {
+		status, err := s.dialNode(ctx, nodeID)
+		if err != nil {
+			return nil, err
+		}
+		return status.TxnWaits(ctx, req)
+	}
+
+	resp := &serverpb.TxnWaitsResponse{}
+	err = s.stores.VisitStores(func(store *storage.Store) error {
+		store.VisitReplicas(func(repl *storage.Replica) bool {
+			for _, push := range repl.GetTxnWaitQueue().WaitingPushes() {
+				resp.Waits = append(resp.Waits, serverpb.TxnWait{
+					PusherID:      push.Pusher,
+					PusheeID:      push.Pushee,
+					Key:           push.Key,
+					RangeID:       repl.RangeID,
+					PushType:      push.PushType,
+					DurationNanos: push.Duration.Nanoseconds(),
+				})
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ConsistencyTriageBundles lists the triage bundles collected by the stores
+// on the given node when the consistency checker found the replicas of a
+// range to be inconsistent. The bundles are kept in the auxiliary directories
+// of the stores, where they can be retrieved from after the node has been
+// terminated.
+func (s *statusServer) ConsistencyTriageBundles(
+	ctx context.Context, req *serverpb.ConsistencyTriageBundlesRequest,
+) (*serverpb.ConsistencyTriageBundlesResponse, error) {
+	ctx = propagateGatewayMetadata(ctx)
+	ctx = s.AnnotateCtx(ctx)
+	nodeID, local, err := s.parseNodeID(req.NodeId)
+	if err != nil {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if !local {
+		status, err := s.dialNode(ctx, nodeID)
+		if err != nil {
+			return nil, err
+		}
+		return status.ConsistencyTriageBundles(ctx, req)
+	}
+
+	resp := &serverpb.ConsistencyTriageBundlesResponse{}
+	err = s.stores.VisitStores(func(store *storage.Store) error {
+		bundles, err := store.ConsistencyTriageBundles()
+		if err != nil {
+			return err
+		}
+		for _, bundle := range bundles {
+			resp.Bundles = append(resp.Bundles, serverpb.ConsistencyTriageBundle{
+				StoreID: store.Ident.StoreID,
+				RangeID: bundle.RangeID,
+				Dir:     bundle.Dir,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// defaultClosedTimestampLagLimit is the number of ranges returned by
+// ClosedTimestampLag when the request doesn't specify a limit.
+const defaultClosedTimestampLagLimit = 20
+
+// ClosedTimestampLag returns the replicas on the given node whose closed
+// timestamps trail the current time the most, so that regressions of the
+// availability of follower reads can be tracked down to individual ranges.
+func (s *statusServer) ClosedTimestampLag(
+	ctx context.Context, req *serverpb.ClosedTimestampLagRequest,
+) (*serverpb.ClosedTimestampLagResponse, error) {
+	ctx = propagateGatewayMetadata(ctx)
+	ctx = s.AnnotateCtx(ctx)
+	nodeID, local, err := s.parseNodeID(req.NodeId)
+	if err != nil {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if !local {
+		status, err := s.dialNode(ctx, nodeID)
+		if err != nil {
+			return nil, err
+		}
+		return status.ClosedTimestampLag(ctx, req)
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultClosedTimestampLagLimit
+	}
+	resp := &serverpb.ClosedTimestampLagResponse{
+		AlertThresholdNanos: storage.ClosedTimestampLagAlertThreshold.Get(&s.st.SV).Nanoseconds(),
+	}
+	err = s.stores.VisitStores(func(store *storage.Store) error {
+		for _, lag := range store.ClosedTimestampLaggingRanges(ctx, limit) {
+			resp.Ranges = append(resp.Ranges, serverpb.RangeClosedTimestampLag{
+				RangeID:         lag.RangeID,
+				StoreID:         store.Ident.StoreID,
+				ClosedTimestamp: lag.ClosedTimestamp,
+				LagNanos:        lag.Lag.Nanoseconds(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(resp.Ranges, func(i, j int) bool {
+		return resp.Ranges[i].LagNanos > resp.Ranges[j].LagNanos
+	})
+	if len(resp.Ranges) > limit {
+		resp.Ranges = resp.Ranges[:limit]
+	}
+	return resp, nil
+}
+
+const (
+	// defaultHotKeysRangeLimit is the number of ranges returned by HotKeys
+	// when the request doesn't specify a limit.
+	defaultHotKeysRangeLimit = 20
+	// defaultHotKeysPerRange is the number of keys returned for each range by
+	// HotKeys when the request doesn't specify a limit.
+	defaultHotKeysPerRange = 10
+)
+
+// HotKeys returns the keys of the replicas on the given node with the highest
+// estimated request rates, so that the rows causing contention can be
+// identified.
+func (s *statusServer) HotKeys(
+	ctx context.Context, req *serverpb.HotKeysRequest,
+) (*serverpb.HotKeysResponse, error) {
+	ctx = propagateGatewayMetadata(ctx)
+	ctx = s.AnnotateCtx(ctx)
+	nodeID, local, err := s.parseNodeID(req.NodeId)
+	if err != nil {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, err.Error())
+	}
+	if req.RangeId < 0 {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, "invalid range ID %d", req.RangeId)
+	}
+
+	if !local {
+		status, err := s.dialNode(ctx, nodeID)
+		if err != nil {
+			return nil, err
+		}
+		return status.HotKeys(ctx, req)
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultHotKeysRangeLimit
+	}
+	keysPerRange := int(req.KeysPerRange)
+	if keysPerRange <= 0 {
+		keysPerRange = defaultHotKeysPerRange
+	}
+	resp := &serverpb.HotKeysResponse{}
+	err = s.stores.VisitStores(func(store *storage.Store) error {
+		var ranges []storage.RangeHotKeys
+		if req.RangeId != 0 {
+			repl, err := store.GetReplica(roachpb.RangeID(req.RangeId))
+			if err != nil {
+				if _, skip := err.(*roachpb.RangeNotFoundError); skip {
+					return nil
+				}
+				return err
+			}
+			if hk := repl.HotKeys(timeutil.Now(), keysPerRange); len(hk.Keys) > 0 {
+				ranges = append(ranges, hk)
+			}
+		} else {
+			ranges = store.HotKeys(limit, keysPerRange)
+		}
+		for _, r := range ranges {
+			rangeHotKeys := serverpb.RangeHotKeys{
+				RangeID: r.RangeID,
+				StoreID: store.Ident.StoreID,
+				QPS:     r.QPS,
+			}
+			for _, k := range r.Keys {
+				hotKey := serverpb.HotKey{
+					Key:       k.Key,
+					PrettyKey: k.Key.String(),
+					QPS:       k.QPS,
+				}
+				for _, m := range k.Methods {
+					hotKey.Methods = append(hotKey.Methods, serverpb.HotKeyMethod{
+						Method: m.Method.String(),
+						QPS:    m.QPS,
+					})
+				}
+				rangeHotKeys.Keys = append(rangeHotKeys.Keys, hotKey)
+			}
+			resp.Ranges = append(resp.Ranges, rangeHotKeys)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(resp.Ranges, func(i, j int) bool {
+		return resp.Ranges[i].QPS > resp.Ranges[j].QPS
+	})
+	if len(resp.Ranges) > limit {
+		resp.Ranges = resp.Ranges[:limit]
+	}
+	return resp, nil
+}
+
+// LSMHealth returns the shape of the log-structured merge-trees of the engines
+// of the stores of a node.
+func (s *statusServer) LSMHealth(
+	ctx context.Context, req *serverpb.LSMHealthRequest,
+) (*serverpb.LSMHealthResponse, error) {
+	ctx = propagateGatewayMetadata(ctx)
+	ctx = s.AnnotateCtx(ctx)
+	nodeID, local, err := s.parseNodeID(req.NodeId)
+	if err != nil {
+		return nil, grpcstatus.Errorf(codes.InvalidArgument, err.Error())
+	}
+
+	if !local {
+		status, err := s.dialNode(ctx, nodeID)
+		if err != nil {
+			return nil, err
+		}
+		return status.LSMHealth(ctx, req)
+	}
+
+	resp := &serverpb.LSMHealthResponse{}
+	err = s.stores.VisitStores(func(store *storage.Store) error {
+		health, err := store.Engine().GetLSMHealth()
+		if err != nil {
+			return err
+		}
+		resp.Stores = append(resp.Stores, serverpb.StoreLSMHealth{
+			StoreID:                        store.Ident.StoreID,
+			ReadAmplification:              health.ReadAmplification,
+			WriteAmplification:             health.WriteAmplification,
+			L0FileCount:                    health.L0FileCount,
+			L0Sublevels:                    health.L0Sublevels,
+			PendingCompactionBytesEstimate: health.PendingCompactionBytesEstimate,
+			IngestDelayNanos:               engine.IngestDelay(store.ClusterSettings(), &health).Nanoseconds(),
+			Overloaded:                     store.IsOverloaded(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 // jsonWrapper provides a wrapper on any slice data type being
 // marshaled to JSON. This prevents a security vulnerability
 // where a phishing attack can trick a user's browser into
@@ -396,6 +396,8 @@ func (mr *MetricsRecorder) getNetworkActivity(
 				stats := tp.(*rpc.Stats)
 				na.Incoming = stats.Incoming()
 				na.Outgoing = stats.Outgoing()
+				na.HeartbeatFailures = stats.HeartbeatFailures()
+				na.DistSQLFlowStreams = stats.DistSQLFlowStreams()
 			}
 			if entry.IsLive {
 				if latency, ok := currentAverages[key]; ok {
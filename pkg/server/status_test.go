@@ -33,6 +33,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/build"
 	"github.com/cockroachdb/cockroach/pkg/gossip"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/rpc"
@@ -50,6 +51,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/httputil"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/log/logtags"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
@@ -249,7 +251,7 @@ func startServer(t *testing.T) *TestServer {
 func newRPCTestContext(ts *TestServer, cfg *base.Config) *rpc.Context {
 	rpcContext := rpc.NewContext(
 		log.AmbientContext{Tracer: ts.ClusterSettings().Tracer}, cfg, ts.Clock(), ts.Stopper(),
-		&ts.ClusterSettings().Version)
+		ts.ClusterSettings())
 	// Ensure that the RPC client context validates the server cluster ID.
 	// This ensures that a test where the server is restarted will not let
 	// its test RPC client talk to a server started by an unrelated concurrent test.
@@ -527,6 +529,44 @@ func TestStatusLocalLogs(t *testing.T) {
 	}
 }
 
+// TestStatusLocalLogsByRange verifies that log entries can be retrieved by the
+// range they were logged for.
+func TestStatusLocalLogsByRange(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	if log.V(3) {
+		t.Skip("Test only works with low verbosity levels")
+	}
+
+	s := log.ScopeWithoutShowLogs(t)
+	defer s.Close(t)
+
+	ts := startServer(t)
+	defer ts.Stopper().Stop(context.TODO())
+
+	ctx := logtags.AddTag(context.Background(), "s", 1)
+	log.Infof(logtags.AddTag(ctx, "r", "12345/1:/M{in-ax}"), "TestStatusLocalLogsByRange r12345")
+	log.Infof(logtags.AddTag(ctx, "r", "12346/1:/M{in-ax}"), "TestStatusLocalLogsByRange r12346")
+	log.Infof(context.Background(), "TestStatusLocalLogsByRange untagged")
+
+	var wrapper serverpb.LogEntriesResponse
+	if err := getStatusJSONProto(ts, "logs/local?range_id=12345", &wrapper); err != nil {
+		t.Fatal(err)
+	}
+	if len(wrapper.Entries) != 1 {
+		t.Fatalf("expected a single entry, got %+v", wrapper.Entries)
+	}
+	if e := wrapper.Entries[0]; e.StoreID != 1 || e.RangeID != 12345 ||
+		!strings.HasSuffix(e.Message, "TestStatusLocalLogsByRange r12345") {
+		t.Fatalf("unexpected entry %+v", e)
+	}
+
+	if err := getStatusJSONProto(ts, "logs/local?range_id=-1", &wrapper); !testutils.IsError(
+		err, "400 Bad Request",
+	) {
+		t.Fatalf("expected an error for a negative range ID, got %v", err)
+	}
+}
+
 // TestNodeStatusResponse verifies that node status returns the expected
 // results.
 func TestNodeStatusResponse(t *testing.T) {
@@ -719,6 +759,63 @@ func TestRangesResponse(t *testing.T) {
 	}
 }
 
+func TestRangesResponsePagination(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ts := startServer(t)
+	defer ts.Stopper().Stop(context.TODO())
+
+	var all serverpb.RangesResponse
+	if err := getStatusJSONProto(ts, "ranges/local", &all); err != nil {
+		t.Fatal(err)
+	}
+	if all.NextRangeID != 0 {
+		t.Errorf("expected no next page without a limit, got cursor r%d", all.NextRangeID)
+	}
+	var expected []roachpb.RangeID
+	for _, ri := range all.Ranges {
+		expected = append(expected, ri.State.Desc.RangeID)
+	}
+	if !sort.SliceIsSorted(expected, func(i, j int) bool { return expected[i] < expected[j] }) {
+		t.Fatalf("expected ranges sorted by range ID, got %v", expected)
+	}
+
+	// Page through the ranges, two at a time.
+	var paged []roachpb.RangeID
+	var cursor roachpb.RangeID
+	for {
+		var page serverpb.RangesResponse
+		if err := getStatusJSONProto(
+			ts, fmt.Sprintf("ranges/local?limit=2&start_range_id=%d", cursor), &page,
+		); err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Ranges) > 2 {
+			t.Fatalf("expected at most 2 ranges per page, got %d", len(page.Ranges))
+		}
+		for _, ri := range page.Ranges {
+			paged = append(paged, ri.State.Desc.RangeID)
+		}
+		if page.NextRangeID == 0 {
+			break
+		}
+		cursor = page.NextRangeID
+	}
+	if !reflect.DeepEqual(expected, paged) {
+		t.Errorf("expected paged ranges %v, got %v", expected, paged)
+	}
+
+	// None of the ranges of a healthy single-node cluster are unavailable.
+	var filtered serverpb.RangesResponse
+	if err := getStatusJSONProto(
+		ts, "ranges/local?problems.unavailable=true", &filtered,
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(filtered.Ranges) != 0 {
+		t.Errorf("expected no unavailable ranges, got %d", len(filtered.Ranges))
+	}
+}
+
 func TestRaftDebug(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	s := startServer(t)
@@ -1042,6 +1139,8 @@ func TestRemoteDebugModeSetting(t *testing.T) {
 			{"logfiles/local/cockroach.log", &serverpb.LogEntriesResponse{}},
 			{"local_sessions", &serverpb.ListSessionsResponse{}},
 			{"sessions", &serverpb.ListSessionsResponse{}},
+			{"local_distsql_flows", &serverpb.ListDistSQLFlowsResponse{}},
+			{"distsql_flows", &serverpb.ListDistSQLFlowsResponse{}},
 		} {
 			err := getStatusJSONProto(ts, tc.path, tc.response)
 			if !testutils.IsError(err, "403 Forbidden") {
@@ -1220,6 +1319,229 @@ func TestStatusAPIStatements(t *testing.T) {
 	}
 }
 
+func TestStatusAPIRaftTransportStats(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testCluster := serverutils.StartTestCluster(t, 3, base.TestClusterArgs{})
+	defer testCluster.Stopper().Stop(context.Background())
+
+	firstServer := testCluster.Server(0)
+
+	// Query the local node as well as a remote one, which requires the request
+	// to be forwarded. Each node exchanges Raft messages with both of its peers
+	// since all ranges are replicated three ways.
+	for _, nodeID := range []roachpb.NodeID{firstServer.NodeID(), testCluster.Server(1).NodeID()} {
+		testutils.SucceedsSoon(t, func() error {
+			var resp serverpb.RaftTransportStatsResponse
+			if err := getStatusJSONProto(firstServer, "raft_transport/"+nodeID.String(), &resp); err != nil {
+				return err
+			}
+			var peers []roachpb.NodeID
+			for _, peer := range resp.Peers {
+				if peer.ClientSent == 0 {
+					return errors.Errorf("n%d: no messages sent to n%d yet", nodeID, peer.NodeID)
+				}
+				peers = append(peers, peer.NodeID)
+			}
+			if len(peers) != 2 {
+				return errors.Errorf("n%d: expected stats for two peers, got %v", nodeID, peers)
+			}
+			for _, peer := range peers {
+				if peer == nodeID {
+					return errors.Errorf("n%d: unexpected stats for the node itself", nodeID)
+				}
+			}
+			return nil
+		})
+	}
+}
+
+func TestStatusAPITxnWaits(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, _, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+	ctx := context.Background()
+
+	// Write an intent with one transaction and have a second one block on it,
+	// which makes it push the first.
+	key := roachpb.Key("a")
+	pushee := client.NewTxn(ctx, kvDB, s.NodeID(), client.RootTxn)
+	if err := pushee.Put(ctx, key, "pushee"); err != nil {
+		t.Fatal(err)
+	}
+	pusher := client.NewTxn(ctx, kvDB, s.NodeID(), client.RootTxn)
+	errCh := make(chan error, 1)
+	go func() {
+		if err := pusher.Put(ctx, key, "pusher"); err != nil {
+			errCh <- err
+			return
+		}
+		errCh <- pusher.Commit(ctx)
+	}()
+
+	testutils.SucceedsSoon(t, func() error {
+		var resp serverpb.TxnWaitsResponse
+		if err := getStatusJSONProto(s, "txn_waits/local", &resp); err != nil {
+			return err
+		}
+		for _, wait := range resp.Waits {
+			if wait.PusherID == pusher.ID() && wait.PusheeID == pushee.ID() {
+				if !wait.Key.Equal(key) {
+					return errors.Errorf("expected pushee's txn record key %s, got %s", key, wait.Key)
+				}
+				return nil
+			}
+		}
+		return errors.Errorf("waiting push not found in %+v", resp.Waits)
+	})
+
+	if err := pushee.Commit(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStatusAPIConsistencyTriageBundles(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+
+	// A healthy cluster has no bundles to list.
+	var resp serverpb.ConsistencyTriageBundlesResponse
+	if err := getStatusJSONProto(s, "consistency_triage/local", &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Bundles) != 0 {
+		t.Fatalf("expected no triage bundles, got %+v", resp.Bundles)
+	}
+}
+
+func TestStatusAPIClosedTimestampLag(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+
+	testutils.SucceedsSoon(t, func() error {
+		var resp serverpb.ClosedTimestampLagResponse
+		if err := getStatusJSONProto(s, "closed_timestamp_lag/local?limit=2", &resp); err != nil {
+			return err
+		}
+		if resp.AlertThresholdNanos != storage.ClosedTimestampLagAlertThreshold.Get(&s.ClusterSettings().SV).Nanoseconds() {
+			return errors.Errorf("unexpected alert threshold %d", resp.AlertThresholdNanos)
+		}
+		if len(resp.Ranges) != 2 {
+			return errors.Errorf("expected two ranges, got %+v", resp.Ranges)
+		}
+		if resp.Ranges[0].LagNanos < resp.Ranges[1].LagNanos {
+			return errors.Errorf("expected ranges ordered by decreasing lag, got %+v", resp.Ranges)
+		}
+		return nil
+	})
+}
+
+func TestStatusAPIHotKeys(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, db, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+
+	if _, err := db.Exec(`SET CLUSTER SETTING kv.hot_key_sampling.enabled = true`); err != nil {
+		t.Fatal(err)
+	}
+
+	hotKey := roachpb.Key("hot")
+	for i := 0; i < 100; i++ {
+		if err := kvDB.Put(context.TODO(), hotKey, i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var resp serverpb.HotKeysResponse
+	if err := getStatusJSONProto(s, "hotkeys/local", &resp); err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, r := range resp.Ranges {
+		for _, k := range r.Keys {
+			if !k.Key.Equal(hotKey) {
+				continue
+			}
+			found = true
+			if len(k.Methods) == 0 || k.QPS <= 0 {
+				t.Fatalf("expected requests to key %s, got %+v", hotKey, k)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected key %s among hot keys, got %+v", hotKey, resp.Ranges)
+	}
+
+	var limitedResp serverpb.HotKeysResponse
+	if err := getStatusJSONProto(s, "hotkeys/local?limit=1&keys_per_range=1", &limitedResp); err != nil {
+		t.Fatal(err)
+	}
+	if len(limitedResp.Ranges) != 1 || len(limitedResp.Ranges[0].Keys) != 1 {
+		t.Fatalf("expected a single range with a single key, got %+v", limitedResp.Ranges)
+	}
+
+	if err := getStatusJSONProto(s, "hotkeys/local?range_id=-1", &serverpb.HotKeysResponse{}); !testutils.IsError(err, "400 Bad Request") {
+		t.Fatalf("expected a bad request error, got %v", err)
+	}
+}
+
+func TestStatusAPILSMHealth(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.TODO())
+	ts := s.(*TestServer)
+
+	var resp serverpb.LSMHealthResponse
+	if err := getStatusJSONProto(s, "lsmhealth/local", &resp); err != nil {
+		t.Fatal(err)
+	}
+	var storeIDs []roachpb.StoreID
+	if err := ts.Stores().VisitStores(func(store *storage.Store) error {
+		storeIDs = append(storeIDs, store.Ident.StoreID)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Stores) != len(storeIDs) {
+		t.Fatalf("expected the LSM health of %d stores, got %+v", len(storeIDs), resp.Stores)
+	}
+	for i, store := range resp.Stores {
+		if store.StoreID != storeIDs[i] {
+			t.Errorf("expected store %d, got %d", storeIDs[i], store.StoreID)
+		}
+		if store.ReadAmplification < store.L0Sublevels {
+			t.Errorf("expected read amplification to account for L0 sublevels, got %+v", store)
+		}
+		if store.Overloaded || store.IngestDelayNanos != 0 {
+			t.Errorf("expected an idle store not to be overloaded, got %+v", store)
+		}
+	}
+}
+
+func TestStatusAPIDistSQLFlows(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	tc := serverutils.StartTestCluster(t, 3, base.TestClusterArgs{})
+	defer tc.Stopper().Stop(context.TODO())
+
+	// No remote flows are running on an idle cluster, but every node must have
+	// been asked for its flows.
+	var resp serverpb.ListDistSQLFlowsResponse
+	if err := getStatusJSONProto(tc.Server(0), "distsql_flows", &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Errors) != 0 {
+		t.Fatalf("unexpected errors: %+v", resp.Errors)
+	}
+	if len(resp.Flows) != 0 {
+		t.Fatalf("expected no flows, got %+v", resp.Flows)
+	}
+}
+
 func TestListSessionsSecurity(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{})
@@ -72,6 +72,13 @@ const (
 	VersionQueryTxnTimestamp
 	VersionStickyBit
 	VersionParallelCommits
+	VersionRecomputeStatsClearEstimates
+	VersionLockTable
+	VersionCoalescedTxnHeartbeats
+	VersionStoreLiveness
+	VersionRowLevelTTL
+	VersionLearnerReplicas
+	VersionShardedReplicaChecksums
 
 	// Add new versions here (step one of two).
 
@@ -479,6 +486,54 @@ var versionsSingleton = keyedVersions([]keyedVersion{
 		Key:     VersionParallelCommits,
 		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 4},
 	},
+	{
+		// VersionRecomputeStatsClearEstimates allows RecomputeStats to clear the
+		// ContainsEstimates flag of a range's stats, which requires all replicas
+		// to understand ReplicatedEvalResult.ClearEstimates.
+		Key:     VersionRecomputeStatsClearEstimates,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 5},
+	},
+	{
+		// VersionLockTable maintains a lock table entry for each intent, which
+		// requires all nodes to ignore the lock table keyspace in their stats
+		// and to remove the entries when resolving intents.
+		Key:     VersionLockTable,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 6},
+	},
+	{
+		// VersionCoalescedTxnHeartbeats allows HeartbeatTxn requests to name the
+		// transaction they heartbeat, so that the heartbeats of many transactions
+		// can share a non-transactional batch.
+		Key:     VersionCoalescedTxnHeartbeats,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 7},
+	},
+	{
+		// VersionStoreLiveness allows stores to exchange store liveness support
+		// for their leases, which requires all nodes to understand
+		// RaftMessageRequest.StoreLiveness and Lease.StoreLiveness.
+		Key:     VersionStoreLiveness,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 8},
+	},
+	{
+		// VersionRowLevelTTL enables ALTER TABLE ... EXPERIMENTAL_TTL, which
+		// creates ROW LEVEL TTL jobs that older nodes can't adopt.
+		Key:     VersionRowLevelTTL,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 9},
+	},
+	{
+		// VersionLearnerReplicas allows replicas to be added as Raft learners,
+		// which requires all nodes to understand ReplicaDescriptor.Type.
+		Key:     VersionLearnerReplicas,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 10},
+	},
+	{
+		// VersionShardedReplicaChecksums allows the checksums of the consistency
+		// checker to be computed over several disjoint spans of a range
+		// concurrently, which requires all nodes to understand
+		// ComputeChecksum.Shards.
+		Key:     VersionShardedReplicaChecksums,
+		Version: roachpb.Version{Major: 19, Minor: 1, Unstable: 11},
+	},
 
 	// Add new versions here (step two of two).
 
@@ -0,0 +1,110 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package settings
+
+import (
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/pkg/errors"
+)
+
+// SetNodeOverrides installs node-local values for the given settings, keyed
+// by setting name. Each setting must have been marked with
+// SetNodeOverridable. The values are in the encoding used by the Updater,
+// except that byte sizes may also be given in human-readable form (e.g.
+// "64MiB").
+//
+// An overridden setting takes on its node-local value immediately, and any
+// cluster-wide value later received through an Updater is retained (see
+// NodeOverride) but not applied. Overrides cannot be removed other than by
+// restarting the node.
+func (sv *Values) SetNodeOverrides(overrides map[string]string) error {
+	encoded := make(map[string]string, len(overrides))
+	for key, val := range overrides {
+		d, ok := Registry[key]
+		if !ok {
+			return errors.Errorf("unknown setting '%s'", key)
+		}
+		if !d.NodeOverridable() {
+			return errors.Errorf("setting '%s' cannot be overridden per node", key)
+		}
+		if _, ok := d.(*ByteSizeSetting); ok {
+			b, err := humanizeutil.ParseBytes(val)
+			if err != nil {
+				return errors.Wrapf(err, "invalid override for setting '%s'", key)
+			}
+			val = EncodeInt(b)
+		}
+		if err := setEncoded(sv, d, val); err != nil {
+			return errors.Wrapf(err, "invalid override for setting '%s'", key)
+		}
+		encoded[key] = val
+	}
+
+	sv.nodeOverrides.Lock()
+	defer sv.nodeOverrides.Unlock()
+	if sv.nodeOverrides.values == nil {
+		sv.nodeOverrides.values = make(map[string]string, len(encoded))
+		sv.nodeOverrides.clusterValues = make(map[string]string, len(encoded))
+	}
+	for key, val := range encoded {
+		if _, ok := sv.nodeOverrides.clusterValues[key]; !ok {
+			sv.nodeOverrides.clusterValues[key] = Registry[key].EncodedDefault()
+		}
+		sv.nodeOverrides.values[key] = val
+	}
+	return nil
+}
+
+// NodeOverride returns the encoded node-local value of the given setting and
+// the encoded cluster-wide value it shadows. The last return value is false
+// if the setting is not overridden on this node.
+func (sv *Values) NodeOverride(key string) (nodeValue, clusterValue string, ok bool) {
+	sv.nodeOverrides.Lock()
+	defer sv.nodeOverrides.Unlock()
+	nodeValue, ok = sv.nodeOverrides.values[key]
+	return nodeValue, sv.nodeOverrides.clusterValues[key], ok
+}
+
+// shadowedByNodeOverride returns whether the setting is overridden on this
+// node, in which case the given cluster-wide value is recorded but must not
+// be applied.
+func (sv *Values) shadowedByNodeOverride(key, clusterValue string) bool {
+	sv.nodeOverrides.Lock()
+	defer sv.nodeOverrides.Unlock()
+	if _, ok := sv.nodeOverrides.values[key]; !ok {
+		return false
+	}
+	sv.nodeOverrides.clusterValues[key] = clusterValue
+	return true
+}
+
+// ParseNodeOverrides parses a comma-separated list of name=value pairs, as
+// accepted by SetNodeOverrides.
+func ParseNodeOverrides(s string) (map[string]string, error) {
+	overrides := make(map[string]string)
+	for _, kv := range strings.Split(s, ",") {
+		if kv = strings.TrimSpace(kv); kv == "" {
+			continue
+		}
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("invalid setting override %q, expected name=value", kv)
+		}
+		overrides[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	return overrides, nil
+}
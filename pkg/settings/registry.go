@@ -31,6 +31,9 @@ import (
 // read concurrently by different callers.
 var Registry = make(map[string]Setting)
 
+// keysBySlot maps the slot index of each registered setting to its key.
+var keysBySlot [maxSettings + 1]string
+
 // When a setting is removed, it should be added to this list so that we cannot
 // accidentally reuse its name, potentially mis-handling older values.
 var retiredSettings = map[string]struct{}{
@@ -66,6 +69,7 @@ func register(key, desc string, s Setting) {
 	s.setDescription(desc)
 	Registry[key] = s
 	s.setSlotIdx(len(Registry))
+	keysBySlot[len(Registry)] = key
 }
 
 // Keys returns a sorted string array with all the known keys.
@@ -112,27 +116,32 @@ var safeToReportSettings = map[string]struct{}{
 // <redacted> for those with values could store sensitive things (i.e. strings).
 func SanitizedValue(name string, values *Values) string {
 	if setting, ok := Lookup(name); ok {
-		if _, ok := safeToReportSettings[name]; ok {
-			return setting.String(values)
-		}
-		// for settings with types that can't be sensitive, report values.
-		switch setting.(type) {
-		case *IntSetting,
-			*FloatSetting,
-			*ByteSizeSetting,
-			*DurationSetting,
-			*BoolSetting,
-			*EnumSetting:
-			return setting.String(values)
-		case *StringSetting:
-			if setting.String(values) == "" {
-				return ""
-			}
-			return "<redacted>"
-		default:
-			return "<redacted>"
+		return sanitize(name, setting, setting.String(values))
+	}
+	return "<unknown>"
+}
+
+// sanitize returns the given representation of the setting's value if the
+// setting's type can't be sensitive, or <redacted> otherwise.
+func sanitize(name string, setting Setting, value string) string {
+	if _, ok := safeToReportSettings[name]; ok {
+		return value
+	}
+	// for settings with types that can't be sensitive, report values.
+	switch setting.(type) {
+	case *IntSetting,
+		*FloatSetting,
+		*ByteSizeSetting,
+		*DurationSetting,
+		*BoolSetting,
+		*EnumSetting:
+		return value
+	case *StringSetting:
+		if value == "" {
+			return ""
 		}
-	} else {
-		return "<unknown>"
+		return "<redacted>"
+	default:
+		return "<redacted>"
 	}
 }
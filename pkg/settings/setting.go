@@ -36,10 +36,26 @@ type Values struct {
 		// NB: any in place modification to individual slices must also hold the
 		// lock, e.g. if we ever add RemoveOnChange or something.
 		onChange [maxSettings][]func()
+		// encoded holds the encoded value of each setting as of its last
+		// change, so that subscribers can be told the value it replaced.
+		encoded [maxSettings + 1]string
+		// subscribers are notified of every change to any setting.
+		subscribers      map[int]func(SettingChange)
+		nextSubscriberID int
 	}
 	// opaque is an arbitrary object that can be set by a higher layer to make it
 	// accessible from certain callbacks (like state machine transformers).
 	opaque interface{}
+
+	nodeOverrides struct {
+		syncutil.Mutex
+		// values maps the keys of overridden settings to their encoded
+		// node-local values.
+		values map[string]string
+		// clusterValues maps the keys of overridden settings to the encoded
+		// cluster-wide values they shadow.
+		clusterValues map[string]string
+	}
 }
 
 var (
@@ -77,6 +93,11 @@ func (sv *Values) Init(opaque interface{}) {
 	for _, s := range Registry {
 		s.setToDefault(sv)
 	}
+	sv.changeMu.Lock()
+	defer sv.changeMu.Unlock()
+	for slotIdx := range keysBySlot {
+		sv.changeMu.encoded[slotIdx] = sv.encodedLocked(slotIdx)
+	}
 }
 
 // Opaque returns the argument passed to Init.
@@ -85,12 +106,47 @@ func (sv *Values) Opaque() interface{} {
 }
 
 func (sv *Values) settingChanged(slotIdx int) {
+	var change SettingChange
+	var subscribers []func(SettingChange)
 	sv.changeMu.Lock()
 	funcs := sv.changeMu.onChange[slotIdx]
+	if key := keysBySlot[slotIdx]; key != "" {
+		change = SettingChange{
+			Key:      key,
+			OldValue: sv.changeMu.encoded[slotIdx],
+			NewValue: sv.encodedLocked(slotIdx),
+		}
+		sv.changeMu.encoded[slotIdx] = change.NewValue
+		if change.OldValue != change.NewValue {
+			for _, fn := range sv.changeMu.subscribers {
+				subscribers = append(subscribers, fn)
+			}
+		}
+	}
 	sv.changeMu.Unlock()
 	for _, fn := range funcs {
 		fn()
 	}
+	for _, fn := range subscribers {
+		fn(change)
+	}
+}
+
+// encodedLocked returns the encoded value of the setting in the given slot.
+// State machine settings are reported in their raw encoding, since decoding
+// them may depend on state not yet available while sv is being initialized.
+func (sv *Values) encodedLocked(slotIdx int) string {
+	s, ok := Registry[keysBySlot[slotIdx]]
+	if !ok {
+		return ""
+	}
+	if _, ok := s.(*StateMachineSetting); ok {
+		if v := sv.getGeneric(slotIdx); v != nil {
+			return string(v.([]byte))
+		}
+		return ""
+	}
+	return s.Encoded(sv)
 }
 
 func (sv *Values) getInt64(slotIdx int) int64 {
@@ -138,13 +194,15 @@ type Setting interface {
 	setDescription(desc string)
 	setSlotIdx(slotIdx int)
 	Hidden() bool
+	NodeOverridable() bool
 
 	SetOnChange(sv *Values, fn func())
 }
 
 type common struct {
-	description string
-	hidden      bool
+	description     string
+	hidden          bool
+	nodeOverridable bool
 	// Each setting has a slotIdx which is used as a handle with Values.
 	slotIdx int
 }
@@ -170,6 +228,12 @@ func (i common) Hidden() bool {
 	return i.hidden
 }
 
+// NodeOverridable returns whether the setting may be overridden on individual
+// nodes. See SetNodeOverridable.
+func (i common) NodeOverridable() bool {
+	return i.nodeOverridable
+}
+
 // SetConfidential prevents a setting from showing up in SHOW ALL
 // CLUSTER SETTINGS. It can still be used with SET and SHOW if the
 // exact setting name is known. Use SetConfidential for data that must
@@ -185,6 +249,13 @@ func (i *common) SetSensitive() {
 	i.description += " (WARNING: may compromise cluster stability or correctness; do not edit without supervision)"
 }
 
+// SetNodeOverridable allows the setting's cluster-wide value to be shadowed by
+// a node-local value (see Values.SetNodeOverrides). Use it for settings, such
+// as IO rate limits, whose ideal value depends on a node's hardware.
+func (i *common) SetNodeOverridable() {
+	i.nodeOverridable = true
+}
+
 // SetDeprecated marks the setting as obsolete. It also hides
 // it from the output of SHOW CLUSTER SETTINGS.
 func (i *common) SetDeprecated() {
@@ -16,6 +16,7 @@ package settings_test
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -616,3 +617,147 @@ func TestHide(t *testing.T) {
 		t.Errorf("expected 'sekretz' to be hidden")
 	}
 }
+
+func TestNodeOverrides(t *testing.T) {
+	overridable := settings.RegisterByteSizeSetting("zzz.overridable", "desc", mb)
+	overridable.SetNodeOverridable()
+
+	sv := &settings.Values{}
+	sv.Init(settings.TestOpaque)
+
+	if err := sv.SetNodeOverrides(map[string]string{"zzz": "2MiB"}); !testutils.IsError(err,
+		"cannot be overridden per node",
+	) {
+		t.Fatal(err)
+	}
+	if err := sv.SetNodeOverrides(map[string]string{"zzz.overridable": "bogus"}); !testutils.IsError(err,
+		"invalid override",
+	) {
+		t.Fatal(err)
+	}
+
+	overrides, err := settings.ParseNodeOverrides("zzz.overridable=2MiB")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sv.SetNodeOverrides(overrides); err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := 2*mb, overridable.Get(sv); expected != actual {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+
+	// Cluster-wide values are recorded but do not displace the override.
+	u := settings.NewUpdater(sv)
+	if err := u.Set("zzz.overridable", settings.EncodeInt(3*mb), "z"); err != nil {
+		t.Fatal(err)
+	}
+	u.ResetRemaining()
+	if expected, actual := 2*mb, overridable.Get(sv); expected != actual {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	nodeVal, clusterVal, ok := sv.NodeOverride("zzz.overridable")
+	if !ok || nodeVal != settings.EncodeInt(2*mb) || clusterVal != settings.EncodeInt(3*mb) {
+		t.Fatalf("unexpected override: %q, %q, %t", nodeVal, clusterVal, ok)
+	}
+
+	// Resetting the cluster-wide value also leaves the override in place.
+	settings.NewUpdater(sv).ResetRemaining()
+	if expected, actual := 2*mb, overridable.Get(sv); expected != actual {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	if _, clusterVal, _ := sv.NodeOverride("zzz.overridable"); clusterVal != settings.EncodeInt(mb) {
+		t.Fatalf("expected cluster value to be reset, got %q", clusterVal)
+	}
+	if _, _, ok := sv.NodeOverride("zzz"); ok {
+		t.Fatal("expected zzz not to be overridden")
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	subscribedInt := settings.RegisterIntSetting("zzz.subscribed.int", "desc", 1)
+	settings.RegisterStringSetting("zzz.subscribed.str", "desc", "")
+
+	sv := &settings.Values{}
+	sv.Init(settings.TestOpaque)
+
+	var changes []settings.SettingChange
+	unsubscribe := sv.Subscribe(func(c settings.SettingChange) {
+		changes = append(changes, c)
+	})
+
+	u := settings.NewUpdater(sv)
+	if err := u.Set("zzz.subscribed.int", settings.EncodeInt(2), "i"); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Set("zzz.subscribed.str", "hunter2", "s"); err != nil {
+		t.Fatal(err)
+	}
+	// Unchanged values don't notify subscribers.
+	if err := u.Set("zzz.subscribed.int", settings.EncodeInt(2), "i"); err != nil {
+		t.Fatal(err)
+	}
+	if expected, actual := int64(2), subscribedInt.Get(sv); expected != actual {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+
+	expected := []settings.SettingChange{
+		{Key: "zzz.subscribed.int", OldValue: "1", NewValue: "2"},
+		{Key: "zzz.subscribed.str", OldValue: "", NewValue: "hunter2"},
+	}
+	if !reflect.DeepEqual(expected, changes) {
+		t.Fatalf("expected %+v, got %+v", expected, changes)
+	}
+	sanitized := changes[1].Sanitized()
+	if sanitized.OldValue != "" || sanitized.NewValue != "<redacted>" {
+		t.Fatalf("expected string setting to be redacted, got %+v", sanitized)
+	}
+
+	unsubscribe()
+	changes = nil
+	if err := u.Set("zzz.subscribed.int", settings.EncodeInt(3), "i"); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected no changes after unsubscribing, got %+v", changes)
+	}
+}
+
+func TestDecodeToString(t *testing.T) {
+	sv := &settings.Values{}
+	sv.Init(settings.TestOpaque)
+
+	testCases := []struct {
+		setting settings.Setting
+		encoded string
+		exp     string
+	}{
+		{boolFA, "true", "true"},
+		{strBarA, "baz", "baz"},
+		{i2A, "7", "7"},
+		{fA, "1.5", "1.5"},
+		{dA, "1m30s", "1m30s"},
+		{eA, "2", "bar"},
+		{byteSize, "2097152", "2.0 MiB"},
+		{mA, "default.AB", "&{default AB}"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.setting.Typ()+"/"+tc.encoded, func(t *testing.T) {
+			s, err := settings.DecodeToString(sv, tc.setting, tc.encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s != tc.exp {
+				t.Fatalf("expected %q, got %q", tc.exp, s)
+			}
+		})
+	}
+
+	// Decoding doesn't change the current value of the setting.
+	if expected, actual := int64(5), i2A.Get(sv); expected != actual {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+	if _, err := settings.DecodeToString(sv, i2A, "notanint"); err == nil {
+		t.Fatal("expected error decoding invalid value")
+	}
+}
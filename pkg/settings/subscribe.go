@@ -0,0 +1,60 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package settings
+
+// SettingChange describes a change to the value of a setting. The values are
+// in the encoding used by the Updater.
+type SettingChange struct {
+	Key      string
+	OldValue string
+	NewValue string
+}
+
+// Sanitized returns a copy of the change in which the values of settings
+// which may hold sensitive information are replaced by <redacted>, as done by
+// SanitizedValue.
+func (c SettingChange) Sanitized() SettingChange {
+	setting, ok := Lookup(c.Key)
+	if !ok {
+		return SettingChange{Key: c.Key, OldValue: "<unknown>", NewValue: "<unknown>"}
+	}
+	c.OldValue = sanitize(c.Key, setting, c.OldValue)
+	c.NewValue = sanitize(c.Key, setting, c.NewValue)
+	return c
+}
+
+// Subscribe installs a callback to be called, with the old and new values,
+// whenever the value of any setting changes. Unlike SetOnChange, which is
+// meant for the owner of a single setting, this is meant for observers of all
+// settings, such as audit logging. `fn` should avoid doing long-running or
+// blocking work as it is called on the goroutine which handles all settings
+// updates.
+//
+// The returned function removes the subscription.
+func (sv *Values) Subscribe(fn func(SettingChange)) (unsubscribe func()) {
+	sv.changeMu.Lock()
+	defer sv.changeMu.Unlock()
+	if sv.changeMu.subscribers == nil {
+		sv.changeMu.subscribers = make(map[int]func(SettingChange))
+	}
+	id := sv.changeMu.nextSubscriberID
+	sv.changeMu.nextSubscriberID++
+	sv.changeMu.subscribers[id] = fn
+	return func() {
+		sv.changeMu.Lock()
+		defer sv.changeMu.Unlock()
+		delete(sv.changeMu.subscribers, id)
+	}
+}
@@ -15,6 +15,7 @@
 package settings
 
 import (
+	"fmt"
 	"strconv"
 	"time"
 
@@ -91,44 +92,74 @@ func (u updater) Set(key, rawValue string, vt string) error {
 		return errors.Errorf("setting '%s' defined as type %s, not %s", key, expected, vt)
 	}
 
+	if u.sv.shadowedByNodeOverride(key, rawValue) {
+		return nil
+	}
+	return setEncoded(u.sv, d, rawValue)
+}
+
+// setEncoded parses the encoded value and updates the setting with it.
+func setEncoded(sv *Values, d Setting, rawValue string) error {
 	switch setting := d.(type) {
 	case *StringSetting:
-		return setting.set(u.sv, rawValue)
+		return setting.set(sv, rawValue)
 	case *BoolSetting:
 		b, err := strconv.ParseBool(rawValue)
 		if err != nil {
 			return err
 		}
-		setting.set(u.sv, b)
+		setting.set(sv, b)
 		return nil
 	case numericSetting: // includes *EnumSetting
 		i, err := strconv.Atoi(rawValue)
 		if err != nil {
 			return err
 		}
-		return setting.set(u.sv, int64(i))
+		return setting.set(sv, int64(i))
 	case *FloatSetting:
 		f, err := strconv.ParseFloat(rawValue, 64)
 		if err != nil {
 			return err
 		}
-		return setting.set(u.sv, f)
+		return setting.set(sv, f)
 	case *DurationSetting:
 		d, err := time.ParseDuration(rawValue)
 		if err != nil {
 			return err
 		}
-		return setting.set(u.sv, d)
+		return setting.set(sv, d)
 	case *StateMachineSetting:
-		return setting.set(u.sv, []byte(rawValue))
+		return setting.set(sv, []byte(rawValue))
 	}
 	return nil
 }
 
+// DecodeToString returns the given encoded value of the setting formatted the
+// way the setting's String method formats its current value. sv is only
+// consulted by the transformers of state machine settings.
+func DecodeToString(sv *Values, d Setting, rawValue string) (string, error) {
+	if setting, ok := d.(*StateMachineSetting); ok {
+		_, obj, err := setting.Validate(sv, []byte(rawValue), nil /* update */)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%v", obj), nil
+	}
+	scratch := new(Values)
+	scratch.Init(sv.Opaque())
+	if err := setEncoded(scratch, d, rawValue); err != nil {
+		return "", err
+	}
+	return d.String(scratch), nil
+}
+
 // ResetRemaining sets all settings not updated by the updater to their default values.
 func (u updater) ResetRemaining() {
 	for k, v := range Registry {
 		if _, ok := u.m[k]; !ok {
+			if u.sv.shadowedByNodeOverride(k, v.EncodedDefault()) {
+				continue
+			}
 			v.setToDefault(u.sv)
 		}
 	}
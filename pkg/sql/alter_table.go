@@ -346,6 +346,14 @@ func (n *alterTableNode) startExec(params runParams) error {
 				return pgerror.Newf(pgerror.CodeInvalidColumnReferenceError,
 					"column %q is referenced by the primary key", col.Name)
 			}
+			if ttl := n.tableDesc.RowLevelTTL; ttl != nil {
+				for _, id := range ttl.ColumnIDs {
+					if id == col.ID {
+						return pgerror.Newf(pgerror.CodeInvalidColumnReferenceError,
+							"column %q is referenced by the row-level TTL", col.Name)
+					}
+				}
+			}
 			for _, idx := range n.tableDesc.AllNonDropIndexes() {
 				// We automatically drop indexes on that column that only
 				// index that column (and no other columns). If CASCADE is
@@ -593,6 +601,13 @@ func (n *alterTableNode) startExec(params runParams) error {
 				return err
 			}
 
+		case *tree.AlterTableSetTTL:
+			descChanged, err := params.p.setRowLevelTTL(params.ctx, n.tableDesc, tn, t.Expr)
+			if err != nil {
+				return err
+			}
+			descriptorChanged = descriptorChanged || descChanged
+
 		case *tree.AlterTableInjectStats:
 			sd, ok := n.statsData[i]
 			if !ok {
@@ -1264,8 +1264,20 @@ func (ex *connExecutor) execCmd(ctx context.Context) error {
 			ExpectedTypes: portal.Stmt.Columns,
 			AnonymizedStr: portal.Stmt.AnonymizedStr,
 		}
+		// If the portal can be suspended once the limit is reached, its rows are
+		// produced through a cursor which takes care of the following executions
+		// of the portal.
+		var execRes RestrictedCommandResult = stmtRes
+		var cursorRes *cursorResult
+		if ex.canUseCursor(portal, tcmd.Limit) {
+			cursorRes = ex.newCursorResult(stmtRes, tcmd.Name, pos, tcmd.Limit)
+			execRes = cursorRes
+		}
 		stmtCtx := withStatement(ctx, ex.curStmt)
-		ev, payload, err = ex.execStmt(stmtCtx, curStmt, stmtRes, pinfo)
+		ev, payload, err = ex.execStmt(stmtCtx, curStmt, execRes, pinfo)
+		if cursorRes != nil {
+			cursorRes.close(ctx)
+		}
 		if err != nil {
 			return err
 		}
@@ -1898,6 +1910,7 @@ func (ex *connExecutor) resetPlanner(
 	p.autoCommit = false
 	p.isPreparing = false
 	p.avoidCachedDescriptors = false
+	p.bundle = nil
 }
 
 // txnStateTransitionsApplyWrapper is a wrapper on top of Machine built with the
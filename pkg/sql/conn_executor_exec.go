@@ -30,6 +30,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
 	"github.com/cockroachdb/cockroach/pkg/util/fsm"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
@@ -359,6 +360,16 @@ func (ex *connExecutor) execStmtInOpenState(
 		discardRows = s.DiscardRows
 	}
 
+	// Attach the fingerprint of the statement to the KV batches with writes it
+	// sends, so that large Raft commands can be traced back to it.
+	if storagebase.LargeWriteBatchThreshold.Get(&ex.server.cfg.Settings.SV) > 0 {
+		fingerprint := stmt.AnonymizedStr
+		if fingerprint == "" {
+			fingerprint = anonymizeStmt(stmt.AST)
+		}
+		ctx = client.ContextWithStatementFingerprint(ctx, fingerprint)
+	}
+
 	// For regular statements (the ones that get to this point), we don't return
 	// any event unless an an error happens.
 
@@ -375,6 +386,12 @@ func (ex *connExecutor) execStmtInOpenState(
 			p.semaCtx.AsOfTimestamp = asOfTs
 			p.extendedEvalCtx.SetTxnTimestamp(asOfTs.GoTime())
 			ex.state.setHistoricalTimestamp(ctx, *asOfTs)
+		} else if asOfTs = p.followerReadAsOf(stmt.AST, stmtTS); asOfTs != nil {
+			// Unlike with AS OF SYSTEM TIME, the statement's data is read at
+			// the historical timestamp but its notion of the current time,
+			// as used for e.g. 'now'::TIMESTAMP, is unchanged.
+			p.semaCtx.AsOfTimestamp = asOfTs
+			ex.state.setHistoricalTimestamp(ctx, *asOfTs)
 		}
 	} else {
 		// If we're in an explicit txn, we allow AOST but only if it matches with
@@ -647,6 +664,8 @@ func (ex *connExecutor) dispatchToExecutionEngine(
 	ctx context.Context, planner *planner, res RestrictedCommandResult,
 ) error {
 	stmt := planner.stmt
+	ctx, finishBundle := ex.maybeStartBundle(ctx, planner, res)
+	defer finishBundle()
 	ex.sessionTracing.TracePlanStart(ctx, stmt.AST.StatementTag())
 	planner.statsCollector.PhaseTimes()[plannerStartLogicalPlan] = timeutil.Now()
 
@@ -656,6 +675,9 @@ func (ex *connExecutor) dispatchToExecutionEngine(
 	// We'll be closing the plan manually below after execution; this
 	// defer is a catch-all in case some other return path is taken.
 	defer planner.curPlan.close(ctx)
+	if planner.bundle != nil && err == nil {
+		planner.bundle.savePlan(ctx, &planner.curPlan)
+	}
 
 	// Certain statements want their results to go to the client
 	// directly. Configure this here.
@@ -881,6 +903,9 @@ func (ex *connExecutor) execWithDistSQLEngine(
 	planCtx.isLocal = !distribute
 	planCtx.planner = planner
 	planCtx.stmtType = recv.stmtType
+	if planner.bundle != nil {
+		planCtx.saveDiagram = planner.bundle.saveDiagram
+	}
 
 	if len(planner.curPlan.subqueryPlans) != 0 {
 		var evalCtx extendedEvalContext
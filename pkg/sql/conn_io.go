@@ -30,6 +30,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/lib/pq/oid"
+	"github.com/pkg/errors"
 )
 
 // This file contains utils and interfaces used by a connExecutor to communicate
@@ -419,6 +420,46 @@ func (buf *StmtBuf) Push(ctx context.Context, cmd Command) error {
 // If the buffer has previously been Close()d, or is closed while this is
 // blocked, io.EOF is returned.
 func (buf *StmtBuf) curCmd() (Command, CmdPos, error) {
+	return buf.curCmdWithTimeout(context.Background(), 0 /* timeout */)
+}
+
+// errCmdWaitTimeout is returned by curCmdWithTimeout when no Command arrived
+// before the timeout.
+var errCmdWaitTimeout = errors.New("timed out waiting for the next command")
+
+// curCmdWithTimeout is like curCmd, except that it stops waiting for the next
+// Command to be pushed into the buffer once the timeout expires, in which case
+// errCmdWaitTimeout is returned, or once the context is canceled, in which case
+// the context's error is returned. A zero timeout means no timeout.
+func (buf *StmtBuf) curCmdWithTimeout(
+	ctx context.Context, timeout time.Duration,
+) (Command, CmdPos, error) {
+	// waitErr is protected by buf.mu. It is set when the wait is interrupted.
+	var waitErr error
+	interrupt := func(err error) {
+		buf.mu.Lock()
+		if waitErr == nil {
+			waitErr = err
+		}
+		buf.mu.cond.Signal()
+		buf.mu.Unlock()
+	}
+	if timeout > 0 {
+		timer := time.AfterFunc(timeout, func() { interrupt(errCmdWaitTimeout) })
+		defer timer.Stop()
+	}
+	if ctxDone := ctx.Done(); ctxDone != nil {
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case <-ctxDone:
+				interrupt(ctx.Err())
+			case <-stop:
+			}
+		}()
+	}
+
 	buf.mu.Lock()
 	defer buf.mu.Unlock()
 	for {
@@ -437,6 +478,9 @@ func (buf *StmtBuf) curCmd() (Command, CmdPos, error) {
 			return nil, 0, pgerror.AssertionFailedf(
 				"can only wait for next command; corrupt cursor: %d", log.Safe(curPos))
 		}
+		if waitErr != nil {
+			return nil, 0, waitErr
+		}
 		// Wait for the next Command to arrive to the buffer.
 		buf.mu.cond.Wait()
 	}
@@ -629,10 +673,23 @@ type CommandResult interface {
 	CommandResultClose
 
 	// SetLimit is used when executing a portal to set a limit on the number of
-	// rows to be returned. We don't currently properly support this feature of
-	// the Postgres protocol; instead, we'll return an error if the number of rows
+	// rows to be returned. Unless the portal is suspended through SuspendPortal
+	// once the limit is reached, we'll return an error if the number of rows
 	// produced is larger than this limit.
 	SetLimit(n int)
+
+	// SuspendPortal tells the client that the execution of the portal has been
+	// suspended because the row count limit was reached, and flushes all the
+	// results accumulated so far. If the result is closed while the portal is
+	// suspended, no completion message is sent to the client.
+	SuspendPortal() error
+
+	// ResumePortal is called when the client executes a suspended portal again,
+	// with the position of the new ExecPortal command and its row count limit.
+	// The results that follow belong to that command. The rows affected count is
+	// reset, so that the completion message reports the rows returned by this
+	// execution only.
+	ResumePortal(pos CmdPos, limit int)
 }
 
 // CommandResultErrBase is the subset of CommandResult dealing with setting a
@@ -923,6 +980,16 @@ func (r *bufferedCommandResult) SetLimit(limit int) {
 	}
 }
 
+// SuspendPortal is part of the CommandResult interface.
+func (r *bufferedCommandResult) SuspendPortal() error {
+	panic("unimplemented")
+}
+
+// ResumePortal is part of the CommandResult interface.
+func (r *bufferedCommandResult) ResumePortal(CmdPos, int) {
+	panic("unimplemented")
+}
+
 // Close is part of the CommandResult interface.
 func (r *bufferedCommandResult) Close(TransactionStatusIndicator) {
 	if r.closeCallback != nil {
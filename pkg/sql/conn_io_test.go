@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"testing"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
@@ -225,6 +226,39 @@ func TestStmtBufCloseUnblocksReader(t *testing.T) {
 	}
 }
 
+// Test that curCmdWithTimeout() stops waiting for the next command once the
+// timeout expires or the context is canceled, and that the buffer remains
+// usable afterwards.
+func TestStmtBufWaitTimeout(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.TODO()
+	buf := NewStmtBuf()
+	if _, _, err := buf.curCmdWithTimeout(ctx, time.Millisecond); err != errCmdWaitTimeout {
+		t.Fatalf("expected timeout, got: %v", err)
+	}
+
+	cancelCtx, cancel := context.WithCancel(ctx)
+	time.AfterFunc(time.Millisecond, cancel)
+	if _, _, err := buf.curCmdWithTimeout(cancelCtx, 0 /* timeout */); err != context.Canceled {
+		t.Fatalf("expected cancellation, got: %v", err)
+	}
+
+	s1, err := parser.ParseOne("SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mustPush(ctx, t, buf, ExecStmt{Statement: s1})
+	cmd, pos, err := buf.curCmdWithTimeout(ctx, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos != 0 {
+		t.Fatalf("expected pos to be 0, got: %d", pos)
+	}
+	assertStmt(t, cmd, "SELECT 1")
+}
+
 // Test that the buffer can hold and return other kinds of commands intermixed
 // with ExecStmt.
 func TestStmtBufPreparedStmt(t *testing.T) {
@@ -40,7 +40,10 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
@@ -63,40 +66,44 @@ const crdbInternalName = "crdb_internal"
 var crdbInternal = virtualSchema{
 	name: crdbInternalName,
 	tableDefs: map[sqlbase.ID]virtualSchemaDef{
-		sqlbase.CrdbInternalBackwardDependenciesTableID: crdbInternalBackwardDependenciesTable,
-		sqlbase.CrdbInternalBuildInfoTableID:            crdbInternalBuildInfoTable,
-		sqlbase.CrdbInternalBuiltinFunctionsTableID:     crdbInternalBuiltinFunctionsTable,
-		sqlbase.CrdbInternalClusterQueriesTableID:       crdbInternalClusterQueriesTable,
-		sqlbase.CrdbInternalClusterSessionsTableID:      crdbInternalClusterSessionsTable,
-		sqlbase.CrdbInternalClusterSettingsTableID:      crdbInternalClusterSettingsTable,
-		sqlbase.CrdbInternalCreateStmtsTableID:          crdbInternalCreateStmtsTable,
-		sqlbase.CrdbInternalFeatureUsageID:              crdbInternalFeatureUsage,
-		sqlbase.CrdbInternalForwardDependenciesTableID:  crdbInternalForwardDependenciesTable,
-		sqlbase.CrdbInternalGossipNodesTableID:          crdbInternalGossipNodesTable,
-		sqlbase.CrdbInternalGossipAlertsTableID:         crdbInternalGossipAlertsTable,
-		sqlbase.CrdbInternalGossipLivenessTableID:       crdbInternalGossipLivenessTable,
-		sqlbase.CrdbInternalGossipNetworkTableID:        crdbInternalGossipNetworkTable,
-		sqlbase.CrdbInternalIndexColumnsTableID:         crdbInternalIndexColumnsTable,
-		sqlbase.CrdbInternalJobsTableID:                 crdbInternalJobsTable,
-		sqlbase.CrdbInternalKVNodeStatusTableID:         crdbInternalKVNodeStatusTable,
-		sqlbase.CrdbInternalKVStoreStatusTableID:        crdbInternalKVStoreStatusTable,
-		sqlbase.CrdbInternalLeasesTableID:               crdbInternalLeasesTable,
-		sqlbase.CrdbInternalLocalQueriesTableID:         crdbInternalLocalQueriesTable,
-		sqlbase.CrdbInternalLocalSessionsTableID:        crdbInternalLocalSessionsTable,
-		sqlbase.CrdbInternalLocalMetricsTableID:         crdbInternalLocalMetricsTable,
-		sqlbase.CrdbInternalPartitionsTableID:           crdbInternalPartitionsTable,
-		sqlbase.CrdbInternalPredefinedCommentsTableID:   crdbInternalPredefinedCommentsTable,
-		sqlbase.CrdbInternalRangesNoLeasesTableID:       crdbInternalRangesNoLeasesTable,
-		sqlbase.CrdbInternalRangesViewID:                crdbInternalRangesView,
-		sqlbase.CrdbInternalRuntimeInfoTableID:          crdbInternalRuntimeInfoTable,
-		sqlbase.CrdbInternalSchemaChangesTableID:        crdbInternalSchemaChangesTable,
-		sqlbase.CrdbInternalSessionTraceTableID:         crdbInternalSessionTraceTable,
-		sqlbase.CrdbInternalSessionVariablesTableID:     crdbInternalSessionVariablesTable,
-		sqlbase.CrdbInternalStmtStatsTableID:            crdbInternalStmtStatsTable,
-		sqlbase.CrdbInternalTableColumnsTableID:         crdbInternalTableColumnsTable,
-		sqlbase.CrdbInternalTableIndexesTableID:         crdbInternalTableIndexesTable,
-		sqlbase.CrdbInternalTablesTableID:               crdbInternalTablesTable,
-		sqlbase.CrdbInternalZonesTableID:                crdbInternalZonesTable,
+		sqlbase.CrdbInternalBackwardDependenciesTableID:  crdbInternalBackwardDependenciesTable,
+		sqlbase.CrdbInternalBuildInfoTableID:             crdbInternalBuildInfoTable,
+		sqlbase.CrdbInternalBuiltinFunctionsTableID:      crdbInternalBuiltinFunctionsTable,
+		sqlbase.CrdbInternalClusterDistSQLFlowsTableID:   crdbInternalClusterDistSQLFlowsTable,
+		sqlbase.CrdbInternalClusterQueriesTableID:        crdbInternalClusterQueriesTable,
+		sqlbase.CrdbInternalClusterSessionsTableID:       crdbInternalClusterSessionsTable,
+		sqlbase.CrdbInternalClusterSettingsTableID:       crdbInternalClusterSettingsTable,
+		sqlbase.CrdbInternalCreateStmtsTableID:           crdbInternalCreateStmtsTable,
+		sqlbase.CrdbInternalFeatureUsageID:               crdbInternalFeatureUsage,
+		sqlbase.CrdbInternalForwardDependenciesTableID:   crdbInternalForwardDependenciesTable,
+		sqlbase.CrdbInternalGCProgressTableID:            crdbInternalGCProgressTable,
+		sqlbase.CrdbInternalGossipNodesTableID:           crdbInternalGossipNodesTable,
+		sqlbase.CrdbInternalGossipAlertsTableID:          crdbInternalGossipAlertsTable,
+		sqlbase.CrdbInternalGossipLivenessTableID:        crdbInternalGossipLivenessTable,
+		sqlbase.CrdbInternalGossipNetworkTableID:         crdbInternalGossipNetworkTable,
+		sqlbase.CrdbInternalIndexColumnsTableID:          crdbInternalIndexColumnsTable,
+		sqlbase.CrdbInternalJobsTableID:                  crdbInternalJobsTable,
+		sqlbase.CrdbInternalKVNodeStatusTableID:          crdbInternalKVNodeStatusTable,
+		sqlbase.CrdbInternalKVProtectedTimestampsTableID: crdbInternalKVProtectedTimestampsTable,
+		sqlbase.CrdbInternalKVStmtDiagnosticsTableID:     crdbInternalKVStmtDiagnosticsTable,
+		sqlbase.CrdbInternalKVStoreStatusTableID:         crdbInternalKVStoreStatusTable,
+		sqlbase.CrdbInternalLeasesTableID:                crdbInternalLeasesTable,
+		sqlbase.CrdbInternalLocalQueriesTableID:          crdbInternalLocalQueriesTable,
+		sqlbase.CrdbInternalLocalSessionsTableID:         crdbInternalLocalSessionsTable,
+		sqlbase.CrdbInternalLocalMetricsTableID:          crdbInternalLocalMetricsTable,
+		sqlbase.CrdbInternalPartitionsTableID:            crdbInternalPartitionsTable,
+		sqlbase.CrdbInternalPredefinedCommentsTableID:    crdbInternalPredefinedCommentsTable,
+		sqlbase.CrdbInternalRangesNoLeasesTableID:        crdbInternalRangesNoLeasesTable,
+		sqlbase.CrdbInternalRangesViewID:                 crdbInternalRangesView,
+		sqlbase.CrdbInternalRuntimeInfoTableID:           crdbInternalRuntimeInfoTable,
+		sqlbase.CrdbInternalSchemaChangesTableID:         crdbInternalSchemaChangesTable,
+		sqlbase.CrdbInternalSessionTraceTableID:          crdbInternalSessionTraceTable,
+		sqlbase.CrdbInternalSessionVariablesTableID:      crdbInternalSessionVariablesTable,
+		sqlbase.CrdbInternalStmtStatsTableID:             crdbInternalStmtStatsTable,
+		sqlbase.CrdbInternalTableColumnsTableID:          crdbInternalTableColumnsTable,
+		sqlbase.CrdbInternalTableIndexesTableID:          crdbInternalTableIndexesTable,
+		sqlbase.CrdbInternalTablesTableID:                crdbInternalTablesTable,
+		sqlbase.CrdbInternalZonesTableID:                 crdbInternalZonesTable,
 	},
 	validWithNoDatabaseContext: true,
 }
@@ -1019,6 +1026,63 @@ func populateSessionsTable(
 	return nil
 }
 
+// crdbInternalClusterDistSQLFlowsTable exposes the list of remote DistSQL
+// flows running on the entire cluster. A flow is part of a distributed query
+// running on its gateway node, where the query can be canceled.
+var crdbInternalClusterDistSQLFlowsTable = virtualSchemaTable{
+	comment: "running remote DistSQL flows (cluster RPC; expensive!)",
+	schema: `
+CREATE TABLE crdb_internal.cluster_distsql_flows (
+  flow_id          UUID,           -- the ID of the flow
+  node_id          INT NOT NULL,   -- the node on which the flow is running
+  gateway_node_id  INT,            -- the node that planned the flow, where its query can be canceled
+  start            TIMESTAMP,      -- the time when the flow was started
+  processors       STRING,         -- the processors of the flow
+  alloc_bytes      INT             -- the number of bytes allocated by the flow
+)
+`,
+	populate: func(ctx context.Context, p *planner, _ *DatabaseDescriptor, addRow func(...tree.Datum) error) error {
+		if err := p.RequireSuperUser(ctx, "read crdb_internal.cluster_distsql_flows"); err != nil {
+			return err
+		}
+		response, err := p.extendedEvalCtx.StatusServer.ListDistSQLFlows(ctx, &serverpb.ListDistSQLFlowsRequest{})
+		if err != nil {
+			return err
+		}
+		for _, flow := range response.Flows {
+			if err := addRow(
+				tree.NewDUuid(tree.DUuid{UUID: flow.FlowID}),
+				tree.NewDInt(tree.DInt(flow.NodeID)),
+				tree.NewDInt(tree.DInt(flow.GatewayNodeID)),
+				tree.MakeDTimestamp(flow.Start, time.Microsecond),
+				tree.NewDString(strings.Join(flow.Processors, ", ")),
+				tree.NewDInt(tree.DInt(flow.AllocBytes)),
+			); err != nil {
+				return err
+			}
+		}
+
+		for _, rpcErr := range response.Errors {
+			log.Warning(ctx, rpcErr.Message)
+			if rpcErr.NodeID != 0 {
+				// Add a row with this node ID, the error for processors, and
+				// nulls for all other columns.
+				if err := addRow(
+					tree.DNull,                             // flow ID
+					tree.NewDInt(tree.DInt(rpcErr.NodeID)), // node ID
+					tree.DNull,                             // gateway node ID
+					tree.DNull,                             // start
+					tree.NewDString("-- "+rpcErr.Message),  // processors
+					tree.DNull,                             // alloc_bytes
+				); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	},
+}
+
 // crdbInternalLocalMetricsTable exposes a snapshot of the metrics on the
 // current node.
 var crdbInternalLocalMetricsTable = virtualSchemaTable{
@@ -1944,6 +2008,87 @@ CREATE TABLE crdb_internal.zones (
 	},
 }
 
+// crdbInternalGCProgressTable exposes the progress of garbage collection on
+// the ranges with a replica on the local node. GC only runs on the
+// leaseholder.
+var crdbInternalGCProgressTable = virtualSchemaTable{
+	comment: "garbage collection progress of the local replicas (RPC + KV reads; local node only)",
+	schema: `
+CREATE TABLE crdb_internal.node_gc_progress (
+  range_id           INT NOT NULL,
+  store_id           INT NOT NULL,
+  start_pretty       STRING NOT NULL,
+  end_pretty         STRING NOT NULL,
+  lease_holder       BOOL NOT NULL,
+  last_gc            TIMESTAMP,
+  gc_threshold       TIMESTAMP,
+  reclaimable_bytes  INT NOT NULL,
+  gc_bytes_age       INT NOT NULL,
+  remaining_versions INT NOT NULL,
+  intent_count       INT NOT NULL
+)
+`,
+	populate: func(ctx context.Context, p *planner, _ *DatabaseDescriptor, addRow func(...tree.Datum) error) error {
+		if err := p.RequireSuperUser(ctx, "read crdb_internal.node_gc_progress"); err != nil {
+			return err
+		}
+
+		response, err := p.ExecCfg().StatusServer.Ranges(ctx, &serverpb.RangesRequest{NodeId: "local"})
+		if err != nil {
+			return err
+		}
+
+		for _, r := range response.Ranges {
+			desc := r.State.Desc
+			if desc == nil {
+				continue
+			}
+			leaseHolder := r.LeaseStatus.State == storagepb.LeaseState_VALID &&
+				r.LeaseStatus.Lease.Replica.StoreID == r.SourceStoreID
+
+			// The GC queue records when it last processed a range; the
+			// timestamp is missing if the range hasn't been GC'ed since.
+			lastGC := tree.DNull
+			var lastGCTS hlc.Timestamp
+			if err := p.ExecCfg().DB.GetProto(
+				ctx, keys.QueueLastProcessedKey(desc.StartKey, "gc" /* the GC queue */), &lastGCTS,
+			); err != nil {
+				return err
+			}
+			if lastGCTS != (hlc.Timestamp{}) {
+				lastGC = tree.TimestampToInexactDTimestamp(lastGCTS)
+			}
+			gcThreshold := tree.DNull
+			if ts := r.State.GCThreshold; ts != nil && *ts != (hlc.Timestamp{}) {
+				gcThreshold = tree.TimestampToInexactDTimestamp(*ts)
+			}
+
+			// The reclaimable bytes and the remaining versions include the
+			// ones that are not yet old enough to be GC'ed.
+			var stats enginepb.MVCCStats
+			if r.State.Stats != nil {
+				stats = *r.State.Stats
+			}
+			if err := addRow(
+				tree.NewDInt(tree.DInt(desc.RangeID)),
+				tree.NewDInt(tree.DInt(r.SourceStoreID)),
+				tree.NewDString(keys.PrettyPrint(nil /* valDirs */, desc.StartKey.AsRawKey())),
+				tree.NewDString(keys.PrettyPrint(nil /* valDirs */, desc.EndKey.AsRawKey())),
+				tree.MakeDBool(tree.DBool(leaseHolder)),
+				lastGC,
+				gcThreshold,
+				tree.NewDInt(tree.DInt(stats.GCBytes())),
+				tree.NewDInt(tree.DInt(stats.GCBytesAge)),
+				tree.NewDInt(tree.DInt(stats.ValCount-stats.LiveCount)),
+				tree.NewDInt(tree.DInt(stats.IntentCount)),
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
 // crdbInternalGossipNodesTable exposes local information about the cluster nodes.
 var crdbInternalGossipNodesTable = virtualSchemaTable{
 	comment: "locally known gossiped node details (RAM; local node only)",
@@ -2427,6 +2572,132 @@ CREATE TABLE crdb_internal.kv_node_status (
 	},
 }
 
+// crdbInternalKVProtectedTimestampsTable exposes the protected timestamp
+// records, which hold back garbage collection.
+var crdbInternalKVProtectedTimestampsTable = virtualSchemaTable{
+	comment: "protected timestamp records (KV scan)",
+	schema: `
+CREATE TABLE crdb_internal.kv_protected_timestamps (
+  id          UUID NOT NULL,
+  ts          DECIMAL NOT NULL,
+  spans       STRING[] NOT NULL,
+  job_id      INT,
+  description STRING NOT NULL
+)
+`,
+	populate: func(ctx context.Context, p *planner, _ *DatabaseDescriptor, addRow func(...tree.Datum) error) error {
+		if err := p.RequireSuperUser(ctx, "read crdb_internal.kv_protected_timestamps"); err != nil {
+			return err
+		}
+
+		records, err := protectedts.GetRecords(ctx, p.txn)
+		if err != nil {
+			return err
+		}
+		for i := range records {
+			r := &records[i]
+			spans := tree.NewDArray(types.String)
+			for _, sp := range r.Spans {
+				if err := spans.Append(tree.NewDString(sp.String())); err != nil {
+					return err
+				}
+			}
+			jobID := tree.DNull
+			if r.JobID != 0 {
+				jobID = tree.NewDInt(tree.DInt(r.JobID))
+			}
+			if err := addRow(
+				tree.NewDUuid(tree.DUuid{UUID: r.ID}),
+				tree.TimestampToDecimal(r.Timestamp),
+				spans,
+				jobID,
+				tree.NewDString(r.Description),
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
+// crdbInternalKVStmtDiagnosticsTable exposes the statement diagnostics requests
+// and the bundles collected for them. See package stmtdiagnostics.
+var crdbInternalKVStmtDiagnosticsTable = virtualSchemaTable{
+	comment: "statement diagnostics requests and bundles (KV scan)",
+	schema: `
+CREATE TABLE crdb_internal.kv_statement_diagnostics (
+  id              UUID NOT NULL,
+  fingerprint     STRING NOT NULL,
+  requested_at    TIMESTAMP NOT NULL,
+  completed       BOOL NOT NULL,
+  node_id         INT,
+  collected_at    TIMESTAMP,
+  statement       STRING,
+  opt_plan        STRING,
+  plan            STRING,
+  distsql_diagram STRING,
+  trace           STRING,
+  error           STRING
+)
+`,
+	populate: func(ctx context.Context, p *planner, _ *DatabaseDescriptor, addRow func(...tree.Datum) error) error {
+		if err := p.RequireSuperUser(ctx, "read crdb_internal.kv_statement_diagnostics"); err != nil {
+			return err
+		}
+
+		registry := p.ExecCfg().StmtDiagnosticsRegistry
+		requests, err := registry.Requests(ctx)
+		if err != nil {
+			return err
+		}
+		stringOrNull := func(s string) tree.Datum {
+			if s == "" {
+				return tree.DNull
+			}
+			return tree.NewDString(s)
+		}
+		for i := range requests {
+			req := &requests[i]
+			nodeID, collectedAt := tree.DNull, tree.DNull
+			statement, optPlan, plan, diagram, trace, errStr :=
+				tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull, tree.DNull
+			if req.Completed {
+				bundle, err := registry.Bundle(ctx, req.ID)
+				if err != nil {
+					return err
+				}
+				if bundle != nil {
+					nodeID = tree.NewDInt(tree.DInt(bundle.NodeID))
+					collectedAt = tree.MakeDTimestamp(bundle.CollectedAt.GoTime(), time.Microsecond)
+					statement = stringOrNull(bundle.Statement)
+					optPlan = stringOrNull(bundle.OptPlan)
+					plan = stringOrNull(bundle.Plan)
+					diagram = stringOrNull(bundle.DistSQLDiagram)
+					trace = stringOrNull(bundle.Trace)
+					errStr = stringOrNull(bundle.Error)
+				}
+			}
+			if err := addRow(
+				tree.NewDUuid(tree.DUuid{UUID: req.ID}),
+				tree.NewDString(req.Fingerprint),
+				tree.MakeDTimestamp(req.RequestedAt.GoTime(), time.Microsecond),
+				tree.MakeDBool(tree.DBool(req.Completed)),
+				nodeID,
+				collectedAt,
+				statement,
+				optPlan,
+				plan,
+				diagram,
+				trace,
+				errStr,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	},
+}
+
 // crdbInternalKVStoreStatusTable exposes information about the cluster stores.
 //
 // TODO(tbg): s/kv_/cluster_/
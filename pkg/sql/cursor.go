@@ -0,0 +1,235 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgwirebase"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/pkg/errors"
+)
+
+// This file implements server-side cursors on top of the row count limits of
+// the pgwire Execute message. When a portal is executed with a limit, its flow
+// is not run to completion: once the limit is reached, the portal is suspended
+// and the flow is paused in place, retaining all its state (the rows buffered
+// in its RowChannels, the resume spans of its fetchers, etc.) until the client
+// fetches more rows by executing the portal again. This lets clients page
+// through large results without re-running the query with an OFFSET.
+//
+// The flow is paused by blocking the goroutine pushing rows into the
+// DistSQLReceiver while the connExecutor reads the next commands from the
+// StmtBuf. As a consequence, the only commands that can be interleaved with
+// the executions of a suspended portal are Sync and Flush; closing the portal
+// or ending the transaction stops the flow, and anything else is an error.
+
+// cursorIdleTimeout is the amount of time a portal can stay suspended before
+// its flow is canceled.
+var cursorIdleTimeout = settings.RegisterNonNegativeDurationSetting(
+	"sql.cursors.idle_timeout",
+	"the maximum amount of time a portal can remain suspended, waiting for the "+
+		"client to fetch more rows, before its execution is canceled (0 to disable)",
+	5*time.Minute,
+)
+
+// cursorPinnedRows is the number of rows assumed to be pinned in the buffers
+// of a paused flow, for memory accounting purposes. It matches the capacity of
+// a RowChannel.
+const cursorPinnedRows = 16
+
+// errCursorClosed is returned by cursorResult.AddRow when the suspended portal
+// is closed by the client. The DistSQLReceiver stops the flow without reporting
+// an error when it gets it.
+var errCursorClosed = errors.New("suspended portal closed")
+
+// cursorResult wraps the result of a portal executed with a row count limit,
+// suspending the portal and pausing its flow every time the limit is reached.
+type cursorResult struct {
+	CommandResult
+
+	ex         *connExecutor
+	portalName string
+	// pos is the position of the ExecPortal command currently being served.
+	pos CmdPos
+	// limit is the row count limit of the current execution of the portal, and
+	// rowsFetched the number of rows it returned so far.
+	limit       int
+	rowsFetched int
+	// rowsAffected is the number of rows returned by all the executions.
+	rowsAffected int
+
+	// acc accounts for the memory pinned by the flow while it's paused.
+	acc mon.BoundAccount
+}
+
+var _ RestrictedCommandResult = &cursorResult{}
+
+// canUseCursor returns whether the portal executed with the given row count
+// limit can be suspended once the limit is reached. Cursors are only supported
+// for queries running in explicit transactions: the implicit transaction of a
+// statement is committed before the next command is processed.
+func (ex *connExecutor) canUseCursor(portal *PreparedPortal, limit int) bool {
+	if limit <= 0 {
+		return false
+	}
+	if _, ok := portal.Stmt.AST.(*tree.Select); !ok {
+		return false
+	}
+	os, ok := ex.machine.CurState().(stateOpen)
+	return ok && !os.ImplicitTxn.Get()
+}
+
+// newCursorResult creates a cursorResult for the ExecPortal command at pos.
+func (ex *connExecutor) newCursorResult(
+	res CommandResult, portalName string, pos CmdPos, limit int,
+) *cursorResult {
+	return &cursorResult{
+		CommandResult: res,
+		ex:            ex,
+		portalName:    portalName,
+		pos:           pos,
+		limit:         limit,
+		acc:           ex.state.mon.MakeBoundAccount(),
+	}
+}
+
+// close releases the resources of the cursor.
+func (r *cursorResult) close(ctx context.Context) {
+	r.acc.Close(ctx)
+}
+
+// AddRow is part of the RestrictedCommandResult interface.
+func (r *cursorResult) AddRow(ctx context.Context, row tree.Datums) error {
+	if err := r.CommandResult.AddRow(ctx, row); err != nil {
+		return err
+	}
+	r.rowsFetched++
+	r.rowsAffected++
+	if r.limit == 0 || r.rowsFetched < r.limit {
+		return nil
+	}
+
+	var rowSize int64
+	for _, d := range row {
+		rowSize += int64(d.Size())
+	}
+	if err := r.acc.ResizeTo(ctx, cursorPinnedRows*rowSize); err != nil {
+		// The portal can't be suspended; the error fails the query.
+		r.SetError(err)
+		return nil
+	}
+	if err := r.CommandResult.SuspendPortal(); err != nil {
+		return err
+	}
+	log.VEventf(ctx, 2, "portal %q suspended after %d rows", r.portalName, r.rowsAffected)
+	defer r.acc.Clear(ctx)
+	return r.waitForFetch(ctx)
+}
+
+// RowsAffected is part of the RestrictedCommandResult interface.
+func (r *cursorResult) RowsAffected() int {
+	return r.rowsAffected
+}
+
+// waitForFetch reads the commands following the execution of the suspended
+// portal until the client executes the portal again, in which case the StmtBuf
+// is left positioned on the new ExecPortal command.
+//
+// errCursorClosed is returned if the client closes the portal or ends the
+// transaction. In that case the StmtBuf is rewound so that the command closing
+// the portal is processed once the current one finishes.
+//
+// Any other command can't be executed while the portal is suspended: a query
+// error is set on the result and the StmtBuf is left positioned on that
+// command, so that it is skipped along with the rest of its batch. The same
+// goes for the idle timeout and for the cancellation of the context. Other
+// returned errors are communication errors.
+func (r *cursorResult) waitForFetch(ctx context.Context) error {
+	ex := r.ex
+	prevPos := r.pos
+	timeout := cursorIdleTimeout.Get(&ex.server.cfg.Settings.SV)
+	ex.stmtBuf.advanceOne()
+	for {
+		cmd, pos, err := ex.stmtBuf.curCmdWithTimeout(ctx, timeout)
+		if err == errCmdWaitTimeout {
+			ex.stmtBuf.rewind(ctx, prevPos)
+			r.SetError(pgerror.Newf(pgerror.CodeInvalidCursorStateError,
+				"portal %q was closed after being suspended for more than %s",
+				r.portalName, timeout))
+			return nil
+		}
+		if err != nil && err == ctx.Err() {
+			// The query was canceled while the portal was suspended.
+			ex.stmtBuf.rewind(ctx, prevPos)
+			r.SetError(err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch tcmd := cmd.(type) {
+		case ExecPortal:
+			if tcmd.Name != r.portalName {
+				r.setInterleavedErr(tcmd)
+				return nil
+			}
+			r.pos = pos
+			r.limit = tcmd.Limit
+			r.rowsFetched = 0
+			r.CommandResult.ResumePortal(pos, tcmd.Limit)
+			return nil
+		case Sync:
+			// We only support explicit transactions, so the transaction is still
+			// open.
+			ex.clientComm.CreateSyncResult(pos).Close(InTxnBlock)
+		case Flush:
+			ex.clientComm.CreateFlushResult(pos).Close(InTxnBlock)
+		case DeletePreparedStmt:
+			if tcmd.Type != pgwirebase.PreparePortal || tcmd.Name != r.portalName {
+				r.setInterleavedErr(tcmd)
+				return nil
+			}
+			ex.stmtBuf.rewind(ctx, prevPos)
+			return errCursorClosed
+		case ExecStmt:
+			switch tcmd.AST.(type) {
+			case *tree.CommitTransaction, *tree.RollbackTransaction:
+				// Ending the transaction destroys the portal.
+				ex.stmtBuf.rewind(ctx, prevPos)
+				return errCursorClosed
+			}
+			r.setInterleavedErr(tcmd)
+			return nil
+		default:
+			r.setInterleavedErr(tcmd)
+			return nil
+		}
+		prevPos = pos
+		ex.stmtBuf.advanceOne()
+	}
+}
+
+// setInterleavedErr sets the error reported when a command other than the
+// execution of the suspended portal is received.
+func (r *cursorResult) setInterleavedErr(cmd Command) {
+	r.SetError(pgerror.UnimplementedWithIssuef(4035,
+		"cannot process %s while portal %q is suspended", cmd.command(), r.portalName))
+}
@@ -36,6 +36,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlrun"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util"
@@ -128,6 +129,21 @@ var planMergeJoins = settings.RegisterBoolSetting(
 	true,
 )
 
+var planSpanClaims = settings.RegisterBoolSetting(
+	"sql.distsql.work_stealing.enabled",
+	"if set, the table readers of unordered distributed scans claim their spans from "+
+		"the gateway and steal the spans of other nodes once they are done with their own",
+	false,
+)
+
+// mayVectorize returns whether the flows of the plan may be run by the
+// vectorized engine.
+func mayVectorize(planCtx *PlanningCtx) bool {
+	evalCtx := planCtx.ExtendedEvalCtx
+	return evalCtx != nil && evalCtx.SessionData != nil &&
+		evalCtx.SessionData.Vectorize != sessiondata.VectorizeOff
+}
+
 // livenessProvider provides just the methods of storage.NodeLiveness that the
 // DistSQLPlanner needs, to avoid importing all of storage.
 type livenessProvider interface {
@@ -528,6 +544,10 @@ type PlanningCtx struct {
 	// noEvalSubqueries indicates that the plan expects any subqueries to not
 	// be replaced by evaluation. Should only be set by EXPLAIN.
 	noEvalSubqueries bool
+
+	// saveDiagram, if set, is called with the diagram of the physical plan
+	// before it is run.
+	saveDiagram func(distsqlpb.FlowDiagram)
 }
 
 var _ distsqlplan.ExprContext = &PlanningCtx{}
@@ -703,9 +723,21 @@ func (h *distSQLNodeHealth) check(ctx context.Context, nodeID roachpb.NodeID) er
 //
 // PartitionSpans does its best to not assign ranges on nodes that are known to
 // either be unhealthy or running an incompatible version. The ranges owned by
-// such nodes are assigned to the gateway.
+// such nodes are assigned to the gateway. It also avoids nodes whose stores are
+// overloaded and, if the session prefers region-local execution, nodes outside
+// of the gateway's region, as long as another replica of the range allows it
+// (see rangePlacer).
 func (dsp *DistSQLPlanner) PartitionSpans(
 	planCtx *PlanningCtx, spans roachpb.Spans,
+) ([]SpanPartition, error) {
+	return dsp.partitionSpans(planCtx, spans, true /* mergeRanges */)
+}
+
+// partitionSpans implements PartitionSpans. If mergeRanges is false, the spans
+// of consecutive ranges on the same node are not merged, so that each span of
+// a partition is contained in a single range.
+func (dsp *DistSQLPlanner) partitionSpans(
+	planCtx *PlanningCtx, spans roachpb.Spans, mergeRanges bool,
 ) ([]SpanPartition, error) {
 	if len(spans) == 0 {
 		panic("no spans")
@@ -723,6 +755,7 @@ func (dsp *DistSQLPlanner) PartitionSpans(
 	// nodeVerCompatMap maintains info about which nodes advertise DistSQL
 	// versions compatible with this plan and which ones don't.
 	nodeVerCompatMap := make(map[roachpb.NodeID]bool)
+	placer := dsp.newRangePlacer(planCtx)
 	it := planCtx.spanIter
 	for _, span := range spans {
 		// rspan is the span we are currently partitioning.
@@ -756,6 +789,7 @@ func (dsp *DistSQLPlanner) PartitionSpans(
 			if log.V(1) {
 				log.Infof(ctx, "lastKey: %s desc: %s", lastKey, desc)
 			}
+			replInfo = placer.place(ctx, desc, replInfo)
 
 			if !desc.ContainsKey(lastKey) {
 				// This range must contain the last range's EndKey.
@@ -814,7 +848,7 @@ func (dsp *DistSQLPlanner) PartitionSpans(
 			}
 			partition := &partitions[partitionIdx]
 
-			if lastNodeID == nodeID {
+			if mergeRanges && lastNodeID == nodeID {
 				// Two consecutive ranges on the same node, merge the spans.
 				partition.Spans[len(partition.Spans)-1].EndKey = endKey.AsRawKey()
 			} else {
@@ -1072,14 +1106,27 @@ func (dsp *DistSQLPlanner) createTableReaders(
 	}
 
 	var spanPartitions []SpanPartition
+	// claimSpans is set if the table readers claim their spans from the gateway,
+	// which lets them steal each other's spans. The spans are then partitioned
+	// at range boundaries so that they can be stolen one range at a time. This
+	// doesn't preserve the order of the rows within a stream.
+	//
+	// The vectorized scan operator doesn't claim its spans, and each node
+	// decides on its own whether its flow is vectorized. A table reader could
+	// then steal the spans that a vectorized scan reads anyway, so spans are
+	// only claimed when the flows are never vectorized.
+	claimSpans := false
 	if planCtx.isLocal {
 		spanPartitions = []SpanPartition{{dsp.nodeDesc.NodeID, n.spans}}
 	} else if n.hardLimit == 0 && n.softLimit == 0 {
 		// No limit - plan all table readers where their data live.
-		spanPartitions, err = dsp.PartitionSpans(planCtx, n.spans)
+		claimSpans = len(n.props.ordering) == 0 && !spec.IsCheck &&
+			planSpanClaims.Get(&dsp.st.SV) && !mayVectorize(planCtx)
+		spanPartitions, err = dsp.partitionSpans(planCtx, n.spans, !claimSpans /* mergeRanges */)
 		if err != nil {
 			return PhysicalPlan{}, err
 		}
+		claimSpans = claimSpans && len(spanPartitions) > 1
 	} else {
 		// If the scan is limited, use a single TableReader to avoid reading more
 		// rows than necessary. Note that distsql is currently only enabled for hard
@@ -1121,6 +1168,7 @@ func (dsp *DistSQLPlanner) createTableReaders(
 		}
 
 		tr.MaxResults = n.maxResults
+		tr.ClaimSpans = claimSpans
 
 		proc := distsqlplan.Processor{
 			Node: sp.Node,
@@ -1850,6 +1898,12 @@ func (dsp *DistSQLPlanner) createPlanForIndexJoin(
 		IndexIdx:   0,
 		Visibility: n.table.colCfg.visibility.toDistSQLScanVisibility(),
 	}
+	// The rows of tables with a single column family are each stored in a
+	// single key, which is cheaper to look up with a Get than with a Scan.
+	if evalCtx := planCtx.ExtendedEvalCtx; evalCtx != nil && evalCtx.SessionData != nil &&
+		evalCtx.SessionData.IndexJoinGetsEnabled && len(joinReaderSpec.Table.Families) == 1 {
+		joinReaderSpec.PointLookups = true
+	}
 
 	filter, err := distsqlplan.MakeExpression(
 		n.table.filter, planCtx, nil /* indexVarMap */)
@@ -2830,6 +2884,9 @@ func (dsp *DistSQLPlanner) isOnlyOnGateway(plan *PhysicalPlan) bool {
 func (dsp *DistSQLPlanner) createPlanForSetOp(
 	planCtx *PlanningCtx, n *unionNode,
 ) (PhysicalPlan, error) {
+	if n.hardLimit != 0 {
+		return dsp.createPlanForLocalityOptimizedSearch(planCtx, n)
+	}
 	leftLogicalPlan := n.left
 	leftPlan, err := dsp.createPlanForNode(planCtx, n.left)
 	if err != nil {
@@ -3075,6 +3132,63 @@ func (dsp *DistSQLPlanner) createPlanForSetOp(
 	return p, nil
 }
 
+// createPlanForLocalityOptimizedSearch plans a unionNode which performs a
+// locality optimized search (see exec.Factory.ConstructLocalityOptimizedSearch).
+// Both operands are planned on the gateway and are read by a no-op processor
+// through a serial synchronizer, local operand first. The processor applies
+// the limit, so once enough local rows are found, the remote operand is never
+// started.
+func (dsp *DistSQLPlanner) createPlanForLocalityOptimizedSearch(
+	planCtx *PlanningCtx, n *unionNode,
+) (PhysicalPlan, error) {
+	// Distributing the operands would set up the remote one on other nodes
+	// regardless of whether its rows are needed. The table readers on the
+	// gateway still read from remote ranges when the remote operand runs.
+	defer func(isLocal bool) {
+		planCtx.isLocal = isLocal
+	}(planCtx.isLocal)
+	planCtx.isLocal = true
+
+	localNode, remoteNode := n.localityOptimizedSearchOperands()
+	localPlan, err := dsp.createPlanForNode(planCtx, localNode)
+	if err != nil {
+		return PhysicalPlan{}, err
+	}
+	remotePlan, err := dsp.createPlanForNode(planCtx, remoteNode)
+	if err != nil {
+		return PhysicalPlan{}, err
+	}
+	if !reflect.DeepEqual(localPlan.PlanToStreamColMap, remotePlan.PlanToStreamColMap) {
+		return PhysicalPlan{}, errors.Errorf(
+			"planToStreamColMap mismatch: %v, %v", localPlan.PlanToStreamColMap,
+			remotePlan.PlanToStreamColMap)
+	}
+	resultTypes, err := distsqlplan.MergeResultTypes(localPlan.ResultTypes, remotePlan.ResultTypes)
+	if err != nil {
+		return PhysicalPlan{}, err
+	}
+
+	var p PhysicalPlan
+	var localRouters, remoteRouters []distsqlplan.ProcessorIdx
+	p.PhysicalPlan, localRouters, remoteRouters = distsqlplan.MergePlans(
+		&localPlan.PhysicalPlan, &remotePlan.PhysicalPlan)
+	p.PlanToStreamColMap = localPlan.PlanToStreamColMap
+	// The streams of the synchronizer are read in the order of the result
+	// routers, so the local ones go first.
+	p.ResultRouters = append(localRouters, remoteRouters...)
+	p.ResultTypes = resultTypes
+	p.SetMergeOrdering(distsqlpb.Ordering{})
+
+	p.AddSingleGroupStage(
+		dsp.nodeDesc.NodeID,
+		distsqlpb.ProcessorCoreUnion{Noop: &distsqlpb.NoopCoreSpec{}},
+		distsqlpb.PostProcessSpec{Limit: n.hardLimit},
+		p.ResultTypes,
+	)
+	p.Processors[p.ResultRouters[0]].Spec.Input[0].Type = distsqlpb.InputSyncSpec_SERIAL_UNORDERED
+	return p, nil
+}
+
 // createPlanForWindow creates a physical plan for computing window functions.
 // We add a new stage of windower processors for each different partitioning
 // scheme found in the query's window functions.
@@ -557,6 +557,61 @@ func TestDistSQLDrainingHosts(t *testing.T) {
 	}
 }
 
+// Test that the table readers of a scan don't claim each other's spans when
+// the flows of the scan may be vectorized: the vectorized scan doesn't claim
+// its spans, so a table reader in a flow which isn't vectorized would read the
+// spans of a vectorized scan a second time. array_agg isn't supported by the
+// vectorized engine, so the flow on the gateway, which aggregates the rows,
+// isn't vectorized while the flows on the other nodes may be.
+func TestDistSQLWorkStealingWithVectorizedFlows(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const numNodes = 3
+	const numRows = 30
+	tc := serverutils.StartTestCluster(t, numNodes, base.TestClusterArgs{
+		ReplicationMode: base.ReplicationManual,
+		ServerArgs:      base.TestServerArgs{UseDatabase: "test"},
+	})
+	defer tc.Stopper().Stop(context.TODO())
+
+	db := tc.ServerConn(0)
+	db.SetMaxOpenConns(1)
+	r := sqlutils.MakeSQLRunner(db)
+	sqlutils.CreateTable(t, db, "t", "k INT PRIMARY KEY", numRows,
+		sqlutils.ToRowFn(sqlutils.RowIdxFn))
+	r.Exec(t, "SET CLUSTER SETTING sql.distsql.work_stealing.enabled = true")
+	for i := 0; i < numNodes; i++ {
+		r.Exec(t, fmt.Sprintf("ALTER TABLE t SPLIT AT VALUES (%d)", numRows*i/numNodes))
+		r.Exec(t, fmt.Sprintf("ALTER TABLE t EXPERIMENTAL_RELOCATE VALUES (ARRAY[%d], %d)",
+			i+1, numRows*i/numNodes))
+	}
+
+	metrics := tc.Server(0).DistSQLServer().(*distsqlrun.ServerImpl).Metrics
+	for _, vectorize := range []string{"off", "on"} {
+		t.Run(vectorize, func(t *testing.T) {
+			r.Exec(t, "SET experimental_vectorize = "+vectorize)
+			stolen := metrics.SpansStolen.Count()
+			var res string
+			r.QueryRow(t, "SELECT array_agg(k)::STRING FROM t").Scan(&res)
+			seen := make(map[string]bool)
+			for _, k := range strings.Split(strings.Trim(res, "{}"), ",") {
+				if seen[k] {
+					t.Fatalf("row %s was read twice: %s", k, res)
+				}
+				seen[k] = true
+			}
+			if len(seen) != numRows {
+				t.Fatalf("expected %d rows, got %s", numRows, res)
+			}
+			if vectorize != "off" {
+				if delta := metrics.SpansStolen.Count() - stolen; delta != 0 {
+					t.Fatalf("expected no spans to be stolen, got %d", delta)
+				}
+			}
+		})
+	}
+}
+
 // testSpanResolverRange describes a range in a test. The ranges are specified
 // in order, so only the start key is needed.
 type testSpanResolverRange struct {
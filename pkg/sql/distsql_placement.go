@@ -0,0 +1,148 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/gossip"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// regionTierKey is the locality tier that identifies the region of a node.
+const regionTierKey = "region"
+
+// localityRegion returns the region of a locality: the value of its "region"
+// tier or, if there is none, of its first tier.
+func localityRegion(l roachpb.Locality) (string, bool) {
+	for _, t := range l.Tiers {
+		if t.Key == regionTierKey {
+			return t.Value, true
+		}
+	}
+	if len(l.Tiers) > 0 {
+		return l.Tiers[0].Value, true
+	}
+	return "", false
+}
+
+// storeLoad is the load of a store, as last gossiped in its descriptor.
+type storeLoad struct {
+	qps        float64
+	overloaded bool
+}
+
+// less orders loads by preference: stores that aren't overloaded come first,
+// and then the ones serving the fewest queries.
+func (l storeLoad) less(o storeLoad) bool {
+	if l.overloaded != o.overloaded {
+		return !l.overloaded
+	}
+	return l.qps < o.qps
+}
+
+// rangePlacer decides on which node the processors reading a range are
+// planned. It starts out from the replica chosen by the replica oracle, which
+// is usually the leaseholder, but moves away from it if the replica's store is
+// overloaded or, when region-local execution is preferred, if the replica is
+// outside of the gateway's region.
+type rangePlacer struct {
+	gossip *gossip.Gossip
+	// region is the gateway's region if region-local execution is preferred,
+	// and empty otherwise.
+	region string
+	// loads caches the loads of the stores looked up so far.
+	loads map[roachpb.StoreID]storeLoad
+}
+
+func (dsp *DistSQLPlanner) newRangePlacer(planCtx *PlanningCtx) *rangePlacer {
+	p := &rangePlacer{
+		gossip: dsp.gossip,
+		loads:  make(map[roachpb.StoreID]storeLoad),
+	}
+	if evalCtx := planCtx.ExtendedEvalCtx; evalCtx != nil && evalCtx.SessionData != nil &&
+		evalCtx.SessionData.PreferRegionLocalExecution {
+		p.region, _ = localityRegion(dsp.nodeDesc.Locality)
+	}
+	return p
+}
+
+func (p *rangePlacer) load(storeID roachpb.StoreID) storeLoad {
+	if l, ok := p.loads[storeID]; ok {
+		return l
+	}
+	var l storeLoad
+	var desc roachpb.StoreDescriptor
+	if err := p.gossip.GetInfoProto(gossip.MakeStoreKey(storeID), &desc); err == nil {
+		l = storeLoad{qps: desc.Capacity.QueriesPerSecond, overloaded: desc.Capacity.Overloaded}
+	}
+	p.loads[storeID] = l
+	return l
+}
+
+// local returns whether the node is in the gateway's region. All nodes are
+// considered local if region-local execution isn't preferred.
+func (p *rangePlacer) local(nd *roachpb.NodeDescriptor) bool {
+	if p.region == "" {
+		return true
+	}
+	region, ok := localityRegion(nd.Locality)
+	return ok && region == p.region
+}
+
+// place returns the replica of the given range on whose node the range is
+// processed, given the replica chosen by the oracle. The chosen replica is
+// kept if it is local and its store isn't overloaded. Otherwise, the replicas
+// in the gateway's region are preferred, followed by the ones on the least
+// loaded stores.
+func (p *rangePlacer) place(
+	ctx context.Context, desc roachpb.RangeDescriptor, chosen kv.ReplicaInfo,
+) kv.ReplicaInfo {
+	if p.gossip == nil {
+		return chosen
+	}
+	bestLocal, bestLoad := p.local(chosen.NodeDesc), p.load(chosen.StoreID)
+	if bestLocal && !bestLoad.overloaded {
+		return chosen
+	}
+	best := chosen
+	for _, r := range desc.Replicas().Unwrap() {
+		if r.StoreID == chosen.StoreID {
+			continue
+		}
+		nd, err := p.gossip.GetNodeDescriptor(r.NodeID)
+		if err != nil {
+			continue
+		}
+		local, load := p.local(nd), p.load(r.StoreID)
+		if local != bestLocal {
+			if !local {
+				continue
+			}
+		} else if !load.less(bestLoad) {
+			continue
+		}
+		best = kv.ReplicaInfo{ReplicaDescriptor: r, NodeDesc: nd}
+		bestLocal, bestLoad = local, load
+	}
+	if best.NodeID != chosen.NodeID {
+		log.VEventf(ctx, 2, "planning r%d on n%d instead of n%d (local: %t, overloaded: %t)",
+			desc.RangeID, best.NodeID, chosen.NodeID, p.local(chosen.NodeDesc),
+			p.load(chosen.StoreID).overloaded)
+	}
+	return best
+}
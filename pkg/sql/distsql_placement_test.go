@@ -0,0 +1,66 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestLocalityRegion(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testCases := []struct {
+		locality string
+		region   string
+		ok       bool
+	}{
+		{"", "", false},
+		{"region=us-east1", "us-east1", true},
+		{"cloud=gce,region=us-east1,zone=us-east1-b", "us-east1", true},
+		{"dc=dc1,rack=r1", "dc1", true},
+	}
+	for _, tc := range testCases {
+		var l roachpb.Locality
+		if tc.locality != "" {
+			if err := l.Set(tc.locality); err != nil {
+				t.Fatal(err)
+			}
+		}
+		region, ok := localityRegion(l)
+		if region != tc.region || ok != tc.ok {
+			t.Errorf("%q: expected (%q, %t), got (%q, %t)", tc.locality, tc.region, tc.ok, region, ok)
+		}
+	}
+}
+
+func TestStoreLoadLess(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	idle := storeLoad{qps: 10}
+	busy := storeLoad{qps: 1000}
+	overloaded := storeLoad{qps: 1, overloaded: true}
+	if !idle.less(busy) || busy.less(idle) {
+		t.Error("expected the store serving fewer queries to be preferred")
+	}
+	if !busy.less(overloaded) || overloaded.less(busy) {
+		t.Error("expected a store that isn't overloaded to be preferred")
+	}
+	if idle.less(idle) {
+		t.Error("expected equal loads not to be ordered")
+	}
+}
@@ -55,6 +55,14 @@ var maxTimestampAge = settings.RegisterDurationSetting(
 	5*time.Minute,
 )
 
+// histogramCollectionEnabled controls whether histograms are collected for
+// single-column statistics.
+var histogramCollectionEnabled = settings.RegisterBoolSetting(
+	"sql.stats.histogram_collection.enabled",
+	"histogram collection mode",
+	true,
+)
+
 func (dsp *DistSQLPlanner) createStatsPlan(
 	planCtx *PlanningCtx,
 	desc *sqlbase.ImmutableTableDescriptor,
@@ -208,20 +216,31 @@ func (dsp *DistSQLPlanner) createPlanForCreateStats(
 	planCtx *PlanningCtx, job *jobs.Job,
 ) (PhysicalPlan, error) {
 	details := job.Details().(jobspb.CreateStatsDetails)
+	tableDesc := sqlbase.NewImmutableTableDescriptor(details.Table)
+	collectHistograms := histogramCollectionEnabled.Get(&dsp.st.SV)
 	reqStats := make([]requestedStat, len(details.ColumnLists))
 	for i := 0; i < len(reqStats); i++ {
-		// Currently we do not use histograms, so don't bother creating one.
-		// When this changes, we can only use it for single-column stats.
+		columns := details.ColumnLists[i].IDs
+		// Histograms can only be generated for single-column stats, and only on
+		// columns whose values can be key-encoded (the bucket boundaries are
+		// stored using the key encoding). Any such column qualifies, whether or
+		// not it is indexed.
 		histogram := false
+		if collectHistograms && len(columns) == 1 {
+			col, err := tableDesc.FindColumnByID(columns[0])
+			if err != nil {
+				return PhysicalPlan{}, err
+			}
+			histogram = !sqlbase.MustBeValueEncoded(col.Type.Family())
+		}
 		reqStats[i] = requestedStat{
-			columns:             details.ColumnLists[i].IDs,
+			columns:             columns,
 			histogram:           histogram,
 			histogramMaxBuckets: histogramBuckets,
 			name:                details.Name,
 		}
 	}
 
-	tableDesc := sqlbase.NewImmutableTableDescriptor(details.Table)
 	return dsp.createStatsPlan(planCtx, tableDesc, reqStats, job)
 }
 
@@ -0,0 +1,334 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlplan"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowcontainer"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/grpcutil"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+)
+
+var restartableFlowsEnabled = settings.RegisterBoolSetting(
+	"sql.distsql.restartable_flows.enabled",
+	"if set, distributed AS OF SYSTEM TIME aggregations re-run on the gateway "+
+		"the part of the query of a node that fails instead of failing the query",
+	false,
+)
+
+// A restartable plan is a physical plan whose results are computed by a
+// single aggregator on the gateway from the partial results produced on each
+// node by table readers, optionally followed by local aggregators:
+//
+//   TableReader -> [Aggregator] -> ... \
+//   TableReader -> [Aggregator] -> ...  -> Aggregator (gateway)
+//
+// Such a plan is run in two phases. First, the portion of the plan of each
+// node is run separately and its results are buffered on the gateway; this
+// acts as a checkpoint, as the results of a portion don't depend on the other
+// portions. If a portion fails because its node became unreachable, only that
+// portion is re-planned on the gateway, reading the same spans, and run
+// again. Then, the final aggregator is run over the buffered results.
+//
+// Re-running a portion is only correct if it reads the same data as the
+// failed attempt, so only non-transactional AS OF SYSTEM TIME queries are
+// run this way.
+
+// restartablePortion is the portion of a restartable plan planned on a node.
+type restartablePortion struct {
+	nodeID roachpb.NodeID
+	// procs are the indexes of the processors of the portion in the plan.
+	procs []distsqlplan.ProcessorIdx
+	// outputs are the indexes of the processors of the portion connected to the
+	// final aggregator.
+	outputs []distsqlplan.ProcessorIdx
+}
+
+// restartablePortionResult holds the buffered results of a portion, encoded
+// as the rows of a Values processor.
+type restartablePortionResult struct {
+	rawBytes [][]byte
+	acc      mon.BoundAccount
+	err      error
+}
+
+// restartablePortions returns the portions of plan if it can be run as a
+// restartable plan. The plan must not have been finalized.
+func (dsp *DistSQLPlanner) restartablePortions(
+	planCtx *PlanningCtx, evalCtx *extendedEvalContext, plan *PhysicalPlan,
+) ([]restartablePortion, bool) {
+	if planCtx.isLocal || planCtx.planner == nil || planCtx.stmtType != tree.Rows ||
+		!restartableFlowsEnabled.Get(&dsp.st.SV) {
+		return nil, false
+	}
+	if planCtx.planner.semaCtx.AsOfTimestamp == nil || !evalCtx.TxnImplicit {
+		return nil, false
+	}
+	if len(plan.LocalProcessors) > 0 || len(plan.ResultRouters) != 1 {
+		return nil, false
+	}
+	finalIdx := plan.ResultRouters[0]
+	final := &plan.Processors[finalIdx]
+	if final.Node != dsp.nodeDesc.NodeID || final.Spec.Core.Aggregator == nil ||
+		len(final.Spec.Input) != 1 ||
+		final.Spec.Input[0].Type != distsqlpb.InputSyncSpec_UNORDERED {
+		return nil, false
+	}
+
+	var portions []restartablePortion
+	byNode := make(map[roachpb.NodeID]int)
+	for i := range plan.Processors {
+		pIdx := distsqlplan.ProcessorIdx(i)
+		if pIdx == finalIdx {
+			continue
+		}
+		proc := &plan.Processors[i]
+		core := &proc.Spec.Core
+		if core.TableReader == nil && core.Aggregator == nil && core.Noop == nil {
+			return nil, false
+		}
+		if len(proc.Spec.Output) != 1 ||
+			proc.Spec.Output[0].Type != distsqlpb.OutputRouterSpec_PASS_THROUGH {
+			return nil, false
+		}
+		n, ok := byNode[proc.Node]
+		if !ok {
+			n = len(portions)
+			byNode[proc.Node] = n
+			portions = append(portions, restartablePortion{nodeID: proc.Node})
+		}
+		portions[n].procs = append(portions[n].procs, pIdx)
+	}
+	for _, s := range plan.Streams {
+		src := &plan.Processors[s.SourceProcessor]
+		if s.DestProcessor == finalIdx {
+			n := byNode[src.Node]
+			portions[n].outputs = append(portions[n].outputs, s.SourceProcessor)
+			continue
+		}
+		// The streams inside of a portion must not cross nodes.
+		if s.SourceProcessor == finalIdx || src.Node != plan.Processors[s.DestProcessor].Node {
+			return nil, false
+		}
+	}
+
+	remote := false
+	for i := range portions {
+		if len(portions[i].outputs) == 0 {
+			return nil, false
+		}
+		if portions[i].nodeID != dsp.nodeDesc.NodeID {
+			remote = true
+		}
+	}
+	// There is nothing to restart if the plan runs only on the gateway.
+	return portions, remote
+}
+
+// runRestartable runs a restartable plan, as described above. All errors are
+// reported to recv.
+func (dsp *DistSQLPlanner) runRestartable(
+	planCtx *PlanningCtx,
+	txn *client.Txn,
+	plan *PhysicalPlan,
+	portions []restartablePortion,
+	recv *DistSQLReceiver,
+	evalCtx *extendedEvalContext,
+) {
+	ctx := planCtx.ctx
+	gateway := dsp.nodeDesc.NodeID
+
+	results := make([]restartablePortionResult, len(portions))
+	for i := range results {
+		results[i].acc = evalCtx.Mon.MakeBoundAccount()
+	}
+	defer func() {
+		for i := range results {
+			results[i].acc.Close(ctx)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(len(portions))
+	for i := range portions {
+		go func(i int) {
+			defer wg.Done()
+			results[i].err = dsp.runRestartablePortion(
+				planCtx, txn, plan, &portions[i], portions[i].nodeID, recv, evalCtx, &results[i],
+			)
+		}(i)
+	}
+	wg.Wait()
+
+	numRows := 0
+	for i := range portions {
+		res := &results[i]
+		if res.err != nil {
+			if portions[i].nodeID == gateway || !isNodeFailureError(ctx, res.err) {
+				recv.SetError(res.err)
+				return
+			}
+			log.VEventf(ctx, 1, "re-running the portion of node %d on the gateway after error: %v",
+				portions[i].nodeID, res.err)
+			res.rawBytes = nil
+			res.acc.Clear(ctx)
+			if err := dsp.runRestartablePortion(
+				planCtx, txn, plan, &portions[i], gateway, recv, evalCtx, res,
+			); err != nil {
+				recv.SetError(err)
+				return
+			}
+		}
+		numRows += len(res.rawBytes)
+	}
+
+	rawBytes := make([][]byte, 0, numRows)
+	for i := range results {
+		rawBytes = append(rawBytes, results[i].rawBytes...)
+	}
+	final := &plan.Processors[plan.ResultRouters[0]]
+	finalPlan, err := dsp.createValuesPlan(final.Spec.Input[0].ColumnTypes, numRows, rawBytes)
+	if err != nil {
+		recv.SetError(err)
+		return
+	}
+	finalPlan.AddSingleGroupStage(gateway, final.Spec.Core, final.Spec.Post, plan.ResultTypes)
+	finalPlan.PlanToStreamColMap = plan.PlanToStreamColMap
+	dsp.FinalizePlan(planCtx, &finalPlan)
+	dsp.Run(planCtx, txn, &finalPlan, recv, evalCtx, nil /* finishedSetupFn */)
+}
+
+// runRestartablePortion runs a portion of a restartable plan, with its
+// processors planned on nodeID, and stores its results in res.
+func (dsp *DistSQLPlanner) runRestartablePortion(
+	planCtx *PlanningCtx,
+	txn *client.Txn,
+	plan *PhysicalPlan,
+	portion *restartablePortion,
+	nodeID roachpb.NodeID,
+	recv *DistSQLReceiver,
+	evalCtx *extendedEvalContext,
+	res *restartablePortionResult,
+) error {
+	ctx := planCtx.ctx
+	if fn := planCtx.planner.ExecCfg().TestingKnobs.BeforeRestartableFlowPortion; fn != nil {
+		if err := fn(nodeID); err != nil {
+			return err
+		}
+	}
+
+	portionPlan := makeRestartablePortionPlan(plan, portion, nodeID)
+	// The portions are run concurrently and mustn't close the plan; this is
+	// done when running the final aggregator.
+	portionPlanCtx := *planCtx
+	portionPlanCtx.ignoreClose = true
+	portionPlanCtx.saveDiagram = nil
+	dsp.FinalizePlan(&portionPlanCtx, &portionPlan)
+
+	typs := portionPlan.ResultTypes
+	rows := rowcontainer.NewRowContainer(
+		evalCtx.Mon.MakeBoundAccount(), sqlbase.ColTypeInfoFromColTypes(typs), 0, /* rowCapacity */
+	)
+	defer rows.Close(ctx)
+	portionRecv := recv.clone()
+	portionRowReceiver := NewRowResultWriter(rows)
+	portionRecv.resultWriter = portionRowReceiver
+	dsp.Run(&portionPlanCtx, txn, &portionPlan, portionRecv, evalCtx, nil /* finishedSetupFn */)
+	if portionRecv.commErr != nil {
+		return portionRecv.commErr
+	}
+	if err := portionRowReceiver.Err(); err != nil {
+		return err
+	}
+
+	var a sqlbase.DatumAlloc
+	for i := 0; i < rows.Len(); i++ {
+		var buf []byte
+		for j, d := range rows.At(i) {
+			var err error
+			datum := sqlbase.DatumToEncDatum(&typs[j], d)
+			buf, err = datum.Encode(&typs[j], &a, sqlbase.DatumEncoding_VALUE, buf)
+			if err != nil {
+				return err
+			}
+		}
+		if err := res.acc.Grow(ctx, int64(len(buf))); err != nil {
+			return err
+		}
+		res.rawBytes = append(res.rawBytes, buf)
+	}
+	return nil
+}
+
+// makeRestartablePortionPlan returns a plan made of the processors of the
+// portion, planned on nodeID, whose results are those of the portion.
+func makeRestartablePortionPlan(
+	plan *PhysicalPlan, portion *restartablePortion, nodeID roachpb.NodeID,
+) PhysicalPlan {
+	var p PhysicalPlan
+	indexes := make(map[distsqlplan.ProcessorIdx]distsqlplan.ProcessorIdx, len(portion.procs))
+	for _, pIdx := range portion.procs {
+		proc := plan.Processors[pIdx]
+		proc.Node = nodeID
+		// The stream endpoints are populated in place when the plan is finalized;
+		// don't share the input and output specs with the original plan.
+		proc.Spec.Input = append([]distsqlpb.InputSyncSpec(nil), proc.Spec.Input...)
+		proc.Spec.Output = append([]distsqlpb.OutputRouterSpec(nil), proc.Spec.Output...)
+		indexes[pIdx] = p.AddProcessor(proc)
+	}
+	for _, s := range plan.Streams {
+		src, ok := indexes[s.SourceProcessor]
+		if !ok {
+			continue
+		}
+		dst, ok := indexes[s.DestProcessor]
+		if !ok {
+			continue
+		}
+		s.SourceProcessor, s.DestProcessor = src, dst
+		p.Streams = append(p.Streams, s)
+	}
+	for _, pIdx := range portion.outputs {
+		p.ResultRouters = append(p.ResultRouters, indexes[pIdx])
+	}
+	final := &plan.Processors[plan.ResultRouters[0]]
+	p.ResultTypes = final.Spec.Input[0].ColumnTypes
+	p.PlanToStreamColMap = identityMap(nil /* buf */, len(p.ResultTypes))
+	return p
+}
+
+// isNodeFailureError returns whether err indicates that a node participating
+// in a flow became unreachable, as opposed to an error of the query itself.
+func isNodeFailureError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		// The query was canceled.
+		return false
+	}
+	if pgErr, ok := pgerror.GetPGCause(err); ok {
+		return pgErr.Code == pgerror.CodeConnectionFailureError
+	}
+	return grpcutil.IsClosedConnection(err) || grpcutil.RequestDidNotStart(err)
+}
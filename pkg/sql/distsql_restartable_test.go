@@ -0,0 +1,133 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/pkg/errors"
+)
+
+// TestRestartableFlows verifies that the portion of a distributed AS OF
+// SYSTEM TIME aggregation whose node fails is re-run on the gateway.
+func TestRestartableFlows(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var mu struct {
+		syncutil.Mutex
+		// nodes are the nodes on which portions were run.
+		nodes []roachpb.NodeID
+		// err, if set, is returned by the next portion of a node other than the
+		// gateway.
+		err error
+	}
+	const gateway = roachpb.NodeID(1)
+	knobs := base.TestingKnobs{
+		SQLExecutor: &sql.ExecutorTestingKnobs{
+			BeforeRestartableFlowPortion: func(nodeID roachpb.NodeID) error {
+				mu.Lock()
+				defer mu.Unlock()
+				mu.nodes = append(mu.nodes, nodeID)
+				if err := mu.err; err != nil && nodeID != gateway {
+					mu.err = nil
+					return err
+				}
+				return nil
+			},
+		},
+	}
+	tc := serverutils.StartTestCluster(t, 3, /* numNodes */
+		base.TestClusterArgs{
+			ReplicationMode: base.ReplicationManual,
+			ServerArgs:      base.TestServerArgs{UseDatabase: "test", Knobs: knobs},
+		})
+	defer tc.Stopper().Stop(context.Background())
+
+	db := tc.ServerConn(0)
+	db.SetMaxOpenConns(1)
+	r := sqlutils.MakeSQLRunner(db)
+	r.Exec(t, `CREATE DATABASE test`)
+	r.Exec(t, `CREATE TABLE t (k INT PRIMARY KEY)`)
+	r.Exec(t, `INSERT INTO t SELECT generate_series(1, 30)`)
+	r.Exec(t, `ALTER TABLE t SPLIT AT VALUES (10), (20)`)
+	r.Exec(t, `ALTER TABLE t EXPERIMENTAL_RELOCATE VALUES (ARRAY[1], 1), (ARRAY[2], 10), (ARRAY[3], 20)`)
+	r.Exec(t, `SET CLUSTER SETTING sql.distsql.restartable_flows.enabled = true`)
+	r.Exec(t, `SET distsql = always`)
+	var ts string
+	r.QueryRow(t, `SELECT cluster_logical_timestamp()`).Scan(&ts)
+	query := fmt.Sprintf(`SELECT count(*), sum(k) FROM t AS OF SYSTEM TIME %s`, ts)
+	expected := [][]string{{"30", "465"}}
+
+	runQuery := func(err error) []roachpb.NodeID {
+		mu.Lock()
+		mu.nodes, mu.err = nil, err
+		mu.Unlock()
+		r.CheckQueryResults(t, query, expected)
+		mu.Lock()
+		defer mu.Unlock()
+		return mu.nodes
+	}
+
+	// Wait for the gateway to plan portions on the other nodes.
+	testutils.SucceedsSoon(t, func() error {
+		if nodes := runQuery(nil /* err */); len(nodes) != 3 {
+			return errors.Errorf("expected portions on 3 nodes, got %v", nodes)
+		}
+		return nil
+	})
+
+	// The portion of a failed node is re-run on the gateway.
+	nodes := runQuery(pgerror.Newf(pgerror.CodeConnectionFailureError, "communication error: injected"))
+	if len(nodes) != 4 || nodes[3] != gateway {
+		t.Fatalf("expected a portion to be re-run on the gateway, got %v", nodes)
+	}
+
+	// Errors which don't come from a node failure fail the query.
+	mu.Lock()
+	mu.err = errors.New("injected")
+	mu.Unlock()
+	r.ExpectErr(t, "injected", query)
+
+	// Queries in explicit transactions and queries of current data aren't run
+	// as restartable plans, nor are any queries once the setting is disabled.
+	mu.Lock()
+	mu.nodes = nil
+	mu.Unlock()
+	r.Exec(t, `BEGIN AS OF SYSTEM TIME `+ts)
+	r.CheckQueryResults(t, `SELECT count(*), sum(k) FROM t`, expected)
+	r.Exec(t, `COMMIT`)
+	r.CheckQueryResults(t, `SELECT count(*), sum(k) FROM t`, expected)
+	mu.Lock()
+	nodes = mu.nodes
+	mu.Unlock()
+	if len(nodes) != 0 {
+		t.Fatalf("expected the queries not to be restartable, got portions on %v", nodes)
+	}
+	r.Exec(t, `SET CLUSTER SETTING sql.distsql.restartable_flows.enabled = false`)
+	if nodes := runQuery(nil /* err */); len(nodes) != 0 {
+		t.Fatalf("expected the query not to be restartable, got portions on %v", nodes)
+	}
+}
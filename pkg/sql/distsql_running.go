@@ -23,6 +23,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/internal/client"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/rpc"
 	"github.com/cockroachdb/cockroach/pkg/rpc/nodedialer"
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlplan"
@@ -38,6 +39,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	"github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc"
 )
 
 // To allow queries to send out flow RPCs in parallel, we use a pool of workers
@@ -68,18 +70,17 @@ func (req runnerRequest) run() {
 
 	res := runnerResult{nodeID: req.nodeID}
 
-	conn, err := req.nodeDialer.Dial(req.ctx, req.nodeID)
-	if err != nil {
-		res.err = err
-	} else {
-		client := distsqlpb.NewDistSQLClient(conn)
-		// TODO(radu): do we want a timeout here?
-		resp, err := client.SetupFlow(req.ctx, req.flowReq)
-		if err != nil {
-			res.err = err
-		} else {
-			res.err = resp.Error.ErrorDetail()
-		}
+	var resp *distsqlpb.SimpleResponse
+	res.err = distsqlrun.RetryStreamSetup(req.ctx, req.nodeDialer, req.nodeID, rpc.DefaultClass,
+		func(ctx context.Context, conn *grpc.ClientConn) error {
+			client := distsqlpb.NewDistSQLClient(conn)
+			// TODO(radu): do we want a timeout here?
+			var err error
+			resp, err = client.SetupFlow(ctx, req.flowReq)
+			return err
+		})
+	if res.err == nil {
+		res.err = resp.Error.ErrorDetail()
 	}
 	req.resultChan <- res
 }
@@ -105,6 +106,45 @@ func (dsp *DistSQLPlanner) initRunners() {
 	}
 }
 
+// registerSpanClaims makes the spans of the table readers of the plan that
+// claim their spans available to be claimed, grouped by stage. The returned
+// function unregisters them and must be called once the flow is done.
+func (dsp *DistSQLPlanner) registerSpanClaims(
+	plan *PhysicalPlan, flowID distsqlpb.FlowID,
+) func() {
+	var stages map[int32]map[roachpb.NodeID]roachpb.Spans
+	for i := range plan.Processors {
+		proc := &plan.Processors[i]
+		tr := proc.Spec.Core.TableReader
+		if tr == nil || !tr.ClaimSpans {
+			continue
+		}
+		// The stages might have been renumbered since the table readers were
+		// planned.
+		tr.ClaimStageID = proc.Spec.StageID
+		if stages == nil {
+			stages = make(map[int32]map[roachpb.NodeID]roachpb.Spans)
+		}
+		partitions, ok := stages[proc.Spec.StageID]
+		if !ok {
+			partitions = make(map[roachpb.NodeID]roachpb.Spans)
+			stages[proc.Spec.StageID] = partitions
+		}
+		for _, sp := range tr.Spans {
+			partitions[proc.Node] = append(partitions[proc.Node], sp.Span)
+		}
+	}
+	unregister := make([]func(), 0, len(stages))
+	for stageID, partitions := range stages {
+		unregister = append(unregister, dsp.distSQLSrv.RegisterSpanClaims(flowID, stageID, partitions))
+	}
+	return func() {
+		for _, f := range unregister {
+			f()
+		}
+	}
+}
+
 // Run executes a physical plan. The plan should have been finalized using
 // FinalizePlan.
 //
@@ -161,6 +201,16 @@ func (dsp *DistSQLPlanner) Run(
 	}
 
 	flows := plan.GenerateFlowSpecs(dsp.nodeDesc.NodeID /* gateway */)
+	defer dsp.registerSpanClaims(plan, flows[dsp.nodeDesc.NodeID].FlowID)()
+
+	if planCtx.saveDiagram != nil {
+		diagram, err := distsqlpb.GeneratePlanDiagram(flows)
+		if err != nil {
+			log.Infof(ctx, "Error generating diagram: %s", err)
+		} else {
+			planCtx.saveDiagram(diagram)
+		}
+	}
 
 	if logPlanDiagram {
 		log.VEvent(ctx, 1, "creating plan diagram")
@@ -571,6 +621,12 @@ func (r *DistSQLReceiver) Push(
 	r.tracing.TraceExecRowsResult(r.ctx, r.row)
 	// Note that AddRow accounts for the memory used by the Datums.
 	if commErr := r.resultWriter.AddRow(r.ctx, r.row); commErr != nil {
+		if commErr == errCursorClosed {
+			// The client closed the suspended portal this flow is producing rows
+			// for. This isn't an error; drain the flow so that we get its metadata.
+			r.status = distsqlrun.DrainRequested
+			return r.status
+		}
 		r.commErr = commErr
 		// Set the error on the resultWriter too, for the convenience of some of the
 		// clients. If clients don't care to differentiate between communication
@@ -837,6 +893,10 @@ func (dsp *DistSQLPlanner) PlanAndRun(
 		recv.SetError(err)
 		return
 	}
+	if portions, ok := dsp.restartablePortions(planCtx, evalCtx, &physPlan); ok {
+		dsp.runRestartable(planCtx, txn, &physPlan, portions, recv, evalCtx)
+		return
+	}
 	dsp.FinalizePlan(planCtx, &physPlan)
 	dsp.Run(planCtx, txn, &physPlan, recv, evalCtx, nil /* finishedSetupFn */)
 }
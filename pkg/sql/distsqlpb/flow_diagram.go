@@ -40,6 +40,13 @@ type diagramCellType interface {
 	summary() (title string, details []string)
 }
 
+// Title returns the title of the processor in flow diagrams, which consists of
+// the type of its core and its ID (e.g. "TableReader/0").
+func (p *ProcessorSpec) Title() string {
+	title, _ := p.Core.GetValue().(diagramCellType).summary()
+	return fmt.Sprintf("%s/%d", title, p.ProcessorID)
+}
+
 func (ord *Ordering) diagramString() string {
 	var buf bytes.Buffer
 	for i, c := range ord.Columns {
@@ -360,6 +367,8 @@ func (is *InputSyncSpec) summary() (string, []string) {
 		return "unordered", []string{}
 	case InputSyncSpec_ORDERED:
 		return "ordered", []string{is.Ordering.diagramString()}
+	case InputSyncSpec_SERIAL_UNORDERED:
+		return "serial unordered", []string{}
 	default:
 		return "unknown", []string{}
 	}
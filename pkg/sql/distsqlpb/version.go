@@ -13,3 +13,66 @@
 // permissions and limitations under the License.
 
 package distsqlpb
+
+import "github.com/pkg/errors"
+
+// StreamVersion identifies versions of the protocol spoken on the streams
+// between producers and consumers (ProducerMessage and ConsumerSignal). It is
+// independent of DistSQLVersion, which is checked when flows are set up: a
+// stream's producer and consumer negotiate a version which both of them speak
+// when the stream is connected, so that new stream features can be introduced
+// without breaking the flows of mixed-version clusters.
+type StreamVersion uint32
+
+const (
+	// StreamVersionUnversioned is the version of the producers and consumers
+	// which predate stream versioning.
+	StreamVersionUnversioned StreamVersion = 0
+	// StreamVersionCapabilities introduces the version range and capabilities
+	// of ProducerHeader and ConsumerHandshake.stream_version.
+	StreamVersionCapabilities StreamVersion = 1
+)
+
+// CurrentStreamVersion is the newest stream version spoken by this binary.
+const CurrentStreamVersion = StreamVersionCapabilities
+
+// MinAcceptedStreamVersion is the oldest stream version spoken by this binary.
+const MinAcceptedStreamVersion = StreamVersionUnversioned
+
+// StreamCapabilities is a set of optional features of the stream protocol
+// used by a producer.
+type StreamCapabilities uint64
+
+const (
+	// StreamCapabilityColumnar is set by producers which send the raw bytes of
+	// their data as serialized column batches rather than encoded rows.
+	StreamCapabilityColumnar StreamCapabilities = 1 << iota
+)
+
+// Has returns whether c contains all the capabilities in other.
+func (c StreamCapabilities) Has(other StreamCapabilities) bool {
+	return c&other == other
+}
+
+// NegotiateVersion returns the newest stream version spoken by both the
+// producer which sent the header and a consumer speaking the versions from
+// minAccepted to current. Producers which predate stream versioning are
+// assumed to only speak StreamVersionUnversioned. An error is returned if
+// there is no such version.
+func (h *ProducerHeader) NegotiateVersion(minAccepted, current StreamVersion) (StreamVersion, error) {
+	producerMinAccepted, producerVersion := h.MinAcceptedVersion, h.Version
+	if producerVersion == StreamVersionUnversioned {
+		producerMinAccepted = StreamVersionUnversioned
+	}
+	v := producerVersion
+	if current < v {
+		v = current
+	}
+	if v < minAccepted || v < producerMinAccepted {
+		return 0, errors.Errorf(
+			"incompatible stream versions: producer speaks versions %d to %d, consumer speaks %d to %d",
+			producerMinAccepted, producerVersion, minAccepted, current,
+		)
+	}
+	return v, nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlpb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestNegotiateStreamVersion(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testCases := []struct {
+		producerMinAccepted, producerVersion StreamVersion
+		consumerMinAccepted, consumerVersion StreamVersion
+		expected                             StreamVersion
+		err                                  string
+	}{
+		// Unversioned producers.
+		{0, 0, 0, 3, 0, ""},
+		{0, 0, 1, 3, 0, "incompatible stream versions"},
+		// The newest common version is used.
+		{1, 3, 1, 3, 3, ""},
+		{1, 2, 1, 3, 2, ""},
+		{1, 4, 1, 3, 3, ""},
+		{0, 4, 3, 5, 4, ""},
+		// No common version.
+		{4, 5, 1, 3, 0, "incompatible stream versions"},
+		{1, 2, 3, 4, 0, "incompatible stream versions"},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("%d-%d/%d-%d", tc.producerMinAccepted, tc.producerVersion,
+			tc.consumerMinAccepted, tc.consumerVersion), func(t *testing.T) {
+			h := ProducerHeader{MinAcceptedVersion: tc.producerMinAccepted, Version: tc.producerVersion}
+			v, err := h.NegotiateVersion(tc.consumerMinAccepted, tc.consumerVersion)
+			if !testutils.IsError(err, tc.err) {
+				t.Fatalf("expected error %q, got %v", tc.err, err)
+			}
+			if v != tc.expected {
+				t.Errorf("expected version %d, got %d", tc.expected, v)
+			}
+		})
+	}
+}
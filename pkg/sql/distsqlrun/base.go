@@ -18,6 +18,7 @@ import (
 	"context"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/internal/client"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -26,6 +27,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/opentracing/opentracing-go"
@@ -360,14 +362,58 @@ type RowChannelMsg struct {
 	Meta *distsqlpb.ProducerMetadata
 }
 
+// PushStallStats describes the backpressure a bounded RowReceiver applied to
+// its producers: the number of Push calls which blocked because the receiver
+// was full, i.e. because its consumer was slower than its producers, and the
+// total time they were blocked for.
+type PushStallStats struct {
+	Stalls    int64
+	StallTime time.Duration
+}
+
+// pushStallCounter accumulates PushStallStats. It is safe for concurrent use.
+type pushStallCounter struct {
+	stalls     int64
+	stallNanos int64
+}
+
+func (c *pushStallCounter) record(stallTime time.Duration) {
+	atomic.AddInt64(&c.stalls, 1)
+	atomic.AddInt64(&c.stallNanos, int64(stallTime))
+}
+
+func (c *pushStallCounter) get() PushStallStats {
+	return PushStallStats{
+		Stalls:    atomic.LoadInt64(&c.stalls),
+		StallTime: time.Duration(atomic.LoadInt64(&c.stallNanos)),
+	}
+}
+
+// RowChannelFairness is the policy used by a RowChannel with multiple senders
+// to deliver the rows pushed by its senders.
+type RowChannelFairness int
+
+const (
+	// RowChannelFIFO delivers the rows in the order in which they were pushed.
+	// The senders share the buffer of the channel, so a fast sender can fill it
+	// and delay the delivery of the rows of the other senders.
+	RowChannelFIFO RowChannelFairness = iota
+	// RowChannelRoundRobin gives each sender an equal share of the buffer of the
+	// channel and delivers the rows of the senders in turn, skipping the senders
+	// which don't have any rows buffered.
+	RowChannelRoundRobin
+)
+
 // RowChannel is a thin layer over a RowChannelMsg channel, which can be used to
-// transfer rows between goroutines.
+// transfer rows between goroutines. The channel is bounded: Push blocks while
+// it is full, which applies backpressure to the producers.
 type RowChannel struct {
 	rowSourceBase
 
 	types []types.T
 
-	// The channel on which rows are delivered.
+	// The channel on which rows are delivered. It is nil with
+	// RowChannelRoundRobin.
 	C <-chan RowChannelMsg
 
 	// dataChan is the same channel as C.
@@ -376,6 +422,24 @@ type RowChannel struct {
 	// numSenders is an atomic counter that keeps track of how many senders have
 	// yet to call ProducerDone().
 	numSenders int32
+
+	// The following fields are only used with RowChannelRoundRobin, with which
+	// each sender pushes its rows on its own channel (see Sender).
+	//
+	// senderChans are the channels of the senders.
+	senderChans []chan RowChannelMsg
+	// ready receives a token each time a sender sends a message on its channel
+	// or closes it, which allows the consumer to wait on all the senders.
+	ready chan struct{}
+	// closed tracks the senderChans which the consumer observed to be closed.
+	closed []bool
+	// numOpen is the number of senderChans which the consumer hasn't observed to
+	// be closed.
+	numOpen int
+	// next is the index of the sender whose rows are delivered next.
+	next int
+
+	stalls pushStallCounter
 }
 
 var _ RowReceiver = &RowChannel{}
@@ -389,6 +453,20 @@ func (rc *RowChannel) InitWithNumSenders(types []types.T, numSenders int) {
 	rc.initWithBufSizeAndNumSenders(types, rowChannelBufSize, numSenders)
 }
 
+// InitWithNumSendersAndFairness is like InitWithNumSenders, but the rows of
+// the senders are delivered according to the given fairness policy. With
+// RowChannelRoundRobin, the i-th sender must push its rows to Sender(i) rather
+// than to the RowChannel.
+func (rc *RowChannel) InitWithNumSendersAndFairness(
+	types []types.T, numSenders int, fairness RowChannelFairness,
+) {
+	if fairness == RowChannelRoundRobin {
+		rc.initRoundRobin(types, rowChannelBufSize, numSenders)
+		return
+	}
+	rc.InitWithNumSenders(types, numSenders)
+}
+
 // initWithBufSizeAndNumSenders initializes the RowChannel with a given buffer
 // size and number of senders.
 func (rc *RowChannel) initWithBufSizeAndNumSenders(types []types.T, chanBufSize, numSenders int) {
@@ -398,19 +476,82 @@ func (rc *RowChannel) initWithBufSizeAndNumSenders(types []types.T, chanBufSize,
 	atomic.StoreInt32(&rc.numSenders, int32(numSenders))
 }
 
+// initRoundRobin initializes the RowChannel with RowChannelRoundRobin; the
+// buffer of the channel is split between the senders.
+func (rc *RowChannel) initRoundRobin(types []types.T, chanBufSize, numSenders int) {
+	rc.types = types
+	senderBufSize := chanBufSize / numSenders
+	if senderBufSize < 1 {
+		senderBufSize = 1
+	}
+	rc.senderChans = make([]chan RowChannelMsg, numSenders)
+	for i := range rc.senderChans {
+		rc.senderChans[i] = make(chan RowChannelMsg, senderBufSize)
+	}
+	// A token is sent for each message and each close, and the tokens of the
+	// messages drained by ConsumerClosed are never received. Sending a token
+	// must never block.
+	rc.ready = make(chan struct{}, numSenders*(senderBufSize+2))
+	rc.closed = make([]bool, numSenders)
+	rc.numOpen = numSenders
+	atomic.StoreInt32(&rc.numSenders, int32(numSenders))
+}
+
+// Sender returns the RowReceiver to which the i-th sender of the RowChannel
+// must push its rows. It is the RowChannel itself, unless the RowChannel uses
+// RowChannelRoundRobin.
+func (rc *RowChannel) Sender(i int) RowReceiver {
+	if rc.senderChans == nil {
+		return rc
+	}
+	return &rowChannelSender{rc: rc, idx: i}
+}
+
+// rowChannelSender is the RowReceiver of a sender of a RowChannel using
+// RowChannelRoundRobin.
+type rowChannelSender struct {
+	rc  *RowChannel
+	idx int
+}
+
+var _ RowReceiver = &rowChannelSender{}
+
+// Push is part of the RowReceiver interface.
+func (s *rowChannelSender) Push(
+	row sqlbase.EncDatumRow, meta *distsqlpb.ProducerMetadata,
+) ConsumerStatus {
+	return s.rc.push(s.rc.senderChans[s.idx], row, meta)
+}
+
+// ProducerDone is part of the RowReceiver interface.
+func (s *rowChannelSender) ProducerDone() {
+	if atomic.AddInt32(&s.rc.numSenders, -1) < 0 {
+		panic("too many ProducerDone() calls")
+	}
+	close(s.rc.senderChans[s.idx])
+	s.rc.ready <- struct{}{}
+}
+
 // Push is part of the RowReceiver interface.
 func (rc *RowChannel) Push(
 	row sqlbase.EncDatumRow, meta *distsqlpb.ProducerMetadata,
+) ConsumerStatus {
+	return rc.push(rc.dataChan, row, meta)
+}
+
+// push implements Push for the sender whose rows are sent on dataChan.
+func (rc *RowChannel) push(
+	dataChan chan RowChannelMsg, row sqlbase.EncDatumRow, meta *distsqlpb.ProducerMetadata,
 ) ConsumerStatus {
 	consumerStatus := ConsumerStatus(
 		atomic.LoadUint32((*uint32)(&rc.consumerStatus)))
 	switch consumerStatus {
 	case NeedMoreRows:
-		rc.dataChan <- RowChannelMsg{Row: row, Meta: meta}
+		rc.send(dataChan, RowChannelMsg{Row: row, Meta: meta})
 	case DrainRequested:
 		// If we're draining, only forward metadata.
 		if meta != nil {
-			rc.dataChan <- RowChannelMsg{Meta: meta}
+			rc.send(dataChan, RowChannelMsg{Meta: meta})
 		}
 	case ConsumerClosed:
 		// If the consumer is gone, swallow all the rows and the metadata.
@@ -418,6 +559,25 @@ func (rc *RowChannel) Push(
 	return consumerStatus
 }
 
+// send sends msg on dataChan, recording a stall if the channel is full.
+func (rc *RowChannel) send(dataChan chan RowChannelMsg, msg RowChannelMsg) {
+	select {
+	case dataChan <- msg:
+	default:
+		start := timeutil.Now()
+		dataChan <- msg
+		rc.stalls.record(timeutil.Since(start))
+	}
+	if rc.ready != nil {
+		rc.ready <- struct{}{}
+	}
+}
+
+// PushStallStats returns the stalls of the Push calls made so far.
+func (rc *RowChannel) PushStallStats() PushStallStats {
+	return rc.stalls.get()
+}
+
 // ProducerDone is part of the RowReceiver interface.
 func (rc *RowChannel) ProducerDone() {
 	newVal := atomic.AddInt32(&rc.numSenders, -1)
@@ -439,6 +599,9 @@ func (rc *RowChannel) Start(ctx context.Context) context.Context { return ctx }
 
 // Next is part of the RowSource interface.
 func (rc *RowChannel) Next() (sqlbase.EncDatumRow, *distsqlpb.ProducerMetadata) {
+	if rc.senderChans != nil {
+		return rc.nextRoundRobin()
+	}
 	d, ok := <-rc.C
 	if !ok {
 		// No more rows.
@@ -447,6 +610,37 @@ func (rc *RowChannel) Next() (sqlbase.EncDatumRow, *distsqlpb.ProducerMetadata)
 	return d.Row, d.Meta
 }
 
+// nextRoundRobin implements Next with RowChannelRoundRobin.
+func (rc *RowChannel) nextRoundRobin() (sqlbase.EncDatumRow, *distsqlpb.ProducerMetadata) {
+	for rc.numOpen > 0 {
+		// Each token guarantees that a message or a close which the consumer
+		// hasn't observed yet is available on one of the senderChans.
+		<-rc.ready
+		for i := range rc.senderChans {
+			idx := (rc.next + i) % len(rc.senderChans)
+			if rc.closed[idx] {
+				continue
+			}
+			var d RowChannelMsg
+			var ok bool
+			select {
+			case d, ok = <-rc.senderChans[idx]:
+			default:
+				continue
+			}
+			if !ok {
+				rc.closed[idx] = true
+				rc.numOpen--
+				break
+			}
+			rc.next = (idx + 1) % len(rc.senderChans)
+			return d.Row, d.Meta
+		}
+	}
+	// No more rows.
+	return nil, nil
+}
+
 // ConsumerDone is part of the RowSource interface.
 func (rc *RowChannel) ConsumerDone() {
 	rc.consumerDone()
@@ -455,6 +649,17 @@ func (rc *RowChannel) ConsumerDone() {
 // ConsumerClosed is part of the RowSource interface.
 func (rc *RowChannel) ConsumerClosed() {
 	rc.consumerClosed("RowChannel")
+	if rc.senderChans != nil {
+		// Drain a message from each sender in case it is blocked trying to emit a
+		// row.
+		for _, c := range rc.senderChans {
+			select {
+			case <-c:
+			default:
+			}
+		}
+		return
+	}
 	numSenders := atomic.LoadInt32(&rc.numSenders)
 	// Drain (at most) numSenders messages in case senders are blocked trying to
 	// emit a row.
@@ -484,10 +689,18 @@ type BufferedRecord struct {
 // RowBuffer is an implementation of RowReceiver that buffers (accumulates)
 // results in memory, as well as an implementation of RowSource that returns
 // records from a record buffer. Just for tests.
+//
+// By default the buffer is unbounded. RowBufferArgs.Capacity bounds it, which
+// makes it behave like a RowChannel with a slow consumer.
 type RowBuffer struct {
 	mu struct {
 		syncutil.Mutex
 
+		// cond is signaled when records are added or removed, when the producer
+		// is done and when the consumer is closed. It is only used if the
+		// RowBuffer is bounded.
+		cond *sync.Cond
+
 		// producerClosed is used when the RowBuffer is used as a RowReceiver; it is
 		// set to true when the sender calls ProducerDone().
 		producerClosed bool
@@ -507,6 +720,8 @@ type RowBuffer struct {
 	types []types.T
 
 	args RowBufferArgs
+
+	stalls pushStallCounter
 }
 
 var _ RowReceiver = &RowBuffer{}
@@ -531,6 +746,12 @@ type RowBufferArgs struct {
 	OnNext func(*RowBuffer) (sqlbase.EncDatumRow, *distsqlpb.ProducerMetadata)
 	// OnPush, if specified, is called as the first thing in the Push() method.
 	OnPush func(sqlbase.EncDatumRow, *distsqlpb.ProducerMetadata)
+	// Capacity, if positive, is the maximum number of records the RowBuffer
+	// holds. Push blocks while the buffer is full, until Next makes room or the
+	// consumer is closed, and Next blocks while the buffer is empty, until a
+	// record is pushed or ProducerDone is called. This allows tests to model
+	// slow consumers deterministically.
+	Capacity int
 }
 
 // NewRowBuffer creates a RowBuffer with the given schema and initial rows.
@@ -544,9 +765,18 @@ func NewRowBuffer(types []types.T, rows sqlbase.EncDatumRows, hooks RowBufferArg
 	}
 	rb := &RowBuffer{types: types, args: hooks}
 	rb.mu.records = wrappedRows
+	rb.mu.cond = sync.NewCond(&rb.mu.Mutex)
 	return rb
 }
 
+// signalLocked wakes up the Push and Next calls blocked on a bounded
+// RowBuffer. rb.mu must be held.
+func (rb *RowBuffer) signalLocked() {
+	if rb.args.Capacity > 0 {
+		rb.mu.cond.Broadcast()
+	}
+}
+
 // Push is part of the RowReceiver interface.
 func (rb *RowBuffer) Push(
 	row sqlbase.EncDatumRow, meta *distsqlpb.ProducerMetadata,
@@ -560,27 +790,43 @@ func (rb *RowBuffer) Push(
 		panic("Push called after ProducerDone")
 	}
 	// We mimic the behavior of RowChannel.
-	storeRow := func() {
-		rowCopy := append(sqlbase.EncDatumRow(nil), row...)
-		rb.mu.records = append(rb.mu.records, BufferedRecord{Row: rowCopy, Meta: meta})
-	}
 	status := ConsumerStatus(atomic.LoadUint32((*uint32)(&rb.ConsumerStatus)))
-	if rb.args.AccumulateRowsWhileDraining {
-		storeRow()
-	} else {
+	store := rb.args.AccumulateRowsWhileDraining
+	if !store {
 		switch status {
 		case NeedMoreRows:
-			storeRow()
+			store = true
 		case DrainRequested:
-			if meta != nil {
-				storeRow()
-			}
+			store = meta != nil
 		case ConsumerClosed:
 		}
 	}
+	if !store {
+		return status
+	}
+	if rb.args.Capacity > 0 && len(rb.mu.records) >= rb.args.Capacity {
+		start := timeutil.Now()
+		for len(rb.mu.records) >= rb.args.Capacity && status != ConsumerClosed {
+			rb.mu.cond.Wait()
+			status = ConsumerStatus(atomic.LoadUint32((*uint32)(&rb.ConsumerStatus)))
+		}
+		rb.stalls.record(timeutil.Since(start))
+		if status == ConsumerClosed && !rb.args.AccumulateRowsWhileDraining {
+			return status
+		}
+	}
+	rowCopy := append(sqlbase.EncDatumRow(nil), row...)
+	rb.mu.records = append(rb.mu.records, BufferedRecord{Row: rowCopy, Meta: meta})
+	rb.signalLocked()
 	return status
 }
 
+// PushStallStats returns the stalls of the Push calls made so far. Only
+// bounded RowBuffers stall.
+func (rb *RowBuffer) PushStallStats() PushStallStats {
+	return rb.stalls.get()
+}
+
 // ProducerClosed is a utility function used by tests to check whether the
 // RowBuffer has had ProducerDone() called on it.
 func (rb *RowBuffer) ProducerClosed() bool {
@@ -598,6 +844,7 @@ func (rb *RowBuffer) ProducerDone() {
 		panic("RowBuffer already closed")
 	}
 	rb.mu.producerClosed = true
+	rb.signalLocked()
 }
 
 // Types is part of the RowReceiver interface.
@@ -618,8 +865,8 @@ func (rb *RowBuffer) Start(ctx context.Context) context.Context { return ctx }
 
 // Next is part of the RowSource interface.
 //
-// There's no synchronization here with Push(). The assumption is that these
-// two methods are not called concurrently.
+// Next may be called concurrently with Push() only if the RowBuffer is
+// bounded.
 func (rb *RowBuffer) Next() (sqlbase.EncDatumRow, *distsqlpb.ProducerMetadata) {
 	if rb.args.OnNext != nil {
 		row, meta := rb.args.OnNext(rb)
@@ -627,12 +874,20 @@ func (rb *RowBuffer) Next() (sqlbase.EncDatumRow, *distsqlpb.ProducerMetadata) {
 			return row, meta
 		}
 	}
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	if rb.args.Capacity > 0 {
+		for len(rb.mu.records) == 0 && !rb.mu.producerClosed {
+			rb.mu.cond.Wait()
+		}
+	}
 	if len(rb.mu.records) == 0 {
 		rb.Done = true
 		return nil, nil
 	}
 	rec := rb.mu.records[0]
 	rb.mu.records = rb.mu.records[1:]
+	rb.signalLocked()
 	return rec.Row, rec.Meta
 }
 
@@ -653,6 +908,9 @@ func (rb *RowBuffer) ConsumerClosed() {
 		log.Fatalf(context.Background(), "RowBuffer already closed")
 	}
 	atomic.StoreUint32((*uint32)(&rb.ConsumerStatus), uint32(ConsumerClosed))
+	rb.mu.Lock()
+	rb.signalLocked()
+	rb.mu.Unlock()
 	if rb.args.OnConsumerClosed != nil {
 		rb.args.OnConsumerClosed(rb)
 	}
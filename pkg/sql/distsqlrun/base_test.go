@@ -18,13 +18,17 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/pkg/errors"
 )
 
 // Test the behavior of Run in the presence of errors that switch to the drain
@@ -53,6 +57,180 @@ func TestRunDrain(t *testing.T) {
 	}
 }
 
+// TestRowBufferCapacity verifies that a bounded RowBuffer blocks its producer
+// while it is full and that it unblocks it once the consumer is closed.
+func TestRowBufferCapacity(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const numRows = 5
+	t.Run("slow consumer", func(t *testing.T) {
+		var pushes int32
+		rb := NewRowBuffer(sqlbase.OneIntCol, nil /* rows */, RowBufferArgs{
+			Capacity: 2,
+			OnPush: func(sqlbase.EncDatumRow, *distsqlpb.ProducerMetadata) {
+				atomic.AddInt32(&pushes, 1)
+			},
+		})
+		pushed := make(chan struct{}, numRows)
+		go func() {
+			for i := 0; i < numRows; i++ {
+				rb.Push(sqlbase.EncDatumRow{sqlbase.IntEncDatum(i)}, nil /* meta */)
+				pushed <- struct{}{}
+			}
+			rb.ProducerDone()
+		}()
+
+		// The third row can't be pushed until a row is consumed.
+		testutils.SucceedsSoon(t, func() error {
+			if p := atomic.LoadInt32(&pushes); p != 3 {
+				return errors.Errorf("expected 3 pushes, got %d", p)
+			}
+			return nil
+		})
+		if n := len(pushed); n != 2 {
+			t.Fatalf("expected 2 rows to be pushed, got %d", n)
+		}
+
+		for i := 0; i < numRows; i++ {
+			row, meta := rb.Next()
+			if meta != nil || row == nil {
+				t.Fatalf("unexpected record: %v %v", row, meta)
+			}
+			if v := int(*row[0].Datum.(*tree.DInt)); v != i {
+				t.Fatalf("expected row %d, got %d", i, v)
+			}
+		}
+		if row, meta := rb.Next(); row != nil || meta != nil {
+			t.Fatalf("unexpected record: %v %v", row, meta)
+		}
+		if stats := rb.PushStallStats(); stats.Stalls == 0 {
+			t.Fatalf("expected stalls, got %+v", stats)
+		}
+	})
+
+	t.Run("consumer closed", func(t *testing.T) {
+		rb := NewRowBuffer(sqlbase.OneIntCol, nil /* rows */, RowBufferArgs{Capacity: 1})
+		rb.Push(sqlbase.EncDatumRow{sqlbase.IntEncDatum(0)}, nil /* meta */)
+		statusCh := make(chan ConsumerStatus)
+		go func() {
+			statusCh <- rb.Push(sqlbase.EncDatumRow{sqlbase.IntEncDatum(1)}, nil /* meta */)
+		}()
+		rb.ConsumerClosed()
+		if status := <-statusCh; status != ConsumerClosed {
+			t.Fatalf("expected ConsumerClosed, got %d", status)
+		}
+		rb.ProducerDone()
+		if rows := rb.GetRowsNoMeta(t); len(rows) != 1 {
+			t.Fatalf("expected a single row to be buffered, got %d", len(rows))
+		}
+	})
+}
+
+// TestRowChannelPushStalls verifies that a RowChannel records the Push calls
+// which block because of a slow consumer.
+func TestRowChannelPushStalls(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	rc := &RowChannel{}
+	rc.initWithBufSizeAndNumSenders(sqlbase.OneIntCol, 1, 1)
+	const numRows = 100
+	go func() {
+		for i := 0; i < numRows; i++ {
+			rc.Push(sqlbase.EncDatumRow{sqlbase.IntEncDatum(i)}, nil /* meta */)
+		}
+		rc.ProducerDone()
+	}()
+	var rows int
+	for {
+		row, _ := rc.Next()
+		if row == nil {
+			break
+		}
+		rows++
+		time.Sleep(100 * time.Microsecond)
+	}
+	if rows != numRows {
+		t.Fatalf("expected %d rows, got %d", numRows, rows)
+	}
+	if stats := rc.PushStallStats(); stats.Stalls == 0 || stats.StallTime == 0 {
+		t.Fatalf("expected stalls, got %+v", stats)
+	}
+}
+
+// TestRowChannelFairness verifies that, with RowChannelRoundRobin, a sender
+// which fills its share of the buffer of a RowChannel doesn't delay the rows
+// of the other senders.
+func TestRowChannelFairness(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const numRows = 100
+	for _, tc := range []struct {
+		fairness RowChannelFairness
+		// minPos and maxPos bound the position at which the row of the slow
+		// sender is delivered.
+		minPos, maxPos int
+	}{
+		{fairness: RowChannelFIFO, minPos: rowChannelBufSize, maxPos: numRows},
+		{fairness: RowChannelRoundRobin, minPos: 0, maxPos: 1},
+	} {
+		t.Run(fmt.Sprintf("fairness=%d", tc.fairness), func(t *testing.T) {
+			rc := &RowChannel{}
+			rc.InitWithNumSendersAndFairness(sqlbase.OneIntCol, 2 /* numSenders */, tc.fairness)
+			fast, slow := rc.Sender(0), rc.Sender(1)
+			go func() {
+				for i := 1; i <= numRows; i++ {
+					fast.Push(sqlbase.EncDatumRow{sqlbase.IntEncDatum(i)}, nil /* meta */)
+				}
+				fast.ProducerDone()
+			}()
+			// Wait for the fast sender to fill its share of the buffer.
+			fastChan := rc.dataChan
+			if tc.fairness == RowChannelRoundRobin {
+				fastChan = rc.senderChans[0]
+			}
+			testutils.SucceedsSoon(t, func() error {
+				if len(fastChan) < cap(fastChan) {
+					return errors.New("the buffer isn't full")
+				}
+				return nil
+			})
+			// With RowChannelFIFO, the slow sender blocks as the buffer is full.
+			go func() {
+				slow.Push(sqlbase.EncDatumRow{sqlbase.IntEncDatum(0)}, nil /* meta */)
+				slow.ProducerDone()
+			}()
+			if tc.fairness == RowChannelRoundRobin {
+				testutils.SucceedsSoon(t, func() error {
+					if len(rc.senderChans[1]) == 0 {
+						return errors.New("the slow sender didn't push its row")
+					}
+					return nil
+				})
+			}
+
+			pos := -1
+			var rows int
+			for {
+				row, _ := rc.Next()
+				if row == nil {
+					break
+				}
+				if int(*row[0].Datum.(*tree.DInt)) == 0 {
+					pos = rows
+				}
+				rows++
+			}
+			if rows != numRows+1 {
+				t.Fatalf("expected %d rows, got %d", numRows+1, rows)
+			}
+			if pos < tc.minPos || pos > tc.maxPos {
+				t.Fatalf("expected the row of the slow sender at position [%d, %d], got %d",
+					tc.minPos, tc.maxPos, pos)
+			}
+		})
+	}
+}
+
 // Benchmark a pipeline of RowChannels.
 func BenchmarkRowChannelPipeline(b *testing.B) {
 	for _, length := range []int{1, 2, 3, 4} {
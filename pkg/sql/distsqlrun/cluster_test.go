@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
 	"sync/atomic"
 	"testing"
@@ -229,6 +230,20 @@ func TestClusterFlow(t *testing.T) {
 		t.Fatal(resp.Error)
 	}
 
+	// The flows on 0 and 1 can't finish until the flow on 2 is set up to
+	// consume their outputs, so they are listed as running.
+	for i, req := range []*distsqlpb.SetupFlowRequest{req1, req2} {
+		flows := tc.Server(i).DistSQLServer().(*ServerImpl).ListFlows()
+		if len(flows) != 1 {
+			t.Fatalf("expected a single flow running on %d, got %+v", i, flows)
+		}
+		expected := []string{req.Flow.Processors[0].Title()}
+		if flows[0].FlowID != fid || !reflect.DeepEqual(flows[0].Processors, expected) {
+			t.Errorf("expected flow %s with processors %v running on %d, got %+v",
+				fid, expected, i, flows[0])
+		}
+	}
+
 	log.Infof(ctx, "Running flow on 2")
 	stream, err := clients[2].RunSyncFlow(ctx)
 	if err != nil {
@@ -106,6 +106,22 @@ type FlowCtx struct {
 	// diskMonitor is used to monitor temporary storage disk usage.
 	diskMonitor *mon.BytesMonitor
 
+	// metrics is the node's DistSQL metrics. It can be nil in tests.
+	metrics *DistSQLMetrics
+
+	// sharedScans is the registry of the shared table scans on the node. It is
+	// nil if scans are never shared.
+	sharedScans *sharedScanRegistry
+
+	// spanClaims is the registry of the span claim queues of the flows planned
+	// by the node. tableReaders whose flow was planned by this node claim their
+	// spans from it directly.
+	spanClaims *spanClaimRegistry
+
+	// gateway is the node that planned the flow. tableReaders that claim their
+	// spans claim them from it.
+	gateway roachpb.NodeID
+
 	// JobRegistry is used during backfill to load jobs which keep state.
 	JobRegistry *jobs.Registry
 
@@ -216,9 +232,13 @@ func newFlow(
 }
 
 // setupInboundStream adds a stream to the stream map (inboundStreams or
-// localStreams).
+// localStreams). rowLimitHint, if non-zero, is the maximum number of rows the
+// receiver needs from a remote stream; see inputRowLimitHint.
 func (f *Flow) setupInboundStream(
-	ctx context.Context, spec distsqlpb.StreamEndpointSpec, receiver RowReceiver,
+	ctx context.Context,
+	spec distsqlpb.StreamEndpointSpec,
+	receiver RowReceiver,
+	rowLimitHint uint64,
 ) error {
 	sid := spec.StreamID
 	switch spec.Type {
@@ -235,7 +255,9 @@ func (f *Flow) setupInboundStream(
 		if log.V(2) {
 			log.Infof(ctx, "set up inbound stream %d", sid)
 		}
-		f.inboundStreams[sid] = &inboundStreamInfo{receiver: receiver, waitGroup: &f.waitGroup}
+		f.inboundStreams[sid] = &inboundStreamInfo{
+			receiver: receiver, rowLimitHint: rowLimitHint, waitGroup: &f.waitGroup,
+		}
 
 	case distsqlpb.StreamEndpointSpec_LOCAL:
 		if _, found := f.localStreams[sid]; found {
@@ -363,11 +385,25 @@ func (f *Flow) makeProcessor(
 	return proc, nil
 }
 
+// inputRowLimitHint returns the maximum number of rows the given processor
+// needs from any of its input streams, or 0 if there is no such limit. This is
+// the case for a noop processor that only applies a LIMIT (and OFFSET), which
+// is how the planner limits the results of multiple processors on the
+// gateway: no matter how the rows are spread over the input streams, no
+// stream needs to provide more than LIMIT+OFFSET rows.
+func inputRowLimitHint(ps *distsqlpb.ProcessorSpec) uint64 {
+	if ps.Core.Noop == nil || len(ps.Input) != 1 || ps.Post.Limit == 0 || !ps.Post.Filter.Empty() {
+		return 0
+	}
+	return ps.Post.Limit + ps.Post.Offset
+}
+
 // setupInputSyncs populates a slice of input syncs, one for each Processor in
 // f.Spec, each containing one RowSource for each input to that Processor.
 func (f *Flow) setupInputSyncs(ctx context.Context) ([][]RowSource, error) {
 	inputSyncs := make([][]RowSource, len(f.spec.Processors))
 	for pIdx, ps := range f.spec.Processors {
+		rowLimitHint := inputRowLimitHint(&ps)
 		for _, is := range ps.Input {
 			if len(is.Streams) == 0 {
 				return nil, errors.Errorf("input sync with no streams")
@@ -376,9 +412,13 @@ func (f *Flow) setupInputSyncs(ctx context.Context) ([][]RowSource, error) {
 			switch is.Type {
 			case distsqlpb.InputSyncSpec_UNORDERED:
 				mrc := &RowChannel{}
-				mrc.InitWithNumSenders(is.ColumnTypes, len(is.Streams))
-				for _, s := range is.Streams {
-					if err := f.setupInboundStream(ctx, s, mrc); err != nil {
+				fairness := RowChannelFIFO
+				if len(is.Streams) > 1 {
+					fairness = RowChannelFairness(settingUnorderedSyncFairness.Get(&f.FlowCtx.Settings.SV))
+				}
+				mrc.InitWithNumSendersAndFairness(is.ColumnTypes, len(is.Streams), fairness)
+				for i, s := range is.Streams {
+					if err := f.setupInboundStream(ctx, s, mrc.Sender(i), rowLimitHint); err != nil {
 						return nil, err
 					}
 				}
@@ -389,7 +429,7 @@ func (f *Flow) setupInputSyncs(ctx context.Context) ([][]RowSource, error) {
 				for i, s := range is.Streams {
 					rowChan := &RowChannel{}
 					rowChan.InitWithNumSenders(is.ColumnTypes, 1 /* numSenders */)
-					if err := f.setupInboundStream(ctx, s, rowChan); err != nil {
+					if err := f.setupInboundStream(ctx, s, rowChan, rowLimitHint); err != nil {
 						return nil, err
 					}
 					streams[i] = rowChan
@@ -399,6 +439,25 @@ func (f *Flow) setupInputSyncs(ctx context.Context) ([][]RowSource, error) {
 				if err != nil {
 					return nil, err
 				}
+			case distsqlpb.InputSyncSpec_SERIAL_UNORDERED:
+				// Serial synchronizer: create a RowChannel for each input. Processors
+				// producing into the synchronizer might later be fused to it instead
+				// (see setupProcessors), in which case they only start running once
+				// the synchronizer gets to them.
+				streams := make([]RowSource, len(is.Streams))
+				for i, s := range is.Streams {
+					rowChan := &RowChannel{}
+					rowChan.InitWithNumSenders(is.ColumnTypes, 1 /* numSenders */)
+					if err := f.setupInboundStream(ctx, s, rowChan, rowLimitHint); err != nil {
+						return nil, err
+					}
+					streams[i] = rowChan
+				}
+				var err error
+				sync, err = makeSerialSync(is.ColumnTypes, streams)
+				if err != nil {
+					return nil, err
+				}
 
 			default:
 				return nil, errors.Errorf("unsupported input sync type %s", is.Type)
@@ -456,6 +515,17 @@ func (f *Flow) setupProcessors(ctx context.Context, inputSyncs [][]RowSource) er
 					continue
 				}
 				for inIdx, in := range ps.Input {
+					// Serial synchronizers read their streams one at a time, so any of
+					// their local streams can be fused.
+					if in.Type == distsqlpb.InputSyncSpec_SERIAL_UNORDERED {
+						for sIdx := range in.Streams {
+							if in.Streams[sIdx].StreamID == ospec.Streams[0].StreamID {
+								inputSyncs[pIdx][inIdx].(*serialSynchronizer).sources[sIdx] = source
+								return true
+							}
+						}
+						continue
+					}
 					// Look for "simple" inputs: an unordered input (which, by definition,
 					// doesn't require an ordered synchronizer), with a single input stream
 					// (which doesn't require a multiplexed RowChannel).
@@ -563,6 +633,7 @@ func (f *Flow) startInternal(ctx context.Context, doneFn func()) error {
 	for i := 0; i < len(f.processors); i++ {
 		f.waitGroup.Add(1)
 		go func(i int) {
+			defer lockOSThreadIfRecording(ctx)()
 			f.processors[i].Run(ctx)
 			f.waitGroup.Done()
 		}(i)
@@ -623,7 +694,9 @@ func (f *Flow) Run(ctx context.Context, doneFn func()) error {
 		}
 		return err
 	}
+	unlock := lockOSThreadIfRecording(ctx)
 	headProc.Run(ctx)
+	unlock()
 	return nil
 }
 
@@ -54,6 +54,10 @@ type inboundStreamInfo struct {
 	// RowReceiver interface.
 	receiver  RowReceiver
 	connected bool
+	// rowLimitHint, if non-zero, is the maximum number of rows the receiver
+	// needs from this stream. It is sent to the producer in the handshake so
+	// that it can start draining on its own once it has sent that many rows.
+	rowLimitHint uint64
 	// if set, indicates that we waited too long for an inbound connection, or
 	// we don't want this stream to connect anymore due to flow cancellation.
 	canceled bool
@@ -497,6 +501,7 @@ func (fr *flowRegistry) ConnectInboundStream(
 			ConsumerScheduled:  true,
 			Version:            Version,
 			MinAcceptedVersion: MinAcceptedVersion,
+			RowLimitHint:       s.rowLimitHint,
 		},
 	}); err != nil {
 		return nil, nil, nil, err
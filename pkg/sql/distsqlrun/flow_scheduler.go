@@ -17,10 +17,13 @@ package distsqlrun
 import (
 	"container/list"
 	"context"
+	"sort"
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
@@ -49,6 +52,9 @@ type flowScheduler struct {
 		numRunning      int
 		maxRunningFlows int
 		queue           *list.List
+		// runningFlows maps the flows which are currently running to the time
+		// at which they were started.
+		runningFlows map[*Flow]time.Time
 	}
 }
 
@@ -74,6 +80,7 @@ func newFlowScheduler(
 		metrics:        metrics,
 	}
 	fs.mu.queue = list.New()
+	fs.mu.runningFlows = make(map[*Flow]time.Time)
 	fs.mu.maxRunningFlows = int(settingMaxRunningFlows.Get(&settings.SV))
 	settingMaxRunningFlows.SetOnChange(&settings.SV, func() {
 		fs.mu.Lock()
@@ -96,9 +103,11 @@ func (fs *flowScheduler) runFlowNow(ctx context.Context, f *Flow) error {
 	)
 	fs.mu.numRunning++
 	fs.metrics.FlowStart()
+	startTime := timeutil.Now()
 	if err := f.Start(ctx, func() { fs.flowDoneCh <- f }); err != nil {
 		return err
 	}
+	fs.mu.runningFlows[f] = startTime
 	// TODO(radu): we could replace the WaitGroup with a structure that keeps a
 	// refcount and automatically runs Cleanup() when the count reaches 0.
 	go func() {
@@ -134,6 +143,47 @@ func (fs *flowScheduler) ScheduleFlow(ctx context.Context, f *Flow) error {
 		})
 }
 
+// FlowInfo describes a flow run by the flow scheduler of a node. See
+// ServerImpl.ListFlows.
+type FlowInfo struct {
+	FlowID distsqlpb.FlowID
+	// Gateway is the node that planned the flow.
+	Gateway roachpb.NodeID
+	// Start is the time at which the flow was started.
+	Start time.Time
+	// Processors are the titles of the processors of the flow, as shown in flow
+	// diagrams.
+	Processors []string
+	// AllocBytes is the number of bytes currently allocated in the memory
+	// monitor of the flow.
+	AllocBytes int64
+}
+
+// runningFlowInfos returns information about the flows which are currently
+// running, ordered by their start time. Queued flows are not included.
+func (fs *flowScheduler) runningFlowInfos() []FlowInfo {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	infos := make([]FlowInfo, 0, len(fs.mu.runningFlows))
+	for f, startTime := range fs.mu.runningFlows {
+		info := FlowInfo{
+			FlowID:     f.spec.FlowID,
+			Gateway:    f.spec.Gateway,
+			Start:      startTime,
+			Processors: make([]string, len(f.spec.Processors)),
+			AllocBytes: f.EvalCtx.Mon.AllocBytes(),
+		}
+		for i := range f.spec.Processors {
+			info.Processors[i] = f.spec.Processors[i].Title()
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Start.Before(infos[j].Start)
+	})
+	return infos
+}
+
 // Start launches the main loop of the scheduler.
 func (fs *flowScheduler) Start() {
 	ctx := fs.AnnotateCtx(context.Background())
@@ -149,9 +199,10 @@ func (fs *flowScheduler) Start() {
 			}
 			fs.mu.Unlock()
 			select {
-			case <-fs.flowDoneCh:
+			case f := <-fs.flowDoneCh:
 				fs.mu.Lock()
 				fs.mu.numRunning--
+				delete(fs.mu.runningFlows, f)
 				fs.metrics.FlowStop()
 				if !stopped {
 					if frElem := fs.mu.queue.Front(); frElem != nil {
@@ -617,6 +617,10 @@ func (h *hashJoiner) close() {
 			h.rows[rightSide].Close(h.Ctx)
 		}
 		if h.storedRows != nil {
+			if rc, ok := h.storedRows.(*rowcontainer.HashDiskBackedRowContainer); ok &&
+				rc.UsingDisk() && h.flowCtx.metrics != nil {
+				h.flowCtx.metrics.HashJoinerSpills.Inc(1)
+			}
 			h.storedRows.Close(h.Ctx)
 		} else {
 			// h.storedRows has not been initialized, so we need to close the stored
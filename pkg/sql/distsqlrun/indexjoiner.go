@@ -17,6 +17,7 @@ package distsqlrun
 import (
 	"context"
 
+	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/row"
@@ -51,6 +52,9 @@ type indexJoiner struct {
 
 	// keyPrefix is the primary index's key prefix.
 	keyPrefix []byte
+	// pointLookups is set if rows are looked up by their single key rather
+	// than by their span. See JoinReaderSpec.PointLookups.
+	pointLookups bool
 	// spans is the batch of spans we will next retrieve from the index.
 	spans roachpb.Spans
 
@@ -75,10 +79,14 @@ func newIndexJoiner(
 		return nil, errors.Errorf("index join must be against primary index")
 	}
 	ij := &indexJoiner{
-		input:     input,
-		desc:      spec.Table,
-		keyPrefix: sqlbase.MakeIndexKeyPrefix(&spec.Table, spec.Table.PrimaryIndex.ID),
-		batchSize: indexJoinerBatchSize,
+		input:        input,
+		desc:         spec.Table,
+		keyPrefix:    sqlbase.MakeIndexKeyPrefix(&spec.Table, spec.Table.PrimaryIndex.ID),
+		pointLookups: spec.PointLookups,
+		batchSize:    indexJoinerBatchSize,
+	}
+	if ij.pointLookups && len(ij.desc.Families) != 1 {
+		return nil, errors.Errorf("point lookups require a single column family")
 	}
 	needMutations := spec.Visibility == distsqlpb.ScanVisibility_PUBLIC_AND_NOT_PUBLIC
 	if err := ij.Init(
@@ -202,9 +210,15 @@ func (ij *indexJoiner) generateSpan(row sqlbase.EncDatumRow) (roachpb.Span, erro
 	// numKeyCols.
 	keyRow := row[:numKeyCols]
 	types := ij.input.OutputTypes()[:numKeyCols]
-	return sqlbase.MakeSpanFromEncDatums(
+	span, err := sqlbase.MakeSpanFromEncDatums(
 		ij.keyPrefix, keyRow, types, ij.desc.PrimaryIndex.ColumnDirections, &ij.desc,
 		&ij.desc.PrimaryIndex, &ij.alloc)
+	if err != nil || !ij.pointLookups {
+		return span, err
+	}
+	// The whole row is stored in the key of its only column family. The fetcher
+	// looks up spans without an end key with Gets.
+	return roachpb.Span{Key: keys.MakeFamilyKey(span.Key[:len(span.Key):len(span.Key)], uint32(ij.desc.Families[0].ID))}, nil
 }
 
 // outputStatsToTrace outputs the collected indexJoiner stats to the trace. Will
@@ -218,9 +232,14 @@ func (ij *indexJoiner) outputStatsToTrace() {
 	if !ok {
 		return
 	}
+	fetchStats := ij.fetcher.GetKVStats()
 	jrs := &JoinReaderStats{
-		InputStats:       is,
-		IndexLookupStats: ils,
+		InputStats:                         is,
+		IndexLookupStats:                   ils,
+		IndexLookupKvBatches:               fetchStats.Batches,
+		IndexLookupKvResumeSpans:           fetchStats.ResumeSpans,
+		IndexLookupKvNotLeaseHolderRetries: fetchStats.NotLeaseHolderRetries,
+		IndexLookupKvFollowerReads:         fetchStats.FollowerReads,
 	}
 	if sp := opentracing.SpanFromContext(ij.Ctx); sp != nil {
 		tracing.SetSpanStats(sp, jrs)
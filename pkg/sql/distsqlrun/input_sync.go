@@ -78,6 +78,10 @@ type orderedSynchronizer struct {
 	// current row from each source and will only contain source indexes of
 	// sources that are not done.
 	heap []srcIdx
+	// runnerUp is the position in the heap (1 or 2) of the source with the
+	// second smallest current row, or 0 if it hasn't been determined since the
+	// heap was last modified. See rootStillSmallest.
+	runnerUp int
 	// needsAdvance is set when the row at the root of the heap has already been
 	// consumed and thus producing a new row requires the root to be advanced.
 	// This is usually set after a row is produced, but is not set when a metadata
@@ -245,8 +249,14 @@ func (s *orderedSynchronizer) advanceRoot() error {
 
 	if src.row == nil {
 		heap.Remove(s, 0)
+		s.runnerUp = 0
 	} else {
-		heap.Fix(s, 0)
+		if ok, err := s.rootStillSmallest(); err != nil {
+			return err
+		} else if !ok {
+			heap.Fix(s, 0)
+			s.runnerUp = 0
+		}
 		// TODO(radu): this check may be costly, we could disable it in production
 		if cmp, err := oldRow.Compare(s.types, &s.alloc, s.ordering, s.evalCtx, src.row); err != nil {
 			return err
@@ -261,6 +271,37 @@ func (s *orderedSynchronizer) advanceRoot() error {
 	return s.err
 }
 
+// rootStillSmallest returns whether the row at the root of the heap, which has
+// just been advanced, still sorts no later than the rows of all the other
+// sources, in which case the heap doesn't need to be fixed.
+//
+// When a source produces a long run of rows that sort before the current rows
+// of the other sources, this saves a heap.Fix per row: the runner-up is only
+// determined once per run, after which each row needs a single comparison
+// against it.
+func (s *orderedSynchronizer) rootStillSmallest() (bool, error) {
+	if len(s.heap) == 1 {
+		return true, nil
+	}
+	if s.runnerUp == 0 {
+		// The second smallest row is at one of the children of the root.
+		s.runnerUp = 1
+		if len(s.heap) > 2 && s.Less(2, 1) {
+			s.runnerUp = 2
+		}
+		// Less might have set s.err.
+		if s.err != nil {
+			return false, s.err
+		}
+	}
+	root := s.sources[s.heap[0]].row
+	cmp, err := root.Compare(s.types, &s.alloc, s.ordering, s.evalCtx, s.sources[s.heap[s.runnerUp]].row)
+	if err != nil {
+		return false, err
+	}
+	return cmp <= 0, nil
+}
+
 // drainSources consumes all the rows from the sources. All the data is
 // discarded, except the metadata records which are accumulated in s.metadata.
 func (s *orderedSynchronizer) drainSources() {
@@ -377,3 +418,86 @@ func makeOrderedSync(
 	}
 	return s, nil
 }
+
+// serialSynchronizer receives rows from multiple streams and produces a single
+// stream of rows which contains all the rows of the first stream, followed by
+// all the rows of the second stream, and so on. A source is only started once
+// all the previous sources are exhausted; if the consumer stops needing rows
+// before that (e.g. because of a limit), the remaining sources are closed
+// without ever being started.
+type serialSynchronizer struct {
+	ctx context.Context
+
+	types []types.T
+
+	sources []RowSource
+
+	// srcIdx is the index of the source currently being read. The sources
+	// before it have been exhausted, and the sources after it haven't been
+	// started yet.
+	srcIdx int
+
+	// draining is set once ConsumerDone() has been called. From then on, only
+	// the metadata of the current source is forwarded.
+	draining bool
+}
+
+var _ RowSource = &serialSynchronizer{}
+
+// OutputTypes is part of the RowSource interface.
+func (s *serialSynchronizer) OutputTypes() []types.T {
+	return s.types
+}
+
+// Start is part of the RowSource interface.
+func (s *serialSynchronizer) Start(ctx context.Context) context.Context {
+	s.ctx = ctx
+	s.sources[0].Start(ctx)
+	return ctx
+}
+
+// Next is part of the RowSource interface.
+func (s *serialSynchronizer) Next() (sqlbase.EncDatumRow, *distsqlpb.ProducerMetadata) {
+	for s.srcIdx < len(s.sources) {
+		row, meta := s.sources[s.srcIdx].Next()
+		if row != nil || meta != nil {
+			return row, meta
+		}
+		// The current source is exhausted; move on to the next one.
+		s.srcIdx++
+		if s.srcIdx == len(s.sources) {
+			break
+		}
+		if s.draining {
+			// The remaining sources haven't been started, and none of their rows
+			// are needed.
+			s.ConsumerClosed()
+			break
+		}
+		s.sources[s.srcIdx].Start(s.ctx)
+	}
+	return nil, nil
+}
+
+// ConsumerDone is part of the RowSource interface.
+func (s *serialSynchronizer) ConsumerDone() {
+	if s.draining || s.srcIdx == len(s.sources) {
+		return
+	}
+	s.draining = true
+	s.sources[s.srcIdx].ConsumerDone()
+}
+
+// ConsumerClosed is part of the RowSource interface.
+func (s *serialSynchronizer) ConsumerClosed() {
+	for ; s.srcIdx < len(s.sources); s.srcIdx++ {
+		s.sources[s.srcIdx].ConsumerClosed()
+	}
+}
+
+func makeSerialSync(typs []types.T, sources []RowSource) (*serialSynchronizer, error) {
+	if len(sources) == 0 {
+		return nil, errors.Errorf("no sources for serial synchronizer")
+	}
+	return &serialSynchronizer{types: typs, sources: sources}, nil
+}
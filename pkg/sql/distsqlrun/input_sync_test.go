@@ -107,6 +107,44 @@ func TestOrderedSync(t *testing.T) {
 				{v[1], v[0], v[4]},
 			},
 		},
+		{
+			// Sources with runs of rows that sort before the rows of the other
+			// sources.
+			sources: []sqlbase.EncDatumRows{
+				{
+					{v[0], v[0], v[0]},
+					{v[0], v[1], v[0]},
+					{v[0], v[2], v[0]},
+					{v[3], v[0], v[0]},
+					{v[3], v[1], v[0]},
+				},
+				{
+					{v[1], v[0], v[1]},
+					{v[1], v[1], v[1]},
+					{v[4], v[0], v[1]},
+				},
+				{
+					{v[2], v[0], v[2]},
+					{v[5], v[0], v[2]},
+				},
+			},
+			ordering: sqlbase.ColumnOrdering{
+				{ColIdx: 0, Direction: asc},
+				{ColIdx: 1, Direction: asc},
+			},
+			expected: sqlbase.EncDatumRows{
+				{v[0], v[0], v[0]},
+				{v[0], v[1], v[0]},
+				{v[0], v[2], v[0]},
+				{v[1], v[0], v[1]},
+				{v[1], v[1], v[1]},
+				{v[2], v[0], v[2]},
+				{v[3], v[0], v[0]},
+				{v[3], v[1], v[0]},
+				{v[4], v[0], v[1]},
+				{v[5], v[0], v[2]},
+			},
+		},
 	}
 	for testIdx, c := range testCases {
 		var sources []RowSource
@@ -183,6 +221,54 @@ func TestOrderedSyncDrainBeforeNext(t *testing.T) {
 	}
 }
 
+func BenchmarkOrderedSync(b *testing.B) {
+	const numSources = 4
+	const numRows = 1 << 12
+	evalCtx := tree.NewTestingEvalContext(cluster.MakeTestingClusterSettings())
+	defer evalCtx.Stop(context.Background())
+	ordering := sqlbase.ColumnOrdering{{ColIdx: 0, Direction: encoding.Ascending}}
+
+	for _, runLength := range []int{1, 16, numRows} {
+		b.Run(fmt.Sprintf("runLength=%d", runLength), func(b *testing.B) {
+			// The sources take turns producing runs of runLength rows.
+			rows := make([]sqlbase.EncDatumRows, numSources)
+			for i := 0; i < numSources*numRows; i++ {
+				src := (i / runLength) % numSources
+				rows[src] = append(rows[src], sqlbase.EncDatumRow{
+					sqlbase.DatumToEncDatum(types.Int, tree.NewDInt(tree.DInt(i))),
+				})
+			}
+			inputs := make([]*RepeatableRowSource, numSources)
+			sources := make([]RowSource, numSources)
+			for i := range sources {
+				inputs[i] = NewRepeatableRowSource(sqlbase.OneIntCol, rows[i])
+				sources[i] = inputs[i]
+			}
+			b.SetBytes(int64(numSources * numRows * 8))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				for _, input := range inputs {
+					input.Reset()
+				}
+				s, err := makeOrderedSync(ordering, evalCtx, sources)
+				if err != nil {
+					b.Fatal(err)
+				}
+				s.Start(context.Background())
+				for {
+					row, meta := s.Next()
+					if meta != nil {
+						b.Fatalf("unexpected metadata: %v", meta)
+					}
+					if row == nil {
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
 func TestUnorderedSync(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -277,3 +363,95 @@ func TestUnorderedSync(t *testing.T) {
 		t.Error("Did not receive expected error")
 	}
 }
+
+// startRecordingRowBuffer is a RowBuffer which records whether it was started.
+type startRecordingRowBuffer struct {
+	*RowBuffer
+	started bool
+}
+
+func (rb *startRecordingRowBuffer) Start(ctx context.Context) context.Context {
+	rb.started = true
+	return rb.RowBuffer.Start(ctx)
+}
+
+func TestSerialSync(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	v := [6]sqlbase.EncDatum{}
+	for i := range v {
+		v[i] = sqlbase.DatumToEncDatum(types.Int, tree.NewDInt(tree.DInt(i)))
+	}
+	makeSources := func() []*startRecordingRowBuffer {
+		return []*startRecordingRowBuffer{
+			{RowBuffer: NewRowBuffer(sqlbase.OneIntCol, sqlbase.EncDatumRows{{v[0]}, {v[1]}}, RowBufferArgs{})},
+			{RowBuffer: NewRowBuffer(sqlbase.OneIntCol, nil /* rows */, RowBufferArgs{})},
+			{RowBuffer: NewRowBuffer(sqlbase.OneIntCol, sqlbase.EncDatumRows{{v[2]}, {v[3]}}, RowBufferArgs{})},
+			{RowBuffer: NewRowBuffer(sqlbase.OneIntCol, sqlbase.EncDatumRows{{v[4]}, {v[5]}}, RowBufferArgs{})},
+		}
+	}
+	makeSync := func(sources []*startRecordingRowBuffer) *serialSynchronizer {
+		rowSources := make([]RowSource, len(sources))
+		for i := range sources {
+			rowSources[i] = sources[i]
+		}
+		s, err := makeSerialSync(sqlbase.OneIntCol, rowSources)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Start(context.Background())
+		return s
+	}
+
+	t.Run("all", func(t *testing.T) {
+		sources := makeSources()
+		s := makeSync(sources)
+		var retRows sqlbase.EncDatumRows
+		for {
+			row, meta := s.Next()
+			if meta != nil {
+				t.Fatalf("unexpected metadata: %v", meta)
+			}
+			if row == nil {
+				break
+			}
+			retRows = append(retRows, row)
+		}
+		expected := sqlbase.EncDatumRows{{v[0]}, {v[1]}, {v[2]}, {v[3]}, {v[4]}, {v[5]}}
+		if exp, ret := expected.String(sqlbase.OneIntCol), retRows.String(sqlbase.OneIntCol); exp != ret {
+			t.Errorf("expected %s, got %s", exp, ret)
+		}
+		for i, src := range sources {
+			if !src.started || !src.Done {
+				t.Errorf("expected source %d to be started and exhausted", i)
+			}
+		}
+	})
+
+	t.Run("drain", func(t *testing.T) {
+		sources := makeSources()
+		s := makeSync(sources)
+		if row, _ := s.Next(); row == nil {
+			t.Fatal("expected a row")
+		}
+		s.ConsumerDone()
+		// The current source is drained, but the later ones aren't started.
+		for {
+			row, meta := s.Next()
+			if row == nil && meta == nil {
+				break
+			}
+		}
+		if sources[0].ConsumerStatus != DrainRequested {
+			t.Errorf("expected the first source to be drained, got %d", sources[0].ConsumerStatus)
+		}
+		for i, src := range sources[1:] {
+			if src.started {
+				t.Errorf("expected source %d not to be started", i+1)
+			}
+			if src.ConsumerStatus != ConsumerClosed {
+				t.Errorf("expected source %d to be closed, got %d", i+1, src.ConsumerStatus)
+			}
+		}
+	})
+}
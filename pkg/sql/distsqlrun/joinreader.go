@@ -529,6 +529,12 @@ func (jrs *JoinReaderStats) Stats() map[string]string {
 	for k, v := range toMerge {
 		statsMap[k] = v
 	}
+	for k, v := range kvStats(
+		joinReaderTagPrefix+"index.", jrs.IndexLookupKvBatches, jrs.IndexLookupKvResumeSpans,
+		jrs.IndexLookupKvNotLeaseHolderRetries, jrs.IndexLookupKvFollowerReads,
+	) {
+		statsMap[k] = v
+	}
 	return statsMap
 }
 
@@ -538,7 +544,10 @@ func (jrs *JoinReaderStats) StatsForQueryPlan() []string {
 		jrs.InputStats.StatsForQueryPlan(""),
 		jrs.IndexLookupStats.StatsForQueryPlan("index ")...,
 	)
-	return is
+	return append(is, kvStatsForQueryPlan(
+		"index ", jrs.IndexLookupKvBatches, jrs.IndexLookupKvResumeSpans,
+		jrs.IndexLookupKvNotLeaseHolderRetries, jrs.IndexLookupKvFollowerReads,
+	)...)
 }
 
 // outputStatsToTrace outputs the collected joinReader stats to the trace. Will
@@ -553,9 +562,14 @@ func (jr *joinReader) outputStatsToTrace() {
 		return
 	}
 
+	fetchStats := jr.fetcher.GetKVStats()
 	jrs := &JoinReaderStats{
-		InputStats:       is,
-		IndexLookupStats: ils,
+		InputStats:                         is,
+		IndexLookupStats:                   ils,
+		IndexLookupKvBatches:               fetchStats.Batches,
+		IndexLookupKvResumeSpans:           fetchStats.ResumeSpans,
+		IndexLookupKvNotLeaseHolderRetries: fetchStats.NotLeaseHolderRetries,
+		IndexLookupKvFollowerReads:         fetchStats.FollowerReads,
 	}
 	if sp := opentracing.SpanFromContext(jr.Ctx); sp != nil {
 		tracing.SetSpanStats(sp, jrs)
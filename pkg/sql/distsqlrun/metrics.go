@@ -31,6 +31,14 @@ type DistSQLMetrics struct {
 	QueueWaitHist *metric.Histogram
 	MaxBytesHist  *metric.Histogram
 	CurBytesCount *metric.Gauge
+
+	SorterSpills     *metric.Counter
+	HashJoinerSpills *metric.Counter
+
+	OutboxStalls    *metric.Counter
+	OutboxStallTime *metric.Counter
+
+	SpansStolen *metric.Counter
 }
 
 // MetricStruct implements the metrics.Struct interface.
@@ -87,6 +95,36 @@ var (
 		Measurement: "Memory",
 		Unit:        metric.Unit_BYTES,
 	}
+	metaSorterSpills = metric.Metadata{
+		Name:        "sql.distsql.spills.sorter",
+		Help:        "Number of distributed SQL sorters that spilled to disk",
+		Measurement: "Processors",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaHashJoinerSpills = metric.Metadata{
+		Name:        "sql.distsql.spills.hash_joiner",
+		Help:        "Number of distributed SQL hash joiners that spilled to disk",
+		Measurement: "Processors",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaOutboxStalls = metric.Metadata{
+		Name:        "sql.distsql.outbox.stalls",
+		Help:        "Number of times distributed SQL processors blocked because the buffer of an outbox to a remote node was full",
+		Measurement: "Stalls",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaOutboxStallTime = metric.Metadata{
+		Name:        "sql.distsql.outbox.stall_time",
+		Help:        "Total time distributed SQL processors spent blocked because the buffer of an outbox to a remote node was full",
+		Measurement: "Nanoseconds",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaSpansStolen = metric.Metadata{
+		Name:        "sql.distsql.spans.stolen",
+		Help:        "Number of spans claimed by table readers from the spans planned on other nodes",
+		Measurement: "Spans",
+		Unit:        metric.Unit_COUNT,
+	}
 )
 
 // See pkg/sql/mem_metrics.go
@@ -104,6 +142,14 @@ func MakeDistSQLMetrics(histogramWindow time.Duration) DistSQLMetrics {
 		QueueWaitHist: metric.NewLatency(metaQueueWaitHist, histogramWindow),
 		MaxBytesHist:  metric.NewHistogram(metaMemMaxBytes, histogramWindow, log10int64times1000, 3),
 		CurBytesCount: metric.NewGauge(metaMemCurBytes),
+
+		SorterSpills:     metric.NewCounter(metaSorterSpills),
+		HashJoinerSpills: metric.NewCounter(metaHashJoinerSpills),
+
+		OutboxStalls:    metric.NewCounter(metaOutboxStalls),
+		OutboxStallTime: metric.NewCounter(metaOutboxStallTime),
+
+		SpansStolen: metric.NewCounter(metaSpansStolen),
 	}
 }
 
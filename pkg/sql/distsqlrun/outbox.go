@@ -19,9 +19,12 @@ import (
 	"fmt"
 	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/rpc"
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
@@ -33,8 +36,22 @@ import (
 	"google.golang.org/grpc"
 )
 
-const outboxBufRows = 16
-const outboxFlushPeriod = 100 * time.Microsecond
+// outboxMaxBufRows bounds the number of rows sent in a single message,
+// regardless of their size. This matters for rows without columns, which don't
+// take up any space in the message.
+const outboxMaxBufRows = 1024
+
+var outboxTargetMessageSize = settings.RegisterByteSizeSetting(
+	"sql.distsql.outbox.target_message_size",
+	"size of the encoded rows at which a DistSQL outbox sends them to the consumer",
+	64<<10,
+)
+
+var outboxMaxFlushDelay = settings.RegisterNonNegativeDurationSetting(
+	"sql.distsql.outbox.max_flush_delay",
+	"maximum amount of time a DistSQL outbox buffers rows before sending them to the consumer",
+	100*time.Microsecond,
+)
 
 // preferredEncoding is the encoding used for EncDatums that don't already have
 // an encoding available.
@@ -47,8 +64,10 @@ type flowStream interface {
 
 // outbox implements an outgoing mailbox as a RowReceiver that receives rows and
 // sends them to a gRPC stream. Its core logic runs in a goroutine. We send rows
-// when we accumulate outboxBufRows or every outboxFlushPeriod (whichever comes
-// first).
+// when their encoding reaches sql.distsql.outbox.target_message_size, when we
+// accumulate outboxMaxBufRows, or sql.distsql.outbox.max_flush_delay after the
+// first buffered row (whichever comes first). This way wide rows don't result
+// in huge messages and narrow rows don't result in lots of tiny ones.
 type outbox struct {
 	// RowChannel implements the RowReceiver interface.
 	RowChannel
@@ -63,6 +82,10 @@ type outbox struct {
 	encoder StreamEncoder
 	// numRows is the number of rows that have been accumulated in the encoder.
 	numRows int
+	// targetMessageSize and maxFlushDelay are read from the cluster settings
+	// when the outbox starts.
+	targetMessageSize int
+	maxFlushDelay     time.Duration
 
 	// flowCtxCancel is the cancellation function for this flow's ctx; context
 	// cancellation is used to stop processors on this flow. It is invoked
@@ -72,6 +95,13 @@ type outbox struct {
 
 	err error
 
+	// rowLimitHint, if non-zero, is the maximum number of rows the consumer
+	// needs from this outbox, as sent by the consumer in its handshake. It is
+	// set by the goroutine listening for consumer signals and so is accessed
+	// atomically. Once that many rows have been sent, the outbox drains its
+	// producer without waiting for the consumer to ask for it.
+	rowLimitHint uint64
+
 	statsCollectionEnabled bool
 	stats                  OutboxStats
 }
@@ -136,7 +166,8 @@ func (m *outbox) addRow(
 	}
 	m.numRows++
 	var flushErr error
-	if m.numRows >= outboxBufRows || mustFlush {
+	if m.encoder.PayloadSize() >= m.targetMessageSize || m.numRows >= outboxMaxBufRows ||
+		mustFlush {
 		flushErr = m.flush(ctx)
 	}
 	if encodingErr != nil {
@@ -179,6 +210,21 @@ func (m *outbox) flush(ctx context.Context) error {
 	return nil
 }
 
+// recordStalls records the backpressure the outbox applied to its producer,
+// because the stream or the consumer were slower than the producer, in the
+// DistSQL metrics.
+func (m *outbox) recordStalls(ctx context.Context) {
+	stats := m.RowChannel.PushStallStats()
+	if stats.Stalls == 0 {
+		return
+	}
+	log.VEventf(ctx, 2, "outbox: producer blocked %d times for %s", stats.Stalls, stats.StallTime)
+	if m.flowCtx != nil && m.flowCtx.metrics != nil {
+		m.flowCtx.metrics.OutboxStalls.Inc(stats.Stalls)
+		m.flowCtx.metrics.OutboxStallTime.Inc(int64(stats.StallTime))
+	}
+}
+
 // mainLoop reads from m.RowChannel and writes to the output stream through
 // addRow()/flush() until the producer doesn't have any more data to send or an
 // error happened.
@@ -193,6 +239,7 @@ func (m *outbox) flush(ctx context.Context) error {
 // Depending on the specific error, the stream might or might not need to be
 // closed. In case it doesn't, m.stream has been set to nil.
 func (m *outbox) mainLoop(ctx context.Context) error {
+	defer m.recordStalls(ctx)
 	// No matter what happens, we need to make sure we close our RowChannel, since
 	// writers could be writing to it as soon as we are started.
 	defer m.RowChannel.ConsumerClosed()
@@ -214,22 +261,22 @@ func (m *outbox) mainLoop(ctx context.Context) error {
 	}()
 
 	if m.stream == nil {
-		var conn *grpc.ClientConn
-		var err error
-		conn, err = m.flowCtx.nodeDialer.Dial(ctx, m.nodeID)
-		if err != nil {
-			return err
-		}
-		client := distsqlpb.NewDistSQLClient(conn)
-		if log.V(2) {
-			log.Infof(ctx, "outbox: calling FlowStream")
-		}
-		// The context used here escapes, so it has to be a background context.
-		m.stream, err = client.FlowStream(context.TODO())
-		if err != nil {
-			if log.V(1) {
-				log.Infof(ctx, "FlowStream error: %s", err)
-			}
+		if err := RetryStreamSetup(ctx, m.flowCtx.nodeDialer, m.nodeID, rpc.DistSQLFlowClass,
+			func(ctx context.Context, conn *grpc.ClientConn) error {
+				client := distsqlpb.NewDistSQLClient(conn)
+				if log.V(2) {
+					log.Infof(ctx, "outbox: calling FlowStream")
+				}
+				// The context used here escapes, so it has to be a background context.
+				var err error
+				m.stream, err = client.FlowStream(context.TODO())
+				if err != nil {
+					if log.V(1) {
+						log.Infof(ctx, "FlowStream error: %s", err)
+					}
+				}
+				return err
+			}); err != nil {
 			return err
 		}
 		if log.V(2) {
@@ -237,10 +284,17 @@ func (m *outbox) mainLoop(ctx context.Context) error {
 		}
 	}
 
+	sv := &m.flowCtx.Settings.SV
+	m.targetMessageSize = int(outboxTargetMessageSize.Get(sv))
+	m.maxFlushDelay = outboxMaxFlushDelay.Get(sv)
+
 	var flushTimer timeutil.Timer
 	defer flushTimer.Stop()
 
 	draining := false
+	// rowsSent is the number of rows (as opposed to metadata) that have been
+	// added to the stream, checked against the consumer's rowLimitHint.
+	var rowsSent uint64
 
 	// TODO(andrei): It's unfortunate that we're spawning a goroutine for every
 	// outgoing stream, but I'm not sure what to do instead. The streams don't
@@ -298,7 +352,18 @@ func (m *outbox) mainLoop(ctx context.Context) error {
 				// If the message to add was metadata, a flush was already forced. If
 				// this is our first row, restart the flushTimer.
 				if m.numRows == 1 {
-					flushTimer.Reset(outboxFlushPeriod)
+					flushTimer.Reset(m.maxFlushDelay)
+				}
+				if msg.Meta == nil && !draining {
+					rowsSent++
+					if hint := atomic.LoadUint64(&m.rowLimitHint); hint != 0 && rowsSent >= hint {
+						// The consumer doesn't need any more rows from us. Drain the
+						// producer now rather than when the consumer gets around to
+						// asking for it.
+						log.VEventf(ctx, 2, "outbox reached row limit hint of %d rows", hint)
+						draining = true
+						m.RowChannel.ConsumerDone()
+					}
 				}
 			}
 		case <-flushTimer.C:
@@ -402,6 +467,9 @@ func (m *outbox) listenForDrainSignalFromConsumer(ctx context.Context) (<-chan d
 			case signal.Handshake != nil:
 				log.Eventf(ctx, "Consumer sent handshake. Consuming flow scheduled: %t",
 					signal.Handshake.ConsumerScheduled)
+				if hint := signal.Handshake.RowLimitHint; hint != 0 {
+					atomic.StoreUint64(&m.rowLimitHint, hint)
+				}
 			}
 		}
 	}); err != nil {
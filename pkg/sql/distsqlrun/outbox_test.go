@@ -19,7 +19,9 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -202,6 +204,113 @@ func TestOutbox(t *testing.T) {
 	streamNotification.Donec <- nil
 }
 
+// Test that an outbox drains its producer on its own once it has sent the
+// number of rows the consumer asked for in its handshake.
+func TestOutboxDrainsAfterRowLimitHint(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	clock := hlc.NewClock(hlc.UnixNano, time.Nanosecond)
+	clusterID, mockServer, addr, err := StartMockDistSQLServer(clock, stopper, staticNodeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := cluster.MakeTestingClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(context.Background())
+
+	clientRPC := rpc.NewInsecureTestingContextWithClusterID(clock, stopper, clusterID)
+	flowCtx := FlowCtx{
+		Settings:   st,
+		stopper:    stopper,
+		EvalCtx:    &evalCtx,
+		nodeDialer: nodedialer.New(clientRPC, staticAddressResolver(addr)),
+	}
+	flowID := distsqlpb.FlowID{UUID: uuid.MakeV4()}
+	streamID := distsqlpb.StreamID(42)
+	outbox := newOutbox(&flowCtx, staticNodeID, flowID, streamID)
+	outbox.init(sqlbase.OneIntCol)
+	var outboxWG sync.WaitGroup
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	outbox.start(ctx, &outboxWG, cancel)
+
+	streamNotification := <-mockServer.InboundStreams
+	serverStream := streamNotification.Stream
+
+	const rowLimitHint = 3
+	if err := serverStream.Send(&distsqlpb.ConsumerSignal{
+		Handshake: &distsqlpb.ConsumerHandshake{
+			ConsumerScheduled:  true,
+			Version:            Version,
+			MinAcceptedVersion: MinAcceptedVersion,
+			RowLimitHint:       rowLimitHint,
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	testutils.SucceedsSoon(t, func() error {
+		if hint := atomic.LoadUint64(&outbox.rowLimitHint); hint != rowLimitHint {
+			return errors.Errorf("expected row limit hint %d, got %d", rowLimitHint, hint)
+		}
+		return nil
+	})
+
+	// Start a producer which sends rows until it is asked to drain, without the
+	// consumer ever sending a drain request.
+	producerC := make(chan error)
+	go func() {
+		producerC <- func() error {
+			for i := 0; ; i++ {
+				row := sqlbase.EncDatumRow{
+					sqlbase.DatumToEncDatum(types.Int, tree.NewDInt(tree.DInt(i))),
+				}
+				consumerStatus := outbox.Push(row, nil /* meta */)
+				if consumerStatus == DrainRequested {
+					break
+				}
+				if consumerStatus == ConsumerClosed {
+					return errors.Errorf("consumer closed prematurely")
+				}
+			}
+			outbox.Push(nil /* row */, &distsqlpb.ProducerMetadata{Err: errors.Errorf("meta 0")})
+			outbox.ProducerDone()
+			return nil
+		}()
+	}()
+
+	var decoder StreamDecoder
+	var rows sqlbase.EncDatumRows
+	var metas []distsqlpb.ProducerMetadata
+	for {
+		msg, err := serverStream.Recv()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatal(err)
+		}
+		if err := decoder.AddMessage(msg); err != nil {
+			t.Fatal(err)
+		}
+		rows, metas = testGetDecodedRows(t, &decoder, rows, metas)
+	}
+	if err := <-producerC; err != nil {
+		t.Fatalf("%+v", err)
+	}
+
+	if str, expected := rows.String(sqlbase.OneIntCol), "[[0] [1] [2]]"; str != expected {
+		t.Errorf("invalid results: %s, expected %s", str, expected)
+	}
+	if len(metas) != 1 || !testutils.IsError(metas[0].Err, "meta 0") {
+		t.Errorf("expected the producer's metadata, got: %+v", metas)
+	}
+
+	outboxWG.Wait()
+	streamNotification.Donec <- nil
+}
+
 // Test that an outbox connects its stream as soon as possible (i.e. before
 // receiving any rows). This is important, since there's a timeout on waiting on
 // the server-side for the streams to be connected.
@@ -490,7 +599,7 @@ func TestOutboxUnblocksProducers(t *testing.T) {
 	outbox.init(sqlbase.OneIntCol)
 
 	// Fill up the outbox.
-	for i := 0; i < outboxBufRows; i++ {
+	for i := 0; i < rowChannelBufSize; i++ {
 		outbox.Push(nil, &distsqlpb.ProducerMetadata{})
 	}
 
@@ -518,6 +627,79 @@ func TestOutboxUnblocksProducers(t *testing.T) {
 	blockedPusherWg.Wait()
 }
 
+// recordingFlowStream is a flowStream that records the messages sent on it.
+type recordingFlowStream struct {
+	msgs []distsqlpb.ProducerMessage
+}
+
+func (s *recordingFlowStream) Send(msg *distsqlpb.ProducerMessage) error {
+	m := *msg
+	m.Data.RawBytes = append([]byte(nil), msg.Data.RawBytes...)
+	s.msgs = append(s.msgs, m)
+	return nil
+}
+
+func (s *recordingFlowStream) Recv() (*distsqlpb.ConsumerSignal, error) {
+	return nil, io.EOF
+}
+
+// TestOutboxMessageSizing verifies that the outbox sends rows once their
+// encoding reaches the target message size, so that the number of rows per
+// message adapts to the width of the rows.
+func TestOutboxMessageSizing(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	for _, tc := range []struct {
+		description string
+		width       int
+		rowsPerMsg  int
+	}{
+		{width: 1, rowsPerMsg: outboxMaxBufRows, description: "narrow"},
+		{width: 1000, rowsPerMsg: 10, description: "wide"},
+		{width: 20000, rowsPerMsg: 1, description: "huge"},
+	} {
+		t.Run(tc.description, func(t *testing.T) {
+			var stream recordingFlowStream
+			m := &outbox{stream: &stream, targetMessageSize: 10000}
+			m.init([]types.T{*types.Bytes})
+			// Send the header, as the outbox does when it starts.
+			if err := m.flush(ctx); err != nil {
+				t.Fatal(err)
+			}
+			row := sqlbase.EncDatumRow{
+				sqlbase.DatumToEncDatum(types.Bytes, tree.NewDBytes(tree.DBytes(strings.Repeat("a", tc.width)))),
+			}
+			const numMsgs = 3
+			for i := 0; i < numMsgs*tc.rowsPerMsg; i++ {
+				if err := m.addRow(ctx, row, nil /* meta */); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if len(stream.msgs) != numMsgs+1 {
+				t.Fatalf("expected %d messages, got %d", numMsgs+1, len(stream.msgs))
+			}
+			if m.numRows != 0 {
+				t.Fatalf("expected no buffered rows, got %d", m.numRows)
+			}
+			var sd StreamDecoder
+			for _, msg := range stream.msgs {
+				msg := msg
+				if err := sd.AddMessage(&msg); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for i := 0; i < numMsgs*tc.rowsPerMsg; i++ {
+				if row, _, err := sd.GetRow(nil /* rowBuf */); err != nil {
+					t.Fatal(err)
+				} else if row == nil {
+					t.Fatalf("expected %d rows, got %d", numMsgs*tc.rowsPerMsg, i)
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkOutbox(b *testing.B) {
 	defer leaktest.AfterTest(b)()
 
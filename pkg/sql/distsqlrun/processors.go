@@ -929,6 +929,7 @@ func getInputStats(flowCtx *FlowCtx, input RowSource) (InputStats, bool) {
 	}
 	if flowCtx.testingKnobs.DeterministicStats {
 		isc.InputStats.StallTime = 0
+		isc.InputStats.CPUTime = 0
 	}
 	return isc.InputStats, true
 }
@@ -76,7 +76,7 @@ import (
 //
 // ATTENTION: When updating these fields, add to version_history.txt explaining
 // what changed.
-const Version distsqlpb.DistSQLVersion = 23
+const Version distsqlpb.DistSQLVersion = 24
 
 // MinAcceptedVersion is the oldest version that the server is
 // compatible with; see above.
@@ -106,6 +106,36 @@ var settingWorkMemBytes = settings.RegisterByteSizeSetting(
 	64*1024*1024, /* 64MB */
 )
 
+// settingKVBatchWorkMemFraction is the fraction of sql.distsql.temp_storage.workmem
+// that the KV batches of a table reader are limited to, on top of their limit
+// on the number of keys.
+var settingKVBatchWorkMemFraction = settings.RegisterValidatedFloatSetting(
+	"sql.distsql.kv_batch_workmem_fraction",
+	"fraction of sql.distsql.temp_storage.workmem which a KV batch of a table scan may return "+
+		"in addition to being limited by number of keys, or 0 to only limit the number of keys",
+	0.125,
+	func(v float64) error {
+		if v < 0 || v > 1 {
+			return errors.Errorf("fraction must be in [0, 1]: %f", v)
+		}
+		return nil
+	},
+)
+
+// settingUnorderedSyncFairness is the RowChannelFairness of the unordered
+// synchronizers which merge multiple streams.
+var settingUnorderedSyncFairness = settings.RegisterEnumSetting(
+	"sql.distsql.unordered_sync.fairness",
+	"policy used by unordered synchronizers to deliver the rows of their streams: "+
+		"fifo delivers them in arrival order, round_robin alternates between the streams "+
+		"so that a fast stream cannot delay the others",
+	"fifo",
+	map[int64]string{
+		int64(RowChannelFIFO):       "fifo",
+		int64(RowChannelRoundRobin): "round_robin",
+	},
+)
+
 var noteworthyMemoryUsageBytes = envutil.EnvOrDefaultInt64("COCKROACH_NOTEWORTHY_DISTSQL_MEMORY_USAGE", 1024*1024 /* 1MB */)
 
 // ServerConfig encompasses the configuration required to create a
@@ -186,6 +216,8 @@ type ServerImpl struct {
 	ServerConfig
 	flowRegistry  *flowRegistry
 	flowScheduler *flowScheduler
+	sharedScans   *sharedScanRegistry
+	spanClaims    *spanClaimRegistry
 	memMonitor    mon.BytesMonitor
 	regexpCache   *tree.RegexpCache
 }
@@ -199,6 +231,8 @@ func NewServer(ctx context.Context, cfg ServerConfig) *ServerImpl {
 		regexpCache:   tree.NewRegexpCache(512),
 		flowRegistry:  makeFlowRegistry(cfg.NodeID.Get()),
 		flowScheduler: newFlowScheduler(cfg.AmbientContext, cfg.Stopper, cfg.Settings, cfg.Metrics),
+		sharedScans:   newSharedScanRegistry(cfg.AmbientContext, cfg.Stopper, cfg.DB, cfg.NodeID),
+		spanClaims:    newSpanClaimRegistry(cfg.Metrics),
 		memMonitor: mon.MakeMonitor(
 			"distsql",
 			mon.MemoryResource,
@@ -455,6 +489,10 @@ func (ds *ServerImpl) setupFlow(
 		TempStorage:    ds.TempStorage,
 		BulkAdder:      ds.BulkAdder,
 		diskMonitor:    ds.DiskMonitor,
+		metrics:        ds.Metrics,
+		sharedScans:    ds.sharedScans,
+		spanClaims:     ds.spanClaims,
+		gateway:        req.Flow.Gateway,
 		JobRegistry:    ds.JobRegistry,
 		traceKV:        req.TraceKV,
 		local:          localState.IsLocal,
@@ -577,6 +615,13 @@ func (ds *ServerImpl) SetupFlow(
 	return &distsqlpb.SimpleResponse{}, nil
 }
 
+// ListFlows returns information about the flows set up through SetupFlow
+// which are currently running on this node. Flows which are queued because
+// too many flows are running are not included.
+func (ds *ServerImpl) ListFlows() []FlowInfo {
+	return ds.flowScheduler.runningFlowInfos()
+}
+
 func (ds *ServerImpl) flowStreamInt(
 	ctx context.Context, stream distsqlpb.DistSQL_FlowStreamServer,
 ) error {
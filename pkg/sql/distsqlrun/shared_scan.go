@@ -0,0 +1,356 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/row"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/pkg/errors"
+)
+
+var sharedScansEnabled = settings.RegisterBoolSetting(
+	"sql.distsql.shared_scans.enabled",
+	"if set, identical AS OF SYSTEM TIME table scans starting concurrently on a node "+
+		"read the data only once",
+	false,
+)
+
+// sharedScanBatchSize is the number of rows a sharedScan hands to its
+// subscribers at a time.
+const sharedScanBatchSize = 64
+
+// sharedScanBufferedBatches is the number of batches a subscriber can fall
+// behind before it holds up the sharedScan, and with it the other subscribers.
+const sharedScanBufferedBatches = 4
+
+var errSharedScanInterrupted = errors.New("shared scan was interrupted")
+
+// sharedScanSpec describes the scan performed by a sharedScan.
+type sharedScanSpec struct {
+	desc          *sqlbase.TableDescriptor
+	indexIdx      int
+	reverse       bool
+	visibility    distsqlpb.ScanVisibility
+	neededColumns util.FastIntSet
+	spans         roachpb.Spans
+	ts            hlc.Timestamp
+}
+
+// key returns a string that is equal for the specs of scans that produce the
+// same rows.
+func (s *sharedScanSpec) key() string {
+	var b []byte
+	b = encoding.EncodeUvarintAscending(b, uint64(s.desc.ID))
+	b = encoding.EncodeUvarintAscending(b, uint64(s.desc.Version))
+	b = encoding.EncodeUvarintAscending(b, uint64(s.indexIdx))
+	if s.reverse {
+		b = encoding.EncodeUvarintAscending(b, 1)
+	} else {
+		b = encoding.EncodeUvarintAscending(b, 0)
+	}
+	b = encoding.EncodeUvarintAscending(b, uint64(s.visibility))
+	b = encoding.EncodeVarintAscending(b, s.ts.WallTime)
+	b = encoding.EncodeVarintAscending(b, int64(s.ts.Logical))
+	b = encoding.EncodeUvarintAscending(b, uint64(len(s.spans)))
+	for _, sp := range s.spans {
+		b = encoding.EncodeBytesAscending(b, sp.Key)
+		b = encoding.EncodeBytesAscending(b, sp.EndKey)
+	}
+	b = append(b, s.neededColumns.String()...)
+	return string(b)
+}
+
+// sharedScanTimestamp returns the timestamp at which a scan on behalf of the
+// given transaction can be shared with scans on behalf of other transactions,
+// if it can be. This is only the case if the transaction reads at a fixed
+// timestamp without an uncertainty interval, as AS OF SYSTEM TIME queries do,
+// and hasn't written anything that the scan would have to see.
+func sharedScanTimestamp(txn *client.Txn) (hlc.Timestamp, bool) {
+	proto := txn.Serialize()
+	if !proto.OrigTimestampWasObserved || proto.Sequence != 0 ||
+		proto.Timestamp != proto.OrigTimestamp || proto.MaxTimestamp != proto.OrigTimestamp {
+		return hlc.Timestamp{}, false
+	}
+	return proto.OrigTimestamp, true
+}
+
+// sharedScanRegistry keeps track of the sharedScans on a node that can still
+// be attached to.
+type sharedScanRegistry struct {
+	log.AmbientContext
+	stopper *stop.Stopper
+	db      *client.DB
+	nodeID  *base.NodeIDContainer
+
+	mu struct {
+		syncutil.Mutex
+		// scans contains the sharedScans that haven't handed out any rows yet,
+		// keyed by the key of their spec. The subscribers of these scans are
+		// protected by mu as well.
+		scans map[string]*sharedScan
+	}
+}
+
+func newSharedScanRegistry(
+	ambient log.AmbientContext, stopper *stop.Stopper, db *client.DB, nodeID *base.NodeIDContainer,
+) *sharedScanRegistry {
+	r := &sharedScanRegistry{
+		AmbientContext: ambient,
+		stopper:        stopper,
+		db:             db,
+		nodeID:         nodeID,
+	}
+	r.mu.scans = make(map[string]*sharedScan)
+	return r
+}
+
+// attach subscribes to the rows of a sharedScan with the given spec. A new
+// sharedScan is started unless there is one which hasn't handed out any rows
+// yet.
+func (r *sharedScanRegistry) attach(spec sharedScanSpec) *sharedScanSubscriber {
+	sub := newSharedScanSubscriber()
+	key := spec.key()
+	r.mu.Lock()
+	if s, ok := r.mu.scans[key]; ok {
+		s.subs = append(s.subs, sub)
+		r.mu.Unlock()
+		return sub
+	}
+	s := &sharedScan{registry: r, key: key, spec: spec, subs: []*sharedScanSubscriber{sub}}
+	r.mu.scans[key] = s
+	r.mu.Unlock()
+
+	ctx := r.AnnotateCtx(context.Background())
+	if err := r.stopper.RunAsyncTask(ctx, "distsqlrun.sharedScan", s.run); err != nil {
+		s.finish(r.claim(s), sharedScanBatch{err: err})
+	}
+	return sub
+}
+
+// claim removes the given sharedScan from the registry, so that no more
+// subscribers can attach to it, and returns its subscribers.
+func (r *sharedScanRegistry) claim(s *sharedScan) []*sharedScanSubscriber {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.mu.scans[s.key] == s {
+		delete(r.mu.scans, s.key)
+	}
+	return s.subs
+}
+
+// sharedScanBatch is a batch of rows handed out by a sharedScan. The rows are
+// shared by all subscribers and must not be modified.
+type sharedScanBatch struct {
+	rows []sqlbase.EncDatumRow
+	// last is set on the final batch of a successful scan.
+	last bool
+	err  error
+}
+
+// sharedScan reads the rows of a table once and hands them out to all of its
+// subscribers. Subscribers can attach until the first batch of rows is handed
+// out, which leaves the time it takes to read that batch for identical scans
+// to join. After that, the scan progresses at the pace of its slowest
+// subscriber, until all of them have detached.
+type sharedScan struct {
+	registry *sharedScanRegistry
+	key      string
+	spec     sharedScanSpec
+	subs     []*sharedScanSubscriber
+}
+
+func (s *sharedScan) run(ctx context.Context) {
+	ctx, cancel := s.registry.stopper.WithCancelOnQuiesce(ctx)
+	defer cancel()
+	var subs []*sharedScanSubscriber
+	err := s.scan(ctx, func(b sharedScanBatch) bool {
+		if subs == nil {
+			subs = s.registry.claim(s)
+		}
+		return s.send(subs, b)
+	})
+	if subs == nil {
+		subs = s.registry.claim(s)
+	}
+	s.finish(subs, sharedScanBatch{err: err})
+}
+
+// scan reads the rows of the scan in a transaction of its own and passes them
+// to emit in batches, until emit returns false.
+func (s *sharedScan) scan(ctx context.Context, emit func(sharedScanBatch) bool) error {
+	txn := client.NewTxn(ctx, s.registry.db, s.registry.nodeID.Get(), client.RootTxn)
+	txn.SetFixedTimestamp(ctx, s.spec.ts)
+
+	var fetcher row.Fetcher
+	var alloc sqlbase.DatumAlloc
+	returnMutations := s.spec.visibility == distsqlpb.ScanVisibility_PUBLIC_AND_NOT_PUBLIC
+	colIdxMap := s.spec.desc.ColumnIdxMapWithMutations(returnMutations)
+	if _, _, err := initRowFetcher(
+		&fetcher, s.spec.desc, s.spec.indexIdx, colIdxMap, s.spec.reverse,
+		s.spec.neededColumns, false /* isCheck */, &alloc, s.spec.visibility,
+	); err != nil {
+		return err
+	}
+	log.VEventf(ctx, 1, "starting shared scan of %s at %s", s.spec.spans, s.spec.ts)
+	if err := fetcher.StartScan(
+		ctx, txn, s.spec.spans, true /* limitBatches */, 0 /* limitHint */, false, /* traceKV */
+	); err != nil {
+		return err
+	}
+
+	var rowAlloc sqlbase.EncDatumRowAlloc
+	rows := make([]sqlbase.EncDatumRow, 0, sharedScanBatchSize)
+	for {
+		r, _, _, err := fetcher.NextRow(ctx)
+		if err != nil {
+			return err
+		}
+		if r == nil {
+			emit(sharedScanBatch{rows: rows, last: true})
+			return nil
+		}
+		// The fetcher reuses the row it returns.
+		rows = append(rows, rowAlloc.CopyRow(r))
+		if len(rows) == sharedScanBatchSize {
+			if !emit(sharedScanBatch{rows: rows}) {
+				log.VEventf(ctx, 1, "all subscribers detached from shared scan")
+				return nil
+			}
+			rows = make([]sqlbase.EncDatumRow, 0, sharedScanBatchSize)
+		}
+	}
+}
+
+// send hands the batch to all subscribers that are still attached, and returns
+// whether there are any.
+func (s *sharedScan) send(subs []*sharedScanSubscriber, b sharedScanBatch) bool {
+	attached := false
+	for _, sub := range subs {
+		select {
+		case <-sub.done:
+			continue
+		default:
+		}
+		select {
+		case sub.batches <- b:
+			attached = true
+		case <-sub.done:
+		case <-s.registry.stopper.ShouldQuiesce():
+			return false
+		}
+	}
+	return attached
+}
+
+// finish hands the final batch to the subscribers if it carries an error, and
+// tells them that the scan is over. A subscriber that doesn't receive a batch
+// marked last before that sees an errSharedScanInterrupted.
+func (s *sharedScan) finish(subs []*sharedScanSubscriber, b sharedScanBatch) {
+	if b.err != nil {
+		s.send(subs, b)
+	}
+	for _, sub := range subs {
+		close(sub.batches)
+	}
+}
+
+// sharedScanSubscriber is a RowSource that returns the rows of a sharedScan.
+type sharedScanSubscriber struct {
+	batches chan sharedScanBatch
+	// done is closed when the subscriber detaches.
+	done       chan struct{}
+	detachOnce sync.Once
+
+	ctx      context.Context
+	rows     []sqlbase.EncDatumRow
+	alloc    sqlbase.EncDatumRowAlloc
+	finished bool
+}
+
+var _ RowSource = &sharedScanSubscriber{}
+
+func newSharedScanSubscriber() *sharedScanSubscriber {
+	return &sharedScanSubscriber{
+		batches: make(chan sharedScanBatch, sharedScanBufferedBatches),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start is part of the RowSource interface.
+func (s *sharedScanSubscriber) Start(ctx context.Context) context.Context {
+	s.ctx = ctx
+	return ctx
+}
+
+// Next is part of the RowSource interface. If the returned ProducerMetadata is
+// not nil, only its Err field is set.
+func (s *sharedScanSubscriber) Next() (sqlbase.EncDatumRow, *distsqlpb.ProducerMetadata) {
+	for len(s.rows) == 0 {
+		if s.finished {
+			return nil, nil
+		}
+		select {
+		case b, ok := <-s.batches:
+			if !ok {
+				s.finished = true
+				return nil, &distsqlpb.ProducerMetadata{Err: errSharedScanInterrupted}
+			}
+			if b.err != nil {
+				s.finished = true
+				return nil, &distsqlpb.ProducerMetadata{Err: b.err}
+			}
+			s.finished = b.last
+			s.rows = b.rows
+		case <-s.ctx.Done():
+			s.finished = true
+			return nil, &distsqlpb.ProducerMetadata{Err: s.ctx.Err()}
+		}
+	}
+	r := s.rows[0]
+	s.rows = s.rows[1:]
+	// Decoding an EncDatum modifies it, so every subscriber needs its own copy
+	// of the rows.
+	return s.alloc.CopyRow(r), nil
+}
+
+// detach unsubscribes from the sharedScan, which stops reading once all of its
+// subscribers have detached.
+func (s *sharedScanSubscriber) detach() {
+	s.detachOnce.Do(func() { close(s.done) })
+}
+
+// OutputTypes is part of the RowSource interface.
+func (s *sharedScanSubscriber) OutputTypes() []types.T { return nil }
+
+// ConsumerDone is part of the RowSource interface.
+func (s *sharedScanSubscriber) ConsumerDone() { s.detach() }
+
+// ConsumerClosed is part of the RowSource interface.
+func (s *sharedScanSubscriber) ConsumerClosed() { s.detach() }
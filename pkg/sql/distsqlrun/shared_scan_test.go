@@ -0,0 +1,122 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+)
+
+func TestSharedScan(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	const numRows = 3*sharedScanBatchSize + 10
+	sqlutils.CreateTable(t, sqlDB, "t", "k INT PRIMARY KEY, v INT", numRows,
+		sqlutils.ToRowFn(sqlutils.RowIdxFn, func(row int) tree.Datum {
+			return tree.NewDInt(tree.DInt(10 * row))
+		}))
+	td := sqlbase.GetTableDescriptor(kvDB, "test", "t")
+
+	r := newSharedScanRegistry(
+		log.AmbientContext{Tracer: tracing.NewTracer()}, s.Stopper(), kvDB, &base.NodeIDContainer{},
+	)
+	spec := sharedScanSpec{
+		desc:          td,
+		neededColumns: util.MakeFastIntSet(0, 1),
+		spans:         roachpb.Spans{td.PrimaryIndexSpan()},
+		ts:            s.Clock().Now(),
+	}
+
+	// Register the scan without starting it, so that the subscribers are
+	// guaranteed to attach to it.
+	scan := &sharedScan{registry: r, key: spec.key(), spec: spec}
+	r.mu.scans[scan.key] = scan
+	subs := []*sharedScanSubscriber{r.attach(spec), r.attach(spec), r.attach(spec)}
+	if len(scan.subs) != len(subs) {
+		t.Fatalf("expected %d subscribers, got %d", len(subs), len(scan.subs))
+	}
+	for _, sub := range subs {
+		sub.Start(ctx)
+	}
+	if err := s.Stopper().RunAsyncTask(ctx, "test-shared-scan", scan.run); err != nil {
+		t.Fatal(err)
+	}
+
+	// The last subscriber detaches after the first row, which mustn't hold up
+	// the others.
+	colTypes := td.ColumnTypes()
+	for i := 1; ; i++ {
+		var rows []string
+		for _, sub := range subs[:2] {
+			row, meta := sub.Next()
+			if meta != nil {
+				t.Fatal(meta.Err)
+			}
+			if row != nil {
+				rows = append(rows, row.String(colTypes))
+			}
+		}
+		if i == 1 {
+			if row, meta := subs[2].Next(); row == nil || meta != nil {
+				t.Fatalf("expected a row, got %v, %v", row, meta)
+			}
+			subs[2].detach()
+		}
+		if i > numRows {
+			if len(rows) != 0 {
+				t.Fatalf("expected no more rows, got %v", rows)
+			}
+			break
+		}
+		exp := fmt.Sprintf("[%d %d]", i, 10*i)
+		if len(rows) != 2 || rows[0] != exp || rows[1] != exp {
+			t.Fatalf("expected two subscribers to return %s, got %v", exp, rows)
+		}
+	}
+
+	// Once the scan has handed out rows, a new one is started.
+	r.mu.Lock()
+	_, ok := r.mu.scans[scan.key]
+	r.mu.Unlock()
+	if ok {
+		t.Fatal("expected scan to be removed from the registry")
+	}
+	sub := r.attach(spec)
+	sub.Start(ctx)
+	for i := 0; i < numRows; i++ {
+		if row, meta := sub.Next(); row == nil || meta != nil {
+			t.Fatalf("expected a row, got %v, %v", row, meta)
+		}
+	}
+	if row, meta := sub.Next(); row != nil || meta != nil {
+		t.Fatalf("expected end of scan, got %v, %v", row, meta)
+	}
+}
@@ -145,6 +145,10 @@ func (s *sorterBase) close() {
 			s.i.Close()
 		}
 		ctx := s.Ctx
+		if rc, ok := s.rows.(*rowcontainer.DiskBackedRowContainer); ok && rc.Spilled() &&
+			s.flowCtx.metrics != nil {
+			s.flowCtx.metrics.SorterSpills.Inc(1)
+		}
 		s.rows.Close(ctx)
 		s.MemMonitor.Stop(ctx)
 		if s.diskMonitor != nil {
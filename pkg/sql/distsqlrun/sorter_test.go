@@ -20,6 +20,7 @@ import (
 	"math"
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
@@ -311,6 +312,8 @@ func TestSorter(t *testing.T) {
 							TempStorage: tempEngine,
 							diskMonitor: diskMonitor,
 						}
+						metrics := MakeDistSQLMetrics(time.Hour /* histogramWindow */)
+						flowCtx.metrics = &metrics
 						// Override the default memory limit. This will result in using
 						// a memory row container which will hit this limit and fall
 						// back to using a disk row container.
@@ -359,6 +362,9 @@ func TestSorter(t *testing.T) {
 						if memLimit.expSpill != spilled {
 							t.Errorf("expected spill to disk=%t, found %t", memLimit.expSpill, spilled)
 						}
+						if c := metrics.SorterSpills.Count(); spilled != (c == 1) {
+							t.Errorf("expected spill to disk=%t, found %d spills in metrics", spilled, c)
+						}
 						if spilled {
 							if scp, ok := s.(*sortChunksProcessor); ok {
 								if scp.rows.(*rowcontainer.DiskBackedRowContainer).UsingDisk() {
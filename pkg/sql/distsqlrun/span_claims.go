@@ -0,0 +1,192 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/pkg/errors"
+)
+
+// spanClaimBatchSize is the number of spans a tableReader claims from the
+// gateway at a time. Claiming few spans at a time leaves more of them to be
+// stolen by the tableReaders that finish their own spans first.
+const spanClaimBatchSize = 4
+
+// spanClaimKey identifies the table scan whose spans are claimed.
+type spanClaimKey struct {
+	flowID  distsqlpb.FlowID
+	stageID int32
+}
+
+// spanClaimQueue holds the unclaimed spans of the tableReaders of a stage,
+// partitioned by the node on which they were planned.
+type spanClaimQueue struct {
+	partitions map[roachpb.NodeID]roachpb.Spans
+}
+
+// claim hands out up to maxSpans spans to a tableReader running on the given
+// node. The spans planned on that node are handed out first, in order. Once
+// they are exhausted, spans are stolen from the end of the partition of the
+// node with the most unclaimed spans, taking at most half of them so that the
+// node's own tableReader keeps some of its work. It returns the node that the
+// spans were stolen from, if any.
+func (q *spanClaimQueue) claim(
+	nodeID roachpb.NodeID, maxSpans int,
+) (spans roachpb.Spans, victim roachpb.NodeID) {
+	if maxSpans <= 0 {
+		maxSpans = 1
+	}
+	if own := q.partitions[nodeID]; len(own) > 0 {
+		n := len(own)
+		if n > maxSpans {
+			n = maxSpans
+		}
+		spans, q.partitions[nodeID] = own[:n:n], own[n:]
+		return spans, 0
+	}
+
+	for n, p := range q.partitions {
+		best := len(q.partitions[victim])
+		if len(p) > best || (len(p) > 0 && len(p) == best && n < victim) {
+			victim = n
+		}
+	}
+	p := q.partitions[victim]
+	if len(p) == 0 {
+		return nil, 0
+	}
+	n := (len(p) + 1) / 2
+	if n > maxSpans {
+		n = maxSpans
+	}
+	rest := len(p) - n
+	spans, q.partitions[victim] = p[rest:], p[:rest:rest]
+	return spans, victim
+}
+
+// spanClaimRegistry keeps track of the span claim queues of the table scans
+// planned by the node.
+type spanClaimRegistry struct {
+	metrics *DistSQLMetrics
+
+	mu struct {
+		syncutil.Mutex
+		queues map[spanClaimKey]*spanClaimQueue
+	}
+}
+
+func newSpanClaimRegistry(metrics *DistSQLMetrics) *spanClaimRegistry {
+	r := &spanClaimRegistry{metrics: metrics}
+	r.mu.queues = make(map[spanClaimKey]*spanClaimQueue)
+	return r
+}
+
+// register creates the span claim queue of a stage of a flow. The returned
+// function removes it again.
+func (r *spanClaimRegistry) register(
+	flowID distsqlpb.FlowID, stageID int32, partitions map[roachpb.NodeID]roachpb.Spans,
+) func() {
+	key := spanClaimKey{flowID: flowID, stageID: stageID}
+	q := &spanClaimQueue{partitions: make(map[roachpb.NodeID]roachpb.Spans, len(partitions))}
+	for nodeID, spans := range partitions {
+		q.partitions[nodeID] = append(roachpb.Spans(nil), spans...)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mu.queues[key] = q
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.mu.queues, key)
+	}
+}
+
+// claim serves a ClaimSpansRequest. An error is returned if the flow has no
+// span claim queue for the stage, which is the case once the gateway is done
+// with the flow.
+func (r *spanClaimRegistry) claim(
+	ctx context.Context, req *distsqlpb.ClaimSpansRequest,
+) (*distsqlpb.ClaimSpansResponse, error) {
+	r.mu.Lock()
+	q, ok := r.mu.queues[spanClaimKey{flowID: req.FlowID, stageID: req.StageID}]
+	var spans roachpb.Spans
+	var victim roachpb.NodeID
+	if ok {
+		spans, victim = q.claim(req.NodeID, int(req.MaxSpans))
+	}
+	r.mu.Unlock()
+
+	if !ok {
+		return nil, errors.Errorf("no spans to claim for stage %d of flow %s",
+			req.StageID, req.FlowID.Short())
+	}
+	if victim != 0 {
+		log.VEventf(ctx, 2, "n%d stole %d spans from n%d", req.NodeID, len(spans), victim)
+		if r.metrics != nil {
+			r.metrics.SpansStolen.Inc(int64(len(spans)))
+		}
+	}
+	return &distsqlpb.ClaimSpansResponse{Spans: spans}, nil
+}
+
+// RegisterSpanClaims makes the spans of a stage of a flow planned by this
+// node available to the stage's tableReaders through ClaimSpans. The spans are
+// partitioned by the node on which they were planned. The returned function
+// unregisters them and must be called once the flow is done.
+func (ds *ServerImpl) RegisterSpanClaims(
+	flowID distsqlpb.FlowID, stageID int32, partitions map[roachpb.NodeID]roachpb.Spans,
+) func() {
+	return ds.spanClaims.register(flowID, stageID, partitions)
+}
+
+// ClaimSpans is part of the DistSQLServer interface.
+func (ds *ServerImpl) ClaimSpans(
+	ctx context.Context, req *distsqlpb.ClaimSpansRequest,
+) (*distsqlpb.ClaimSpansResponse, error) {
+	return ds.spanClaims.claim(ctx, req)
+}
+
+// claimSpans claims the next batch of spans for a tableReader of the given
+// stage from the gateway of its flow. No spans are returned once all the spans
+// of the stage have been claimed.
+func claimSpans(ctx context.Context, flowCtx *FlowCtx, stageID int32) (roachpb.Spans, error) {
+	req := &distsqlpb.ClaimSpansRequest{
+		FlowID:   flowCtx.id,
+		StageID:  stageID,
+		NodeID:   flowCtx.nodeID,
+		MaxSpans: spanClaimBatchSize,
+	}
+	var resp *distsqlpb.ClaimSpansResponse
+	var err error
+	if flowCtx.gateway == flowCtx.nodeID && flowCtx.spanClaims != nil {
+		resp, err = flowCtx.spanClaims.claim(ctx, req)
+	} else {
+		conn, dialErr := flowCtx.nodeDialer.Dial(ctx, flowCtx.gateway)
+		if dialErr != nil {
+			return nil, dialErr
+		}
+		resp, err = distsqlpb.NewDistSQLClient(conn).ClaimSpans(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.Spans, nil
+}
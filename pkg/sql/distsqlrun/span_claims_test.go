@@ -0,0 +1,182 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+)
+
+func TestSpanClaimQueue(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	span := func(i int) roachpb.Span {
+		return roachpb.Span{Key: roachpb.Key{byte(i)}, EndKey: roachpb.Key{byte(i + 1)}}
+	}
+	spans := func(from, to int) roachpb.Spans {
+		var s roachpb.Spans
+		for i := from; i < to; i++ {
+			s = append(s, span(i))
+		}
+		return s
+	}
+
+	q := &spanClaimQueue{partitions: map[roachpb.NodeID]roachpb.Spans{
+		1: spans(0, 2),
+		2: spans(2, 10),
+		3: spans(10, 14),
+	}}
+	testCases := []struct {
+		nodeID    roachpb.NodeID
+		expSpans  roachpb.Spans
+		expVictim roachpb.NodeID
+	}{
+		// A node first claims its own spans, in order.
+		{1, spans(0, 2), 0},
+		// It then steals at most half of the spans of the node with the most
+		// spans left, from the end of its partition.
+		{1, spans(6, 10), 2},
+		// On a tie, the node with the lowest ID is stolen from.
+		{1, spans(4, 6), 2},
+		{3, spans(10, 14), 0},
+		{3, spans(3, 4), 2},
+		// The last span of a node can be stolen too.
+		{1, spans(2, 3), 2},
+		{1, nil, 0},
+		{2, nil, 0},
+	}
+	for i, tc := range testCases {
+		s, victim := q.claim(tc.nodeID, 4 /* maxSpans */)
+		if !reflect.DeepEqual(s, tc.expSpans) || victim != tc.expVictim {
+			t.Fatalf("%d: n%d expected to claim %v from n%d, got %v from n%d",
+				i, tc.nodeID, tc.expSpans, tc.expVictim, s, victim)
+		}
+	}
+}
+
+// Test that a tableReader that claims its spans steals the spans planned on
+// another node once it is done with its own, and that the tableReader on the
+// other node is left with nothing to read.
+func TestTableReaderClaimSpans(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+
+	s, sqlDB, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	const numRows = 10
+	sqlutils.CreateTable(t, sqlDB, "t", "k INT PRIMARY KEY", numRows,
+		sqlutils.ToRowFn(sqlutils.RowIdxFn))
+	td := sqlbase.GetTableDescriptor(kvDB, "test", "t")
+
+	// Plan a span per row, most of them on the second node.
+	prefix := roachpb.Key(sqlbase.MakeIndexKeyPrefix(td, td.PrimaryIndex.ID))
+	partitions := make(map[roachpb.NodeID]roachpb.Spans)
+	for i := 1; i <= numRows; i++ {
+		key := encoding.EncodeVarintAscending(append(roachpb.Key(nil), prefix...), int64(i))
+		nodeID := roachpb.NodeID(2)
+		if i <= 2 {
+			nodeID = 1
+		}
+		partitions[nodeID] = append(partitions[nodeID],
+			roachpb.Span{Key: key, EndKey: roachpb.Key(key).PrefixEnd()})
+	}
+
+	metrics := MakeDistSQLMetrics(time.Hour /* histogramWindow */)
+	registry := newSpanClaimRegistry(&metrics)
+	flowID := distsqlpb.FlowID{UUID: uuid.MakeV4()}
+	const stageID = 1
+	defer registry.register(flowID, stageID, partitions)()
+
+	st := s.ClusterSettings()
+	evalCtx := tree.MakeTestingEvalContext(st)
+	defer evalCtx.Stop(ctx)
+
+	readAll := func(nodeID roachpb.NodeID) []int {
+		// Both tableReaders claim their spans from the local registry; it stands
+		// in for the gateway.
+		flowCtx := FlowCtx{
+			EvalCtx:    &evalCtx,
+			Settings:   st,
+			txn:        client.NewTxn(ctx, kvDB, nodeID, client.RootTxn),
+			nodeID:     nodeID,
+			id:         flowID,
+			gateway:    nodeID,
+			spanClaims: registry,
+		}
+		var spec distsqlpb.TableReaderSpec
+		spec.Table = *td
+		for _, sp := range partitions[nodeID] {
+			spec.Spans = append(spec.Spans, distsqlpb.TableReaderSpan{Span: sp})
+		}
+		spec.ClaimSpans = true
+		spec.ClaimStageID = stageID
+		post := distsqlpb.PostProcessSpec{Projection: true, OutputColumns: []uint32{0}}
+
+		tr, err := newTableReader(&flowCtx, 0 /* processorID */, &spec, &post, nil /* output */)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr.Start(ctx)
+		var res []int
+		for {
+			row, meta := tr.Next()
+			if meta != nil {
+				if meta.Err != nil {
+					t.Fatal(meta.Err)
+				}
+				continue
+			}
+			if row == nil {
+				break
+			}
+			if err := row[0].EnsureDecoded(&td.Columns[0].Type, &sqlbase.DatumAlloc{}); err != nil {
+				t.Fatal(err)
+			}
+			res = append(res, int(*row[0].Datum.(*tree.DInt)))
+		}
+		sort.Ints(res)
+		return res
+	}
+
+	var exp []int
+	for i := 1; i <= numRows; i++ {
+		exp = append(exp, i)
+	}
+	if res := readAll(1); !reflect.DeepEqual(res, exp) {
+		t.Fatalf("expected n1 to read %v, got %v", exp, res)
+	}
+	if res := readAll(2); len(res) != 0 {
+		t.Fatalf("expected n2 to have nothing left to read, got %v", res)
+	}
+	if stolen := metrics.SpansStolen.Count(); stolen != numRows-2 {
+		t.Fatalf("expected %d spans to be stolen, got %d", numRows-2, stolen)
+	}
+}
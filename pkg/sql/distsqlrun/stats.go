@@ -15,18 +15,33 @@
 package distsqlrun
 
 import (
+	"context"
 	"fmt"
+	"runtime"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/opentracing/opentracing-go"
 )
 
+// cpuTimeSampleInterval is the number of calls to InputStatCollector.Next per
+// measurement of the CPU time. Reading the thread CPU clock is a system call,
+// which is too expensive to make twice per row.
+const cpuTimeSampleInterval = 16
+
 // InputStatCollector wraps a RowSource and collects stats from it.
 type InputStatCollector struct {
 	RowSource
 	InputStats
+
+	// numCalls is the number of calls to Next so far.
+	numCalls int64
+	// sampledStallTime and sampledCPUTime are the wall and CPU times of the
+	// calls to Next whose CPU time was measured.
+	sampledStallTime, sampledCPUTime time.Duration
 }
 
 var _ RowSource = &InputStatCollector{}
@@ -39,49 +54,148 @@ func NewInputStatCollector(input RowSource) *InputStatCollector {
 
 // Next implements the RowSource interface. It calls Next on the embedded
 // RowSource and collects stats.
+//
+// The CPU time is measured on the thread of the calling goroutine, which
+// is only accurate if the goroutine is locked to its thread (see
+// lockOSThreadIfRecording). It is only measured for one in
+// cpuTimeSampleInterval calls, and the CPU time of the input is estimated
+// from the share of the stall time of these calls that was spent on the CPU.
 func (isc *InputStatCollector) Next() (sqlbase.EncDatumRow, *distsqlpb.ProducerMetadata) {
+	sample := timeutil.ThreadCPUTimeSupported && isc.numCalls%cpuTimeSampleInterval == 0
+	isc.numCalls++
 	start := timeutil.Now()
+	var startCPU time.Duration
+	if sample {
+		startCPU = timeutil.ThreadCPUTime()
+	}
 	row, meta := isc.RowSource.Next()
 	if row != nil {
 		isc.NumRows++
 	}
-	isc.StallTime += timeutil.Since(start)
+	stallTime := timeutil.Since(start)
+	isc.StallTime += stallTime
+	if sample {
+		// The CPU time can't exceed the wall time; it can only appear to do so
+		// because of the granularity of the clocks, or if the goroutine moved to
+		// another thread.
+		cpuTime := timeutil.ThreadCPUTime() - startCPU
+		if cpuTime > stallTime {
+			cpuTime = stallTime
+		} else if cpuTime < 0 {
+			cpuTime = 0
+		}
+		isc.sampledStallTime += stallTime
+		isc.sampledCPUTime += cpuTime
+	}
+	if isc.sampledStallTime > 0 {
+		isc.CPUTime = time.Duration(
+			float64(isc.StallTime) * float64(isc.sampledCPUTime) / float64(isc.sampledStallTime),
+		)
+	}
 	return row, meta
 }
 
+// lockOSThreadIfRecording locks the calling goroutine to its OS thread if ctx
+// has a recording span, so that the InputStatCollectors used by the
+// processors run on the goroutine measure its CPU time. The returned function
+// must be called to unlock the goroutine.
+func lockOSThreadIfRecording(ctx context.Context) func() {
+	if !timeutil.ThreadCPUTimeSupported {
+		return func() {}
+	}
+	if sp := opentracing.SpanFromContext(ctx); sp == nil || !tracing.IsRecording(sp) {
+		return func() {}
+	}
+	runtime.LockOSThread()
+	return runtime.UnlockOSThread
+}
+
 const (
 	rowsReadTagSuffix  = "input.rows"
 	stallTimeTagSuffix = "stalltime"
+	cpuTimeTagSuffix   = "cputime"
 	maxMemoryTagSuffix = "mem.max"
 	maxDiskTagSuffix   = "disk.max"
 	bytesReadTagSuffix = "bytes.read"
+
+	kvBatchesTagSuffix               = "kv.batches"
+	kvResumeSpansTagSuffix           = "kv.resume_spans"
+	kvNotLeaseHolderRetriesTagSuffix = "kv.not_leaseholder_retries"
+	kvFollowerReadsTagSuffix         = "kv.follower_reads"
 )
 
 // Stats is a utility method that returns a map of the InputStats` stats to
 // output to a trace as tags. The given prefix is prefixed to the keys.
+//
+// The CPU time is omitted if it wasn't measured.
 func (is InputStats) Stats(prefix string) map[string]string {
-	return map[string]string{
+	stats := map[string]string{
 		prefix + rowsReadTagSuffix:  fmt.Sprintf("%d", is.NumRows),
 		prefix + stallTimeTagSuffix: fmt.Sprintf("%v", is.RoundStallTime()),
 	}
+	if is.CPUTime != 0 {
+		stats[prefix+cpuTimeTagSuffix] = fmt.Sprintf("%v", is.RoundCPUTime())
+	}
+	return stats
 }
 
 const (
 	rowsReadQueryPlanSuffix  = "rows read"
 	stallTimeQueryPlanSuffix = "stall time"
+	cpuTimeQueryPlanSuffix   = "cpu time"
 	maxMemoryQueryPlanSuffix = "max memory used"
 	maxDiskQueryPlanSuffix   = "max disk used"
 	bytesReadQueryPlanSuffix = "bytes read"
+
+	kvBatchesQueryPlanSuffix               = "kv batches"
+	kvResumeSpansQueryPlanSuffix           = "kv resume spans"
+	kvNotLeaseHolderRetriesQueryPlanSuffix = "kv not leaseholder retries"
+	kvFollowerReadsQueryPlanSuffix         = "kv follower reads"
 )
 
 // StatsForQueryPlan is a utility method that returns a list of the InputStats'
 // stats to output on a query plan. The given prefix is prefixed to each element
 // in the returned list.
+//
+// The CPU time is omitted if it wasn't measured. A stall time much larger than
+// the CPU time indicates that the processor was mostly blocked, waiting for
+// its input to be produced by another goroutine or for a KV response.
 func (is InputStats) StatsForQueryPlan(prefix string) []string {
-	return []string{
+	stats := []string{
 		fmt.Sprintf("%s%s: %d", prefix, rowsReadQueryPlanSuffix, is.NumRows),
 		fmt.Sprintf("%s%s: %v", prefix, stallTimeQueryPlanSuffix, is.RoundStallTime()),
 	}
+	if is.CPUTime != 0 {
+		stats = append(stats, fmt.Sprintf("%s%s: %v", prefix, cpuTimeQueryPlanSuffix, is.RoundCPUTime()))
+	}
+	return stats
+}
+
+// kvStats returns a map of the given KV stats to output to a trace as tags.
+// The given prefix is prefixed to the keys.
+func kvStats(
+	prefix string, batches, resumeSpans, notLeaseHolderRetries, followerReads int64,
+) map[string]string {
+	return map[string]string{
+		prefix + kvBatchesTagSuffix:               fmt.Sprintf("%d", batches),
+		prefix + kvResumeSpansTagSuffix:           fmt.Sprintf("%d", resumeSpans),
+		prefix + kvNotLeaseHolderRetriesTagSuffix: fmt.Sprintf("%d", notLeaseHolderRetries),
+		prefix + kvFollowerReadsTagSuffix:         fmt.Sprintf("%d", followerReads),
+	}
+}
+
+// kvStatsForQueryPlan returns a list of the given KV stats to output on a
+// query plan. The given prefix is prefixed to each element in the returned
+// list.
+func kvStatsForQueryPlan(
+	prefix string, batches, resumeSpans, notLeaseHolderRetries, followerReads int64,
+) []string {
+	return []string{
+		fmt.Sprintf("%s%s: %d", prefix, kvBatchesQueryPlanSuffix, batches),
+		fmt.Sprintf("%s%s: %d", prefix, kvResumeSpansQueryPlanSuffix, resumeSpans),
+		fmt.Sprintf("%s%s: %d", prefix, kvNotLeaseHolderRetriesQueryPlanSuffix, notLeaseHolderRetries),
+		fmt.Sprintf("%s%s: %d", prefix, kvFollowerReadsQueryPlanSuffix, followerReads),
+	}
 }
 
 // RoundStallTime returns the InputStats' StallTime rounded to the nearest
@@ -89,3 +203,9 @@ func (is InputStats) StatsForQueryPlan(prefix string) []string {
 func (is InputStats) RoundStallTime() time.Duration {
 	return is.StallTime.Round(time.Microsecond)
 }
+
+// RoundCPUTime returns the InputStats' CPUTime rounded to the nearest
+// time.Microsecond.
+func (is InputStats) RoundCPUTime() time.Duration {
+	return is.CPUTime.Round(time.Microsecond)
+}
@@ -15,10 +15,14 @@
 package distsqlrun
 
 import (
+	"runtime"
 	"testing"
+	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 )
 
 // TestInputStatCollector verifies that an InputStatCollector correctly collects
@@ -37,3 +41,53 @@ func TestInputStatCollector(t *testing.T) {
 		t.Fatalf("counted %d rows but expected %d", isc.NumRows, numRows)
 	}
 }
+
+// slowRowSource is a RowSource which calls wait before returning each row.
+type slowRowSource struct {
+	RowSource
+	wait func()
+}
+
+func (s *slowRowSource) Next() (sqlbase.EncDatumRow, *distsqlpb.ProducerMetadata) {
+	s.wait()
+	return s.RowSource.Next()
+}
+
+// TestInputStatCollectorCPUTime verifies that an InputStatCollector
+// distinguishes an input which blocks from an input which uses the CPU.
+func TestInputStatCollectorCPUTime(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	if !timeutil.ThreadCPUTimeSupported {
+		t.Skip("thread CPU time is not supported on this platform")
+	}
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	// Enough rows for the CPU time to be measured more than once.
+	const numRows = 2 * cpuTimeSampleInterval
+	const delay = 5 * time.Millisecond
+	run := func(wait func()) InputStats {
+		isc := NewInputStatCollector(&slowRowSource{
+			RowSource: NewRowBuffer(sqlbase.OneIntCol, sqlbase.MakeIntRows(numRows, 1), RowBufferArgs{}),
+			wait:      wait,
+		})
+		for row, meta := isc.Next(); row != nil || meta != nil; row, meta = isc.Next() {
+		}
+		return isc.InputStats
+	}
+
+	blocked := run(func() { time.Sleep(delay) })
+	if blocked.StallTime < numRows*delay || blocked.CPUTime > blocked.StallTime/4 {
+		t.Errorf("expected a blocked input, got stall time %s and CPU time %s",
+			blocked.StallTime, blocked.CPUTime)
+	}
+	busy := run(func() {
+		for deadline := timeutil.Now().Add(delay); timeutil.Now().Before(deadline); {
+		}
+	})
+	if busy.StallTime < numRows*delay || busy.CPUTime < busy.StallTime/4 || busy.CPUTime > busy.StallTime {
+		t.Errorf("expected a busy input, got stall time %s and CPU time %s",
+			busy.StallTime, busy.CPUTime)
+	}
+}
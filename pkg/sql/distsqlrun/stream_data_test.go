@@ -23,6 +23,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 )
@@ -149,6 +150,49 @@ func TestEmptyStreamEncodeDecode(t *testing.T) {
 	}
 }
 
+// TestStreamDecoderHeader verifies that a StreamDecoder rejects the streams of
+// producers it can't talk to.
+func TestStreamDecoderHeader(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testCases := []struct {
+		header distsqlpb.ProducerHeader
+		err    string
+	}{
+		{header: distsqlpb.ProducerHeader{}},
+		{header: distsqlpb.ProducerHeader{
+			Version:            distsqlpb.CurrentStreamVersion,
+			MinAcceptedVersion: distsqlpb.MinAcceptedStreamVersion,
+		}},
+		{
+			header: distsqlpb.ProducerHeader{
+				Version:            distsqlpb.CurrentStreamVersion + 2,
+				MinAcceptedVersion: distsqlpb.CurrentStreamVersion + 1,
+			},
+			err: "incompatible stream versions",
+		},
+		{
+			header: distsqlpb.ProducerHeader{
+				Version:            distsqlpb.CurrentStreamVersion,
+				MinAcceptedVersion: distsqlpb.MinAcceptedStreamVersion,
+				Capabilities:       distsqlpb.StreamCapabilityColumnar,
+			},
+			err: "received columnar stream",
+		},
+	}
+	for i, tc := range testCases {
+		var sd StreamDecoder
+		err := sd.AddMessage(&distsqlpb.ProducerMessage{Header: &tc.header})
+		if !testutils.IsError(err, tc.err) {
+			t.Errorf("%d: expected error %q, got %v", i, tc.err, err)
+		}
+	}
+}
+
+// benchRowsPerMessage is the number of rows per message in the stream
+// benchmarks.
+const benchRowsPerMessage = 16
+
 func BenchmarkStreamEncoder(b *testing.B) {
 	numRows := 1 << 16
 
@@ -177,7 +221,7 @@ func BenchmarkStreamEncoder(b *testing.B) {
 				b.StartTimer()
 
 				// Add rows to the StreamEncoder until the input source is exhausted.
-				// "Flush" every outboxBufRows.
+				// "Flush" every benchRowsPerMessage.
 				for j := 0; ; j++ {
 					row, _ := input.Next()
 					if row == nil {
@@ -186,7 +230,7 @@ func BenchmarkStreamEncoder(b *testing.B) {
 					if err := se.AddRow(row); err != nil {
 						b.Fatal(err)
 					}
-					if j%outboxBufRows == 0 {
+					if j%benchRowsPerMessage == 0 {
 						// ignore output
 						se.FormMessage(ctx)
 					}
@@ -201,12 +245,12 @@ func BenchmarkStreamDecoder(b *testing.B) {
 
 	for _, numCols := range []int{1, 4, 16, 64} {
 		b.Run(fmt.Sprintf("cols=%d", numCols), func(b *testing.B) {
-			b.SetBytes(int64(outboxBufRows * numCols * 8))
+			b.SetBytes(int64(benchRowsPerMessage * numCols * 8))
 			var se StreamEncoder
 			colTypes := sqlbase.MakeIntCols(numCols)
 			se.init(colTypes)
 			inRow := sqlbase.MakeIntRows(1, numCols)[0]
-			for i := 0; i < outboxBufRows; i++ {
+			for i := 0; i < benchRowsPerMessage; i++ {
 				if err := se.AddRow(inRow); err != nil {
 					b.Fatal(err)
 				}
@@ -218,7 +262,7 @@ func BenchmarkStreamDecoder(b *testing.B) {
 				if err := sd.AddMessage(msg); err != nil {
 					b.Fatal(err)
 				}
-				for j := 0; j < outboxBufRows; j++ {
+				for j := 0; j < benchRowsPerMessage; j++ {
 					row, meta, err := sd.GetRow(nil)
 					if err != nil {
 						b.Fatal(err)
@@ -66,6 +66,14 @@ func (sd *StreamDecoder) AddMessage(msg *distsqlpb.ProducerMessage) error {
 		if sd.headerReceived {
 			return errors.Errorf("received multiple headers")
 		}
+		if _, err := msg.Header.NegotiateVersion(
+			distsqlpb.MinAcceptedStreamVersion, distsqlpb.CurrentStreamVersion,
+		); err != nil {
+			return err
+		}
+		if msg.Header.Capabilities.Has(distsqlpb.StreamCapabilityColumnar) {
+			return errors.Errorf("received columnar stream, expected rows")
+		}
 		sd.headerReceived = true
 	}
 	if msg.Typing != nil {
@@ -62,6 +62,8 @@ type StreamEncoder struct {
 func (se *StreamEncoder) setHeaderFields(flowID distsqlpb.FlowID, streamID distsqlpb.StreamID) {
 	se.msgHdr.FlowID = flowID
 	se.msgHdr.StreamID = streamID
+	se.msgHdr.Version = distsqlpb.CurrentStreamVersion
+	se.msgHdr.MinAcceptedVersion = distsqlpb.MinAcceptedStreamVersion
 }
 
 func (se *StreamEncoder) init(types []types.T) {
@@ -122,6 +124,12 @@ func (se *StreamEncoder) AddRow(row sqlbase.EncDatumRow) error {
 	return nil
 }
 
+// PayloadSize returns the size of the encoded rows added since the last call
+// to FormMessage.
+func (se *StreamEncoder) PayloadSize() int {
+	return len(se.rowBuf)
+}
+
 // FormMessage populates a message containing the rows added since the last call
 // to FormMessage. The returned ProducerMessage should be treated as immutable.
 func (se *StreamEncoder) FormMessage(ctx context.Context) *distsqlpb.ProducerMessage {
@@ -0,0 +1,92 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"context"
+	"time"
+
+	circuit "github.com/cockroachdb/circuitbreaker"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/rpc"
+	"github.com/cockroachdb/cockroach/pkg/rpc/nodedialer"
+	"github.com/cockroachdb/cockroach/pkg/util/grpcutil"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+// streamSetupRetryPolicy governs the retries of the RPCs which set up flows
+// and their streams on remote nodes. The budget is shared by all queries on
+// this node, and the breakers are keyed by the remote node, so that a node
+// which is down doesn't have every query hammering at it.
+var streamSetupRetryPolicy = retry.Policy{
+	Options: retry.Options{
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		MaxRetries:     3,
+	},
+	Budget:   retry.NewBudget(100 /* maxTokens */, 0.1 /* tokenRatio */),
+	Breakers: retry.NewBreakers("distsql-stream-setup", 5 /* threshold */),
+}
+
+// dialError wraps an error returned from dialing a remote node.
+type dialError struct {
+	cause error
+}
+
+func (e *dialError) Error() string {
+	return e.cause.Error()
+}
+
+func (e *dialError) Cause() error {
+	return e.cause
+}
+
+// isRetryableStreamSetupErr returns whether err, returned from a stream setup
+// attempt, can safely be retried. That's the case for failed dials (unless
+// the dialer's own breaker is open, in which case retrying is pointless) and
+// for RPCs which provably never reached the remote node.
+func isRetryableStreamSetupErr(err error) bool {
+	if _, ok := err.(*dialError); ok {
+		return errors.Cause(err) != circuit.ErrBreakerOpen
+	}
+	return grpcutil.RequestDidNotStart(err)
+}
+
+// RetryStreamSetup dials a connection of the given class to nodeID and invokes
+// fn with it, retrying as long as the failure is known to be safe to retry. Retries are
+// subject to a node-wide budget and to a circuit breaker for nodeID.
+func RetryStreamSetup(
+	ctx context.Context,
+	dialer *nodedialer.Dialer,
+	nodeID roachpb.NodeID,
+	class rpc.ConnectionClass,
+	fn func(context.Context, *grpc.ClientConn) error,
+) error {
+	err := streamSetupRetryPolicy.Do(ctx, nodeID.String(), isRetryableStreamSetupErr,
+		func(ctx context.Context) error {
+			conn, err := dialer.DialClass(ctx, nodeID, class)
+			if err != nil {
+				return &dialError{cause: err}
+			}
+			return fn(ctx, conn)
+		})
+	if err != nil && log.V(1) {
+		log.Infof(ctx, "stream setup to n%d failed: %v", nodeID, err)
+	}
+	return err
+}
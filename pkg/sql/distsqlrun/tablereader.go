@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/row"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
@@ -64,6 +65,27 @@ type tableReader struct {
 	// initialization, call input.Next() to retrieve rows once initialized.
 	fetcher row.Fetcher
 	alloc   sqlbase.DatumAlloc
+
+	// sharedScanSpec is set if the scan can be shared with identical scans
+	// running concurrently on the node, provided that the transaction allows
+	// it (see sharedScanTimestamp).
+	sharedScanSpec *sharedScanSpec
+	// sharedScan is set if the tableReader attached to a sharedScan, in which
+	// case input returns the rows of the sharedScan instead of the fetcher's.
+	sharedScan *sharedScanSubscriber
+
+	// claimStageID is set if the tableReader claims its spans from the gateway
+	// instead of scanning spans (see TableReaderSpec.ClaimSpans). A new scan is
+	// started every time a batch of spans is claimed.
+	claimStageID int32
+	fetcherCtx   context.Context
+	limitBatches bool
+	// scanned is set once the fetcher started a scan. prevBytesRead and
+	// prevKVStats accumulate the stats of the scans that preceded the current
+	// one.
+	scanned       bool
+	prevBytesRead int64
+	prevKVStats   row.KVStats
 }
 
 var _ Processor = &tableReader{}
@@ -99,6 +121,11 @@ func newTableReader(
 	returnMutations := spec.Visibility == distsqlpb.ScanVisibility_PUBLIC_AND_NOT_PUBLIC
 	types := spec.Table.ColumnTypesWithMutations(returnMutations)
 	tr.ignoreMisplannedRanges = flowCtx.local
+	if spec.ClaimSpans {
+		tr.claimStageID = spec.ClaimStageID
+		// The claimed spans can be planned on other nodes on purpose.
+		tr.ignoreMisplannedRanges = true
+	}
 	if err := tr.Init(
 		tr,
 		post,
@@ -128,6 +155,7 @@ func newTableReader(
 	); err != nil {
 		return nil, err
 	}
+	tr.fetcher.SetBatchTargetBytes(kvBatchTargetBytes(&flowCtx.Settings.SV))
 
 	nSpans := len(spec.Spans)
 	if cap(tr.spans) >= nSpans {
@@ -145,6 +173,21 @@ func newTableReader(
 		tr.finishTrace = tr.outputStatsToTrace
 	}
 
+	// A shared scan reads all the rows of its spans, and isn't traced on behalf
+	// of any one query.
+	if flowCtx.sharedScans != nil && sharedScansEnabled.Get(&flowCtx.Settings.SV) &&
+		tr.limitHint == 0 && tr.maxResults == 0 && tr.maxTimestampAge == 0 && !spec.IsCheck &&
+		tr.finishTrace == nil && tr.claimStageID == 0 {
+		tr.sharedScanSpec = &sharedScanSpec{
+			desc:          &spec.Table,
+			indexIdx:      int(spec.IndexIdx),
+			reverse:       spec.Reverse,
+			visibility:    spec.Visibility,
+			neededColumns: neededColumns,
+			spans:         append(roachpb.Spans(nil), tr.spans...),
+		}
+	}
+
 	return tr, nil
 }
 
@@ -214,12 +257,24 @@ func initRowFetcher(
 	return index, isSecondaryIndex, nil
 }
 
+// kvBatchTargetBytes returns the number of bytes the KV batches of a table
+// scan are limited to, or 0 if they are only limited by number of keys.
+func kvBatchTargetBytes(sv *settings.Values) int64 {
+	return int64(float64(settingWorkMemBytes.Get(sv)) * settingKVBatchWorkMemFraction.Get(sv))
+}
+
 func (tr *tableReader) generateTrailingMeta(ctx context.Context) []distsqlpb.ProducerMetadata {
 	trailingMeta := tr.generateMeta(ctx)
-	tr.InternalClose()
+	tr.close()
 	return trailingMeta
 }
 
+func (tr *tableReader) close() {
+	if tr.InternalClose() && tr.sharedScan != nil {
+		tr.sharedScan.detach()
+	}
+}
+
 // Start is part of the RowSource interface.
 func (tr *tableReader) Start(ctx context.Context) context.Context {
 	if tr.flowCtx.txn == nil {
@@ -235,6 +290,17 @@ func (tr *tableReader) Start(ctx context.Context) context.Context {
 		fetcherCtx = opentracing.ContextWithSpan(fetcherCtx, procSpan)
 	}
 
+	if tr.sharedScanSpec != nil {
+		if ts, ok := sharedScanTimestamp(tr.flowCtx.txn); ok {
+			tr.sharedScanSpec.ts = ts
+			tr.sharedScan = tr.flowCtx.sharedScans.attach(*tr.sharedScanSpec)
+			tr.input = tr.sharedScan
+			tr.input.Start(fetcherCtx)
+			log.VEventf(ctx, 1, "attached to shared scan at %s", ts)
+			return ctx
+		}
+	}
+
 	// This call doesn't do much; the real "starting" is below.
 	tr.input.Start(fetcherCtx)
 
@@ -250,25 +316,54 @@ func (tr *tableReader) Start(ctx context.Context) context.Context {
 		limitBatches = false
 	}
 	log.VEventf(ctx, 1, "starting scan with limitBatches %t", limitBatches)
-	var err error
+	tr.fetcherCtx = fetcherCtx
+	tr.limitBatches = limitBatches
+	if tr.claimStageID != 0 {
+		if ok, err := tr.claimAndStartScan(); !ok || err != nil {
+			tr.MoveToDraining(err)
+		}
+		return ctx
+	}
+	if err := tr.startScan(tr.spans); err != nil {
+		tr.MoveToDraining(err)
+	}
+	return ctx
+}
+
+// startScan starts the fetcher's scan of the given spans.
+func (tr *tableReader) startScan(spans roachpb.Spans) error {
+	if tr.scanned {
+		tr.prevBytesRead += tr.fetcher.GetBytesRead()
+		stats := tr.fetcher.GetKVStats()
+		tr.prevKVStats.Batches += stats.Batches
+		tr.prevKVStats.ResumeSpans += stats.ResumeSpans
+		tr.prevKVStats.NotLeaseHolderRetries += stats.NotLeaseHolderRetries
+		tr.prevKVStats.FollowerReads += stats.FollowerReads
+	}
+	tr.scanned = true
 	if tr.maxTimestampAge == 0 {
-		err = tr.fetcher.StartScan(
-			fetcherCtx, tr.flowCtx.txn, tr.spans,
-			limitBatches, tr.limitHint, tr.flowCtx.traceKV,
-		)
-	} else {
-		initialTS := tr.flowCtx.txn.GetTxnCoordMeta(ctx).Txn.OrigTimestamp
-		err = tr.fetcher.StartInconsistentScan(
-			fetcherCtx, tr.flowCtx.ClientDB, initialTS,
-			tr.maxTimestampAge, tr.spans,
-			limitBatches, tr.limitHint, tr.flowCtx.traceKV,
+		return tr.fetcher.StartScan(
+			tr.fetcherCtx, tr.flowCtx.txn, spans,
+			tr.limitBatches, tr.limitHint, tr.flowCtx.traceKV,
 		)
 	}
+	initialTS := tr.flowCtx.txn.GetTxnCoordMeta(tr.fetcherCtx).Txn.OrigTimestamp
+	return tr.fetcher.StartInconsistentScan(
+		tr.fetcherCtx, tr.flowCtx.ClientDB, initialTS,
+		tr.maxTimestampAge, spans,
+		tr.limitBatches, tr.limitHint, tr.flowCtx.traceKV,
+	)
+}
 
-	if err != nil {
-		tr.MoveToDraining(err)
+// claimAndStartScan claims the next batch of spans from the gateway and starts
+// scanning them. It returns false if there were no spans left to claim.
+func (tr *tableReader) claimAndStartScan() (bool, error) {
+	spans, err := claimSpans(tr.fetcherCtx, tr.flowCtx, tr.claimStageID)
+	if err != nil || len(spans) == 0 {
+		return false, err
 	}
-	return ctx
+	log.VEventf(tr.Ctx, 2, "claimed %d spans", len(spans))
+	return true, tr.startScan(spans)
 }
 
 // Release releases this tableReader back to the pool.
@@ -295,6 +390,14 @@ func (tr *tableReader) Next() (sqlbase.EncDatumRow, *distsqlpb.ProducerMetadata)
 			return nil, meta
 		}
 		if row == nil {
+			if tr.claimStageID != 0 {
+				if ok, err := tr.claimAndStartScan(); ok || err != nil {
+					if err != nil {
+						tr.MoveToDraining(err)
+					}
+					continue
+				}
+			}
 			tr.MoveToDraining(nil /* err */)
 			break
 		}
@@ -309,7 +412,7 @@ func (tr *tableReader) Next() (sqlbase.EncDatumRow, *distsqlpb.ProducerMetadata)
 // ConsumerClosed is part of the RowSource interface.
 func (tr *tableReader) ConsumerClosed() {
 	// The consumer is done, Next() will not be called again.
-	tr.InternalClose()
+	tr.close()
 }
 
 var _ distsqlpb.DistSQLSpanStats = &TableReaderStats{}
@@ -320,15 +423,25 @@ const tableReaderTagPrefix = "tablereader."
 func (trs *TableReaderStats) Stats() map[string]string {
 	inputStatsMap := trs.InputStats.Stats(tableReaderTagPrefix)
 	inputStatsMap[tableReaderTagPrefix+bytesReadTagSuffix] = humanizeutil.IBytes(trs.BytesRead)
+	for k, v := range kvStats(
+		tableReaderTagPrefix, trs.KvBatches, trs.KvResumeSpans, trs.KvNotLeaseHolderRetries,
+		trs.KvFollowerReads,
+	) {
+		inputStatsMap[k] = v
+	}
 	return inputStatsMap
 }
 
 // StatsForQueryPlan implements the DistSQLSpanStats interface.
 func (trs *TableReaderStats) StatsForQueryPlan() []string {
-	return append(
+	stats := append(
 		trs.InputStats.StatsForQueryPlan("" /* prefix */),
 		fmt.Sprintf("%s: %s", bytesReadQueryPlanSuffix, humanizeutil.IBytes(trs.BytesRead)),
 	)
+	return append(stats, kvStatsForQueryPlan(
+		"" /* prefix */, trs.KvBatches, trs.KvResumeSpans, trs.KvNotLeaseHolderRetries,
+		trs.KvFollowerReads,
+	)...)
 }
 
 // outputStatsToTrace outputs the collected tableReader stats to the trace. Will
@@ -339,9 +452,19 @@ func (tr *tableReader) outputStatsToTrace() {
 		return
 	}
 	if sp := opentracing.SpanFromContext(tr.Ctx); sp != nil {
+		var fetchStats row.KVStats
+		var bytesRead int64
+		if tr.scanned {
+			fetchStats = tr.fetcher.GetKVStats()
+			bytesRead = tr.fetcher.GetBytesRead()
+		}
 		tracing.SetSpanStats(sp, &TableReaderStats{
-			InputStats: is,
-			BytesRead:  tr.fetcher.GetBytesRead(),
+			InputStats:              is,
+			BytesRead:               tr.prevBytesRead + bytesRead,
+			KvBatches:               tr.prevKVStats.Batches + fetchStats.Batches,
+			KvResumeSpans:           tr.prevKVStats.ResumeSpans + fetchStats.ResumeSpans,
+			KvNotLeaseHolderRetries: tr.prevKVStats.NotLeaseHolderRetries + fetchStats.NotLeaseHolderRetries,
+			KvFollowerReads:         tr.prevKVStats.FollowerReads + fetchStats.FollowerReads,
 		})
 	}
 }
@@ -385,6 +385,9 @@ func TestLimitScans(t *testing.T) {
 			if trs.InputStats.NumRows != limit {
 				t.Fatalf("read %d rows, but stats only counted: %d", limit, trs.InputStats.NumRows)
 			}
+			if trs.KvBatches != 1 {
+				t.Fatalf("expected the rows to be read in one kv batch, stats counted: %d", trs.KvBatches)
+			}
 		}
 		for _, l := range span.Logs {
 			for _, f := range l.Fields {
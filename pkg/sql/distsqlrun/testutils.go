@@ -16,6 +16,7 @@ package distsqlrun
 
 import (
 	"context"
+	"io"
 	"net"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/netutil"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 )
@@ -39,7 +41,7 @@ func newInsecureRPCContext(stopper *stop.Stopper) *rpc.Context {
 		&base.Config{Insecure: true},
 		hlc.NewClock(hlc.UnixNano, time.Nanosecond),
 		stopper,
-		&cluster.MakeTestingClusterSettings().Version,
+		cluster.MakeTestingClusterSettings(),
 	)
 }
 
@@ -65,6 +67,39 @@ func StartMockDistSQLServer(
 type MockDistSQLServer struct {
 	InboundStreams   chan InboundStreamNotification
 	runSyncFlowCalls chan RunSyncFlowCall
+
+	mu struct {
+		syncutil.Mutex
+		faults StreamFaults
+	}
+}
+
+// StreamFaults describes the faults that a MockDistSQLServer injects into the
+// FlowStream RPCs it receives. Messages are numbered per stream starting at 1,
+// counting all the ProducerMessages received from the producer, including the
+// one carrying the header. A zero value injects no faults.
+type StreamFaults struct {
+	// DropMessage is the number of the message that is dropped rather than
+	// returned by Recv.
+	DropMessage int
+	// CorruptMessage is the number of the message whose data payload is
+	// corrupted: its RawBytes are inverted, or replaced by a garbage byte if
+	// the message has none.
+	CorruptMessage int
+	// HalfCloseAfter is the number of messages after which Recv returns
+	// io.EOF, as if the producer had closed its side of the stream. Signals can
+	// still be sent to the producer.
+	HalfCloseAfter int
+	// SendDelay delays every ConsumerSignal sent to the producer.
+	SendDelay time.Duration
+}
+
+// SetStreamFaults sets the faults injected into the FlowStream RPCs received
+// from now on. Streams that have already arrived aren't affected.
+func (ds *MockDistSQLServer) SetStreamFaults(faults StreamFaults) {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.mu.faults = faults
 }
 
 // InboundStreamNotification is the MockDistSQLServer's way to tell its clients
@@ -109,7 +144,66 @@ func (ds *MockDistSQLServer) SetupFlow(
 
 // FlowStream is part of the DistSQLServer interface.
 func (ds *MockDistSQLServer) FlowStream(stream distsqlpb.DistSQL_FlowStreamServer) error {
+	ds.mu.Lock()
+	faults := ds.mu.faults
+	ds.mu.Unlock()
+	if faults != (StreamFaults{}) {
+		stream = &faultyFlowStream{DistSQL_FlowStreamServer: stream, faults: faults}
+	}
 	donec := make(chan error)
 	ds.InboundStreams <- InboundStreamNotification{Stream: stream, Donec: donec}
 	return <-donec
 }
+
+// ClaimSpans is part of the DistSQLServer interface.
+func (ds *MockDistSQLServer) ClaimSpans(
+	_ context.Context, req *distsqlpb.ClaimSpansRequest,
+) (*distsqlpb.ClaimSpansResponse, error) {
+	return &distsqlpb.ClaimSpansResponse{}, nil
+}
+
+// faultyFlowStream wraps the server side of a FlowStream RPC and injects the
+// given faults into it.
+type faultyFlowStream struct {
+	distsqlpb.DistSQL_FlowStreamServer
+	faults StreamFaults
+	// numRecv is the number of messages received from the producer so far.
+	numRecv int
+}
+
+// Recv is part of the DistSQL_FlowStreamServer interface.
+func (s *faultyFlowStream) Recv() (*distsqlpb.ProducerMessage, error) {
+	for {
+		if s.faults.HalfCloseAfter != 0 && s.numRecv >= s.faults.HalfCloseAfter {
+			return nil, io.EOF
+		}
+		msg, err := s.DistSQL_FlowStreamServer.Recv()
+		if err != nil {
+			return nil, err
+		}
+		s.numRecv++
+		switch s.numRecv {
+		case s.faults.DropMessage:
+			continue
+		case s.faults.CorruptMessage:
+			corrupted := *msg
+			corrupted.Data.RawBytes = make([]byte, len(msg.Data.RawBytes))
+			for i, b := range msg.Data.RawBytes {
+				corrupted.Data.RawBytes[i] = ^b
+			}
+			if len(corrupted.Data.RawBytes) == 0 {
+				corrupted.Data.RawBytes = []byte{0xff}
+			}
+			msg = &corrupted
+		}
+		return msg, nil
+	}
+}
+
+// Send is part of the DistSQL_FlowStreamServer interface.
+func (s *faultyFlowStream) Send(signal *distsqlpb.ConsumerSignal) error {
+	if s.faults.SendDelay != 0 {
+		time.Sleep(s.faults.SendDelay)
+	}
+	return s.DistSQL_FlowStreamServer.Send(signal)
+}
@@ -0,0 +1,103 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package distsqlrun
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"google.golang.org/grpc"
+)
+
+func TestMockDistSQLServerStreamFaults(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	clock := hlc.NewClock(hlc.UnixNano, time.Nanosecond)
+	_, mockServer, addr, err := StartMockDistSQLServer(clock, stopper, staticNodeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := grpc.Dial(addr.String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	const sendDelay = 10 * time.Millisecond
+	mockServer.SetStreamFaults(StreamFaults{
+		DropMessage:    2,
+		CorruptMessage: 3,
+		HalfCloseAfter: 3,
+		SendDelay:      sendDelay,
+	})
+	clientStream, err := distsqlpb.NewDistSQLClient(conn).FlowStream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, payload := range []string{"a", "b", "c", "d"} {
+		msg := &distsqlpb.ProducerMessage{}
+		msg.Data.RawBytes = []byte(payload)
+		if err := clientStream.Send(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	notification := <-mockServer.InboundStreams
+	serverStream := notification.Stream
+	expected := [][]byte{[]byte("a"), {^byte('c')}}
+	for _, exp := range expected {
+		msg, err := serverStream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(msg.Data.RawBytes) != string(exp) {
+			t.Fatalf("expected payload %q, got %q", exp, msg.Data.RawBytes)
+		}
+	}
+	if _, err := serverStream.Recv(); err != io.EOF {
+		t.Fatalf("expected EOF after the stream was half-closed, got %v", err)
+	}
+
+	// Signals can still be sent to the producer, albeit with a delay.
+	start := timeutil.Now()
+	if err := serverStream.Send(&distsqlpb.ConsumerSignal{DrainRequest: &distsqlpb.DrainRequest{}}); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := timeutil.Since(start); elapsed < sendDelay {
+		t.Fatalf("expected the send to be delayed by %s, took %s", sendDelay, elapsed)
+	}
+	signal, err := clientStream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signal.DrainRequest == nil {
+		t.Fatalf("expected a drain request, got %v", signal)
+	}
+
+	notification.Donec <- nil
+	if _, err := clientStream.Recv(); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
@@ -109,6 +109,9 @@ type EventLogSetClusterSettingDetail struct {
 	SettingName string
 	Value       string
 	User        string
+	// PreviousValue is the encoded value the setting had before the change. It
+	// is empty if the setting was at its default value.
+	PreviousValue string `json:",omitempty"`
 }
 
 // An EventLogger exposes methods used to record events to the event table.
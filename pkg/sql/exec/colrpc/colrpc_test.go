@@ -217,7 +217,7 @@ func TestOutboxInbox(t *testing.T) {
 		}
 		input := exec.NewRandomDataOp(rng, args)
 
-		outbox, err := NewOutbox(input, typs, nil)
+		outbox, err := NewOutbox(input, typs, nil /* metadataSources */, nil /* metrics */)
 		require.NoError(t, err)
 
 		inbox, err := NewInbox(typs)
@@ -430,6 +430,11 @@ func TestOutboxInboxMetadataPropagation(t *testing.T) {
 			)
 
 			const expectedMeta = "someError"
+			// The refresh spans of leaf transactions have to make it to the root
+			// transaction for read refreshes to work.
+			expectedTxnMeta := roachpb.TxnCoordMeta{
+				RefreshReads: []roachpb.Span{{Key: roachpb.Key("a"), EndKey: roachpb.Key("b")}},
+			}
 
 			outbox, err := NewOutbox(
 				input,
@@ -437,10 +442,14 @@ func TestOutboxInboxMetadataPropagation(t *testing.T) {
 				[]distsqlpb.MetadataSource{
 					distsqlpb.CallbackMetadataSource{
 						DrainMetaCb: func(context.Context) []distsqlpb.ProducerMetadata {
-							return []distsqlpb.ProducerMetadata{{Err: errors.New(expectedMeta)}}
+							return []distsqlpb.ProducerMetadata{
+								{Err: errors.New(expectedMeta)},
+								{TxnCoordMeta: &expectedTxnMeta},
+							}
 						},
 					},
 				},
+				nil, /* metrics */
 			)
 			require.NoError(t, err)
 
@@ -468,8 +477,177 @@ func TestOutboxInboxMetadataPropagation(t *testing.T) {
 			require.True(t, atomic.LoadUint32(&canceled) == 0)
 
 			// Verify that we received the expected metadata.
-			require.True(t, len(meta) == 1)
+			require.True(t, len(meta) == 2)
 			require.True(t, testutils.IsError(meta[0].Err, expectedMeta), meta[0].Err)
+			require.NotNil(t, meta[1].TxnCoordMeta)
+			require.Equal(t, expectedTxnMeta.RefreshReads, meta[1].TxnCoordMeta.RefreshReads)
+		})
+	}
+}
+
+// gatedOperator calls gate before returning the first batch of its input.
+type gatedOperator struct {
+	exec.Operator
+	gate func()
+	once sync.Once
+}
+
+func (o *gatedOperator) Next(ctx context.Context) coldata.Batch {
+	o.once.Do(o.gate)
+	return o.Operator.Next(ctx)
+}
+
+// TestOutboxInboxCompression verifies that an Outbox compresses its data once
+// the Inbox has accepted its offer to do so.
+func TestOutboxInboxCompression(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var (
+		ctx      = context.Background()
+		rpcLayer = makeMockFlowStreamRPCLayer()
+		typs     = []types.T{types.Int64}
+		rng, _   = randutil.NewPseudoRand()
+		expected []int64
+		outbox   *Outbox
+	)
+	input := &gatedOperator{
+		Operator: exec.NewRandomDataOp(rng, exec.RandomDataOpArgs{
+			DeterministicTyps: typs,
+			NumBatches:        16,
+			BatchAccumulator: func(b coldata.Batch) {
+				expected = append(expected, b.ColVec(0).Int64()[:b.Length()]...)
+			},
+		}),
+		gate: func() {
+			// Only start sending data once the offer to compress it was accepted.
+			for atomic.LoadUint32(&outbox.compress) == 0 {
+				time.Sleep(time.Millisecond)
+			}
+		},
+	}
+	var err error
+	metrics := MakeMetrics()
+	outbox, err = NewOutbox(input, typs, nil /* metadataSources */, &metrics)
+	require.NoError(t, err)
+	outbox.offerCompression = true
+
+	inbox, err := NewInbox(typs)
+	require.NoError(t, err)
+	streamHandlerErrCh := handleStream(ctx, inbox, rpcLayer.server, func() { close(rpcLayer.server.csChan) })
+
+	// Send the header offering compression, as Run does.
+	require.NoError(t, rpcLayer.client.Send(&distsqlpb.ProducerMessage{
+		Header: &distsqlpb.ProducerHeader{SnappyCompression: true},
+	}))
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		outbox.runWithStream(ctx, rpcLayer.client, nil /* cancelFn */)
+		wg.Done()
+	}()
+
+	var actual []int64
+	for {
+		b := inbox.Next(ctx)
+		if b.Length() == 0 {
+			break
+		}
+		actual = append(actual, b.ColVec(0).Int64()[:b.Length()]...)
+	}
+	wg.Wait()
+	require.NoError(t, <-streamHandlerErrCh)
+
+	require.Equal(t, expected, actual)
+	stats := outbox.CompressionStats()
+	require.True(t, stats.CompressedBytes > 0)
+	require.Equal(t, int64(1), metrics.CompressedStreams.Count())
+	require.Equal(t, stats.UncompressedBytes, metrics.UncompressedBytes.Count())
+	require.Equal(t, stats.CompressedBytes, metrics.CompressedBytes.Count())
+}
+
+// oldProducerStreamClient is a flowStreamClient that hides the columns
+// requested by the consumer from the Outbox, like a producer that doesn't
+// support projection would ignore them.
+type oldProducerStreamClient struct {
+	flowStreamClient
+}
+
+func (c oldProducerStreamClient) Recv() (*distsqlpb.ConsumerSignal, error) {
+	s, err := c.flowStreamClient.Recv()
+	if s != nil && s.Handshake != nil {
+		s.Handshake.OutputColumns = nil
+	}
+	return s, err
+}
+
+// TestOutboxInboxProjection verifies that an Outbox only sends the columns an
+// Inbox asked for, and that the Inbox projects the columns itself if the
+// producer doesn't.
+func TestOutboxInboxProjection(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	for _, oldProducer := range []bool{false, true} {
+		t.Run(fmt.Sprintf("oldProducer=%t", oldProducer), func(t *testing.T) {
+			var (
+				ctx      = context.Background()
+				rpcLayer = makeMockFlowStreamRPCLayer()
+				typs     = []types.T{types.Int64, types.Int64, types.Int64}
+				rng, _   = randutil.NewPseudoRand()
+				expected []int64
+				outbox   *Outbox
+			)
+			input := &gatedOperator{
+				Operator: exec.NewRandomDataOp(rng, exec.RandomDataOpArgs{
+					DeterministicTyps: typs,
+					NumBatches:        16,
+					BatchAccumulator: func(b coldata.Batch) {
+						expected = append(expected, b.ColVec(1).Int64()[:b.Length()]...)
+					},
+				}),
+				gate: func() {
+					if oldProducer {
+						return
+					}
+					// Only start sending data once the consumer asked for its columns.
+					for outbox.projection.Load() == nil {
+						time.Sleep(time.Millisecond)
+					}
+				},
+			}
+			var err error
+			outbox, err = NewOutbox(input, typs, nil /* metadataSources */, nil /* metrics */)
+			require.NoError(t, err)
+
+			inbox, err := NewProjectingInbox(typs, []uint32{1})
+			require.NoError(t, err)
+			streamHandlerErrCh := handleStream(ctx, inbox, rpcLayer.server, func() { close(rpcLayer.server.csChan) })
+
+			var client flowStreamClient = rpcLayer.client
+			if oldProducer {
+				client = oldProducerStreamClient{flowStreamClient: client}
+			}
+			require.NoError(t, client.Send(&distsqlpb.ProducerMessage{Header: &distsqlpb.ProducerHeader{}}))
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				outbox.runWithStream(ctx, client, nil /* cancelFn */)
+				wg.Done()
+			}()
+
+			var actual []int64
+			for {
+				b := inbox.Next(ctx)
+				if b.Length() == 0 {
+					break
+				}
+				require.Equal(t, 1, b.Width())
+				actual = append(actual, b.ColVec(0).Int64()[:b.Length()]...)
+			}
+			wg.Wait()
+			require.NoError(t, <-streamHandlerErrCh)
+
+			require.Equal(t, expected, actual)
+			require.Equal(t, !oldProducer, outbox.projecting)
 		})
 	}
 }
@@ -502,7 +680,7 @@ func BenchmarkOutboxInbox(b *testing.B) {
 
 	input := exec.NewRepeatableBatchSource(batch)
 
-	outbox, err := NewOutbox(input, typs, nil /* metadataSources */)
+	outbox, err := NewOutbox(input, typs, nil /* metadataSources */, nil /* metrics */)
 	require.NoError(b, err)
 
 	inbox, err := NewInbox(typs)
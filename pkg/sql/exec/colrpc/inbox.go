@@ -25,9 +25,17 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/coldata"
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/colserde"
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
 )
 
+// inboxMaxBufferedMetaBytes bounds the size of the metadata an Inbox buffers
+// before it is drained, so that a remote flow flooding it with metadata
+// can't use up an unbounded amount of memory.
+const inboxMaxBufferedMetaBytes = 16 << 20
+
 // flowStreamServer is a utility interface used to mock out the RPC layer.
 type flowStreamServer interface {
 	Send(*distsqlpb.ConsumerSignal) error
@@ -52,6 +60,16 @@ type Inbox struct {
 	converter  *colserde.ArrowBatchConverter
 	serializer *colserde.RecordBatchSerializer
 
+	// projection, if set, are the columns of the stream that the Inbox outputs,
+	// in order. The producer is asked to only send these columns, but producers
+	// that don't support this send all of them, so the Inbox has to be able to
+	// deserialize both kinds of batches.
+	projection []uint32
+	projected  struct {
+		converter  *colserde.ArrowBatchConverter
+		serializer *colserde.RecordBatchSerializer
+	}
+
 	// initialized and done prevent double initialization/closing. Should not be
 	// used by the RunWithStream goroutine.
 	initialized bool
@@ -76,6 +94,14 @@ type Inbox struct {
 	// bufferedMeta buffers any metadata found in Next when reading from the
 	// stream and is returned by DrainMeta.
 	bufferedMeta []distsqlpb.ProducerMetadata
+	// bufferedMetaBytes is the encoded size of the metadata in bufferedMeta,
+	// which may not exceed maxBufferedMetaBytes.
+	bufferedMetaBytes    int
+	maxBufferedMetaBytes int
+	// sawErrorMeta is set once an error has been received as metadata. Next
+	// then stops returning data so that the error is propagated by DrainMeta
+	// right away rather than once the remote flow is done.
+	sawErrorMeta bool
 
 	scratch struct {
 		data []*array.Data
@@ -86,19 +112,45 @@ var _ exec.Operator = &Inbox{}
 
 // NewInbox creates a new Inbox.
 func NewInbox(typs []types.T) (*Inbox, error) {
+	return NewProjectingInbox(typs, nil /* projection */)
+}
+
+// NewProjectingInbox creates a new Inbox for a stream of the given types that
+// only outputs the columns in projection, in order, so that the other columns
+// don't need to be sent over the wire. A nil projection outputs all columns.
+func NewProjectingInbox(typs []types.T, projection []uint32) (*Inbox, error) {
 	s, err := colserde.NewRecordBatchSerializer(typs)
 	if err != nil {
 		return nil, err
 	}
+	outputTyps := typs
+	if projection != nil {
+		outputTyps = make([]types.T, len(projection))
+		for j, c := range projection {
+			if int(c) >= len(typs) {
+				return nil, errors.Errorf("column %d projected, but the stream only has %d columns", c, len(typs))
+			}
+			outputTyps[j] = typs[c]
+		}
+	}
 	i := &Inbox{
 		typs:         typs,
-		zeroBatch:    coldata.NewMemBatchWithSize(typs, 0),
+		zeroBatch:    coldata.NewMemBatchWithSize(outputTyps, 0),
 		converter:    colserde.NewArrowBatchConverter(typs),
 		serializer:   s,
 		streamCh:     make(chan flowStreamServer, 1),
 		contextCh:    make(chan context.Context, 1),
 		errCh:        make(chan error, 1),
 		bufferedMeta: make([]distsqlpb.ProducerMetadata, 0),
+
+		maxBufferedMetaBytes: inboxMaxBufferedMetaBytes,
+	}
+	if projection != nil {
+		i.projection = projection
+		i.projected.converter = colserde.NewArrowBatchConverter(outputTyps)
+		if i.projected.serializer, err = colserde.NewRecordBatchSerializer(outputTyps); err != nil {
+			return nil, err
+		}
 	}
 	i.zeroBatch.SetLength(0)
 	i.scratch.data = make([]*array.Data, len(typs))
@@ -183,11 +235,63 @@ func (i *Inbox) RunWithStream(streamCtx context.Context, stream flowStreamServer
 // Init is part of the Operator interface.
 func (i *Inbox) Init() {}
 
+// bufferMeta adds the given metadata received from the stream to
+// bufferedMeta. An error is returned if this would make the buffered metadata
+// exceed maxBufferedMetaBytes.
+func (i *Inbox) bufferMeta(rpms []distsqlpb.RemoteProducerMetadata) error {
+	for _, rpm := range rpms {
+		meta, ok := distsqlpb.RemoteProducerMetaToLocalMeta(rpm)
+		if !ok {
+			continue
+		}
+		i.bufferedMetaBytes += rpm.Size()
+		if i.bufferedMetaBytes > i.maxBufferedMetaBytes {
+			return pgerror.Newf(pgerror.CodeProgramLimitExceededError,
+				"Inbox received more than %d bytes of metadata", i.maxBufferedMetaBytes)
+		}
+		if meta.Err != nil {
+			i.sawErrorMeta = true
+		}
+		i.bufferedMeta = append(i.bufferedMeta, meta)
+	}
+	return nil
+}
+
+// handleHeader validates the header of the stream and, if needed, replies with
+// a handshake which reports the negotiated stream version, accepts the
+// producer's offer to compress its data, if any, and asks it to only send the
+// columns we need.
+func (i *Inbox) handleHeader(h *distsqlpb.ProducerHeader) error {
+	version, err := h.NegotiateVersion(
+		distsqlpb.MinAcceptedStreamVersion, distsqlpb.CurrentStreamVersion,
+	)
+	if err != nil {
+		return err
+	}
+	// Producers which predate stream versioning don't advertise their
+	// capabilities, but they are columnar if they talk to an Inbox.
+	if version != distsqlpb.StreamVersionUnversioned &&
+		!h.Capabilities.Has(distsqlpb.StreamCapabilityColumnar) {
+		return errors.New("Inbox received row-based stream, expected columnar batches")
+	}
+	if version == distsqlpb.StreamVersionUnversioned && !h.SnappyCompression && i.projection == nil {
+		return nil
+	}
+	return i.stream.Send(&distsqlpb.ConsumerSignal{
+		Handshake: &distsqlpb.ConsumerHandshake{
+			ConsumerScheduled: true,
+			SnappyCompression: h.SnappyCompression,
+			OutputColumns:     i.projection,
+			StreamVersion:     version,
+		},
+	})
+}
+
 // Next returns the next batch. It will block until there is data available.
 // For simplicity, the Inbox will only listen for cancellation of the context
 // passed in to the first Next call.
 func (i *Inbox) Next(ctx context.Context) coldata.Batch {
-	if i.done {
+	if i.done || i.sawErrorMeta {
 		return i.zeroBatch
 	}
 
@@ -219,13 +323,20 @@ func (i *Inbox) Next(ctx context.Context) coldata.Batch {
 			i.errCh <- err
 			panic(err)
 		}
+		if m.Header != nil {
+			if err := i.handleHeader(m.Header); err != nil {
+				i.errCh <- err
+				panic(err)
+			}
+		}
 		if len(m.Data.Metadata) != 0 {
-			for _, rpm := range m.Data.Metadata {
-				meta, ok := distsqlpb.RemoteProducerMetaToLocalMeta(rpm)
-				if !ok {
-					continue
-				}
-				i.bufferedMeta = append(i.bufferedMeta, meta)
+			if err := i.bufferMeta(m.Data.Metadata); err != nil {
+				panic(err)
+			}
+			if i.sawErrorMeta {
+				// The remote flow failed, don't wait for it to finish before the
+				// error is propagated.
+				return i.zeroBatch
 			}
 			// Continue until we get the next batch or EOF.
 			continue
@@ -235,14 +346,31 @@ func (i *Inbox) Next(ctx context.Context) coldata.Batch {
 			// TODO(asubiotto): I don't think we're using NumEmptyRows, right?
 			continue
 		}
+		rawBytes := m.Data.RawBytes
+		if m.Data.SnappyCompressed {
+			if rawBytes, err = snappy.Decode(nil /* dst */, rawBytes); err != nil {
+				panic(err)
+			}
+		}
+		converter, serializer := i.converter, i.serializer
+		if m.Data.ColumnsProjected {
+			if i.projection == nil {
+				panic(errors.New("Inbox received projected columns without asking for them"))
+			}
+			converter, serializer = i.projected.converter, i.projected.serializer
+		}
 		i.scratch.data = i.scratch.data[:0]
-		if err := i.serializer.Deserialize(&i.scratch.data, m.Data.RawBytes); err != nil {
+		if err := serializer.Deserialize(&i.scratch.data, rawBytes); err != nil {
 			panic(err)
 		}
-		b, err := i.converter.ArrowToBatch(i.scratch.data)
+		b, err := converter.ArrowToBatch(i.scratch.data)
 		if err != nil {
 			panic(err)
 		}
+		if i.projection != nil && !m.Data.ColumnsProjected {
+			// The producer doesn't support projection, so do it here.
+			return exec.NewProjectingBatch(b, i.projection)
+		}
 		return b
 	}
 }
@@ -250,22 +378,24 @@ func (i *Inbox) Next(ctx context.Context) coldata.Batch {
 // DrainMeta is part of the MetadataGenerator interface. DrainMeta may not be
 // called concurrently with Next.
 func (i *Inbox) DrainMeta(ctx context.Context) []distsqlpb.ProducerMetadata {
-	allMeta := i.bufferedMeta
-	i.bufferedMeta = i.bufferedMeta[:0]
+	defer func() {
+		i.bufferedMeta = nil
+		i.bufferedMetaBytes = 0
+	}()
 
 	if i.done {
-		return allMeta
+		return i.bufferedMeta
 	}
 
 	defer i.close()
 	if err := i.maybeInit(ctx); err != nil {
 		log.Warningf(ctx, "Inbox unable to initialize stream while draining metadata: %s", err)
-		return allMeta
+		return i.bufferedMeta
 	}
 	log.VEvent(ctx, 2, "Inbox sending drain signal to Outbox")
 	if err := i.stream.Send(&distsqlpb.ConsumerSignal{DrainRequest: &distsqlpb.DrainRequest{}}); err != nil {
 		log.Warningf(ctx, "Inbox unable to send drain signal to Outbox: %s", err)
-		return allMeta
+		return i.bufferedMeta
 	}
 	for {
 		msg, err := i.stream.Recv()
@@ -274,16 +404,14 @@ func (i *Inbox) DrainMeta(ctx context.Context) []distsqlpb.ProducerMetadata {
 				break
 			}
 			log.Warningf(ctx, "Inbox Recv connection error while draining metadata: %s", err)
-			return allMeta
+			return i.bufferedMeta
 		}
-		for _, remoteMeta := range msg.Data.Metadata {
-			meta, ok := distsqlpb.RemoteProducerMetaToLocalMeta(remoteMeta)
-			if !ok {
-				continue
-			}
-			allMeta = append(allMeta, meta)
+		if err := i.bufferMeta(msg.Data.Metadata); err != nil {
+			// Stop reading from the stream; returning closes it, which the remote
+			// flow will notice.
+			return append(i.bufferedMeta, distsqlpb.ProducerMetadata{Err: err})
 		}
 	}
 
-	return allMeta
+	return i.bufferedMeta
 }
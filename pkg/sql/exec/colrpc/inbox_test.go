@@ -18,11 +18,13 @@ import (
 	"context"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/exec"
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -138,3 +140,125 @@ func TestInboxNextPanicDoesntLeakGoroutines(t *testing.T) {
 	// panic is bubbled up through the Next chain on the Inbox's host.
 	require.NoError(t, <-streamHandlerErrCh)
 }
+
+// TestInboxMetadata verifies that an Inbox stops returning data as soon as it
+// receives an error as metadata and that it bounds the metadata it buffers.
+func TestInboxMetadata(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	typs := []types.T{types.Int64}
+	t.Run("ErrorStopsNext", func(t *testing.T) {
+		inbox, err := NewInbox(typs)
+		require.NoError(t, err)
+
+		rpcLayer := makeMockFlowStreamRPCLayer()
+		streamHandlerErrCh := handleStream(context.Background(), inbox, rpcLayer.server, nil /* doneFn */)
+
+		m := &distsqlpb.ProducerMessage{}
+		m.Data.Metadata = append(m.Data.Metadata, distsqlpb.LocalMetaToRemoteProducerMeta(
+			distsqlpb.ProducerMetadata{Err: errors.New("remote error")},
+		))
+		require.NoError(t, rpcLayer.client.Send(m))
+
+		// The remote flow hasn't closed the stream, yet the Inbox shouldn't wait
+		// for more data after the error.
+		require.Equal(t, uint16(0), inbox.Next(context.Background()).Length())
+		require.Equal(t, uint16(0), inbox.Next(context.Background()).Length())
+
+		require.NoError(t, rpcLayer.client.CloseSend())
+		meta := inbox.DrainMeta(context.Background())
+		require.Len(t, meta, 1)
+		require.True(t, testutils.IsError(meta[0].Err, "remote error"), meta[0].Err)
+		require.NoError(t, <-streamHandlerErrCh)
+	})
+
+	t.Run("BufferLimit", func(t *testing.T) {
+		inbox, err := NewInbox(typs)
+		require.NoError(t, err)
+		inbox.maxBufferedMetaBytes = 1
+
+		rpcLayer := makeMockFlowStreamRPCLayer()
+		streamHandlerErrCh := handleStream(
+			context.Background(), inbox, rpcLayer.server, func() { close(rpcLayer.client.csChan) },
+		)
+
+		m := &distsqlpb.ProducerMessage{}
+		m.Data.Metadata = append(m.Data.Metadata, distsqlpb.LocalMetaToRemoteProducerMeta(
+			distsqlpb.ProducerMetadata{TxnCoordMeta: &roachpb.TxnCoordMeta{}},
+		))
+		require.NoError(t, rpcLayer.client.Send(m))
+
+		err = exec.CatchVectorizedRuntimeError(func() { inbox.Next(context.Background()) })
+		require.True(t, testutils.IsError(err, "more than 1 bytes of metadata"), err)
+		require.NoError(t, <-streamHandlerErrCh)
+	})
+}
+
+// TestInboxStreamVersion verifies that an Inbox reports the stream version it
+// negotiated with its producer and that it rejects the streams of producers it
+// can't talk to.
+func TestInboxStreamVersion(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	typs := []types.T{types.Int64}
+	t.Run("Compatible", func(t *testing.T) {
+		inbox, err := NewInbox(typs)
+		require.NoError(t, err)
+
+		rpcLayer := makeMockFlowStreamRPCLayer()
+		streamHandlerErrCh := handleStream(context.Background(), inbox, rpcLayer.server, nil /* doneFn */)
+
+		require.NoError(t, rpcLayer.client.Send(&distsqlpb.ProducerMessage{
+			Header: &distsqlpb.ProducerHeader{
+				Version:            distsqlpb.CurrentStreamVersion + 1,
+				MinAcceptedVersion: distsqlpb.CurrentStreamVersion,
+				Capabilities:       distsqlpb.StreamCapabilityColumnar,
+			},
+		}))
+		require.NoError(t, rpcLayer.client.CloseSend())
+		require.Equal(t, uint16(0), inbox.Next(context.Background()).Length())
+		require.NoError(t, <-streamHandlerErrCh)
+
+		signal, err := rpcLayer.client.Recv()
+		require.NoError(t, err)
+		require.NotNil(t, signal.Handshake)
+		require.Equal(t, distsqlpb.CurrentStreamVersion, signal.Handshake.StreamVersion)
+	})
+
+	for _, tc := range []struct {
+		name   string
+		header distsqlpb.ProducerHeader
+		err    string
+	}{
+		{
+			name: "Incompatible",
+			header: distsqlpb.ProducerHeader{
+				Version:            distsqlpb.CurrentStreamVersion + 2,
+				MinAcceptedVersion: distsqlpb.CurrentStreamVersion + 1,
+				Capabilities:       distsqlpb.StreamCapabilityColumnar,
+			},
+			err: "incompatible stream versions",
+		},
+		{
+			name: "RowBased",
+			header: distsqlpb.ProducerHeader{
+				Version:            distsqlpb.CurrentStreamVersion,
+				MinAcceptedVersion: distsqlpb.MinAcceptedStreamVersion,
+			},
+			err: "received row-based stream",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			inbox, err := NewInbox(typs)
+			require.NoError(t, err)
+
+			rpcLayer := makeMockFlowStreamRPCLayer()
+			streamHandlerErrCh := handleStream(context.Background(), inbox, rpcLayer.server, nil /* doneFn */)
+
+			require.NoError(t, rpcLayer.client.Send(&distsqlpb.ProducerMessage{Header: &tc.header}))
+			err = exec.CatchVectorizedRuntimeError(func() { inbox.Next(context.Background()) })
+			require.True(t, testutils.IsError(err, tc.err), err)
+			require.True(t, testutils.IsError(<-streamHandlerErrCh, tc.err))
+		})
+	}
+}
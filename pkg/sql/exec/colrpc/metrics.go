@@ -0,0 +1,59 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package colrpc
+
+import "github.com/cockroachdb/cockroach/pkg/util/metric"
+
+// Metrics holds the metrics of the vectorized streams between nodes.
+type Metrics struct {
+	CompressedStreams *metric.Counter
+	UncompressedBytes *metric.Counter
+	CompressedBytes   *metric.Counter
+}
+
+// MetricStruct implements the metric.Struct interface.
+func (Metrics) MetricStruct() {}
+
+var _ metric.Struct = Metrics{}
+
+var (
+	metaCompressedStreams = metric.Metadata{
+		Name:        "sql.distsql.vec.streams.compressed",
+		Help:        "Number of vectorized streams whose data was compressed",
+		Measurement: "Streams",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaUncompressedBytes = metric.Metadata{
+		Name:        "sql.distsql.vec.streams.compression.uncompressed_bytes",
+		Help:        "Size of the data sent over compressed vectorized streams before compression",
+		Measurement: "Memory",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaCompressedBytes = metric.Metadata{
+		Name:        "sql.distsql.vec.streams.compression.compressed_bytes",
+		Help:        "Size of the data sent over compressed vectorized streams after compression",
+		Measurement: "Memory",
+		Unit:        metric.Unit_BYTES,
+	}
+)
+
+// MakeMetrics instantiates the metrics of the vectorized streams.
+func MakeMetrics() Metrics {
+	return Metrics{
+		CompressedStreams: metric.NewCounter(metaCompressedStreams),
+		UncompressedBytes: metric.NewCounter(metaUncompressedBytes),
+		CompressedBytes:   metric.NewCounter(metaCompressedBytes),
+	}
+}
@@ -21,7 +21,10 @@ import (
 	"sync/atomic"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/rpc"
 	"github.com/cockroachdb/cockroach/pkg/rpc/nodedialer"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/exec"
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/coldata"
@@ -29,6 +32,15 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/exec/types"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/log/logtags"
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+)
+
+var streamCompressionEnabled = settings.RegisterBoolSetting(
+	"sql.distsql.vectorize_stream_compression.enabled",
+	"if set, vectorized DistSQL streams compress the data they send with snappy; "+
+		"useful when RPC compression is disabled",
+	false,
 )
 
 // flowStreamClient is a utility interface used to mock out the RPC layer.
@@ -52,15 +64,44 @@ type Outbox struct {
 	draining        uint32
 	metadataSources []distsqlpb.MetadataSource
 
+	// offerCompression is set if the Outbox offered to compress its data in
+	// the header of its stream. compress is an atomic that is set once the
+	// consumer has accepted the offer.
+	offerCompression bool
+	compress         uint32
+	// compressionStats track the effect of compression on the data sent over
+	// the stream. They're also added to metrics, if set.
+	compressionStats CompressionStats
+	metrics          *Metrics
+
+	// projection is set by the Recv goroutine to the columns the consumer asked
+	// for in its handshake, if any. Once the sending goroutine sees it, it
+	// inserts a simple projection into the Outbox's pipeline and sets
+	// projecting, so that only these columns are sent from then on.
+	projection atomic.Value
+	projecting bool
+
 	scratch struct {
-		buf *bytes.Buffer
-		msg *distsqlpb.ProducerMessage
+		buf        *bytes.Buffer
+		compressed []byte
+		msg        *distsqlpb.ProducerMessage
 	}
 }
 
-// NewOutbox creates a new Outbox.
+// CompressionStats describe the effect of compression on the data sent over
+// the stream of an Outbox. Only the data sent once the consumer accepted the
+// offer to compress it is accounted for.
+type CompressionStats struct {
+	UncompressedBytes int64
+	CompressedBytes   int64
+}
+
+// NewOutbox creates a new Outbox. The metrics are optional.
 func NewOutbox(
-	input exec.Operator, typs []types.T, metadataSources []distsqlpb.MetadataSource,
+	input exec.Operator,
+	typs []types.T,
+	metadataSources []distsqlpb.MetadataSource,
+	metrics *Metrics,
 ) (*Outbox, error) {
 	s, err := colserde.NewRecordBatchSerializer(typs)
 	if err != nil {
@@ -74,12 +115,19 @@ func NewOutbox(
 		converter:       colserde.NewArrowBatchConverter(typs),
 		serializer:      s,
 		metadataSources: metadataSources,
+		metrics:         metrics,
 	}
 	o.scratch.buf = &bytes.Buffer{}
 	o.scratch.msg = &distsqlpb.ProducerMessage{}
 	return o, nil
 }
 
+// CompressionStats returns the compression stats of the Outbox's stream. It
+// must not be called before Run has returned.
+func (o *Outbox) CompressionStats() CompressionStats {
+	return o.compressionStats
+}
+
 // Get rid of unused warning.
 // TODO(asubiotto): Remove this once Outbox is used.
 var _ = (&Outbox{}).Run
@@ -104,6 +152,7 @@ var _ = (&Outbox{}).Run
 //    Outbox goes through the same steps as 1).
 func (o *Outbox) Run(
 	ctx context.Context,
+	st *cluster.Settings,
 	dialer *nodedialer.Dialer,
 	nodeID roachpb.NodeID,
 	flowID distsqlpb.FlowID,
@@ -116,7 +165,7 @@ func (o *Outbox) Run(
 	defer cancel()
 
 	log.VEventf(ctx, 2, "Outbox Dialing %s", nodeID)
-	conn, err := dialer.Dial(ctx, nodeID)
+	conn, err := dialer.DialClass(ctx, nodeID, rpc.DistSQLFlowClass)
 	if err != nil {
 		log.Warningf(
 			ctx,
@@ -139,8 +188,16 @@ func (o *Outbox) Run(
 
 	log.VEvent(ctx, 2, "Outbox sending header")
 	// Send header message to establish the remote server (consumer).
+	o.offerCompression = streamCompressionEnabled.Get(&st.SV)
 	if err := stream.Send(
-		&distsqlpb.ProducerMessage{Header: &distsqlpb.ProducerHeader{FlowID: flowID, StreamID: streamID}},
+		&distsqlpb.ProducerMessage{Header: &distsqlpb.ProducerHeader{
+			FlowID:             flowID,
+			StreamID:           streamID,
+			SnappyCompression:  o.offerCompression,
+			Version:            distsqlpb.CurrentStreamVersion,
+			MinAcceptedVersion: distsqlpb.MinAcceptedStreamVersion,
+			Capabilities:       distsqlpb.StreamCapabilityColumnar,
+		}},
 	); err != nil {
 		log.Warningf(
 			ctx,
@@ -199,6 +256,15 @@ func (o *Outbox) sendBatches(
 		if atomic.LoadUint32(&o.draining) == 1 {
 			return true, nil
 		}
+		if !o.projecting {
+			if projection, ok := o.projection.Load().([]uint32); ok {
+				if err := o.startProjecting(projection); err != nil {
+					log.Errorf(ctx, "Outbox error projecting columns: %s", err)
+					return false, err
+				}
+				log.VEventf(ctx, 2, "Outbox only sending columns %v", projection)
+			}
+		}
 		var b coldata.Batch
 		if err := exec.CatchVectorizedRuntimeError(func() { b = o.input.Next(ctx) }); err != nil {
 			log.Errorf(ctx, "Outbox Next error: %s", err)
@@ -219,6 +285,22 @@ func (o *Outbox) sendBatches(
 			return false, err
 		}
 		o.scratch.msg.Data.RawBytes = o.scratch.buf.Bytes()
+		o.scratch.msg.Data.SnappyCompressed = false
+		o.scratch.msg.Data.ColumnsProjected = o.projecting
+		if atomic.LoadUint32(&o.compress) == 1 {
+			o.scratch.compressed = snappy.Encode(
+				o.scratch.compressed[:cap(o.scratch.compressed)], o.scratch.msg.Data.RawBytes,
+			)
+			uncompressed, compressed := len(o.scratch.msg.Data.RawBytes), len(o.scratch.compressed)
+			o.compressionStats.UncompressedBytes += int64(uncompressed)
+			o.compressionStats.CompressedBytes += int64(compressed)
+			if o.metrics != nil {
+				o.metrics.UncompressedBytes.Inc(int64(uncompressed))
+				o.metrics.CompressedBytes.Inc(int64(compressed))
+			}
+			o.scratch.msg.Data.RawBytes = o.scratch.compressed
+			o.scratch.msg.Data.SnappyCompressed = true
+		}
 
 		// o.scratch.msg can be reused as soon as Send returns since it returns as
 		// soon as the message is written to the control buffer. The message is
@@ -230,6 +312,37 @@ func (o *Outbox) sendBatches(
 	}
 }
 
+// startProjecting inserts a simple projection onto the given columns into the
+// Outbox's pipeline and sets up the Outbox to serialize the projected batches.
+func (o *Outbox) startProjecting(projection []uint32) error {
+	typs := make([]types.T, len(projection))
+	for i, c := range projection {
+		typs[i] = o.typs[c]
+	}
+	s, err := colserde.NewRecordBatchSerializer(typs)
+	if err != nil {
+		return err
+	}
+	// The input was already initialized, and simpleProjectOp doesn't need
+	// initialization of its own.
+	o.input = exec.NewSimpleProjectOp(o.input, projection)
+	o.converter = colserde.NewArrowBatchConverter(typs)
+	o.serializer = s
+	o.projecting = true
+	return nil
+}
+
+// validateProjection returns an error if the given columns requested by a
+// consumer don't all refer to columns of the Outbox's input.
+func (o *Outbox) validateProjection(projection []uint32) error {
+	for _, c := range projection {
+		if int(c) >= len(o.typs) {
+			return errors.Errorf("column %d requested, but the stream only has %d columns", c, len(o.typs))
+		}
+	}
+	return nil
+}
+
 // sendMetadata drains the Outbox.metadataSources and sends the metadata over
 // the given stream, returning the Send error, if any. sendMetadata also sends
 // errToSend as metadata if non-nil.
@@ -271,6 +384,19 @@ func (o *Outbox) runWithStream(
 			switch {
 			case msg.Handshake != nil:
 				log.VEventf(ctx, 2, "Outbox received handshake: %v", msg.Handshake)
+				if o.offerCompression && msg.Handshake.SnappyCompression &&
+					atomic.CompareAndSwapUint32(&o.compress, 0, 1) && o.metrics != nil {
+					o.metrics.CompressedStreams.Inc(1)
+				}
+				if projection := msg.Handshake.OutputColumns; len(projection) > 0 {
+					if err := o.validateProjection(projection); err != nil {
+						// Keep sending all the columns, which the consumer has to handle
+						// anyway in case the producer doesn't support projection.
+						log.Warningf(ctx, "Outbox ignoring requested output columns: %s", err)
+					} else {
+						o.projection.Store(projection)
+					}
+				}
 			case msg.DrainRequest != nil:
 				o.moveToDraining(ctx)
 			}
@@ -279,6 +405,11 @@ func (o *Outbox) runWithStream(
 	}()
 
 	terminatedGracefully, errToSend := o.sendBatches(ctx, stream, cancelFn)
+	if stats := o.compressionStats; stats.UncompressedBytes > 0 {
+		log.VEventf(ctx, 2, "Outbox compressed %d bytes of data to %d bytes (ratio %.2f)",
+			stats.UncompressedBytes, stats.CompressedBytes,
+			float64(stats.UncompressedBytes)/float64(stats.CompressedBytes))
+	}
 	if terminatedGracefully || errToSend != nil {
 		o.moveToDraining(ctx)
 		if err := o.sendMetadata(ctx, stream, errToSend); err != nil {
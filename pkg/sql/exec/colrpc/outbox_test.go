@@ -38,7 +38,7 @@ func TestOutboxCatchesPanics(t *testing.T) {
 		typs     = []types.T{types.Int64}
 		rpcLayer = makeMockFlowStreamRPCLayer()
 	)
-	outbox, err := NewOutbox(input, typs, nil)
+	outbox, err := NewOutbox(input, typs, nil /* metadataSources */, nil /* metrics */)
 	require.NoError(t, err)
 
 	// This test relies on the fact that BatchBuffer panics when there are no
@@ -98,6 +98,7 @@ func TestOutboxDrainsMetadataSources(t *testing.T) {
 					},
 				},
 			},
+			nil, /* metrics */
 		)
 		if err != nil {
 			return nil, nil, err
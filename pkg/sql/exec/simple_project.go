@@ -46,6 +46,14 @@ func newProjectionBatch(projection []uint32) *projectingBatch {
 	}
 }
 
+// NewProjectingBatch returns a Batch that only exposes the columns of the
+// given batch in the projection slice, in order.
+func NewProjectingBatch(batch coldata.Batch, projection []uint32) coldata.Batch {
+	b := newProjectionBatch(projection)
+	b.Batch = batch
+	return b
+}
+
 func (b *projectingBatch) ColVec(i int) coldata.Vec {
 	return b.Batch.ColVec(int(b.projection[i]))
 }
@@ -46,11 +46,13 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/querycache"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/builtins"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlutil"
 	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+	"github.com/cockroachdb/cockroach/pkg/sql/stmtdiagnostics"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/bitarray"
 	"github.com/cockroachdb/cockroach/pkg/util/duration"
@@ -475,6 +477,7 @@ type ExecutorConfig struct {
 	Clock             *hlc.Clock
 	DistSQLSrv        *distsqlrun.ServerImpl
 	StatusServer      serverpb.StatusServer
+	AdminServer       serverpb.AdminServer
 	MetricsRecorder   nodeStatusGenerator
 	SessionRegistry   *SessionRegistry
 	JobRegistry       *jobs.Registry
@@ -486,6 +489,11 @@ type ExecutorConfig struct {
 	AuditLogger       *log.SecondaryLogger
 	InternalExecutor  *InternalExecutor
 	QueryCache        *querycache.C
+	OptTableCache     *OptTableCache
+
+	// StmtDiagnosticsRegistry keeps track of the statements for which a
+	// diagnostics bundle was requested.
+	StmtDiagnosticsRegistry *stmtdiagnostics.Registry
 
 	TestingKnobs              ExecutorTestingKnobs
 	PGWireTestingKnobs        *PGWireTestingKnobs
@@ -551,6 +559,12 @@ type ExecutorTestingKnobs struct {
 	// optimization). This is only called when the Executor is the one doing the
 	// committing.
 	BeforeAutoCommit func(ctx context.Context, stmt string) error
+
+	// BeforeRestartableFlowPortion is called before running the portion of a
+	// restartable DistSQL plan planned on the given node, allowing tests to
+	// simulate the failure of that node. If an error is returned, it is
+	// considered the result of the portion, which is not run.
+	BeforeRestartableFlowPortion func(nodeID roachpb.NodeID) error
 }
 
 // PGWireTestingKnobs contains knobs for the pgwire module.
@@ -873,6 +887,183 @@ func (p *planner) isAsOf(stmt tree.Statement) (*hlc.Timestamp, error) {
 	return &ts, err
 }
 
+// followerReadAsOf returns the timestamp at which a statement outside of an
+// explicit transaction is to be run so that it can be served by follower
+// replicas, or nil if it is to be run at the current time. This is the case
+// for read-only SELECT statements reading from tables without an AS OF SYSTEM
+// TIME clause when the session's max_follower_read_staleness allows for the
+// staleness of follower reads, as computed from the closed timestamp
+// settings by experimental_follower_read_timestamp().
+func (p *planner) followerReadAsOf(stmt tree.Statement, stmtTS time.Time) *hlc.Timestamp {
+	maxStaleness := p.SessionData().MaxFollowerReadStaleness
+	if maxStaleness == 0 || builtins.EvalFollowerReadOffset == nil {
+		return nil
+	}
+	s, ok := stmt.(*tree.Select)
+	if !ok {
+		return nil
+	}
+	selStmt := s.Select
+	for parenSel, ok := selStmt.(*tree.ParenSelect); ok; parenSel, ok = selStmt.(*tree.ParenSelect) {
+		selStmt = parenSel.Select.Select
+	}
+	if sc, ok := selStmt.(*tree.SelectClause); !ok || sc.From == nil || len(sc.From.Tables) == 0 {
+		return nil
+	}
+	v := readOnlySelectVisitor{searchPath: p.SessionData().SearchPath, readOnly: true}
+	if v.visitSelect(s); !v.readOnly {
+		return nil
+	}
+	offset, err := builtins.EvalFollowerReadOffset(p.ExecCfg().ClusterID(), p.ExecCfg().Settings)
+	if err != nil || -offset > maxStaleness {
+		// Without an enterprise license, or when the closed timestamp settings
+		// make follower reads staler than the session allows, the statement is
+		// served by the leaseholders.
+		return nil
+	}
+	return &hlc.Timestamp{WallTime: stmtTS.Add(offset).UnixNano()}
+}
+
+// readOnlySelectVisitor determines whether a SELECT statement provably only
+// reads data which doesn't depend on the time at which it is run, so that it
+// can be run at a historical timestamp without changing its meaning. This
+// rules out statement sources like [INSERT ... RETURNING *] and WITH
+// clauses, which can write, as well as impure builtins like now(), random()
+// or the sequence builtins like nextval().
+type readOnlySelectVisitor struct {
+	searchPath sessiondata.SearchPath
+	readOnly   bool
+}
+
+var _ tree.Visitor = &readOnlySelectVisitor{}
+
+// VisitPre implements the tree.Visitor interface.
+func (v *readOnlySelectVisitor) VisitPre(expr tree.Expr) (recurse bool, newExpr tree.Expr) {
+	if !v.readOnly {
+		return false, expr
+	}
+	switch t := expr.(type) {
+	case *tree.Subquery:
+		v.visitSelectStmt(t.Select)
+		return false, expr
+	case *tree.FuncExpr:
+		fd, err := t.Func.Resolve(v.searchPath)
+		if err != nil || fd.Impure {
+			v.readOnly = false
+			return false, expr
+		}
+	}
+	return v.readOnly, expr
+}
+
+// VisitPost implements the tree.Visitor interface.
+func (*readOnlySelectVisitor) VisitPost(expr tree.Expr) tree.Expr { return expr }
+
+func (v *readOnlySelectVisitor) visitExpr(expr tree.Expr) {
+	if expr != nil && v.readOnly {
+		tree.WalkExprConst(v, expr)
+	}
+}
+
+func (v *readOnlySelectVisitor) visitOrderBy(orderBy tree.OrderBy) {
+	for _, o := range orderBy {
+		v.visitExpr(o.Expr)
+	}
+}
+
+func (v *readOnlySelectVisitor) visitWindowDef(w *tree.WindowDef) {
+	for _, e := range w.Partitions {
+		v.visitExpr(e)
+	}
+	v.visitOrderBy(w.OrderBy)
+	if w.Frame != nil {
+		v.visitExpr(w.Frame.Bounds.StartBound.OffsetExpr)
+		if w.Frame.Bounds.EndBound != nil {
+			v.visitExpr(w.Frame.Bounds.EndBound.OffsetExpr)
+		}
+	}
+}
+
+func (v *readOnlySelectVisitor) visitSelect(s *tree.Select) {
+	if s.With != nil {
+		v.readOnly = false
+		return
+	}
+	v.visitSelectStmt(s.Select)
+	v.visitOrderBy(s.OrderBy)
+	if s.Limit != nil {
+		v.visitExpr(s.Limit.Count)
+		v.visitExpr(s.Limit.Offset)
+	}
+}
+
+func (v *readOnlySelectVisitor) visitSelectStmt(stmt tree.SelectStatement) {
+	switch t := stmt.(type) {
+	case *tree.ParenSelect:
+		v.visitSelect(t.Select)
+	case *tree.UnionClause:
+		v.visitSelect(t.Left)
+		v.visitSelect(t.Right)
+	case *tree.ValuesClause:
+		for _, row := range t.Rows {
+			for _, e := range row {
+				v.visitExpr(e)
+			}
+		}
+	case *tree.SelectClause:
+		for _, e := range t.DistinctOn {
+			v.visitExpr(e)
+		}
+		for _, e := range t.Exprs {
+			v.visitExpr(e.Expr)
+		}
+		if t.From != nil {
+			for _, te := range t.From.Tables {
+				v.visitTableExpr(te)
+			}
+		}
+		if t.Where != nil {
+			v.visitExpr(t.Where.Expr)
+		}
+		for _, e := range t.GroupBy {
+			v.visitExpr(e)
+		}
+		if t.Having != nil {
+			v.visitExpr(t.Having.Expr)
+		}
+		for _, w := range t.Window {
+			v.visitWindowDef(w)
+		}
+	default:
+		v.readOnly = false
+	}
+}
+
+func (v *readOnlySelectVisitor) visitTableExpr(te tree.TableExpr) {
+	switch t := te.(type) {
+	case *tree.UnresolvedObjectName, *tree.TableName, *tree.TableRef:
+	case *tree.AliasedTableExpr:
+		v.visitTableExpr(t.Expr)
+	case *tree.ParenTableExpr:
+		v.visitTableExpr(t.Expr)
+	case *tree.JoinTableExpr:
+		v.visitTableExpr(t.Left)
+		v.visitTableExpr(t.Right)
+		if on, ok := t.Cond.(*tree.OnJoinCond); ok {
+			v.visitExpr(on.Expr)
+		}
+	case *tree.Subquery:
+		v.visitSelectStmt(t.Select)
+	case *tree.RowsFromExpr:
+		for _, e := range t.Items {
+			v.visitExpr(e)
+		}
+	default:
+		// Statement sources, like [INSERT ... RETURNING *], may write.
+		v.readOnly = false
+	}
+}
+
 // isSavepoint returns true if stmt is a SAVEPOINT statement.
 func isSavepoint(stmt Statement) bool {
 	_, isSavepoint := stmt.AST.(*tree.Savepoint)
@@ -1796,6 +1987,14 @@ func (m *sessionDataMutator) SetZigzagJoinEnabled(val bool) {
 	m.data.ZigzagJoinEnabled = val
 }
 
+func (m *sessionDataMutator) SetIndexJoinGetsEnabled(val bool) {
+	m.data.IndexJoinGetsEnabled = val
+}
+
+func (m *sessionDataMutator) SetPreferRegionLocalExecution(val bool) {
+	m.data.PreferRegionLocalExecution = val
+}
+
 func (m *sessionDataMutator) SetReorderJoinsLimit(val int) {
 	m.data.ReorderJoinsLimit = val
 }
@@ -1832,6 +2031,10 @@ func (m *sessionDataMutator) SetStmtTimeout(timeout time.Duration) {
 	m.data.StmtTimeout = timeout
 }
 
+func (m *sessionDataMutator) SetMaxFollowerReadStaleness(staleness time.Duration) {
+	m.data.MaxFollowerReadStaleness = staleness
+}
+
 func (m *sessionDataMutator) SetAllowPrepareAsOptPlan(val bool) {
 	m.data.AllowPrepareAsOptPlan = val
 }
@@ -130,6 +130,12 @@ func (f *stubFactory) ConstructSetOp(
 	return struct{}{}, nil
 }
 
+func (f *stubFactory) ConstructLocalityOptimizedSearch(
+	local, remote exec.Node, limit uint64,
+) (exec.Node, error) {
+	return struct{}{}, nil
+}
+
 func (f *stubFactory) ConstructSort(
 	input exec.Node, ordering sqlbase.ColumnOrdering,
 ) (exec.Node, error) {
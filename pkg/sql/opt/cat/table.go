@@ -139,6 +139,10 @@ type Table interface {
 
 	// InboundForeignKey returns the ith inbound foreign key reference.
 	InboundForeignKey(i int) ForeignKeyConstraint
+
+	// RowLevelTTL returns the row-level TTL metadata of the table, or nil if the
+	// rows of the table don't expire.
+	RowLevelTTL() *RowLevelTTL
 }
 
 // CheckConstraint contains the SQL text and the validity status for a check
@@ -153,6 +157,23 @@ type CheckConstraint struct {
 	Validated  bool
 }
 
+// RowLevelTTL contains the row-level TTL metadata of a table. Each row of such
+// a table expires at the time given by an expression over its columns, after
+// which it is deleted by a background job. For example, the rows of this table
+// expire 30 days after their creation:
+//
+//   ALTER TABLE a EXPERIMENTAL_TTL SET created_at + '30 days'
+//
+type RowLevelTTL struct {
+	// ExpirationExpr is the SQL text of the TIMESTAMPTZ expression which
+	// determines when a row expires.
+	ExpirationExpr string
+
+	// ColumnOrdinals are the ordinals (see Table.Column) of the columns
+	// referenced by ExpirationExpr.
+	ColumnOrdinals []int
+}
+
 // TableStatistic is an interface to a table statistic. Each statistic is
 // associated with a set of columns.
 type TableStatistic interface {
@@ -179,7 +200,26 @@ type TableStatistic interface {
 	// any column in the statistic.
 	NullCount() uint64
 
-	// TODO(radu): add Histogram().
+	// Histogram returns the histogram on the column of the statistic, in
+	// ascending order of the bucket upper bounds. It returns nil if the
+	// statistic has no histogram, which is always the case for multi-column
+	// statistics.
+	Histogram() []HistogramBucket
+}
+
+// HistogramBucket contains the data for a single bucket of a histogram. The
+// bucket covers the values between the upper bound of the previous bucket
+// (exclusive) and its own upper bound (inclusive).
+type HistogramBucket struct {
+	// NumEq is the estimated number of values equal to UpperBound.
+	NumEq uint64
+
+	// NumRange is the estimated number of values between the upper bound of the
+	// previous bucket and UpperBound (both boundaries are exclusive).
+	NumRange uint64
+
+	// UpperBound is the upper boundary of the bucket.
+	UpperBound tree.Datum
 }
 
 // ForeignKeyConstraint represents a foreign key constraint. A foreign key
@@ -156,6 +156,10 @@ func FormatTable(cat Catalog, tab Table, tp treeprinter.Node) {
 		child.Childf("CHECK (%s)", tab.Check(i).Constraint)
 	}
 
+	if ttl := tab.RowLevelTTL(); ttl != nil {
+		child.Childf("EXPERIMENTAL_TTL (%s)", ttl.ExpirationExpr)
+	}
+
 	// Don't print the primary family, since it's implied.
 	if tab.FamilyCount() > 1 || tab.Family(0).Name() != "primary" {
 		for i := 0; i < tab.FamilyCount(); i++ {
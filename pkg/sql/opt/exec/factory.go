@@ -188,6 +188,14 @@ type Factory interface {
 	// nodes must have the same number of columns.
 	ConstructSetOp(typ tree.UnionType, all bool, left, right Node) (Node, error)
 
+	// ConstructLocalityOptimizedSearch returns a node that returns the rows of
+	// the local node followed by the rows of the remote node, like UNION ALL,
+	// but stops after limit rows. The local node is expected to only read data
+	// in the gateway's locality; the remote node is only executed if the local
+	// node returned fewer than limit rows. This allows lookups of rows which
+	// live in the gateway's locality to avoid any remote round-trips.
+	ConstructLocalityOptimizedSearch(local, remote Node, limit uint64) (Node, error)
+
 	// ConstructSort returns a node that performs a resorting of the rows produced
 	// by the input node.
 	ConstructSort(input Node, ordering sqlbase.ColumnOrdering) (Node, error)
@@ -19,6 +19,7 @@ import (
 	"reflect"
 
 	"github.com/cockroachdb/cockroach/pkg/sql/opt"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/cat"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/constraint"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/props"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
@@ -2383,6 +2384,9 @@ func (sb *statisticsBuilder) applyIndexConstraint(
 	}
 
 	applied := sb.updateDistinctCountsFromConstraint(c, e, relProps)
+	if applied == 0 {
+		applied = sb.updateDistinctCountFromHistogram(c, e, relProps)
+	}
 	for i, n := applied, c.ConstrainedColumns(sb.evalCtx); i < n; i++ {
 		// Unlike the constraints found in Select and Join filters, an index
 		// constraint may represent multiple conjuncts. Therefore, we need to
@@ -2407,6 +2411,9 @@ func (sb *statisticsBuilder) applyConstraintSet(
 	numUnappliedConjuncts = 0
 	for i := 0; i < cs.Length(); i++ {
 		applied := sb.updateDistinctCountsFromConstraint(cs.Constraint(i), e, relProps)
+		if applied == 0 {
+			applied = sb.updateDistinctCountFromHistogram(cs.Constraint(i), e, relProps)
+		}
 		if applied == 0 {
 			// If a constraint cannot be applied, it may represent an
 			// inequality like x < 1. As a result, distinctCounts does not fully
@@ -2571,6 +2578,147 @@ func (sb *statisticsBuilder) updateDistinctCountsFromConstraint(
 	return applied
 }
 
+// updateDistinctCountFromHistogram updates the distinct count of the first
+// column of a constraint using the histogram on that column, if there is one.
+// It is meant for constraints which updateDistinctCountsFromConstraint can't
+// handle, such as open inequalities or ranges on non-integer columns:
+//
+//   /a: [ - /'mango')
+//
+// The distinct count of the column is scaled down by the fraction of the
+// histogram values which fall inside the spans of the constraint, so that the
+// selectivity later derived by selectivityFromDistinctCounts reflects the
+// actual distribution of the data. Note that the histogram describes the base
+// table, so this assumes that the distribution of the column is unchanged by
+// the operators below e.
+//
+// It returns the number of columns for which the distinct count was updated
+// (either 0 or 1).
+func (sb *statisticsBuilder) updateDistinctCountFromHistogram(
+	c *constraint.Constraint, e RelExpr, relProps *props.Relational,
+) (applied int) {
+	col := c.Columns.Get(0)
+	hist := sb.histogram(col.ID())
+	if hist == nil {
+		return 0
+	}
+
+	var total, inSpans float64
+	for i := range hist {
+		total += float64(hist[i].NumEq + hist[i].NumRange)
+	}
+	if total == 0 {
+		return 0
+	}
+	for i := 0; i < c.Spans.Count(); i++ {
+		sp := c.Spans.Get(i)
+		if sp.StartKey().Length() > 1 || sp.EndKey().Length() > 1 {
+			// Spans which constrain more than the first column can share values
+			// of the first column (e.g. /a/b: [/1/2 - /1/4] [/1/6 - /1/8]), which
+			// would be counted several times.
+			return 0
+		}
+		inSpans += sb.histogramRowsInSpan(hist, sp, col.Descending())
+	}
+
+	colSet := util.MakeFastIntSet(int(col.ID()))
+	inputColStat := sb.colStatFromInput(colSet, e)
+	// All columns should have at least one distinct value.
+	distinctCount := max(inputColStat.DistinctCount*min(inSpans/total, 1), 1)
+	sb.ensureColStat(colSet, distinctCount, e, relProps)
+	return 1
+}
+
+// histogram returns the histogram on the given column from the most recent
+// statistic on that column alone. It returns nil if the column doesn't belong
+// to a base table, or if that statistic has no histogram.
+func (sb *statisticsBuilder) histogram(col opt.ColumnID) []cat.HistogramBucket {
+	tabID := sb.md.ColumnMeta(col).Table
+	if tabID == 0 {
+		return nil
+	}
+	tab := sb.md.Table(tabID)
+	// Stats are ordered with most recent first.
+	for i := 0; i < tab.StatisticCount(); i++ {
+		stat := tab.Statistic(i)
+		if stat.ColumnCount() == 1 && tabID.ColumnID(stat.ColumnOrdinal(0)) == col {
+			return stat.Histogram()
+		}
+	}
+	return nil
+}
+
+// histogramRowsInSpan returns the estimated number of histogram values which
+// fall inside the given single-column span. Nothing is known about the
+// distribution of the values strictly inside a bucket, so if the span only
+// covers part of a bucket, half of those values are counted.
+func (sb *statisticsBuilder) histogramRowsInSpan(
+	hist []cat.HistogramBucket, sp *constraint.Span, descending bool,
+) float64 {
+	// Find the boundaries of the span in ascending order. A nil boundary is
+	// unbounded; NULL boundaries are treated as such since the histogram
+	// doesn't contain NULLs.
+	var lo, hi tree.Datum
+	loInclusive, hiInclusive := true, true
+	if sp.StartKey().Length() > 0 {
+		lo = sp.StartKey().Value(0)
+		loInclusive = sp.StartBoundary() == constraint.IncludeBoundary
+	}
+	if sp.EndKey().Length() > 0 {
+		hi = sp.EndKey().Value(0)
+		hiInclusive = sp.EndBoundary() == constraint.IncludeBoundary
+	}
+	if descending {
+		lo, hi = hi, lo
+		loInclusive, hiInclusive = hiInclusive, loInclusive
+	}
+	if lo == tree.DNull {
+		lo = nil
+	}
+	if hi == tree.DNull {
+		hi = nil
+	}
+
+	var rows float64
+	// prev is the upper bound of the previous bucket, or nil for the first
+	// bucket.
+	var prev tree.Datum
+	for i := range hist {
+		b := &hist[i]
+
+		// Count the values equal to the upper bound of the bucket.
+		inSpan := true
+		if lo != nil {
+			cmp := b.UpperBound.Compare(sb.evalCtx, lo)
+			inSpan = cmp > 0 || (cmp == 0 && loInclusive)
+		}
+		if inSpan && hi != nil {
+			cmp := b.UpperBound.Compare(sb.evalCtx, hi)
+			inSpan = cmp < 0 || (cmp == 0 && hiInclusive)
+		}
+		if inSpan {
+			rows += float64(b.NumEq)
+		}
+
+		// Count the values strictly between prev and the upper bound.
+		if b.NumRange > 0 {
+			disjoint := (hi != nil && prev != nil && hi.Compare(sb.evalCtx, prev) <= 0) ||
+				(lo != nil && lo.Compare(sb.evalCtx, b.UpperBound) >= 0)
+			contained := (lo == nil || (prev != nil && lo.Compare(sb.evalCtx, prev) <= 0)) &&
+				(hi == nil || hi.Compare(sb.evalCtx, b.UpperBound) >= 0)
+			switch {
+			case disjoint:
+			case contained:
+				rows += float64(b.NumRange)
+			default:
+				rows += float64(b.NumRange) / 2
+			}
+		}
+		prev = b.UpperBound
+	}
+	return rows
+}
+
 func (sb *statisticsBuilder) applyEquivalencies(
 	equivReps opt.ColSet, filterFD *props.FuncDepSet, e RelExpr, relProps *props.Relational,
 ) {
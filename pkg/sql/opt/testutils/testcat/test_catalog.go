@@ -496,6 +496,7 @@ type Table struct {
 	Stats      TableStats
 	Checks     []cat.CheckConstraint
 	Families   []*Family
+	TTL        *cat.RowLevelTTL
 	IsVirtual  bool
 	Catalog    cat.Catalog
 
@@ -642,6 +643,11 @@ func (tt *Table) InboundForeignKey(i int) cat.ForeignKeyConstraint {
 	return &tt.inboundFKs[i]
 }
 
+// RowLevelTTL is part of the cat.Table interface.
+func (tt *Table) RowLevelTTL() *cat.RowLevelTTL {
+	return tt.TTL
+}
+
 // FindOrdinal returns the ordinal of the column with the given name.
 func (tt *Table) FindOrdinal(name string) int {
 	for i, col := range tt.Columns {
@@ -831,8 +837,9 @@ func (tc *Column) ComputedExprStr() string {
 
 // TableStat implements the cat.TableStatistic interface for testing purposes.
 type TableStat struct {
-	js stats.JSONStatistic
-	tt *Table
+	js        stats.JSONStatistic
+	tt        *Table
+	histogram []cat.HistogramBucket
 }
 
 var _ cat.TableStatistic = &TableStat{}
@@ -871,6 +878,35 @@ func (ts *TableStat) NullCount() uint64 {
 	return ts.js.NullCount
 }
 
+// Histogram is part of the cat.TableStatistic interface.
+func (ts *TableStat) Histogram() []cat.HistogramBucket {
+	return ts.histogram
+}
+
+// initHistogram parses the histogram buckets of the JSON statistic, if any.
+func (ts *TableStat) initHistogram(evalCtx *tree.EvalContext) {
+	if len(ts.js.HistogramBuckets) == 0 {
+		return
+	}
+	colType, err := parser.ParseType(ts.js.HistogramColumnType)
+	if err != nil {
+		panic(err)
+	}
+	ts.histogram = make([]cat.HistogramBucket, len(ts.js.HistogramBuckets))
+	for i := range ts.histogram {
+		b := &ts.js.HistogramBuckets[i]
+		upperBound, err := tree.ParseStringAs(colType, b.UpperBound, evalCtx)
+		if err != nil {
+			panic(err)
+		}
+		ts.histogram[i] = cat.HistogramBucket{
+			NumEq:      uint64(b.NumEq),
+			NumRange:   uint64(b.NumRange),
+			UpperBound: upperBound,
+		}
+	}
+}
+
 // TableStats is a slice of TableStat pointers.
 type TableStats []*TableStat
 
@@ -29,6 +29,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/sql/stats"
 	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/encoding"
 )
 
 // optCatalog implements the cat.Catalog interface over the SchemaResolver
@@ -342,12 +343,25 @@ func (oc *optCatalog) dataSourceForTable(
 		}
 	}
 
+	// Leased descriptors are shared by the transactions of the node, and so can
+	// their wrappers. Virtual tables are never leased.
+	var sharedCache *OptTableCache
+	if optTableCacheEnabled.Get(&oc.planner.execCfg.Settings.SV) &&
+		oc.planner.Tables().isLeased(desc) {
+		sharedCache = oc.planner.execCfg.OptTableCache
+		if ot := sharedCache.lookup(desc, name, tableStats, zoneConfig); ot != nil {
+			oc.dataSources[desc] = ot
+			return ot, nil
+		}
+	}
+
 	ds := newOptTable(desc, id, name, tableStats, zoneConfig)
 	if !desc.IsVirtualTable() {
 		// Virtual tables can have multiple effective instances that utilize the
 		// same descriptor (see above).
 		oc.dataSources[desc] = ds
 	}
+	sharedCache.add(ds)
 	return ds, nil
 }
 
@@ -519,6 +533,10 @@ type optTable struct {
 	outboundFKs []optForeignKeyConstraint
 	inboundFKs  []optForeignKeyConstraint
 
+	// ttl is the row-level TTL metadata of the table, or nil if the rows of the
+	// table don't expire.
+	ttl *cat.RowLevelTTL
+
 	// colMap is a mapping from unique ColumnID to column ordinal within the
 	// table. This is a common lookup that needs to be fast.
 	colMap map[sqlbase.ColumnID]int
@@ -623,6 +641,16 @@ func newOptTable(
 		}
 	}
 
+	if ttl := desc.RowLevelTTL; ttl != nil {
+		ot.ttl = &cat.RowLevelTTL{
+			ExpirationExpr: ttl.ExpirationExpr,
+			ColumnOrdinals: make([]int, len(ttl.ColumnIDs)),
+		}
+		for i, colID := range ttl.ColumnIDs {
+			ot.ttl.ColumnOrdinals[i] = ot.colMap[colID]
+		}
+	}
+
 	// Add stats last, now that other metadata is initialized.
 	if stats != nil {
 		ot.stats = make([]optTableStat, len(stats))
@@ -830,6 +858,11 @@ func (ot *optTable) InboundForeignKey(i int) cat.ForeignKeyConstraint {
 	return &ot.inboundFKs[i]
 }
 
+// RowLevelTTL is part of the cat.Table interface.
+func (ot *optTable) RowLevelTTL() *cat.RowLevelTTL {
+	return ot.ttl
+}
+
 // lookupColumnOrdinal returns the ordinal of the column with the given ID. A
 // cache makes the lookup O(1).
 func (ot *optTable) lookupColumnOrdinal(colID sqlbase.ColumnID) (int, error) {
@@ -1003,6 +1036,7 @@ type optTableStat struct {
 	rowCount       uint64
 	distinctCount  uint64
 	nullCount      uint64
+	histogram      []cat.HistogramBucket
 }
 
 var _ cat.TableStatistic = &optTableStat{}
@@ -1022,9 +1056,33 @@ func (os *optTableStat) init(tab *optTable, stat *stats.TableStatistic) (ok bool
 			return false
 		}
 	}
+	if stat.Histogram != nil && len(stat.ColumnIDs) == 1 {
+		os.histogram = decodeHistogram(stat.Histogram)
+	}
 	return true
 }
 
+// decodeHistogram converts the buckets of a histogram to their optimizer
+// representation. It returns nil if any of the bucket boundaries can't be
+// decoded, in which case the optimizer just doesn't use the histogram.
+func decodeHistogram(h *stats.HistogramData) []cat.HistogramBucket {
+	var a sqlbase.DatumAlloc
+	buckets := make([]cat.HistogramBucket, len(h.Buckets))
+	for i := range h.Buckets {
+		b := &h.Buckets[i]
+		datum, _, err := sqlbase.DecodeTableKey(&a, &h.ColumnType, b.UpperBound, encoding.Ascending)
+		if err != nil {
+			return nil
+		}
+		buckets[i] = cat.HistogramBucket{
+			NumEq:      uint64(b.NumEq),
+			NumRange:   uint64(b.NumRange),
+			UpperBound: datum,
+		}
+	}
+	return buckets
+}
+
 func (os *optTableStat) equals(other *optTableStat) bool {
 	// Two table statistics are considered equal if they have been created at the
 	// same time, on the same set of columns.
@@ -1069,6 +1127,11 @@ func (os *optTableStat) NullCount() uint64 {
 	return os.nullCount
 }
 
+// Histogram is part of the cat.TableStatistic interface.
+func (os *optTableStat) Histogram() []cat.HistogramBucket {
+	return os.histogram
+}
+
 // optFamily is a wrapper around sqlbase.ColumnFamilyDescriptor that keeps a
 // reference to the table wrapper.
 type optFamily struct {
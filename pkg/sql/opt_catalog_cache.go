@@ -0,0 +1,104 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/config"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/cat"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/stats"
+	"github.com/cockroachdb/cockroach/pkg/util/cache"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+var optTableCacheEnabled = settings.RegisterBoolSetting(
+	"sql.catalog.table_cache.enabled",
+	"share the optimizer catalog objects of leased tables between the sessions of a node",
+	true,
+)
+
+// OptTableCache is a node-wide cache of the cat.Table (and, inlined in them,
+// cat.Index) objects that wrap leased table descriptors for the optimizer. It
+// lets sessions and statements reuse the wrappers built by others instead of
+// re-wrapping the descriptors for every statement, which matters in schemas
+// with thousands of tables.
+//
+// The cached objects are immutable and are never updated in place: when a new
+// version of a descriptor is leased, or the statistics or zone config of the
+// table change, a new object is built and replaces the cached one. Planners
+// which still hold the previous object can keep using it.
+type OptTableCache struct {
+	// NB: This can't be a RWMutex for lookup because UnorderedCache.Get
+	// manipulates an internal LRU list.
+	mu struct {
+		syncutil.Mutex
+		// cache maps a sqlbase.ID to the *optTable wrapping the most recent
+		// version of the descriptor that was added.
+		cache *cache.UnorderedCache
+	}
+}
+
+// NewOptTableCache creates a new OptTableCache which holds the wrappers of up
+// to cacheSize tables.
+func NewOptTableCache(cacheSize int) *OptTableCache {
+	c := &OptTableCache{}
+	c.mu.cache = cache.NewUnorderedCache(cache.Config{
+		Policy:      cache.CacheLRU,
+		ShouldEvict: func(s int, key, value interface{}) bool { return s > cacheSize },
+	})
+	return c
+}
+
+// lookup returns the cached wrapper of the given leased descriptor, provided it
+// was built for the same version, name, statistics and zone config. It returns
+// nil otherwise. The cache can be nil, in which case it is always empty.
+func (c *OptTableCache) lookup(
+	desc *sqlbase.ImmutableTableDescriptor,
+	name *cat.DataSourceName,
+	tableStats []*stats.TableStatistic,
+	zone *config.ZoneConfig,
+) *optTable {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.mu.cache.Get(desc.ID); ok {
+		ot := v.(*optTable)
+		if ot.desc.Version == desc.Version &&
+			ot.name.TableName == name.TableName &&
+			ot.name.SchemaName == name.SchemaName &&
+			ot.name.CatalogName == name.CatalogName &&
+			!ot.isStale(tableStats, zone) {
+			return ot
+		}
+	}
+	return nil
+}
+
+// add caches the wrapper of a leased descriptor, replacing the wrapper of any
+// other version of the descriptor unless that version is more recent.
+func (c *OptTableCache) add(ot *optTable) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.mu.cache.Get(ot.desc.ID); ok && v.(*optTable).desc.Version > ot.desc.Version {
+		return
+	}
+	c.mu.cache.Add(ot.desc.ID, ot)
+}
@@ -0,0 +1,98 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// TestOptTableCache verifies that the optimizer catalog objects of a leased
+// table are shared between sessions, and that they are rebuilt when a new
+// version of the table is leased.
+func TestOptTableCache(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	s, db, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+	c := s.ExecutorConfig().(ExecutorConfig).OptTableCache
+
+	var runners [2]*sqlutils.SQLRunner
+	for i := range runners {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		runners[i] = sqlutils.MakeSQLRunner(conn)
+	}
+	r0, r1 := runners[0], runners[1]
+	r0.Exec(t, "SET CLUSTER SETTING sql.stats.automatic_collection.enabled = false")
+	r0.Exec(t, "CREATE DATABASE db")
+	r0.Exec(t, "CREATE TABLE db.t (a INT PRIMARY KEY, b INT, INDEX (b))")
+	id := sqlbase.GetTableDescriptor(kvDB, "db", "t").ID
+
+	cached := func() *optTable {
+		t.Helper()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		v, ok := c.mu.cache.Get(id)
+		if !ok {
+			t.Fatal("table not found in the cache")
+		}
+		return v.(*optTable)
+	}
+
+	r0.Exec(t, "SELECT a FROM db.t")
+	ot := cached()
+	if ot.ColumnCount() != 2 || ot.IndexCount() != 2 {
+		t.Fatalf("unexpected cached table: %d columns, %d indexes", ot.ColumnCount(), ot.IndexCount())
+	}
+
+	// The wrapper is reused by the other session rather than being rebuilt.
+	r1.Exec(t, "SELECT b FROM db.t")
+	r0.Exec(t, "SELECT a, b FROM db.t WHERE b > 1")
+	if cached() != ot {
+		t.Fatal("expected the cached table to be reused")
+	}
+
+	// A new version of the table is wrapped again, and the new wrapper replaces
+	// the previous one.
+	r0.Exec(t, "ALTER TABLE db.t ADD COLUMN c INT")
+	r1.Exec(t, "SELECT c FROM db.t")
+	newOt := cached()
+	if newOt == ot || newOt.desc.Version <= ot.desc.Version || newOt.ColumnCount() != 3 {
+		t.Fatalf("expected the table to be wrapped again at a new version")
+	}
+	r0.Exec(t, "SELECT b, c FROM db.t")
+	if cached() != newOt {
+		t.Fatal("expected the cached table to be reused")
+	}
+
+	// The cache is neither read nor populated when disabled.
+	r0.Exec(t, "SET CLUSTER SETTING sql.catalog.table_cache.enabled = false")
+	r0.Exec(t, "ALTER TABLE db.t ADD COLUMN d INT")
+	r1.Exec(t, "SELECT d FROM db.t")
+	if cached() != newOt {
+		t.Fatal("expected the cache to be bypassed")
+	}
+}
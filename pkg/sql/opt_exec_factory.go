@@ -508,6 +508,22 @@ func (ef *execFactory) ConstructSetOp(
 	return ef.planner.newUnionNode(typ, all, left.(planNode), right.(planNode))
 }
 
+// ConstructLocalityOptimizedSearch is part of the exec.Factory interface.
+func (ef *execFactory) ConstructLocalityOptimizedSearch(
+	local, remote exec.Node, limit uint64,
+) (exec.Node, error) {
+	if limit == 0 {
+		return nil, pgerror.AssertionFailedf("locality optimized search requires a limit")
+	}
+	n, err := ef.planner.newUnionNode(tree.UnionOp, true /* all */, local.(planNode), remote.(planNode))
+	if err != nil {
+		return nil, err
+	}
+	union := n.(*unionNode)
+	union.hardLimit = limit
+	return union, nil
+}
+
 // ConstructSort is part of the exec.Factory interface.
 func (ef *execFactory) ConstructSort(
 	input exec.Node, ordering sqlbase.ColumnOrdering,
@@ -982,9 +998,9 @@ func (ef *execFactory) showEnv(plan string, envOpts exec.ExplainEnvData) (exec.N
 		// In addition to the schema, it's important to know what the table
 		// statistics on each table are.
 
-		// NOTE: The histogram buckets take up a ton of vertical space and we
-		// don't use them in planning, so don't include them.
-		// TODO(justin): Revisit this once we use histograms in planning.
+		// NOTE: The histogram buckets take up a ton of vertical space, so don't
+		// include them.
+		// TODO(justin): Revisit this now that histograms are used in planning.
 		stats, err := ef.environmentQuery(
 			fmt.Sprintf(
 				`
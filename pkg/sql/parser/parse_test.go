@@ -1207,6 +1207,8 @@ func TestParse(t *testing.T) {
 		{`ALTER TABLE t EXPERIMENTAL_AUDIT SET READ WRITE`},
 		{`EXPLAIN ALTER TABLE t EXPERIMENTAL_AUDIT SET READ WRITE`},
 		{`ALTER TABLE t EXPERIMENTAL_AUDIT SET OFF`},
+		{`ALTER TABLE t EXPERIMENTAL_TTL SET ts + '1 day'`},
+		{`ALTER TABLE t EXPERIMENTAL_TTL RESET`},
 
 		{`COMMENT ON COLUMN a.b IS 'a'`},
 		{`COMMENT ON COLUMN a.b IS NULL`},
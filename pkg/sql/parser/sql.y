@@ -497,7 +497,7 @@ func newNameFromStr(s string) *tree.Name {
 %token <str> ELSE ENCODING END ENUM ESCAPE EXCEPT
 %token <str> EXISTS EXECUTE EXPERIMENTAL
 %token <str> EXPERIMENTAL_FINGERPRINTS EXPERIMENTAL_REPLICA
-%token <str> EXPERIMENTAL_AUDIT
+%token <str> EXPERIMENTAL_AUDIT EXPERIMENTAL_TTL
 %token <str> EXPLAIN EXPORT EXTENSION EXTRACT EXTRACT_DURATION
 
 %token <str> FALSE FAMILY FETCH FETCHVAL FETCHTEXT FETCHVAL_PATH FETCHTEXT_PATH
@@ -1112,6 +1112,7 @@ alter_ddl_stmt:
 //   ALTER TABLE ... UNSPLIT AT <selectclause>
 //   ALTER TABLE ... SCATTER [ FROM ( <exprs...> ) TO ( <exprs...> ) ]
 //   ALTER TABLE ... INJECT STATISTICS ...  (experimental)
+//   ALTER TABLE ... EXPERIMENTAL_TTL {SET <expr> | RESET}  (experimental)
 //   ALTER TABLE ... PARTITION BY RANGE ( <name...> ) ( <rangespec> )
 //   ALTER TABLE ... PARTITION BY LIST ( <name...> ) ( <listspec> )
 //   ALTER TABLE ... PARTITION BY NOTHING
@@ -1610,6 +1611,18 @@ alter_table_cmd:
       Stats: $3.expr(),
     }
   }
+  // ALTER TABLE <name> EXPERIMENTAL_TTL SET <expr>
+| EXPERIMENTAL_TTL SET a_expr
+  {
+    /* SKIP DOC */
+    $$.val = &tree.AlterTableSetTTL{Expr: $3.expr()}
+  }
+  // ALTER TABLE <name> EXPERIMENTAL_TTL RESET
+| EXPERIMENTAL_TTL RESET
+  {
+    /* SKIP DOC */
+    $$.val = &tree.AlterTableSetTTL{}
+  }
 
 audit_mode:
   READ WRITE { $$.val = tree.AuditModeReadWrite }
@@ -8920,6 +8933,7 @@ unreserved_keyword:
 | EXPERIMENTAL_RANGES
 | EXPERIMENTAL_RELOCATE
 | EXPERIMENTAL_REPLICA
+| EXPERIMENTAL_TTL
 | EXPLAIN
 | EXPORT
 | EXTENSION
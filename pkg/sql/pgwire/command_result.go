@@ -62,8 +62,13 @@ type commandResult struct {
 	// CommandComplete message.
 	cmdCompleteTag string
 	// If set, an error will be sent to the client if more rows are produced than
-	// this limit.
+	// this limit, unless the portal is suspended once the limit is reached.
 	limit int
+	// suspended is set while the execution of the portal is suspended, waiting
+	// for the client to execute it again. If the result is closed in this state,
+	// no completion message is sent: the client has already received a
+	// PortalSuspended message for its last execution of the portal.
+	suspended bool
 
 	stmtType     tree.StatementType
 	descOpt      sql.RowDescOpt
@@ -125,6 +130,9 @@ func (r *commandResult) Close(t sql.TransactionStatusIndicator) {
 		r.conn.bufferErr(convertToErrWithPGCode(r.err))
 		return
 	}
+	if r.suspended {
+		return
+	}
 
 	if r.err == nil &&
 		r.limit != 0 &&
@@ -279,6 +287,25 @@ func (r *commandResult) SetLimit(n int) {
 	r.limit = n
 }
 
+// SuspendPortal is part of the CommandResult interface.
+func (r *commandResult) SuspendPortal() error {
+	r.conn.writerState.fi.registerCmd(r.pos)
+	if err := r.conn.GetErr(); err != nil {
+		return err
+	}
+	r.suspended = true
+	r.conn.bufferPortalSuspended()
+	return r.conn.Flush(r.pos)
+}
+
+// ResumePortal is part of the CommandResult interface.
+func (r *commandResult) ResumePortal(pos sql.CmdPos, limit int) {
+	r.suspended = false
+	r.pos = pos
+	r.limit = limit
+	r.rowsAffected = 0
+}
+
 // ResetStmtType is part of the CommandResult interface.
 func (r *commandResult) ResetStmtType(stmt tree.Statement) {
 	r.stmtType = stmt.StatementType()
@@ -1158,6 +1158,13 @@ func (c *conn) bufferCloseComplete() {
 	}
 }
 
+func (c *conn) bufferPortalSuspended() {
+	c.msgBuilder.initMsg(pgwirebase.ServerMsgPortalSuspended)
+	if err := c.msgBuilder.finishMsg(&c.writerState.buf); err != nil {
+		panic(fmt.Sprintf("unexpected err from buffer: %s", err))
+	}
+}
+
 func (c *conn) bufferCommandComplete(tag []byte) {
 	c.msgBuilder.initMsg(pgwirebase.ServerMsgCommandComplete)
 	c.msgBuilder.write(tag)
@@ -2343,3 +2343,128 @@ func TestFailPrepareFailsTxn(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestPortalSuspension checks that a portal executed with a row count limit in
+// an explicit transaction is suspended once the limit is reached, and that the
+// client can page through its results by executing it again.
+func TestPortalSuspension(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, _, _ := serverutils.StartServer(t, base.TestServerArgs{Insecure: true})
+	ctx := context.TODO()
+	defer s.Stopper().Stop(ctx)
+
+	conn, err := net.Dial("tcp", s.ServingAddr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fe, err := pgproto3.NewFrontend(conn, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	send := func(msgs ...pgproto3.FrontendMessage) {
+		t.Helper()
+		for _, msg := range msgs {
+			if err := fe.Send(msg); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	// expect reads the messages sent by the server up to the next ReadyForQuery
+	// and checks them against a summary of the expected messages.
+	expect := func(exp ...string) {
+		t.Helper()
+		var msgs []string
+		for {
+			msg, err := fe.Receive()
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch m := msg.(type) {
+			case *pgproto3.ParseComplete:
+				msgs = append(msgs, "ParseComplete")
+			case *pgproto3.BindComplete:
+				msgs = append(msgs, "BindComplete")
+			case *pgproto3.CloseComplete:
+				msgs = append(msgs, "CloseComplete")
+			case *pgproto3.DataRow:
+				msgs = append(msgs, fmt.Sprintf("DataRow %s", m.Values[0]))
+			case *pgproto3.PortalSuspended:
+				msgs = append(msgs, "PortalSuspended")
+			case *pgproto3.CommandComplete:
+				msgs = append(msgs, fmt.Sprintf("CommandComplete %s", m.CommandTag))
+			case *pgproto3.ErrorResponse:
+				msgs = append(msgs, fmt.Sprintf("ErrorResponse %s", m.Code))
+			case *pgproto3.ReadyForQuery:
+				msgs = append(msgs, fmt.Sprintf("ReadyForQuery %c", m.TxStatus))
+			}
+			if _, ok := msg.(*pgproto3.ReadyForQuery); ok {
+				break
+			}
+		}
+		if !reflect.DeepEqual(msgs, exp) {
+			t.Fatalf("expected:\n%s\ngot:\n%s", strings.Join(exp, "\n"), strings.Join(msgs, "\n"))
+		}
+	}
+
+	const version30 = 196608
+	send(&pgproto3.StartupMessage{
+		ProtocolVersion: version30,
+		Parameters:      map[string]string{"user": security.RootUser},
+	})
+	for {
+		msg, err := fe.Receive()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := msg.(*pgproto3.ReadyForQuery); ok {
+			break
+		}
+	}
+
+	send(&pgproto3.Query{String: "BEGIN"})
+	expect("CommandComplete BEGIN", "ReadyForQuery T")
+
+	// Page through the results of a portal.
+	send(
+		&pgproto3.Parse{Query: "SELECT generate_series(1, 7)"},
+		&pgproto3.Bind{},
+		&pgproto3.Execute{MaxRows: 3},
+		&pgproto3.Sync{},
+	)
+	expect("ParseComplete", "BindComplete",
+		"DataRow 1", "DataRow 2", "DataRow 3", "PortalSuspended", "ReadyForQuery T")
+	send(&pgproto3.Execute{MaxRows: 3}, &pgproto3.Sync{})
+	expect("DataRow 4", "DataRow 5", "DataRow 6", "PortalSuspended", "ReadyForQuery T")
+	send(&pgproto3.Execute{MaxRows: 3}, &pgproto3.Sync{})
+	expect("DataRow 7", "CommandComplete SELECT 1", "ReadyForQuery T")
+
+	// Close a suspended portal.
+	send(
+		&pgproto3.Bind{},
+		&pgproto3.Execute{MaxRows: 2},
+		&pgproto3.Close{ObjectType: 'P'},
+		&pgproto3.Sync{},
+	)
+	expect("BindComplete", "DataRow 1", "DataRow 2", "PortalSuspended", "CloseComplete",
+		"ReadyForQuery T")
+
+	// Other statements can't be interleaved with the executions of a suspended
+	// portal.
+	send(&pgproto3.Bind{}, &pgproto3.Execute{MaxRows: 2}, &pgproto3.Sync{})
+	expect("BindComplete", "DataRow 1", "DataRow 2", "PortalSuspended", "ReadyForQuery T")
+	send(&pgproto3.Query{String: "SELECT 1"})
+	expect("ErrorResponse 0A000", "ReadyForQuery E")
+	send(&pgproto3.Query{String: "ROLLBACK"})
+	expect("CommandComplete ROLLBACK", "ReadyForQuery I")
+
+	// Committing the transaction destroys the suspended portal.
+	send(&pgproto3.Query{String: "BEGIN"})
+	expect("CommandComplete BEGIN", "ReadyForQuery T")
+	send(&pgproto3.Bind{}, &pgproto3.Execute{MaxRows: 2}, &pgproto3.Sync{})
+	expect("BindComplete", "DataRow 1", "DataRow 2", "PortalSuspended", "ReadyForQuery T")
+	send(&pgproto3.Query{String: "COMMIT"})
+	expect("CommandComplete COMMIT", "ReadyForQuery I")
+}
@@ -151,6 +151,9 @@ func (p *planner) makeOptimizerPlan(ctx context.Context) (_ *planTop, isCorrelat
 
 	// Build the plan tree.
 	root := execMemo.RootExpr()
+	if p.bundle != nil {
+		p.bundle.bundle.OptPlan = memo.FormatExpr(root, memo.ExprFmtHideQualifications)
+	}
 	execFactory := makeExecFactory(p)
 	plan, err := execbuilder.New(&execFactory, execMemo, root, p.EvalContext()).Build()
 	if err != nil {
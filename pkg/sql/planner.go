@@ -34,6 +34,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log/logtags"
 	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/pkg/errors"
 )
 
@@ -173,6 +174,10 @@ type planner struct {
 	optPlanningCtx optPlanningCtx
 
 	queryCacheSession querycache.Session
+
+	// bundle is set while a diagnostics bundle is collected for the current
+	// statement. See stmtBundleBuilder.
+	bundle *stmtBundleBuilder
 }
 
 // noteworthyInternalMemoryUsageBytes is the minimum size tracked by each
@@ -410,6 +415,50 @@ func (p *planner) ResolveTableName(ctx context.Context, tn *tree.TableName) erro
 	return err
 }
 
+// EnqueueRange implements the tree.EvalPlanner interface.
+func (p *planner) EnqueueRange(
+	ctx context.Context, rangeID roachpb.RangeID, queue string, skipShouldQueue bool,
+) ([]tree.EnqueueRangeEvent, error) {
+	if err := p.RequireSuperUser(ctx, "enqueue ranges"); err != nil {
+		return nil, err
+	}
+	resp, err := p.ExecCfg().AdminServer.EnqueueRange(ctx, &serverpb.EnqueueRangeRequest{
+		RangeID:         rangeID,
+		Queue:           queue,
+		SkipShouldQueue: skipShouldQueue,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var events []tree.EnqueueRangeEvent
+	for _, details := range resp.Details {
+		for _, event := range details.Events {
+			events = append(events, tree.EnqueueRangeEvent{
+				NodeID:  details.NodeID,
+				Time:    event.Time,
+				Message: event.Message,
+			})
+		}
+		if details.Error != "" {
+			events = append(events, tree.EnqueueRangeEvent{
+				NodeID: details.NodeID,
+				Error:  details.Error,
+			})
+		}
+	}
+	return events, nil
+}
+
+// RequestStatementBundle is part of the tree.EvalPlanner interface.
+func (p *planner) RequestStatementBundle(
+	ctx context.Context, fingerprint string,
+) (uuid.UUID, error) {
+	if err := p.RequireSuperUser(ctx, "request statement diagnostics"); err != nil {
+		return uuid.UUID{}, err
+	}
+	return p.ExecCfg().StmtDiagnosticsRegistry.InsertRequest(ctx, fingerprint)
+}
+
 // LookupTableByID looks up a table, by the given descriptor ID. Based on the
 // CommonLookupFlags, it could use or skip the TableCollection cache. See
 // TableCollection.getTableVersionByID for how it's used.
@@ -159,6 +159,15 @@ func (p *planner) renameColumn(
 		}
 	}
 
+	// Rename the column in the row-level TTL.
+	if ttl := tableDesc.RowLevelTTL; ttl != nil {
+		var err error
+		ttl.ExpirationExpr, err = renameIn(ttl.ExpirationExpr)
+		if err != nil {
+			return false, err
+		}
+	}
+
 	// Rename the column in the indexes.
 	tableDesc.RenameColumnDescriptor(col, string(*newName))
 
@@ -47,6 +47,12 @@ func (f *singleKVFetcher) getRangesInfo() []roachpb.RangeInfo {
 	panic(pgerror.AssertionFailedf("getRangesInfo() called on singleKVFetcher"))
 }
 
+// getKVStats implements the kvBatchFetcher interface. The KVs are provided by
+// the caller, so no batches are ever sent.
+func (f *singleKVFetcher) getKVStats() KVStats {
+	return KVStats{}
+}
+
 // ConvertBatchError returns a user friendly constraint violation error.
 func ConvertBatchError(
 	ctx context.Context, tableDesc *sqlbase.ImmutableTableDescriptor, b *client.Batch,
@@ -49,6 +49,25 @@ type kvBatchFetcher interface {
 	nextBatch(ctx context.Context) (ok bool, kvs []roachpb.KeyValue,
 		batchResponse []byte, origSpan roachpb.Span, err error)
 	getRangesInfo() []roachpb.RangeInfo
+	// getKVStats returns statistics about the KV batches sent so far.
+	getKVStats() KVStats
+}
+
+// KVStats are statistics about the KV batches sent by a Fetcher, as shown by
+// EXPLAIN ANALYZE.
+type KVStats struct {
+	// Batches is the number of KV batches sent.
+	Batches int64
+	// ResumeSpans is the number of scans which returned a resume span because
+	// they hit the batch limit, each of which resulted in another scan.
+	ResumeSpans int64
+	// NotLeaseHolderRetries is the number of times a batch was retried on
+	// another replica because it was sent to a replica that wasn't the
+	// leaseholder.
+	NotLeaseHolderRetries int64
+	// FollowerReads is the number of ranges at which a batch was served by a
+	// follower replica rather than the leaseholder.
+	FollowerReads int64
 }
 
 type tableInfo struct {
@@ -203,6 +222,10 @@ type Fetcher struct {
 	// If set, GetRangesInfo() can be used to retrieve the accumulated info.
 	returnRangeInfo bool
 
+	// batchTargetBytes, if set, limits the size of the KV batches of scans
+	// which limit their batches. See SetBatchTargetBytes.
+	batchTargetBytes int64
+
 	// traceKV indicates whether or not session tracing is enabled. It is set
 	// when beginning a new scan.
 	traceKV bool
@@ -458,9 +481,18 @@ func (rf *Fetcher) StartScan(
 	if err != nil {
 		return err
 	}
+	f.targetBytes = rf.batchTargetBytes
 	return rf.StartScanFrom(ctx, &f)
 }
 
+// SetBatchTargetBytes limits the KV batches of the scans started afterwards
+// to roughly the given number of bytes, in addition to their number of keys,
+// so that scans of wide rows don't buffer too much data at once. It only
+// applies to scans which limit their batches. A value of 0 means no limit.
+func (rf *Fetcher) SetBatchTargetBytes(targetBytes int64) {
+	rf.batchTargetBytes = targetBytes
+}
+
 // StartInconsistentScan initializes and starts an inconsistent scan, where each
 // KV batch can be read at a different historical timestamp.
 //
@@ -537,6 +569,7 @@ func (rf *Fetcher) StartInconsistentScan(
 	if err != nil {
 		return err
 	}
+	f.targetBytes = rf.batchTargetBytes
 	return rf.StartScanFrom(ctx, &f)
 }
 
@@ -1429,6 +1462,12 @@ func (rf *Fetcher) GetBytesRead() int64 {
 	return rf.kvFetcher.bytesRead
 }
 
+// GetKVStats returns statistics about the KV batches sent by the underlying
+// kvFetcher.
+func (rf *Fetcher) GetKVStats() KVStats {
+	return rf.kvFetcher.getKVStats()
+}
+
 // Only unique secondary indexes have extra columns to decode (namely the
 // primary index columns).
 func hasExtraCols(table *tableInfo) bool {
@@ -46,6 +46,24 @@ type sendFunc func(
 	ctx context.Context, ba roachpb.BatchRequest,
 ) (*roachpb.BatchResponse, error)
 
+// cancelCheckingSendFunc wraps sendFn so that no batch is sent once ctx has
+// been canceled, and so that a response which arrives after ctx was canceled
+// is dropped instead of being decoded. Without the latter, a large batch which
+// the KV layer finished evaluating despite the cancellation would be processed
+// in full before the query noticed that it was canceled.
+func cancelCheckingSendFunc(sendFn sendFunc) sendFunc {
+	return func(ctx context.Context, ba roachpb.BatchRequest) (*roachpb.BatchResponse, error) {
+		if ctx.Err() != nil {
+			return nil, sqlbase.QueryCanceledError
+		}
+		br, err := sendFn(ctx, ba)
+		if ctx.Err() != nil {
+			return nil, sqlbase.QueryCanceledError
+		}
+		return br, err
+	}
+}
+
 // txnKVFetcher handles retrieval of key/values.
 type txnKVFetcher struct {
 	// "Constant" fields, provided by the caller.
@@ -56,7 +74,14 @@ type txnKVFetcher struct {
 	// Subsequent batches are larger, up to kvBatchSize.
 	firstBatchLimit int64
 	useBatchLimit   bool
-	reverse         bool
+	// If targetBytes is also set along with useBatchLimit, batches are further
+	// limited to roughly that many bytes.
+	targetBytes int64
+	reverse     bool
+	// If useGets is true, the spans are all single keys, which are looked up
+	// with GetRequests rather than ScanRequests. Gets are cheaper to evaluate
+	// and never return resume spans.
+	useGets bool
 	// returnRangeInfo, if set, causes the kvBatchFetcher to populate rangeInfos.
 	// See also rowFetcher.returnRangeInfo.
 	returnRangeInfo bool
@@ -79,6 +104,9 @@ type txnKVFetcher struct {
 	rangeInfos       []roachpb.RangeInfo
 	origSpan         roachpb.Span
 	remainingBatches [][]byte
+
+	// kvStats accumulates statistics about the KV batches sent so far.
+	kvStats KVStats
 }
 
 var _ kvBatchFetcher = &txnKVFetcher{}
@@ -90,6 +118,10 @@ func (f *txnKVFetcher) getRangesInfo() []roachpb.RangeInfo {
 	return f.rangeInfos
 }
 
+func (f *txnKVFetcher) getKVStats() KVStats {
+	return f.kvStats
+}
+
 // getBatchSize returns the max size of the next batch.
 func (f *txnKVFetcher) getBatchSize() int64 {
 	return f.getBatchSizeForIdx(f.batchIdx)
@@ -164,7 +196,8 @@ func makeKVBatchFetcher(
 }
 
 // makeKVBatchFetcherWithSendFunc is like makeKVBatchFetcher but uses a custom
-// send function.
+// send function. The send function is wrapped so that it respects the
+// cancellation of the context; see cancelCheckingSendFunc.
 func makeKVBatchFetcherWithSendFunc(
 	sendFn sendFunc,
 	spans roachpb.Spans,
@@ -178,6 +211,17 @@ func makeKVBatchFetcherWithSendFunc(
 			firstBatchLimit, useBatchLimit)
 	}
 
+	useGets := len(spans) > 0
+	for i := range spans {
+		if len(spans[i].EndKey) != 0 {
+			useGets = false
+			break
+		}
+	}
+	if useGets && useBatchLimit {
+		return txnKVFetcher{}, errors.Errorf("batch limit used with single-key spans")
+	}
+
 	if useBatchLimit {
 		// Verify the spans are ordered if a batch limit is used.
 		for i := 1; i < len(spans); i++ {
@@ -217,12 +261,13 @@ func makeKVBatchFetcherWithSendFunc(
 	}
 
 	return txnKVFetcher{
-		sendFn:          sendFn,
+		sendFn:          cancelCheckingSendFunc(sendFn),
 		spans:           copySpans,
 		reverse:         reverse,
 		useBatchLimit:   useBatchLimit,
 		firstBatchLimit: firstBatchLimit,
 		returnRangeInfo: returnRangeInfo,
+		useGets:         useGets,
 	}, nil
 }
 
@@ -230,9 +275,20 @@ func makeKVBatchFetcherWithSendFunc(
 func (f *txnKVFetcher) fetch(ctx context.Context) error {
 	var ba roachpb.BatchRequest
 	ba.Header.MaxSpanRequestKeys = f.getBatchSize()
+	if ba.Header.MaxSpanRequestKeys != 0 {
+		// The byte target can only be used along with a key limit. When either
+		// of them is hit, the scans return resume spans which are handled below.
+		ba.Header.TargetBytes = f.targetBytes
+	}
 	ba.Header.ReturnRangeInfo = f.returnRangeInfo
 	ba.Requests = make([]roachpb.RequestUnion, len(f.spans))
-	if f.reverse {
+	if f.useGets {
+		gets := make([]roachpb.GetRequest, len(f.spans))
+		for i := range f.spans {
+			gets[i].Key = f.spans[i].Key
+			ba.Requests[i].MustSetInner(&gets[i])
+		}
+	} else if f.reverse {
 		scans := make([]roachpb.ReverseScanRequest, len(f.spans))
 		for i := range f.spans {
 			scans[i].ScanFormat = roachpb.BATCH_RESPONSE
@@ -255,7 +311,11 @@ func (f *txnKVFetcher) fetch(ctx context.Context) error {
 	copy(f.requestSpans, f.spans)
 
 	if log.ExpensiveLogEnabled(ctx, 2) {
-		buf := bytes.NewBufferString("Scan ")
+		op := "Scan "
+		if f.useGets {
+			op = "Get "
+		}
+		buf := bytes.NewBufferString(op)
 		for i, span := range f.spans {
 			if i != 0 {
 				buf.WriteString(", ")
@@ -268,11 +328,14 @@ func (f *txnKVFetcher) fetch(ctx context.Context) error {
 	// Reset spans in preparation for adding resume-spans below.
 	f.spans = f.spans[:0]
 
+	f.kvStats.Batches++
 	br, err := f.sendFn(ctx, ba)
 	if err != nil {
 		return err
 	}
 	if br != nil {
+		f.kvStats.NotLeaseHolderRetries += br.NotLeaseHolderRetries
+		f.kvStats.FollowerReads += br.FollowerReads
 		f.responses = br.Responses
 	} else {
 		f.responses = nil
@@ -280,6 +343,18 @@ func (f *txnKVFetcher) fetch(ctx context.Context) error {
 
 	// Set end to true until disproved.
 	f.fetchEnd = true
+	if f.useGets {
+		// Gets are never limited, so there is nothing to resume.
+		if f.returnRangeInfo {
+			for _, resp := range f.responses {
+				for _, ri := range resp.GetInner().Header().RangeInfos {
+					f.rangeInfos = roachpb.InsertRangeInfo(f.rangeInfos, ri)
+				}
+			}
+		}
+		f.batchIdx++
+		return nil
+	}
 	var sawResumeSpan bool
 	for _, resp := range f.responses {
 		reply := resp.GetInner()
@@ -296,6 +371,7 @@ func (f *txnKVFetcher) fetch(ctx context.Context) error {
 			// A span needs to be resumed.
 			f.fetchEnd = false
 			f.spans = append(f.spans, *resumeSpan)
+			f.kvStats.ResumeSpans++
 			// Verify we don't receive results for any remaining spans.
 			sawResumeSpan = true
 		}
@@ -347,6 +423,11 @@ func (f *txnKVFetcher) nextBatch(
 				f.remainingBatches = t.BatchResponses[1:]
 			}
 			return true, t.Rows, batchResp, origSpan, nil
+		case *roachpb.GetResponse:
+			if t.Value == nil {
+				return true, nil, nil, origSpan, nil
+			}
+			return true, []roachpb.KeyValue{{Key: origSpan.Key, Value: *t.Value}}, nil, origSpan, nil
 		}
 	}
 	if f.fetchEnd {
@@ -18,6 +18,7 @@ import (
 	"context"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
 )
 
@@ -32,8 +33,18 @@ type kvFetcher struct {
 	bytesRead     int64
 	span          roachpb.Span
 	newSpan       bool
+
+	// kvsSinceCancelCheck counts the kvs returned since the context was last
+	// checked for cancellation. See nextKV.
+	kvsSinceCancelCheck int
 }
 
+// kvFetcherCancelCheckInterval is the number of kvs returned by a kvFetcher
+// between two checks for the cancellation of the context. Checking on every
+// kv would be too expensive, but checking only between batches would let a
+// canceled query decode a whole batch, which can hold many thousands of kvs.
+const kvFetcherCancelCheckInterval = 1024
+
 func newKVFetcher(batchFetcher kvBatchFetcher) kvFetcher {
 	return kvFetcher{
 		kvBatchFetcher: batchFetcher,
@@ -46,6 +57,14 @@ func newKVFetcher(batchFetcher kvBatchFetcher) kvFetcher {
 func (f *kvFetcher) nextKV(
 	ctx context.Context,
 ) (ok bool, kv roachpb.KeyValue, newSpan bool, err error) {
+	if f.kvsSinceCancelCheck%kvFetcherCancelCheckInterval == 0 {
+		select {
+		case <-ctx.Done():
+			return false, kv, false, sqlbase.QueryCanceledError
+		default:
+		}
+	}
+	f.kvsSinceCancelCheck++
 	for {
 		newSpan = f.newSpan
 		f.newSpan = false
@@ -0,0 +1,155 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package row
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// cancelLatencyBound is the time within which a fetcher must notice that its
+// context was canceled. It is generous so that the tests aren't flaky under
+// stress; without cancellation checks the fetchers wouldn't return at all.
+const cancelLatencyBound = 5 * time.Second
+
+// makeTestKVs returns n kvs with increasing keys.
+func makeTestKVs(n int) []roachpb.KeyValue {
+	kvs := make([]roachpb.KeyValue, n)
+	for i := range kvs {
+		kvs[i].Key = roachpb.Key(fmt.Sprintf("k%08d", i))
+	}
+	return kvs
+}
+
+// singleBatchFetcher is a kvBatchFetcher which returns a single batch.
+type singleBatchFetcher struct {
+	kvs  []roachpb.KeyValue
+	done bool
+}
+
+var _ kvBatchFetcher = &singleBatchFetcher{}
+
+func (f *singleBatchFetcher) nextBatch(
+	ctx context.Context,
+) (ok bool, kvs []roachpb.KeyValue, batchResponse []byte, origSpan roachpb.Span, err error) {
+	if f.done {
+		return false, nil, nil, roachpb.Span{}, nil
+	}
+	f.done = true
+	return true, f.kvs, nil, roachpb.Span{}, nil
+}
+
+func (f *singleBatchFetcher) getRangesInfo() []roachpb.RangeInfo { return nil }
+
+func (f *singleBatchFetcher) getKVStats() KVStats { return KVStats{} }
+
+// TestKVFetcherCancellation verifies that a kvFetcher stops returning the kvs
+// of a batch shortly after its context is canceled, rather than once the whole
+// batch has been decoded.
+func TestKVFetcherCancellation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const numKVs = 100 * kvFetcherCancelCheckInterval
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	f := newKVFetcher(&singleBatchFetcher{kvs: makeTestKVs(numKVs)})
+	for i := 0; i < 10; i++ {
+		if ok, _, _, err := f.nextKV(ctx); !ok || err != nil {
+			t.Fatalf("unexpected result before cancellation: %t, %v", ok, err)
+		}
+	}
+
+	cancel()
+	var kvsAfterCancel int
+	for {
+		ok, _, _, err := f.nextKV(ctx)
+		if err != nil {
+			if err != sqlbase.QueryCanceledError {
+				t.Fatalf("expected %v, got %v", sqlbase.QueryCanceledError, err)
+			}
+			break
+		}
+		if !ok {
+			t.Fatal("the whole batch was returned despite the cancellation")
+		}
+		kvsAfterCancel++
+	}
+	if kvsAfterCancel >= kvFetcherCancelCheckInterval {
+		t.Fatalf("expected cancellation to be noticed within %d kvs, but %d kvs were returned",
+			kvFetcherCancelCheckInterval, kvsAfterCancel)
+	}
+}
+
+// TestKVBatchFetcherCancellation verifies that a txnKVFetcher doesn't return
+// a batch which arrives after its context was canceled, and that it doesn't
+// send any more batches.
+func TestKVBatchFetcherCancellation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	kvs := makeTestKVs(10 * kvFetcherCancelCheckInterval)
+	var sent int
+	sending := make(chan struct{})
+	sendFn := func(ctx context.Context, ba roachpb.BatchRequest) (*roachpb.BatchResponse, error) {
+		sent++
+		close(sending)
+		// Pretend that the KV layer finishes evaluating the batch regardless
+		// of the cancellation.
+		<-ctx.Done()
+		br := &roachpb.BatchResponse{}
+		br.Add(&roachpb.ScanResponse{Rows: kvs})
+		return br, nil
+	}
+	spans := roachpb.Spans{{Key: roachpb.Key("a"), EndKey: roachpb.Key("z")}}
+	bf, err := makeKVBatchFetcherWithSendFunc(
+		sendFn, spans, false /* reverse */, false /* useBatchLimit */, 0 /* firstBatchLimit */, false, /* returnRangeInfo */
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := newKVFetcher(&bf)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() {
+		_, _, _, err := f.nextKV(ctx)
+		errCh <- err
+	}()
+
+	<-sending
+	cancel()
+	select {
+	case err := <-errCh:
+		if err != sqlbase.QueryCanceledError {
+			t.Fatalf("expected %v, got %v", sqlbase.QueryCanceledError, err)
+		}
+	case <-time.After(cancelLatencyBound):
+		t.Fatalf("cancellation wasn't noticed within %s", cancelLatencyBound)
+	}
+
+	// The fetcher doesn't send any more batches once canceled.
+	if _, _, _, err := f.nextKV(ctx); err != sqlbase.QueryCanceledError {
+		t.Fatalf("expected %v, got %v", sqlbase.QueryCanceledError, err)
+	}
+	if sent != 1 {
+		t.Fatalf("expected a single batch to be sent, got %d", sent)
+	}
+}
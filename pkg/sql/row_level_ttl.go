@@ -0,0 +1,305 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/types"
+	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+var rowLevelTTLJobInterval = settings.RegisterNonNegativeDurationSetting(
+	"sql.row_level_ttl.job_interval",
+	"the amount of time between two deletions of the expired rows of a table with row-level TTL",
+	5*time.Minute,
+)
+
+var rowLevelTTLDeleteBatchSize = settings.RegisterPositiveIntSetting(
+	"sql.row_level_ttl.delete_batch_size",
+	"the maximum number of expired rows deleted in a single transaction",
+	1000,
+)
+
+// MakeRowLevelTTL validates the expiration expression of a row-level TTL and
+// returns the corresponding descriptor metadata. The expression must have
+// type TIMESTAMPTZ; the rows for which it is in the past are deleted by the
+// RowLevelTTL job of the table.
+func MakeRowLevelTTL(
+	ctx context.Context,
+	desc *sqlbase.MutableTableDescriptor,
+	expr tree.Expr,
+	semaCtx *tree.SemaContext,
+	tableName tree.TableName,
+) (*sqlbase.TableDescriptor_RowLevelTTL, error) {
+	replacedExpr, colIDsUsed, err := replaceVars(desc, expr)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := sqlbase.SanitizeVarFreeExpr(
+		replacedExpr, types.TimestampTZ, "EXPERIMENTAL_TTL", semaCtx, true, /* allowImpure */
+	); err != nil {
+		return nil, err
+	}
+
+	colIDs := make([]sqlbase.ColumnID, 0, len(colIDsUsed))
+	for colID := range colIDsUsed {
+		colIDs = append(colIDs, colID)
+	}
+	sort.Sort(sqlbase.ColumnIDs(colIDs))
+
+	sourceInfo := sqlbase.NewSourceInfoForSingleTable(
+		tableName, sqlbase.ResultColumnsFromColDescs(desc.TableDesc().AllNonDropColumns()),
+	)
+	sources := sqlbase.MultiSourceInfo{sourceInfo}
+
+	expr, err = dequalifyColumnRefs(ctx, sources, expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqlbase.TableDescriptor_RowLevelTTL{
+		ExpirationExpr: tree.Serialize(expr),
+		ColumnIDs:      colIDs,
+	}, nil
+}
+
+// setRowLevelTTL sets the row-level TTL of a table or, if expr is nil, resets
+// it. Setting it creates a RowLevelTTL job which periodically deletes the
+// expired rows of the table once the transaction commits; the job of a
+// previous TTL, if any, finishes as it is no longer referenced by the
+// descriptor.
+func (p *planner) setRowLevelTTL(
+	ctx context.Context,
+	desc *sqlbase.MutableTableDescriptor,
+	tableName *tree.TableName,
+	expr tree.Expr,
+) (bool, error) {
+	// Nodes running an older version panic when they adopt a job of an unknown
+	// type.
+	if !p.ExecCfg().Settings.Version.IsActive(cluster.VersionRowLevelTTL) {
+		return false, pgerror.Newf(pgerror.CodeObjectNotInPrerequisiteStateError,
+			`EXPERIMENTAL_TTL requires all nodes to be upgraded to %s`,
+			cluster.VersionByKey(cluster.VersionRowLevelTTL),
+		)
+	}
+	if expr == nil {
+		if desc.RowLevelTTL == nil {
+			return false, nil
+		}
+		desc.RowLevelTTL = nil
+		return true, nil
+	}
+
+	ttl, err := MakeRowLevelTTL(ctx, desc, expr, &p.semaCtx, *tableName)
+	if err != nil {
+		return false, err
+	}
+	record := jobs.Record{
+		Description:   fmt.Sprintf("delete expired rows of %s", tableName.FQString()),
+		Username:      p.User(),
+		DescriptorIDs: sqlbase.IDs{desc.ID},
+		Details:       jobspb.RowLevelTTLDetails{TableID: desc.ID},
+		Progress:      jobspb.RowLevelTTLProgress{},
+	}
+	job, err := p.ExecCfg().JobRegistry.CreateAdoptableJobWithTxn(ctx, record, p.txn)
+	if err != nil {
+		return false, err
+	}
+	ttl.JobID = *job.ID()
+	desc.RowLevelTTL = ttl
+	return true, nil
+}
+
+// rowLevelTTLResumer implements the jobs.Resumer interface for RowLevelTTL
+// jobs. The job of a table deletes its expired rows every
+// sql.row_level_ttl.job_interval; it succeeds once the table is dropped or
+// its row-level TTL is reset or replaced.
+type rowLevelTTLResumer struct {
+	job *jobs.Job
+}
+
+var _ jobs.Resumer = &rowLevelTTLResumer{}
+
+// Resume is part of the jobs.Resumer interface.
+func (r *rowLevelTTLResumer) Resume(
+	ctx context.Context, phs interface{}, resultsCh chan<- tree.Datums,
+) error {
+	p := phs.(*planner)
+	execCfg := p.ExecCfg()
+	details := r.job.Details().(jobspb.RowLevelTTLDetails)
+	// The job is created in the pending state and adopted by a registry, which
+	// doesn't mark it as started.
+	if err := r.job.Started(ctx); err != nil {
+		return err
+	}
+
+	var timer timeutil.Timer
+	defer timer.Stop()
+	timer.Reset(0)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			timer.Read = true
+		}
+
+		done, err := r.deleteExpiredRows(ctx, execCfg, details.TableID)
+		if err != nil {
+			if _, ok := err.(*jobs.InvalidStatusError); ok {
+				// The job was paused or canceled.
+				return err
+			}
+			// Failing the job would stop the deletions for good; try again at
+			// the next interval instead.
+			log.Warningf(ctx, "failed to delete expired rows of table %d: %v", details.TableID, err)
+		}
+		if done {
+			return nil
+		}
+		timer.Reset(rowLevelTTLJobInterval.Get(&execCfg.Settings.SV))
+	}
+}
+
+// deleteExpiredRows deletes the expired rows of the table, in batches of at
+// most sql.row_level_ttl.delete_batch_size rows. The batches are deleted in
+// primary key order, each one resuming the scan of the table after the last
+// row deleted by the previous one, so that the table is scanned only once. It
+// returns true if the job has nothing left to do, because the table was
+// dropped or its row-level TTL doesn't belong to the job anymore.
+func (r *rowLevelTTLResumer) deleteExpiredRows(
+	ctx context.Context, execCfg *ExecutorConfig, tableID sqlbase.ID,
+) (done bool, _ error) {
+	var tableName tree.TableName
+	var ttl *sqlbase.TableDescriptor_RowLevelTTL
+	var pkCols []string
+	if err := execCfg.DB.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		desc, err := sqlbase.GetTableDescFromID(ctx, txn, tableID)
+		if err != nil {
+			if err == sqlbase.ErrDescriptorNotFound {
+				done = true
+				return nil
+			}
+			return err
+		}
+		if desc.Dropped() || desc.RowLevelTTL == nil || desc.RowLevelTTL.JobID != *r.job.ID() {
+			done = true
+			return nil
+		}
+		dbDesc, err := sqlbase.GetDatabaseDescFromID(ctx, txn, desc.ParentID)
+		if err != nil {
+			return err
+		}
+		tableName = tree.MakeTableName(tree.Name(dbDesc.Name), tree.Name(desc.Name))
+		ttl = desc.RowLevelTTL
+		pkCols = make([]string, len(desc.PrimaryIndex.ColumnNames))
+		for i, name := range desc.PrimaryIndex.ColumnNames {
+			pkCols[i] = tree.NameString(name)
+		}
+		return nil
+	}); err != nil || done {
+		return done, err
+	}
+
+	batchSize := int(rowLevelTTLDeleteBatchSize.Get(&execCfg.Settings.SV))
+	pk := strings.Join(pkCols, ", ")
+	placeholders := make([]string, len(pkCols))
+	for i := range placeholders {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+	}
+	// The first batch starts at the beginning of the table; the next ones
+	// start after the primary key of the last row deleted.
+	firstStmt := fmt.Sprintf(
+		`DELETE FROM %s WHERE (%s) <= $1 ORDER BY %s LIMIT %d RETURNING %s`,
+		tableName.String(), ttl.ExpirationExpr, pk, batchSize, pk,
+	)
+	nextStmt := fmt.Sprintf(
+		`DELETE FROM %s WHERE (%s) <= $1 AND (%s) > (%s) ORDER BY %s LIMIT %d RETURNING %s`,
+		tableName.String(), ttl.ExpirationExpr, pk, strings.Join(placeholders, ", "), pk, batchSize, pk,
+	)
+	// All the batches use the same cutoff, so that rows expiring while the
+	// table is scanned are left to the next run.
+	cutoff := tree.MakeDTimestampTZ(timeutil.Now(), time.Microsecond)
+	var lastKey tree.Datums
+	for {
+		stmt, qargs := firstStmt, []interface{}{cutoff}
+		if lastKey != nil {
+			stmt = nextStmt
+			for _, d := range lastKey {
+				qargs = append(qargs, d)
+			}
+		}
+		rows, err := execCfg.InternalExecutor.Query(
+			ctx, "delete-expired-rows", nil /* txn */, stmt, qargs...,
+		)
+		if err != nil {
+			return false, err
+		}
+		deleted := len(rows)
+		if deleted > 0 {
+			// The rows are returned in the order in which they were deleted.
+			lastKey = rows[deleted-1]
+			if err := r.job.RunningStatus(ctx, func(
+				_ context.Context, details jobspb.Details,
+			) (jobs.RunningStatus, error) {
+				prog := details.(*jobspb.Progress_RowLevelTTL).RowLevelTTL
+				prog.RowsDeleted += int64(deleted)
+				return jobs.RunningStatus(fmt.Sprintf("deleted %d expired rows", prog.RowsDeleted)), nil
+			}); err != nil {
+				return false, err
+			}
+		}
+		if deleted < batchSize {
+			return false, nil
+		}
+	}
+}
+
+// OnFailOrCancel is part of the jobs.Resumer interface.
+func (r *rowLevelTTLResumer) OnFailOrCancel(context.Context, *client.Txn) error {
+	return nil
+}
+
+// OnSuccess is part of the jobs.Resumer interface.
+func (r *rowLevelTTLResumer) OnSuccess(context.Context, *client.Txn) error {
+	return nil
+}
+
+// OnTerminal is part of the jobs.Resumer interface.
+func (r *rowLevelTTLResumer) OnTerminal(context.Context, jobs.Status, chan<- tree.Datums) {}
+
+func init() {
+	jobs.RegisterConstructor(
+		jobspb.TypeRowLevelTTL,
+		func(job *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+			return &rowLevelTTLResumer{job: job}
+		},
+	)
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/pkg/errors"
+)
+
+func TestRowLevelTTL(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer func(oldInterval time.Duration) {
+		jobs.DefaultAdoptInterval = oldInterval
+	}(jobs.DefaultAdoptInterval)
+	jobs.DefaultAdoptInterval = 100 * time.Millisecond
+
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.Background())
+	r := sqlutils.MakeSQLRunner(db)
+	r.Exec(t, `SET CLUSTER SETTING sql.row_level_ttl.job_interval = '10ms'`)
+	r.Exec(t, `SET CLUSTER SETTING sql.row_level_ttl.delete_batch_size = 2`)
+	r.Exec(t, `CREATE DATABASE d`)
+	r.Exec(t, `CREATE TABLE d.t (k INT PRIMARY KEY, expires_at TIMESTAMPTZ)`)
+	r.Exec(t, `
+INSERT INTO d.t
+     SELECT i, now() + IF(i % 2 = 0, '-1h', '1h')::INTERVAL
+       FROM generate_series(1, 10) AS g (i)`,
+	)
+
+	r.ExpectErr(t, `expected EXPERIMENTAL_TTL expression to have type timestamptz`,
+		`ALTER TABLE d.t EXPERIMENTAL_TTL SET k`)
+	r.Exec(t, `ALTER TABLE d.t EXPERIMENTAL_TTL SET expires_at`)
+
+	waitForRows := func(expected [][]string) {
+		testutils.SucceedsSoon(t, func() error {
+			var count int
+			r.QueryRow(t, `SELECT count(*) FROM d.t`).Scan(&count)
+			if count != len(expected) {
+				return errors.Errorf("expected %d rows, got %d", len(expected), count)
+			}
+			return nil
+		})
+		r.CheckQueryResults(t, `SELECT k FROM d.t ORDER BY k`, expected)
+	}
+	waitForRows([][]string{{"1"}, {"3"}, {"5"}, {"7"}, {"9"}})
+
+	// The column referenced by the TTL can be renamed but not dropped.
+	r.ExpectErr(t, `column "expires_at" is referenced by the row-level TTL`,
+		`ALTER TABLE d.t DROP COLUMN expires_at`)
+	r.Exec(t, `ALTER TABLE d.t RENAME COLUMN expires_at TO exp`)
+	r.Exec(t, `UPDATE d.t SET exp = now() - '1h'::INTERVAL WHERE k = 1`)
+	waitForRows([][]string{{"3"}, {"5"}, {"7"}, {"9"}})
+
+	// Resetting the TTL makes the job succeed.
+	r.Exec(t, `ALTER TABLE d.t EXPERIMENTAL_TTL RESET`)
+	testutils.SucceedsSoon(t, func() error {
+		var status string
+		r.QueryRow(t,
+			`SELECT status FROM [SHOW JOBS] WHERE job_type = 'ROW LEVEL TTL'`,
+		).Scan(&status)
+		if status != string(jobs.StatusSucceeded) {
+			return errors.Errorf("expected the job to succeed, got status %s", status)
+		}
+		return nil
+	})
+	r.Exec(t, `ALTER TABLE d.t DROP COLUMN exp`)
+}
@@ -2998,6 +2998,28 @@ may increase either contention or retry errors, or both.`,
 		},
 	),
 
+	"crdb_internal.request_statement_bundle": makeBuiltin(
+		tree.FunctionProperties{
+			Category: categorySystemInfo,
+			Impure:   true,
+		},
+		tree.Overload{
+			Types:      tree.ArgTypes{{"fingerprint", types.String}},
+			ReturnType: tree.FixedReturnType(types.Uuid),
+			Fn: func(ctx *tree.EvalContext, args tree.Datums) (tree.Datum, error) {
+				id, err := ctx.Planner.RequestStatementBundle(ctx.Ctx(), string(tree.MustBeDString(args[0])))
+				if err != nil {
+					return nil, err
+				}
+				return tree.NewDUuid(tree.DUuid{UUID: id}), nil
+			},
+			Info: "Requests a diagnostics bundle (plans, trace and execution statistics) to be " +
+				"collected by the next execution of a statement with the given fingerprint, as " +
+				"shown in `crdb_internal.node_statement_statistics`, and returns the ID of the " +
+				"request. The bundle can be retrieved from `crdb_internal.kv_statement_diagnostics`.",
+		},
+	),
+
 	// Returns the number of distinct inverted index entries that would be generated for a JSON value.
 	"crdb_internal.json_num_index_entries": makeBuiltin(
 		tree.FunctionProperties{
@@ -204,6 +204,65 @@ var generators = map[string]builtinDefinition{
 				"SELECT * FROM crdb_internal.check_consistency(true, '\\x02', '\\x04')",
 		),
 	),
+
+	"crdb_internal.check_consistency_diff": makeBuiltin(
+		tree.FunctionProperties{
+			Impure:       true,
+			Class:        tree.GeneratorClass,
+			Category:     categorySystemInfo,
+			ReturnLabels: checkConsistencyDiffGeneratorType.TupleLabels(),
+		},
+		makeGeneratorOverload(
+			tree.ArgTypes{
+				{Name: "range_id", Typ: types.Int},
+			},
+			checkConsistencyDiffGeneratorType,
+			makeCheckConsistencyDiffGenerator,
+			"Runs a full consistency check on the range and returns the key-value "+
+				"pairs on which its replicas differ from the leaseholder. Each returned "+
+				"row contains a key-value pair, the replica it was compared against, and "+
+				"whether the pair is present on the leaseholder or on that replica only.\n\n"+
+				"Example usage:\n"+
+				"SELECT * FROM crdb_internal.check_consistency_diff(1)",
+		),
+	),
+
+	"crdb_internal.enqueue_range": makeBuiltin(
+		tree.FunctionProperties{
+			Impure:           true,
+			Class:            tree.GeneratorClass,
+			Category:         categorySystemInfo,
+			ReturnLabels:     enqueueRangeGeneratorType.TupleLabels(),
+			DistsqlBlacklist: true,
+		},
+		makeGeneratorOverload(
+			tree.ArgTypes{
+				{Name: "range_id", Typ: types.Int},
+				{Name: "queue", Typ: types.String},
+			},
+			enqueueRangeGeneratorType,
+			makeEnqueueRangeGenerator,
+			"Runs the range through the named replica queue (e.g. split, merge, gc, "+
+				"replicate, raftlog, consistencyChecker) on every node with a replica of "+
+				"it, and returns the trace of the queue's decisions. Each returned row "+
+				"contains a trace event, or the error the queue returned on a node.\n\n"+
+				"Example usage:\n"+
+				"SELECT * FROM crdb_internal.enqueue_range(1, 'gc')",
+		),
+		makeGeneratorOverload(
+			tree.ArgTypes{
+				{Name: "range_id", Typ: types.Int},
+				{Name: "queue", Typ: types.String},
+				{Name: "skip_should_queue", Typ: types.Bool},
+			},
+			enqueueRangeGeneratorType,
+			makeEnqueueRangeGenerator,
+			"Runs the range through the named replica queue (e.g. split, merge, gc, "+
+				"replicate, raftlog, consistencyChecker) on every node with a replica of "+
+				"it, and returns the trace of the queue's decisions. If skip_should_queue "+
+				"is set, the range is processed even if the queue would not have queued it.",
+		),
+	),
 }
 
 func makeGeneratorOverload(
@@ -998,3 +1057,232 @@ func (c *checkConsistencyGenerator) Values() tree.Datums {
 
 // Close is part of the tree.ValueGenerator interface.
 func (c *checkConsistencyGenerator) Close() {}
+
+// checkConsistencyDiffGenerator supports the execution of
+// crdb_internal.check_consistency_diff().
+type checkConsistencyDiffGenerator struct {
+	ctx     context.Context
+	db      *client.DB
+	rangeID roachpb.RangeID
+	// remainingRows is populated by Start(). Each Next() call peels of the first
+	// row and moves it to curRow.
+	remainingRows []roachpb.CheckConsistencyResponse_Diff
+	curRow        roachpb.CheckConsistencyResponse_Diff
+}
+
+var _ tree.ValueGenerator = &checkConsistencyDiffGenerator{}
+
+func makeCheckConsistencyDiffGenerator(
+	ctx *tree.EvalContext, args tree.Datums,
+) (tree.ValueGenerator, error) {
+	rangeID := int64(tree.MustBeDInt(args[0]))
+	if rangeID <= 0 {
+		return nil, pgerror.Newf(pgerror.CodeInvalidParameterValueError,
+			"range_id must be positive; got %d", rangeID)
+	}
+	return &checkConsistencyDiffGenerator{
+		ctx:     ctx.Ctx(),
+		db:      ctx.DB,
+		rangeID: roachpb.RangeID(rangeID),
+	}, nil
+}
+
+var checkConsistencyDiffGeneratorType = types.MakeLabeledTuple(
+	[]types.T{
+		*types.Int, *types.Int, *types.Int, *types.String,
+		*types.Bytes, *types.String, *types.Decimal, *types.Bytes,
+	},
+	[]string{
+		"node_id", "store_id", "replica_id", "present_on",
+		"key", "key_pretty", "timestamp", "value",
+	},
+)
+
+// ResolvedType is part of the tree.ValueGenerator interface.
+func (*checkConsistencyDiffGenerator) ResolvedType() *types.T {
+	return checkConsistencyDiffGeneratorType
+}
+
+// rangeDescriptorLookupPageSize is the number of meta2 addressing records
+// read at a time while looking up the descriptor of a range by its ID.
+const rangeDescriptorLookupPageSize = 1000
+
+// lookupRangeDescriptor scans the meta2 addressing records for the descriptor
+// of the given range. The records are read a page at a time, so that neither
+// the scan nor its result is unbounded on clusters with many ranges.
+func (c *checkConsistencyDiffGenerator) lookupRangeDescriptor() (roachpb.RangeDescriptor, error) {
+	var desc roachpb.RangeDescriptor
+	for start := keys.Meta2Prefix; ; {
+		kvs, err := c.db.Scan(c.ctx, start, keys.MetaMax, rangeDescriptorLookupPageSize)
+		if err != nil {
+			return roachpb.RangeDescriptor{}, err
+		}
+		for _, kv := range kvs {
+			if err := kv.ValueProto(&desc); err != nil {
+				return roachpb.RangeDescriptor{}, err
+			}
+			if desc.RangeID == c.rangeID {
+				return desc, nil
+			}
+		}
+		if len(kvs) < rangeDescriptorLookupPageSize {
+			break
+		}
+		start = kvs[len(kvs)-1].Key.Next()
+	}
+	return roachpb.RangeDescriptor{}, pgerror.Newf(pgerror.CodeInvalidParameterValueError,
+		"range r%d not found", c.rangeID)
+}
+
+// Start is part of the tree.ValueGenerator interface.
+func (c *checkConsistencyDiffGenerator) Start() error {
+	desc, err := c.lookupRangeDescriptor()
+	if err != nil {
+		return err
+	}
+	from := desc.StartKey.AsRawKey()
+	if bytes.Compare(from, keys.LocalMax) < 0 {
+		from = keys.LocalMax
+	}
+	var b client.Batch
+	b.AddRawRequest(&roachpb.CheckConsistencyRequest{
+		RequestHeader: roachpb.RequestHeader{
+			Key:    from,
+			EndKey: desc.EndKey.AsRawKey(),
+		},
+		Mode:     roachpb.ChecksumMode_CHECK_FULL,
+		WithDiff: true,
+	})
+	if err := c.db.Run(c.ctx, &b); err != nil {
+		return err
+	}
+	resp := b.RawResponse().Responses[0].GetInner().(*roachpb.CheckConsistencyResponse)
+	// The range may have been split or merged since its descriptor was looked
+	// up, in which case the check covered other ranges as well.
+	for _, res := range resp.Result {
+		if res.RangeID == c.rangeID {
+			c.remainingRows = append(c.remainingRows, res.Diff...)
+		}
+	}
+	return nil
+}
+
+// Next is part of the tree.ValueGenerator interface.
+func (c *checkConsistencyDiffGenerator) Next() (bool, error) {
+	if len(c.remainingRows) == 0 {
+		return false, nil
+	}
+	c.curRow = c.remainingRows[0]
+	c.remainingRows = c.remainingRows[1:]
+	return true, nil
+}
+
+// Values is part of the tree.ValueGenerator interface.
+func (c *checkConsistencyDiffGenerator) Values() tree.Datums {
+	presentOn := "replica"
+	if c.curRow.LeaseHolder {
+		presentOn = "leaseholder"
+	}
+	return tree.Datums{
+		tree.NewDInt(tree.DInt(c.curRow.Replica.NodeID)),
+		tree.NewDInt(tree.DInt(c.curRow.Replica.StoreID)),
+		tree.NewDInt(tree.DInt(c.curRow.Replica.ReplicaID)),
+		tree.NewDString(presentOn),
+		tree.NewDBytes(tree.DBytes(c.curRow.Key)),
+		tree.NewDString(c.curRow.Key.String()),
+		tree.TimestampToDecimal(c.curRow.Timestamp),
+		tree.NewDBytes(tree.DBytes(c.curRow.Value)),
+	}
+}
+
+// Close is part of the tree.ValueGenerator interface.
+func (c *checkConsistencyDiffGenerator) Close() {}
+
+// enqueueRangeGenerator supports the execution of
+// crdb_internal.enqueue_range().
+type enqueueRangeGenerator struct {
+	ctx             context.Context
+	planner         tree.EvalPlanner
+	rangeID         roachpb.RangeID
+	queue           string
+	skipShouldQueue bool
+	// remainingRows is populated by Start(). Each Next() call peels of the first
+	// row and moves it to curRow.
+	remainingRows []tree.EnqueueRangeEvent
+	curRow        tree.EnqueueRangeEvent
+}
+
+var _ tree.ValueGenerator = &enqueueRangeGenerator{}
+
+func makeEnqueueRangeGenerator(
+	ctx *tree.EvalContext, args tree.Datums,
+) (tree.ValueGenerator, error) {
+	rangeID := int64(tree.MustBeDInt(args[0]))
+	if rangeID <= 0 {
+		return nil, pgerror.Newf(pgerror.CodeInvalidParameterValueError,
+			"range_id must be positive; got %d", rangeID)
+	}
+	queue := string(tree.MustBeDString(args[1]))
+	if queue == "" {
+		return nil, pgerror.New(pgerror.CodeInvalidParameterValueError, "queue name must be non-empty")
+	}
+	var skipShouldQueue bool
+	if len(args) > 2 {
+		skipShouldQueue = bool(tree.MustBeDBool(args[2]))
+	}
+	return &enqueueRangeGenerator{
+		ctx:             ctx.Ctx(),
+		planner:         ctx.Planner,
+		rangeID:         roachpb.RangeID(rangeID),
+		queue:           queue,
+		skipShouldQueue: skipShouldQueue,
+	}, nil
+}
+
+var enqueueRangeGeneratorType = types.MakeLabeledTuple(
+	[]types.T{*types.Int, *types.TimestampTZ, *types.String, *types.String},
+	[]string{"node_id", "time", "message", "error"},
+)
+
+// ResolvedType is part of the tree.ValueGenerator interface.
+func (*enqueueRangeGenerator) ResolvedType() *types.T {
+	return enqueueRangeGeneratorType
+}
+
+// Start is part of the tree.ValueGenerator interface.
+func (e *enqueueRangeGenerator) Start() error {
+	var err error
+	e.remainingRows, err = e.planner.EnqueueRange(e.ctx, e.rangeID, e.queue, e.skipShouldQueue)
+	return err
+}
+
+// Next is part of the tree.ValueGenerator interface.
+func (e *enqueueRangeGenerator) Next() (bool, error) {
+	if len(e.remainingRows) == 0 {
+		return false, nil
+	}
+	e.curRow = e.remainingRows[0]
+	e.remainingRows = e.remainingRows[1:]
+	return true, nil
+}
+
+// Values is part of the tree.ValueGenerator interface.
+func (e *enqueueRangeGenerator) Values() tree.Datums {
+	if e.curRow.Error != "" {
+		return tree.Datums{
+			tree.NewDInt(tree.DInt(e.curRow.NodeID)),
+			tree.DNull,
+			tree.DNull,
+			tree.NewDString(e.curRow.Error),
+		}
+	}
+	return tree.Datums{
+		tree.NewDInt(tree.DInt(e.curRow.NodeID)),
+		tree.MakeDTimestampTZ(e.curRow.Time, time.Microsecond),
+		tree.NewDString(e.curRow.Message),
+		tree.DNull,
+	}
+}
+
+// Close is part of the tree.ValueGenerator interface.
+func (e *enqueueRangeGenerator) Close() {}
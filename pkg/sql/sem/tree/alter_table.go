@@ -65,6 +65,7 @@ func (*AlterTableRenameColumn) alterTableCmd()       {}
 func (*AlterTableRenameConstraint) alterTableCmd()   {}
 func (*AlterTableRenameTable) alterTableCmd()        {}
 func (*AlterTableSetAudit) alterTableCmd()           {}
+func (*AlterTableSetTTL) alterTableCmd()             {}
 func (*AlterTableSetDefault) alterTableCmd()         {}
 func (*AlterTableValidateConstraint) alterTableCmd() {}
 func (*AlterTablePartitionBy) alterTableCmd()        {}
@@ -81,6 +82,7 @@ var _ AlterTableCmd = &AlterTableRenameColumn{}
 var _ AlterTableCmd = &AlterTableRenameConstraint{}
 var _ AlterTableCmd = &AlterTableRenameTable{}
 var _ AlterTableCmd = &AlterTableSetAudit{}
+var _ AlterTableCmd = &AlterTableSetTTL{}
 var _ AlterTableCmd = &AlterTableSetDefault{}
 var _ AlterTableCmd = &AlterTableValidateConstraint{}
 var _ AlterTableCmd = &AlterTablePartitionBy{}
@@ -396,6 +398,24 @@ func (node *AlterTableSetAudit) Format(ctx *FmtCtx) {
 	ctx.WriteString(node.Mode.String())
 }
 
+// AlterTableSetTTL represents an ALTER TABLE EXPERIMENTAL_TTL SET or RESET
+// statement.
+type AlterTableSetTTL struct {
+	// Expr is the expiration expression of the rows of the table, or nil if
+	// row-level TTL is disabled (RESET).
+	Expr Expr
+}
+
+// Format implements the NodeFormatter interface.
+func (node *AlterTableSetTTL) Format(ctx *FmtCtx) {
+	if node.Expr == nil {
+		ctx.WriteString(" EXPERIMENTAL_TTL RESET")
+		return
+	}
+	ctx.WriteString(" EXPERIMENTAL_TTL SET ")
+	ctx.FormatNode(node.Expr)
+}
+
 // AlterTableInjectStats represents an ALTER TABLE INJECT STATISTICS statement.
 type AlterTableInjectStats struct {
 	Stats Expr
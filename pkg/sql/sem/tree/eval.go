@@ -2457,6 +2457,28 @@ type EvalPlanner interface {
 
 	// EvalSubquery returns the Datum for the given subquery node.
 	EvalSubquery(expr *Subquery) (Datum, error)
+
+	// EnqueueRange synchronously runs the given range through the named
+	// replica queue on every node with a replica of the range, and returns
+	// the trace events collected while doing so.
+	EnqueueRange(
+		ctx context.Context, rangeID roachpb.RangeID, queue string, skipShouldQueue bool,
+	) ([]EnqueueRangeEvent, error)
+
+	// RequestStatementBundle requests a diagnostics bundle to be collected by
+	// the next execution of a statement with the given fingerprint, and returns
+	// the ID of the request.
+	RequestStatementBundle(ctx context.Context, fingerprint string) (uuid.UUID, error)
+}
+
+// EnqueueRangeEvent is a trace event collected by EvalPlanner.EnqueueRange.
+// Events with a non-empty Error report that processing the range failed on
+// the node; their Time and Message are unset.
+type EnqueueRangeEvent struct {
+	NodeID  roachpb.NodeID
+	Time    time.Time
+	Message string
+	Error   string
 }
 
 // EvalSessionAccessor is a limited interface to access session variables.
@@ -52,6 +52,10 @@ type SessionData struct {
 	// StmtTimeout is the duration a query is permitted to run before it is
 	// canceled by the session. If set to 0, there is no timeout.
 	StmtTimeout time.Duration
+	// MaxFollowerReadStaleness is how stale the results of reads outside of
+	// explicit transactions may be for them to be served by follower replicas.
+	// If set to 0, such reads are served at the current time.
+	MaxFollowerReadStaleness time.Duration
 	// User is the name of the user logged into the session.
 	User string
 	// SafeUpdates causes errors when the client
@@ -62,6 +66,13 @@ type SessionData struct {
 	// ZigzagJoinEnabled indicates whether the optimizer should try and plan a
 	// zigzag join.
 	ZigzagJoinEnabled bool
+	// IndexJoinGetsEnabled indicates whether index joins against tables with a
+	// single column family should look up rows with Gets rather than Scans.
+	IndexJoinGetsEnabled bool
+	// PreferRegionLocalExecution indicates whether DistSQL should plan the
+	// processing of ranges on replicas in the gateway's region, rather than on
+	// their leaseholders, when possible.
+	PreferRegionLocalExecution bool
 	// ReorderJoinsLimit indicates the number of joins at which the optimizer should
 	// stop attempting to reorder.
 	ReorderJoinsLimit int
@@ -109,6 +109,27 @@ func (n *setClusterSettingNode) startExec(params runParams) error {
 	var expectedEncodedValue string
 	if err := execCfg.DB.Txn(params.ctx, func(ctx context.Context, txn *client.Txn) error {
 		var reportedValue string
+		// Retrieve the current value so that it can be recorded in the event log
+		// and, for state machine settings, be used to validate the transition.
+		datums, err := execCfg.InternalExecutor.QueryRow(
+			ctx, "retrieve-prev-setting", txn, "SELECT value FROM system.settings WHERE name = $1", n.name,
+		)
+		if err != nil {
+			return err
+		}
+		var prev tree.Datum
+		var previousValue string
+		if len(datums) > 0 {
+			prev = datums[0]
+			// Record the previous value the way SHOW CLUSTER SETTING displays it
+			// rather than in its encoding, falling back to the latter if it can't
+			// be decoded.
+			encodedPrev := string(tree.MustBeDString(prev))
+			previousValue, err = settings.DecodeToString(&n.st.SV, n.setting, encodedPrev)
+			if err != nil {
+				previousValue = encodedPrev
+			}
+		}
 		if n.value == nil {
 			reportedValue = "DEFAULT"
 			expectedEncodedValue = n.setting.EncodedDefault()
@@ -124,22 +145,12 @@ func (n *setClusterSettingNode) startExec(params runParams) error {
 				return err
 			}
 			reportedValue = tree.AsStringWithFlags(value, tree.FmtBareStrings)
-			var prev tree.Datum
-			if _, ok := n.setting.(*settings.StateMachineSetting); ok {
-				datums, err := execCfg.InternalExecutor.QueryRow(
-					ctx, "retrieve-prev-setting", txn, "SELECT value FROM system.settings WHERE name = $1", n.name,
-				)
-				if err != nil {
-					return err
-				}
-				if len(datums) == 0 {
-					// There is a SQL migration which adds this value. If it
-					// hasn't run yet, we can't update the version as we don't
-					// have good enough information about the current cluster
-					// version.
-					return errors.New("no persisted cluster version found, please retry later")
-				}
-				prev = datums[0]
+			if _, ok := n.setting.(*settings.StateMachineSetting); ok && prev == nil {
+				// There is a SQL migration which adds this value. If it
+				// hasn't run yet, we can't update the version as we don't
+				// have good enough information about the current cluster
+				// version.
+				return errors.New("no persisted cluster version found, please retry later")
 			}
 			encoded, err := toSettingString(ctx, n.st, n.name, n.setting, value, prev)
 			expectedEncodedValue = encoded
@@ -179,7 +190,7 @@ func (n *setClusterSettingNode) startExec(params runParams) error {
 			EventLogSetClusterSetting,
 			0, /* no target */
 			int32(params.extendedEvalCtx.NodeID),
-			EventLogSetClusterSettingDetail{n.name, reportedValue, params.SessionData().User},
+			EventLogSetClusterSettingDetail{n.name, reportedValue, params.SessionData().User, previousValue},
 		)
 	}); err != nil {
 		return err
@@ -278,9 +278,18 @@ func timeZoneVarSet(_ context.Context, m *sessionDataMutator, s string) error {
 
 func stmtTimeoutVarGetStringVal(
 	ctx context.Context, evalCtx *extendedEvalContext, values []tree.TypedExpr,
+) (string, error) {
+	return durationVarGetStringVal(evalCtx, "statement_timeout", values)
+}
+
+// durationVarGetStringVal evaluates the value of a session variable holding a
+// duration, which may be given as an interval or as an integer number of
+// milliseconds.
+func durationVarGetStringVal(
+	evalCtx *extendedEvalContext, name string, values []tree.TypedExpr,
 ) (string, error) {
 	if len(values) != 1 {
-		return "", newSingleArgVarError("statement_timeout")
+		return "", newSingleArgVarError(name)
 	}
 	d, err := values[0].Eval(&evalCtx.EvalContext)
 	if err != nil {
@@ -294,7 +303,7 @@ func stmtTimeoutVarGetStringVal(
 	case *tree.DInterval:
 		timeout, err = intervalToDuration(v)
 		if err != nil {
-			return "", wrapSetVarError("statement_timeout", values[0].String(), "%v", err)
+			return "", wrapSetVarError(name, values[0].String(), "%v", err)
 		}
 	case *tree.DInt:
 		timeout = time.Duration(*v) * time.Millisecond
@@ -320,6 +329,30 @@ func stmtTimeoutVarSet(ctx context.Context, m *sessionDataMutator, s string) err
 	return nil
 }
 
+func followerReadStalenessVarGetStringVal(
+	ctx context.Context, evalCtx *extendedEvalContext, values []tree.TypedExpr,
+) (string, error) {
+	return durationVarGetStringVal(evalCtx, "max_follower_read_staleness", values)
+}
+
+func followerReadStalenessVarSet(ctx context.Context, m *sessionDataMutator, s string) error {
+	interval, err := tree.ParseDIntervalWithField(s, tree.Millisecond)
+	if err != nil {
+		return wrapSetVarError("max_follower_read_staleness", s, "%v", err)
+	}
+	staleness, err := intervalToDuration(interval)
+	if err != nil {
+		return wrapSetVarError("max_follower_read_staleness", s, "%v", err)
+	}
+
+	if staleness < 0 {
+		return wrapSetVarError("max_follower_read_staleness", s,
+			"max_follower_read_staleness cannot have a negative duration")
+	}
+	m.SetMaxFollowerReadStaleness(staleness)
+	return nil
+}
+
 func intervalToDuration(interval *tree.DInterval) (time.Duration, error) {
 	nanos, _, _, err := interval.Encode()
 	if err != nil {
@@ -56,12 +56,14 @@ const (
 	CrdbInternalBackwardDependenciesTableID
 	CrdbInternalBuildInfoTableID
 	CrdbInternalBuiltinFunctionsTableID
+	CrdbInternalClusterDistSQLFlowsTableID
 	CrdbInternalClusterQueriesTableID
 	CrdbInternalClusterSessionsTableID
 	CrdbInternalClusterSettingsTableID
 	CrdbInternalCreateStmtsTableID
 	CrdbInternalFeatureUsageID
 	CrdbInternalForwardDependenciesTableID
+	CrdbInternalGCProgressTableID
 	CrdbInternalGossipNodesTableID
 	CrdbInternalGossipAlertsTableID
 	CrdbInternalGossipLivenessTableID
@@ -69,6 +71,8 @@ const (
 	CrdbInternalIndexColumnsTableID
 	CrdbInternalJobsTableID
 	CrdbInternalKVNodeStatusTableID
+	CrdbInternalKVProtectedTimestampsTableID
+	CrdbInternalKVStmtDiagnosticsTableID
 	CrdbInternalKVStoreStatusTableID
 	CrdbInternalLeasesTableID
 	CrdbInternalLocalQueriesTableID
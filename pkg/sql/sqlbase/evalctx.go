@@ -17,8 +17,10 @@ package sqlbase
 import (
 	"context"
 
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/pkg/errors"
 )
 
@@ -108,6 +110,20 @@ func (ep *DummyEvalPlanner) EvalSubquery(expr *tree.Subquery) (tree.Datum, error
 	return nil, errEvalPlanner
 }
 
+// EnqueueRange is part of the tree.EvalPlanner interface.
+func (ep *DummyEvalPlanner) EnqueueRange(
+	ctx context.Context, rangeID roachpb.RangeID, queue string, skipShouldQueue bool,
+) ([]tree.EnqueueRangeEvent, error) {
+	return nil, errEvalPlanner
+}
+
+// RequestStatementBundle is part of the tree.EvalPlanner interface.
+func (ep *DummyEvalPlanner) RequestStatementBundle(
+	ctx context.Context, fingerprint string,
+) (uuid.UUID, error) {
+	return uuid.UUID{}, errEvalPlanner
+}
+
 // DummySessionAccessor implements the tree.EvalSessionAccessor interface by returning errors.
 type DummySessionAccessor struct{}
 
@@ -1403,6 +1403,14 @@ func (desc *TableDescriptor) ValidateTable(st *cluster.Settings) error {
 		}
 	}
 
+	if desc.RowLevelTTL != nil {
+		for _, colID := range desc.RowLevelTTL.ColumnIDs {
+			if _, ok := columnIDs[colID]; !ok {
+				return fmt.Errorf("row-level TTL refers to unknown column ID %d", colID)
+			}
+		}
+	}
+
 	// TODO(dt): Validate each column only appears at-most-once in any FKs.
 
 	// Only validate column families and indexes if this is actually a table, not
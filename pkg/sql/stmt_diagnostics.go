@@ -0,0 +1,120 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/distsqlpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/stmtdiagnostics/stmtdiagnosticspb"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	opentracing "github.com/opentracing/opentracing-go"
+)
+
+// stmtBundleBuilder accumulates the diagnostics bundle of the execution of a
+// statement whose fingerprint was requested through the statement diagnostics
+// registry. See package stmtdiagnostics.
+type stmtBundleBuilder struct {
+	// id is the ID of the request the bundle is collected for.
+	id uuid.UUID
+	// sp is the recording span of the execution.
+	sp opentracing.Span
+	// diagram is the diagram of the physical plan of the main query, if it was
+	// run through DistSQL.
+	diagram distsqlpb.FlowDiagram
+
+	bundle stmtdiagnosticspb.Bundle
+}
+
+// maybeStartBundle checks whether a diagnostics bundle was requested for the
+// fingerprint of the planner's current statement. If so, it sets up the
+// planner to collect one and returns a context with a recording span which
+// should be used for the execution of the statement. The returned function
+// must be called once the statement has finished executing; it stores the
+// bundle.
+//
+// It is a no-op if there is no pending request for the statement.
+func (ex *connExecutor) maybeStartBundle(
+	ctx context.Context, planner *planner, res RestrictedCommandResult,
+) (context.Context, func()) {
+	registry := ex.server.cfg.StmtDiagnosticsRegistry
+	if registry == nil || !registry.HasPendingRequests() {
+		return ctx, func() {}
+	}
+	stmt := planner.stmt
+	fingerprint := stmt.AnonymizedStr
+	if fingerprint == "" {
+		fingerprint = anonymizeStmt(stmt.AST)
+	}
+	id, ok := registry.ShouldCollect(fingerprint)
+	if !ok {
+		return ctx, func() {}
+	}
+
+	origCtx := ctx
+	ctx, sp, err := tracing.StartSnowballTrace(ctx, ex.server.cfg.AmbientCtx.Tracer, "stmt-diagnostics")
+	if err != nil {
+		log.Warningf(ctx, "not collecting diagnostics bundle for statement: %v", err)
+		return ctx, func() {}
+	}
+	b := &stmtBundleBuilder{id: id, sp: sp}
+	b.bundle.NodeID = ex.server.cfg.NodeID.Get()
+	b.bundle.Statement = stmt.String()
+	planner.bundle = b
+	// Planning and execution use the context of the eval context in places;
+	// make sure it is recorded as well.
+	origEvalCtx := planner.extendedEvalCtx.Context
+	planner.extendedEvalCtx.Context = ctx
+
+	return ctx, func() {
+		planner.extendedEvalCtx.Context = origEvalCtx
+		planner.bundle = nil
+
+		recording := tracing.GetRecording(sp)
+		tracing.FinishSpan(sp)
+		b.bundle.Trace = tracing.FormatRecordedSpans(recording)
+		if b.diagram != nil {
+			b.diagram.AddSpans(recording)
+			json, _, err := b.diagram.ToURL()
+			if err != nil {
+				log.Warningf(origCtx, "error generating diagram for diagnostics bundle: %v", err)
+			} else {
+				b.bundle.DistSQLDiagram = json
+			}
+		}
+		if err := res.Err(); err != nil {
+			b.bundle.Error = err.Error()
+		}
+		b.bundle.CollectedAt = ex.server.cfg.Clock.Now()
+		if err := registry.InsertBundle(origCtx, b.id, &b.bundle); err != nil {
+			log.Warningf(origCtx, "failed to store diagnostics bundle for request %s: %v", b.id, err)
+		}
+	}
+}
+
+// savePlan records the logical plan of the statement in the bundle. It must
+// be called before the plan is executed.
+func (b *stmtBundleBuilder) savePlan(ctx context.Context, plan *planTop) {
+	b.bundle.Plan = planToString(ctx, plan.plan, plan.subqueryPlans)
+}
+
+// saveDiagram records the diagram of the physical plan of the statement in the
+// bundle. The statistics of its processors are added from the trace once the
+// execution is done.
+func (b *stmtBundleBuilder) saveDiagram(diagram distsqlpb.FlowDiagram) {
+	b.diagram = diagram
+}
@@ -0,0 +1,269 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package stmtdiagnostics implements statement diagnostics requests, which ask
+// for a diagnostics bundle (plans, trace and execution statistics) to be
+// collected by the next execution of a statement fingerprint, so that slow
+// queries can be debugged after the fact.
+//
+// A request is a stmtdiagnosticspb.Request stored in the system keyspace under
+// keys.StmtDiagnosticsRequestPrefix. Every node keeps the pending requests in a
+// Registry, which is refreshed periodically. The first node to execute a
+// statement with a requested fingerprint collects a bundle, stores it under
+// keys.StmtDiagnosticsBundlePrefix and marks the request as completed.
+package stmtdiagnostics
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/stmtdiagnostics/stmtdiagnosticspb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/pkg/errors"
+)
+
+// PollInterval controls how often the Registry reloads the pending requests in
+// the background. Requests made on a node are known to it right away.
+var PollInterval = settings.RegisterNonNegativeDurationSetting(
+	"sql.stmt_diagnostics.poll_interval",
+	"the interval at which the statement diagnostics requests are reloaded in the background",
+	10*time.Second,
+)
+
+// BundleTTL controls how long completed requests and their bundles are kept
+// around. Bundles can be large, so they aren't kept forever.
+var BundleTTL = settings.RegisterNonNegativeDurationSetting(
+	"sql.stmt_diagnostics.bundle_ttl",
+	"the amount of time after which completed statement diagnostics requests and their bundles "+
+		"are deleted (0 disables the deletion)",
+	7*24*time.Hour,
+)
+
+// Registry keeps track of the pending statement diagnostics requests and
+// stores the bundles collected for them.
+type Registry struct {
+	db    *client.DB
+	clock *hlc.Clock
+	st    *cluster.Settings
+
+	// numPending mirrors len(mu.pending), so that statements can check for
+	// pending requests without locking.
+	numPending int32
+	mu         struct {
+		syncutil.Mutex
+		// pending maps the fingerprints of the pending requests to their IDs.
+		pending map[string]uuid.UUID
+	}
+}
+
+// NewRegistry returns a Registry reading and writing the requests through db.
+// The Registry only knows about the requests made through it until the first
+// call to Refresh.
+func NewRegistry(db *client.DB, clock *hlc.Clock, st *cluster.Settings) *Registry {
+	r := &Registry{db: db, clock: clock, st: st}
+	r.mu.pending = make(map[string]uuid.UUID)
+	return r
+}
+
+// Start starts a task which periodically refreshes the Registry and garbage
+// collects the expired bundles until the stopper quiesces.
+func (r *Registry) Start(ctx context.Context, stopper *stop.Stopper) {
+	stopper.RunWorker(ctx, func(ctx context.Context) {
+		var timer timeutil.Timer
+		defer timer.Stop()
+		for {
+			timer.Reset(PollInterval.Get(&r.st.SV))
+			select {
+			case <-timer.C:
+				timer.Read = true
+				if err := r.Refresh(ctx); err != nil {
+					log.Warningf(ctx, "failed to refresh statement diagnostics requests: %v", err)
+				}
+				if err := r.GC(ctx); err != nil {
+					log.Warningf(ctx, "failed to garbage collect statement diagnostics bundles: %v", err)
+				}
+			case <-stopper.ShouldQuiesce():
+				return
+			}
+		}
+	})
+}
+
+// Refresh reloads the pending requests from the database.
+func (r *Registry) Refresh(ctx context.Context) error {
+	requests, err := r.Requests(ctx)
+	if err != nil {
+		return err
+	}
+	pending := make(map[string]uuid.UUID)
+	for i := range requests {
+		if !requests[i].Completed {
+			pending[requests[i].Fingerprint] = requests[i].ID
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mu.pending = pending
+	atomic.StoreInt32(&r.numPending, int32(len(pending)))
+	return nil
+}
+
+// InsertRequest requests a bundle to be collected by the next execution of a
+// statement with the given fingerprint, and returns the ID of the request.
+func (r *Registry) InsertRequest(ctx context.Context, fingerprint string) (uuid.UUID, error) {
+	if fingerprint == "" {
+		return uuid.UUID{}, errors.New("statement fingerprint must not be empty")
+	}
+	req := stmtdiagnosticspb.Request{
+		ID:          uuid.MakeV4(),
+		Fingerprint: fingerprint,
+		RequestedAt: r.clock.Now(),
+	}
+	if err := r.db.Put(ctx, keys.StmtDiagnosticsRequestKey(req.ID), &req); err != nil {
+		return uuid.UUID{}, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mu.pending[fingerprint] = req.ID
+	atomic.StoreInt32(&r.numPending, int32(len(r.mu.pending)))
+	return req.ID, nil
+}
+
+// HasPendingRequests returns whether there are requests for which no bundle
+// has been collected yet. It is cheap, so that statements only need to compute
+// their fingerprint if it returns true.
+func (r *Registry) HasPendingRequests() bool {
+	return atomic.LoadInt32(&r.numPending) > 0
+}
+
+// ShouldCollect returns whether a bundle should be collected for the
+// execution of a statement with the given fingerprint, and the ID of the
+// request it should be stored for. The request is then considered to be taken
+// care of on this node, so that concurrent executions don't collect bundles
+// for it as well.
+func (r *Registry) ShouldCollect(fingerprint string) (uuid.UUID, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id, ok := r.mu.pending[fingerprint]
+	if ok {
+		delete(r.mu.pending, fingerprint)
+		atomic.StoreInt32(&r.numPending, int32(len(r.mu.pending)))
+	}
+	return id, ok
+}
+
+// InsertBundle stores the bundle collected for the request with the given ID
+// and marks the request as completed. If another node collected a bundle for
+// the request in the meantime, or the request was deleted, the bundle is
+// dropped.
+func (r *Registry) InsertBundle(
+	ctx context.Context, id uuid.UUID, bundle *stmtdiagnosticspb.Bundle,
+) error {
+	return r.db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		var req stmtdiagnosticspb.Request
+		kv, err := txn.Get(ctx, keys.StmtDiagnosticsRequestKey(id))
+		if err != nil {
+			return err
+		}
+		if kv.Value == nil {
+			log.VEventf(ctx, 2, "dropping bundle for deleted statement diagnostics request %s", id)
+			return nil
+		}
+		if err := kv.ValueProto(&req); err != nil {
+			return err
+		}
+		if req.Completed {
+			log.VEventf(ctx, 2, "dropping bundle for completed statement diagnostics request %s", id)
+			return nil
+		}
+		req.Completed = true
+		req.CompletedAt = r.clock.Now()
+		b := txn.NewBatch()
+		b.Put(keys.StmtDiagnosticsRequestKey(id), &req)
+		b.Put(keys.StmtDiagnosticsBundleKey(id), bundle)
+		return txn.CommitInBatch(ctx, b)
+	})
+}
+
+// GC deletes the completed requests, and their bundles, which were completed
+// more than sql.stmt_diagnostics.bundle_ttl ago. Pending requests are kept
+// until a bundle is collected for them.
+func (r *Registry) GC(ctx context.Context) error {
+	ttl := BundleTTL.Get(&r.st.SV)
+	if ttl == 0 {
+		return nil
+	}
+	requests, err := r.Requests(ctx)
+	if err != nil {
+		return err
+	}
+	cutoff := r.clock.Now().Add(-ttl.Nanoseconds(), 0)
+	b := &client.Batch{}
+	var n int
+	for i := range requests {
+		req := &requests[i]
+		if !req.Completed || !req.CompletedAt.Less(cutoff) {
+			continue
+		}
+		b.Del(keys.StmtDiagnosticsRequestKey(req.ID), keys.StmtDiagnosticsBundleKey(req.ID))
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	log.VEventf(ctx, 2, "deleting %d expired statement diagnostics bundles", n)
+	return r.db.Run(ctx, b)
+}
+
+// Requests returns all the requests, ordered by ID.
+func (r *Registry) Requests(ctx context.Context) ([]stmtdiagnosticspb.Request, error) {
+	kvs, err := r.db.Scan(ctx, keys.StmtDiagnosticsRequestPrefix, keys.StmtDiagnosticsRequestKeyMax, 0 /* maxRows */)
+	if err != nil {
+		return nil, err
+	}
+	requests := make([]stmtdiagnosticspb.Request, len(kvs))
+	for i, kv := range kvs {
+		if err := kv.ValueProto(&requests[i]); err != nil {
+			return nil, errors.Wrapf(err, "decoding statement diagnostics request at %s", kv.Key)
+		}
+	}
+	return requests, nil
+}
+
+// Bundle returns the bundle collected for the request with the given ID, or
+// nil if there is none.
+func (r *Registry) Bundle(ctx context.Context, id uuid.UUID) (*stmtdiagnosticspb.Bundle, error) {
+	kv, err := r.db.Get(ctx, keys.StmtDiagnosticsBundleKey(id))
+	if err != nil {
+		return nil, err
+	}
+	if kv.Value == nil {
+		return nil, nil
+	}
+	var bundle stmtdiagnosticspb.Bundle
+	if err := kv.ValueProto(&bundle); err != nil {
+		return nil, errors.Wrapf(err, "decoding statement diagnostics bundle at %s", kv.Key)
+	}
+	return &bundle, nil
+}
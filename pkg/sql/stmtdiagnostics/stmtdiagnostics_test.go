@@ -0,0 +1,195 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package stmtdiagnostics_test
+
+import (
+	"context"
+	gosql "database/sql"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/sql/stmtdiagnostics"
+	"github.com/cockroachdb/cockroach/pkg/sql/stmtdiagnostics/stmtdiagnosticspb"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestStatementBundle(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(context.Background())
+	r := sqlutils.MakeSQLRunner(db)
+	r.Exec(t, `CREATE TABLE t (k INT PRIMARY KEY, v INT)`)
+	r.Exec(t, `INSERT INTO t VALUES (1, 2)`)
+
+	var id string
+	r.QueryRow(t, `SELECT crdb_internal.request_statement_bundle('SELECT v FROM t WHERE k = _')`).Scan(&id)
+	r.CheckQueryResults(t,
+		`SELECT id::STRING, completed FROM crdb_internal.kv_statement_diagnostics`,
+		[][]string{{id, "false"}},
+	)
+
+	// Statements with other fingerprints don't complete the request.
+	r.Exec(t, `SELECT k FROM t`)
+	r.CheckQueryResults(t,
+		`SELECT completed FROM crdb_internal.kv_statement_diagnostics`, [][]string{{"false"}},
+	)
+
+	r.Exec(t, `SELECT v FROM t WHERE k = 1`)
+	var statement, optPlan, plan, trace, errStr gosql.NullString
+	r.QueryRow(t, `
+SELECT statement, opt_plan, plan, trace, error
+  FROM crdb_internal.kv_statement_diagnostics
+ WHERE completed`,
+	).Scan(&statement, &optPlan, &plan, &trace, &errStr)
+	if exp := "SELECT v FROM t WHERE k = 1"; statement.String != exp {
+		t.Errorf("expected statement %q, got %q", exp, statement.String)
+	}
+	if optPlan.String == "" || plan.String == "" || trace.String == "" {
+		t.Errorf("expected plans and trace to be collected, got:\n%s\n%s\n%s",
+			optPlan.String, plan.String, trace.String)
+	}
+	if errStr.Valid {
+		t.Errorf("unexpected error in bundle: %s", errStr.String)
+	}
+
+	// Only the first execution collects a bundle.
+	r.Exec(t, `SELECT v FROM t WHERE k = 2`)
+	r.CheckQueryResults(t,
+		`SELECT statement FROM crdb_internal.kv_statement_diagnostics`,
+		[][]string{{"SELECT v FROM t WHERE k = 1"}},
+	)
+}
+
+func TestRegistry(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	s, _, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	r1 := stmtdiagnostics.NewRegistry(kvDB, s.Clock(), s.ClusterSettings())
+	r2 := stmtdiagnostics.NewRegistry(kvDB, s.Clock(), s.ClusterSettings())
+
+	id, err := r1.InsertRequest(ctx, "SELECT _")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r1.HasPendingRequests() {
+		t.Fatal("expected request to be pending on the registry it was made through")
+	}
+	if r2.HasPendingRequests() {
+		t.Fatal("expected other registry not to know about the request before refreshing")
+	}
+	if err := r2.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r2.ShouldCollect("SELECT _, _"); ok {
+		t.Fatal("expected no request for another fingerprint")
+	}
+	collectID, ok := r2.ShouldCollect("SELECT _")
+	if !ok || collectID != id {
+		t.Fatalf("expected to collect a bundle for %s, got %s (%t)", id, collectID, ok)
+	}
+	if r2.HasPendingRequests() {
+		t.Fatal("expected the request to be taken care of")
+	}
+
+	// The first bundle stored for the request wins.
+	if err := r2.InsertBundle(ctx, id, &stmtdiagnosticspb.Bundle{Statement: "SELECT 1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := r1.ShouldCollect("SELECT _"); !ok {
+		t.Fatal("expected stale registry to still collect a bundle")
+	}
+	if err := r1.InsertBundle(ctx, id, &stmtdiagnosticspb.Bundle{Statement: "SELECT 2"}); err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := r1.Bundle(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle == nil || bundle.Statement != "SELECT 1" {
+		t.Fatalf("expected the first bundle to be stored, got %+v", bundle)
+	}
+
+	requests, err := r1.Requests(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 || !requests[0].Completed {
+		t.Fatalf("expected a single completed request, got %+v", requests)
+	}
+	if err := r1.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if r1.HasPendingRequests() {
+		t.Fatal("expected no pending requests after refreshing")
+	}
+}
+
+func TestRegistryGC(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	s, _, kvDB := serverutils.StartServer(t, base.TestServerArgs{})
+	defer s.Stopper().Stop(ctx)
+
+	st := s.ClusterSettings()
+	r := stmtdiagnostics.NewRegistry(kvDB, s.Clock(), st)
+
+	completedID, err := r.InsertRequest(ctx, "SELECT _")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle := &stmtdiagnosticspb.Bundle{Statement: "SELECT 1"}
+	if err := r.InsertBundle(ctx, completedID, bundle); err != nil {
+		t.Fatal(err)
+	}
+	pendingID, err := r.InsertRequest(ctx, "SELECT _, _")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Nothing is deleted before the bundle expires.
+	if err := r.GC(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if bundle, err := r.Bundle(ctx, completedID); err != nil {
+		t.Fatal(err)
+	} else if bundle == nil {
+		t.Fatal("expected the bundle not to be deleted before it expires")
+	}
+
+	stmtdiagnostics.BundleTTL.Override(&st.SV, time.Nanosecond)
+	if err := r.GC(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if bundle, err := r.Bundle(ctx, completedID); err != nil {
+		t.Fatal(err)
+	} else if bundle != nil {
+		t.Fatalf("expected the expired bundle to be deleted, got %+v", bundle)
+	}
+	requests, err := r.Requests(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 || requests[0].ID != pendingID {
+		t.Fatalf("expected only the pending request to be left, got %+v", requests)
+	}
+}
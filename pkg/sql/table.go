@@ -95,6 +95,9 @@ type TableCollection struct {
 	// If the transaction gets pushed and the timestamp changes,
 	// the tables are released.
 	leasedTables []*sqlbase.ImmutableTableDescriptor
+	// leasedTablesByID indexes leasedTables by table ID. It is allocated
+	// lazily, when the first table is leased.
+	leasedTablesByID map[sqlbase.ID]*sqlbase.ImmutableTableDescriptor
 	// Tables modified by the uncommitted transaction affiliated
 	// with this TableCollection. This allows a transaction to see
 	// its own modifications while bypassing the table lease mechanism.
@@ -294,7 +297,7 @@ func (tc *TableCollection) getTableVersion(
 		log.Fatalf(ctx, "bad table for T=%s, expiration=%s", origTimestamp, expiration)
 	}
 
-	tc.leasedTables = append(tc.leasedTables, table)
+	tc.addLeasedTable(table)
 	log.VEventf(ctx, 2, "added table '%s' to table collection", tn)
 
 	// If the table we just acquired expires before the txn's deadline, reduce
@@ -336,11 +339,9 @@ func (tc *TableCollection) getTableVersionByID(
 
 	// First, look to see if we already have the table -- including those
 	// via `getTableVersion`.
-	for _, table := range tc.leasedTables {
-		if table.ID == tableID {
-			log.VEventf(ctx, 2, "found table %d in table cache", tableID)
-			return table, nil
-		}
+	if table, ok := tc.leasedTablesByID[tableID]; ok {
+		log.VEventf(ctx, 2, "found table %d in table cache", tableID)
+		return table, nil
 	}
 
 	origTimestamp := txn.OrigTimestamp()
@@ -359,7 +360,7 @@ func (tc *TableCollection) getTableVersionByID(
 		log.Fatalf(ctx, "bad table for T=%s, expiration=%s", origTimestamp, expiration)
 	}
 
-	tc.leasedTables = append(tc.leasedTables, table)
+	tc.addLeasedTable(table)
 	log.VEventf(ctx, 2, "added table '%s' to table collection", table.Name)
 
 	// If the table we just acquired expires before the txn's deadline, reduce
@@ -393,9 +394,21 @@ func (tc *TableCollection) releaseLeases(ctx context.Context) {
 			}
 		}
 		tc.leasedTables = tc.leasedTables[:0]
+		for id := range tc.leasedTablesByID {
+			delete(tc.leasedTablesByID, id)
+		}
 	}
 }
 
+// addLeasedTable adds a table leased by the transaction to the collection.
+func (tc *TableCollection) addLeasedTable(table *sqlbase.ImmutableTableDescriptor) {
+	tc.leasedTables = append(tc.leasedTables, table)
+	if tc.leasedTablesByID == nil {
+		tc.leasedTablesByID = make(map[sqlbase.ID]*sqlbase.ImmutableTableDescriptor)
+	}
+	tc.leasedTablesByID[table.ID] = table
+}
+
 // releaseTables releases all tables currently held by the TableCollection.
 func (tc *TableCollection) releaseTables(ctx context.Context) {
 	tc.releaseLeases(ctx)
@@ -437,6 +450,12 @@ func (tc *TableCollection) waitForCacheToDropDatabases(ctx context.Context) {
 	}
 }
 
+// isLeased returns whether the given descriptor was leased by the transaction,
+// as opposed to being read from the store or modified by the transaction.
+func (tc *TableCollection) isLeased(desc *sqlbase.ImmutableTableDescriptor) bool {
+	return tc.leasedTablesByID[desc.ID] == desc
+}
+
 func (tc *TableCollection) hasUncommittedTables() bool {
 	return len(tc.uncommittedTables) > 0
 }
@@ -80,6 +80,22 @@ type unionNode struct {
 	unionType tree.UnionType
 	// all indicates if the operation is the ALL or DISTINCT version
 	all bool
+
+	// hardLimit, if set, indicates that the node is a UNION ALL which performs a
+	// locality optimized search: the rows of the left operand (in the input
+	// SQL syntax), which only reads local data, are returned first, and the
+	// right operand is only read if fewer than hardLimit rows were found. See
+	// createPlanForLocalityOptimizedSearch. Such a node can also be run in
+	// local mode.
+	hardLimit uint64
+
+	run struct {
+		// source is the operand currently being read by a locality optimized
+		// search run in local mode.
+		source planNode
+		// numRows is the number of rows returned so far.
+		numRows uint64
+	}
 }
 
 // Union constructs a planNode from a UNION/INTERSECT/EXCEPT expression.
@@ -164,16 +180,52 @@ func (p *planner) newUnionNode(
 	return node, nil
 }
 
+// localityOptimizedSearchOperands returns the local and remote operands of a
+// unionNode which performs a locality optimized search.
+func (n *unionNode) localityOptimizedSearchOperands() (local, remote planNode) {
+	if n.inverted {
+		return n.right, n.left
+	}
+	return n.left, n.right
+}
+
 func (n *unionNode) startExec(params runParams) error {
-	panic("unionNode cannot be run in local mode")
+	if n.hardLimit == 0 {
+		panic("unionNode cannot be run in local mode")
+	}
+	n.run.source, _ = n.localityOptimizedSearchOperands()
+	return nil
 }
 
 func (n *unionNode) Next(params runParams) (bool, error) {
-	panic("unionNode cannot be run in local mode")
+	if n.hardLimit == 0 {
+		panic("unionNode cannot be run in local mode")
+	}
+	if n.run.numRows >= n.hardLimit {
+		// The remote operand must not be read once enough rows were found.
+		return false, nil
+	}
+	for {
+		ok, err := n.run.source.Next(params)
+		if err != nil || ok {
+			if ok {
+				n.run.numRows++
+			}
+			return ok, err
+		}
+		local, remote := n.localityOptimizedSearchOperands()
+		if n.run.source != local {
+			return false, nil
+		}
+		n.run.source = remote
+	}
 }
 
 func (n *unionNode) Values() tree.Datums {
-	panic("unionNode cannot be run in local mode")
+	if n.hardLimit == 0 {
+		panic("unionNode cannot be run in local mode")
+	}
+	return n.run.source.Values()
 }
 
 func (n *unionNode) Close(ctx context.Context) {
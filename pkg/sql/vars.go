@@ -341,6 +341,23 @@ var varGen = map[string]sessionVar{
 		GlobalDefault: globalFalse,
 	},
 
+	// CockroachDB extension.
+	`experimental_enable_index_join_gets`: {
+		GetStringVal: makeBoolGetStringValFn(`experimental_enable_index_join_gets`),
+		Set: func(_ context.Context, m *sessionDataMutator, s string) error {
+			b, err := parsePostgresBool(s)
+			if err != nil {
+				return err
+			}
+			m.SetIndexJoinGetsEnabled(b)
+			return nil
+		},
+		Get: func(evalCtx *extendedEvalContext) string {
+			return formatBoolAsPostgresSetting(evalCtx.SessionData.IndexJoinGetsEnabled)
+		},
+		GlobalDefault: globalTrue,
+	},
+
 	// CockroachDB extension.
 	`experimental_enable_zigzag_join`: {
 		GetStringVal: makeBoolGetStringValFn(`experimental_enable_zigzag_join`),
@@ -358,6 +375,23 @@ var varGen = map[string]sessionVar{
 		GlobalDefault: globalTrue,
 	},
 
+	// CockroachDB extension.
+	`prefer_region_local_execution`: {
+		GetStringVal: makeBoolGetStringValFn(`prefer_region_local_execution`),
+		Set: func(_ context.Context, m *sessionDataMutator, s string) error {
+			b, err := parsePostgresBool(s)
+			if err != nil {
+				return err
+			}
+			m.SetPreferRegionLocalExecution(b)
+			return nil
+		},
+		Get: func(evalCtx *extendedEvalContext) string {
+			return formatBoolAsPostgresSetting(evalCtx.SessionData.PreferRegionLocalExecution)
+		},
+		GlobalDefault: globalFalse,
+	},
+
 	// CockroachDB extension.
 	`reorder_joins_limit`: {
 		GetStringVal: makeIntGetStringValFn(`reorder_joins_limit`),
@@ -497,6 +531,17 @@ var varGen = map[string]sessionVar{
 	// See also issue #5924.
 	`idle_in_transaction_session_timeout`: makeCompatIntVar(`idle_in_transaction_session_timeout`, 0),
 
+	// CockroachDB extension.
+	`max_follower_read_staleness`: {
+		GetStringVal: followerReadStalenessVarGetStringVal,
+		Set:          followerReadStalenessVarSet,
+		Get: func(evalCtx *extendedEvalContext) string {
+			ms := evalCtx.SessionData.MaxFollowerReadStaleness.Nanoseconds() / int64(time.Millisecond)
+			return strconv.FormatInt(ms, 10)
+		},
+		GlobalDefault: func(sv *settings.Values) string { return "0" },
+	},
+
 	// See https://www.postgresql.org/docs/10/static/runtime-config-preset.html#GUC-MAX-INDEX-KEYS
 	`max_index_keys`: makeReadOnlyVar("32"),
 
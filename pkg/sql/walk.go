@@ -414,6 +414,9 @@ func (v *planVisitor) visitInternal(plan planNode, name string) {
 		n.plan = v.visit(n.plan)
 
 	case *unionNode:
+		if v.observer.attr != nil && n.hardLimit != 0 {
+			v.observer.attr(name, "limit", fmt.Sprintf("%d", n.hardLimit))
+		}
 		n.left = v.visit(n.left)
 		n.right = v.visit(n.right)
 
@@ -669,6 +672,9 @@ func nodeName(plan planNode) string {
 			return "revscan"
 		}
 	case *unionNode:
+		if n.hardLimit != 0 {
+			return "locality-optimized-search"
+		}
 		if n.emitAll {
 			return "append"
 		}
@@ -51,6 +51,7 @@ const (
 	minReplicaWeight = 0.001
 
 	// priorities for various repair operations.
+	removeLearnerReplicaPriority          float64 = 12001
 	addDeadReplacementPriority            float64 = 12000
 	addMissingReplicaPriority             float64 = 10000
 	addDecommissioningReplacementPriority float64 = 5000
@@ -90,6 +91,26 @@ var leaseRebalancingAggressiveness = settings.RegisterNonNegativeFloatSetting(
 	1.0,
 )
 
+// leaseRebalancingMinRequestRatio is the minimum gain in request locality
+// for which load-based lease rebalancing moves a lease toward another
+// replica: the requests weighted by their proximity to the other replica must
+// exceed those weighted by their proximity to the leaseholder by this factor.
+// Raising it keeps leases of ranges with traffic from several localities from
+// moving back and forth as the mix of requests shifts slightly.
+var leaseRebalancingMinRequestRatio = settings.RegisterValidatedFloatSetting(
+	"kv.allocator.load_based_lease_rebalancing.min_request_ratio",
+	"minimum ratio of the requests for a range coming from near another replica to those "+
+		"coming from near the leaseholder for the lease to be moved toward them based on load",
+	1.0,
+	func(v float64) error {
+		if v < 1 {
+			return errors.Errorf("cannot set %s to a value below 1: %f",
+				"kv.allocator.load_based_lease_rebalancing.min_request_ratio", v)
+		}
+		return nil
+	},
+)
+
 // AllocatorAction enumerates the various replication adjustments that may be
 // recommended by the allocator.
 type AllocatorAction int
@@ -103,6 +124,7 @@ const (
 	AllocatorRemoveDead
 	AllocatorRemoveDecommissioning
 	AllocatorConsiderRebalance
+	AllocatorRemoveLearner
 )
 
 var allocatorActionNames = map[AllocatorAction]string{
@@ -112,6 +134,7 @@ var allocatorActionNames = map[AllocatorAction]string{
 	AllocatorRemoveDead:            "remove dead",
 	AllocatorRemoveDecommissioning: "remove decommissioning",
 	AllocatorConsiderRebalance:     "consider rebalance",
+	AllocatorRemoveLearner:         "remove learner",
 }
 
 func (a AllocatorAction) String() string {
@@ -300,9 +323,22 @@ func (a *Allocator) ComputeAction(
 	}
 	// TODO(mrtracy): Handle non-homogeneous and mismatched attribute sets.
 
-	have := len(rangeInfo.Desc.Replicas().Unwrap())
+	if learners := rangeInfo.Desc.Replicas().Learners(); len(learners) > 0 {
+		// Learners only exist while a replica is being added. One left behind by
+		// a failed addition never catches up, as the Raft snapshot queue doesn't
+		// send snapshots to learners, so it is removed before anything else. The
+		// decisions below only consider the voters.
+		//
+		// This can race with an addition in progress, whose promotion of the
+		// learner then fails.
+		log.VEventf(ctx, 3, "AllocatorRemoveLearner - learners=%d, priority=%.2f",
+			len(learners), removeLearnerReplicaPriority)
+		return AllocatorRemoveLearner, removeLearnerReplicaPriority
+	}
+
+	have := len(rangeInfo.Desc.Replicas().Voters())
 	decommissioningReplicas := a.storePool.decommissioningReplicas(
-		rangeInfo.Desc.RangeID, rangeInfo.Desc.Replicas().Unwrap())
+		rangeInfo.Desc.RangeID, rangeInfo.Desc.Replicas().Voters())
 	clusterNodes := a.storePool.ClusterNodeCount()
 	need := GetNeededReplicas(*zone.NumReplicas, clusterNodes)
 	desiredQuorum := computeQuorum(need)
@@ -328,7 +364,7 @@ func (a *Allocator) ComputeAction(
 	}
 
 	liveReplicas, deadReplicas := a.storePool.liveAndDeadReplicas(
-		rangeInfo.Desc.RangeID, rangeInfo.Desc.Replicas().Unwrap())
+		rangeInfo.Desc.RangeID, rangeInfo.Desc.Replicas().Voters())
 	if len(liveReplicas) < quorum {
 		// Do not take any removal action if we do not have a quorum of live
 		// replicas.
@@ -496,18 +532,18 @@ func (a Allocator) RemoveTarget(
 	sl, _, _ := a.storePool.getStoreListFromIDs(existingStoreIDs, roachpb.RangeID(0), storeFilterNone)
 
 	analyzedConstraints := analyzeConstraints(
-		ctx, a.storePool.getStoreDescriptor, rangeInfo.Desc.Replicas().Unwrap(), zone)
+		ctx, a.storePool.getStoreDescriptor, rangeInfo.Desc.Replicas().Voters(), zone)
 	options := a.scorerOptions()
 	rankedCandidates := removeCandidates(
 		sl,
 		analyzedConstraints,
 		rangeInfo,
-		a.storePool.getLocalities(rangeInfo.Desc.Replicas().Unwrap()),
+		a.storePool.getLocalities(rangeInfo.Desc.Replicas().Voters()),
 		options,
 	)
 	log.VEventf(ctx, 3, "remove candidates: %s", rankedCandidates)
 	if bad := rankedCandidates.selectBad(a.randGen); bad != nil {
-		for _, exist := range rangeInfo.Desc.Replicas().Unwrap() {
+		for _, exist := range rangeInfo.Desc.Replicas().Voters() {
 			if exist.StoreID == bad.store.StoreID {
 				log.VEventf(ctx, 3, "remove target: %s", bad)
 				details := decisionDetails{Target: bad.compactString(options)}
@@ -560,17 +596,17 @@ func (a Allocator) RebalanceTarget(
 	// NB: The len(replicas) > 1 check allows rebalancing of ranges with only a
 	// single replica. This is a corner case which could happen in practice and
 	// also affects tests.
-	if len(rangeInfo.Desc.Replicas().Unwrap()) > 1 {
+	if len(rangeInfo.Desc.Replicas().Voters()) > 1 {
 		var numLiveReplicas int
 		for _, s := range sl.stores {
-			for _, repl := range rangeInfo.Desc.Replicas().Unwrap() {
+			for _, repl := range rangeInfo.Desc.Replicas().Voters() {
 				if s.StoreID == repl.StoreID {
 					numLiveReplicas++
 					break
 				}
 			}
 		}
-		newQuorum := computeQuorum(len(rangeInfo.Desc.Replicas().Unwrap()) + 1)
+		newQuorum := computeQuorum(len(rangeInfo.Desc.Replicas().Voters()) + 1)
 		if numLiveReplicas < newQuorum {
 			// Don't rebalance as we won't be able to make quorum after the rebalance
 			// until the new replica has been caught up.
@@ -579,14 +615,14 @@ func (a Allocator) RebalanceTarget(
 	}
 
 	analyzedConstraints := analyzeConstraints(
-		ctx, a.storePool.getStoreDescriptor, rangeInfo.Desc.Replicas().Unwrap(), zone)
+		ctx, a.storePool.getStoreDescriptor, rangeInfo.Desc.Replicas().Voters(), zone)
 	options := a.scorerOptions()
 	results := rebalanceCandidates(
 		ctx,
 		sl,
 		analyzedConstraints,
 		rangeInfo,
-		a.storePool.getLocalities(rangeInfo.Desc.Replicas().Unwrap()),
+		a.storePool.getLocalities(rangeInfo.Desc.Replicas().Voters()),
 		a.storePool.getNodeLocalityString,
 		options,
 	)
@@ -1033,6 +1069,9 @@ func (a Allocator) shouldTransferLeaseUsingStats(
 //
 // * LeaseRebalancingAggressiveness: Allow the aggressiveness to be tuned via
 //   a cluster setting.
+// * LeaseRebalancingMinRequestRatio: Ignore the weights unless the remote
+//   replica's exceeds the local replica's by at least this factor, which is
+//   tuned via a cluster setting.
 // * 0.1: Constant factor to reduce aggressiveness by default
 // * math.Log10(remoteWeight/sourceWeight): Comparison of the remote replica's
 //   weight to the local replica's weight. Taking the log of the ratio instead
@@ -1072,6 +1111,11 @@ func loadBasedLeaseRebalanceScore(
 	remoteLatencyMillis := float64(remoteLatency) / float64(time.Millisecond)
 	rebalanceAdjustment :=
 		leaseRebalancingAggressiveness.Get(&st.SV) * 0.1 * math.Log10(remoteWeight/sourceWeight) * math.Log1p(remoteLatencyMillis)
+	if rebalanceAdjustment > 0 && remoteWeight < sourceWeight*leaseRebalancingMinRequestRatio.Get(&st.SV) {
+		// The gain from moving the lease toward the requests is too small to be
+		// worth it; leave it to the lease counts.
+		rebalanceAdjustment = 0
+	}
 	// Start with twice the base rebalance threshold in order to fight more
 	// strongly against thrashing caused by small variances in the distribution
 	// of request weights.
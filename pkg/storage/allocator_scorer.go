@@ -513,7 +513,7 @@ func rebalanceCandidates(
 	var needRebalanceFrom bool
 	curDiversityScore := rangeDiversityScore(existingNodeLocalities)
 	for _, store := range allStores.stores {
-		for _, repl := range rangeInfo.Desc.Replicas().Unwrap() {
+		for _, repl := range rangeInfo.Desc.Replicas().Voters() {
 			if store.StoreID != repl.StoreID {
 				continue
 			}
@@ -592,8 +592,8 @@ func rebalanceCandidates(
 			// rebalance targets. We do include stores that currently have a replica
 			// because we want them to be considered as valid stores in the
 			// ConvergesOnMean calculations below. This is subtle but important.
-			if nodeHasReplica(store.Node.NodeID, rangeInfo.Desc.Replicas().Unwrap()) &&
-				!storeHasReplica(store.StoreID, rangeInfo.Desc.Replicas().Unwrap()) {
+			if nodeHasReplica(store.Node.NodeID, rangeInfo.Desc.Replicas().Voters()) &&
+				!storeHasReplica(store.StoreID, rangeInfo.Desc.Replicas().Voters()) {
 				log.VEventf(ctx, 2, "nodeHasReplica(n%d, %v)=true",
 					store.Node.NodeID, rangeInfo.Desc.Replicas())
 				continue
@@ -3892,6 +3892,51 @@ func TestLoadBasedLeaseRebalanceScore(t *testing.T) {
 	}
 }
 
+// TestLoadBasedLeaseRebalanceScoreMinRequestRatio verifies that leases only
+// follow the requests if the gain in request locality is large enough.
+func TestLoadBasedLeaseRebalanceScoreMinRequestRatio(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	st := cluster.MakeTestingClusterSettings()
+	leaseRebalancingMinRequestRatio.Override(&st.SV, 20)
+
+	remoteStore := roachpb.StoreDescriptor{Node: roachpb.NodeDescriptor{NodeID: 2}}
+	sourceStore := roachpb.StoreDescriptor{Node: roachpb.NodeDescriptor{NodeID: 1}}
+	remoteStore.Capacity.LeaseCount = 100
+	sourceStore.Capacity.LeaseCount = 100
+
+	testCases := []struct {
+		remoteWeight float64
+		sourceWeight float64
+		expected     int32
+	}{
+		// Below the minimum ratio, only the lease counts matter.
+		{1, 1, -21},
+		{10, 1, -21},
+		{19, 1, -21},
+		// At or above it, the leases follow the requests as usual.
+		{20, 1, 42},
+		{100, 1, 74},
+		// The minimum ratio doesn't make leases stick to the local store more.
+		{1, 10, -68},
+	}
+	for _, c := range testCases {
+		score, _ := loadBasedLeaseRebalanceScore(
+			context.Background(),
+			st,
+			c.remoteWeight,
+			10*time.Millisecond,
+			remoteStore,
+			c.sourceWeight,
+			sourceStore,
+			100, /* meanLeases */
+		)
+		if c.expected != score {
+			t.Errorf("%+v: expected %d, got %d", c, c.expected, score)
+		}
+	}
+}
+
 // TestAllocatorRemoveTarget verifies that the replica chosen by RemoveTarget is
 // the one with the lowest capacity.
 func TestAllocatorRemoveTarget(t *testing.T) {
@@ -4551,6 +4596,58 @@ func TestAllocatorComputeActionRemoveDead(t *testing.T) {
 	}
 }
 
+// TestAllocatorComputeActionRemoveLearner verifies that a learner left behind
+// in a range is removed before any other action is taken, and that only the
+// voters count towards the replication factor.
+func TestAllocatorComputeActionRemoveLearner(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	zone := config.ZoneConfig{
+		NumReplicas: proto.Int32(3),
+	}
+	desc := roachpb.RangeDescriptor{
+		InternalReplicas: []roachpb.ReplicaDescriptor{
+			{
+				StoreID:   1,
+				NodeID:    1,
+				ReplicaID: 1,
+			},
+			{
+				StoreID:   2,
+				NodeID:    2,
+				ReplicaID: 2,
+			},
+			{
+				StoreID:   3,
+				NodeID:    3,
+				ReplicaID: 3,
+				Type:      roachpb.LEARNER.Enum(),
+			},
+		},
+	}
+
+	stopper, _, sp, a, _ := createTestAllocator(10, false /* deterministic */)
+	ctx := context.Background()
+	defer stopper.Stop(ctx)
+	mockStorePool(sp, []roachpb.StoreID{1, 2, 3, 4}, nil, nil, nil, nil)
+
+	action, priority := a.ComputeAction(ctx, &zone, RangeInfo{Desc: &desc})
+	if action != AllocatorRemoveLearner {
+		t.Fatalf("expected action %q, got action %q",
+			allocatorActionNames[AllocatorRemoveLearner], allocatorActionNames[action])
+	}
+	if priority != removeLearnerReplicaPriority {
+		t.Errorf("expected priority %f, got %f", removeLearnerReplicaPriority, priority)
+	}
+
+	// Once the learner is removed, the range is under-replicated.
+	desc.InternalReplicas = desc.InternalReplicas[:2]
+	if action, _ := a.ComputeAction(ctx, &zone, RangeInfo{Desc: &desc}); action != AllocatorAdd {
+		t.Fatalf("expected action %q, got action %q",
+			allocatorActionNames[AllocatorAdd], allocatorActionNames[action])
+	}
+}
+
 func TestAllocatorComputeActionDecommission(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -5503,7 +5600,7 @@ func TestAllocatorFullDisks(t *testing.T) {
 		&base.Config{Insecure: true},
 		clock,
 		stopper,
-		&st.Version,
+		st,
 	)
 	server := rpc.NewServer(rpcContext) // never started
 	g := gossip.NewTest(1, rpcContext, server, stopper, metric.NewRegistry(), config.DefaultZoneConfigRef())
@@ -5643,7 +5740,7 @@ func Example_rebalancing() {
 		&base.Config{Insecure: true},
 		clock,
 		stopper,
-		&st.Version,
+		st,
 	)
 	server := rpc.NewServer(rpcContext) // never started
 	g := gossip.NewTest(1, rpcContext, server, stopper, metric.NewRegistry(), config.DefaultZoneConfigRef())
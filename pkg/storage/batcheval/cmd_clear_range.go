@@ -77,6 +77,19 @@ func ClearRange(
 	}
 	cArgs.Stats.Subtract(statsDelta)
 
+	// Clear the lock table entries of the cleared keys. There normally aren't
+	// any, as the span is no longer written to, so they are cleared
+	// individually.
+	if err := batch.Iterate(
+		engine.MakeMVCCMetadataKey(keys.LockTableSingleKey(args.Key)),
+		engine.MakeMVCCMetadataKey(keys.LockTableSingleKey(args.EndKey)),
+		func(kv engine.MVCCKeyValue) (bool, error) {
+			return false, batch.Clear(kv.Key)
+		},
+	); err != nil {
+		return result.Result{}, err
+	}
+
 	// If the total size of data to be cleared is less than
 	// clearRangeBytesThreshold, clear the individual values manually,
 	// instead of using a range tombstone (inefficient for small ranges).
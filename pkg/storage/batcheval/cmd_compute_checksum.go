@@ -63,6 +63,7 @@ func ComputeChecksum(
 		SaveSnapshot: args.Snapshot,
 		Mode:         args.Mode,
 		Checkpoint:   args.Checkpoint,
+		Shards:       args.Shards,
 	}
 	return pd, nil
 }
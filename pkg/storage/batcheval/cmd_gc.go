@@ -24,6 +24,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/spanset"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/pkg/errors"
 )
 
 func init() {
@@ -69,6 +70,24 @@ func GC(
 	args := cArgs.Args.(*roachpb.GCRequest)
 	h := cArgs.Header
 
+	// Refuse to remove data protected by a protected timestamp. The GC queue
+	// keeps its threshold below the earliest protection it knows of, but it
+	// may have raced with a protection being added. Keys are only ever
+	// collected up to versions shadowed at the threshold, so as a sanity
+	// check, no version at or above the protection may be removed either.
+	if protected := cArgs.EvalCtx.GetProtectedTimestamp(); protected != (hlc.Timestamp{}) {
+		if args.Threshold != (hlc.Timestamp{}) && !args.Threshold.Less(protected) {
+			return result.Result{}, errors.Errorf(
+				"GC threshold %s is not below protected timestamp %s", args.Threshold, protected)
+		}
+		for _, k := range args.Keys {
+			if !k.Timestamp.Less(protected) {
+				return result.Result{}, errors.Errorf(
+					"cannot GC key %s at %s: not below protected timestamp %s", k.Key, k.Timestamp, protected)
+			}
+		}
+	}
+
 	// All keys must be inside the current replica range. Keys outside
 	// of this range in the GC request are dropped silently, which is
 	// safe because they can simply be re-collected later on the correct
@@ -33,17 +33,27 @@ func init() {
 func declareKeysHeartbeatTransaction(
 	desc *roachpb.RangeDescriptor, header roachpb.Header, req roachpb.Request, spans *spanset.SpanSet,
 ) {
+	if txn := req.(*roachpb.HeartbeatTxnRequest).Txn; txn != nil {
+		header.Txn = txn
+	}
 	declareKeysWriteTransaction(desc, header, req, spans)
 }
 
 // HeartbeatTxn updates the transaction status and heartbeat
 // timestamp after receiving transaction heartbeat messages from
 // coordinator. Returns the updated transaction.
+//
+// The transaction is taken from the request if it names one, and from the
+// batch header otherwise. In the former case, a transaction whose record can't
+// be created is returned as aborted rather than as an error.
 func HeartbeatTxn(
 	ctx context.Context, batch engine.ReadWriter, cArgs CommandArgs, resp roachpb.Response,
 ) (result.Result, error) {
 	args := cArgs.Args.(*roachpb.HeartbeatTxnRequest)
 	h := cArgs.Header
+	if args.Txn != nil {
+		h.Txn = args.Txn
+	}
 	reply := resp.(*roachpb.HeartbeatTxnResponse)
 
 	if err := VerifyTransaction(h, args, roachpb.PENDING, roachpb.STAGING); err != nil {
@@ -68,7 +78,16 @@ func HeartbeatTxn(
 
 		// Verify that it is safe to create the transaction record.
 		if err := CanCreateTxnRecord(cArgs.EvalCtx, &txn); err != nil {
-			return result.Result{}, err
+			if args.Txn == nil {
+				return result.Result{}, err
+			}
+			// The heartbeat shares its batch with the heartbeats of other
+			// transactions, which the error would fail as well. Return the
+			// transaction as aborted instead, which is what its coordinator
+			// concludes from the error.
+			txn.Status = roachpb.ABORTED
+			reply.Txn = &txn
+			return result.Result{}, nil
 		}
 	}
 
@@ -87,7 +87,8 @@ func evalNewLease(
 
 	// Verify that requesting replica is part of the current replica set.
 	desc := rec.Desc()
-	if _, ok := desc.GetReplicaDescriptor(lease.Replica.StoreID); !ok {
+	repDesc, ok := desc.GetReplicaDescriptor(lease.Replica.StoreID)
+	if !ok {
 		return newFailedLeaseTrigger(isTransfer),
 			&roachpb.LeaseRejectedError{
 				Existing:  prevLease,
@@ -95,6 +96,16 @@ func evalNewLease(
 				Message:   "replica not found",
 			}
 	}
+	// Learners can't become Raft leaders, so they mustn't hold the lease
+	// either. They only exist while being promoted to voters.
+	if repDesc.GetType() == roachpb.LEARNER {
+		return newFailedLeaseTrigger(isTransfer),
+			&roachpb.LeaseRejectedError{
+				Existing:  prevLease,
+				Requested: lease,
+				Message:   "replica is a learner",
+			}
+	}
 
 	// Requests should not set the sequence number themselves. Set the sequence
 	// number here based on whether the lease is equivalent to the one it's
@@ -151,6 +162,12 @@ func evalNewLease(
 	if rec.ClusterSettings().Version.IsActive(cluster.VersionProposedTSLeaseRequest) {
 		pd.Replicated.PrevLeaseProposal = prevLease.ProposedTS
 	}
+	if isTransfer {
+		// Hand over the state of the outgoing leaseholder, so that the incoming
+		// leaseholder doesn't start out cold. Nodes which don't know about it
+		// ignore it.
+		pd.Replicated.LeaseTransferSummary = rec.GetLeaseTransferSummary()
+	}
 
 	pd.Local.Metrics = new(result.Metrics)
 	if isTransfer {
@@ -19,6 +19,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/storage/batcheval/result"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
@@ -52,12 +53,29 @@ func declareKeysRecomputeStats(
 	rdKey := keys.RangeDescriptorKey(desc.StartKey)
 	spans.Add(spanset.SpanReadOnly, roachpb.Span{Key: rdKey})
 	spans.Add(spanset.SpanReadWrite, roachpb.Span{Key: keys.TransactionKey(rdKey, uuid.Nil)})
+
+	if args := req.(*roachpb.RecomputeStatsRequest); args.ClearEstimates || args.MigrateLockTable {
+		// Clearing the ContainsEstimates flag is only correct if no command
+		// which sets it is in flight, and backfilling the lock table is only
+		// correct if no command lays down or resolves intents concurrently. Like
+		// Subsume, declare that we read and write every addressable key in the
+		// range, which guarantees that we conflict with (and thus wait for)
+		// every other command.
+		spans.Add(spanset.SpanReadWrite, roachpb.Span{
+			Key:    desc.StartKey.AsRawKey(),
+			EndKey: desc.EndKey.AsRawKey(),
+		})
+		spans.Add(spanset.SpanReadWrite, roachpb.Span{
+			Key:    keys.MakeRangeKeyPrefix(desc.StartKey),
+			EndKey: keys.MakeRangeKeyPrefix(desc.EndKey).PrefixEnd(),
+		})
+	}
 }
 
 // RecomputeStats recomputes the MVCCStats stored for this range and adjust them accordingly,
 // returning the MVCCStats delta obtained in the process.
 func RecomputeStats(
-	ctx context.Context, _ engine.ReadWriter, cArgs CommandArgs, resp roachpb.Response,
+	ctx context.Context, batch engine.ReadWriter, cArgs CommandArgs, resp roachpb.Response,
 ) (result.Result, error) {
 	desc := cArgs.EvalCtx.Desc()
 	args := cArgs.Args.(*roachpb.RecomputeStatsRequest)
@@ -65,6 +83,14 @@ func RecomputeStats(
 		return result.Result{}, errors.New("descriptor mismatch; range likely merged")
 	}
 	dryRun := args.DryRun
+	clearEstimates := args.ClearEstimates
+	if clearEstimates && !cArgs.EvalCtx.ClusterSettings().Version.IsActive(cluster.VersionRecomputeStatsClearEstimates) {
+		return result.Result{}, errors.New("clearing stats estimates requires a cluster version upgrade")
+	}
+	migrateLockTable := args.MigrateLockTable
+	if migrateLockTable && !cArgs.EvalCtx.ClusterSettings().Version.IsActive(cluster.VersionLockTable) {
+		return result.Result{}, errors.New("migrating the lock table requires a cluster version upgrade")
+	}
 
 	args = nil // avoid accidental use below
 
@@ -96,14 +122,13 @@ func RecomputeStats(
 	delta := actualMS
 	delta.Subtract(currentStats)
 
+	var res result.Result
 	if !dryRun {
-		// NB: this will never clear the ContainsEstimates flag. To be able to do this,
-		// we would need to guarantee that no command that sets it is in-flight in
-		// parallel with this command. This can be achieved by blocking all of the range
-		// or by using our inside knowledge that dictates that ranges which contain no
-		// timeseries writes never have the flag reset, or by making ContainsEstimates
-		// a counter (and ensuring that we're the only one subtracting at any given
-		// time).
+		// NB: unless requested, this will never clear the ContainsEstimates flag.
+		// To be able to do this, we need to guarantee that no command that sets
+		// it is in-flight in parallel with this command, which is achieved by
+		// blocking all of the range (see declareKeysRecomputeStats). Since that
+		// is disruptive, the consistency checker doesn't ask for it.
 		//
 		// TODO(tschottdorf): do we not want to run at all if we have estimates in
 		// this range? I think we want to as this would give us much more realistic
@@ -111,8 +136,39 @@ func RecomputeStats(
 		// wildly overcounting) and this is paced by the consistency checker, but it
 		// means some extra engine churn.
 		cArgs.Stats.Add(delta)
+		res.Replicated.ClearEstimates = clearEstimates
+
+		if migrateLockTable {
+			// Lock table entries are not accounted for in the stats, so
+			// backfilling them doesn't affect the delta computed above.
+			if err := backfillLockTable(ctx, batch, desc); err != nil {
+				return result.Result{}, err
+			}
+		}
 	}
 
 	resp.(*roachpb.RecomputeStatsResponse).AddedDelta = enginepb.MVCCStatsDelta(delta)
-	return result.Result{}, nil
+	return res, nil
+}
+
+// backfillLockTable writes the missing lock table entries for the intents on
+// the range-local and global keys of the range.
+func backfillLockTable(
+	ctx context.Context, batch engine.ReadWriter, desc *roachpb.RangeDescriptor,
+) error {
+	// The first range in the keyspace starts at KeyMin, which includes the
+	// node-local space. The global keys start at LocalMax.
+	dataStartKey := desc.StartKey.AsRawKey()
+	if desc.StartKey.Equal(roachpb.RKeyMin) {
+		dataStartKey = keys.LocalMax
+	}
+	for _, span := range []roachpb.Span{
+		{Key: keys.MakeRangeKeyPrefix(desc.StartKey), EndKey: keys.MakeRangeKeyPrefix(desc.EndKey)},
+		{Key: dataStartKey, EndKey: desc.EndKey.AsRawKey()},
+	} {
+		if _, err := engine.MVCCBackfillLockTable(ctx, batch, span.Key, span.EndKey); err != nil {
+			return err
+		}
+	}
+	return nil
 }
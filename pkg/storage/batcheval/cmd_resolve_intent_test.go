@@ -28,6 +28,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/storage/spanset"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
 	"github.com/cockroachdb/cockroach/pkg/storage/txnwait"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
@@ -117,12 +118,18 @@ func (m *mockEvalCtx) GetGCThreshold() hlc.Timestamp {
 func (m *mockEvalCtx) GetTxnSpanGCThreshold() hlc.Timestamp {
 	panic("unimplemented")
 }
+func (m *mockEvalCtx) GetProtectedTimestamp() hlc.Timestamp {
+	panic("unimplemented")
+}
 func (m *mockEvalCtx) GetLastReplicaGCTimestamp(context.Context) (hlc.Timestamp, error) {
 	panic("unimplemented")
 }
 func (m *mockEvalCtx) GetLease() (roachpb.Lease, roachpb.Lease) {
 	panic("unimplemented")
 }
+func (m *mockEvalCtx) GetLeaseTransferSummary() *storagepb.LeaseTransferSummary {
+	panic("unimplemented")
+}
 
 func TestDeclareKeysResolveIntent(t *testing.T) {
 	defer leaktest.AfterTest(t)()
@@ -53,11 +53,13 @@ func ReverseScan(
 				IgnoreSequence: shouldIgnoreSequenceNums(cArgs.EvalCtx),
 				Txn:            h.Txn,
 				Reverse:        true,
+				TargetBytes:    cArgs.TargetBytes,
 			})
 		if err != nil {
 			return result.Result{}, err
 		}
 		reply.NumKeys = numKvs
+		reply.NumBytes = int64(len(kvData))
 		reply.BatchResponses = [][]byte{kvData}
 	case roachpb.KEY_VALUES:
 		var rows []roachpb.KeyValue
@@ -67,11 +69,15 @@ func ReverseScan(
 				IgnoreSequence: shouldIgnoreSequenceNums(cArgs.EvalCtx),
 				Txn:            h.Txn,
 				Reverse:        true,
+				TargetBytes:    cArgs.TargetBytes,
 			})
 		if err != nil {
 			return result.Result{}, err
 		}
 		reply.NumKeys = int64(len(rows))
+		for i := range rows {
+			reply.NumBytes += int64(len(rows[i].Key) + len(rows[i].Value.RawBytes))
+		}
 		reply.Rows = rows
 	default:
 		panic(fmt.Sprintf("Unknown scanFormat %d", args.ScanFormat))
@@ -80,6 +86,9 @@ func ReverseScan(
 	if resumeSpan != nil {
 		reply.ResumeSpan = resumeSpan
 		reply.ResumeReason = roachpb.RESUME_KEY_LIMIT
+		if reply.NumKeys < cArgs.MaxKeys {
+			reply.ResumeReason = roachpb.RESUME_BYTE_LIMIT
+		}
 	}
 
 	if h.ReadConsistency == roachpb.READ_UNCOMMITTED {
@@ -52,11 +52,13 @@ func Scan(
 				Inconsistent:   h.ReadConsistency != roachpb.CONSISTENT,
 				IgnoreSequence: shouldIgnoreSequenceNums(cArgs.EvalCtx),
 				Txn:            h.Txn,
+				TargetBytes:    cArgs.TargetBytes,
 			})
 		if err != nil {
 			return result.Result{}, err
 		}
 		reply.NumKeys = numKvs
+		reply.NumBytes = int64(len(kvData))
 		reply.BatchResponses = [][]byte{kvData}
 	case roachpb.KEY_VALUES:
 		var rows []roachpb.KeyValue
@@ -65,11 +67,15 @@ func Scan(
 				Inconsistent:   h.ReadConsistency != roachpb.CONSISTENT,
 				IgnoreSequence: shouldIgnoreSequenceNums(cArgs.EvalCtx),
 				Txn:            h.Txn,
+				TargetBytes:    cArgs.TargetBytes,
 			})
 		if err != nil {
 			return result.Result{}, err
 		}
 		reply.NumKeys = int64(len(rows))
+		for i := range rows {
+			reply.NumBytes += int64(len(rows[i].Key) + len(rows[i].Value.RawBytes))
+		}
 		reply.Rows = rows
 	default:
 		panic(fmt.Sprintf("Unknown scanFormat %d", args.ScanFormat))
@@ -78,6 +84,9 @@ func Scan(
 	if resumeSpan != nil {
 		reply.ResumeSpan = resumeSpan
 		reply.ResumeReason = roachpb.RESUME_KEY_LIMIT
+		if reply.NumKeys < cArgs.MaxKeys {
+			reply.ResumeReason = roachpb.RESUME_BYTE_LIMIT
+		}
 	}
 
 	if h.ReadConsistency == roachpb.READ_UNCOMMITTED {
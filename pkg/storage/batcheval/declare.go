@@ -65,6 +65,10 @@ type CommandArgs struct {
 	// that many keys. Commands using this feature should also set
 	// NumKeys and ResumeSpan in their responses.
 	MaxKeys int64
+	// If TargetBytes is non-zero, scans should limit themselves to roughly
+	// that many bytes of keys and values. Commands using this feature should
+	// also set NumBytes in their responses.
+	TargetBytes int64
 
 	// *Stats should be mutated to reflect any writes made by the command.
 	Stats *enginepb.MVCCStats
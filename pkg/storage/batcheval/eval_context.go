@@ -25,6 +25,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
 	"github.com/cockroachdb/cockroach/pkg/storage/txnwait"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/limit"
@@ -34,7 +35,21 @@ import (
 
 // Limiters is the collection of per-store limits used during cmd evaluation.
 type Limiters struct {
-	BulkIOWriteRate              *rate.Limiter
+	// BackgroundIORate is the parent of the per-subsystem background IO rate
+	// limiters below. It caps the store's total background disk bandwidth.
+	BackgroundIORate *limit.RateLimiter
+	BulkIOWriteRate  *limit.RateLimiter
+	SnapshotSendRate *limit.RateLimiter
+	ExportReadRate   *limit.RateLimiter
+	GCByteRate       *limit.RateLimiter
+	// GCKeyRate limits the rate (in key versions) at which the GC queue
+	// removes data. Unlike GCByteRate, it doesn't count against the background
+	// IO budget.
+	GCKeyRate *limit.RateLimiter
+	// LeaseIntentCleanupRate limits the rate (in intents) at which intents are
+	// cleaned up after lease acquisitions.
+	LeaseIntentCleanupRate *limit.RateLimiter
+
 	ConcurrentImportRequests     limit.ConcurrentRequestLimiter
 	ConcurrentExportRequests     limit.ConcurrentRequestLimiter
 	AddSSTableRequestRate        *rate.Limiter
@@ -96,6 +111,15 @@ type EvalContext interface {
 	// TODO(nvanbenschoten): Remove this in 2.3, at which point no request type
 	// will ever need to consult the threshold.
 	GetTxnSpanGCThreshold() hlc.Timestamp
+	// GetProtectedTimestamp returns the earliest protected timestamp of any
+	// protection overlapping the range, or the zero timestamp if there is
+	// none. See the protectedts package.
+	GetProtectedTimestamp() hlc.Timestamp
 	GetLastReplicaGCTimestamp(context.Context) (hlc.Timestamp, error)
 	GetLease() (roachpb.Lease, roachpb.Lease)
+	// GetLeaseTransferSummary returns the state the replica accumulated while
+	// serving requests as the leaseholder, which a lease transfer hands over
+	// to the incoming leaseholder. It must only be called once the replica
+	// stopped serving requests.
+	GetLeaseTransferSummary() *storagepb.LeaseTransferSummary
 }
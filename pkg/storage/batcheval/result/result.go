@@ -308,6 +308,13 @@ func (p *Result) MergeAndDestroy(q Result) error {
 	}
 	q.Replicated.PrevLeaseProposal = nil
 
+	if p.Replicated.LeaseTransferSummary == nil {
+		p.Replicated.LeaseTransferSummary = q.Replicated.LeaseTransferSummary
+	} else if q.Replicated.LeaseTransferSummary != nil {
+		return errors.New("conflicting LeaseTransferSummary")
+	}
+	q.Replicated.LeaseTransferSummary = nil
+
 	if q.Local.Intents != nil {
 		if p.Local.Intents == nil {
 			p.Local.Intents = q.Local.Intents
@@ -17,6 +17,7 @@ package bulk
 import (
 	"bytes"
 	"context"
+	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv"
@@ -25,6 +26,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
 	"github.com/pkg/errors"
 )
 
@@ -200,6 +202,18 @@ func (b *SSTBatcher) GetSummary() roachpb.BulkOpSummary {
 	return b.totalRows
 }
 
+// addSSTableRetryPolicy governs the retries of ambiguous AddSSTable results.
+// The budget is shared by all AddSSTable calls on this node so that, during a
+// partial outage, bulk ingestion doesn't turn into a retry storm.
+var addSSTableRetryPolicy = retry.Policy{
+	Options: retry.Options{
+		InitialBackoff: 50 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		MaxRetries:     9,
+	},
+	Budget: retry.NewBudget(100 /* maxTokens */, 0.1 /* tokenRatio */),
+}
+
 type sender interface {
 	AddSSTable(ctx context.Context, begin, end interface{}, data []byte) error
 }
@@ -221,42 +235,44 @@ func AddSSTable(ctx context.Context, db sender, start, end roachpb.Key, sstBytes
 			iter.Close()
 		}
 	}()
-	const maxAddSSTableRetries = 10
 	for len(work) > 0 {
 		item := work[0]
 		work = work[1:]
 		if err := func() error {
-			var err error
-			for i := 0; i < maxAddSSTableRetries; i++ {
+			attempt := 0
+			err := addSSTableRetryPolicy.Do(ctx, "" /* dest */, func(err error) bool {
+				// Retry on AmbiguousResult.
+				if _, ok := err.(*roachpb.AmbiguousResultError); ok {
+					log.Warningf(ctx, "addsstable [%s,%s) attempt %d failed: %+v", start, end, attempt, err)
+					attempt++
+					return true
+				}
+				return false
+			}, func(ctx context.Context) error {
 				log.VEventf(ctx, 2, "sending %s AddSSTable [%s,%s)", sz(len(sstBytes)), start, end)
 				// This will fail if the range has split but we'll check for that below.
-				err = db.AddSSTable(ctx, item.start, item.end, item.sstBytes)
-				if err == nil {
-					return nil
-				}
-				// This range has split -- we need to split the SST to try again.
-				if m, ok := errors.Cause(err).(*roachpb.RangeKeyMismatchError); ok {
-					if iter == nil {
-						iter, err = engine.NewMemSSTIterator(sstBytes, false)
-						if err != nil {
-							return err
-						}
-					}
-					split := m.MismatchedRange.EndKey.AsRawKey()
-					log.Infof(ctx, "SSTable cannot be added spanning range bounds %v, retrying...", split)
-					left, right, err := createSplitSSTable(ctx, db, item.start, split, iter)
+				return db.AddSSTable(ctx, item.start, item.end, item.sstBytes)
+			})
+			if err == nil {
+				return nil
+			}
+			// This range has split -- we need to split the SST to try again.
+			if m, ok := errors.Cause(err).(*roachpb.RangeKeyMismatchError); ok {
+				if iter == nil {
+					iter, err = engine.NewMemSSTIterator(sstBytes, false)
 					if err != nil {
 						return err
 					}
-					// Add more work.
-					work = append([]*sstSpan{left, right}, work...)
-					return nil
 				}
-				// Retry on AmbiguousResult.
-				if _, ok := err.(*roachpb.AmbiguousResultError); ok {
-					log.Warningf(ctx, "addsstable [%s,%s) attempt %d failed: %+v", start, end, i, err)
-					continue
+				split := m.MismatchedRange.EndKey.AsRawKey()
+				log.Infof(ctx, "SSTable cannot be added spanning range bounds %v, retrying...", split)
+				left, right, err := createSplitSSTable(ctx, db, item.start, split, iter)
+				if err != nil {
+					return err
 				}
+				// Add more work.
+				work = append([]*sstSpan{left, right}, work...)
+				return nil
 			}
 			return errors.Wrapf(err, "addsstable [%s,%s)", item.start, item.end)
 		}(); err != nil {
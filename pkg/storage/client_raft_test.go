@@ -22,6 +22,7 @@ import (
 	"math/rand"
 	"reflect"
 	"runtime"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -48,6 +49,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -1410,6 +1412,7 @@ func TestLogGrowthWhenRefreshingPendingCommands(t *testing.T) {
 	// Disable leader transfers during leaseholder changes so that we
 	// can easily create leader-not-leaseholder scenarios.
 	sc.TestingKnobs.DisableLeaderFollowsLeaseholder = true
+	sc.TestingKnobs.DisableRaftLeadershipColocationRepair = true
 	// Refresh pending commands on every Raft group tick instead of
 	// every RaftElectionTimeoutTicks.
 	sc.TestingKnobs.RefreshReasonTicksPeriod = 1
@@ -1540,6 +1543,95 @@ func TestLogGrowthWhenRefreshingPendingCommands(t *testing.T) {
 	})
 }
 
+// TestRaftLeadershipColocationRepair verifies that a leaseholder which has not
+// been the Raft leader of its range for longer than the repair threshold gets
+// Raft leadership transferred to it.
+func TestRaftLeadershipColocationRepair(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	sc := storage.TestStoreConfig(nil)
+	// Keep Raft leadership where it is when the lease moves, so that only the
+	// repair can colocate it with the lease.
+	sc.TestingKnobs.DisableLeaderFollowsLeaseholder = true
+	// Disable periodic gossip tasks which can move the range 1 lease
+	// unexpectedly.
+	sc.TestingKnobs.DisablePeriodicGossips = true
+	storage.RaftLeadershipColocationThreshold.Override(&sc.Settings.SV, time.Nanosecond)
+	mtc := &multiTestContext{
+		storeConfig:          &sc,
+		startWithSingleRange: true,
+	}
+	defer mtc.Stop()
+	mtc.Start(t, 3)
+
+	const rangeID = roachpb.RangeID(1)
+	mtc.replicateRange(rangeID, 1, 2)
+	mtc.transferLease(context.TODO(), rangeID, 0, 1)
+
+	repl, err := mtc.Store(1).GetReplica(rangeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutils.SucceedsSoon(t, func() error {
+		mtc.Store(1).RepairRaftLeadershipColocation(context.TODO())
+		if status := repl.RaftStatus(); status == nil || status.RaftState != raft.StateLeader {
+			return errors.Errorf("leaseholder is not the Raft leader: %+v", status)
+		}
+		return nil
+	})
+	if n := mtc.Store(1).Metrics().RangeRaftLeadershipRequests.Count(); n == 0 {
+		t.Fatal("expected Raft leadership to be requested")
+	}
+
+	mtc.Store(1).RepairRaftLeadershipColocation(context.TODO())
+	if n := mtc.Store(1).Metrics().LeaseHolderNotRaftLeaderCount.Value(); n != 0 {
+		t.Fatalf("expected no leaseholder without Raft leadership, got %d", n)
+	}
+}
+
+// TestRaftApplicationTracing verifies that the followers record the
+// application of a command proposed from a traced context and that the
+// recordings make it into the trace of the proposer.
+func TestRaftApplicationTracing(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	sc := storage.TestStoreConfig(nil)
+	storage.RaftApplicationTraceSampleRate.Override(&sc.Settings.SV, 1)
+	mtc := &multiTestContext{
+		storeConfig:          &sc,
+		startWithSingleRange: true,
+	}
+	defer mtc.Stop()
+	mtc.Start(t, 3)
+
+	const rangeID = roachpb.RangeID(1)
+	mtc.replicateRange(rangeID, 1, 2)
+
+	ctx, getRecording, cancel := tracing.ContextWithRecordingSpan(context.Background(), "test")
+	defer cancel()
+	if _, pErr := client.SendWrapped(ctx, mtc.stores[0].TestSender(), putArgs([]byte("a"), []byte("b"))); pErr != nil {
+		t.Fatal(pErr)
+	}
+
+	testutils.SucceedsSoon(t, func() error {
+		var stores []string
+		for _, sp := range getRecording() {
+			if sp.Operation == "raft application" {
+				stores = append(stores, sp.Tags["s"])
+			}
+		}
+		sort.Strings(stores)
+		if exp := []string{"2", "3"}; !reflect.DeepEqual(stores, exp) {
+			return errors.Errorf("expected raft application spans from stores %v, got %v", exp, stores)
+		}
+		return nil
+	})
+	if tracing.FindMsgInRecording(getRecording(), "written in") == -1 {
+		t.Fatalf("expected the log write latency of the followers in the trace:\n%s",
+			tracing.FormatRecordedSpans(getRecording()))
+	}
+}
+
 // TestStoreRangeUpReplicate verifies that the replication queue will notice
 // under-replicated ranges and replicate them. Also tests that preemptive
 // snapshots which contain sideloaded proposals don't panic the receiving end.
@@ -1550,6 +1642,8 @@ func TestStoreRangeUpReplicate(t *testing.T) {
 	// Prevent the split queue from creating additional ranges while we're
 	// waiting for replication.
 	sc.TestingKnobs.DisableSplitQueue = true
+	// This test checks the preemptive snapshots sent when up-replicating.
+	storage.SetLearnerReplicasEnabled(sc.Settings, false)
 	mtc := &multiTestContext{
 		storeConfig: &sc,
 	}
@@ -1664,7 +1758,11 @@ func TestUnreplicateFirstRange(t *testing.T) {
 // case in stats already) or doesn't produce a Ready.
 func TestChangeReplicasDescriptorInvariant(t *testing.T) {
 	defer leaktest.AfterTest(t)()
+	sc := storage.TestStoreConfig(nil)
+	// This test counts the preemptive snapshots applied by ChangeReplicas.
+	storage.SetLearnerReplicasEnabled(sc.Settings, false)
 	mtc := &multiTestContext{
+		storeConfig: &sc,
 		// This test was written before the multiTestContext started creating many
 		// system ranges at startup, and hasn't been update to take that into
 		// account.
@@ -3937,6 +4035,18 @@ func TestRangeQuiescence(t *testing.T) {
 	if state := rep.RaftStatus().SoftState.RaftState; state != raft.StateLeader {
 		t.Fatalf("%s should be the leader: %s", rep, state)
 	}
+
+	// The leader was woken up by the follower's message, which the ranges
+	// status endpoint reports.
+	metrics := rep.Metrics(
+		context.Background(), mtc.stores[leaderIdx].Clock().Now(), nil /* livenessMap */, 0, /* clusterNodes */
+	)
+	if metrics.UnquiesceReason != "raft message" {
+		t.Fatalf("expected leader to be woken by a raft message, got %q", metrics.UnquiesceReason)
+	}
+	if metrics.RaftTicks == 0 {
+		t.Fatal("expected leader to have been ticked")
+	}
 }
 
 // TestInitRaftGroupOnRequest verifies that an uninitialized Raft group
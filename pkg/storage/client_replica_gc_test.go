@@ -23,11 +23,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/storage"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/pkg/errors"
 )
@@ -148,6 +150,71 @@ func TestReplicaGCQueueDropReplicaDirect(t *testing.T) {
 	})
 }
 
+// TestReplicaGCQueueDropReplicaEagerly verifies that a replica which applies
+// its own removal removes itself, leaving a tombstone behind, and that it
+// waits for the replica GC queue if eager removal is disabled.
+func TestReplicaGCQueueDropReplicaEagerly(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testutils.RunTrueAndFalse(t, "eager", func(t *testing.T, eager bool) {
+		cfg := storage.TestStoreConfig(nil)
+		cfg.TestingKnobs.DisableEagerReplicaRemoval = !eager
+		mtc := &multiTestContext{storeConfig: &cfg}
+		defer mtc.Stop()
+		mtc.Start(t, 3)
+
+		rangeID := roachpb.RangeID(1)
+		mtc.replicateRange(rangeID, 1, 2)
+		repl, err := mtc.stores[1].GetReplica(rangeID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		replicaID := repl.ReplicaID()
+
+		if !eager {
+			// Without eager removal, the replica lingers while the replica GC
+			// queue is disabled.
+			mtc.stores[1].SetReplicaGCQueueActive(false)
+		}
+		mtc.unreplicateRange(rangeID, 1)
+		if !eager {
+			testutils.SucceedsSoon(t, func() error {
+				if _, ok := repl.Desc().GetReplicaDescriptor(mtc.stores[1].StoreID()); ok {
+					return errors.New("removal not applied yet")
+				}
+				return nil
+			})
+			if _, err := mtc.stores[1].GetReplica(rangeID); err != nil {
+				t.Fatalf("unexpected range removal: %v", err)
+			}
+			mtc.stores[1].SetReplicaGCQueueActive(true)
+			mtc.advanceClock(context.TODO())
+			mtc.manualClock.Increment(int64(storage.ReplicaGCQueueInactivityThreshold + 1))
+		}
+
+		testutils.SucceedsSoon(t, func() error {
+			if !eager {
+				mtc.stores[1].MustForceReplicaGCScanAndProcess()
+			}
+			if _, err := mtc.stores[1].GetReplica(rangeID); !testutils.IsError(err, "r[0-9]+ was not found") {
+				return errors.Errorf("expected range removal: %v", err)
+			}
+			return nil
+		})
+		var tombstone roachpb.RaftTombstone
+		if ok, err := engine.MVCCGetProto(
+			context.Background(), mtc.stores[1].Engine(), keys.RaftTombstoneKey(rangeID),
+			hlc.Timestamp{}, &tombstone, engine.MVCCGetOptions{},
+		); err != nil {
+			t.Fatal(err)
+		} else if !ok {
+			t.Fatal("expected a tombstone for the removed replica")
+		} else if tombstone.NextReplicaID <= replicaID {
+			t.Fatalf("expected tombstone beyond replica ID %d, got %d", replicaID, tombstone.NextReplicaID)
+		}
+	})
+}
+
 // TestReplicaGCQueueDropReplicaOnScan verifies that the range GC queue
 // removes a range from a store that no longer should have a replica.
 func TestReplicaGCQueueDropReplicaGCOnScan(t *testing.T) {
@@ -1073,6 +1073,81 @@ func TestLeaseMetricsOnSplitAndTransfer(t *testing.T) {
 	})
 }
 
+// TestStaleProposalPolicy verifies that a write proposed under a lease which
+// is transferred before the write applies is rejected with a
+// NotLeaseHolderError, unless the stale proposal policy forwards it to the new
+// leaseholder.
+func TestStaleProposalPolicy(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testutils.RunTrueAndFalse(t, "forward", func(t *testing.T, forward bool) {
+		ctx := context.Background()
+		key := roachpb.Key("a")
+
+		// Hold the increment of key a between its evaluation and its proposal.
+		var blockInc int32
+		blocked := make(chan struct{})
+		unblock := make(chan struct{})
+		sc := storage.TestStoreConfig(nil)
+		sc.TestingKnobs.TestingProposalFilter = func(args storagebase.ProposalFilterArgs) *roachpb.Error {
+			if inc, ok := args.Req.GetArg(roachpb.Increment); ok && inc.Header().Key.Equal(key) &&
+				atomic.CompareAndSwapInt32(&blockInc, 1, 0) {
+				close(blocked)
+				<-unblock
+			}
+			return nil
+		}
+		mtc := &multiTestContext{storeConfig: &sc, startWithSingleRange: true}
+		defer mtc.Stop()
+		mtc.Start(t, 2)
+
+		policy := storage.StaleProposalReject
+		if forward {
+			policy = storage.StaleProposalForward
+		}
+		for _, s := range mtc.stores {
+			storage.StaleProposalPolicy.Override(&s.ClusterSettings().SV, int64(policy))
+		}
+
+		rangeID := mtc.stores[0].LookupReplica(roachpb.RKey(key)).RangeID
+		mtc.replicateRange(rangeID, 1)
+
+		atomic.StoreInt32(&blockInc, 1)
+		errCh := make(chan *roachpb.Error, 1)
+		go func() {
+			_, pErr := client.SendWrappedWith(ctx, mtc.stores[0].TestSender(), roachpb.Header{
+				RangeID: rangeID,
+			}, incrementArgs(key, 5))
+			errCh <- pErr
+		}()
+
+		// Transfer the lease while the increment is held, so that it is proposed
+		// under the previous lease.
+		<-blocked
+		mtc.transferLease(ctx, rangeID, 0, 1)
+		close(unblock)
+		pErr := <-errCh
+
+		forwarded := mtc.stores[0].Metrics().RangeStaleProposalsForwarded.Count()
+		if !forward {
+			if _, ok := pErr.GetDetail().(*roachpb.NotLeaseHolderError); !ok {
+				t.Fatalf("expected a NotLeaseHolderError, got %v", pErr)
+			}
+			if forwarded != 0 {
+				t.Fatalf("expected no forwarded proposal, got %d", forwarded)
+			}
+			return
+		}
+		if pErr != nil {
+			t.Fatal(pErr)
+		}
+		if forwarded != 1 {
+			t.Fatalf("expected one forwarded proposal, got %d", forwarded)
+		}
+		mtc.waitForValues(key, []int64{5, 5})
+	})
+}
+
 // Test that leases held before a restart are not used after the restart.
 // See replica.mu.minLeaseProposedTS for the reasons why this isn't allowed.
 func TestLeaseNotUsedAfterRestart(t *testing.T) {
@@ -1962,6 +2037,49 @@ func TestLeaseTransferInSnapshotUpdatesTimestampCache(t *testing.T) {
 	}
 }
 
+// TestLeaseTransferHandsOverRequestCounts verifies that the incoming
+// leaseholder of a lease transfer takes over the request counts of the
+// outgoing leaseholder instead of starting from scratch.
+func TestLeaseTransferHandsOverRequestCounts(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	sc := storage.TestStoreConfig(nil)
+	sc.TestingKnobs.DisableReplicateQueue = true
+	sc.TestingKnobs.DisableMergeQueue = true
+	mtc := &multiTestContext{storeConfig: &sc, startWithSingleRange: true}
+	defer mtc.Stop()
+	mtc.Start(t, 2)
+
+	key := roachpb.Key("a")
+	repl0 := mtc.stores[0].LookupReplica(roachpb.RKey(key))
+	mtc.replicateRange(repl0.RangeID, 1)
+
+	for i := 0; i < 10; i++ {
+		if _, pErr := client.SendWrappedWith(ctx, mtc.stores[0].TestSender(), roachpb.Header{
+			GatewayNodeID: mtc.stores[0].Ident.NodeID,
+		}, getArgs(key)); pErr != nil {
+			t.Fatal(pErr)
+		}
+	}
+	mtc.manualClock.Increment(int64(time.Second))
+	if qps, _ := repl0.LeaseholderQPS(); qps == 0 {
+		t.Fatal("expected requests to be recorded by the leaseholder")
+	}
+
+	mtc.transferLease(ctx, repl0.RangeID, 0, 1)
+	repl1 := mtc.stores[1].LookupReplica(roachpb.RKey(key))
+	testutils.SucceedsSoon(t, func() error {
+		if lease, _ := repl1.GetLease(); !lease.OwnedBy(mtc.stores[1].StoreID()) {
+			return errors.Errorf("lease not applied yet on the incoming leaseholder: %s", lease)
+		}
+		return nil
+	})
+	if qps, dur := repl1.LeaseholderQPS(); qps == 0 || dur < time.Second {
+		t.Fatalf("expected the request counts to be handed over, got %f over %s", qps, dur)
+	}
+}
+
 // TestConcurrentAdminChangeReplicasRequests ensures that when two attempts to
 // change replicas for a range race, only one will succeed.
 func TestConcurrentAdminChangeReplicasRequests(t *testing.T) {
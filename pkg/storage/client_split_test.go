@@ -1257,6 +1257,93 @@ func TestStoreRangeSplitBackpressureWrites(t *testing.T) {
 	}
 }
 
+// TestStoreRangeSplitBackpressureHardCap tests that writes to a range which
+// grew past the hard cap on its size queue it for a split and wait for that
+// split, instead of proceeding because no split was ongoing.
+func TestStoreRangeSplitBackpressureHardCap(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var activateSplitFilter int32
+	splitKey := roachpb.RKey(keys.UserTableDataMin)
+	splitPending, blockSplits := make(chan struct{}), make(chan struct{})
+	storeCfg := storage.TestStoreConfig(nil)
+	const maxBytes = 1 << 16
+	storeCfg.DefaultZoneConfig.RangeMaxBytes = proto.Int64(maxBytes)
+	storage.BackpressureRangeSizeHardCapMultiplier.Override(&storeCfg.Settings.SV, 3)
+	storeCfg.TestingKnobs.DisableGCQueue = true
+	storeCfg.TestingKnobs.DisableMergeQueue = true
+	storeCfg.TestingKnobs.DisableSplitQueue = true
+	storeCfg.TestingKnobs.TestingRequestFilter =
+		func(ba roachpb.BatchRequest) *roachpb.Error {
+			for _, req := range ba.Requests {
+				if cPut, ok := req.GetInner().(*roachpb.ConditionalPutRequest); ok {
+					if cPut.Key.Equal(keys.RangeDescriptorKey(splitKey)) {
+						if atomic.CompareAndSwapInt32(&activateSplitFilter, 1, 0) {
+							splitPending <- struct{}{}
+							<-blockSplits
+						}
+					}
+				}
+			}
+			return nil
+		}
+
+	ctx := context.Background()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	store := createTestStoreWithConfig(t, stopper, storeCfg)
+
+	// Split at the split key.
+	sArgs := adminSplitArgs(splitKey.AsRawKey())
+	repl := store.LookupReplica(splitKey)
+	if _, pErr := client.SendWrappedWith(ctx, store.TestSender(), roachpb.Header{
+		RangeID: repl.RangeID,
+	}, sArgs); pErr != nil {
+		t.Fatal(pErr)
+	}
+
+	// Fill the new range past the hard cap while the split queue is disabled.
+	repl = store.LookupReplica(splitKey)
+	origDesc := repl.Desc()
+	fillRange(t, store, repl.RangeID, splitKey.AsRawKey(), 4*maxBytes+1, false /* singleKey */)
+	if !repl.ExceedsBackpressureHardCap() {
+		t.Fatal("expected ExceedsBackpressureHardCap=true, found false")
+	}
+
+	// Enable the split queue. The range isn't queued, so no split is ongoing.
+	atomic.StoreInt32(&activateSplitFilter, 1)
+	store.SetSplitQueueActive(true)
+
+	putRes := make(chan error)
+	go func() {
+		// Write to the first key of the range to make sure that
+		// we don't end up on the wrong side of the split.
+		putRes <- store.DB().Put(ctx, splitKey, "test")
+	}()
+
+	// The put queues the split and waits for it.
+	<-splitPending
+	select {
+	case err := <-putRes:
+		close(blockSplits)
+		t.Fatalf("put was not blocked on split, returned err %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if n := store.Metrics().BackpressureHardCapSplits.Count(); n != 1 {
+		close(blockSplits)
+		t.Fatalf("expected 1 split queued by the hard cap, found %d", n)
+	}
+
+	// Let the split through. The put should follow.
+	close(blockSplits)
+	if err := <-putRes; err != nil {
+		t.Fatalf("put returned err %v, expected success", err)
+	}
+	if desc := store.LookupReplica(splitKey).Desc(); desc.EndKey.Equal(origDesc.EndKey) {
+		t.Fatalf("expected %s to be split", origDesc)
+	}
+}
+
 // TestStoreRangeSystemSplits verifies that splits are based on the contents of
 // the system.descriptor table.
 func TestStoreRangeSystemSplits(t *testing.T) {
@@ -0,0 +1,90 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage/closedts"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// ClosedTimestampLagAlertThreshold is the lag of a range's closed timestamp
+// behind the current time past which the range is considered lagging. Lagging
+// ranges can't serve follower reads at timestamps which would usually be
+// eligible for them.
+var ClosedTimestampLagAlertThreshold = settings.RegisterNonNegativeDurationSetting(
+	"kv.closed_timestamp.lag_alert_threshold",
+	"if nonzero, ranges whose closed timestamp trails the current time by more than this duration "+
+		"are counted as lagging and reported in the logs",
+	2*time.Minute,
+)
+
+// closedTimestampLagLogLimiter rate limits the reports of lagging ranges.
+var closedTimestampLagLogLimiter = log.Every(time.Minute)
+
+// ClosedTimestampLag describes how far the closed timestamp of a replica
+// trails the current time.
+type ClosedTimestampLag struct {
+	RangeID         roachpb.RangeID
+	ClosedTimestamp hlc.Timestamp
+	Lag             time.Duration
+}
+
+// closedTimestampLag returns the lag of the replica's closed timestamp, as
+// returned by maxClosed, behind now. Only ranges with epoch-based leases have
+// their timestamps closed, so false is returned for all others, as well as
+// when closed timestamps are disabled.
+func (r *Replica) closedTimestampLag(now, closed hlc.Timestamp) (ClosedTimestampLag, bool) {
+	if closedts.TargetDuration.Get(&r.store.cfg.Settings.SV) == 0 {
+		return ClosedTimestampLag{}, false
+	}
+	if lease, _ := r.GetLease(); lease.Type() != roachpb.LeaseEpoch {
+		return ClosedTimestampLag{}, false
+	}
+	return ClosedTimestampLag{
+		RangeID:         r.RangeID,
+		ClosedTimestamp: closed,
+		Lag:             now.GoTime().Sub(closed.GoTime()),
+	}, true
+}
+
+// ClosedTimestampLaggingRanges returns the ranges of the store whose closed
+// timestamps trail the current time the most, ordered by decreasing lag and
+// limited to the given number of ranges.
+func (s *Store) ClosedTimestampLaggingRanges(
+	ctx context.Context, limit int,
+) []ClosedTimestampLag {
+	now := s.cfg.Clock.Now()
+	var lags []ClosedTimestampLag
+	newStoreReplicaVisitor(s).Visit(func(repl *Replica) bool {
+		if lag, ok := repl.closedTimestampLag(now, repl.maxClosed(ctx)); ok {
+			lags = append(lags, lag)
+		}
+		return true // more
+	})
+	sort.Slice(lags, func(i, j int) bool {
+		return lags[i].Lag > lags[j].Lag
+	})
+	if len(lags) > limit {
+		lags = lags[:limit]
+	}
+	return lags
+}
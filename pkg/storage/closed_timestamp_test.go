@@ -19,6 +19,7 @@ import (
 	gosql "database/sql"
 	"fmt"
 	"math/rand"
+	"reflect"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -99,6 +100,68 @@ func TestClosedTimestampCanServe(t *testing.T) {
 	}
 }
 
+// TestClosedTimestampLatchlessReads verifies that non-transactional reads
+// below the closed timestamp are served by all replicas without acquiring
+// latches, unless disabled.
+func TestClosedTimestampLatchlessReads(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	if util.RaceEnabled {
+		// Limiting how long transactions can run does not work
+		// well with race unless we're extremely lenient, which
+		// drives up the test duration.
+		t.Skip("skipping under race")
+	}
+
+	ctx := context.Background()
+	tc, db0, desc, repls := setupTestClusterForClosedTimestampTesting(ctx, t, testingTargetDuration)
+	defer tc.Stopper().Stop(ctx)
+
+	if _, err := db0.Exec(`INSERT INTO cttest.kv VALUES(1, $1)`, "foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	latchlessReads := func() []int64 {
+		var counts []int64
+		for i, repl := range repls {
+			store, err := tc.Server(i).GetStores().(*storage.Stores).GetStore(repl.StoreID())
+			if err != nil {
+				t.Fatal(err)
+			}
+			counts = append(counts, store.Metrics().LatchlessReadsCount.Count())
+		}
+		return counts
+	}
+
+	ts := hlc.Timestamp{WallTime: timeutil.Now().UnixNano()}
+	baRead := makeReadBatchRequestForDesc(desc, ts)
+	testutils.SucceedsSoon(t, func() error {
+		return verifyCanReadFromAllRepls(ctx, t, baRead, repls, expectRows(1))
+	})
+	before := latchlessReads()
+	for i, count := range before {
+		if count == 0 {
+			t.Fatalf("expected replica %d to have served latchless reads", i)
+		}
+	}
+
+	if _, err := db0.Exec(
+		`SET CLUSTER SETTING kv.closed_timestamp.latchless_reads_enabled = false`,
+	); err != nil {
+		t.Fatal(err)
+	}
+	testutils.SucceedsSoon(t, func() error {
+		before = latchlessReads()
+		if err := verifyCanReadFromAllRepls(ctx, t, baRead, repls, expectRows(1)); err != nil {
+			return err
+		}
+		if after := latchlessReads(); !reflect.DeepEqual(before, after) {
+			return errors.Errorf("expected no latchless reads, got %v -> %v", before, after)
+		}
+		return nil
+	})
+}
+
 // TestClosedTimestampCanServerThroughoutLeaseTransfer verifies that lease
 // transfers does not prevent reading a value from a follower that was
 // previously readable.
@@ -83,10 +83,42 @@ func (c *Compactor) thresholdBytesAvailableFraction() float64 {
 	return thresholdBytesAvailableFraction.Get(&c.st.SV)
 }
 
+// maxAggregatedBytes returns the size at which an aggregated compaction stops
+// growing. Without an aggregation window, aggregations are compacted as soon
+// as they reach the threshold.
+func (c *Compactor) maxAggregatedBytes() int64 {
+	if c.aggregationWindow() == 0 {
+		return c.thresholdBytes()
+	}
+	if b := maxAggregatedBytes.Get(&c.st.SV); b > c.thresholdBytes() {
+		return b
+	}
+	return c.thresholdBytes()
+}
+
+func (c *Compactor) aggregationWindow() time.Duration {
+	return aggregationWindow.Get(&c.st.SV)
+}
+
 func (c *Compactor) maxAge() time.Duration {
 	return maxSuggestedCompactionRecordAge.Get(&c.st.SV)
 }
 
+func (c *Compactor) backgroundConcurrency() int {
+	return int(backgroundConcurrency.Get(&c.st.SV))
+}
+
+// setBackgroundConcurrency applies the compactor.background_concurrency
+// setting to the engine.
+func (c *Compactor) setBackgroundConcurrency(ctx context.Context) {
+	concurrency := c.backgroundConcurrency()
+	if err := c.eng.SetCompactionConcurrency(concurrency); err != nil {
+		log.Warningf(ctx, "unable to set background compaction concurrency to %d: %s", concurrency, err)
+		return
+	}
+	log.VEventf(ctx, 1, "set background compaction concurrency to %d", concurrency)
+}
+
 // poke instructs the compactor's main loop to react to new suggestions in a
 // timely manner.
 func (c *Compactor) poke() {
@@ -107,6 +139,16 @@ func (c *Compactor) Start(ctx context.Context, stopper *stop.Stopper) {
 	// started (this isn't great, but it's how it is right now).
 	c.poke()
 
+	// Apply the background compaction concurrency now and whenever it changes.
+	// Changes are applied in a task so that they don't reach the engine after
+	// the stopper closed it.
+	if c.backgroundConcurrency() != 0 {
+		c.setBackgroundConcurrency(ctx)
+	}
+	backgroundConcurrency.SetOnChange(&c.st.SV, func() {
+		_ = stopper.RunTask(ctx, "compactor-set-concurrency", c.setBackgroundConcurrency)
+	})
+
 	// Run the Worker in a Task because the worker holds on to the engine and
 	// may still access it even though the stopper has allowed it to close.
 	_ = stopper.RunTask(ctx, "compactor", func(ctx context.Context) {
@@ -202,8 +244,10 @@ func (aggr aggregatedCompaction) String() string {
 // processes contiguous or nearly contiguous aggregations if they
 // exceed the absolute or fractional size thresholds. If suggested
 // compactions don't meet thresholds, they're discarded if they're
-// older than maxSuggestedCompactionRecordAge. Returns a boolean
-// indicating whether the queue was successfully processed.
+// older than maxSuggestedCompactionRecordAge. Aggregations which
+// received suggestions within the aggregation window are left for
+// later. Returns a boolean indicating whether the queue was
+// successfully processed.
 func (c *Compactor) processSuggestions(ctx context.Context) (bool, error) {
 	ctx, cleanup := tracing.EnsureContext(ctx, c.st.Tracer, "process suggested compactions")
 	defer cleanup()
@@ -216,6 +260,7 @@ func (c *Compactor) processSuggestions(ctx context.Context) (bool, error) {
 	// Update at start of processing. Note that totalBytes is decremented and
 	// updated after any compactions which are processed.
 	c.Metrics.BytesQueued.Update(totalBytes)
+	c.Metrics.SuggestionsQueued.Update(int64(len(suggestions)))
 
 	if len(suggestions) == 0 {
 		return false, nil
@@ -258,32 +303,68 @@ func (c *Compactor) processSuggestions(ctx context.Context) (bool, error) {
 	// aggregation. Aggregates which exceed size thresholds are compacted. Small,
 	// isolated suggestions will be ignored until becoming too old, at which
 	// point they are discarded without compaction.
+	var deferred bool
 	aggr := initAggregatedCompaction(0, len(suggestions), suggestions[0])
 	for i, sc := range suggestions[1:] {
 		// Aggregate current suggestion with running aggregate if possible. If
 		// the current suggestion cannot be merged with the aggregate, process
 		// it if it meets compaction thresholds.
 		if done := c.aggregateCompaction(ctx, ssti, &aggr, sc); done {
-			processedBytes, err := c.processCompaction(ctx, aggr, capacity)
-			if err != nil {
-				log.Errorf(ctx, "failed processing suggested compactions %+v: %s", aggr, err)
-			} else if err := updateBytesQueued(processedBytes); err != nil {
-				log.Errorf(ctx, "failed updating bytes queued metric %+v", err)
+			if c.shouldDefer(aggr) {
+				log.VEventf(ctx, 2, "deferring compaction(s) %s", aggr)
+				deferred = true
+			} else {
+				processedBytes, err := c.processCompaction(ctx, aggr, capacity)
+				if err != nil {
+					log.Errorf(ctx, "failed processing suggested compactions %+v: %s", aggr, err)
+				} else if err := updateBytesQueued(processedBytes); err != nil {
+					log.Errorf(ctx, "failed updating bytes queued metric %+v", err)
+				}
 			}
 			// Reset aggregation to the last, un-aggregated, suggested compaction.
 			aggr = initAggregatedCompaction(i, len(suggestions), sc)
 		}
 	}
 	// Process remaining aggregated compaction.
-	processedBytes, err := c.processCompaction(ctx, aggr, capacity)
-	if err != nil {
-		return false, err
+	if c.shouldDefer(aggr) {
+		log.VEventf(ctx, 2, "deferring compaction(s) %s", aggr)
+		deferred = true
+	} else {
+		processedBytes, err := c.processCompaction(ctx, aggr, capacity)
+		if err != nil {
+			return false, err
+		}
+		if err := updateBytesQueued(processedBytes); err != nil {
+			log.Errorf(ctx, "failed updating bytes queued metric %+v", err)
+		}
 	}
-	if err := updateBytesQueued(processedBytes); err != nil {
-		log.Errorf(ctx, "failed updating bytes queued metric %+v", err)
+	// Refresh the number of queued suggestions, which only examineQueue
+	// computes, now that the processed suggestions were deleted.
+	if _, err := c.examineQueue(ctx); err != nil {
+		log.Errorf(ctx, "failed updating suggestions queued metric %+v", err)
 	}
 
-	return true, nil
+	// Deferred suggestions have to be revisited soon, once their aggregation
+	// window has passed.
+	return !deferred, nil
+}
+
+// shouldDefer returns whether the processing of the aggregated compaction
+// should be left for later because it received a suggestion within the
+// aggregation window, so that suggestions for adjacent key spans can still be
+// added to it. Aggregations which reached the maximum size aren't deferred.
+func (c *Compactor) shouldDefer(aggr aggregatedCompaction) bool {
+	window := c.aggregationWindow()
+	if window == 0 || !c.enabled() || aggr.Bytes >= c.maxAggregatedBytes() {
+		return false
+	}
+	var newest int64
+	for _, sc := range aggr.suggestions {
+		if sc.SuggestedAtNanos > newest {
+			newest = sc.SuggestedAtNanos
+		}
+	}
+	return timeutil.Since(timeutil.Unix(0, newest)) < window
 }
 
 // fetchSuggestions loads the persisted suggested compactions from the store.
@@ -435,8 +516,8 @@ func (c *Compactor) aggregateCompaction(
 	aggr *aggregatedCompaction,
 	sc storagepb.SuggestedCompaction,
 ) (done bool) {
-	// Don't bother aggregating more once we reach threshold bytes.
-	if aggr.Bytes >= c.thresholdBytes() {
+	// Don't bother aggregating more once we reach the maximum size.
+	if aggr.Bytes >= c.maxAggregatedBytes() {
 		return true // suggested compation could not be aggregated
 	}
 
@@ -463,9 +544,9 @@ func (c *Compactor) aggregateCompaction(
 }
 
 // examineQueue returns the total number of bytes queued and updates the
-// BytesQueued gauge.
+// BytesQueued and SuggestionsQueued gauges.
 func (c *Compactor) examineQueue(ctx context.Context) (int64, error) {
-	var totalBytes int64
+	var totalBytes, count int64
 	if err := c.eng.Iterate(
 		engine.MVCCKey{Key: keys.LocalStoreSuggestedCompactionsMin},
 		engine.MVCCKey{Key: keys.LocalStoreSuggestedCompactionsMax},
@@ -475,12 +556,14 @@ func (c *Compactor) examineQueue(ctx context.Context) (int64, error) {
 				return false, err
 			}
 			totalBytes += c.Bytes
+			count++
 			return false, nil // continue iteration
 		},
 	); err != nil {
 		return 0, err
 	}
 	c.Metrics.BytesQueued.Update(totalBytes)
+	c.Metrics.SuggestionsQueued.Update(count)
 	return totalBytes, nil
 }
 
@@ -43,6 +43,7 @@ type wrappedEngine struct {
 	mu struct {
 		syncutil.Mutex
 		compactions []roachpb.Span
+		concurrency int
 	}
 }
 
@@ -84,6 +85,19 @@ func (we *wrappedEngine) CompactRange(start, end roachpb.Key, forceBottommost bo
 	return nil
 }
 
+func (we *wrappedEngine) SetCompactionConcurrency(concurrency int) error {
+	we.mu.Lock()
+	defer we.mu.Unlock()
+	we.mu.concurrency = concurrency
+	return we.RocksDB.SetCompactionConcurrency(concurrency)
+}
+
+func (we *wrappedEngine) GetCompactionConcurrency() int {
+	we.mu.Lock()
+	defer we.mu.Unlock()
+	return we.mu.concurrency
+}
+
 func (we *wrappedEngine) GetCompactions() []roachpb.Span {
 	we.mu.Lock()
 	defer we.mu.Unlock()
@@ -123,6 +137,7 @@ func TestCompactorThresholds(t *testing.T) {
 		expBytesCompacted int64
 		expCompactions    []roachpb.Span
 		expUncompacted    []roachpb.Span
+		aggregationWindow time.Duration
 	}{
 		// Single suggestion under all thresholds.
 		{
@@ -514,6 +529,71 @@ func TestCompactorThresholds(t *testing.T) {
 				{Key: key("a"), EndKey: key("zzz")},
 			},
 		},
+		// Adjacent suggestions over the threshold which aggregate until reaching
+		// the maximum aggregated size, which only applies with an aggregation
+		// window. The window is short enough for none of them to be deferred.
+		{
+			name:              "adjacent suggestions over threshold which aggregate up to max size",
+			aggregationWindow: time.Nanosecond,
+			suggestions: []storagepb.SuggestedCompaction{
+				{
+					StartKey: key("a"), EndKey: key("b"),
+					Compaction: storagepb.Compaction{
+						Bytes:            maxAggregatedBytes.Default() / 2,
+						SuggestedAtNanos: nowNanos,
+					},
+				},
+				{
+					StartKey: key("b"), EndKey: key("c"),
+					Compaction: storagepb.Compaction{
+						Bytes:            maxAggregatedBytes.Default() / 2,
+						SuggestedAtNanos: nowNanos,
+					},
+				},
+				{
+					StartKey: key("c"), EndKey: key("d"),
+					Compaction: storagepb.Compaction{
+						Bytes:            thresholdBytes.Default(),
+						SuggestedAtNanos: nowNanos,
+					},
+				},
+			},
+			logicalBytes:      maxAggregatedBytes.Default() * 100, // not going to trigger fractional threshold
+			availableBytes:    maxAggregatedBytes.Default() * 100, // not going to trigger fractional threshold
+			expBytesCompacted: maxAggregatedBytes.Default() + thresholdBytes.Default(),
+			expCompactions: []roachpb.Span{
+				{Key: key("a"), EndKey: key("c")},
+				{Key: key("c"), EndKey: key("d")},
+			},
+		},
+		// Without an aggregation window, adjacent suggestions stop aggregating
+		// once they reach the threshold.
+		{
+			name: "adjacent suggestions over threshold which aggregate up to threshold",
+			suggestions: []storagepb.SuggestedCompaction{
+				{
+					StartKey: key("a"), EndKey: key("b"),
+					Compaction: storagepb.Compaction{
+						Bytes:            thresholdBytes.Default(),
+						SuggestedAtNanos: nowNanos,
+					},
+				},
+				{
+					StartKey: key("b"), EndKey: key("c"),
+					Compaction: storagepb.Compaction{
+						Bytes:            thresholdBytes.Default(),
+						SuggestedAtNanos: nowNanos,
+					},
+				},
+			},
+			logicalBytes:      maxAggregatedBytes.Default() * 100, // not going to trigger fractional threshold
+			availableBytes:    maxAggregatedBytes.Default() * 100, // not going to trigger fractional threshold
+			expBytesCompacted: 2 * thresholdBytes.Default(),
+			expCompactions: []roachpb.Span{
+				{Key: key("a"), EndKey: key("b")},
+				{Key: key("b"), EndKey: key("c")},
+			},
+		},
 	}
 
 	for _, test := range testCases {
@@ -528,6 +608,7 @@ func TestCompactorThresholds(t *testing.T) {
 			defer cleanup()
 			// Shorten wait times for compactor processing.
 			minInterval.Override(&compactor.st.SV, time.Millisecond)
+			aggregationWindow.Override(&compactor.st.SV, test.aggregationWindow)
 
 			// Add a key so we can test that suggestions that span live data are
 			// ignored.
@@ -716,6 +797,63 @@ func TestCompactorCleansUpOldRecords(t *testing.T) {
 	})
 }
 
+// TestCompactorAggregationWindow verifies that suggestions aren't compacted
+// while they're within the aggregation window, and that suggestions for
+// adjacent spans made within the window are aggregated.
+func TestCompactorAggregationWindow(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	capacityFn := func() (roachpb.StoreCapacity, error) {
+		return roachpb.StoreCapacity{
+			LogicalBytes: 100 * maxAggregatedBytes.Default(),
+			Available:    100 * maxAggregatedBytes.Default(),
+		}, nil
+	}
+	compactor, we, compactionCount, cleanup := testSetup(capacityFn)
+	defer cleanup()
+	minInterval.Override(&compactor.st.SV, time.Millisecond)
+	aggregationWindow.Override(&compactor.st.SV, time.Hour)
+
+	// Suggest a compaction over the threshold. It isn't processed while it's
+	// within the aggregation window.
+	compactor.Suggest(context.Background(), storagepb.SuggestedCompaction{
+		StartKey: key("a"), EndKey: key("b"),
+		Compaction: storagepb.Compaction{
+			Bytes:            thresholdBytes.Default(),
+			SuggestedAtNanos: timeutil.Now().UnixNano(),
+		},
+	})
+	time.Sleep(10 * time.Millisecond)
+	if comps := we.GetCompactions(); len(comps) != 0 {
+		t.Fatalf("expected no compactions within the aggregation window; got %+v", comps)
+	}
+
+	// An adjacent suggestion joins the aggregation, which is processed once the
+	// window has passed.
+	compactor.Suggest(context.Background(), storagepb.SuggestedCompaction{
+		StartKey: key("b"), EndKey: key("c"),
+		Compaction: storagepb.Compaction{
+			Bytes:            thresholdBytes.Default(),
+			SuggestedAtNanos: timeutil.Now().UnixNano(),
+		},
+	})
+	aggregationWindow.Override(&compactor.st.SV, time.Millisecond)
+	testutils.SucceedsSoon(t, func() error {
+		comps := we.GetCompactions()
+		expComps := []roachpb.Span{{Key: key("a"), EndKey: key("c")}}
+		if !reflect.DeepEqual(expComps, comps) {
+			return fmt.Errorf("expected %+v; got %+v", expComps, comps)
+		}
+		if a, e := compactor.Metrics.BytesCompacted.Count(), 2*thresholdBytes.Default(); a != e {
+			return fmt.Errorf("expected bytes compacted %d; got %d", e, a)
+		}
+		if a, e := atomic.LoadInt32(compactionCount), int32(1); a != e {
+			return fmt.Errorf("expected %d; got %d", e, a)
+		}
+		return nil
+	})
+}
+
 // TestCompactorDisabled that a disabled compactor throws away past and future
 // suggestions.
 func TestCompactorDisabled(t *testing.T) {
@@ -782,3 +920,56 @@ func TestCompactorDisabled(t *testing.T) {
 		return nil
 	})
 }
+
+// TestCompactorBackgroundConcurrency verifies that the compactor applies the
+// background compaction concurrency setting to its engine.
+func TestCompactorBackgroundConcurrency(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	capacityFn := func() (roachpb.StoreCapacity, error) {
+		return roachpb.StoreCapacity{}, nil
+	}
+	compactor, we, _, cleanup := testSetup(capacityFn)
+	defer cleanup()
+
+	if a := we.GetCompactionConcurrency(); a != 0 {
+		t.Fatalf("expected the default concurrency to be left alone; got %d", a)
+	}
+	for _, concurrency := range []int{1, 8, 0} {
+		backgroundConcurrency.Override(&compactor.st.SV, int64(concurrency))
+		if a, e := we.GetCompactionConcurrency(), concurrency; a != e {
+			t.Fatalf("expected concurrency %d; got %d", e, a)
+		}
+	}
+}
+
+// TestCompactorSuggestionsQueued verifies that the number of queued
+// suggestions is tracked.
+func TestCompactorSuggestionsQueued(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	capacityFn := func() (roachpb.StoreCapacity, error) {
+		return roachpb.StoreCapacity{LogicalBytes: 100 * thresholdBytes.Default()}, nil
+	}
+	compactor, _, _, cleanup := testSetup(capacityFn)
+	defer cleanup()
+
+	// Make sure the suggestions aren't processed while the test runs.
+	minInterval.Override(&compactor.st.SV, time.Hour)
+	for _, k := range []string{"a", "c", "e"} {
+		compactor.Suggest(context.Background(), storagepb.SuggestedCompaction{
+			StartKey: key(k), EndKey: key(k).Next(),
+			Compaction: storagepb.Compaction{
+				Bytes:            1,
+				SuggestedAtNanos: timeutil.Now().UnixNano(),
+			},
+		})
+	}
+
+	testutils.SucceedsSoon(t, func() error {
+		if a, e := compactor.Metrics.SuggestionsQueued.Value(), int64(3); a != e {
+			return fmt.Errorf("expected %d queued suggestions; got %d", e, a)
+		}
+		return nil
+	})
+}
@@ -19,6 +19,7 @@ import "github.com/cockroachdb/cockroach/pkg/util/metric"
 // Metrics holds all metrics relating to a Compactor.
 type Metrics struct {
 	BytesQueued         *metric.Gauge
+	SuggestionsQueued   *metric.Gauge
 	BytesSkipped        *metric.Counter
 	BytesCompacted      *metric.Counter
 	CompactionSuccesses *metric.Counter
@@ -38,6 +39,12 @@ var (
 		Measurement: "Logical Bytes",
 		Unit:        metric.Unit_BYTES,
 	}
+	metaSuggestionsQueued = metric.Metadata{
+		Name:        "compactor.suggestions.queued",
+		Help:        "Number of suggested compactions in the queue",
+		Measurement: "Suggested Compactions",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaBytesSkipped = metric.Metadata{
 		Name:        "compactor.suggestionbytes.skipped",
 		Help:        "Number of logical bytes in suggested compactions which were not compacted",
@@ -74,6 +81,7 @@ var (
 func makeMetrics() Metrics {
 	return Metrics{
 		BytesQueued:         metric.NewGauge(metaBytesQueued),
+		SuggestionsQueued:   metric.NewGauge(metaSuggestionsQueued),
 		BytesSkipped:        metric.NewCounter(metaBytesSkipped),
 		BytesCompacted:      metric.NewCounter(metaBytesCompacted),
 		CompactionSuccesses: metric.NewCounter(metaCompactionSuccesses),
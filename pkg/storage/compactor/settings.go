@@ -102,6 +102,38 @@ var thresholdBytesAvailableFraction = func() *settings.FloatSetting {
 	return s
 }()
 
+// maxAggregatedBytes is the expected logical space reclamation at which the
+// compactor stops merging adjacent suggestions into an aggregated compaction.
+// Suggestions for the ranges of a dropped table are aggregated into large
+// compaction spans, which allows the storage engine to drop whole SSTables,
+// but a single compaction shouldn't grow without bound either. It is never
+// lower than thresholdBytes, and only applies with a non-zero
+// aggregationWindow.
+var maxAggregatedBytes = func() *settings.ByteSizeSetting {
+	s := settings.RegisterByteSizeSetting(
+		"compactor.max_aggregated_bytes",
+		"expected logical space reclamation at which adjacent suggestions stop being aggregated into a single compaction (only used with a non-zero compactor.aggregation_window)",
+		2<<30, // 2GiB
+	)
+	s.SetSensitive()
+	return s
+}()
+
+// aggregationWindow is the period of time during which an aggregated
+// compaction that received a new suggestion is held back, waiting for
+// suggestions for adjacent ranges. Dropping a large table clears its ranges
+// over a period of time, and waiting for all of them results in fewer, larger
+// compactions that reclaim space faster.
+var aggregationWindow = func() *settings.DurationSetting {
+	s := settings.RegisterNonNegativeDurationSetting(
+		"compactor.aggregation_window",
+		"amount of time to wait for suggestions for adjacent key spans before compacting an aggregated suggestion (zero to disable)",
+		0,
+	)
+	s.SetSensitive()
+	return s
+}()
+
 // maxSuggestedCompactionRecordAge is the maximum age of a
 // suggested compaction record. If not processed within this time
 // interval since the compaction was suggested, it will be deleted.
@@ -114,3 +146,22 @@ var maxSuggestedCompactionRecordAge = func() *settings.DurationSetting {
 	s.SetSensitive()
 	return s
 }()
+
+// backgroundConcurrency is the maximum number of background compactions the
+// storage engine runs concurrently. Operators can lower it to reduce the IO
+// impact of compactions on foreground traffic, accepting higher read
+// amplification while compactions fall behind, or raise it to let compactions
+// catch up faster. The compactor applies it to its engine.
+//
+// The engine's background thread pool is sized once at startup by
+// COCKROACH_ROCKSDB_CONCURRENCY, which defaults to the number of CPUs, up to
+// 4. That is also the default of this setting, and higher values are clamped
+// to it because there wouldn't be threads to run the additional compactions.
+var backgroundConcurrency = settings.RegisterNonNegativeIntSetting(
+	"compactor.background_concurrency",
+	"maximum number of concurrent background compactions run by the storage engine; "+
+		"lower values reduce the IO impact of compactions on foreground traffic "+
+		"at the cost of higher read amplification (zero for the default, which is also "+
+		"the maximum: the number of CPUs up to 4, or COCKROACH_ROCKSDB_CONCURRENCY if set)",
+	0,
+)
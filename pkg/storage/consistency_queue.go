@@ -20,12 +20,17 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/config"
 	"github.com/cockroachdb/cockroach/pkg/gossip"
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/util/envutil"
 	"github.com/cockroachdb/cockroach/pkg/util/grpcutil"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/pkg/errors"
 )
 
 var consistencyCheckInterval = settings.RegisterNonNegativeDurationSetting(
@@ -34,12 +39,63 @@ var consistencyCheckInterval = settings.RegisterNonNegativeDurationSetting(
 	24*time.Hour,
 )
 
+// consistencyCheckMaxConcurrencyLimit is the largest value accepted for
+// server.consistency_check.max_concurrency.
+const consistencyCheckMaxConcurrencyLimit = 16
+
+var consistencyCheckMaxConcurrency = settings.RegisterValidatedIntSetting(
+	"server.consistency_check.max_concurrency",
+	"the maximum number of consistency checks run concurrently by the consistency checker queue of each store",
+	1,
+	func(v int64) error {
+		if v < 1 || v > consistencyCheckMaxConcurrencyLimit {
+			return errors.Errorf("value must be between 1 and %d", consistencyCheckMaxConcurrencyLimit)
+		}
+		return nil
+	},
+)
+
+// consistencyCheckHashShardsLimit is the largest value accepted for
+// server.consistency_check.hash_shards.
+const consistencyCheckHashShardsLimit = 64
+
+var consistencyCheckHashShards = settings.RegisterValidatedIntSetting(
+	"server.consistency_check.hash_shards",
+	"the number of disjoint key spans the data of a range is divided into to be hashed concurrently "+
+		"by a consistency check; 1 hashes the data sequentially",
+	1,
+	func(v int64) error {
+		if v < 1 || v > consistencyCheckHashShardsLimit {
+			return errors.Errorf("value must be between 1 and %d", consistencyCheckHashShardsLimit)
+		}
+		return nil
+	},
+)
+
+var consistencyCheckDelay = settings.RegisterNonNegativeDurationSetting(
+	"server.consistency_check.delay",
+	"the minimum time between consecutive consistency checks started by each store",
+	0,
+)
+
+// consistencyCheckMaxDuration is the largest check duration tracked by the
+// consistency check duration histogram.
+const consistencyCheckMaxDuration = 10 * time.Minute
+
 var testingAggressiveConsistencyChecks = envutil.EnvOrDefaultBool("COCKROACH_CONSISTENCY_AGGRESSIVE", false)
 
 type consistencyQueue struct {
 	*baseQueue
 	interval       func() time.Duration
+	delay          func() time.Duration
 	replicaCountFn func() int
+
+	mu struct {
+		syncutil.Mutex
+		// lockTableMigrated contains the ranges whose lock table was backfilled
+		// by this queue since the store started (see maybeMigrateLockTable).
+		lockTableMigrated map[roachpb.RangeID]struct{}
+	}
 }
 
 // newConsistencyQueue returns a new instance of consistencyQueue.
@@ -48,12 +104,20 @@ func newConsistencyQueue(store *Store, gossip *gossip.Gossip) *consistencyQueue
 		interval: func() time.Duration {
 			return consistencyCheckInterval.Get(&store.ClusterSettings().SV)
 		},
+		delay: func() time.Duration {
+			return consistencyCheckDelay.Get(&store.ClusterSettings().SV)
+		},
 		replicaCountFn: store.ReplicaCount,
 	}
+	q.mu.lockTableMigrated = make(map[roachpb.RangeID]struct{})
 	q.baseQueue = newBaseQueue(
 		"consistencyChecker", q, store, gossip,
 		queueConfig{
-			maxSize:              defaultQueueMaxSize,
+			maxSize:        defaultQueueMaxSize,
+			maxConcurrency: consistencyCheckMaxConcurrencyLimit,
+			concurrencyFn: func() int {
+				return int(consistencyCheckMaxConcurrency.Get(&store.ClusterSettings().SV))
+			},
 			needsLease:           true,
 			needsSystemConfig:    false,
 			acceptsUnsplitRanges: true,
@@ -113,6 +177,10 @@ func (q *consistencyQueue) process(
 		log.VErrEventf(ctx, 2, "failed to update last processed time: %v", err)
 	}
 
+	if err := q.maybeMigrateLockTable(ctx, repl); err != nil {
+		log.Warningf(ctx, "failed to migrate the lock table: %s", err)
+	}
+
 	req := roachpb.CheckConsistencyRequest{
 		// Tell CheckConsistency that the caller is the queue. This triggers
 		// code to handle inconsistencies by recomputing with a diff and exiting
@@ -122,7 +190,9 @@ func (q *consistencyQueue) process(
 		// into the queue in the future.
 		Mode: roachpb.ChecksumMode_CHECK_VIA_QUEUE,
 	}
+	start := timeutil.Now()
 	resp, pErr := repl.CheckConsistency(ctx, req)
+	repl.store.metrics.ConsistencyQueueCheckDuration.RecordValue(timeutil.Since(start).Nanoseconds())
 	if pErr != nil {
 		var shouldQuiesce bool
 		select {
@@ -145,16 +215,49 @@ func (q *consistencyQueue) process(
 	return nil
 }
 
+// maybeMigrateLockTable backfills the lock table entries of the intents that
+// were laid down in the range before cluster.VersionLockTable became active.
+// This is done once per range, paced by the consistency checker, since the
+// backfill blocks all other writes to the range while it scans it. The ranges
+// that were migrated are only tracked in memory: backfilling is idempotent, so
+// after a restart the backfill of each range is simply redone once.
+func (q *consistencyQueue) maybeMigrateLockTable(ctx context.Context, repl *Replica) error {
+	if !repl.store.ClusterSettings().Version.IsActive(cluster.VersionLockTable) {
+		return nil
+	}
+	q.mu.Lock()
+	_, migrated := q.mu.lockTableMigrated[repl.RangeID]
+	q.mu.Unlock()
+	if migrated {
+		return nil
+	}
+
+	var b client.Batch
+	b.AddRawRequest(&roachpb.RecomputeStatsRequest{
+		RequestHeader:    roachpb.RequestHeader{Key: repl.Desc().StartKey.AsRawKey()},
+		MigrateLockTable: true,
+	})
+	if err := repl.store.db.Run(ctx, &b); err != nil {
+		return err
+	}
+
+	q.mu.Lock()
+	q.mu.lockTableMigrated[repl.RangeID] = struct{}{}
+	q.mu.Unlock()
+	return nil
+}
+
 func (q *consistencyQueue) timer(duration time.Duration) time.Duration {
 	// An interval between replicas to space consistency checks out over
-	// the check interval.
+	// the check interval, but no shorter than the configured delay.
+	delay := q.delay()
 	replicaCount := q.replicaCountFn()
 	if replicaCount == 0 {
-		return 0
+		return delay
 	}
 	replInterval := q.interval() / time.Duration(replicaCount)
-	if replInterval < duration {
-		return 0
+	if replInterval < duration+delay {
+		return delay
 	}
 	return replInterval - duration
 }
@@ -354,6 +354,48 @@ func TestCheckConsistencyInconsistent(t *testing.T) {
 	assert.Equal(t, roachpb.CheckConsistencyResponse_RANGE_INCONSISTENT, resp.Result[0].Status)
 	assert.Contains(t, resp.Result[0].Detail, `is inconsistent`)
 	assert.Contains(t, resp.Result[0].Detail, `persisted stats`)
+
+	// The leaseholder collected a triage bundle, and the followers didn't.
+	bundles, err := mtc.stores[0].ConsistencyTriageBundles()
+	assert.NoError(t, err)
+	assert.Len(t, bundles, 1)
+	assert.Equal(t, roachpb.RangeID(1), bundles[0].RangeID)
+	for _, file := range []string{"report.txt", "diff.txt", "descriptors.txt", "raft_log.txt", "checkpoints.txt"} {
+		b, err := mtc.engines[0].ReadFile(filepath.Join(bundles[0].Dir, file))
+		assert.NoError(t, err)
+		assert.NotEmpty(t, b, file)
+	}
+	diff, err := mtc.engines[0].ReadFile(filepath.Join(bundles[0].Dir, "diff.txt"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(diff), `"e"`)
+	for i := 1; i < numStores; i++ {
+		bundles, err := mtc.stores[i].ConsistencyTriageBundles()
+		assert.NoError(t, err)
+		assert.Empty(t, bundles)
+	}
+
+	// A full check requesting a diff returns it in structured form.
+	diffArgs := roachpb.CheckConsistencyRequest{
+		RequestHeader: roachpb.RequestHeader{
+			Key:    []byte("a"),
+			EndKey: []byte("z"),
+		},
+		Mode:     roachpb.ChecksumMode_CHECK_FULL,
+		WithDiff: true,
+	}
+	diffResp, pErr := client.SendWrapped(context.Background(), mtc.stores[0].TestSender(), &diffArgs)
+	if pErr != nil {
+		t.Fatal(pErr)
+	}
+	<-notifyReportDiff
+	result := diffResp.(*roachpb.CheckConsistencyResponse).Result
+	assert.Len(t, result, 1)
+	assert.Len(t, result[0].Diff, 1)
+	d := result[0].Diff[0]
+	assert.Equal(t, mtc.stores[1].StoreID(), d.Replica.StoreID)
+	assert.False(t, d.LeaseHolder)
+	assert.Equal(t, roachpb.Key(diffKey), d.Key)
+	assert.Equal(t, diffTimestamp, d.Timestamp)
 }
 
 // TestConsistencyQueueRecomputeStats is an end-to-end test of the mechanism CockroachDB
@@ -0,0 +1,252 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/pkg/errors"
+	"go.etcd.io/etcd/raft/raftpb"
+)
+
+const (
+	// consistencyTriageDirName is the name of the directory in the auxiliary
+	// directory of a store which holds the triage bundles of the consistency
+	// check failures found by the store.
+	consistencyTriageDirName = "consistency-triage"
+	// consistencyTriageIndexFileName is the name of the file in the triage
+	// directory which lists the bundles, one per line.
+	consistencyTriageIndexFileName = "index"
+	// consistencyTriageRaftLogEntries is the number of the most recent Raft
+	// log entries summarized in a triage bundle.
+	consistencyTriageRaftLogEntries = 1000
+)
+
+// ConsistencyTriageBundle describes a triage bundle collected by a store when
+// the consistency checker found a range's replicas to be inconsistent.
+type ConsistencyTriageBundle struct {
+	RangeID roachpb.RangeID
+	// Dir is the directory holding the files of the bundle.
+	Dir string
+}
+
+// writeConsistencyTriageBundle collects the information needed to triage an
+// inconsistency of the range into a new directory under the store's auxiliary
+// directory, so that it survives the node being terminated. The bundle
+// consists of:
+//
+// - report.txt: the result of the consistency check.
+// - diff.txt: the keys in which the replicas diverge.
+// - descriptors.txt: the versions of the range descriptor which haven't been
+//   garbage collected, that is the recent history of the range.
+// - raft_log.txt: a summary of the most recent entries of the Raft log.
+// - checkpoints.txt: where to find the checkpoints taken by each replica.
+//
+// It returns the directory of the bundle.
+func (r *Replica) writeConsistencyTriageBundle(
+	ctx context.Context, report, diff string,
+) (string, error) {
+	eng := r.store.engine
+	base := filepath.Join(eng.GetAuxiliaryDir(), consistencyTriageDirName)
+	name := fmt.Sprintf("r%d_%s", r.RangeID, timeutil.Now().Format("20060102T150405.000"))
+	dir := filepath.Join(base, name)
+	if err := eng.MkdirAll(dir); err != nil {
+		return "", err
+	}
+
+	desc := r.Desc()
+	files := []struct {
+		name string
+		fn   func(*bytes.Buffer) error
+	}{
+		{"report.txt", func(buf *bytes.Buffer) error {
+			buf.WriteString(report)
+			return nil
+		}},
+		{"diff.txt", func(buf *bytes.Buffer) error {
+			buf.WriteString(diff)
+			return nil
+		}},
+		{"descriptors.txt", func(buf *bytes.Buffer) error {
+			return writeRangeDescriptorHistory(buf, eng, desc.StartKey)
+		}},
+		{"raft_log.txt", func(buf *bytes.Buffer) error {
+			return r.writeRaftLogSummary(ctx, buf)
+		}},
+		{"checkpoints.txt", func(buf *bytes.Buffer) error {
+			fmt.Fprintf(buf, "each replica of r%d took a checkpoint of the range's data named "+
+				"r%d_at_<applied index> in the checkpoints directory of its store's auxiliary directory; "+
+				"on this store: %s\n",
+				r.RangeID, r.RangeID, filepath.Join(eng.GetAuxiliaryDir(), "checkpoints"))
+			for _, replica := range desc.Replicas().Unwrap() {
+				fmt.Fprintf(buf, "%s\n", replica)
+			}
+			return nil
+		}},
+	}
+	for _, f := range files {
+		var buf bytes.Buffer
+		if err := f.fn(&buf); err != nil {
+			// Collect as much as possible.
+			fmt.Fprintf(&buf, "\nerror: %s\n", err)
+		}
+		if err := eng.WriteFile(filepath.Join(dir, f.name), buf.Bytes()); err != nil {
+			return "", err
+		}
+	}
+
+	r.store.consistencyTriageMu.Lock()
+	defer r.store.consistencyTriageMu.Unlock()
+	indexFile := filepath.Join(base, consistencyTriageIndexFileName)
+	index, err := eng.ReadFile(indexFile)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	index = append(index, fmt.Sprintf("%d %s\n", r.RangeID, name)...)
+	if err := eng.WriteFile(indexFile, index); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// writeRangeDescriptorHistory writes all versions of the descriptor of the
+// range starting at the given key.
+func writeRangeDescriptorHistory(buf *bytes.Buffer, reader engine.Reader, startKey roachpb.RKey) error {
+	descKey := keys.RangeDescriptorKey(startKey)
+	iter := reader.NewIterator(engine.IterOptions{UpperBound: descKey.Next()})
+	defer iter.Close()
+	for iter.Seek(engine.MakeMVCCMetadataKey(descKey)); ; iter.Next() {
+		if ok, err := iter.Valid(); err != nil {
+			return err
+		} else if !ok {
+			return nil
+		}
+		key := iter.UnsafeKey()
+		if !key.IsValue() {
+			// An intent, whose provisional value is written at its timestamp.
+			fmt.Fprintf(buf, "intent on %s\n", key.Key)
+			continue
+		}
+		value := roachpb.Value{RawBytes: iter.UnsafeValue()}
+		if len(value.RawBytes) == 0 {
+			fmt.Fprintf(buf, "%s: deleted\n", key.Timestamp)
+			continue
+		}
+		var desc roachpb.RangeDescriptor
+		if err := value.GetProto(&desc); err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "%s: %s\n", key.Timestamp, &desc)
+	}
+}
+
+// writeRaftLogSummary writes a line for each of the most recent entries of
+// the replica's Raft log.
+func (r *Replica) writeRaftLogSummary(ctx context.Context, buf *bytes.Buffer) error {
+	r.mu.Lock()
+	lastIndex := r.mu.lastIndex
+	r.mu.Unlock()
+	lo := uint64(1)
+	if lastIndex > consistencyTriageRaftLogEntries {
+		lo = lastIndex - consistencyTriageRaftLogEntries + 1
+	}
+	var ent raftpb.Entry
+	return iterateEntries(ctx, r.store.engine, r.RangeID, lo, lastIndex+1,
+		func(kv roachpb.KeyValue) (bool, error) {
+			if err := kv.Value.GetProto(&ent); err != nil {
+				return false, err
+			}
+			fmt.Fprintf(buf, "%d/%d: %s\n", ent.Term, ent.Index, summarizeRaftLogEntry(&ent))
+			return false, nil
+		})
+}
+
+// summarizeRaftLogEntry returns a one-line description of a Raft log entry.
+func summarizeRaftLogEntry(ent *raftpb.Entry) string {
+	if ent.Type != raftpb.EntryNormal {
+		return ent.Type.String()
+	}
+	if len(ent.Data) == 0 {
+		return "empty entry"
+	}
+	cmdID, data := DecodeRaftCommand(ent.Data)
+	var cmd storagepb.RaftCommand
+	if err := protoutil.Unmarshal(data, &cmd); err != nil {
+		return fmt.Sprintf("command %x: %s", cmdID, err)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "command %x proposed by %s under lease %d, max lease index %d",
+		cmdID, cmd.ProposerReplica, cmd.ProposerLeaseSequence, cmd.MaxLeaseIndex)
+	if cmd.WriteBatch != nil {
+		fmt.Fprintf(&b, ", %d byte write batch", len(cmd.WriteBatch.Data))
+	}
+	res := &cmd.ReplicatedEvalResult
+	fmt.Fprintf(&b, ", stats delta %+v", res.Delta)
+	switch {
+	case res.Split != nil:
+		fmt.Fprintf(&b, ", split into %s and %s", &res.Split.LeftDesc, &res.Split.RightDesc)
+	case res.Merge != nil:
+		fmt.Fprintf(&b, ", merge of %s", &res.Merge.RightDesc)
+	case res.ChangeReplicas != nil:
+		fmt.Fprintf(&b, ", %s", res.ChangeReplicas)
+	case res.IsLeaseRequest && res.State != nil && res.State.Lease != nil:
+		fmt.Fprintf(&b, ", lease %s", res.State.Lease)
+	case res.ComputeChecksum != nil:
+		fmt.Fprintf(&b, ", compute checksum %s", res.ComputeChecksum.ChecksumID)
+	}
+	return b.String()
+}
+
+// ConsistencyTriageBundles returns the triage bundles collected by the store,
+// oldest first.
+func (s *Store) ConsistencyTriageBundles() ([]ConsistencyTriageBundle, error) {
+	s.consistencyTriageMu.Lock()
+	defer s.consistencyTriageMu.Unlock()
+	base := filepath.Join(s.engine.GetAuxiliaryDir(), consistencyTriageDirName)
+	index, err := s.engine.ReadFile(filepath.Join(base, consistencyTriageIndexFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var bundles []ConsistencyTriageBundle
+	for _, line := range strings.Split(strings.TrimSpace(string(index)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, errors.Errorf("malformed consistency triage index entry %q", line)
+		}
+		rangeID, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "malformed consistency triage index entry %q", line)
+		}
+		bundles = append(bundles, ConsistencyTriageBundle{
+			RangeID: roachpb.RangeID(rangeID),
+			Dir:     filepath.Join(base, fields[1]),
+		})
+	}
+	return bundles, nil
+}
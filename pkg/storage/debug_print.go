@@ -165,6 +165,78 @@ func decodeWriteBatch(writeBatch *storagepb.WriteBatch) (string, error) {
 	return sb.String(), r.Error()
 }
 
+// summarizeWriteBatch returns the number of writes of each type in the write
+// batch, the span of keys they touch and the size of the batch.
+func summarizeWriteBatch(writeBatch *storagepb.WriteBatch) string {
+	if writeBatch == nil {
+		return "<nil>"
+	}
+
+	r, err := engine.NewRocksDBBatchReader(writeBatch.Data)
+	if err != nil {
+		return "failed to decode: " + err.Error()
+	}
+
+	var puts, merges, deletes, singleDeletes, rangeDeletes, unknown int
+	var minKey, maxKey roachpb.Key
+	track := func(key roachpb.Key) {
+		if minKey == nil || key.Compare(minKey) < 0 {
+			minKey = append(roachpb.Key(nil), key...)
+		}
+		if maxKey == nil || key.Compare(maxKey) > 0 {
+			maxKey = append(roachpb.Key(nil), key...)
+		}
+	}
+	for r.Next() {
+		switch r.BatchType() {
+		case engine.BatchTypeValue:
+			puts++
+		case engine.BatchTypeMerge:
+			merges++
+		case engine.BatchTypeDeletion:
+			deletes++
+		case engine.BatchTypeSingleDeletion:
+			singleDeletes++
+		case engine.BatchTypeRangeDeletion:
+			rangeDeletes++
+			if mvccEndKey, err := r.MVCCEndKey(); err == nil {
+				track(mvccEndKey.Key)
+			}
+		default:
+			unknown++
+			continue
+		}
+		if mvccKey, err := r.MVCCKey(); err == nil {
+			track(mvccKey.Key)
+		}
+	}
+
+	var sb strings.Builder
+	for _, op := range []struct {
+		name  string
+		count int
+	}{
+		{"puts", puts},
+		{"merges", merges},
+		{"deletes", deletes},
+		{"single_deletes", singleDeletes},
+		{"range_deletes", rangeDeletes},
+		{"unknown", unknown},
+	} {
+		if op.count > 0 {
+			fmt.Fprintf(&sb, "%s=%d ", op.name, op.count)
+		}
+	}
+	if minKey != nil {
+		fmt.Fprintf(&sb, "keys=[%s, %s] ", minKey, maxKey)
+	}
+	fmt.Fprintf(&sb, "bytes=%d", len(writeBatch.Data))
+	if err := r.Error(); err != nil {
+		fmt.Fprintf(&sb, " error=%q", err)
+	}
+	return sb.String()
+}
+
 func tryRaftLogEntry(kv engine.MVCCKeyValue) (string, error) {
 	var ent raftpb.Entry
 	if err := maybeUnmarshalInline(kv.Value, &ent); err != nil {
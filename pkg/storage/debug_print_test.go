@@ -15,6 +15,7 @@
 package storage
 
 import (
+	"fmt"
 	"math"
 	"testing"
 
@@ -45,3 +46,23 @@ func TestStringifyWriteBatch(t *testing.T) {
 		t.Errorf("expected %q for stringified write batch; got %q", expStr, str)
 	}
 }
+
+func TestSummarizeWriteBatch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	if str, expStr := summarizeWriteBatch(nil), "<nil>"; str != expStr {
+		t.Errorf("expected %q for summarized write batch; got %q", expStr, str)
+	}
+
+	builder := engine.RocksDBBatchBuilder{}
+	ts := hlc.Timestamp{WallTime: 1}
+	builder.Put(engine.MVCCKey{Key: roachpb.Key("b"), Timestamp: ts}, []byte("value"))
+	builder.Put(engine.MVCCKey{Key: roachpb.Key("c"), Timestamp: ts}, []byte("value"))
+	builder.Clear(engine.MVCCKey{Key: roachpb.Key("a")})
+	wb := storagepb.WriteBatch{Data: builder.Finish()}
+	expStr := fmt.Sprintf("puts=2 deletes=1 keys=[%s, %s] bytes=%d",
+		roachpb.Key("a"), roachpb.Key("c"), len(wb.Data))
+	if str := summarizeWriteBatch(&wb); str != expStr {
+		t.Errorf("expected %q for summarized write batch; got %q", expStr, str)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/elastic/gosigar"
+	"github.com/pkg/errors"
+)
+
+// BallastFileName is the name of the ballast file in a store's auxiliary
+// directory. The ballast reserves disk space which an operator can reclaim by
+// deleting the file when the disk has filled up, to give the node room to
+// start and shed data. It is recreated at the next start if space permits.
+const BallastFileName = "EMERGENCY_BALLAST"
+
+// maxBallastFraction caps the size of the ballast at this fraction of the
+// total size of the file system.
+const maxBallastFraction = 0.01
+
+// EnsureBallast creates a ballast file of the given size at path if it does not
+// exist or is smaller. The size is capped at 1% of the file system's total
+// size, and no ballast is created if less than twice its size is available, so
+// as not to fill up an already nearly full disk. It returns whether the file
+// was (re)written.
+func EnsureBallast(path string, size int64) (bool, error) {
+	fsu := gosigar.FileSystemUsage{}
+	if err := fsu.Get(filepath.Dir(path)); err != nil {
+		return false, err
+	}
+	if max := int64(float64(fsu.Total) * maxBallastFraction); size > max {
+		size = max
+	}
+	if size <= 0 {
+		return false, nil
+	}
+
+	existing := int64(0)
+	if fi, err := os.Stat(path); err == nil {
+		existing = fi.Size()
+	} else if !os.IsNotExist(err) {
+		return false, err
+	}
+	if existing >= size || int64(fsu.Avail)+existing < 2*size {
+		return false, nil
+	}
+
+	// Write the ballast out in full rather than truncating the file to its
+	// size, which would create a sparse file that doesn't reserve any space.
+	tmpPath := path + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return false, err
+	}
+	buf := make([]byte, 1<<20)
+	for remaining := size; remaining > 0; remaining -= int64(len(buf)) {
+		if remaining < int64(len(buf)) {
+			buf = buf[:remaining]
+		}
+		if _, err := f.Write(buf); err != nil {
+			_ = f.Close()
+			_ = os.Remove(tmpPath)
+			return false, errors.Wrap(err, "writing ballast")
+		}
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmpPath)
+		return false, err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return false, err
+	}
+	return true, os.Rename(tmpPath, path)
+}
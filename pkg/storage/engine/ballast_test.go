@@ -0,0 +1,69 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package engine
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestEnsureBallast(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+	path := filepath.Join(dir, BallastFileName)
+
+	checkSize := func(exp int64) {
+		t.Helper()
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Size() != exp {
+			t.Fatalf("expected ballast of %d bytes, found %d", exp, fi.Size())
+		}
+	}
+
+	const size = 3<<20 + 17
+	if created, err := EnsureBallast(path, size); err != nil {
+		t.Fatal(err)
+	} else if !created {
+		t.Fatal("expected ballast to be created")
+	}
+	checkSize(size)
+
+	// An existing ballast of sufficient size is left alone.
+	if created, err := EnsureBallast(path, size-1); err != nil {
+		t.Fatal(err)
+	} else if created {
+		t.Fatal("expected existing ballast to be kept")
+	}
+	checkSize(size)
+
+	// A deleted ballast is recreated.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if created, err := EnsureBallast(path, size); err != nil {
+		t.Fatal(err)
+	} else if !created {
+		t.Fatal("expected ballast to be recreated")
+	}
+	checkSize(size)
+}
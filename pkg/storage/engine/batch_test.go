@@ -756,6 +756,45 @@ func TestBatchConcurrency(t *testing.T) {
 	}
 }
 
+// TestBatchCommitNoSyncWait verifies that the writes of a batch committed
+// with CommitNoSyncWait are visible before SyncWait is called.
+func TestBatchCommitNoSyncWait(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	e := NewInMem(roachpb.Attributes{}, 1<<20)
+	stopper.AddCloser(e)
+
+	// An empty batch takes the fast path.
+	b := e.NewWriteOnlyBatch()
+	if err := b.CommitNoSyncWait(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SyncWait(); err != nil {
+		t.Fatal(err)
+	}
+	b.Close()
+
+	b = e.NewWriteOnlyBatch()
+	defer b.Close()
+	if err := b.Put(mvccKey("a"), []byte("value")); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.CommitNoSyncWait(); err != nil {
+		t.Fatal(err)
+	}
+	val, err := e.Get(mvccKey("a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(val, []byte("value")) {
+		t.Fatalf("expected %q, got %q", "value", val)
+	}
+	if err := b.SyncWait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestBatchBuilder(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
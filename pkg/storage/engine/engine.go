@@ -269,6 +269,11 @@ type Writer interface {
 	// details to the writer, if it has logical op logging enabled. For most
 	// Writer implementations, this is a no-op.
 	LogLogicalOp(op MVCCLogicalOpType, details MVCCLogicalOpDetails)
+	// LockTableEnabled returns whether the intents written and resolved
+	// through the writer maintain their lock table entries. This is only the
+	// case once all nodes in the cluster know about the lock table (see
+	// cluster.VersionLockTable).
+	LockTableEnabled() bool
 }
 
 // ReadWriter is the read/write interface to an engine's data.
@@ -277,9 +282,60 @@ type ReadWriter interface {
 	Writer
 }
 
+// FS is the interface to the auxiliary files an engine keeps next to its data,
+// such as sideloaded SSTables, SSTables staged for ingestion and the
+// directories holding checkpoints. These files must be accessed through the
+// engine rather than the os package, so that they are subject to the same
+// environment as the engine's data files (for example, encryption at rest for
+// RocksDB, or memory for in-memory engines).
+type FS interface {
+	// OpenFile creates a DBFile with the given filename, truncating the file
+	// if it already exists. The data appended to the file is only durable after
+	// a call to its Sync method. If the parent directory doesn't exist,
+	// returns os.ErrNotExist.
+	OpenFile(filename string) (DBFile, error)
+	// ReadFile reads the content from the file with the given filename. If the
+	// file doesn't exist, returns os.ErrNotExist.
+	ReadFile(filename string) ([]byte, error)
+	// WriteFile writes data to the file with the given filename, truncating it
+	// if it already exists. The data is synced before WriteFile returns.
+	WriteFile(filename string, data []byte) error
+	// DeleteFile deletes the file with the given filename. If the file with
+	// given filename doesn't exist, return os.ErrNotExist.
+	DeleteFile(filename string) error
+	// DeleteDirAndFiles deletes the directory and any files it contains but
+	// not subdirectories. If dir does not exist, DeleteDirAndFiles returns nil
+	// (no error).
+	DeleteDirAndFiles(dir string) error
+	// LinkFile creates 'newname' as a hard link to 'oldname'. For RocksDB, this
+	// means using the Env responsible for the file which may handle extra logic
+	// (eg: copy encryption settings for EncryptedEnv).
+	LinkFile(oldname, newname string) error
+	// MkdirAll creates the directory with the given name along with any
+	// missing parents. It is not an error for the directory to exist already.
+	MkdirAll(dir string) error
+	// RenameFile renames the file or directory 'oldname' to 'newname',
+	// replacing a file at 'newname' if it exists. If 'oldname' doesn't exist,
+	// returns os.ErrNotExist.
+	RenameFile(oldname, newname string) error
+	// DeleteDir deletes the given directory, which must be empty. If dir
+	// doesn't exist, returns os.ErrNotExist.
+	DeleteDir(dir string) error
+	// ListDir returns the names of the files and directories in the given
+	// directory. If dir doesn't exist, ListDir returns no names (no error).
+	ListDir(dir string) ([]string, error)
+	// FileSize returns the size of the file with the given filename. If the
+	// file doesn't exist, returns os.ErrNotExist.
+	FileSize(filename string) (int64, error)
+	// NumFileLinks returns the number of hard links to the file with the given
+	// filename. It returns an error if the engine doesn't support hard links.
+	NumFileLinks(filename string) (uint64, error)
+}
+
 // Engine is the interface that wraps the core operations of a key/value store.
 type Engine interface {
 	ReadWriter
+	FS
 	// Attrs returns the engine/store attributes.
 	Attrs() roachpb.Attributes
 	// Capacity returns capacity details for the engine's available storage.
@@ -289,6 +345,10 @@ type Engine interface {
 	Flush() error
 	// GetStats retrieves stats from the engine.
 	GetStats() (*Stats, error)
+	// GetLSMHealth retrieves the shape of the engine's log-structured
+	// merge-tree. The parts which are expensive to compute are sampled at most
+	// every LSMHealthSampleInterval.
+	GetLSMHealth() (LSMHealth, error)
 	// GetEnvStats retrieves stats about the engine's environment
 	// For RocksDB, this includes details of at-rest encryption.
 	GetEnvStats() (*EnvStats, error)
@@ -339,25 +399,18 @@ type Engine interface {
 	// that the key range is compacted all the way to the bottommost level of
 	// SSTables, which is necessary to pick up changes to bloom filters.
 	CompactRange(start, end roachpb.Key, forceBottommost bool) error
-	// OpenFile opens a DBFile with the given filename.
-	OpenFile(filename string) (DBFile, error)
-	// ReadFile reads the content from the file with the given filename int this RocksDB's env.
-	ReadFile(filename string) ([]byte, error)
-	// DeleteFile deletes the file with the given filename from this RocksDB's env.
-	// If the file with given filename doesn't exist, return os.ErrNotExist.
-	DeleteFile(filename string) error
-	// DeleteDirAndFiles deletes the directory and any files it contains but
-	// not subdirectories from this RocksDB's env. If dir does not exist,
-	// DeleteDirAndFiles returns nil (no error).
-	DeleteDirAndFiles(dir string) error
-	// LinkFile creates 'newname' as a hard link to 'oldname'. This is done using
-	// the engine implementation. For RocksDB, this means using the Env responsible for the file
-	// which may handle extra logic (eg: copy encryption settings for EncryptedEnv).
-	LinkFile(oldname, newname string) error
+	// SetCompactionConcurrency sets the maximum number of background
+	// compactions the engine runs concurrently, which trades off the IO
+	// impact of compactions on foreground traffic against the read
+	// amplification incurred when compactions fall behind. A value of zero
+	// restores the default, which is also the maximum.
+	SetCompactionConcurrency(concurrency int) error
 	// CreateCheckpoint creates a checkpoint of the engine in the given directory,
 	// which must not exist. The directory should be on the same file system so
-	// that hard links can be used.
-	CreateCheckpoint(dir string) error
+	// that hard links can be used. If spans is not empty, the checkpoint only
+	// retains the SSTs which overlap one of the given (non-overlapping) spans,
+	// so that it doesn't hold on to the data of the whole engine.
+	CreateCheckpoint(dir string, spans []roachpb.Span) error
 }
 
 // MapProvidingEngine is an Engine that also provides facilities for making a
@@ -382,6 +435,15 @@ type Batch interface {
 	// engine. This is a noop unless the batch was created via NewBatch(). If
 	// sync is true, the batch is synchronously committed to disk.
 	Commit(sync bool) error
+	// CommitNoSyncWait atomically applies any batched updates to the
+	// underlying engine and initiates a synchronous commit to disk, but
+	// returns without waiting for it to complete. Batches committed to the
+	// engine after CommitNoSyncWait returns are ordered after this one. SyncWait
+	// must be called before the batch is closed.
+	CommitNoSyncWait() error
+	// SyncWait waits for the synchronous commit initiated by CommitNoSyncWait
+	// to complete.
+	SyncWait() error
 	// Distinct returns a view of the existing batch which only sees writes that
 	// were performed before the Distinct batch was created. That is, the
 	// returned batch will not read its own writes, but it will read writes to
@@ -434,6 +496,27 @@ type Stats struct {
 	TableReadersMemEstimate        int64
 	PendingCompactionBytesEstimate int64
 	L0FileCount                    int64
+	RunningCompactions             int64
+}
+
+// LSMHealth describes the shape of the log-structured merge-tree of an engine,
+// which determines how much work reads and writes cost it.
+type LSMHealth struct {
+	// ReadAmplification is the worst case number of sstables a read has to
+	// consult (see SSTableInfos.ReadAmplification).
+	ReadAmplification int64
+	// WriteAmplification estimates the number of bytes written to sstables by
+	// flushes and compactions for each byte flushed from the memtables since
+	// the engine was opened. It is zero until the first flush.
+	WriteAmplification float64
+	// L0FileCount is the number of sstables in level 0.
+	L0FileCount int64
+	// L0Sublevels is the number of sublevels of level 0 (see
+	// SSTableInfos.L0Sublevels).
+	L0Sublevels int64
+	// PendingCompactionBytesEstimate is the estimated number of bytes which
+	// compactions have to rewrite for every level to be within its target size.
+	PendingCompactionBytesEstimate int64
 }
 
 // EnvStats is a set of RocksDB env stats, including encryption status.
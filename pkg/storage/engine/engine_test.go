@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"math/rand"
+	"os"
 	"path/filepath"
 	"reflect"
 	"sort"
@@ -815,12 +816,166 @@ func TestCreateCheckpoint(t *testing.T) {
 	dir = filepath.Join(dir, "checkpoint")
 
 	assert.NoError(t, err)
-	assert.NoError(t, db.CreateCheckpoint(dir))
+	assert.NoError(t, db.CreateCheckpoint(dir, nil /* spans */))
 	assert.DirExists(t, dir)
 	m, err := filepath.Glob(dir + "/*")
 	assert.NoError(t, err)
 	assert.True(t, len(m) > 0)
-	if err := db.CreateCheckpoint(dir); !testutils.IsError(err, "exists") {
+	if err := db.CreateCheckpoint(dir, nil /* spans */); !testutils.IsError(err, "exists") {
 		t.Fatal(err)
 	}
 }
+
+func TestCreateCheckpointSpans(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+
+	db, err := NewRocksDB(
+		RocksDBConfig{
+			Settings: cluster.MakeTestingClusterSettings(),
+			Dir:      filepath.Join(dir, "db"),
+		},
+		RocksDBCache{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if err := db.disableAutoCompaction(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Write each key into an SST of its own.
+	keys := []roachpb.Key{roachpb.Key("a"), roachpb.Key("c"), roachpb.Key("e")}
+	for _, key := range keys {
+		assert.NoError(t, db.Put(MakeMVCCMetadataKey(key), []byte("value")))
+		assert.NoError(t, db.Flush())
+	}
+
+	cpDir := filepath.Join(dir, "checkpoint")
+	spans := []roachpb.Span{{Key: roachpb.Key("b"), EndKey: roachpb.Key("d")}}
+	if err := db.CreateCheckpoint(cpDir, spans); err != nil {
+		t.Fatal(err)
+	}
+
+	cp, err := NewRocksDB(
+		RocksDBConfig{
+			Settings:  cluster.MakeTestingClusterSettings(),
+			Dir:       cpDir,
+			MustExist: true,
+		},
+		RocksDBCache{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cp.Close()
+
+	// Only the SST holding "c" was retained.
+	assert.Len(t, cp.GetSSTables(), 1)
+	for _, key := range keys {
+		val, err := cp.Get(MakeMVCCMetadataKey(key))
+		assert.NoError(t, err)
+		assert.Equal(t, key.Equal(roachpb.Key("c")), val != nil, "key %s", key)
+	}
+}
+
+func TestEngineFS(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+
+	rocksDB, err := NewRocksDB(
+		RocksDBConfig{
+			Settings: cluster.MakeTestingClusterSettings(),
+			Dir:      dir,
+		},
+		RocksDBCache{},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rocksDB.Close()
+	inMem := NewInMem(inMemAttrs, testCacheSize)
+	defer inMem.Close()
+
+	for _, tc := range []struct {
+		name string
+		fs   FS
+		base string
+	}{
+		{"disk", rocksDB, rocksDB.GetAuxiliaryDir()},
+		{"mem", inMem, inMem.GetAuxiliaryDir()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			subDir := filepath.Join(tc.base, "a", "b")
+			assert.NoError(t, tc.fs.MkdirAll(subDir))
+			// Creating an existing directory is not an error.
+			assert.NoError(t, tc.fs.MkdirAll(subDir))
+
+			fname := filepath.Join(subDir, "f")
+			assert.NoError(t, tc.fs.WriteFile(fname, []byte("foo")))
+			b, err := tc.fs.ReadFile(fname)
+			assert.NoError(t, err)
+			assert.Equal(t, "foo", string(b))
+
+			// WriteFile truncates existing files.
+			assert.NoError(t, tc.fs.WriteFile(fname, []byte("ba")))
+			b, err = tc.fs.ReadFile(fname)
+			assert.NoError(t, err)
+			assert.Equal(t, "ba", string(b))
+
+			assert.NoError(t, tc.fs.DeleteFile(fname))
+			if _, err := tc.fs.ReadFile(fname); !os.IsNotExist(err) {
+				t.Fatalf("expected IsNotExist, but got %v", err)
+			}
+			if err := tc.fs.DeleteFile(fname); !os.IsNotExist(err) {
+				t.Fatalf("expected IsNotExist, but got %v", err)
+			}
+
+			assert.NoError(t, tc.fs.WriteFile(fname, []byte("foo")))
+			size, err := tc.fs.FileSize(fname)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(3), size)
+			if _, err := tc.fs.FileSize(fname + ".missing"); !os.IsNotExist(err) {
+				t.Fatalf("expected IsNotExist, but got %v", err)
+			}
+
+			renamed := filepath.Join(subDir, "g")
+			assert.NoError(t, tc.fs.RenameFile(fname, renamed))
+			names, err := tc.fs.ListDir(subDir)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"g"}, names)
+			if err := tc.fs.RenameFile(fname, renamed); !os.IsNotExist(err) {
+				t.Fatalf("expected IsNotExist, but got %v", err)
+			}
+
+			links, err := tc.fs.NumFileLinks(renamed)
+			if tc.name == "mem" {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, uint64(1), links)
+			}
+
+			assert.NoError(t, tc.fs.DeleteFile(renamed))
+			names, err = tc.fs.ListDir(subDir)
+			assert.NoError(t, err)
+			assert.Empty(t, names)
+			assert.NoError(t, tc.fs.DeleteDir(subDir))
+			names, err = tc.fs.ListDir(subDir)
+			assert.NoError(t, err)
+			assert.Empty(t, names)
+			assert.NoError(t, tc.fs.MkdirAll(subDir))
+
+			assert.NoError(t, tc.fs.WriteFile(fname, []byte("foo")))
+			assert.NoError(t, tc.fs.DeleteDirAndFiles(subDir))
+			if _, err := tc.fs.ReadFile(fname); !os.IsNotExist(err) {
+				t.Fatalf("expected IsNotExist, but got %v", err)
+			}
+		})
+	}
+}
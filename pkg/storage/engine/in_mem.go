@@ -14,7 +14,10 @@
 
 package engine
 
-import "github.com/cockroachdb/cockroach/pkg/roachpb"
+import (
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/pkg/errors"
+)
 
 // InMem wraps RocksDB and configures it for in-memory only storage.
 type InMem struct {
@@ -43,3 +46,9 @@ func NewInMem(attrs roachpb.Attributes, cacheSize int64) InMem {
 }
 
 var _ Engine = InMem{}
+
+// NumFileLinks implements the FS interface. The in-memory env has no notion
+// of hard links, so this always returns an error.
+func (db InMem) NumFileLinks(filename string) (uint64, error) {
+	return 0, errors.Errorf("%s: hard links are not supported by in-memory engines", filename)
+}
@@ -1004,6 +1004,34 @@ func (b *putBuffer) putMeta(
 	return int64(key.EncodedSize()), int64(len(bytes)), nil
 }
 
+// putLockTableEntry writes the lock table entry for the intent described by
+// meta on the given key. The entry retains only the intent's transaction and
+// timestamp. Lock table entries are not accounted for in MVCCStats, as they
+// mirror intents which already are. Nothing is written unless the writer
+// maintains the lock table.
+func (b *putBuffer) putLockTableEntry(
+	engine Writer, key roachpb.Key, meta *enginepb.MVCCMetadata,
+) error {
+	if !engine.LockTableEnabled() {
+		return nil
+	}
+	lockMeta := enginepb.MVCCMetadata{Txn: meta.Txn, Timestamp: meta.Timestamp}
+	bytes, err := b.marshalMeta(&lockMeta)
+	if err != nil {
+		return err
+	}
+	return engine.Put(MakeMVCCMetadataKey(keys.LockTableSingleKey(key)), bytes)
+}
+
+// clearLockTableEntry removes the lock table entry for the intent on the given
+// key, if the writer maintains the lock table.
+func clearLockTableEntry(engine Writer, key roachpb.Key) error {
+	if !engine.LockTableEnabled() {
+		return nil
+	}
+	return engine.Clear(MakeMVCCMetadataKey(keys.LockTableSingleKey(key)))
+}
+
 // MVCCPut sets the value for a specified key. It will save the value
 // with different versions according to its timestamp and update the
 // key metadata. The timestamp must be passed as a parameter; using
@@ -1559,6 +1587,9 @@ func mvccPutInternal(
 		if err != nil {
 			return err
 		}
+		if err := buf.putLockTableEntry(engine, key, newMeta); err != nil {
+			return err
+		}
 	} else {
 		// Per-key stats count the full-key once and mvccVersionTimestampSize for
 		// each versioned value. We maintain that accounting even when the MVCC
@@ -1932,6 +1963,10 @@ func mvccScanToKvs(
 	if err != nil {
 		return nil, nil, nil, err
 	}
+	kvData, numKVs, resumeSpan, err = limitScanToTargetBytes(key, endKey, kvData, numKVs, resumeSpan, opts)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	kvs := make([]roachpb.KeyValue, numKVs)
 	var k MVCCKey
 	var rawBytes []byte
@@ -1995,6 +2030,11 @@ type MVCCScanOptions struct {
 	IgnoreSequence bool
 	Reverse        bool
 	Txn            *roachpb.Transaction
+	// TargetBytes, if non-zero, limits the scan to the first key/value pairs
+	// whose encodings in the results add up to at least that many bytes. At
+	// least one pair is returned if there is one. If the limit truncates the
+	// results, a resume span is returned as for max.
+	TargetBytes int64
 }
 
 // MVCCScan scans the key range [key, endKey) in the provided engine up to some
@@ -2052,7 +2092,49 @@ func MVCCScanToBytes(
 ) ([]byte, int64, *roachpb.Span, []roachpb.Intent, error) {
 	iter := engine.NewIterator(IterOptions{LowerBound: key, UpperBound: endKey})
 	defer iter.Close()
-	return iter.MVCCScan(key, endKey, max, timestamp, opts)
+	kvData, numKVs, resumeSpan, intents, err := iter.MVCCScan(key, endKey, max, timestamp, opts)
+	if err != nil {
+		return kvData, numKVs, resumeSpan, intents, err
+	}
+	kvData, numKVs, resumeSpan, err = limitScanToTargetBytes(key, endKey, kvData, numKVs, resumeSpan, opts)
+	if err != nil {
+		return nil, 0, nil, nil, err
+	}
+	return kvData, numKVs, resumeSpan, intents, nil
+}
+
+// limitScanToTargetBytes truncates the results of a scan of [key, endKey) in
+// the format returned by Iterator.MVCCScan to opts.TargetBytes, returning the
+// resume span at the first key/value pair which was dropped.
+func limitScanToTargetBytes(
+	key, endKey roachpb.Key,
+	kvData []byte,
+	numKVs int64,
+	resumeSpan *roachpb.Span,
+	opts MVCCScanOptions,
+) ([]byte, int64, *roachpb.Span, error) {
+	if opts.TargetBytes <= 0 {
+		return kvData, numKVs, resumeSpan, nil
+	}
+	var numBytes int64
+	rest := kvData
+	for i := int64(0); i < numKVs; i++ {
+		k, _, next, err := MVCCScanDecodeKeyValue(rest)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		if numBytes >= opts.TargetBytes {
+			if opts.Reverse {
+				resumeSpan = &roachpb.Span{Key: key, EndKey: k.Key.Next()}
+			} else {
+				resumeSpan = &roachpb.Span{Key: k.Key, EndKey: endKey}
+			}
+			return kvData[:len(kvData)-len(rest)], i, resumeSpan, nil
+		}
+		numBytes += int64(len(rest) - len(next))
+		rest = next
+	}
+	return kvData, numKVs, resumeSpan, nil
 }
 
 // MVCCIterate iterates over the key range [start,end). At each step of the
@@ -2354,9 +2436,15 @@ func mvccResolveWriteIntent(
 			// pusher's job isn't to do anything to update the intent but
 			// to move the timestamp forward, even if it can.
 			metaKeySize, metaValSize, err = buf.putMeta(engine, metaKey, &buf.newMeta)
+			if err == nil {
+				err = buf.putLockTableEntry(engine, intent.Key, &buf.newMeta)
+			}
 		} else {
 			metaKeySize = int64(metaKey.EncodedSize())
 			err = engine.Clear(metaKey)
+			if err == nil {
+				err = clearLockTableEntry(engine, intent.Key)
+			}
 		}
 		if err != nil {
 			return false, err
@@ -2429,11 +2517,14 @@ func mvccResolveWriteIntent(
 	// - writer2 dispatches ResolveIntent to key0 (with epoch 0)
 	// - ResolveIntent with epoch 0 aborts intent from epoch 1.
 
-	// First clear the intent value.
+	// First clear the intent value and its lock table entry.
 	latestKey := MVCCKey{Key: intent.Key, Timestamp: hlc.Timestamp(meta.Timestamp)}
 	if err := engine.Clear(latestKey); err != nil {
 		return false, err
 	}
+	if err := clearLockTableEntry(engine, intent.Key); err != nil {
+		return false, err
+	}
 
 	// Log the logical MVCC operation.
 	engine.LogLogicalOp(MVCCAbortIntentOpType, MVCCLogicalOpDetails{
@@ -2587,6 +2678,97 @@ func MVCCResolveWriteIntentRangeUsingIter(
 	return num, nil, nil
 }
 
+// MVCCScanLockTable returns the intents on the keys in [key, endKey) as
+// recorded in the lock table, in key order. At most max intents are returned
+// if max is positive. Unlike a scan of the MVCC data, this visits only the
+// lock table entries, so its cost doesn't depend on the amount of data in the
+// span.
+func MVCCScanLockTable(
+	reader Reader, key, endKey roachpb.Key, max int64,
+) ([]roachpb.Intent, error) {
+	if max < 0 {
+		return nil, nil
+	}
+	var intents []roachpb.Intent
+	var meta enginepb.MVCCMetadata
+	err := reader.Iterate(
+		MakeMVCCMetadataKey(keys.LockTableSingleKey(key)),
+		MakeMVCCMetadataKey(keys.LockTableSingleKey(endKey)),
+		func(kv MVCCKeyValue) (bool, error) {
+			lockedKey, err := keys.DecodeLockTableSingleKey(kv.Key.Key)
+			if err != nil {
+				return false, err
+			}
+			if err := protoutil.Unmarshal(kv.Value, &meta); err != nil {
+				return false, errors.Wrapf(err, "unable to decode lock table entry for %s", lockedKey)
+			}
+			if meta.Txn == nil {
+				return false, errors.Errorf("lock table entry for %s has no transaction", lockedKey)
+			}
+			intents = append(intents, roachpb.Intent{
+				Span:   roachpb.Span{Key: lockedKey},
+				Status: roachpb.PENDING,
+				Txn:    *meta.Txn,
+			})
+			return max > 0 && int64(len(intents)) == max, nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	return intents, nil
+}
+
+// MVCCBackfillLockTable writes the missing lock table entries for the intents
+// on the keys in [key, endKey). Such intents were laid down before the lock
+// table was maintained (see cluster.VersionLockTable). It returns the number
+// of entries written.
+func MVCCBackfillLockTable(
+	ctx context.Context, rw ReadWriter, key, endKey roachpb.Key,
+) (int64, error) {
+	iter := rw.NewIterator(IterOptions{UpperBound: endKey})
+	defer iter.Close()
+
+	buf := newPutBuffer()
+	defer buf.release()
+
+	var meta enginepb.MVCCMetadata
+	var num int64
+	for iter.Seek(MakeMVCCMetadataKey(key)); ; iter.NextKey() {
+		if ok, err := iter.Valid(); err != nil {
+			return 0, err
+		} else if !ok {
+			break
+		}
+		if unsafeKey := iter.UnsafeKey(); unsafeKey.IsValue() {
+			// The key has no metadata, so there is no intent on it.
+			continue
+		} else if bytes.HasPrefix(unsafeKey.Key, keys.LocalRangeLockTablePrefix) {
+			// Lock table entries mirror intents but aren't intents themselves.
+			continue
+		}
+		if err := protoutil.Unmarshal(iter.UnsafeValue(), &meta); err != nil {
+			return 0, errors.Wrapf(err, "unable to decode MVCCMetadata for %s", iter.UnsafeKey())
+		}
+		if meta.Txn == nil {
+			continue
+		}
+		intentKey := iter.Key().Key
+		if v, err := rw.Get(MakeMVCCMetadataKey(keys.LockTableSingleKey(intentKey))); err != nil {
+			return 0, err
+		} else if v != nil {
+			continue
+		}
+		if err := buf.putLockTableEntry(rw, intentKey, &meta); err != nil {
+			return 0, err
+		}
+		num++
+	}
+	if num > 0 {
+		log.VEventf(ctx, 2, "backfilled %d lock table entries in [%s,%s)", num, key, endKey)
+	}
+	return num, nil
+}
+
 // MVCCGarbageCollect creates an iterator on the engine. In parallel
 // it iterates through the keys listed for garbage collection by the
 // keys slice. The engine iterator is seeked in turn to each listed
@@ -2897,6 +3079,10 @@ func ComputeStatsGo(
 					continue
 				}
 			}
+		} else if bytes.HasPrefix(unsafeKey.Key, keys.LocalRangeLockTablePrefix) {
+			// Lock table key. Ignore, as each lock table entry mirrors an intent
+			// which is already accounted for.
+			continue
 		}
 
 		isSys := isSysLocal(unsafeKey.Key)
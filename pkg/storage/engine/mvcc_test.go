@@ -28,6 +28,7 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/zerofields"
@@ -1671,6 +1672,59 @@ func TestMVCCScanMaxNum(t *testing.T) {
 	}
 }
 
+func TestMVCCScanTargetBytes(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	engine := createTestEngine()
+	defer engine.Close()
+
+	for _, kv := range []struct {
+		key   roachpb.Key
+		value roachpb.Value
+	}{
+		{testKey1, value1},
+		{testKey2, value2},
+		{testKey3, value3},
+	} {
+		if err := MVCCPut(ctx, engine, nil, kv.key, hlc.Timestamp{WallTime: 1}, kv.value, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		targetBytes int64
+		reverse     bool
+		expKeys     []roachpb.Key
+		expResume   *roachpb.Span
+	}{
+		// At least one key is returned, however small the target.
+		{1, false, []roachpb.Key{testKey1}, &roachpb.Span{Key: testKey2, EndKey: testKey4}},
+		{1, true, []roachpb.Key{testKey3}, &roachpb.Span{Key: testKey1, EndKey: testKey2.Next()}},
+		// The target is large enough for all keys.
+		{1 << 20, false, []roachpb.Key{testKey1, testKey2, testKey3}, nil},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("target=%d,reverse=%t", tc.targetBytes, tc.reverse), func(t *testing.T) {
+			kvs, resumeSpan, _, err := MVCCScan(ctx, engine, testKey1, testKey4, math.MaxInt64,
+				hlc.Timestamp{WallTime: 1}, MVCCScanOptions{TargetBytes: tc.targetBytes, Reverse: tc.reverse})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var keys []roachpb.Key
+			for _, kv := range kvs {
+				keys = append(keys, kv.Key)
+			}
+			if !reflect.DeepEqual(keys, tc.expKeys) {
+				t.Fatalf("expected keys %s, got %s", tc.expKeys, keys)
+			}
+			if !reflect.DeepEqual(resumeSpan, tc.expResume) {
+				t.Fatalf("expected resume span %s, got %s", tc.expResume, resumeSpan)
+			}
+		})
+	}
+}
+
 func TestMVCCScanWithKeyPrefix(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -3019,6 +3073,173 @@ func TestMVCCResolveTxn(t *testing.T) {
 	}
 }
 
+// TestMVCCLockTable verifies that the lock table holds an entry for each
+// intent, which is updated and removed as the intent is pushed and resolved.
+func TestMVCCLockTable(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	engine := createTestEngine()
+	defer engine.Close()
+
+	ts := hlc.Timestamp{Logical: 1}
+	for _, key := range []roachpb.Key{testKey1, testKey2, testKey3} {
+		if err := MVCCPut(ctx, engine, nil, key, ts, value1, txn1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := MVCCPut(ctx, engine, nil, testKey4, ts, value1, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	expectLocks := func(max int64, expKeys []roachpb.Key, expTS hlc.Timestamp) {
+		t.Helper()
+		intents, err := MVCCScanLockTable(engine, testKey1, testKey5, max)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(intents) != len(expKeys) {
+			t.Fatalf("expected %d locks, found %v", len(expKeys), intents)
+		}
+		for i, intent := range intents {
+			if !intent.Key.Equal(expKeys[i]) || intent.Txn.ID != txn1ID || intent.Txn.Timestamp != expTS {
+				t.Errorf("%d: unexpected lock %v", i, intent)
+			}
+		}
+	}
+	expectLocks(0, []roachpb.Key{testKey1, testKey2, testKey3}, ts)
+	expectLocks(2, []roachpb.Key{testKey1, testKey2}, ts)
+
+	// Lock table entries are neither visible to MVCC scans nor counted in the
+	// MVCCStats.
+	kvs, _, _, err := MVCCScan(ctx, engine, keyMin, keyMax, math.MaxInt64, ts,
+		MVCCScanOptions{Txn: txn1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kvs) != 4 {
+		t.Fatalf("expected 4 kvs, found %v", kvs)
+	}
+	iter := engine.NewIterator(IterOptions{UpperBound: keyMax})
+	ms, err := ComputeStatsGo(iter, mvccKey(keyMin), mvccKey(keyMax), 0)
+	iter.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ms.KeyCount != 4 || ms.IntentCount != 3 {
+		t.Fatalf("unexpected stats: %+v", ms)
+	}
+
+	// Committing and aborting intents remove their lock table entries.
+	if err := MVCCResolveWriteIntent(ctx, engine, nil, roachpb.Intent{
+		Span:   roachpb.Span{Key: testKey1},
+		Txn:    txn1Commit.TxnMeta,
+		Status: txn1Commit.Status,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := MVCCResolveWriteIntent(ctx, engine, nil, roachpb.Intent{
+		Span:   roachpb.Span{Key: testKey3},
+		Txn:    txn1Abort.TxnMeta,
+		Status: txn1Abort.Status,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	expectLocks(0, []roachpb.Key{testKey2}, ts)
+
+	// Pushing an intent retains its lock table entry.
+	pushed := makeTxn(*txn1, hlc.Timestamp{WallTime: 1})
+	if err := MVCCResolveWriteIntent(ctx, engine, nil, roachpb.Intent{
+		Span:   roachpb.Span{Key: testKey2},
+		Txn:    pushed.TxnMeta,
+		Status: roachpb.PENDING,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	expectLocks(0, []roachpb.Key{testKey2}, ts)
+}
+
+// TestMVCCLockTableMigration verifies that the lock table is only maintained
+// once cluster.VersionLockTable is active, and that MVCCBackfillLockTable then
+// writes the entries of the intents that were laid down before.
+func TestMVCCLockTableMigration(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+
+	open := func(st *cluster.Settings) *RocksDB {
+		t.Helper()
+		engine, err := NewRocksDB(RocksDBConfig{Settings: st, Dir: dir}, RocksDBCache{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return engine
+	}
+	expectLocks := func(engine Reader, expKeys ...roachpb.Key) {
+		t.Helper()
+		intents, err := MVCCScanLockTable(engine, keyMin, keyMax, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(intents) != len(expKeys) {
+			t.Fatalf("expected %d locks, found %v", len(expKeys), intents)
+		}
+		for i, intent := range intents {
+			if !intent.Key.Equal(expKeys[i]) {
+				t.Errorf("%d: unexpected lock %v", i, intent)
+			}
+		}
+	}
+	backfill := func(engine ReadWriter, expNum int64) {
+		t.Helper()
+		num, err := MVCCBackfillLockTable(ctx, engine, keyMin, keyMax)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if num != expNum {
+			t.Fatalf("expected %d lock table entries to be backfilled, got %d", expNum, num)
+		}
+	}
+
+	// Before the version is active, intents don't get lock table entries, and
+	// none can be backfilled.
+	oldVersion := cluster.VersionByKey(cluster.VersionLockTable - 1)
+	engine := open(cluster.MakeTestingClusterSettingsWithVersion(oldVersion, oldVersion))
+	ts := hlc.Timestamp{Logical: 1}
+	for _, key := range []roachpb.Key{testKey1, testKey2} {
+		if err := MVCCPut(ctx, engine, nil, key, ts, value1, txn1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expectLocks(engine)
+	backfill(engine, 0)
+	expectLocks(engine)
+	engine.Close()
+
+	// Once it is, new intents get lock table entries and the old ones are
+	// backfilled, exactly once.
+	engine = open(cluster.MakeTestingClusterSettings())
+	defer engine.Close()
+	if err := MVCCPut(ctx, engine, nil, testKey3, ts, value1, txn1); err != nil {
+		t.Fatal(err)
+	}
+	expectLocks(engine, testKey3)
+	backfill(engine, 2)
+	expectLocks(engine, testKey1, testKey2, testKey3)
+	backfill(engine, 0)
+
+	if err := MVCCResolveWriteIntent(ctx, engine, nil, roachpb.Intent{
+		Span:   roachpb.Span{Key: testKey1},
+		Txn:    txn1Commit.TxnMeta,
+		Status: txn1Commit.Status,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	expectLocks(engine, testKey2, testKey3)
+}
+
 // TestMVCCResolveNewerIntent verifies that resolving a newer intent
 // than the committing transaction aborts the intent.
 func TestMVCCResolveNewerIntent(t *testing.T) {
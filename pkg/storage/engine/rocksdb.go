@@ -98,6 +98,17 @@ var ingestDelayTime = settings.RegisterDurationSetting(
 	time.Second*5,
 )
 
+var ingestDelayReadAmpThreshold = settings.RegisterIntSetting(
+	"rocksdb.ingest_backpressure.read_amplification_threshold",
+	"if nonzero, read amplification after which to backpressure SST ingestions",
+	0,
+)
+
+// LSMHealthSampleInterval is the interval at which the read and write
+// amplification and the sublevels of level 0 reported by GetLSMHealth are
+// recomputed, as they require listing every sstable and ticker of the engine.
+const LSMHealthSampleInterval = 10 * time.Second
+
 // Set to true to perform expensive iterator debug leak checking. In normal
 // operation, we perform inexpensive iterator leak checking but those checks do
 // not indicate where the leak arose. The expensive checking tracks the stack
@@ -309,6 +320,45 @@ func (s SSTableInfos) ReadAmplification() int {
 	return readAmp
 }
 
+// L0Sublevels returns the number of sublevels of level 0, which is the largest
+// number of level-0 sstables overlapping a single key. Unlike in the other
+// levels, the sstables of level 0 may overlap each other, so a read consults
+// every level-0 sstable overlapping its key; the number of sublevels is the
+// part of the read amplification due to level 0 in the worst case, while the
+// number of level-0 sstables overestimates it when they don't overlap, as is
+// the case with ingested sstables.
+func (s SSTableInfos) L0Sublevels() int {
+	type bound struct {
+		key   MVCCKey
+		start bool
+	}
+	var bounds []bound
+	for _, t := range s {
+		if t.Level == 0 {
+			bounds = append(bounds, bound{key: t.Start, start: true}, bound{key: t.End})
+		}
+	}
+	// The bounds of sstables are inclusive, so the start of an sstable sorts
+	// before the end of another at the same key.
+	sort.Slice(bounds, func(i, j int) bool {
+		if !bounds[i].key.Equal(bounds[j].key) {
+			return bounds[i].key.Less(bounds[j].key)
+		}
+		return bounds[i].start && !bounds[j].start
+	})
+	var depth, sublevels int
+	for _, b := range bounds {
+		if !b.start {
+			depth--
+			continue
+		}
+		if depth++; depth > sublevels {
+			sublevels = depth
+		}
+	}
+	return sublevels
+}
+
 // SSTableInfosByLevel maintains slices of SSTableInfo objects, one
 // per level. The slice for each level contains the SSTableInfo
 // objects for SSTables at that level, sorted by start key.
@@ -525,6 +575,12 @@ type RocksDB struct {
 		syncutil.Mutex
 		m map[*rocksDBIterator][]byte
 	}
+
+	lsmHealth struct {
+		syncutil.Mutex
+		sampledAt time.Time
+		sample    LSMHealth
+	}
 }
 
 var _ Engine = &RocksDB{}
@@ -769,8 +825,33 @@ func (r *RocksDB) Close() {
 // CreateCheckpoint creates a RocksDB checkpoint in the given directory (which
 // must not exist). This directory should be located on the same file system, or
 // copies of all data are used instead of hard links, which is very expensive.
-func (r *RocksDB) CreateCheckpoint(dir string) error {
-	status := C.DBCreateCheckpoint(r.rdb, goToCSlice([]byte(dir)))
+// If spans is not empty, the SSTs which don't overlap any of the spans are
+// removed from the checkpoint.
+func (r *RocksDB) CreateCheckpoint(dir string, spans []roachpb.Span) error {
+	spans = append([]roachpb.Span(nil), spans...)
+	sort.Slice(spans, func(i, j int) bool {
+		return spans[i].Key.Compare(spans[j].Key) < 0
+	})
+	cSpans := make([]C.DBSlice, 0, 2*len(spans))
+	for _, span := range spans {
+		for _, key := range []roachpb.Key{span.Key, span.EndKey} {
+			cSpans = append(cSpans, C.DBSlice{
+				data: (*C.char)(C.CBytes(key)),
+				len:  C.size_t(len(key)),
+			})
+		}
+	}
+	defer func() {
+		for i := range cSpans {
+			C.free(unsafe.Pointer(cSpans[i].data))
+		}
+	}()
+
+	var cSpansPtr *C.DBSlice
+	if len(cSpans) > 0 {
+		cSpansPtr = &cSpans[0]
+	}
+	status := C.DBCreateCheckpoint(r.rdb, goToCSlice([]byte(dir)), cSpansPtr, C.size_t(len(spans)))
 	return errors.Wrap(statusToError(status), "unable to take RocksDB checkpoint")
 }
 
@@ -817,6 +898,13 @@ func (r *RocksDB) LogLogicalOp(op MVCCLogicalOpType, details MVCCLogicalOpDetail
 	// No-op. Logical logging disabled.
 }
 
+// LockTableEnabled is part of the Writer interface. Engines opened without
+// cluster settings, such as those of tests and tools, always maintain the
+// lock table.
+func (r *RocksDB) LockTableEnabled() bool {
+	return r.cfg.Settings == nil || r.cfg.Settings.Version.IsActive(cluster.VersionLockTable)
+}
+
 // ApplyBatchRepr atomically applies a set of batched updates. Created by
 // calling Repr() on a batch. Using this method is equivalent to constructing
 // and committing a batch whose Repr() equals repr.
@@ -967,6 +1055,16 @@ func (r *RocksDB) CompactRange(start, end roachpb.Key, forceBottommost bool) err
 	return statusToError(C.DBCompactRange(r.rdb, goToCSlice(start), goToCSlice(end), C.bool(forceBottommost)))
 }
 
+// SetCompactionConcurrency implements the Engine interface.
+func (r *RocksDB) SetCompactionConcurrency(concurrency int) error {
+	// The background thread pool has rocksdbConcurrency threads, so higher
+	// values wouldn't run more compactions.
+	if concurrency <= 0 || concurrency > rocksdbConcurrency {
+		concurrency = rocksdbConcurrency
+	}
+	return statusToError(C.DBSetCompactionConcurrency(r.rdb, C.int(concurrency)))
+}
+
 // disableAutoCompaction disables automatic compactions. For testing use only.
 func (r *RocksDB) disableAutoCompaction() error {
 	return statusToError(C.DBDisableAutoCompaction(r.rdb))
@@ -1127,6 +1225,10 @@ func (r *rocksDBReadOnly) LogLogicalOp(op MVCCLogicalOpType, details MVCCLogical
 	panic("not implemented")
 }
 
+func (r *rocksDBReadOnly) LockTableEnabled() bool {
+	panic("not implemented")
+}
+
 // NewBatch returns a new batch wrapping this rocksdb engine.
 func (r *RocksDB) NewBatch() Batch {
 	return newRocksDBBatch(r, false /* writeOnly */)
@@ -1241,9 +1343,45 @@ func (r *RocksDB) GetStats() (*Stats, error) {
 		TableReadersMemEstimate:        int64(s.table_readers_mem_estimate),
 		PendingCompactionBytesEstimate: int64(s.pending_compaction_bytes_estimate),
 		L0FileCount:                    int64(s.l0_file_count),
+		RunningCompactions:             int64(s.running_compactions),
 	}, nil
 }
 
+// GetLSMHealth is part of the Engine interface. The L0 file count and the
+// pending compaction estimate are always current.
+func (r *RocksDB) GetLSMHealth() (LSMHealth, error) {
+	stats, err := r.GetStats()
+	if err != nil {
+		return LSMHealth{}, err
+	}
+
+	r.lsmHealth.Lock()
+	defer r.lsmHealth.Unlock()
+	if now := timeutil.Now(); now.Sub(r.lsmHealth.sampledAt) >= LSMHealthSampleInterval {
+		tickers, err := r.GetTickersAndHistograms()
+		if err != nil {
+			return LSMHealth{}, err
+		}
+		sstables := r.GetSSTables()
+		var writeAmp float64
+		// Ticker names are defined in rocksdb/monitoring/statistics.cc.
+		if flushed := tickers.Tickers["rocksdb.flush.write.bytes"]; flushed > 0 {
+			compacted := tickers.Tickers["rocksdb.compact.write.bytes"]
+			writeAmp = float64(flushed+compacted) / float64(flushed)
+		}
+		r.lsmHealth.sample = LSMHealth{
+			ReadAmplification:  int64(sstables.ReadAmplification()),
+			WriteAmplification: writeAmp,
+			L0Sublevels:        int64(sstables.L0Sublevels()),
+		}
+		r.lsmHealth.sampledAt = now
+	}
+	health := r.lsmHealth.sample
+	health.L0FileCount = stats.L0FileCount
+	health.PendingCompactionBytesEstimate = stats.PendingCompactionBytesEstimate
+	return health, nil
+}
+
 // GetTickersAndHistograms retrieves maps of all RocksDB tickers and histograms.
 // It differs from `GetStats` by getting _every_ ticker and histogram, and by not
 // getting anything else (DB properties, for example).
@@ -1648,6 +1786,10 @@ type rocksDBBatch struct {
 	committed          bool
 	commitErr          error
 	commitWG           sync.WaitGroup
+	// writeErr and writeWG are the equivalent of commitErr and commitWG for
+	// the write of the batch to the engine, which precedes its sync.
+	writeErr error
+	writeWG  sync.WaitGroup
 }
 
 var batchPool = sync.Pool{
@@ -1819,6 +1961,10 @@ func (r *rocksDBBatch) LogLogicalOp(op MVCCLogicalOpType, details MVCCLogicalOpD
 	// No-op. Logical logging disabled.
 }
 
+func (r *rocksDBBatch) LockTableEnabled() bool {
+	return r.parent.LockTableEnabled()
+}
+
 // NewIterator returns an iterator over the batch and underlying engine. Note
 // that the returned iterator is cached and re-used for the lifetime of the
 // batch. A panic will be thrown if multiple prefix or normal (non-prefix)
@@ -1899,6 +2045,29 @@ func nextBatchGroup(pending []*rocksDBBatch) (prefix []*rocksDBBatch, suffix []*
 }
 
 func (r *rocksDBBatch) Commit(syncCommit bool) error {
+	r.commitNoWait(syncCommit)
+	// Wait for the commit/sync to finish.
+	r.commitWG.Wait()
+	return r.commitErr
+}
+
+// CommitNoSyncWait implements the Batch interface.
+func (r *rocksDBBatch) CommitNoSyncWait() error {
+	r.commitNoWait(true /* syncCommit */)
+	r.writeWG.Wait()
+	return r.writeErr
+}
+
+// SyncWait implements the Batch interface.
+func (r *rocksDBBatch) SyncWait() error {
+	r.commitWG.Wait()
+	return r.commitErr
+}
+
+// commitNoWait queues the batch for commit. Once the batch has been written to
+// the engine, writeWG is signaled; once it has also been synced (if requested),
+// commitWG is signaled.
+func (r *rocksDBBatch) commitNoWait(syncCommit bool) {
 	if r.Closed() {
 		panic("this batch was already committed")
 	}
@@ -1907,7 +2076,7 @@ func (r *rocksDBBatch) Commit(syncCommit bool) error {
 	if r.Empty() {
 		// Nothing was written to this batch. Fast path.
 		r.committed = true
-		return nil
+		return
 	}
 
 	// Combine multiple write-only batch commits into a single call to
@@ -1918,6 +2087,7 @@ func (r *rocksDBBatch) Commit(syncCommit bool) error {
 	// 30 concurrent commits.
 	c := &r.parent.commit
 	r.commitWG.Add(1)
+	r.writeWG.Add(1)
 	r.syncCommit = syncCommit
 
 	// The leader for the commit is the first batch to be added to the pending
@@ -1980,6 +2150,10 @@ func (r *rocksDBBatch) Commit(syncCommit bool) error {
 		// while holding the sync lock below, and appending to the commit pending
 		// list while holding the commit lock above.
 		syncing := pending[:0:len(pending)]
+		for _, b := range pending {
+			b.writeErr = err
+			b.writeWG.Done()
+		}
 		for _, b := range pending {
 			if err != nil || !b.syncCommit {
 				b.commitErr = err
@@ -2005,9 +2179,6 @@ func (r *rocksDBBatch) Commit(syncCommit bool) error {
 	} else {
 		c.Unlock()
 	}
-	// Wait for the commit/sync to finish.
-	r.commitWG.Wait()
-	return r.commitErr
 }
 
 func (r *rocksDBBatch) commitInternal(sync bool) error {
@@ -2941,8 +3112,21 @@ func (fw *RocksDBSstFileWriter) Delete(k MVCCKey) error {
 
 var _ = (*RocksDBSstFileWriter).Delete
 
+// ClearRange puts a range deletion tombstone covering [start, end) into the
+// sstable being built. Keys added to the sstable itself are not covered by the
+// tombstone once the sstable is ingested. Unlike the entries added with Add,
+// range deletion tombstones can be added in any order. `Close` cannot have
+// been called.
+func (fw *RocksDBSstFileWriter) ClearRange(start, end MVCCKey) error {
+	if fw.fw == nil {
+		return errors.New("cannot call ClearRange on a closed writer")
+	}
+	fw.DataSize += int64(len(start.Key)) + int64(len(end.Key))
+	return statusToError(C.DBSstFileWriterDeleteRange(fw.fw, goToCKey(start), goToCKey(end)))
+}
+
 // Finish finalizes the writer and returns the constructed file's contents. At
-// least one kv entry must have been added.
+// least one kv entry or range deletion tombstone must have been added.
 func (fw *RocksDBSstFileWriter) Finish() ([]byte, error) {
 	if fw.fw == nil {
 		return nil, errors.New("cannot call Finish on a closed writer")
@@ -3025,17 +3209,18 @@ func (r *RocksDB) PreIngestDelay(ctx context.Context) {
 	if r.cfg.Settings == nil {
 		return
 	}
-	stats, err := r.GetStats()
+	health, err := r.GetLSMHealth()
 	if err != nil {
-		log.Warningf(ctx, "failed to read stats: %+v", err)
+		log.Warningf(ctx, "failed to read LSM health: %+v", err)
 		return
 	}
-	targetDelay := calculatePreIngestDelay(r.cfg, stats)
+	targetDelay := calculatePreIngestDelay(r.cfg, &health)
 
 	if targetDelay == 0 {
 		return
 	}
-	log.VEventf(ctx, 2, "delaying SST ingestion %s. %d L0 files, %db pending compaction", targetDelay, stats.L0FileCount, stats.PendingCompactionBytesEstimate)
+	log.VEventf(ctx, 2, "delaying SST ingestion %s. %d L0 files, %db pending compaction, read amplification %d",
+		targetDelay, health.L0FileCount, health.PendingCompactionBytesEstimate, health.ReadAmplification)
 
 	select {
 	case <-time.After(targetDelay):
@@ -3043,18 +3228,48 @@ func (r *RocksDB) PreIngestDelay(ctx context.Context) {
 	}
 }
 
-func calculatePreIngestDelay(cfg RocksDBConfig, stats *Stats) time.Duration {
+// IsOverloaded returns whether the given LSM health indicates that the engine
+// is severely overloaded, i.e. whether PreIngestDelay would delay an SST
+// ingestion by the maximum configured amount.
+func IsOverloaded(st *cluster.Settings, health *LSMHealth) bool {
+	if st == nil {
+		return false
+	}
+	maxDelay := ingestDelayTime.Get(&st.SV)
+	return maxDelay > 0 && calculatePreIngestDelay(RocksDBConfig{Settings: st}, health) >= maxDelay
+}
+
+// IngestDelay returns the delay PreIngestDelay imposes on SST ingestions when
+// the engine is in the given LSM health.
+func IngestDelay(st *cluster.Settings, health *LSMHealth) time.Duration {
+	if st == nil {
+		return 0
+	}
+	return calculatePreIngestDelay(RocksDBConfig{Settings: st}, health)
+}
+
+func calculatePreIngestDelay(cfg RocksDBConfig, health *LSMHealth) time.Duration {
 	maxDelay := ingestDelayTime.Get(&cfg.Settings.SV)
 	l0Filelimit := ingestDelayL0Threshold.Get(&cfg.Settings.SV)
 	compactionLimit := ingestDelayPendingLimit.Get(&cfg.Settings.SV)
+	readAmpLimit := ingestDelayReadAmpThreshold.Get(&cfg.Settings.SV)
 
-	if stats.PendingCompactionBytesEstimate >= compactionLimit {
+	if health.PendingCompactionBytesEstimate >= compactionLimit {
 		return maxDelay
 	}
+	// The delay ramps up to the maximum over ten files (or sstables consulted
+	// by reads) past the thresholds.
 	const ramp = 10
-	if stats.L0FileCount > l0Filelimit {
+	var excess int64
+	if health.L0FileCount > l0Filelimit {
+		excess = health.L0FileCount - l0Filelimit
+	}
+	if readAmpLimit > 0 && health.ReadAmplification-readAmpLimit > excess {
+		excess = health.ReadAmplification - readAmpLimit
+	}
+	if excess > 0 {
 		delayPerFile := maxDelay / time.Duration(ramp)
-		targetDelay := time.Duration(stats.L0FileCount-l0Filelimit) * delayPerFile
+		targetDelay := time.Duration(excess) * delayPerFile
 		if targetDelay > maxDelay {
 			return maxDelay
 		}
@@ -3088,9 +3303,12 @@ func (r *RocksDB) IngestExternalFiles(
 	))
 }
 
-// WriteFile writes data to a file in this RocksDB's env.
+// WriteFile writes data to a file in this RocksDB's env and syncs it.
 func (r *RocksDB) WriteFile(filename string, data []byte) error {
-	return statusToError(C.DBEnvWriteFile(r.rdb, goToCSlice([]byte(filename)), goToCSlice(data)))
+	if err := statusToError(C.DBEnvWriteFile(r.rdb, goToCSlice([]byte(filename)), goToCSlice(data))); err != nil {
+		return notFoundErrOrDefault(err)
+	}
+	return nil
 }
 
 // OpenFile opens a DBFile, which is essentially a rocksdb WritableFile
@@ -3148,6 +3366,65 @@ func (r *RocksDB) LinkFile(oldname, newname string) error {
 	return nil
 }
 
+// MkdirAll creates the given directory and any missing parent directories in
+// this RocksDB's env.
+func (r *RocksDB) MkdirAll(dir string) error {
+	return statusToError(C.DBEnvMkdirAll(r.rdb, goToCSlice([]byte(dir))))
+}
+
+// RenameFile renames the file 'oldname' to 'newname' in this RocksDB's env.
+func (r *RocksDB) RenameFile(oldname, newname string) error {
+	if err := statusToError(C.DBEnvRenameFile(r.rdb, goToCSlice([]byte(oldname)), goToCSlice([]byte(newname)))); err != nil {
+		return notFoundErrOrDefault(err)
+	}
+	return nil
+}
+
+// DeleteDir deletes the given empty directory from this RocksDB's env.
+func (r *RocksDB) DeleteDir(dir string) error {
+	if err := statusToError(C.DBEnvDeleteDir(r.rdb, goToCSlice([]byte(dir)))); err != nil {
+		return notFoundErrOrDefault(err)
+	}
+	return nil
+}
+
+// ListDir returns the names of the children of the given directory in this
+// RocksDB's env. If dir does not exist, ListDir returns no names (no error).
+func (r *RocksDB) ListDir(dir string) ([]string, error) {
+	var names C.DBString
+	if err := statusToError(C.DBEnvListDir(r.rdb, goToCSlice([]byte(dir)), &names)); err != nil {
+		if err := notFoundErrOrDefault(err); err != os.ErrNotExist {
+			return nil, err
+		}
+		return nil, nil
+	}
+	joined := cStringToGoString(names)
+	if joined == "" {
+		return nil, nil
+	}
+	return strings.Split(joined, "\x00"), nil
+}
+
+// FileSize returns the size of the file with the given filename in this
+// RocksDB's env. For encrypted stores, this is the size of the plaintext.
+func (r *RocksDB) FileSize(filename string) (int64, error) {
+	var size C.uint64_t
+	if err := statusToError(C.DBEnvGetFileSize(r.rdb, goToCSlice([]byte(filename)), &size)); err != nil {
+		return 0, notFoundErrOrDefault(err)
+	}
+	return int64(size), nil
+}
+
+// NumFileLinks returns the number of hard links to the file with the given
+// filename in this RocksDB's env.
+func (r *RocksDB) NumFileLinks(filename string) (uint64, error) {
+	var count C.uint64_t
+	if err := statusToError(C.DBEnvNumFileLinks(r.rdb, goToCSlice([]byte(filename)), &count)); err != nil {
+		return 0, notFoundErrOrDefault(err)
+	}
+	return uint64(count), nil
+}
+
 // NewSortedDiskMap implements the MapProvidingEngine interface.
 func (r *RocksDB) NewSortedDiskMap() diskmap.SortedDiskMap {
 	return NewRocksDBMap(r)
@@ -612,6 +612,36 @@ func TestReadAmplification(t *testing.T) {
 	}
 }
 
+func TestL0Sublevels(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	info := func(level int, start, end string) SSTableInfo {
+		return SSTableInfo{
+			Level: level,
+			Start: MakeMVCCMetadataKey(roachpb.Key(start)),
+			End:   MakeMVCCMetadataKey(roachpb.Key(end)),
+		}
+	}
+
+	for i, tc := range []struct {
+		tables   SSTableInfos
+		expected int
+	}{
+		{SSTableInfos{}, 0},
+		{SSTableInfos{info(1, "a", "z")}, 0},
+		// Disjoint sstables, as left by ingestions, form a single sublevel.
+		{SSTableInfos{info(0, "a", "b"), info(0, "c", "d"), info(0, "e", "f")}, 1},
+		// Bounds are inclusive.
+		{SSTableInfos{info(0, "a", "c"), info(0, "c", "d")}, 2},
+		{SSTableInfos{info(0, "a", "z"), info(0, "b", "c"), info(0, "d", "e"), info(0, "d", "f")}, 3},
+		{SSTableInfos{info(0, "a", "b"), info(1, "a", "b"), info(2, "a", "b")}, 1},
+	} {
+		if a := tc.tables.L0Sublevels(); a != tc.expected {
+			t.Errorf("%d: got %d, expected %d", i, a, tc.expected)
+		}
+	}
+}
+
 func TestInMemIllegalOption(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -1631,6 +1661,59 @@ func TestSstFileWriterTimeBound(t *testing.T) {
 	}
 }
 
+// Verify that the range deletion tombstones of an ingested sstable clear the
+// existing keys they cover, but not the keys of the sstable itself.
+func TestSstFileWriterClearRange(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	db := setupMVCCInMemRocksDB(t, "sstwriter-clearrange").(InMem)
+	defer db.Close()
+
+	for _, k := range []string{"a", "b", "c", "d"} {
+		if err := db.Put(MakeMVCCMetadataKey(roachpb.Key(k)), []byte("old")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sst, err := MakeRocksDBSstFileWriter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sst.Close()
+	if err := sst.ClearRange(
+		MakeMVCCMetadataKey(roachpb.Key("a")), MakeMVCCMetadataKey(roachpb.Key("d")),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if err := sst.Add(MVCCKeyValue{
+		Key:   MakeMVCCMetadataKey(roachpb.Key("b")),
+		Value: []byte("new"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	sstContents, err := sst.Finish()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WriteFile(`ingest`, sstContents); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.IngestExternalFiles(ctx, []string{`ingest`}, true, true); err != nil {
+		t.Fatal(err)
+	}
+
+	for k, expected := range map[string]string{"a": "", "b": "new", "c": "", "d": "old"} {
+		val, err := db.Get(MakeMVCCMetadataKey(roachpb.Key(k)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(val) != expected {
+			t.Errorf("%s: expected %q, got %q", k, expected, val)
+		}
+	}
+}
+
 // TestRocksDBWALFileEmptyBatch verifies that committing an empty batch does
 // not write an entry to RocksDB's write-ahead log.
 func TestRocksDBWALFileEmptyBatch(t *testing.T) {
@@ -1747,19 +1830,37 @@ func TestIngestDelayLimit(t *testing.T) {
 	max, ramp := time.Second*5, time.Second*5/10
 
 	for _, tc := range []struct {
-		exp   time.Duration
-		stats Stats
+		exp    time.Duration
+		health LSMHealth
+	}{
+		{0, LSMHealth{}},
+		{0, LSMHealth{L0FileCount: 19}},
+		{0, LSMHealth{L0FileCount: 20}},
+		{ramp, LSMHealth{L0FileCount: 21}},
+		{ramp * 2, LSMHealth{L0FileCount: 22}},
+		{max, LSMHealth{L0FileCount: 55}},
+		{0, LSMHealth{PendingCompactionBytesEstimate: 20 << 30}},
+		{max, LSMHealth{L0FileCount: 25, PendingCompactionBytesEstimate: 80 << 30}},
+		{max, LSMHealth{L0FileCount: 35, PendingCompactionBytesEstimate: 20 << 30}},
+		// Read amplification is ignored unless a threshold is set.
+		{0, LSMHealth{ReadAmplification: 100}},
+	} {
+		require.Equal(t, tc.exp, calculatePreIngestDelay(cfg, &tc.health))
+		require.Equal(t, tc.exp == max, IsOverloaded(cfg.Settings, &tc.health))
+	}
+
+	ingestDelayReadAmpThreshold.Override(&cfg.Settings.SV, 30)
+	for _, tc := range []struct {
+		exp    time.Duration
+		health LSMHealth
 	}{
-		{0, Stats{}},
-		{0, Stats{L0FileCount: 19}},
-		{0, Stats{L0FileCount: 20}},
-		{ramp, Stats{L0FileCount: 21}},
-		{ramp * 2, Stats{L0FileCount: 22}},
-		{max, Stats{L0FileCount: 55}},
-		{0, Stats{PendingCompactionBytesEstimate: 20 << 30}},
-		{max, Stats{L0FileCount: 25, PendingCompactionBytesEstimate: 80 << 30}},
-		{max, Stats{L0FileCount: 35, PendingCompactionBytesEstimate: 20 << 30}},
+		{0, LSMHealth{ReadAmplification: 30}},
+		{ramp, LSMHealth{ReadAmplification: 31}},
+		{ramp * 3, LSMHealth{L0FileCount: 22, ReadAmplification: 33}},
+		{ramp * 3, LSMHealth{L0FileCount: 23, ReadAmplification: 32}},
+		{max, LSMHealth{ReadAmplification: 65}},
 	} {
-		require.Equal(t, tc.exp, calculatePreIngestDelay(cfg, &tc.stats))
+		require.Equal(t, tc.exp, calculatePreIngestDelay(cfg, &tc.health))
+		require.Equal(t, tc.exp == max, IsOverloaded(cfg.Settings, &tc.health))
 	}
 }
@@ -27,6 +27,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/gossip"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/storage/abortspan"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
@@ -40,6 +41,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
 )
 
 const (
@@ -60,8 +62,46 @@ const (
 	// gcKeyVersionChunkBytes is the threshold size for splitting
 	// GCRequests into multiple batches.
 	gcKeyVersionChunkBytes = base.ChunkRaftCommandThresholdBytes
+
+	// gcKeyRateBurst is the burst (in key versions) of the GC queue's key rate
+	// limiter.
+	gcKeyRateBurst = 1000
 )
 
+// gcMaxRate is the rate limit (bytes/sec) for the key versions removed by the
+// GC queue on a store. The GC queue also draws from the store's background IO
+// budget.
+var gcMaxRate = func() *settings.ByteSizeSetting {
+	s := settings.RegisterByteSizeSetting(
+		"kv.gc.max_rate",
+		"the rate limit (bytes/sec) for the key versions garbage collected on a store",
+		1<<40,
+	)
+	s.SetNodeOverridable()
+	return s
+}()
+
+// gcMaxKeysPerSecond is the rate limit (key versions/sec) for the key versions
+// removed by the GC queue on a store.
+var gcMaxKeysPerSecond = func() *settings.IntSetting {
+	s := settings.RegisterNonNegativeIntSetting(
+		"kv.gc.max_keys_per_second",
+		"the rate limit (key versions/sec) for the key versions garbage collected on a store; "+
+			"0 disables the limit",
+		0,
+	)
+	s.SetNodeOverridable()
+	return s
+}()
+
+// gcKeyRate returns the rate limit for the GC queue's key rate limiter.
+func gcKeyRate(sv *settings.Values) rate.Limit {
+	if n := gcMaxKeysPerSecond.Get(sv); n > 0 {
+		return rate.Limit(n)
+	}
+	return rate.Inf
+}
+
 // gcQueue manages a queue of replicas slated to be scanned in their
 // entirety using the MVCC versions iterator. The gc queue manages the
 // following tasks:
@@ -500,7 +540,14 @@ func (gcq *gcQueue) process(ctx context.Context, repl *Replica, sysCfg *config.S
 	}
 
 	log.Eventf(ctx, "processing replica with score %s", r)
-	return gcq.processImpl(ctx, repl, sysCfg, now)
+	if err := gcq.processImpl(ctx, repl, sysCfg, now); err != nil {
+		return err
+	}
+	// Record the time of the GC, which crdb_internal.node_gc_progress reports.
+	if err := repl.setQueueLastProcessed(ctx, gcq.name, now); err != nil {
+		log.VErrEventf(ctx, 2, "failed to update last processed time: %v", err)
+	}
+	return nil
 }
 
 // NoopGCer implements GCer by doing nothing.
@@ -555,6 +602,14 @@ func (r *replicaGCer) SetGCThreshold(ctx context.Context, thresh GCThreshold) er
 	return r.send(ctx, req)
 }
 
+// pace implements gcPacer by waiting on the store's GC rate limiters.
+func (r *replicaGCer) pace(ctx context.Context, versions int, bytes int64) error {
+	if err := r.repl.store.limiters.GCKeyRate.WaitN(ctx, versions); err != nil {
+		return err
+	}
+	return r.repl.store.limiters.GCByteRate.WaitN(ctx, int(bytes))
+}
+
 func (r *replicaGCer) GC(ctx context.Context, keys []roachpb.GCRequest_GCKey) error {
 	if len(keys) == 0 {
 		return nil
@@ -573,7 +628,15 @@ func (gcq *gcQueue) processImpl(
 	// Lookup the descriptor and GC policy for the zone containing this key range.
 	desc, zone := repl.DescAndZone()
 
-	info, err := RunGC(ctx, desc, snap, now, *zone.GC, &replicaGCer{repl: repl},
+	// Reload the protected timestamps so that any protection committed before
+	// this GC run started is respected.
+	ptCache := repl.store.cfg.ProtectedTimestampCache
+	if err := ptCache.Refresh(ctx); err != nil {
+		return errors.Wrap(err, "failed to refresh protected timestamps")
+	}
+	protected := ptCache.Earliest(desc.RSpan().AsRawSpanWithNoLocals())
+
+	info, err := RunGC(ctx, desc, snap, now, *zone.GC, protected, &replicaGCer{repl: repl},
 		func(ctx context.Context, intents []roachpb.Intent) error {
 			intentCount, err := repl.store.intentResolver.CleanupIntents(ctx, intents, now, roachpb.PUSH_ABORT)
 			if err == nil {
@@ -641,8 +704,12 @@ type GCInfo struct {
 	// ResolveTotal is the total number of attempted intent resolutions in
 	// this cycle.
 	ResolveTotal int
-	// Threshold is the computed expiration timestamp. Equal to `Now - Policy`.
+	// Threshold is the computed expiration timestamp. Equal to `Now - Policy`,
+	// unless held back by ProtectedTimestamp.
 	Threshold hlc.Timestamp
+	// ProtectedTimestamp is the earliest protected timestamp of the range, if
+	// any. Threshold is kept below it.
+	ProtectedTimestamp hlc.Timestamp
 	// AffectedVersionsKeyBytes is the number of (fully encoded) bytes deleted from keys in the storage engine.
 	// Note that this does not account for compression that the storage engine uses to store data on disk. Real
 	// space savings tends to be smaller due to this compression, and space may be released only at a later point
@@ -686,18 +753,28 @@ type GCer interface {
 	GC(context.Context, []roachpb.GCRequest_GCKey) error
 }
 
+// A gcPacer is a GCer which paces the removal of key versions. Before each GC
+// of a chunk of key versions, RunGC passes the number of versions in the chunk
+// and their total size to pace, which blocks until they may be removed.
+type gcPacer interface {
+	pace(ctx context.Context, versions int, bytes int64) error
+}
+
 // RunGC runs garbage collection for the specified descriptor on the
 // provided Engine (which is not mutated). It uses the provided gcFn
 // to run garbage collection once on all implicated spans,
 // cleanupIntentsFn to resolve intents synchronously, and
 // cleanupTxnIntentsAsyncFn to asynchronously cleanup intents and
-// associated transaction record on success.
+// associated transaction record on success. If protected is set, the GC
+// threshold is kept below it so that all the data visible at protected
+// remains readable.
 func RunGC(
 	ctx context.Context,
 	desc *roachpb.RangeDescriptor,
 	snap engine.Reader,
 	now hlc.Timestamp,
 	policy config.GCPolicy,
+	protected hlc.Timestamp,
 	gcer GCer,
 	cleanupIntentsFn cleanupIntentsFunc,
 	cleanupTxnIntentsAsyncFn cleanupTxnIntentsAsyncFunc,
@@ -715,7 +792,11 @@ func RunGC(
 	txnExp := now.Add(-storagebase.TxnCleanupThreshold.Nanoseconds(), 0)
 
 	gc := engine.MakeGarbageCollector(now, policy)
+	if protected != (hlc.Timestamp{}) && !gc.Threshold.Less(protected) {
+		gc.Threshold = protected.Prev()
+	}
 	infoMu.Threshold = gc.Threshold
+	infoMu.ProtectedTimestamp = protected
 	infoMu.TxnSpanGCThreshold = txnExp
 
 	if err := gcer.SetGCThreshold(ctx, GCThreshold{
@@ -727,12 +808,26 @@ func RunGC(
 
 	var batchGCKeys []roachpb.GCRequest_GCKey
 	var batchGCKeysBytes int64
+	// batchGCVersions and batchGCVersionBytes are the number and the size of
+	// the key versions removed by batchGCKeys.
+	var batchGCVersions int
+	var batchGCVersionBytes int64
 	var expBaseKey roachpb.Key
 	var keys []engine.MVCCKey
 	var vals [][]byte
 	var keyBytes int64
 	var valBytes int64
 
+	pacer, _ := gcer.(gcPacer)
+	gcBatch := func() error {
+		if pacer != nil {
+			if err := pacer.pace(ctx, batchGCVersions, batchGCVersionBytes); err != nil {
+				return err
+			}
+		}
+		return gcer.GC(ctx, batchGCKeys)
+	}
+
 	// Maps from txn ID to txn and intent key slice.
 	txnMap := map[uuid.UUID]*roachpb.Transaction{}
 	intentSpanMap := map[uuid.UUID][]roachpb.Span{}
@@ -794,18 +889,22 @@ func RunGC(
 						infoMu.GCInfo.AffectedVersionsValBytes += valBytes
 
 						batchGCKeysBytes += keyBytes
+						batchGCVersions++
+						batchGCVersionBytes += keyBytes + valBytes
 						// If the current key brings the batch over the target
 						// size, add the current timestamp to finish the current
 						// chunk and start a new one.
 						if batchGCKeysBytes >= gcKeyVersionChunkBytes {
 							batchGCKeys = append(batchGCKeys, roachpb.GCRequest_GCKey{Key: expBaseKey, Timestamp: keys[i].Timestamp})
 
-							err := gcer.GC(ctx, batchGCKeys)
+							err := gcBatch()
 
 							// Succeed or fail, allow releasing the memory backing batchGCKeys.
 							iter.ResetAllocator()
 							batchGCKeys = nil
 							batchGCKeysBytes = 0
+							batchGCVersions = 0
+							batchGCVersionBytes = 0
 
 							if err != nil {
 								// Even though we are batching the GC process, it's
@@ -861,7 +960,7 @@ func RunGC(
 	// Handle last collected set of keys/vals.
 	processKeysAndValues()
 	if len(batchGCKeys) > 0 {
-		if err := gcer.GC(ctx, batchGCKeys); err != nil {
+		if err := gcBatch(); err != nil {
 			return GCInfo{}, err
 		}
 	}
@@ -366,6 +366,19 @@ func TestGCQueueMakeGCScoreRealistic(t *testing.T) {
 
 // TestGCQueueProcess creates test data in the range over various time
 // scales and verifies that scan queue process properly GCs test data.
+// pacingGCer is a NoopGCer which records the key versions it is asked to pace.
+type pacingGCer struct {
+	NoopGCer
+	versions int
+	bytes    int64
+}
+
+func (g *pacingGCer) pace(_ context.Context, versions int, bytes int64) error {
+	g.versions += versions
+	g.bytes += bytes
+	return nil
+}
+
 func TestGCQueueProcess(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	tc := testContext{}
@@ -494,6 +507,7 @@ func TestGCQueueProcess(t *testing.T) {
 	var expectedVersionsValBytes int64 = 5 * 10
 
 	// Call RunGC with dummy functions to get current GCInfo.
+	var gcer pacingGCer
 	gcInfo, err := func() (GCInfo, error) {
 		snap := tc.repl.store.Engine().NewSnapshot()
 		desc := tc.repl.Desc()
@@ -506,8 +520,8 @@ func TestGCQueueProcess(t *testing.T) {
 
 		ctx := context.Background()
 		now := tc.Clock().Now()
-		return RunGC(ctx, desc, snap, now, *zone.GC,
-			NoopGCer{},
+		return RunGC(ctx, desc, snap, now, *zone.GC, hlc.Timestamp{}, /* protected */
+			&gcer,
 			func(ctx context.Context, intents []roachpb.Intent) error {
 				return nil
 			},
@@ -524,6 +538,13 @@ func TestGCQueueProcess(t *testing.T) {
 	if gcInfo.AffectedVersionsValBytes != expectedVersionsValBytes {
 		t.Errorf("expected total values size: %d bytes; got %d bytes", expectedVersionsValBytes, gcInfo.AffectedVersionsValBytes)
 	}
+	// All of the GC'able versions are paced.
+	if gcer.versions != 7 {
+		t.Errorf("expected 7 paced versions; got %d", gcer.versions)
+	}
+	if exp := expectedVersionsKeyBytes + expectedVersionsValBytes; gcer.bytes != exp {
+		t.Errorf("expected %d paced bytes; got %d", exp, gcer.bytes)
+	}
 
 	// Process through a scan queue.
 	gcQ := newGCQueue(tc.store, tc.gossip)
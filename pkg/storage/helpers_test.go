@@ -31,6 +31,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/config"
 	"github.com/cockroachdb/cockroach/pkg/internal/client"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/storage/batcheval"
 	"github.com/cockroachdb/cockroach/pkg/storage/batcheval/result"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
@@ -46,6 +47,13 @@ import (
 	"github.com/pkg/errors"
 )
 
+// SetLearnerReplicasEnabled overrides the kv.learner_replicas.enabled
+// setting, for tests that exercise replica additions via preemptive
+// snapshots.
+func SetLearnerReplicasEnabled(st *cluster.Settings, enabled bool) {
+	learnerReplicasEnabled.Override(&st.SV, enabled)
+}
+
 // AddReplica adds the replica to the store's replica map and to the sorted
 // replicasByKey slice. To be used only by unittests.
 func (s *Store) AddReplica(repl *Replica) error {
@@ -149,6 +157,12 @@ func (s *Store) EnqueueRaftUpdateCheck(rangeID roachpb.RangeID) {
 	s.enqueueRaftUpdateCheck(rangeID)
 }
 
+// RepairRaftLeadershipColocation scans the replicas of the store for
+// leaseholders which are not the Raft leader of their range and repairs them.
+func (s *Store) RepairRaftLeadershipColocation(ctx context.Context) {
+	s.repairRaftLeadershipColocation(ctx)
+}
+
 func manualQueue(s *Store, q queueImpl, repl *Replica) error {
 	cfg := s.Gossip().GetSystemConfig()
 	if cfg == nil {
@@ -170,7 +184,7 @@ func (s *Store) ManualReplicaGC(repl *Replica) error {
 }
 
 func (s *Store) ReservationCount() int {
-	return len(s.snapshotApplySem)
+	return s.snapshotRecvQueue.inUse()
 }
 
 // ClearClosedTimestampStorage clears the closed timestamp storage of all
@@ -328,6 +342,27 @@ func (r *Replica) ShouldBackpressureWrites() bool {
 	return r.shouldBackpressureWrites()
 }
 
+// LeaseholderQPS returns the average number of requests per second received
+// by the replica as the leaseholder, and the duration over which it was
+// measured.
+func (r *Replica) LeaseholderQPS() (float64, time.Duration) {
+	return r.leaseholderStats.avgQPS()
+}
+
+// ExceedsBackpressureHardCap returns whether writes to the range queue it for
+// a split when none is ongoing.
+func (r *Replica) ExceedsBackpressureHardCap() bool {
+	return r.exceedsBackpressureHardCap()
+}
+
+// BackpressureRangeSizeHardCapMultiplier exports the hard cap on the size of
+// ranges for tests.
+var BackpressureRangeSizeHardCapMultiplier = backpressureRangeSizeHardCapMultiplier
+
+// RaftApplicationTraceSampleRate exports the sample rate of the tracing of the
+// application of Raft commands on followers for tests.
+var RaftApplicationTraceSampleRate = raftApplicationTraceSampleRate
+
 // GetRaftLogSize returns the approximate raft log size and whether it is
 // trustworthy.. See r.mu.raftLogSize for details.
 func (r *Replica) GetRaftLogSize() (int64, bool) {
@@ -473,7 +508,7 @@ func (r *Replica) GetQueueLastProcessed(ctx context.Context, queue string) (hlc.
 func (r *Replica) UnquiesceAndWakeLeader() {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.unquiesceAndWakeLeaderLocked()
+	r.unquiesceAndWakeLeaderLocked(unquiesceReasonRaftGroup)
 }
 
 func (nl *NodeLiveness) SetDrainingInternal(
@@ -68,6 +68,25 @@ const (
 	// TODO(ajwerner): justify this value
 	intentResolverBatchSize = 100
 
+	// intentResolverRangeBatchSize is the maximum number of intent spans that
+	// will be resolved in a single batch. The number of intents resolved by the
+	// batch is bounded by intentResolverBatchSize, so the batch is paginated
+	// if its spans cover more intents than that.
+	intentResolverRangeBatchSize = 10
+
+	// intentResolverBatchBytes is the maximum total size of the requests in a
+	// single intent resolution batch. Each request carries the key (or span)
+	// of the intent it resolves, so large keys would otherwise result in large
+	// Raft commands.
+	intentResolverBatchBytes = 4 << 20 // 4 MiB
+
+	// defaultInFlightBatchLimit is the default number of intent resolution
+	// batches (of each kind) the intent resolver of a store sends concurrently
+	// before applying backpressure to the callers. This keeps the commit of
+	// large transactions from flooding the cluster with intent resolution
+	// traffic.
+	defaultInFlightBatchLimit = 100
+
 	// cleanupIntentsTxnsPerBatch is the number of transactions whose
 	// corresponding intents will be resolved at a time. Intents are batched
 	// by transaction to avoid timeouts while resolving intents and ensure that
@@ -110,6 +129,10 @@ type Config struct {
 	MaxGCBatchIdle               time.Duration
 	MaxIntentResolutionBatchWait time.Duration
 	MaxIntentResolutionBatchIdle time.Duration
+	// InFlightBatchLimit is the number of intent resolution batches in flight
+	// above which callers resolving intents experience backpressure. 0 uses
+	// defaultInFlightBatchLimit.
+	InFlightBatchLimit int
 }
 
 // IntentResolver manages the process of pushing transactions and
@@ -127,8 +150,9 @@ type IntentResolver struct {
 
 	rdc kvbase.RangeDescriptorCache
 
-	gcBatcher *requestbatcher.RequestBatcher
-	irBatcher *requestbatcher.RequestBatcher
+	gcBatcher      *requestbatcher.RequestBatcher
+	irBatcher      *requestbatcher.RequestBatcher
+	irRangeBatcher *requestbatcher.RequestBatcher
 
 	mu struct {
 		syncutil.Mutex
@@ -161,6 +185,9 @@ func setConfigDefaults(c *Config) {
 	if c.MaxIntentResolutionBatchWait == 0 {
 		c.MaxIntentResolutionBatchWait = defaultIntentResolutionBatchWait
 	}
+	if c.InFlightBatchLimit == 0 {
+		c.InFlightBatchLimit = defaultInFlightBatchLimit
+	}
 	if c.RangeDescriptorCache == nil {
 		c.RangeDescriptorCache = nopRangeDescriptorCache{}
 	}
@@ -205,16 +232,41 @@ func New(c Config) *IntentResolver {
 		batchSize = c.TestingKnobs.MaxIntentResolutionBatchSize
 	}
 	ir.irBatcher = requestbatcher.New(requestbatcher.Config{
-		Name:            "intent_resolver_ir_batcher",
-		MaxMsgsPerBatch: batchSize,
-		MaxWait:         c.MaxIntentResolutionBatchWait,
-		MaxIdle:         c.MaxIntentResolutionBatchIdle,
-		Stopper:         c.Stopper,
-		Sender:          c.DB.NonTransactionalSender(),
+		Name:                      "intent_resolver_ir_batcher",
+		MaxMsgsPerBatch:           batchSize,
+		MaxSizePerBatch:           intentResolverBatchBytes,
+		MaxWait:                   c.MaxIntentResolutionBatchWait,
+		MaxIdle:                   c.MaxIntentResolutionBatchIdle,
+		InFlightBackpressureLimit: c.InFlightBatchLimit,
+		Stopper:                   c.Stopper,
+		Sender:                    ir.countingSender(c.DB.NonTransactionalSender()),
+	})
+	ir.irRangeBatcher = requestbatcher.New(requestbatcher.Config{
+		Name:                      "intent_resolver_ir_range_batcher",
+		MaxMsgsPerBatch:           intentResolverRangeBatchSize,
+		MaxSizePerBatch:           intentResolverBatchBytes,
+		MaxKeysPerBatchReq:        intentResolverBatchSize,
+		MaxWait:                   c.MaxIntentResolutionBatchWait,
+		MaxIdle:                   c.MaxIntentResolutionBatchIdle,
+		InFlightBackpressureLimit: c.InFlightBatchLimit,
+		Stopper:                   c.Stopper,
+		Sender:                    ir.countingSender(c.DB.NonTransactionalSender()),
 	})
 	return ir
 }
 
+// countingSender wraps the given sender to keep track of the intent
+// resolution batches sent through it in the metrics.
+func (ir *IntentResolver) countingSender(s client.Sender) client.Sender {
+	return client.SenderFunc(func(
+		ctx context.Context, ba roachpb.BatchRequest,
+	) (*roachpb.BatchResponse, *roachpb.Error) {
+		ir.Metrics.IntentResolverBatchesSent.Inc(1)
+		ir.Metrics.IntentResolverBatchedRequests.Inc(int64(len(ba.Requests)))
+		return s.Send(ctx, ba)
+	})
+}
+
 // NumContended exists to ease writing tests at the storage level above which
 // want to verify that contention is occurring within the IntentResolver.
 func (ir *IntentResolver) NumContended(key roachpb.Key) int {
@@ -929,25 +981,37 @@ func (ir *IntentResolver) ResolveIntents(
 	}
 
 	// Resolve spans differently. We don't know how many intents will be
-	// swept up with each request, so we limit the spanning resolve
-	// requests to a maximum number of keys and resume as necessary.
-	for _, req := range resolveRangeReqs {
-		for {
-			b := &client.Batch{}
-			b.Header.MaxSpanRequestKeys = intentResolverBatchSize
-			b.AddRawRequest(req)
-			if err := ir.db.Run(ctx, b); err != nil {
+	// swept up with each request, so the batches of spanning resolve requests
+	// are limited to a maximum number of keys and we resume as necessary.
+	for len(resolveRangeReqs) > 0 {
+		respChans := make([]chan requestbatcher.Response, len(resolveRangeReqs))
+		for i, req := range resolveRangeReqs {
+			respChans[i] = make(chan requestbatcher.Response, 1)
+			rangeID := ir.lookupRangeID(ctx, req.Header().Key)
+			if err := ir.irRangeBatcher.SendWithChan(ctx, respChans[i], rangeID, req); err != nil {
 				return err
 			}
-			// Check response to see if it must be resumed.
-			resp := b.RawResponse().Responses[0].GetInner().(*roachpb.ResolveIntentRangeResponse)
-			if resp.ResumeSpan == nil {
-				break
+		}
+		var resumeReqs []roachpb.Request
+		for i, req := range resolveRangeReqs {
+			select {
+			case resp := <-respChans[i]:
+				if resp.Err != nil {
+					return resp.Err
+				}
+				// Check response to see if it must be resumed.
+				rResp := resp.Resp.(*roachpb.ResolveIntentRangeResponse)
+				if rResp.ResumeSpan == nil {
+					continue
+				}
+				reqCopy := *(req.(*roachpb.ResolveIntentRangeRequest))
+				reqCopy.SetSpan(*rResp.ResumeSpan)
+				resumeReqs = append(resumeReqs, &reqCopy)
+			case <-ctx.Done():
+				return ctx.Err()
 			}
-			reqCopy := *(req.(*roachpb.ResolveIntentRangeRequest))
-			reqCopy.SetSpan(*resp.ResumeSpan)
-			req = &reqCopy
 		}
+		resolveRangeReqs = resumeReqs
 	}
 
 	return nil
@@ -825,6 +825,60 @@ func TestCleanupIntents(t *testing.T) {
 	}
 }
 
+// TestResolveIntentRangeBatching verifies that intent spans are resolved in
+// batches limited to a maximum number of keys, and that the spans which
+// aren't fully resolved by a batch are resumed.
+func TestResolveIntentRangeBatching(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	clock := hlc.NewClock(hlc.UnixNano, time.Nanosecond)
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.Background())
+
+	txn := newTransaction("txn", roachpb.Key("a"), 1, clock)
+	var intents []roachpb.Intent
+	for i := 0; i < intentResolverRangeBatchSize; i++ {
+		key := roachpb.Key(fmt.Sprintf("k%02d", i))
+		intents = append(intents, roachpb.Intent{
+			Span:   roachpb.Span{Key: key, EndKey: key.PrefixEnd()},
+			Txn:    txn.TxnMeta,
+			Status: roachpb.COMMITTED,
+		})
+	}
+	sf := newSendFuncs(t,
+		// The first batch holds all the spans, but only resolves the first one
+		// before hitting the key limit.
+		func(ba roachpb.BatchRequest) (*roachpb.BatchResponse, *roachpb.Error) {
+			assert.Len(t, ba.Requests, intentResolverRangeBatchSize)
+			assert.Equal(t, int64(intentResolverBatchSize), ba.MaxSpanRequestKeys)
+			resp := &roachpb.BatchResponse{}
+			for i, r := range ba.Requests {
+				var rResp roachpb.ResolveIntentRangeResponse
+				if i > 0 {
+					span := r.GetInner().Header().Span()
+					rResp.ResumeSpan = &span
+				}
+				resp.Add(&rResp)
+			}
+			return resp, nil
+		},
+		// The second batch resumes the remaining spans.
+		func(ba roachpb.BatchRequest) (*roachpb.BatchResponse, *roachpb.Error) {
+			assert.Len(t, ba.Requests, intentResolverRangeBatchSize-1)
+			return respForResolveIntentBatch(t, ba), nil
+		},
+	)
+	ir := newIntentResolverWithSendFuncs(Config{
+		Stopper: stopper,
+		Clock:   clock,
+	}, sf)
+	if err := ir.ResolveIntents(context.Background(), intents, ResolveOptions{Wait: true}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, sf.len())
+	assert.Equal(t, int64(2), ir.Metrics.IntentResolverBatchesSent.Count())
+	assert.Equal(t, int64(2*intentResolverRangeBatchSize-1), ir.Metrics.IntentResolverBatchedRequests.Count())
+}
+
 func newTransaction(
 	name string, baseKey roachpb.Key, userPriority roachpb.UserPriority, clock *hlc.Clock,
 ) *roachpb.Transaction {
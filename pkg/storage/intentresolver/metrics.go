@@ -25,17 +25,33 @@ var (
 		Measurement: "Intent Resolutions",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaIntentResolverBatchesSent = metric.Metadata{
+		Name:        "intentresolver.batches.sent",
+		Help:        "Number of batches of intent resolution requests sent",
+		Measurement: "Batches",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaIntentResolverBatchedRequests = metric.Metadata{
+		Name:        "intentresolver.batches.requests",
+		Help:        "Number of intent resolution requests sent in batches",
+		Measurement: "Requests",
+		Unit:        metric.Unit_COUNT,
+	}
 )
 
 // Metrics contains the metrics for the IntentResolver.
 type Metrics struct {
 	// Intent resolver metrics.
-	IntentResolverAsyncThrottled *metric.Counter
+	IntentResolverAsyncThrottled  *metric.Counter
+	IntentResolverBatchesSent     *metric.Counter
+	IntentResolverBatchedRequests *metric.Counter
 }
 
 func makeMetrics() Metrics {
 	// Intent resolver metrics.
 	return Metrics{
-		IntentResolverAsyncThrottled: metric.NewCounter(metaIntentResolverAsyncThrottled),
+		IntentResolverAsyncThrottled:  metric.NewCounter(metaIntentResolverAsyncThrottled),
+		IntentResolverBatchesSent:     metric.NewCounter(metaIntentResolverBatchesSent),
+		IntentResolverBatchedRequests: metric.NewCounter(metaIntentResolverBatchedRequests),
 	}
 }
@@ -0,0 +1,172 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package metering defines the hooks through which the storage layer reports
+// the KV work it does on behalf of requests, so that deployments which need
+// to attribute that work to keyspaces (for instance to charge it back to the
+// owners of the data) can do so by plugging in a Meter.
+//
+// A Meter is notified once per batch evaluated by a replica: for reads after
+// the batch was evaluated, and for writes once the batch was evaluated into a
+// Raft proposal. Work which doesn't originate from a batch, such as Raft log
+// application on followers or the work done by the store's queues, isn't
+// metered.
+package metering
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// Op is the kind of work an Event accounts for.
+type Op int
+
+const (
+	// Read is the evaluation of a read-only batch.
+	Read Op = iota
+	// Write is the evaluation of a batch into a Raft proposal.
+	Write
+)
+
+func (op Op) String() string {
+	switch op {
+	case Read:
+		return "read"
+	case Write:
+		return "write"
+	default:
+		return fmt.Sprintf("Op(%d)", int(op))
+	}
+}
+
+// Event describes the work done by a replica to serve a batch of requests.
+type Event struct {
+	Op Op
+	// StoreID and RangeID identify the replica which did the work.
+	StoreID roachpb.StoreID
+	RangeID roachpb.RangeID
+	// Span is the addressed key span touched by the batch.
+	Span roachpb.RSpan
+	// Requests is the number of requests in the batch.
+	Requests int
+	// RequestBytes is the encoded size of the batch.
+	RequestBytes int64
+	// Bytes is the size of the data read or written: the encoded size of the
+	// response for reads, and the size of the write batch proposed to Raft
+	// for writes.
+	Bytes int64
+}
+
+// A Meter is notified of the work done by the storage layer to serve
+// requests. Meters are called on the request path and from many goroutines
+// at once; implementations must be safe for concurrent use and return
+// quickly.
+type Meter interface {
+	Record(context.Context, Event)
+}
+
+// KeyspaceFunc maps a key to the keyspace to which work on it is attributed.
+type KeyspaceFunc func(roachpb.RKey) string
+
+// SystemKeyspace is the keyspace to which TableKeyspace attributes all keys
+// outside of the SQL table data.
+const SystemKeyspace = "system"
+
+// TableKeyspace is a KeyspaceFunc which attributes the keys of SQL tables to
+// their table (e.g. "/Table/53") and all other keys to SystemKeyspace.
+func TableKeyspace(key roachpb.RKey) string {
+	if _, tableID, err := keys.DecodeTablePrefix(key.AsRawKey()); err == nil {
+		return fmt.Sprintf("/Table/%d", tableID)
+	}
+	return SystemKeyspace
+}
+
+// Totals is the work attributed to a keyspace.
+type Totals struct {
+	ReadBatches  int64
+	ReadRequests int64
+	ReadBytes    int64
+
+	WriteBatches  int64
+	WriteRequests int64
+	WriteBytes    int64
+}
+
+// Aggregator is a Meter which accumulates the work recorded with it per
+// keyspace. A batch is attributed in its entirety to the keyspace of the
+// start of its span.
+type Aggregator struct {
+	keyspaceOf KeyspaceFunc
+
+	mu struct {
+		syncutil.Mutex
+		totals map[string]*Totals
+	}
+}
+
+var _ Meter = (*Aggregator)(nil)
+
+// NewAggregator creates an Aggregator which attributes work to the keyspaces
+// returned by the given function.
+func NewAggregator(keyspaceOf KeyspaceFunc) *Aggregator {
+	a := &Aggregator{keyspaceOf: keyspaceOf}
+	a.mu.totals = make(map[string]*Totals)
+	return a
+}
+
+// Record implements the Meter interface.
+func (a *Aggregator) Record(_ context.Context, ev Event) {
+	keyspace := a.keyspaceOf(ev.Span.Key)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	t, ok := a.mu.totals[keyspace]
+	if !ok {
+		t = &Totals{}
+		a.mu.totals[keyspace] = t
+	}
+	switch ev.Op {
+	case Read:
+		t.ReadBatches++
+		t.ReadRequests += int64(ev.Requests)
+		t.ReadBytes += ev.Bytes
+	case Write:
+		t.WriteBatches++
+		t.WriteRequests += int64(ev.Requests)
+		t.WriteBytes += ev.Bytes
+	}
+}
+
+// Totals returns the work accumulated per keyspace since the Aggregator was
+// created or last reset.
+func (a *Aggregator) Totals() map[string]Totals {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	totals := make(map[string]Totals, len(a.mu.totals))
+	for keyspace, t := range a.mu.totals {
+		totals[keyspace] = *t
+	}
+	return totals
+}
+
+// Reset discards the work accumulated so far.
+func (a *Aggregator) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.mu.totals = make(map[string]*Totals)
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package metering
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestTableKeyspace(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	testCases := []struct {
+		key      roachpb.RKey
+		expected string
+	}{
+		{roachpb.RKeyMin, SystemKeyspace},
+		{roachpb.RKey(keys.Meta2Prefix), SystemKeyspace},
+		{roachpb.RKey(keys.NodeLivenessPrefix), SystemKeyspace},
+		{roachpb.RKey(keys.MakeTablePrefix(keys.DescriptorTableID)), "/Table/3"},
+		{roachpb.RKey(append(keys.MakeTablePrefix(53), "foo"...)), "/Table/53"},
+	}
+	for _, tc := range testCases {
+		if ks := TableKeyspace(tc.key); ks != tc.expected {
+			t.Errorf("%s: expected keyspace %q, got %q", tc.key, tc.expected, ks)
+		}
+	}
+}
+
+func TestAggregator(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	a := NewAggregator(TableKeyspace)
+	span := func(tableID uint32) roachpb.RSpan {
+		prefix := roachpb.RKey(keys.MakeTablePrefix(tableID))
+		return roachpb.RSpan{Key: prefix, EndKey: prefix.PrefixEnd()}
+	}
+	a.Record(ctx, Event{Op: Read, Span: span(53), Requests: 2, Bytes: 100})
+	a.Record(ctx, Event{Op: Write, Span: span(53), Requests: 1, Bytes: 10})
+	a.Record(ctx, Event{Op: Write, Span: span(53), Requests: 3, Bytes: 30})
+	a.Record(ctx, Event{Op: Read, Span: span(54), Requests: 1, Bytes: 5})
+
+	expected := map[string]Totals{
+		"/Table/53": {
+			ReadBatches: 1, ReadRequests: 2, ReadBytes: 100,
+			WriteBatches: 2, WriteRequests: 4, WriteBytes: 40,
+		},
+		"/Table/54": {ReadBatches: 1, ReadRequests: 1, ReadBytes: 5},
+	}
+	if totals := a.Totals(); !reflect.DeepEqual(totals, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, totals)
+	}
+
+	a.Reset()
+	if totals := a.Totals(); len(totals) != 0 {
+		t.Fatalf("expected no totals after reset, got %+v", totals)
+	}
+}
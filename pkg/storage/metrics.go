@@ -54,6 +54,12 @@ var (
 		Measurement: "Replicas",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaLeaseHolderNotRaftLeaderCount = metric.Metadata{
+		Name:        "replicas.leaseholders_not_leaders",
+		Help:        "Number of replicas holding a valid range lease which have not been the Raft leader of their range for longer than kv.raft.leadership_colocation.repair_threshold",
+		Measurement: "Replicas",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaLeaseHolderCount = metric.Metadata{
 		Name:        "replicas.leaseholders",
 		Help:        "Number of lease holders",
@@ -66,6 +72,18 @@ var (
 		Measurement: "Replicas",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaReplicaInvariantViolations = metric.Metadata{
+		Name:        "replicas.invariant_violations",
+		Help:        "Number of Raft commands applied with unhandled fields in their local results",
+		Measurement: "Violations",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaQuarantinedCount = metric.Metadata{
+		Name:        "replicas.quarantined",
+		Help:        "Number of replicas quarantined after violating an invariant checked when applying Raft commands",
+		Measurement: "Replicas",
+		Unit:        metric.Unit_COUNT,
+	}
 
 	// Range metrics.
 	metaRangeCount = metric.Metadata{
@@ -130,6 +148,18 @@ var (
 		Measurement: "Replicas",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaLeaseIntentCleanupResolved = metric.Metadata{
+		Name:        "leases.intent_cleanup.resolved",
+		Help:        "Number of intents of abandoned transactions resolved after lease acquisitions",
+		Measurement: "Intents",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaStoreLivenessLeaseTakeovers = metric.Metadata{
+		Name:        "leases.store_liveness.takeovers",
+		Help:        "Number of leases acquired from stores whose store liveness support was withdrawn",
+		Measurement: "Lease Requests",
+		Unit:        metric.Unit_COUNT,
+	}
 
 	// Storage metrics.
 	metaLiveBytes = metric.Metadata{
@@ -293,6 +323,26 @@ var (
 		Measurement: "Read Ops",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaLatchlessReadsCount = metric.Metadata{
+		Name:        "follower_reads.latchless_count",
+		Help:        "Number of non-transactional reads below the closed timestamp served without latches",
+		Measurement: "Read Ops",
+		Unit:        metric.Unit_COUNT,
+	}
+
+	// Read cache metrics.
+	metaReadCacheHits = metric.Metadata{
+		Name:        "readcache.hits",
+		Help:        "Number of point reads served from a replica's read cache",
+		Measurement: "Read Ops",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaReadCacheMisses = metric.Metadata{
+		Name:        "readcache.misses",
+		Help:        "Number of point reads eligible for a replica's read cache which were evaluated",
+		Measurement: "Read Ops",
+		Unit:        metric.Unit_COUNT,
+	}
 
 	// RocksDB metrics.
 	metaRdbBlockCacheHits = metric.Metadata{
@@ -349,6 +399,18 @@ var (
 		Measurement: "Compactions",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaRdbRunningCompactions = metric.Metadata{
+		Name:        "rocksdb.running-compactions",
+		Help:        "Number of table compactions currently running",
+		Measurement: "Compactions",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRdbPendingCompaction = metric.Metadata{
+		Name:        "rocksdb.estimated-pending-compaction",
+		Help:        "Estimated number of bytes compactions need to rewrite to bring all levels down to their target size",
+		Measurement: "Storage",
+		Unit:        metric.Unit_BYTES,
+	}
 	metaRdbTableReadersMemEstimate = metric.Metadata{
 		Name:        "rocksdb.table-readers-mem-estimate",
 		Help:        "Memory used by index and filter blocks",
@@ -367,6 +429,18 @@ var (
 		Measurement: "SSTables",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaRdbWriteAmplification = metric.Metadata{
+		Name:        "rocksdb.write-amplification",
+		Help:        "Number of bytes written by flushes and compactions per byte flushed from the memtables",
+		Measurement: "Disk Writes per Flushed Byte",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRdbL0Sublevels = metric.Metadata{
+		Name:        "rocksdb.l0-sublevels",
+		Help:        "Largest number of level 0 SSTables overlapping a single key",
+		Measurement: "SSTables",
+		Unit:        metric.Unit_COUNT,
+	}
 
 	// Range event metrics.
 	metaRangeSplits = metric.Metadata{
@@ -411,12 +485,84 @@ var (
 		Measurement: "Snapshots",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaRangeSnapshotRecvQueueRecoveryWaiting = metric.Metadata{
+		Name:        "range.snapshots.recv-queue.recovery-waiting",
+		Help:        "Number of incoming recovery snapshots waiting to be admitted",
+		Measurement: "Snapshots",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRangeSnapshotRecvQueueRebalanceWaiting = metric.Metadata{
+		Name:        "range.snapshots.recv-queue.rebalance-waiting",
+		Help:        "Number of incoming rebalancing snapshots waiting to be admitted",
+		Measurement: "Snapshots",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRangeSnapshotRecvQueueRecoveryAdmitted = metric.Metadata{
+		Name:        "range.snapshots.recv-queue.recovery-admitted",
+		Help:        "Number of incoming non-empty recovery snapshots admitted",
+		Measurement: "Snapshots",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRangeSnapshotRecvQueueRebalanceAdmitted = metric.Metadata{
+		Name:        "range.snapshots.recv-queue.rebalance-admitted",
+		Help:        "Number of incoming non-empty rebalancing snapshots admitted",
+		Measurement: "Snapshots",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaReadOnlyQueueWaiting = metric.Metadata{
+		Name:        "requests.read-only-queue.waiting",
+		Help:        "Number of read-only batches waiting to be admitted for evaluation",
+		Measurement: "Batches",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaReadOnlyQueueAdmitted = metric.Metadata{
+		Name:        "requests.read-only-queue.admitted",
+		Help:        "Number of read-only batches admitted for evaluation while their concurrency was limited",
+		Measurement: "Batches",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaReadOnlyQueueWaitNanos = metric.Metadata{
+		Name:        "requests.read-only-queue.wait-nanos",
+		Help:        "Nanoseconds spent by read-only batches waiting to be admitted for evaluation",
+		Measurement: "Wait Time",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaRangeSnapshotRecvQueueDeclined = metric.Metadata{
+		Name:        "range.snapshots.recv-queue.declined",
+		Help:        "Number of incoming snapshots declined because too many snapshots were in progress",
+		Measurement: "Snapshots",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRangeSnapshotsIngested = metric.Metadata{
+		Name:        "range.snapshots.ingested",
+		Help:        "Number of applied snapshots whose data was ingested as SSTables",
+		Measurement: "Snapshots",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRangeSnapshotsRecvResumed = metric.Metadata{
+		Name:        "range.snapshots.recv-resumed",
+		Help:        "Number of incoming snapshots resumed from the data retained from an interrupted stream",
+		Measurement: "Snapshots",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaRangeRaftLeaderTransfers = metric.Metadata{
 		Name:        "range.raftleadertransfers",
 		Help:        "Number of raft leader transfers",
 		Measurement: "Leader Transfers",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaRangeRaftLeadershipRequests = metric.Metadata{
+		Name:        "range.raftleadershiprequests",
+		Help:        "Number of requests for Raft leadership made by leaseholders which were not the Raft leader of their range",
+		Measurement: "Leadership Requests",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRangeStaleProposalsForwarded = metric.Metadata{
+		Name:        "range.staleproposals.forwarded",
+		Help:        "Number of writes proposed under a lease which was transferred before they applied, and which were forwarded to the new leaseholder",
+		Measurement: "Proposals",
+		Unit:        metric.Unit_COUNT,
+	}
 
 	// Raft processing metrics.
 	metaRaftTicks = metric.Metadata{
@@ -443,6 +589,30 @@ var (
 		Measurement: "Commands",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaRaftCommandsSlowApplied = metric.Metadata{
+		Name:        "raft.commandsslowapplied",
+		Help:        "Count of Raft commands whose application took longer than kv.raft.slow_apply_threshold",
+		Measurement: "Commands",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftCommandsReproposed = metric.Metadata{
+		Name:        "raft.commandsreproposed",
+		Help:        "Count of Raft commands reproposed because they may have been dropped",
+		Measurement: "Commands",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftCommandsReproposalsDeferred = metric.Metadata{
+		Name:        "raft.commandsreproposalsdeferred",
+		Help:        "Count of reproposals of Raft commands deferred because the commands were backing off after previous reproposals",
+		Measurement: "Commands",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftCommandsAbandoned = metric.Metadata{
+		Name:        "raft.commandsabandoned",
+		Help:        "Count of Raft commands which were not reproposed because the deadline of the request that proposed them had passed",
+		Measurement: "Commands",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaRaftLogCommitLatency = metric.Metadata{
 		Name:        "raft.process.logcommit.latency",
 		Help:        "Latency histogram for committing Raft log entries",
@@ -455,6 +625,18 @@ var (
 		Measurement: "Latency",
 		Unit:        metric.Unit_NANOSECONDS,
 	}
+	metaRaftCommandWriteBatchSize = metric.Metadata{
+		Name:        "raft.process.commandwritebatch.size",
+		Help:        "Histogram of the sizes of the write batches of proposed Raft commands",
+		Measurement: "Batch Size",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaRaftCommandsLargeWriteBatch = metric.Metadata{
+		Name:        "raft.process.commandwritebatch.large",
+		Help:        "Number of proposed Raft commands with a write batch larger than kv.raft.command.large_write_batch_threshold",
+		Measurement: "Commands",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaRaftHandleReadyLatency = metric.Metadata{
 		Name:        "raft.process.handleready.latency",
 		Help:        "Latency histogram for handling a Raft ready",
@@ -559,6 +741,24 @@ var (
 		Measurement: "Messages",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaRaftPausedFollowerCount = metric.Metadata{
+		Name:        "raft.paused.followers",
+		Help:        "Number of followers on overloaded stores to which Raft leaders on this store don't replicate",
+		Measurement: "Replicas",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftPausedDropped = metric.Metadata{
+		Name:        "raft.paused.dropped",
+		Help:        "Number of outgoing MsgApp messages not sent to followers on overloaded stores",
+		Measurement: "Messages",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftStoreOverloaded = metric.Metadata{
+		Name:        "raft.paused.store_overloaded",
+		Help:        "1 if the store's storage engine is overloaded and leaders are asked to pause replication to it, 0 otherwise",
+		Measurement: "Stores",
+		Unit:        metric.Unit_COUNT,
+	}
 
 	// Raft log metrics.
 	metaRaftLogFollowerBehindCount = metric.Metadata{
@@ -701,6 +901,18 @@ var (
 		Measurement: "Processing Time",
 		Unit:        metric.Unit_NANOSECONDS,
 	}
+	metaConsistencyQueueCheckDuration = metric.Metadata{
+		Name:        "queue.consistency.check.duration",
+		Help:        "Duration of the consistency checks run by the consistency checker queue",
+		Measurement: "Latency",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaConsistencyQueueBytesHashed = metric.Metadata{
+		Name:        "queue.consistency.check.bytes_hashed",
+		Help:        "Number of bytes of replica data hashed by consistency checks",
+		Measurement: "Bytes",
+		Unit:        metric.Unit_BYTES,
+	}
 	metaReplicaGCQueueSuccesses = metric.Metadata{
 		Name:        "queue.replicagc.process.success",
 		Help:        "Number of replicas successfully processed by the replica GC queue",
@@ -809,6 +1021,36 @@ var (
 		Measurement: "Processing Time",
 		Unit:        metric.Unit_NANOSECONDS,
 	}
+	metaReplicaMigrationQueueSuccesses = metric.Metadata{
+		Name:        "queue.replicamigration.process.success",
+		Help:        "Number of replicas successfully processed by the replica migration queue",
+		Measurement: "Replicas",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaReplicaMigrationQueueFailures = metric.Metadata{
+		Name:        "queue.replicamigration.process.failure",
+		Help:        "Number of replicas which failed processing in the replica migration queue",
+		Measurement: "Replicas",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaReplicaMigrationQueuePending = metric.Metadata{
+		Name:        "queue.replicamigration.pending",
+		Help:        "Number of pending replicas in the replica migration queue",
+		Measurement: "Replicas",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaReplicaMigrationQueueProcessingNanos = metric.Metadata{
+		Name:        "queue.replicamigration.processingnanos",
+		Help:        "Nanoseconds spent processing replicas in the replica migration queue",
+		Measurement: "Processing Time",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaReplicaMigrationsPending = metric.Metadata{
+		Name:        "replicas.migrations.pending",
+		Help:        "Number of replicas which still need one of the active replica migrations",
+		Measurement: "Replicas",
+		Unit:        metric.Unit_COUNT,
+	}
 
 	// GCInfo cumulative totals.
 	metaGCNumKeysAffected = metric.Metadata{
@@ -895,6 +1137,12 @@ var (
 		Measurement: "Intent Resolutions",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaGCProtectedTimestampViolations = metric.Metadata{
+		Name:        "queue.gc.protectedts.violations",
+		Help:        "Number of applied GC thresholds at or above a protected timestamp",
+		Measurement: "GC Thresholds",
+		Unit:        metric.Unit_COUNT,
+	}
 
 	// Slow request metrics.
 	metaLatchRequests = metric.Metadata{
@@ -923,6 +1171,12 @@ var (
 		Measurement: "Writes",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaBackpressureHardCapSplits = metric.Metadata{
+		Name:        "requests.backpressure.hardcap",
+		Help:        "Number of splits queued by writes to a Range exceeding the hard cap on its size",
+		Measurement: "Splits",
+		Unit:        metric.Unit_COUNT,
+	}
 
 	// AddSSTable metrics.
 	metaAddSSTableProposals = metric.Metadata{
@@ -960,6 +1214,18 @@ var (
 		Measurement: "Nanoseconds",
 		Unit:        metric.Unit_NANOSECONDS,
 	}
+	metaClosedTimestampLag = metric.Metadata{
+		Name:        "kv.closed_timestamp.lag",
+		Help:        "Latency between realtime and the max closed timestamp of replicas",
+		Measurement: "Nanoseconds",
+		Unit:        metric.Unit_NANOSECONDS,
+	}
+	metaClosedTimestampLaggingReplicas = metric.Metadata{
+		Name:        "kv.closed_timestamp.lagging_replicas",
+		Help:        "Number of replicas whose max closed timestamp lags by more than kv.closed_timestamp.lag_alert_threshold",
+		Measurement: "Replicas",
+		Unit:        metric.Unit_COUNT,
+	}
 )
 
 // StoreMetrics is the set of metrics for a given store.
@@ -971,8 +1237,11 @@ type StoreMetrics struct {
 	ReservedReplicaCount          *metric.Gauge
 	RaftLeaderCount               *metric.Gauge
 	RaftLeaderNotLeaseHolderCount *metric.Gauge
+	LeaseHolderNotRaftLeaderCount *metric.Gauge
 	LeaseHolderCount              *metric.Gauge
 	QuiescentCount                *metric.Gauge
+	ReplicaInvariantViolations    *metric.Counter
+	QuarantinedCount              *metric.Counter
 
 	// Range metrics.
 	RangeCount                *metric.Gauge
@@ -989,6 +1258,12 @@ type StoreMetrics struct {
 	LeaseTransferErrorCount   *metric.Counter
 	LeaseExpirationCount      *metric.Gauge
 	LeaseEpochCount           *metric.Gauge
+	// LeaseIntentCleanupResolved counts the intents resolved by the scans
+	// following lease acquisitions.
+	LeaseIntentCleanupResolved *metric.Counter
+	// StoreLivenessLeaseTakeovers counts the leases acquired by this store
+	// from stores whose store liveness support was withdrawn.
+	StoreLivenessLeaseTakeovers *metric.Counter
 
 	// Storage metrics.
 	LiveBytes          *metric.Gauge
@@ -1018,7 +1293,12 @@ type StoreMetrics struct {
 	AverageWritesPerSecond  *metric.GaugeFloat64
 
 	// Follower read metrics.
-	FollowerReadsCount *metric.Counter
+	FollowerReadsCount  *metric.Counter
+	LatchlessReadsCount *metric.Counter
+
+	// Read cache metrics.
+	ReadCacheHits   *metric.Counter
+	ReadCacheMisses *metric.Counter
 
 	// RocksDB metrics.
 	RdbBlockCacheHits           *metric.Gauge
@@ -1030,9 +1310,13 @@ type StoreMetrics struct {
 	RdbMemtableTotalSize        *metric.Gauge
 	RdbFlushes                  *metric.Gauge
 	RdbCompactions              *metric.Gauge
+	RdbRunningCompactions       *metric.Gauge
+	RdbPendingCompaction        *metric.Gauge
 	RdbTableReadersMemEstimate  *metric.Gauge
 	RdbReadAmplification        *metric.Gauge
 	RdbNumSSTables              *metric.Gauge
+	RdbWriteAmplification       *metric.GaugeFloat64
+	RdbL0Sublevels              *metric.Gauge
 
 	// TODO(mrtracy): This should be removed as part of #4465. This is only
 	// maintained to keep the current structure of NodeStatus; it would be
@@ -1047,17 +1331,39 @@ type StoreMetrics struct {
 	RangeSnapshotsGenerated         *metric.Counter
 	RangeSnapshotsNormalApplied     *metric.Counter
 	RangeSnapshotsPreemptiveApplied *metric.Counter
+	RangeSnapshotsIngested          *metric.Counter
+	RangeSnapshotsRecvResumed       *metric.Counter
 	RangeRaftLeaderTransfers        *metric.Counter
+	RangeRaftLeadershipRequests     *metric.Counter
+	RangeStaleProposalsForwarded    *metric.Counter
+
+	// Snapshot receive queue metrics.
+	RangeSnapshotRecvQueueRecoveryWaiting   *metric.Gauge
+	RangeSnapshotRecvQueueRebalanceWaiting  *metric.Gauge
+	RangeSnapshotRecvQueueRecoveryAdmitted  *metric.Counter
+	RangeSnapshotRecvQueueRebalanceAdmitted *metric.Counter
+	RangeSnapshotRecvQueueDeclined          *metric.Counter
+
+	// Read-only request queue metrics.
+	ReadOnlyQueueWaiting   *metric.Gauge
+	ReadOnlyQueueAdmitted  *metric.Counter
+	ReadOnlyQueueWaitNanos *metric.Counter
 
 	// Raft processing metrics.
-	RaftTicks                 *metric.Counter
-	RaftWorkingDurationNanos  *metric.Counter
-	RaftTickingDurationNanos  *metric.Counter
-	RaftCommandsApplied       *metric.Counter
-	RaftLogCommitLatency      *metric.Histogram
-	RaftCommandCommitLatency  *metric.Histogram
-	RaftHandleReadyLatency    *metric.Histogram
-	RaftApplyCommittedLatency *metric.Histogram
+	RaftTicks                       *metric.Counter
+	RaftWorkingDurationNanos        *metric.Counter
+	RaftTickingDurationNanos        *metric.Counter
+	RaftCommandsApplied             *metric.Counter
+	RaftCommandsSlowApplied         *metric.Counter
+	RaftCommandsReproposed          *metric.Counter
+	RaftCommandsReproposalsDeferred *metric.Counter
+	RaftCommandsAbandoned           *metric.Counter
+	RaftLogCommitLatency            *metric.Histogram
+	RaftCommandCommitLatency        *metric.Histogram
+	RaftHandleReadyLatency          *metric.Histogram
+	RaftApplyCommittedLatency       *metric.Histogram
+	RaftCommandWriteBatchSize       *metric.Histogram
+	RaftCommandsLargeWriteBatch     *metric.Counter
 
 	// Raft message metrics.
 	RaftRcvdMsgProp           *metric.Counter
@@ -1087,6 +1393,11 @@ type StoreMetrics struct {
 	RaftEnqueuedPending            *metric.Gauge
 	RaftCoalescedHeartbeatsPending *metric.Gauge
 
+	// Metrics for pausing replication to followers on overloaded stores.
+	RaftPausedFollowerCount *metric.Gauge
+	RaftPausedDropped       *metric.Counter
+	RaftStoreOverloaded     *metric.Gauge
+
 	// Replica queue metrics.
 	GCQueueSuccesses                          *metric.Counter
 	GCQueueFailures                           *metric.Counter
@@ -1109,6 +1420,8 @@ type StoreMetrics struct {
 	ConsistencyQueueFailures                  *metric.Counter
 	ConsistencyQueuePending                   *metric.Gauge
 	ConsistencyQueueProcessingNanos           *metric.Counter
+	ConsistencyQueueCheckDuration             *metric.Histogram
+	ConsistencyQueueBytesHashed               *metric.Counter
 	ReplicaGCQueueSuccesses                   *metric.Counter
 	ReplicaGCQueueFailures                    *metric.Counter
 	ReplicaGCQueuePending                     *metric.Gauge
@@ -1127,6 +1440,11 @@ type StoreMetrics struct {
 	TimeSeriesMaintenanceQueueFailures        *metric.Counter
 	TimeSeriesMaintenanceQueuePending         *metric.Gauge
 	TimeSeriesMaintenanceQueueProcessingNanos *metric.Counter
+	ReplicaMigrationQueueSuccesses            *metric.Counter
+	ReplicaMigrationQueueFailures             *metric.Counter
+	ReplicaMigrationQueuePending              *metric.Gauge
+	ReplicaMigrationQueueProcessingNanos      *metric.Counter
+	ReplicaMigrationsPending                  *metric.Gauge
 
 	// GCInfo cumulative totals.
 	GCNumKeysAffected            *metric.Counter
@@ -1143,6 +1461,7 @@ type StoreMetrics struct {
 	GCPushTxn                    *metric.Counter
 	GCResolveTotal               *metric.Counter
 	GCResolveSuccess             *metric.Counter
+	GCProtectedTSViolations      *metric.Counter
 
 	// Slow request counts.
 	SlowLatchRequests *metric.Gauge
@@ -1151,6 +1470,7 @@ type StoreMetrics struct {
 
 	// Backpressure counts.
 	BackpressuredOnSplitRequests *metric.Gauge
+	BackpressureHardCapSplits    *metric.Counter
 
 	// AddSSTable stats: how many AddSSTable commands were proposed and how many
 	// were applied? How many applications required writing a copy?
@@ -1166,7 +1486,9 @@ type StoreMetrics struct {
 	RangeFeedMetrics *rangefeed.Metrics
 
 	// Closed timestamp metrics.
-	ClosedTimestampMaxBehindNanos *metric.Gauge
+	ClosedTimestampMaxBehindNanos  *metric.Gauge
+	ClosedTimestampLag             *metric.Histogram
+	ClosedTimestampLaggingReplicas *metric.Gauge
 
 	// Stats for efficient merges.
 	mu struct {
@@ -1185,8 +1507,11 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		ReservedReplicaCount:          metric.NewGauge(metaReservedReplicaCount),
 		RaftLeaderCount:               metric.NewGauge(metaRaftLeaderCount),
 		RaftLeaderNotLeaseHolderCount: metric.NewGauge(metaRaftLeaderNotLeaseHolderCount),
+		LeaseHolderNotRaftLeaderCount: metric.NewGauge(metaLeaseHolderNotRaftLeaderCount),
 		LeaseHolderCount:              metric.NewGauge(metaLeaseHolderCount),
 		QuiescentCount:                metric.NewGauge(metaQuiescentCount),
+		ReplicaInvariantViolations:    metric.NewCounter(metaReplicaInvariantViolations),
+		QuarantinedCount:              metric.NewCounter(metaQuarantinedCount),
 
 		// Range metrics.
 		RangeCount:                metric.NewGauge(metaRangeCount),
@@ -1202,6 +1527,10 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		LeaseExpirationCount:      metric.NewGauge(metaLeaseExpirationCount),
 		LeaseEpochCount:           metric.NewGauge(metaLeaseEpochCount),
 
+		LeaseIntentCleanupResolved: metric.NewCounter(metaLeaseIntentCleanupResolved),
+
+		StoreLivenessLeaseTakeovers: metric.NewCounter(metaStoreLivenessLeaseTakeovers),
+
 		// Storage metrics.
 		LiveBytes:       metric.NewGauge(metaLiveBytes),
 		KeyBytes:        metric.NewGauge(metaKeyBytes),
@@ -1232,7 +1561,12 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		AverageWritesPerSecond:  metric.NewGaugeFloat64(metaAverageWritesPerSecond),
 
 		// Follower reads metrics.
-		FollowerReadsCount: metric.NewCounter(metaFollowerReadsCount),
+		FollowerReadsCount:  metric.NewCounter(metaFollowerReadsCount),
+		LatchlessReadsCount: metric.NewCounter(metaLatchlessReadsCount),
+
+		// Read cache metrics.
+		ReadCacheHits:   metric.NewCounter(metaReadCacheHits),
+		ReadCacheMisses: metric.NewCounter(metaReadCacheMisses),
 
 		// RocksDB metrics.
 		RdbBlockCacheHits:           metric.NewGauge(metaRdbBlockCacheHits),
@@ -1244,9 +1578,13 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		RdbMemtableTotalSize:        metric.NewGauge(metaRdbMemtableTotalSize),
 		RdbFlushes:                  metric.NewGauge(metaRdbFlushes),
 		RdbCompactions:              metric.NewGauge(metaRdbCompactions),
+		RdbRunningCompactions:       metric.NewGauge(metaRdbRunningCompactions),
+		RdbPendingCompaction:        metric.NewGauge(metaRdbPendingCompaction),
 		RdbTableReadersMemEstimate:  metric.NewGauge(metaRdbTableReadersMemEstimate),
 		RdbReadAmplification:        metric.NewGauge(metaRdbReadAmplification),
 		RdbNumSSTables:              metric.NewGauge(metaRdbNumSSTables),
+		RdbWriteAmplification:       metric.NewGaugeFloat64(metaRdbWriteAmplification),
+		RdbL0Sublevels:              metric.NewGauge(metaRdbL0Sublevels),
 
 		// Range event metrics.
 		RangeSplits:                     metric.NewCounter(metaRangeSplits),
@@ -1256,17 +1594,39 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		RangeSnapshotsGenerated:         metric.NewCounter(metaRangeSnapshotsGenerated),
 		RangeSnapshotsNormalApplied:     metric.NewCounter(metaRangeSnapshotsNormalApplied),
 		RangeSnapshotsPreemptiveApplied: metric.NewCounter(metaRangeSnapshotsPreemptiveApplied),
+		RangeSnapshotsIngested:          metric.NewCounter(metaRangeSnapshotsIngested),
+		RangeSnapshotsRecvResumed:       metric.NewCounter(metaRangeSnapshotsRecvResumed),
 		RangeRaftLeaderTransfers:        metric.NewCounter(metaRangeRaftLeaderTransfers),
+		RangeRaftLeadershipRequests:     metric.NewCounter(metaRangeRaftLeadershipRequests),
+		RangeStaleProposalsForwarded:    metric.NewCounter(metaRangeStaleProposalsForwarded),
+
+		// Snapshot receive queue metrics.
+		RangeSnapshotRecvQueueRecoveryWaiting:   metric.NewGauge(metaRangeSnapshotRecvQueueRecoveryWaiting),
+		RangeSnapshotRecvQueueRebalanceWaiting:  metric.NewGauge(metaRangeSnapshotRecvQueueRebalanceWaiting),
+		RangeSnapshotRecvQueueRecoveryAdmitted:  metric.NewCounter(metaRangeSnapshotRecvQueueRecoveryAdmitted),
+		RangeSnapshotRecvQueueRebalanceAdmitted: metric.NewCounter(metaRangeSnapshotRecvQueueRebalanceAdmitted),
+		RangeSnapshotRecvQueueDeclined:          metric.NewCounter(metaRangeSnapshotRecvQueueDeclined),
+
+		// Read-only request queue metrics.
+		ReadOnlyQueueWaiting:   metric.NewGauge(metaReadOnlyQueueWaiting),
+		ReadOnlyQueueAdmitted:  metric.NewCounter(metaReadOnlyQueueAdmitted),
+		ReadOnlyQueueWaitNanos: metric.NewCounter(metaReadOnlyQueueWaitNanos),
 
 		// Raft processing metrics.
-		RaftTicks:                 metric.NewCounter(metaRaftTicks),
-		RaftWorkingDurationNanos:  metric.NewCounter(metaRaftWorkingDurationNanos),
-		RaftTickingDurationNanos:  metric.NewCounter(metaRaftTickingDurationNanos),
-		RaftCommandsApplied:       metric.NewCounter(metaRaftCommandsApplied),
-		RaftLogCommitLatency:      metric.NewLatency(metaRaftLogCommitLatency, histogramWindow),
-		RaftCommandCommitLatency:  metric.NewLatency(metaRaftCommandCommitLatency, histogramWindow),
-		RaftHandleReadyLatency:    metric.NewLatency(metaRaftHandleReadyLatency, histogramWindow),
-		RaftApplyCommittedLatency: metric.NewLatency(metaRaftApplyCommittedLatency, histogramWindow),
+		RaftTicks:                       metric.NewCounter(metaRaftTicks),
+		RaftWorkingDurationNanos:        metric.NewCounter(metaRaftWorkingDurationNanos),
+		RaftTickingDurationNanos:        metric.NewCounter(metaRaftTickingDurationNanos),
+		RaftCommandsApplied:             metric.NewCounter(metaRaftCommandsApplied),
+		RaftCommandsSlowApplied:         metric.NewCounter(metaRaftCommandsSlowApplied),
+		RaftCommandsReproposed:          metric.NewCounter(metaRaftCommandsReproposed),
+		RaftCommandsReproposalsDeferred: metric.NewCounter(metaRaftCommandsReproposalsDeferred),
+		RaftCommandsAbandoned:           metric.NewCounter(metaRaftCommandsAbandoned),
+		RaftLogCommitLatency:            metric.NewLatency(metaRaftLogCommitLatency, histogramWindow),
+		RaftCommandCommitLatency:        metric.NewLatency(metaRaftCommandCommitLatency, histogramWindow),
+		RaftHandleReadyLatency:          metric.NewLatency(metaRaftHandleReadyLatency, histogramWindow),
+		RaftApplyCommittedLatency:       metric.NewLatency(metaRaftApplyCommittedLatency, histogramWindow),
+		RaftCommandWriteBatchSize:       metric.NewHistogram(metaRaftCommandWriteBatchSize, histogramWindow, maxRecordedWriteBatchSize, 1),
+		RaftCommandsLargeWriteBatch:     metric.NewCounter(metaRaftCommandsLargeWriteBatch),
 
 		// Raft message metrics.
 		RaftRcvdMsgProp:           metric.NewCounter(metaRaftRcvdProp),
@@ -1290,6 +1650,10 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		// the queue is cleared, to avoid flapping wildly.
 		RaftCoalescedHeartbeatsPending: metric.NewGauge(metaRaftCoalescedHeartbeatsPending),
 
+		RaftPausedFollowerCount: metric.NewGauge(metaRaftPausedFollowerCount),
+		RaftPausedDropped:       metric.NewCounter(metaRaftPausedDropped),
+		RaftStoreOverloaded:     metric.NewGauge(metaRaftStoreOverloaded),
+
 		// Raft log metrics.
 		RaftLogFollowerBehindCount: metric.NewGauge(metaRaftLogFollowerBehindCount),
 		RaftLogTruncated:           metric.NewCounter(metaRaftLogTruncated),
@@ -1316,6 +1680,8 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		ConsistencyQueueFailures:                  metric.NewCounter(metaConsistencyQueueFailures),
 		ConsistencyQueuePending:                   metric.NewGauge(metaConsistencyQueuePending),
 		ConsistencyQueueProcessingNanos:           metric.NewCounter(metaConsistencyQueueProcessingNanos),
+		ConsistencyQueueCheckDuration:             metric.NewHistogram(metaConsistencyQueueCheckDuration, histogramWindow, consistencyCheckMaxDuration.Nanoseconds(), 1),
+		ConsistencyQueueBytesHashed:               metric.NewCounter(metaConsistencyQueueBytesHashed),
 		ReplicaGCQueueSuccesses:                   metric.NewCounter(metaReplicaGCQueueSuccesses),
 		ReplicaGCQueueFailures:                    metric.NewCounter(metaReplicaGCQueueFailures),
 		ReplicaGCQueuePending:                     metric.NewGauge(metaReplicaGCQueuePending),
@@ -1334,6 +1700,11 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		TimeSeriesMaintenanceQueueFailures:        metric.NewCounter(metaTimeSeriesMaintenanceQueueFailures),
 		TimeSeriesMaintenanceQueuePending:         metric.NewGauge(metaTimeSeriesMaintenanceQueuePending),
 		TimeSeriesMaintenanceQueueProcessingNanos: metric.NewCounter(metaTimeSeriesMaintenanceQueueProcessingNanos),
+		ReplicaMigrationQueueSuccesses:            metric.NewCounter(metaReplicaMigrationQueueSuccesses),
+		ReplicaMigrationQueueFailures:             metric.NewCounter(metaReplicaMigrationQueueFailures),
+		ReplicaMigrationQueuePending:              metric.NewGauge(metaReplicaMigrationQueuePending),
+		ReplicaMigrationQueueProcessingNanos:      metric.NewCounter(metaReplicaMigrationQueueProcessingNanos),
+		ReplicaMigrationsPending:                  metric.NewGauge(metaReplicaMigrationsPending),
 
 		// GCInfo cumulative totals.
 		GCNumKeysAffected:            metric.NewCounter(metaGCNumKeysAffected),
@@ -1350,6 +1721,7 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		GCPushTxn:                    metric.NewCounter(metaGCPushTxn),
 		GCResolveTotal:               metric.NewCounter(metaGCResolveTotal),
 		GCResolveSuccess:             metric.NewCounter(metaGCResolveSuccess),
+		GCProtectedTSViolations:      metric.NewCounter(metaGCProtectedTimestampViolations),
 
 		// Wedge request counters.
 		SlowLatchRequests: metric.NewGauge(metaLatchRequests),
@@ -1358,6 +1730,7 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 
 		// Backpressure counters.
 		BackpressuredOnSplitRequests: metric.NewGauge(metaBackpressuredOnSplitRequests),
+		BackpressureHardCapSplits:    metric.NewCounter(metaBackpressureHardCapSplits),
 
 		// AddSSTable proposal + applications counters.
 		AddSSTableProposals:         metric.NewCounter(metaAddSSTableProposals),
@@ -1371,7 +1744,9 @@ func newStoreMetrics(histogramWindow time.Duration) *StoreMetrics {
 		RangeFeedMetrics: rangefeed.NewMetrics(),
 
 		// Closed timestamp metrics.
-		ClosedTimestampMaxBehindNanos: metric.NewGauge(metaClosedTimestampMaxBehindNanos),
+		ClosedTimestampMaxBehindNanos:  metric.NewGauge(metaClosedTimestampMaxBehindNanos),
+		ClosedTimestampLag:             metric.NewHistogram(metaClosedTimestampLag, histogramWindow, time.Hour.Nanoseconds(), 1),
+		ClosedTimestampLaggingReplicas: metric.NewGauge(metaClosedTimestampLaggingReplicas),
 	}
 
 	sm.raftRcvdMessages[raftpb.MsgProp] = sm.RaftRcvdMsgProp
@@ -1442,9 +1817,17 @@ func (sm *StoreMetrics) updateRocksDBStats(stats engine.Stats) {
 	sm.RdbMemtableTotalSize.Update(stats.MemtableTotalSize)
 	sm.RdbFlushes.Update(stats.Flushes)
 	sm.RdbCompactions.Update(stats.Compactions)
+	sm.RdbRunningCompactions.Update(stats.RunningCompactions)
+	sm.RdbPendingCompaction.Update(stats.PendingCompactionBytesEstimate)
 	sm.RdbTableReadersMemEstimate.Update(stats.TableReadersMemEstimate)
 }
 
+func (sm *StoreMetrics) updateLSMHealth(health engine.LSMHealth) {
+	sm.RdbReadAmplification.Update(health.ReadAmplification)
+	sm.RdbWriteAmplification.Update(health.WriteAmplification)
+	sm.RdbL0Sublevels.Update(health.L0Sublevels)
+}
+
 func (sm *StoreMetrics) updateEnvStats(stats engine.EnvStats) {
 	sm.EncryptionAlgorithm.Update(int64(stats.EncryptionType))
 }
@@ -863,6 +863,9 @@ func (nl *NodeLiveness) updateLivenessAttempt(
 
 	if err := nl.db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
 		b := txn.NewBatch()
+		// Liveness updates are serviced ahead of other requests on the node
+		// liveness range, as a late heartbeat costs the node its leases.
+		b.Header.PriorityClass = roachpb.HIGH_PRIORITY
 		key := keys.NodeLivenessKey(update.NodeID)
 		// The batch interface requires interface{}(nil), not *Liveness(nil).
 		if oldLiveness == nil {
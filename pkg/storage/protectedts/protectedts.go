@@ -0,0 +1,204 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// Package protectedts implements protected timestamps, which allow jobs such
+// as BACKUP to prevent the garbage collection of MVCC versions they still need
+// to read.
+//
+// A protection is a ptpb.Record stored in the system keyspace under
+// keys.ProtectedTimestampPrefix. While the record exists, the GC queue does not
+// advance the GC threshold of any range overlapping its spans to or past its
+// timestamp, and GC requests which would do so are rejected during evaluation.
+// Records are read by the GC queue through a Cache, which is refreshed before
+// every GC run, so a protection is guaranteed to be respected by any GC run
+// which starts after the transaction writing the record has committed.
+package protectedts
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/internal/client"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/pkg/errors"
+)
+
+// PollInterval controls how often the Cache reloads the protection records in
+// the background.
+var PollInterval = settings.RegisterNonNegativeDurationSetting(
+	"kv.protectedts.poll_interval",
+	"the interval at which the protected timestamp records are reloaded in the background",
+	2*time.Minute,
+)
+
+// ErrExists is returned by Protect when a record with the same ID already
+// exists.
+var ErrExists = errors.New("protected timestamp record already exists")
+
+// ErrNotExists is returned by Release when the record does not exist.
+var ErrNotExists = errors.New("protected timestamp record does not exist")
+
+// Protect writes the record r in txn. The protection takes effect when txn
+// commits.
+func Protect(ctx context.Context, txn *client.Txn, r *ptpb.Record) error {
+	if r.ID == uuid.Nil {
+		return errors.New("protected timestamp record must have an ID")
+	}
+	if r.Timestamp == (hlc.Timestamp{}) {
+		return errors.New("protected timestamp record must have a timestamp")
+	}
+	if len(r.Spans) == 0 {
+		return errors.New("protected timestamp record must have at least one span")
+	}
+	for _, sp := range r.Spans {
+		if !sp.Valid() {
+			return errors.Errorf("protected timestamp record has invalid span %s", sp)
+		}
+	}
+	if err := txn.CPut(ctx, keys.ProtectedTimestampKey(r.ID), r, nil); err != nil {
+		if _, ok := err.(*roachpb.ConditionFailedError); ok {
+			return ErrExists
+		}
+		return err
+	}
+	return nil
+}
+
+// Release deletes the record with the given ID in txn. The protection is
+// lifted when txn commits.
+func Release(ctx context.Context, txn *client.Txn, id uuid.UUID) error {
+	key := keys.ProtectedTimestampKey(id)
+	kv, err := txn.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if kv.Value == nil {
+		return ErrNotExists
+	}
+	return txn.Del(ctx, key)
+}
+
+// GetRecords returns all the protection records, ordered by ID.
+func GetRecords(ctx context.Context, txn *client.Txn) ([]ptpb.Record, error) {
+	kvs, err := txn.Scan(ctx, keys.ProtectedTimestampPrefix, keys.ProtectedTimestampKeyMax, 0 /* maxRows */)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]ptpb.Record, len(kvs))
+	for i, kv := range kvs {
+		if err := kv.ValueProto(&records[i]); err != nil {
+			return nil, errors.Wrapf(err, "decoding protected timestamp record at %s", kv.Key)
+		}
+	}
+	return records, nil
+}
+
+// Cache is an in-memory copy of the protection records. A nil *Cache is valid
+// and reports no protections.
+type Cache struct {
+	db *client.DB
+	st *cluster.Settings
+
+	mu struct {
+		syncutil.RWMutex
+		records []ptpb.Record
+	}
+}
+
+// NewCache returns a Cache reading the records through db. The Cache is empty
+// until the first call to Refresh.
+func NewCache(db *client.DB, st *cluster.Settings) *Cache {
+	return &Cache{db: db, st: st}
+}
+
+// Start starts a task which periodically refreshes the Cache until the
+// stopper quiesces.
+func (c *Cache) Start(ctx context.Context, stopper *stop.Stopper) {
+	if c == nil {
+		return
+	}
+	stopper.RunWorker(ctx, func(ctx context.Context) {
+		var timer timeutil.Timer
+		defer timer.Stop()
+		for {
+			timer.Reset(PollInterval.Get(&c.st.SV))
+			select {
+			case <-timer.C:
+				timer.Read = true
+				if err := c.Refresh(ctx); err != nil {
+					log.Warningf(ctx, "failed to refresh protected timestamps: %v", err)
+				}
+			case <-stopper.ShouldQuiesce():
+				return
+			}
+		}
+	})
+}
+
+// Refresh reloads the records from the database.
+func (c *Cache) Refresh(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	var records []ptpb.Record
+	if err := c.db.Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
+		var err error
+		records, err = GetRecords(ctx, txn)
+		return err
+	}); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.mu.records = records
+	c.mu.Unlock()
+	return nil
+}
+
+// Earliest returns the earliest timestamp protected by a record overlapping
+// the span, or the zero timestamp if no record overlaps it.
+func (c *Cache) Earliest(span roachpb.Span) hlc.Timestamp {
+	if c == nil {
+		return hlc.Timestamp{}
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return earliest(c.mu.records, span)
+}
+
+func earliest(records []ptpb.Record, span roachpb.Span) hlc.Timestamp {
+	var ts hlc.Timestamp
+	for i := range records {
+		r := &records[i]
+		if ts != (hlc.Timestamp{}) && !r.Timestamp.Less(ts) {
+			continue
+		}
+		for _, sp := range r.Spans {
+			if sp.Overlaps(span) {
+				ts = r.Timestamp
+				break
+			}
+		}
+	}
+	return ts
+}
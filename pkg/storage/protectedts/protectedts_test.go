@@ -0,0 +1,62 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package protectedts
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts/ptpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestEarliest(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	span := func(start, end string) roachpb.Span {
+		return roachpb.Span{Key: roachpb.Key(start), EndKey: roachpb.Key(end)}
+	}
+	ts := func(wallTime int64) hlc.Timestamp {
+		return hlc.Timestamp{WallTime: wallTime}
+	}
+	records := []ptpb.Record{
+		{Timestamp: ts(3), Spans: []roachpb.Span{span("a", "c"), span("x", "z")}},
+		{Timestamp: ts(2), Spans: []roachpb.Span{span("b", "d")}},
+		{Timestamp: ts(5), Spans: []roachpb.Span{span("e", "g")}},
+	}
+
+	testCases := []struct {
+		span roachpb.Span
+		exp  hlc.Timestamp
+	}{
+		{span("a", "b"), ts(3)},
+		{span("a", "z"), ts(2)},
+		{span("c", "e"), ts(2)},
+		{span("d", "e"), hlc.Timestamp{}},
+		{span("f", "y"), ts(3)},
+		{span("g", "x"), hlc.Timestamp{}},
+	}
+	for _, tc := range testCases {
+		if act := earliest(records, tc.span); act != tc.exp {
+			t.Errorf("%s: expected %s, got %s", tc.span, tc.exp, act)
+		}
+	}
+
+	var c *Cache
+	if act := c.Earliest(span("a", "z")); act != (hlc.Timestamp{}) {
+		t.Errorf("expected nil cache to report no protection, got %s", act)
+	}
+}
@@ -225,7 +225,11 @@ type queueConfig struct {
 	maxSize int
 	// maxConcurrency is the maximum number of replicas that can be processed
 	// concurrently. If not set, defaults to 1.
-	maxConcurrency       int
+	maxConcurrency int
+	// concurrencyFn, if set, returns the number of replicas that can currently
+	// be processed concurrently, which is capped by maxConcurrency. This allows
+	// the concurrency of a queue to be controlled by a cluster setting.
+	concurrencyFn        func() int
 	addOrMaybeAddSemSize int
 	// needsLease controls whether this queue requires the range lease to
 	// operate on a replica. If so, one will be acquired if necessary.
@@ -293,6 +297,7 @@ type baseQueue struct {
 	queueConfig
 	incoming         chan struct{} // Channel signaled when a new replica is added to the queue.
 	processSem       chan struct{}
+	processDone      chan struct{} // Channel signaled when the processing of a replica finishes.
 	addOrMaybeAddSem chan struct{} // for {Maybe,}AddAsync
 	addLogN          log.EveryN    // avoid log spam when addSem, addOrMaybeAddSemSize are maxed out
 	processDur       int64         // accessed atomically
@@ -346,6 +351,7 @@ func newBaseQueue(
 		queueConfig:      cfg,
 		incoming:         make(chan struct{}, 1),
 		processSem:       make(chan struct{}, cfg.maxConcurrency),
+		processDone:      make(chan struct{}, 1),
 		addOrMaybeAddSem: make(chan struct{}, cfg.addOrMaybeAddSemSize),
 		addLogN:          log.Every(5 * time.Second),
 		getReplica: func(id roachpb.RangeID) (replicaInQueue, error) {
@@ -685,6 +691,9 @@ func (bq *baseQueue) processLoop(stopper *stop.Stopper) {
 		// nextTime is initially nil; we don't start any timers until the queue
 		// becomes non-empty.
 		var nextTime <-chan time.Time
+		// throttled is set when processing was held back because the queue was
+		// at its concurrency limit, and is resumed once a replica finishes.
+		var throttled bool
 
 		immediately := make(chan time.Time)
 		close(immediately)
@@ -706,8 +715,22 @@ func (bq *baseQueue) processLoop(stopper *stop.Stopper) {
 					// In case we're in a test, still block on the impl.
 					bq.impl.timer(0)
 				}
+			// Resume processing held back by the concurrency limit.
+			case <-bq.processDone:
+				if throttled {
+					throttled = false
+					if nextTime == nil {
+						nextTime = immediately
+					}
+				}
+
 			// Process replicas as the timer expires.
 			case <-nextTime:
+				if bq.atConcurrencyLimit() {
+					nextTime, throttled = nil, true
+					continue
+				}
+
 				// Acquire from the process semaphore.
 				bq.processSem <- struct{}{}
 
@@ -718,7 +741,13 @@ func (bq *baseQueue) processLoop(stopper *stop.Stopper) {
 						annotatedCtx, fmt.Sprintf("storage.%s: processing replica", bq.name),
 						func(ctx context.Context) {
 							// Release semaphore when finished processing.
-							defer func() { <-bq.processSem }()
+							defer func() {
+								<-bq.processSem
+								select {
+								case bq.processDone <- struct{}{}:
+								default:
+								}
+							}()
 
 							start := timeutil.Now()
 							err := bq.processReplica(ctx, repl)
@@ -754,6 +783,12 @@ func (bq *baseQueue) processLoop(stopper *stop.Stopper) {
 	})
 }
 
+// atConcurrencyLimit returns whether the number of replicas being processed
+// has reached the current concurrency limit of the queue.
+func (bq *baseQueue) atConcurrencyLimit() bool {
+	return bq.concurrencyFn != nil && len(bq.processSem) >= bq.concurrencyFn()
+}
+
 // lastProcessDuration returns the duration of the last processing attempt.
 func (bq *baseQueue) lastProcessDuration() time.Duration {
 	return time.Duration(atomic.LoadInt64(&bq.processDur))
@@ -805,7 +840,8 @@ func (bq *baseQueue) processReplica(ctx context.Context, repl replicaInQueue) er
 			}
 
 			if reason, err := repl.IsDestroyed(); err != nil {
-				if !bq.queueConfig.processDestroyedReplicas || reason == destroyReasonRemoved {
+				if !bq.queueConfig.processDestroyedReplicas || reason == destroyReasonRemoved ||
+					reason == destroyReasonQuarantined {
 					log.VEventf(ctx, 3, "replica destroyed (%s); skipping", err)
 					return nil
 				}
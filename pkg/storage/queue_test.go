@@ -512,7 +512,7 @@ func TestNeedsSystemConfig(t *testing.T) {
 	// bqNeedsSysCfg will not add the replica or process it without a system config.
 	rpcContext := rpc.NewContext(
 		tc.store.cfg.AmbientCtx, &base.Config{Insecure: true}, tc.store.cfg.Clock, stopper,
-		&cluster.MakeTestingClusterSettings().Version)
+		cluster.MakeTestingClusterSettings())
 	emptyGossip := gossip.NewTest(
 		tc.gossip.NodeID.Get(), rpcContext, rpc.NewServer(rpcContext), stopper, tc.store.Registry(), config.DefaultZoneConfigRef())
 	bqNeedsSysCfg := makeTestBaseQueue("test", testQueue, tc.store, emptyGossip, queueConfig{
@@ -1103,6 +1103,70 @@ func TestBaseQueueProcessConcurrently(t *testing.T) {
 	assertProcessedAndProcessing(3, 0)
 }
 
+// TestBaseQueueConcurrencyFn verifies that the number of replicas processed
+// concurrently follows the limit returned by concurrencyFn.
+func TestBaseQueueConcurrencyFn(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc.Start(t, stopper)
+
+	repls := createReplicas(t, &tc, 3)
+
+	pQueue := &parallelQueueImpl{
+		testQueueImpl: testQueueImpl{
+			shouldQueueFn: func(now hlc.Timestamp, r *Replica) (shouldQueue bool, priority float64) {
+				return true, 1
+			},
+		},
+		processBlocker: make(chan struct{}, 1),
+	}
+	concurrency := int32(1)
+	bq := makeTestBaseQueue("test", pQueue, tc.store, tc.gossip,
+		queueConfig{
+			maxSize:        3,
+			maxConcurrency: 3,
+			concurrencyFn: func() int {
+				return int(atomic.LoadInt32(&concurrency))
+			},
+		},
+	)
+	bq.Start(stopper)
+
+	ctx := context.Background()
+	for _, r := range repls {
+		bq.maybeAdd(ctx, r, hlc.Timestamp{})
+	}
+
+	assertProcessedAndProcessing := func(expProcessed, expProcessing int) {
+		t.Helper()
+		testutils.SucceedsSoon(t, func() error {
+			if p := pQueue.getProcessed(); p != expProcessed {
+				return errors.Errorf("expected %d processed replicas; got %d", expProcessed, p)
+			}
+			if p := pQueue.getProcessing(); p != expProcessing {
+				return errors.Errorf("expected %d processing replicas; got %d", expProcessing, p)
+			}
+			return nil
+		})
+	}
+
+	assertProcessedAndProcessing(0, 1)
+
+	// Raising the limit lets the next replica be processed once the current
+	// one finishes.
+	atomic.StoreInt32(&concurrency, 2)
+	pQueue.processBlocker <- struct{}{}
+	assertProcessedAndProcessing(1, 2)
+
+	pQueue.processBlocker <- struct{}{}
+	assertProcessedAndProcessing(2, 1)
+
+	pQueue.processBlocker <- struct{}{}
+	assertProcessedAndProcessing(3, 0)
+}
+
 func TestBaseQueueRequeue(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	tc := testContext{}
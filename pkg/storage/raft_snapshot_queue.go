@@ -109,6 +109,14 @@ func (rq *raftSnapshotQueue) processRaftSnapshot(
 	if !ok {
 		return errors.Errorf("%s: replica %d not present in %v", repl, id, desc.Replicas())
 	}
+	// Learners receive their initial snapshot from the replica change which
+	// added them, and sending a second one here would only race with it.
+	if repDesc.GetType() == roachpb.LEARNER {
+		if log.V(1) {
+			log.Infof(ctx, "skipping snapshot to learner replica %s", repDesc)
+		}
+		return nil
+	}
 	err := repl.sendSnapshot(ctx, repDesc, snapTypeRaft, SnapshotRequest_RECOVERY)
 
 	// NB: if the snapshot fails because of an overlapping replica on the
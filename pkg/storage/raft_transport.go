@@ -28,9 +28,12 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/rpc/nodedialer"
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/limit"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
@@ -55,6 +58,21 @@ const (
 	// TODO(tamird): make culling of outbound streams more evented, so that we
 	// need not rely on this timeout to shut things down.
 	raftIdleTimeout = time.Minute
+
+	// raftTransportMaxBatchBytes is the size above which no more messages are
+	// added to a batch of outgoing Raft messages. Messages remaining in the
+	// queue are sent in the next batch.
+	raftTransportMaxBatchBytes = 4 << 20
+)
+
+// raftTransportBatchDelay is the time for which the Raft transport waits for
+// more messages to a node to be queued before sending a batch.
+var raftTransportBatchDelay = settings.RegisterNonNegativeDurationSetting(
+	"kv.raft.transport.batch_delay",
+	"the maximum duration for which outgoing Raft messages are held back to be "+
+		"batched with later messages to the same node; 0 sends the messages queued "+
+		"at the time without waiting",
+	0,
 )
 
 // RaftMessageResponseStream is the subset of the
@@ -125,6 +143,11 @@ type raftTransportStats struct {
 	clientDropped int64
 	serverSent    int64
 	serverRecv    int64
+	// queueBytes is the total size of the messages in the queue to the node.
+	queueBytes int64
+	// dropped counts the dropped messages to the node by class. Their sum is
+	// clientDropped.
+	dropped [numRaftTransportMsgClasses]int64
 }
 
 type raftTransportStatsSlice []*raftTransportStats
@@ -155,6 +178,7 @@ type RaftTransport struct {
 	stats    syncutil.IntMap // map[roachpb.NodeID]*raftTransportStats
 	dialer   *nodedialer.Dialer
 	handlers syncutil.IntMap // map[roachpb.StoreID]*RaftMessageHandler
+	metrics  RaftTransportMetrics
 }
 
 // NewDummyRaftTransport returns a dummy raft transport for use in tests which
@@ -182,6 +206,7 @@ func NewRaftTransport(
 		stopper: stopper,
 		dialer:  dialer,
 	}
+	t.metrics = newRaftTransportMetrics(t)
 
 	if grpcServer != nil {
 		RegisterMultiRaftServer(grpcServer, t)
@@ -265,6 +290,96 @@ func (t *RaftTransport) queuedMessageCount() int64 {
 	return n
 }
 
+func (t *RaftTransport) queuedMessageBytes() int64 {
+	var n int64
+	t.stats.Range(func(k int64, v unsafe.Pointer) bool {
+		n += atomic.LoadInt64(&(*raftTransportStats)(v).queueBytes)
+		return true
+	})
+	return n
+}
+
+func (t *RaftTransport) maxQueuedMessageCount() int64 {
+	var n int64
+	t.queues.Range(func(k int64, v unsafe.Pointer) bool {
+		ch := *(*chan *RaftMessageRequest)(v)
+		if l := int64(len(ch)); l > n {
+			n = l
+		}
+		return true
+	})
+	return n
+}
+
+// Metrics returns the metrics of the outgoing Raft message queues.
+func (t *RaftTransport) Metrics() RaftTransportMetrics {
+	return t.metrics
+}
+
+// RaftTransportPeerStats is a snapshot of the statistics of the outgoing Raft
+// message queue to a node and of the message streams to and from it.
+type RaftTransportPeerStats struct {
+	NodeID roachpb.NodeID
+	// QueueLen and QueueBytes are the number and the total size of the
+	// messages currently queued for sending to the node. QueueMax is the
+	// highest queue length observed.
+	QueueLen   int64
+	QueueBytes int64
+	QueueMax   int64
+	// ClientSent and ClientRecv count the message batches sent to and the
+	// responses received from the node, ServerRecv and ServerSent the message
+	// batches received from and the responses sent to it.
+	ClientSent int64
+	ClientRecv int64
+	ServerSent int64
+	ServerRecv int64
+	// The number of outgoing messages to the node which were dropped, by type.
+	DroppedApp       int64
+	DroppedAppResp   int64
+	DroppedVote      int64
+	DroppedHeartbeat int64
+	DroppedOther     int64
+}
+
+// PeerStats returns the statistics for each node the transport has exchanged
+// messages with, sorted by node ID.
+func (t *RaftTransport) PeerStats() []RaftTransportPeerStats {
+	var res []RaftTransportPeerStats
+	t.stats.Range(func(k int64, v unsafe.Pointer) bool {
+		s := (*raftTransportStats)(v)
+		ps := RaftTransportPeerStats{
+			NodeID:           s.nodeID,
+			QueueBytes:       atomic.LoadInt64(&s.queueBytes),
+			QueueMax:         int64(atomic.LoadInt32(&s.queueMax)),
+			ClientSent:       atomic.LoadInt64(&s.clientSent),
+			ClientRecv:       atomic.LoadInt64(&s.clientRecv),
+			ServerSent:       atomic.LoadInt64(&s.serverSent),
+			ServerRecv:       atomic.LoadInt64(&s.serverRecv),
+			DroppedApp:       atomic.LoadInt64(&s.dropped[raftTransportMsgApp]),
+			DroppedAppResp:   atomic.LoadInt64(&s.dropped[raftTransportMsgAppResp]),
+			DroppedVote:      atomic.LoadInt64(&s.dropped[raftTransportMsgVote]),
+			DroppedHeartbeat: atomic.LoadInt64(&s.dropped[raftTransportMsgHeartbeat]),
+			DroppedOther:     atomic.LoadInt64(&s.dropped[raftTransportMsgOther]),
+		}
+		if value, ok := t.queues.Load(k); ok {
+			ps.QueueLen = int64(len(*(*chan *RaftMessageRequest)(value)))
+		}
+		res = append(res, ps)
+		return true
+	})
+	sort.Slice(res, func(i, j int) bool { return res[i].NodeID < res[j].NodeID })
+	return res
+}
+
+// recordDropped accounts for an outgoing message to the node of the given
+// stats which was dropped.
+func (t *RaftTransport) recordDropped(stats *raftTransportStats, req *RaftMessageRequest) {
+	class := raftTransportMsgClassOf(req)
+	atomic.AddInt64(&stats.clientDropped, 1)
+	atomic.AddInt64(&stats.dropped[class], 1)
+	t.metrics.dropped[class].Inc(1)
+}
+
 func (t *RaftTransport) getHandler(storeID roachpb.StoreID) (RaftMessageHandler, bool) {
 	if value, ok := t.handlers.Load(int64(storeID)); ok {
 		return *(*RaftMessageHandler)(value), true
@@ -449,6 +564,8 @@ func (t *RaftTransport) processQueue(
 
 	var raftIdleTimer timeutil.Timer
 	defer raftIdleTimer.Stop()
+	var batchTimer timeutil.Timer
+	defer batchTimer.Stop()
 	batch := &RaftMessageRequestBatch{}
 	for {
 		raftIdleTimer.Reset(raftIdleTimeout)
@@ -461,20 +578,47 @@ func (t *RaftTransport) processQueue(
 		case err := <-errCh:
 			return err
 		case req := <-ch:
+			size := int64(req.Size())
+			atomic.AddInt64(&stats.queueBytes, -size)
 			batch.Requests = append(batch.Requests, *req)
 
-			// Pull off as many queued requests as possible.
-			//
-			// TODO(peter): Think about limiting the size of the batch we send.
-			for done := false; !done; {
-				select {
-				case req = <-ch:
-					batch.Requests = append(batch.Requests, *req)
-				default:
-					done = true
+			// Pull off as many queued requests as possible, up to the maximum
+			// batch size. If a batch delay is configured, also wait for that long
+			// for more requests to be queued.
+			delay := raftTransportBatchDelay.Get(&t.st.SV)
+			if delay > 0 {
+				batchTimer.Reset(delay)
+			}
+		pull:
+			for size < raftTransportMaxBatchBytes {
+				if delay == 0 {
+					select {
+					case req = <-ch:
+					default:
+						break pull
+					}
+				} else {
+					select {
+					case req = <-ch:
+					case <-batchTimer.C:
+						batchTimer.Read = true
+						break pull
+					case <-t.stopper.ShouldStop():
+						return nil
+					}
 				}
+				reqSize := int64(req.Size())
+				atomic.AddInt64(&stats.queueBytes, -reqSize)
+				size += reqSize
+				batch.Requests = append(batch.Requests, *req)
 			}
 
+			var coalesced int
+			batch.Requests, coalesced = coalesceRaftHeartbeats(batch.Requests)
+			t.metrics.HeartbeatsCoalesced.Inc(int64(coalesced))
+			t.metrics.BatchesSent.Inc(1)
+			t.metrics.BatchMessagesSent.Inc(int64(len(batch.Requests)))
+
 			err := stream.Send(batch)
 			batch.Requests = batch.Requests[:0]
 
@@ -486,6 +630,49 @@ func (t *RaftTransport) processQueue(
 	}
 }
 
+// coalesceRaftHeartbeats merges the requests carrying coalesced heartbeats or
+// heartbeat responses between the same pair of stores into the first such
+// request, which happens when the heartbeats of several ticks are sent in the
+// same batch. It returns the remaining requests and the number of requests
+// which were merged away. The order of the other requests is preserved.
+func coalesceRaftHeartbeats(reqs []RaftMessageRequest) ([]RaftMessageRequest, int) {
+	type heartbeatKey struct {
+		from, to roachpb.StoreID
+		resps    bool
+	}
+	var first map[heartbeatKey]int
+	out := reqs[:0]
+	for _, req := range reqs {
+		beats, resps := len(req.Heartbeats) > 0, len(req.HeartbeatResps) > 0
+		if req.RangeID != 0 || beats == resps {
+			out = append(out, req)
+			continue
+		}
+		key := heartbeatKey{from: req.FromReplica.StoreID, to: req.ToReplica.StoreID, resps: resps}
+		if i, ok := first[key]; ok {
+			// Copy the heartbeats of the first request before appending to them,
+			// as their slice is owned by the sender.
+			merged := &out[i]
+			merged.Heartbeats = append(merged.Heartbeats[:len(merged.Heartbeats):len(merged.Heartbeats)],
+				req.Heartbeats...)
+			merged.HeartbeatResps = append(
+				merged.HeartbeatResps[:len(merged.HeartbeatResps):len(merged.HeartbeatResps)],
+				req.HeartbeatResps...)
+			continue
+		}
+		if first == nil {
+			first = make(map[heartbeatKey]int)
+		}
+		first[key] = len(out)
+		out = append(out, req)
+	}
+	// Clear the tail so that the requests merged away can be collected.
+	for i := len(out); i < len(reqs); i++ {
+		reqs[i] = RaftMessageRequest{}
+	}
+	return out, len(reqs) - len(out)
+}
+
 // getQueue returns the queue for the specified node ID and a boolean
 // indicating whether the queue already exists (true) or was created (false).
 func (t *RaftTransport) getQueue(nodeID roachpb.NodeID) (chan *RaftMessageRequest, bool) {
@@ -505,16 +692,18 @@ func (t *RaftTransport) SendAsync(req *RaftMessageRequest) (sent bool) {
 	stats := t.getStats(toNodeID)
 	defer func() {
 		if !sent {
-			atomic.AddInt64(&stats.clientDropped, 1)
+			t.recordDropped(stats, req)
 		}
 	}()
 
 	ctx := t.AnnotateCtx(context.Background())
 
-	if req.RangeID == 0 && len(req.Heartbeats) == 0 && len(req.HeartbeatResps) == 0 {
-		// Coalesced heartbeats are addressed to range 0; everything else
-		// needs an explicit range ID.
-		panic("only messages with coalesced heartbeats or heartbeat responses may be sent to range ID 0")
+	if req.RangeID == 0 && len(req.Heartbeats) == 0 && len(req.HeartbeatResps) == 0 &&
+		req.StoreLiveness == nil {
+		// Coalesced heartbeats and store liveness messages are addressed to
+		// range 0; everything else needs an explicit range ID.
+		panic("only messages with coalesced heartbeats, heartbeat responses, or store liveness " +
+			"may be sent to range ID 0")
 	}
 	if req.Message.Type == raftpb.MsgSnap {
 		panic("snapshots must be sent using SendSnapshot")
@@ -533,6 +722,10 @@ func (t *RaftTransport) SendAsync(req *RaftMessageRequest) (sent bool) {
 		}
 	}
 
+	// The size is accounted for before the message is queued so that the
+	// queue's bytes never go negative when it is sent right away.
+	size := int64(req.Size())
+	atomic.AddInt64(&stats.queueBytes, size)
 	select {
 	case ch <- req:
 		l := int32(len(ch))
@@ -541,6 +734,7 @@ func (t *RaftTransport) SendAsync(req *RaftMessageRequest) (sent bool) {
 		}
 		return true
 	default:
+		atomic.AddInt64(&stats.queueBytes, -size)
 		return false
 	}
 }
@@ -591,8 +785,9 @@ func (t *RaftTransport) startProcessNewQueue(
 		// way the code is written).
 		for {
 			select {
-			case <-ch:
-				atomic.AddInt64(&stats.clientDropped, 1)
+			case req := <-ch:
+				atomic.AddInt64(&stats.queueBytes, -int64(req.Size()))
+				t.recordDropped(stats, req)
 			default:
 				return
 			}
@@ -613,6 +808,10 @@ func (t *RaftTransport) startProcessNewQueue(
 
 // SendSnapshot streams the given outgoing snapshot. The caller is responsible
 // for closing the OutgoingSnapshot.
+//
+// If the snapshot is resumable and the stream breaks while the snapshot's data
+// is being sent, SendSnapshot reconnects and resumes the snapshot from the
+// data the recipient already has, a bounded number of times.
 func (t *RaftTransport) SendSnapshot(
 	ctx context.Context,
 	raftCfg *base.RaftConfig,
@@ -621,6 +820,49 @@ func (t *RaftTransport) SendSnapshot(
 	snap *OutgoingSnapshot,
 	newBatch func() engine.Batch,
 	sent func(),
+	limiter *limit.RateLimiter,
+) error {
+	retryOpts := retry.Options{
+		InitialBackoff: 50 * time.Millisecond,
+		MaxBackoff:     1 * time.Second,
+		Multiplier:     2,
+		MaxRetries:     maxSnapshotResumeAttempts,
+	}
+	// The snapshot is counted as sent once, by the attempt which delivers all
+	// of its data, no matter how many attempts it took.
+	var delivered bool
+	sentOnce := func() {
+		if !delivered {
+			delivered = true
+			sent()
+		}
+	}
+	var err error
+	for r := retry.StartWithCtx(ctx, retryOpts); r.Next(); {
+		if err != nil {
+			// The interrupted attempt consumed the snapshot's iterator.
+			snap.resetIter()
+		}
+		err = t.sendSnapshotAttempt(
+			ctx, raftCfg, storePool, header, snap, newBatch, sentOnce, limiter,
+		)
+		if _, ok := err.(*errSnapshotStreamInterrupted); !ok || !header.Resumable {
+			return err
+		}
+		log.Infof(ctx, "%s; reconnecting to resume %s", err, snap)
+	}
+	return err
+}
+
+func (t *RaftTransport) sendSnapshotAttempt(
+	ctx context.Context,
+	raftCfg *base.RaftConfig,
+	storePool *StorePool,
+	header SnapshotRequest_Header,
+	snap *OutgoingSnapshot,
+	newBatch func() engine.Batch,
+	sent func(),
+	limiter *limit.RateLimiter,
 ) error {
 	var stream MultiRaft_RaftSnapshotClient
 	nodeID := header.RaftMessageRequest.ToReplica.NodeID
@@ -641,5 +883,5 @@ func (t *RaftTransport) SendSnapshot(
 			log.Warningf(ctx, "failed to close snapshot stream: %s", err)
 		}
 	}()
-	return sendSnapshot(ctx, raftCfg, t.st, stream, storePool, header, snap, newBatch, sent)
+	return sendSnapshot(ctx, raftCfg, t.st, stream, storePool, header, snap, newBatch, sent, limiter)
 }
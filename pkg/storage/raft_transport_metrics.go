@@ -0,0 +1,170 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"go.etcd.io/etcd/raft/raftpb"
+)
+
+// raftTransportMsgClass groups outgoing Raft messages for the purpose of
+// accounting for the messages dropped by the RaftTransport.
+type raftTransportMsgClass int
+
+const (
+	raftTransportMsgApp raftTransportMsgClass = iota
+	raftTransportMsgAppResp
+	raftTransportMsgVote
+	raftTransportMsgHeartbeat
+	raftTransportMsgOther
+	numRaftTransportMsgClasses
+)
+
+// raftTransportMsgClassOf returns the class of the given request. Coalesced
+// heartbeats and heartbeat responses are classified as heartbeats.
+func raftTransportMsgClassOf(req *RaftMessageRequest) raftTransportMsgClass {
+	if len(req.Heartbeats) > 0 || len(req.HeartbeatResps) > 0 {
+		return raftTransportMsgHeartbeat
+	}
+	switch req.Message.Type {
+	case raftpb.MsgApp:
+		return raftTransportMsgApp
+	case raftpb.MsgAppResp:
+		return raftTransportMsgAppResp
+	case raftpb.MsgVote, raftpb.MsgVoteResp, raftpb.MsgPreVote, raftpb.MsgPreVoteResp:
+		return raftTransportMsgVote
+	case raftpb.MsgHeartbeat, raftpb.MsgHeartbeatResp:
+		return raftTransportMsgHeartbeat
+	default:
+		return raftTransportMsgOther
+	}
+}
+
+// Raft transport metrics counter names.
+var (
+	metaRaftTransportSendQueueSize = metric.Metadata{
+		Name:        "raft.transport.sendqueue.size",
+		Help:        "Number of outgoing Raft messages queued for sending to other nodes",
+		Measurement: "Messages",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftTransportSendQueueBytes = metric.Metadata{
+		Name:        "raft.transport.sendqueue.bytes",
+		Help:        "Total size of the outgoing Raft messages queued for sending to other nodes",
+		Measurement: "Storage",
+		Unit:        metric.Unit_BYTES,
+	}
+	metaRaftTransportSendQueueMaxSize = metric.Metadata{
+		Name:        "raft.transport.sendqueue.maxsize",
+		Help:        "Number of outgoing Raft messages in the longest queue to a single node",
+		Measurement: "Messages",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftTransportDroppedApp = metric.Metadata{
+		Name:        "raft.transport.dropped.app",
+		Help:        "Number of outgoing MsgApp messages dropped by the Raft transport",
+		Measurement: "Messages",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftTransportDroppedAppResp = metric.Metadata{
+		Name:        "raft.transport.dropped.appresp",
+		Help:        "Number of outgoing MsgAppResp messages dropped by the Raft transport",
+		Measurement: "Messages",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftTransportDroppedVote = metric.Metadata{
+		Name:        "raft.transport.dropped.vote",
+		Help:        "Number of outgoing (pre-)vote requests and responses dropped by the Raft transport",
+		Measurement: "Messages",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftTransportDroppedHeartbeat = metric.Metadata{
+		Name:        "raft.transport.dropped.heartbeat",
+		Help:        "Number of outgoing (coalesced, if enabled) heartbeats and heartbeat responses dropped by the Raft transport",
+		Measurement: "Messages",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftTransportDroppedOther = metric.Metadata{
+		Name:        "raft.transport.dropped.other",
+		Help:        "Number of other outgoing Raft messages dropped by the Raft transport",
+		Measurement: "Messages",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftTransportBatchesSent = metric.Metadata{
+		Name:        "raft.transport.batches.sent",
+		Help:        "Number of batches of outgoing Raft messages sent by the Raft transport",
+		Measurement: "Batches",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftTransportBatchMessagesSent = metric.Metadata{
+		Name:        "raft.transport.batches.messages",
+		Help:        "Number of outgoing Raft messages sent in batches by the Raft transport",
+		Measurement: "Messages",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaRaftTransportHeartbeatsCoalesced = metric.Metadata{
+		Name:        "raft.transport.heartbeats.coalesced",
+		Help:        "Number of outgoing coalesced heartbeat messages merged into another message of the same batch",
+		Measurement: "Messages",
+		Unit:        metric.Unit_COUNT,
+	}
+)
+
+// RaftTransportMetrics holds metrics for the outgoing Raft message queues of
+// a RaftTransport.
+type RaftTransportMetrics struct {
+	SendQueueSize    *metric.Gauge
+	SendQueueBytes   *metric.Gauge
+	SendQueueMaxSize *metric.Gauge
+	DroppedApp       *metric.Counter
+	DroppedAppResp   *metric.Counter
+	DroppedVote      *metric.Counter
+	DroppedHeartbeat *metric.Counter
+	DroppedOther     *metric.Counter
+	// BatchesSent and BatchMessagesSent count the batches of outgoing messages
+	// and the messages in them, so their ratio is the average batch size.
+	// HeartbeatsCoalesced counts the heartbeat messages merged into another
+	// one of the same batch, which are not included in BatchMessagesSent.
+	BatchesSent         *metric.Counter
+	BatchMessagesSent   *metric.Counter
+	HeartbeatsCoalesced *metric.Counter
+
+	dropped [numRaftTransportMsgClasses]*metric.Counter
+}
+
+func newRaftTransportMetrics(t *RaftTransport) RaftTransportMetrics {
+	m := RaftTransportMetrics{
+		SendQueueSize:    metric.NewFunctionalGauge(metaRaftTransportSendQueueSize, t.queuedMessageCount),
+		SendQueueBytes:   metric.NewFunctionalGauge(metaRaftTransportSendQueueBytes, t.queuedMessageBytes),
+		SendQueueMaxSize: metric.NewFunctionalGauge(metaRaftTransportSendQueueMaxSize, t.maxQueuedMessageCount),
+		DroppedApp:       metric.NewCounter(metaRaftTransportDroppedApp),
+		DroppedAppResp:   metric.NewCounter(metaRaftTransportDroppedAppResp),
+		DroppedVote:      metric.NewCounter(metaRaftTransportDroppedVote),
+		DroppedHeartbeat: metric.NewCounter(metaRaftTransportDroppedHeartbeat),
+		DroppedOther:     metric.NewCounter(metaRaftTransportDroppedOther),
+
+		BatchesSent:         metric.NewCounter(metaRaftTransportBatchesSent),
+		BatchMessagesSent:   metric.NewCounter(metaRaftTransportBatchMessagesSent),
+		HeartbeatsCoalesced: metric.NewCounter(metaRaftTransportHeartbeatsCoalesced),
+	}
+	m.dropped = [numRaftTransportMsgClasses]*metric.Counter{
+		raftTransportMsgApp:       m.DroppedApp,
+		raftTransportMsgAppResp:   m.DroppedAppResp,
+		raftTransportMsgVote:      m.DroppedVote,
+		raftTransportMsgHeartbeat: m.DroppedHeartbeat,
+		raftTransportMsgOther:     m.DroppedOther,
+	}
+	return m
+}
@@ -118,7 +118,7 @@ func newRaftTransportTestContext(t testing.TB) *raftTransportTestContext {
 		testutils.NewNodeTestBaseContext(),
 		hlc.NewClock(hlc.UnixNano, time.Nanosecond),
 		rttc.stopper,
-		&cluster.MakeTestingClusterSettings().Version,
+		cluster.MakeTestingClusterSettings(),
 	)
 	// Ensure that tests using this test context and restart/shut down
 	// their servers do not inadvertently start talking to servers from
@@ -463,6 +463,101 @@ func TestRaftTransportCircuitBreaker(t *testing.T) {
 	})
 }
 
+// TestRaftTransportDroppedMessages verifies that dropped outgoing messages
+// are accounted for by type, and that the queued messages are accounted for
+// until they are sent.
+func TestRaftTransportDroppedMessages(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	rttc := newRaftTransportTestContext(t)
+	defer rttc.Stop()
+
+	serverReplica := roachpb.ReplicaDescriptor{
+		NodeID:    2,
+		StoreID:   2,
+		ReplicaID: 2,
+	}
+	_, serverAddr := rttc.AddNodeWithoutGossip(serverReplica.NodeID, util.TestAddr, rttc.stopper)
+	serverChannel := rttc.ListenStore(serverReplica.NodeID, serverReplica.StoreID)
+
+	clientReplica := roachpb.ReplicaDescriptor{
+		NodeID:    1,
+		StoreID:   1,
+		ReplicaID: 1,
+	}
+	clientTransport := rttc.AddNode(clientReplica.NodeID)
+
+	// The address of the server hasn't been gossiped, so all messages to it are
+	// dropped.
+	for _, typ := range []raftpb.MessageType{
+		raftpb.MsgApp, raftpb.MsgApp, raftpb.MsgAppResp, raftpb.MsgVote, raftpb.MsgPreVoteResp,
+		raftpb.MsgTimeoutNow,
+	} {
+		if rttc.Send(clientReplica, serverReplica, 1, raftpb.Message{Type: typ}) {
+			t.Fatalf("expected %s to be dropped", typ)
+		}
+	}
+	if clientTransport.SendAsync(&storage.RaftMessageRequest{
+		ToReplica:   serverReplica,
+		FromReplica: clientReplica,
+		Heartbeats:  []storage.RaftHeartbeat{{RangeID: 1}},
+	}) {
+		t.Fatal("expected coalesced heartbeat to be dropped")
+	}
+
+	m := clientTransport.Metrics()
+	for _, tc := range []struct {
+		name     string
+		counter  *metric.Counter
+		expected int64
+	}{
+		{"app", m.DroppedApp, 2},
+		{"appresp", m.DroppedAppResp, 1},
+		{"vote", m.DroppedVote, 2},
+		{"heartbeat", m.DroppedHeartbeat, 1},
+		{"other", m.DroppedOther, 1},
+	} {
+		if a := tc.counter.Count(); a != tc.expected {
+			t.Errorf("expected %d dropped %s messages, got %d", tc.expected, tc.name, a)
+		}
+	}
+	peerStats := clientTransport.PeerStats()
+	if len(peerStats) != 1 || peerStats[0].NodeID != serverReplica.NodeID {
+		t.Fatalf("expected stats for n%d only, got %+v", serverReplica.NodeID, peerStats)
+	}
+	if ps := peerStats[0]; ps.DroppedApp != 2 || ps.DroppedAppResp != 1 || ps.DroppedVote != 2 ||
+		ps.DroppedHeartbeat != 1 || ps.DroppedOther != 1 {
+		t.Errorf("unexpected dropped messages in %+v", ps)
+	}
+
+	// Once the server's address is gossiped, messages make it through and the
+	// queue drains.
+	rttc.GossipNode(serverReplica.NodeID, serverAddr)
+	testutils.SucceedsSoon(t, func() error {
+		if !rttc.Send(clientReplica, serverReplica, 1, raftpb.Message{Commit: 1}) {
+			clientTransport.GetCircuitBreaker(serverReplica.NodeID).Reset()
+		}
+		select {
+		case <-serverChannel.ch:
+			return nil
+		default:
+		}
+		return errors.Errorf("expected message to be received")
+	})
+	testutils.SucceedsSoon(t, func() error {
+		ps := clientTransport.PeerStats()[0]
+		if ps.QueueLen != 0 || ps.QueueBytes != 0 {
+			return errors.Errorf("expected queue to be drained: %+v", ps)
+		}
+		if ps.ClientSent == 0 {
+			return errors.Errorf("expected a batch to have been sent: %+v", ps)
+		}
+		if a := m.SendQueueBytes.Value(); a != 0 {
+			return errors.Errorf("expected no queued bytes, got %d", a)
+		}
+		return nil
+	})
+}
+
 // TestRaftTransportIndependentRanges ensures that errors from one
 // range do not interfere with messages to another range on the same
 // store.
@@ -18,6 +18,7 @@ import (
 	"context"
 	"math/rand"
 	"net"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -36,6 +37,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/pkg/errors"
+	"go.etcd.io/etcd/raft/raftpb"
 )
 
 func TestRaftTransportStartNewQueue(t *testing.T) {
@@ -47,7 +49,7 @@ func TestRaftTransportStartNewQueue(t *testing.T) {
 
 	st := cluster.MakeTestingClusterSettings()
 	rpcC := rpc.NewContext(log.AmbientContext{}, &base.Config{Insecure: true},
-		hlc.NewClock(hlc.UnixNano, 500*time.Millisecond), stopper, &st.Version)
+		hlc.NewClock(hlc.UnixNano, 500*time.Millisecond), stopper, st)
 	rpcC.ClusterID.Set(context.TODO(), uuid.MakeV4())
 
 	// mrs := &dummyMultiRaftServer{}
@@ -107,3 +109,59 @@ func TestRaftTransportStartNewQueue(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestCoalesceRaftHeartbeats(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	heartbeats := func(from, to roachpb.StoreID, rangeIDs ...roachpb.RangeID) RaftMessageRequest {
+		req := RaftMessageRequest{
+			FromReplica: roachpb.ReplicaDescriptor{StoreID: from},
+			ToReplica:   roachpb.ReplicaDescriptor{StoreID: to},
+		}
+		for _, rangeID := range rangeIDs {
+			req.Heartbeats = append(req.Heartbeats, RaftHeartbeat{RangeID: rangeID})
+		}
+		return req
+	}
+	resps := func(from, to roachpb.StoreID, rangeIDs ...roachpb.RangeID) RaftMessageRequest {
+		req := heartbeats(from, to)
+		for _, rangeID := range rangeIDs {
+			req.HeartbeatResps = append(req.HeartbeatResps, RaftHeartbeat{RangeID: rangeID})
+		}
+		return req
+	}
+	app := func(rangeID roachpb.RangeID) RaftMessageRequest {
+		return RaftMessageRequest{RangeID: rangeID, Message: raftpb.Message{Type: raftpb.MsgApp}}
+	}
+
+	// Give the first request's heartbeats spare capacity, which must not be
+	// used as the slice is owned by the sender.
+	first := heartbeats(1, 2, 1, 2)
+	first.Heartbeats = append(make([]RaftHeartbeat, 0, 10), first.Heartbeats...)
+	reqs := []RaftMessageRequest{
+		first,
+		app(1),
+		heartbeats(1, 3, 3),
+		resps(1, 2, 4),
+		heartbeats(1, 2, 5),
+		app(2),
+		resps(1, 2, 6),
+	}
+	exp := []RaftMessageRequest{
+		heartbeats(1, 2, 1, 2, 5),
+		app(1),
+		heartbeats(1, 3, 3),
+		resps(1, 2, 4, 6),
+		app(2),
+	}
+	out, coalesced := coalesceRaftHeartbeats(reqs)
+	if coalesced != 2 {
+		t.Errorf("expected 2 requests to be coalesced, got %d", coalesced)
+	}
+	if !reflect.DeepEqual(out, exp) {
+		t.Errorf("expected\n%+v\ngot\n%+v", exp, out)
+	}
+	if spare := first.Heartbeats[:3][2]; spare != (RaftHeartbeat{}) {
+		t.Errorf("expected sender's heartbeats to be unchanged, found %+v", spare)
+	}
+}
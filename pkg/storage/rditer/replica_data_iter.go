@@ -56,8 +56,10 @@ func MakeReplicatedKeyRanges(d *roachpb.RangeDescriptor) []KeyRange {
 	return makeReplicaKeyRanges(d, keys.MakeRangeIDReplicatedPrefix)
 }
 
-// makeReplicaKeyRanges returns a slice of 3 key ranges. The last key range in
-// the returned slice corresponds to the actual range data (i.e. not the range
+// makeReplicaKeyRanges returns a slice of 5 key ranges: the range-ID local
+// keys, the range-local keys, the lock table entries for locks on range-local
+// and on global keys, and finally the global keys. The last key range in the
+// returned slice corresponds to the actual range data (i.e. not the range
 // metadata).
 func makeReplicaKeyRanges(
 	d *roachpb.RangeDescriptor, metaFunc func(roachpb.RangeID) roachpb.Key,
@@ -79,6 +81,14 @@ func makeReplicaKeyRanges(
 			Start: engine.MakeMVCCMetadataKey(keys.MakeRangeKeyPrefix(d.StartKey)),
 			End:   engine.MakeMVCCMetadataKey(keys.MakeRangeKeyPrefix(d.EndKey)),
 		},
+		{
+			Start: engine.MakeMVCCMetadataKey(keys.LockTableSingleKey(keys.MakeRangeKeyPrefix(d.StartKey))),
+			End:   engine.MakeMVCCMetadataKey(keys.LockTableSingleKey(keys.MakeRangeKeyPrefix(d.EndKey))),
+		},
+		{
+			Start: engine.MakeMVCCMetadataKey(keys.LockTableSingleKey(dataStartKey)),
+			End:   engine.MakeMVCCMetadataKey(keys.LockTableSingleKey(d.EndKey.AsRawKey())),
+		},
 		{
 			Start: engine.MakeMVCCMetadataKey(dataStartKey),
 			End:   engine.MakeMVCCMetadataKey(d.EndKey.AsRawKey()),
@@ -0,0 +1,263 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/pkg/errors"
+)
+
+// readOnlyRequestsLimit limits the number of read-only batches a store
+// evaluates concurrently.
+var readOnlyRequestsLimit = settings.RegisterNonNegativeIntSetting(
+	"kv.store.concurrent_read_only_requests",
+	"number of read-only batches a store evaluates concurrently before queuing, or 0 for no limit",
+	0,
+)
+
+// readOnlyRequestsReservedFraction is the fraction of the read-only request
+// slots reserved for each priority above the lowest.
+var readOnlyRequestsReservedFraction = settings.RegisterValidatedFloatSetting(
+	"kv.store.concurrent_read_only_requests.reserved_fraction",
+	"fraction of the concurrent read-only request slots of a store which are reserved for "+
+		"requests of each priority above the lowest; high priority and system requests may "+
+		"use all slots, normal priority requests all but one fraction, and low priority "+
+		"requests all but two",
+	0.2,
+	func(v float64) error {
+		if v < 0 || v >= 0.5 {
+			return errors.Errorf("reserved fraction must be in [0, 0.5): %f", v)
+		}
+		return nil
+	},
+)
+
+// readOnlyPriority is the priority with which a read-only batch is admitted
+// by a readOnlyQueue. Lower values are admitted first.
+type readOnlyPriority int
+
+const (
+	readOnlyHigh readOnlyPriority = iota
+	readOnlyNormal
+	readOnlyLow
+	numReadOnlyPriorities
+)
+
+// readOnlyPriorityOf returns the priority with which a read-only batch on a
+// range with the given start key is admitted. Batches of the high priority
+// class and those reading system data (such as range lookups) are of high
+// priority, and those of transactions or requests with the minimum user
+// priority, which analytic queries are typically run with, are of low
+// priority.
+func readOnlyPriorityOf(ba *roachpb.BatchRequest, startKey roachpb.RKey) readOnlyPriority {
+	if ba.PriorityClass == roachpb.HIGH_PRIORITY || startKey.Less(roachpb.RKey(keys.UserTableDataMin)) {
+		return readOnlyHigh
+	}
+	if ba.UserPriority == roachpb.MinUserPriority ||
+		(ba.Txn != nil && ba.Txn.Priority == enginepb.MinTxnPriority) {
+		return readOnlyLow
+	}
+	return readOnlyNormal
+}
+
+// readOnlyQueue limits the number of read-only batches a store evaluates
+// concurrently, so that a burst of expensive scans can't take up all of the
+// node's CPU and starve writes. Batches which can't be admitted right away
+// wait in a queue per priority, and a freed slot always goes to the oldest
+// waiter of the highest priority which may use it.
+//
+// A fraction of the slots is carved out for each priority above the lowest:
+// batches of a lower priority are only admitted while fewer slots than the
+// capacity minus the slots carved out for the higher priorities are in use.
+// This keeps some slots available for range lookups and other high priority
+// reads when the store is flooded with ordinary ones.
+type readOnlyQueue struct {
+	waiting   *metric.Gauge
+	admitted  *metric.Counter
+	waitNanos *metric.Counter
+
+	mu struct {
+		syncutil.Mutex
+		// capacity is the number of slots, or 0 if the number of concurrent
+		// batches isn't limited.
+		capacity         int
+		reservedFraction float64
+		inUse            int
+		// waiters holds, for each priority, the channels of the waiting batches
+		// in the order in which they arrived. A waiter is admitted by closing
+		// its channel, at which point the slot has been handed over to it.
+		waiters [numReadOnlyPriorities][]chan struct{}
+	}
+}
+
+func newReadOnlyQueue(capacity int, reservedFraction float64, metrics *StoreMetrics) *readOnlyQueue {
+	q := &readOnlyQueue{
+		waiting:   metrics.ReadOnlyQueueWaiting,
+		admitted:  metrics.ReadOnlyQueueAdmitted,
+		waitNanos: metrics.ReadOnlyQueueWaitNanos,
+	}
+	q.mu.capacity = capacity
+	q.mu.reservedFraction = reservedFraction
+	return q
+}
+
+// setCapacity sets the number of slots and the fraction of them reserved per
+// priority, admitting waiters if this freed up slots for them.
+func (q *readOnlyQueue) setCapacity(capacity int, reservedFraction float64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.mu.capacity = capacity
+	q.mu.reservedFraction = reservedFraction
+	q.admitLocked()
+}
+
+// limitLocked returns the number of slots which may be in use for a batch of
+// the given priority to be admitted. It is at least one, so that batches of
+// all priorities make progress.
+func (q *readOnlyQueue) limitLocked(p readOnlyPriority) int {
+	reserved := int(float64(q.mu.capacity) * q.mu.reservedFraction)
+	limit := q.mu.capacity - int(p)*reserved
+	if limit < 1 {
+		limit = 1
+	}
+	return limit
+}
+
+// canAdmitLocked returns whether a batch of the given priority can be
+// admitted without waiting.
+func (q *readOnlyQueue) canAdmitLocked(p readOnlyPriority) bool {
+	return q.mu.capacity == 0 || q.mu.inUse < q.limitLocked(p)
+}
+
+// acquire admits a read-only batch of the given priority, waiting for a slot
+// if necessary. It returns whether a slot was acquired, in which case it must
+// be returned by calling release once the batch has been evaluated.
+func (q *readOnlyQueue) acquire(
+	ctx context.Context, stopper *stop.Stopper, p readOnlyPriority,
+) (bool, error) {
+	q.mu.Lock()
+	if q.mu.capacity == 0 {
+		q.mu.Unlock()
+		return false, nil
+	}
+	if q.canAdmitLocked(p) && !q.hasWaitersLocked(p) {
+		q.mu.inUse++
+		q.mu.Unlock()
+		q.admitted.Inc(1)
+		return true, nil
+	}
+	ch := make(chan struct{})
+	q.mu.waiters[p] = append(q.mu.waiters[p], ch)
+	q.mu.Unlock()
+
+	ctx, span := tracing.ChildSpan(ctx, "read-only request queue")
+	defer tracing.FinishSpan(span)
+	start := timeutil.Now()
+	q.waiting.Inc(1)
+	defer func() {
+		q.waiting.Dec(1)
+		q.waitNanos.Inc(timeutil.Since(start).Nanoseconds())
+	}()
+	select {
+	case <-ch:
+		q.admitted.Inc(1)
+		return true, nil
+	case <-ctx.Done():
+		q.abandon(p, ch)
+		return false, ctx.Err()
+	case <-stopper.ShouldStop():
+		q.abandon(p, ch)
+		return false, errors.Errorf("stopped")
+	}
+}
+
+// release returns the slot of an admitted batch, handing it to the next
+// waiter which may use it.
+func (q *readOnlyQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.mu.inUse--
+	q.admitLocked()
+}
+
+// admitLocked admits waiters, highest priority first, for as long as there
+// are slots available to them.
+func (q *readOnlyQueue) admitLocked() {
+	for p := range q.mu.waiters {
+		for len(q.mu.waiters[p]) > 0 && q.canAdmitLocked(readOnlyPriority(p)) {
+			close(q.mu.waiters[p][0])
+			q.mu.waiters[p] = q.mu.waiters[p][1:]
+			q.mu.inUse++
+		}
+		if len(q.mu.waiters[p]) > 0 {
+			// Don't let lower priorities jump ahead.
+			return
+		}
+	}
+}
+
+// hasWaitersLocked returns whether any batch of the given or a higher
+// priority is waiting, in which case a newly arrived batch of the given
+// priority must not jump ahead of it.
+func (q *readOnlyQueue) hasWaitersLocked(p readOnlyPriority) bool {
+	for i := readOnlyPriority(0); i <= p; i++ {
+		if len(q.mu.waiters[i]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// abandon removes a waiter which gave up. If the waiter was admitted
+// concurrently, the slot it was handed is released.
+func (q *readOnlyQueue) abandon(p readOnlyPriority, ch chan struct{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, waiter := range q.mu.waiters[p] {
+		if waiter == ch {
+			// The waiter may have held back waiters of lower priorities.
+			q.mu.waiters[p] = append(q.mu.waiters[p][:i], q.mu.waiters[p][i+1:]...)
+			q.admitLocked()
+			return
+		}
+	}
+	q.mu.inUse--
+	q.admitLocked()
+}
+
+// admitReadOnlyBatch waits for the store's read-only request queue to admit
+// the evaluation of the batch. The returned function must be called once the
+// evaluation is done.
+func (r *Replica) admitReadOnlyBatch(
+	ctx context.Context, ba *roachpb.BatchRequest,
+) (func(), error) {
+	p := readOnlyPriorityOf(ba, r.Desc().StartKey)
+	if acquired, err := r.store.readOnlyQueue.acquire(ctx, r.store.stopper, p); err != nil {
+		return nil, err
+	} else if !acquired {
+		return func() {}, nil
+	}
+	return r.store.readOnlyQueue.release, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/pkg/errors"
+)
+
+// TestReadOnlyQueuePriority verifies that the slots carved out for higher
+// priorities are respected and that waiters are admitted highest priority
+// first.
+func TestReadOnlyQueuePriority(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+
+	// With five slots and one of them carved out per priority, low priority
+	// batches may use three slots, normal priority ones four and high priority
+	// ones all five.
+	metrics := newStoreMetrics(time.Minute)
+	q := newReadOnlyQueue(5 /* capacity */, 0.2 /* reservedFraction */, metrics)
+
+	mustAcquire := func(p readOnlyPriority) {
+		t.Helper()
+		if acquired, err := q.acquire(ctx, stopper, p); err != nil || !acquired {
+			t.Fatalf("expected priority %d to be admitted: %v", p, err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		mustAcquire(readOnlyLow)
+	}
+
+	admitted := make(chan readOnlyPriority, 2)
+	waitFor := func(p readOnlyPriority) {
+		if acquired, err := q.acquire(ctx, stopper, p); err != nil || !acquired {
+			t.Errorf("expected priority %d to be admitted: %v", p, err)
+		}
+		admitted <- p
+	}
+	expectWaiting := func(exp int64) {
+		t.Helper()
+		testutils.SucceedsSoon(t, func() error {
+			if n := metrics.ReadOnlyQueueWaiting.Value(); n != exp {
+				return errors.Errorf("expected %d waiting batches, got %d", exp, n)
+			}
+			return nil
+		})
+	}
+	go waitFor(readOnlyLow)
+	expectWaiting(1)
+
+	// Higher priorities can still use the slots carved out for them.
+	mustAcquire(readOnlyNormal)
+	mustAcquire(readOnlyHigh)
+	go waitFor(readOnlyNormal)
+	expectWaiting(2)
+
+	// The normal priority batch is admitted once four slots are free, and the
+	// low priority one only after it, once three are.
+	q.release()
+	select {
+	case p := <-admitted:
+		t.Fatalf("unexpected admission of priority %d", p)
+	case <-time.After(10 * time.Millisecond):
+	}
+	q.release()
+	if p := <-admitted; p != readOnlyNormal {
+		t.Fatalf("expected normal priority to be admitted first, got %d", p)
+	}
+	q.release()
+	select {
+	case p := <-admitted:
+		t.Fatalf("unexpected admission of priority %d", p)
+	case <-time.After(10 * time.Millisecond):
+	}
+	q.release()
+	if p := <-admitted; p != readOnlyLow {
+		t.Fatalf("expected low priority to be admitted, got %d", p)
+	}
+	expectWaiting(0)
+
+	// A canceled waiter gives up its place in the queue.
+	for i := 0; i < 2; i++ {
+		mustAcquire(readOnlyHigh)
+	}
+	cancelCtx, cancel := context.WithCancel(ctx)
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := q.acquire(cancelCtx, stopper, readOnlyHigh)
+		errCh <- err
+	}()
+	expectWaiting(1)
+	cancel()
+	if err := <-errCh; err != context.Canceled {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+	expectWaiting(0)
+
+	// Without a limit, batches are admitted without taking up a slot.
+	q.setCapacity(0, 0.2)
+	if acquired, err := q.acquire(ctx, stopper, readOnlyLow); err != nil || acquired {
+		t.Fatalf("expected batch to be admitted without a slot: %v", err)
+	}
+}
@@ -184,6 +184,10 @@ type Replica struct {
 	// https://github.com/cockroachdb/cockroach/issues/32583.
 	readOnlyCmdMu syncutil.RWMutex
 
+	// readCache caches the results of point reads until the next command is
+	// applied. See replicaReadCache.
+	readCache replicaReadCache
+
 	// rangeStr is a string representation of a RangeDescriptor that can be
 	// atomically read and updated without needing to acquire the replica.mu lock.
 	// All updates to state.Desc should be duplicated here.
@@ -200,6 +204,14 @@ type Replica struct {
 		stateLoader stateloader.StateLoader
 		// on-disk storage for sideloaded SSTables. nil when there's no ReplicaID.
 		sideloaded SideloadStorage
+		// removedNextReplicaID is set when the replica applied a replication
+		// change removing it and may be removed eagerly at the end of the
+		// current Raft ready iteration. It is the NextReplicaID of the range
+		// descriptor the change installed.
+		removedNextReplicaID roachpb.ReplicaID
+		// logAppends remembers the latency of the most recent writes to the
+		// Raft log, for the tracing of the application of commands.
+		logAppends raftLogAppendHistory
 	}
 
 	// Contains the lease history when enabled.
@@ -220,6 +232,8 @@ type Replica struct {
 		// Is the range quiescent? Quiescent ranges are not Tick()'d and unquiesce
 		// whenever a Raft operation is performed.
 		quiescent bool
+		// The reason the range was last unquiesced, if it ever was.
+		unquiesceReason unquiesceReason
 		// mergeComplete is non-nil if a merge is in-progress, in which case any
 		// requests should be held until the completion of the merge is signaled by
 		// the closing of the channel.
@@ -286,6 +300,11 @@ type Replica struct {
 		minLeaseProposedTS hlc.Timestamp
 		// A pointer to the zone config for this replica.
 		zone *config.ZoneConfig
+		// zoneIsDefault is set while zone is the default zone config of the
+		// store, which the replica starts out with until the zone config of its
+		// range is looked up in the gossiped system config. See
+		// maybeResolveZoneConfig.
+		zoneIsDefault bool
 		// proposals stores the Raft in-flight commands which originated at
 		// this Replica, i.e. all commands for which propose has been called,
 		// but which have not yet applied.
@@ -344,6 +363,12 @@ type Replica struct {
 		// live node will not lose leaseholdership.
 		lastUpdateTimes lastUpdateTimesMap
 
+		// pausedFollowers is the set of followers to which the leader doesn't
+		// send log entries because their stores are overloaded. It is
+		// recomputed on every tick, and empty on followers. See
+		// updatePausedFollowersLocked.
+		pausedFollowers map[roachpb.ReplicaID]struct{}
+
 		// The last seen replica descriptors from incoming Raft messages. These are
 		// stored so that the replica still knows the replica descriptors for itself
 		// and for its message recipients in the circumstances when its RangeDescriptor
@@ -449,6 +474,8 @@ type Replica struct {
 
 	// loadBasedSplitter keeps information about load-based splitting.
 	loadBasedSplitter split.Decider
+	// hotKeys samples the keys of the requests served by the replica.
+	hotKeys hotKeySampler
 
 	unreachablesMu struct {
 		syncutil.Mutex
@@ -615,6 +642,41 @@ func (r *Replica) SetZoneConfig(zone *config.ZoneConfig) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	r.mu.zone = zone
+	r.mu.zoneIsDefault = false
+}
+
+// maybeResolveZoneConfig looks up the zone config of a replica which still
+// uses the default zone config of the store, which is the case when no system
+// config had been gossiped by the time the replica was created. Without it,
+// such a replica would be split and backpressured according to the default
+// range_max_bytes instead of the one of its zone until the next system config
+// update. Returns whether the zone config of the replica was updated.
+func (r *Replica) maybeResolveZoneConfig(desc *roachpb.RangeDescriptor) bool {
+	if r.store.Gossip() == nil {
+		return false
+	}
+	cfg := r.store.Gossip().GetSystemConfig()
+	if cfg == nil {
+		return false
+	}
+	zone, err := cfg.GetZoneConfigForKey(desc.StartKey)
+	if err != nil {
+		// Keep the default zone config, like systemGossipUpdate does, instead
+		// of looking it up again on every command.
+		zone = nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.mu.zoneIsDefault {
+		return false
+	}
+	r.mu.zoneIsDefault = false
+	if zone == nil {
+		return false
+	}
+	r.mu.zone = zone
+	return true
 }
 
 // IsFirstRange returns true if this is the first range.
@@ -735,6 +797,13 @@ func (r *Replica) GetTxnSpanGCThreshold() hlc.Timestamp {
 	return *r.mu.state.TxnSpanGCThreshold
 }
 
+// GetProtectedTimestamp returns the earliest protected timestamp overlapping
+// the replica, as known to the store's protected timestamp cache.
+func (r *Replica) GetProtectedTimestamp() hlc.Timestamp {
+	span := r.Desc().RSpan().AsRawSpanWithNoLocals()
+	return r.store.cfg.ProtectedTimestampCache.Earliest(span)
+}
+
 func maxReplicaID(desc *roachpb.RangeDescriptor) roachpb.ReplicaID {
 	if desc == nil || !desc.IsInitialized() {
 		return 0
@@ -1130,7 +1199,7 @@ func (r *Replica) beginCmds(
 		// protected access and to avoid interacting requests from operating at
 		// the same time. The latches will be held for the duration of request.
 		var err error
-		lg, err = r.latchMgr.Acquire(ctx, spans, ba.Timestamp)
+		lg, err = r.latchMgr.Acquire(ctx, spans, ba.Timestamp, ba.PriorityClass)
 		if err != nil {
 			return nil, err
 		}
@@ -1208,6 +1277,11 @@ func (r *Replica) beginCmds(
 		}
 	}
 
+	// Sample the keys of the batch to report the hottest keys of the range.
+	if HotKeySamplingEnabled.Get(&r.store.cfg.Settings.SV) {
+		r.hotKeys.record(timeutil.Now(), ba)
+	}
+
 	ec := &endCmds{
 		repl: r,
 		lg:   lg,
@@ -1436,7 +1510,7 @@ func (r *Replica) maybeWatchForMerge(ctx context.Context) error {
 	// orphaned followers would fail to queue themselves for GC.) Unquiesce the
 	// range in case it managed to quiesce between when the Subsume request
 	// arrived and now, which is rare but entirely legal.
-	r.unquiesceLocked()
+	r.unquiesceLocked(unquiesceReasonMerge)
 	r.mu.Unlock()
 
 	taskCtx := r.AnnotateCtx(context.Background())
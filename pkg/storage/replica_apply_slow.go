@@ -0,0 +1,130 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// slowApplyThreshold is the duration past which the application of the
+// replicated result of a Raft command is logged along with the time spent in
+// each of its steps. Application happens on the Raft goroutine of the store,
+// so a slow application stalls all other ranges handled by that goroutine.
+var slowApplyThreshold = settings.RegisterNonNegativeDurationSetting(
+	"kv.raft.slow_apply_threshold",
+	"if nonzero, Raft commands whose side effects take longer than this duration "+
+		"to apply are logged along with the time spent in each step",
+	time.Second,
+)
+
+// applyStep is a named step of the application of a replicated result and
+// the time spent in it.
+type applyStep struct {
+	name     string
+	duration time.Duration
+}
+
+// applyTimer times the steps of the application of a replicated result. To
+// keep the common case cheap, a step is only recorded if the application has
+// been running for at least half of the slow application threshold by the
+// time the step ends; the steps before that together took less than half of
+// the threshold and are left out. The zero value is disabled, and all of its
+// methods are no-ops.
+type applyTimer struct {
+	enabled     bool
+	recordAfter time.Duration
+	start       time.Time
+	last        time.Time
+	steps       []applyStep
+}
+
+// makeApplyTimer returns an applyTimer, which is enabled if the slow
+// application threshold is nonzero.
+func makeApplyTimer(threshold time.Duration) applyTimer {
+	if threshold == 0 {
+		return applyTimer{}
+	}
+	now := timeutil.Now()
+	return applyTimer{enabled: true, recordAfter: threshold / 2, start: now, last: now}
+}
+
+// step records the time since the end of the previous step, or since the
+// timer was created, as spent in the named step, provided that the slow
+// application threshold is near.
+func (t *applyTimer) step(name string) {
+	if !t.enabled {
+		return
+	}
+	now := timeutil.Now()
+	if now.Sub(t.start) >= t.recordAfter {
+		t.steps = append(t.steps, applyStep{name: name, duration: now.Sub(t.last)})
+	}
+	t.last = now
+}
+
+// elapsed returns the time since the timer was created.
+func (t *applyTimer) elapsed() time.Duration {
+	if !t.enabled {
+		return 0
+	}
+	return timeutil.Since(t.start)
+}
+
+// String formats the recorded steps as space-separated name=duration pairs.
+func (t *applyTimer) String() string {
+	var buf bytes.Buffer
+	for i, s := range t.steps {
+		if i > 0 {
+			buf.WriteByte(' ')
+		}
+		fmt.Fprintf(&buf, "%s=%s", s.name, s.duration)
+	}
+	return buf.String()
+}
+
+// maybeLogSlowApply logs the application of a replicated result if it took
+// longer than the slow application threshold. The request is only known to
+// the replica which proposed the command and is nil elsewhere.
+func (r *Replica) maybeLogSlowApply(
+	ctx context.Context,
+	t *applyTimer,
+	ba *roachpb.BatchRequest,
+	raftAppliedIndex, leaseAppliedIndex uint64,
+) {
+	if !t.enabled {
+		return
+	}
+	elapsed := t.elapsed()
+	threshold := slowApplyThreshold.Get(&r.store.cfg.Settings.SV)
+	if threshold == 0 || elapsed < threshold {
+		return
+	}
+	r.store.metrics.RaftCommandsSlowApplied.Inc(1)
+	summary := "<remote>"
+	if ba != nil {
+		summary = ba.Summary()
+	}
+	log.Warningf(ctx, "slow application of Raft command: raft_index=%d lease_index=%d "+
+		"duration=%s request=%q steps=[%s]",
+		raftAppliedIndex, leaseAppliedIndex, elapsed, summary, t)
+}
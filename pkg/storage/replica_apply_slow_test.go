@@ -0,0 +1,59 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestApplyTimer(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	disabled := makeApplyTimer(0)
+	disabled.step("stats")
+	if len(disabled.steps) != 0 || disabled.elapsed() != 0 {
+		t.Fatalf("expected disabled timer to record nothing, got %+v", disabled)
+	}
+
+	// Far from the threshold, steps aren't recorded.
+	fast := makeApplyTimer(time.Hour)
+	fast.step("stats")
+	if len(fast.steps) != 0 {
+		t.Fatalf("expected steps far below the threshold to be omitted, got %+v", fast.steps)
+	}
+
+	timer := makeApplyTimer(time.Nanosecond)
+	timer.step("stats")
+	timer.step("raft_log")
+	timer.step("split")
+	if len(timer.steps) != 3 {
+		t.Fatalf("expected 3 steps, got %+v", timer.steps)
+	}
+	var sum time.Duration
+	for _, s := range timer.steps {
+		sum += s.duration
+	}
+	if elapsed := timer.elapsed(); sum > elapsed {
+		t.Fatalf("steps took %s in total, more than the elapsed %s", sum, elapsed)
+	}
+	re := regexp.MustCompile(`^stats=\S+ raft_log=\S+ split=\S+$`)
+	if s := timer.String(); !re.MatchString(s) {
+		t.Fatalf("unexpected format of steps: %q", s)
+	}
+}
@@ -43,6 +43,22 @@ var backpressureRangeSizeMultiplier = settings.RegisterValidatedFloatSetting(
 	},
 )
 
+// backpressureRangeSizeHardCapMultiplier is the multiple of range_max_bytes
+// that a range's size must grow to before writes to it are backpressured on a
+// split even if none was ongoing. Set to 0 to disable.
+var backpressureRangeSizeHardCapMultiplier = settings.RegisterValidatedFloatSetting(
+	"kv.range.backpressure_hard_cap_multiplier",
+	"multiple of range_max_bytes past which writes to a range which is not "+
+		"splitting queue it for a split and wait for the split, or 0 to disable",
+	8.0,
+	func(v float64) error {
+		if v != 0 && v < 1 {
+			return errors.Errorf("backpressure hard cap multiplier cannot be smaller than 1: %f", v)
+		}
+		return nil
+	},
+)
+
 // backpressurableSpans contains spans of keys where write backpressuring
 // is permitted. Writes to any keys within these spans may cause a batch
 // to be backpressured.
@@ -94,6 +110,20 @@ func (r *Replica) shouldBackpressureWrites() bool {
 	return r.exceedsMultipleOfSplitSizeRLocked(mult)
 }
 
+// exceedsBackpressureHardCap returns whether the range is more than
+// backpressureRangeSizeHardCapMultiplier times larger than the split size.
+func (r *Replica) exceedsBackpressureHardCap() bool {
+	mult := backpressureRangeSizeHardCapMultiplier.Get(&r.store.cfg.Settings.SV)
+	if mult == 0 {
+		// Disabled.
+		return false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.exceedsMultipleOfSplitSizeRLocked(mult)
+}
+
 // maybeBackpressureWriteBatch blocks to apply backpressure if the replica
 // deems that backpressure is necessary.
 func (r *Replica) maybeBackpressureWriteBatch(ctx context.Context, ba roachpb.BatchRequest) error {
@@ -105,6 +135,7 @@ func (r *Replica) maybeBackpressureWriteBatch(ctx context.Context, ba roachpb.Ba
 	// if one exists. This does not place a hard upper bound on the size of
 	// a range because we don't track all in-flight requests (like we do for
 	// the quota pool), but it does create an effective soft upper bound.
+	var queued bool
 	for first := true; r.shouldBackpressureWrites(); first = false {
 		if first {
 			r.store.metrics.BackpressuredOnSplitRequests.Inc(1)
@@ -120,10 +151,32 @@ func (r *Replica) maybeBackpressureWriteBatch(ctx context.Context, ba roachpb.Ba
 		if !r.store.splitQueue.MaybeAddCallback(r.RangeID, func(err error) {
 			splitC <- err
 		}) {
-			// No split ongoing. We may have raced with its completion. There's
-			// no good way to prevent this race, so we conservatively allow the
-			// request to proceed instead of throwing an error that would surface
-			// to the client.
+			// No split ongoing. If the range vastly exceeds its maximum size,
+			// letting writes through until the split queue gets to it only
+			// makes it worse, so queue the range for a split right away and
+			// wait on that split.
+			if !queued && r.exceedsBackpressureHardCap() {
+				queued = true
+				// Prioritize the split like the split queue does.
+				priority := float64(r.GetMVCCStats().Total()) / float64(r.GetMaxBytes())
+				_, err := r.store.splitQueue.addInternal(ctx, r.Desc(), priority)
+				if err == nil {
+					r.store.metrics.BackpressureHardCapSplits.Inc(1)
+					if backpressureLogLimiter.ShouldLog() {
+						log.Warningf(ctx, "range exceeds the hard cap on its size, queued split for batch %s", ba)
+					}
+					continue
+				}
+				// The split queue is disabled or stopped, so waiting would be
+				// in vain.
+				if !isExpectedQueueError(err) {
+					log.Errorf(ctx, "unable to queue split: %s", err)
+				}
+			}
+			// Otherwise, we may have raced with the completion of a split.
+			// There's no good way to prevent this race, so we conservatively
+			// allow the request to proceed instead of throwing an error that
+			// would surface to the client.
 			return nil
 		}
 
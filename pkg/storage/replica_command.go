@@ -812,7 +812,6 @@ func (r *Replica) changeReplicas(
 		}
 	}
 
-	rangeID := desc.RangeID
 	updatedDesc := *desc
 	updatedDesc.SetReplicas(desc.Replicas().DeepCopy())
 
@@ -827,6 +826,10 @@ func (r *Replica) changeReplicas(
 			return nil, errors.Errorf("%s: unable to add replica %v; node already has a replica", r, repDesc)
 		}
 
+		if useLearnerReplicas(r.store.ClusterSettings()) {
+			return r.addReplicaViaLearner(ctx, repDesc, desc, priority, reason, details)
+		}
+
 		// Send a pre-emptive snapshot. Note that the replica to which this
 		// snapshot is addressed has not yet had its replica ID initialized; this
 		// is intentional, and serves to avoid the following race with the replica
@@ -868,6 +871,22 @@ func (r *Replica) changeReplicas(
 		}
 	}
 
+	return r.execChangeReplicasTxn(ctx, desc, &updatedDesc, changeType, repDesc, reason, details)
+}
+
+// execChangeReplicasTxn runs the transaction which replaces the range
+// descriptor desc with updatedDesc, carrying a ChangeReplicasTrigger for the
+// given change of the given replica. It returns the updated descriptor.
+func (r *Replica) execChangeReplicasTxn(
+	ctx context.Context,
+	desc *roachpb.RangeDescriptor,
+	updatedDesc *roachpb.RangeDescriptor,
+	changeType roachpb.ReplicaChangeType,
+	repDesc roachpb.ReplicaDescriptor,
+	reason storagepb.RangeLogEventReason,
+	details string,
+) (*roachpb.RangeDescriptor, error) {
+	rangeID := desc.RangeID
 	descKey := keys.RangeDescriptorKey(desc.StartKey)
 
 	if err := r.store.DB().Txn(ctx, func(ctx context.Context, txn *client.Txn) error {
@@ -890,7 +909,7 @@ func (r *Replica) changeReplicas(
 
 			// Important: the range descriptor must be the first thing touched in the transaction
 			// so the transaction record is co-located with the range being modified.
-			if err := updateRangeDescriptor(b, descKey, desc, &updatedDesc); err != nil {
+			if err := updateRangeDescriptor(b, descKey, desc, updatedDesc); err != nil {
 				return err
 			}
 
@@ -902,7 +921,7 @@ func (r *Replica) changeReplicas(
 
 		// Log replica change into range event log.
 		if err := r.store.logChange(
-			ctx, txn, changeType, repDesc, updatedDesc, reason, details,
+			ctx, txn, changeType, repDesc, *updatedDesc, reason, details,
 		); err != nil {
 			return err
 		}
@@ -912,7 +931,7 @@ func (r *Replica) changeReplicas(
 		b := txn.NewBatch()
 
 		// Update range descriptor addressing record(s).
-		if err := updateRangeAddressing(b, &updatedDesc); err != nil {
+		if err := updateRangeAddressing(b, updatedDesc); err != nil {
 			return err
 		}
 
@@ -950,16 +969,16 @@ func (r *Replica) changeReplicas(
 		return nil, errors.Wrapf(err, "change replicas of r%d failed", rangeID)
 	}
 	log.Event(ctx, "txn complete")
-	return &updatedDesc, nil
+	return updatedDesc, nil
 }
 
 // sendSnapshot sends a snapshot of the replica state to the specified
 // replica. This is used for both preemptive snapshots that are performed
 // before adding a replica to a range, and for Raft-initiated snapshots that
 // are used to bring a replica up to date that has fallen too far
-// behind. Currently only invoked from replicateQueue and raftSnapshotQueue. Be
-// careful about adding additional calls as generating a snapshot is moderately
-// expensive.
+// behind, including learners that were just added to a range. Currently only
+// invoked from replicateQueue and raftSnapshotQueue. Be careful about adding
+// additional calls as generating a snapshot is moderately expensive.
 func (r *Replica) sendSnapshot(
 	ctx context.Context,
 	repDesc roachpb.ReplicaDescriptor,
@@ -1039,6 +1058,10 @@ func (r *Replica) sendSnapshot(
 		CanDecline: snapType == snapTypePreemptive,
 		Priority:   priority,
 		Strategy:   SnapshotRequest_KV_BATCH,
+		// Let the recipient retain the data received on an interrupted
+		// stream. Recipients which don't know about resumption ignore this
+		// and the snapshot is sent again in full.
+		Resumable: true,
 	}
 	sent := func() {
 		r.store.metrics.RangeSnapshotsGenerated.Inc(1)
@@ -1051,6 +1074,7 @@ func (r *Replica) sendSnapshot(
 		snap,
 		r.store.Engine().NewBatch,
 		sent,
+		r.store.limiters.SnapshotSendRate,
 	); err != nil {
 		return &snapshotError{err}
 	}
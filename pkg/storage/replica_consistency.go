@@ -20,6 +20,7 @@ import (
 	"crypto/sha512"
 	"encoding/binary"
 	"fmt"
+	"hash"
 	"math"
 	"sort"
 	"sync"
@@ -37,6 +38,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/stateloader"
 	"github.com/cockroachdb/cockroach/pkg/util/bufalloc"
 	"github.com/cockroachdb/cockroach/pkg/util/contextutil"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
 	"github.com/cockroachdb/cockroach/pkg/util/envutil"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
@@ -90,6 +92,9 @@ func (r *Replica) CheckConsistency(
 		Mode:          args.Mode,
 		Checkpoint:    args.Checkpoint,
 	}
+	if st := r.ClusterSettings(); st.Version.IsActive(cluster.VersionShardedReplicaChecksums) {
+		checkArgs.Shards = uint32(consistencyCheckHashShards.Get(&st.SV))
+	}
 
 	isQueue := args.Mode == roachpb.ChecksumMode_CHECK_VIA_QUEUE
 
@@ -100,6 +105,8 @@ func (r *Replica) CheckConsistency(
 
 	var inconsistencyCount int
 	var missingCount int
+	// diffs holds the diffs of the inconsistent replicas against the local one.
+	var diffs bytes.Buffer
 
 	res := roachpb.CheckConsistencyResponse_Result{}
 	res.RangeID = r.RangeID
@@ -127,10 +134,21 @@ func (r *Replica) CheckConsistency(
 		)
 		if expResponse.Snapshot != nil && result.Response.Snapshot != nil {
 			diff := diffRange(expResponse.Snapshot, result.Response.Snapshot)
+			for _, d := range diff {
+				res.Diff = append(res.Diff, roachpb.CheckConsistencyResponse_Diff{
+					Replica:     result.Replica,
+					LeaseHolder: d.LeaseHolder,
+					Key:         d.Key,
+					Timestamp:   d.Timestamp,
+					Value:       d.Value,
+				})
+			}
 			if report := r.store.cfg.TestingKnobs.ConsistencyTestingKnobs.BadChecksumReportDiff; report != nil {
 				report(*r.store.Ident, diff)
 			}
 			_, _ = diff.WriteTo(&buf)
+			_, _ = fmt.Fprintf(&diffs, "replica %s:\n", result.Replica)
+			_, _ = diff.WriteTo(&diffs)
 		}
 		if isQueue {
 			log.Error(ctx, buf.String())
@@ -220,6 +238,13 @@ func (r *Replica) CheckConsistency(
 
 	// Diff was printed above, so call logFunc with a short message only.
 	if args.WithDiff {
+		// Save what's needed to triage the inconsistency before the node is
+		// terminated.
+		if dir, err := r.writeConsistencyTriageBundle(ctx, res.Detail, diffs.String()); err != nil {
+			log.Warningf(ctx, "unable to write consistency triage bundle: %s", err)
+		} else {
+			log.Errorf(ctx, "wrote consistency triage bundle to %s", dir)
+		}
 		logFunc(ctx, "consistency check failed with %d inconsistent replicas", inconsistencyCount)
 		return resp, nil
 	}
@@ -445,78 +470,37 @@ type replicaHash struct {
 }
 
 // sha512 computes the SHA512 hash of all the replica data at the snapshot.
-// It will dump all the kv data into snapshot if it is provided.
+// It will dump all the kv data into snapshot if it is provided. With more than
+// one shard, the replicated data is divided into disjoint spans which are
+// hashed concurrently, and the hash is computed over the hashes of the spans.
 func (r *Replica) sha512(
 	ctx context.Context,
 	desc roachpb.RangeDescriptor,
 	snap engine.Reader,
 	snapshot *roachpb.RaftSnapshotData,
 	mode roachpb.ChecksumMode,
+	shards int,
 ) (*replicaHash, error) {
 	statsOnly := mode == roachpb.ChecksumMode_CHECK_STATS
 
-	// Iterate over all the data in the range.
-	iter := snap.NewIterator(engine.IterOptions{UpperBound: desc.EndKey.AsRawKey()})
-	defer iter.Close()
-
-	var alloc bufalloc.ByteAllocator
-	var intBuf [8]byte
-	var legacyTimestamp hlc.LegacyTimestamp
-	var timestampBuf []byte
 	hasher := sha512.New()
-
-	visitor := func(unsafeKey engine.MVCCKey, unsafeValue []byte) error {
-		if snapshot != nil {
-			// Add (a copy of) the kv pair into the debug message.
-			kv := roachpb.RaftSnapshotData_KeyValue{
-				Timestamp: unsafeKey.Timestamp,
-			}
-			alloc, kv.Key = alloc.Copy(unsafeKey.Key, 0)
-			alloc, kv.Value = alloc.Copy(unsafeValue, 0)
-			snapshot.KV = append(snapshot.KV, kv)
-		}
-
-		// Encode the length of the key and value.
-		binary.LittleEndian.PutUint64(intBuf[:], uint64(len(unsafeKey.Key)))
-		if _, err := hasher.Write(intBuf[:]); err != nil {
-			return err
-		}
-		binary.LittleEndian.PutUint64(intBuf[:], uint64(len(unsafeValue)))
-		if _, err := hasher.Write(intBuf[:]); err != nil {
-			return err
-		}
-		if _, err := hasher.Write(unsafeKey.Key); err != nil {
-			return err
-		}
-		legacyTimestamp = hlc.LegacyTimestamp(unsafeKey.Timestamp)
-		if size := legacyTimestamp.Size(); size > cap(timestampBuf) {
-			timestampBuf = make([]byte, size)
-		} else {
-			timestampBuf = timestampBuf[:size]
-		}
-		if _, err := protoutil.MarshalToWithoutFuzzing(&legacyTimestamp, timestampBuf); err != nil {
-			return err
-		}
-		if _, err := hasher.Write(timestampBuf); err != nil {
-			return err
-		}
-		_, err := hasher.Write(unsafeValue)
-		return err
-	}
-
 	var ms enginepb.MVCCStats
 	// In statsOnly mode, we hash only the RangeAppliedState. In regular mode, hash
 	// all of the replicated key space.
 	if !statsOnly {
-		for _, span := range rditer.MakeReplicatedKeyRanges(&desc) {
-			spanMS, err := engine.ComputeStatsGo(
-				iter, span.Start, span.End, 0 /* nowNanos */, visitor,
-			)
-			if err != nil {
-				return nil, err
-			}
-			ms.Add(spanMS)
+		var bytesHashed int64
+		var err error
+		if shards <= 1 {
+			h := replicaHasher{hasher: hasher, snapshot: snapshot}
+			ms, err = h.hashSpans(snap, rditer.MakeReplicatedKeyRanges(&desc))
+			bytesHashed = h.bytesHashed
+		} else {
+			ms, bytesHashed, err = hashShards(ctx, hasher, snap, snapshot, checksumShards(&desc, shards))
+		}
+		if err != nil {
+			return nil, err
 		}
+		r.store.metrics.ConsistencyQueueBytesHashed.Inc(bytesHashed)
 	}
 
 	var result replicaHash
@@ -565,3 +549,186 @@ func (r *Replica) sha512(
 
 	return &result, nil
 }
+
+// replicaHasher hashes the key-value pairs of a replica for a consistency
+// check, optionally collecting them into a snapshot.
+type replicaHasher struct {
+	hasher       hash.Hash
+	snapshot     *roachpb.RaftSnapshotData
+	alloc        bufalloc.ByteAllocator
+	intBuf       [8]byte
+	timestampBuf []byte
+	bytesHashed  int64
+}
+
+// hashSpans hashes the key-value pairs of the given spans, which must be
+// ordered by key, and returns the MVCC stats of the spans.
+func (h *replicaHasher) hashSpans(
+	snap engine.Reader, spans []rditer.KeyRange,
+) (enginepb.MVCCStats, error) {
+	iter := snap.NewIterator(engine.IterOptions{UpperBound: spans[len(spans)-1].End.Key})
+	defer iter.Close()
+
+	var ms enginepb.MVCCStats
+	for _, span := range spans {
+		spanMS, err := engine.ComputeStatsGo(
+			iter, span.Start, span.End, 0 /* nowNanos */, h.visit,
+		)
+		if err != nil {
+			return enginepb.MVCCStats{}, err
+		}
+		ms.Add(spanMS)
+	}
+	return ms, nil
+}
+
+func (h *replicaHasher) visit(unsafeKey engine.MVCCKey, unsafeValue []byte) error {
+	h.bytesHashed += int64(len(unsafeKey.Key) + len(unsafeValue))
+	if h.snapshot != nil {
+		// Add (a copy of) the kv pair into the debug message.
+		kv := roachpb.RaftSnapshotData_KeyValue{
+			Timestamp: unsafeKey.Timestamp,
+		}
+		h.alloc, kv.Key = h.alloc.Copy(unsafeKey.Key, 0)
+		h.alloc, kv.Value = h.alloc.Copy(unsafeValue, 0)
+		h.snapshot.KV = append(h.snapshot.KV, kv)
+	}
+
+	// Encode the length of the key and value.
+	binary.LittleEndian.PutUint64(h.intBuf[:], uint64(len(unsafeKey.Key)))
+	if _, err := h.hasher.Write(h.intBuf[:]); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint64(h.intBuf[:], uint64(len(unsafeValue)))
+	if _, err := h.hasher.Write(h.intBuf[:]); err != nil {
+		return err
+	}
+	if _, err := h.hasher.Write(unsafeKey.Key); err != nil {
+		return err
+	}
+	legacyTimestamp := hlc.LegacyTimestamp(unsafeKey.Timestamp)
+	if size := legacyTimestamp.Size(); size > cap(h.timestampBuf) {
+		h.timestampBuf = make([]byte, size)
+	} else {
+		h.timestampBuf = h.timestampBuf[:size]
+	}
+	if _, err := protoutil.MarshalToWithoutFuzzing(&legacyTimestamp, h.timestampBuf); err != nil {
+		return err
+	}
+	if _, err := h.hasher.Write(h.timestampBuf); err != nil {
+		return err
+	}
+	_, err := h.hasher.Write(unsafeValue)
+	return err
+}
+
+// hashShards hashes each of the given shards concurrently and writes their
+// hashes, in order, to hasher. It returns the combined MVCC stats and the
+// number of bytes hashed. If snapshot is provided, it receives the key-value
+// pairs of all shards, in order.
+func hashShards(
+	ctx context.Context,
+	hasher hash.Hash,
+	snap engine.Reader,
+	snapshot *roachpb.RaftSnapshotData,
+	shards [][]rditer.KeyRange,
+) (enginepb.MVCCStats, int64, error) {
+	hashers := make([]replicaHasher, len(shards))
+	stats := make([]enginepb.MVCCStats, len(shards))
+	g := ctxgroup.WithContext(ctx)
+	for i := range shards {
+		i := i // copy for goroutine
+		hashers[i].hasher = sha512.New()
+		if snapshot != nil {
+			hashers[i].snapshot = &roachpb.RaftSnapshotData{}
+		}
+		g.Go(func() error {
+			var err error
+			stats[i], err = hashers[i].hashSpans(snap, shards[i])
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return enginepb.MVCCStats{}, 0, err
+	}
+
+	var ms enginepb.MVCCStats
+	var bytesHashed int64
+	for i := range hashers {
+		h := &hashers[i]
+		if _, err := hasher.Write(h.hasher.Sum(nil)); err != nil {
+			return enginepb.MVCCStats{}, 0, err
+		}
+		ms.Add(stats[i])
+		bytesHashed += h.bytesHashed
+		if snapshot != nil {
+			snapshot.KV = append(snapshot.KV, h.snapshot.KV...)
+		}
+	}
+	return ms, bytesHashed, nil
+}
+
+// checksumShards divides the replicated key spans of a range into at most n
+// shards, each of which lists its spans in key order. The user data of the
+// range is split across all shards by splitKeySpan, and the first shard also
+// holds the range-ID local and range-local spans.
+func checksumShards(desc *roachpb.RangeDescriptor, n int) [][]rditer.KeyRange {
+	spans := rditer.MakeReplicatedKeyRanges(desc)
+	local, data := spans[:len(spans)-1], spans[len(spans)-1]
+	splits := splitKeySpan(data.Start.Key, data.End.Key, n)
+
+	shards := make([][]rditer.KeyRange, 0, len(splits)+1)
+	start := data.Start
+	for i := 0; i <= len(splits); i++ {
+		end := data.End
+		if i < len(splits) {
+			end = engine.MakeMVCCMetadataKey(splits[i])
+		}
+		var shard []rditer.KeyRange
+		if i == 0 {
+			shard = append(shard, local...)
+		}
+		shards = append(shards, append(shard, rditer.KeyRange{Start: start, End: end}))
+		start = end
+	}
+	return shards
+}
+
+// splitKeySpan returns up to n-1 keys, in increasing order, which divide the
+// span [start, end) into n spans of equal width. Only the eight bytes
+// following the common prefix of start and end are considered, which makes
+// the result cheap to compute and independent of the data in the span, but
+// the spans hold equal amounts of data only if the keys are distributed
+// uniformly.
+func splitKeySpan(start, end roachpb.Key, n int) []roachpb.Key {
+	if n <= 1 {
+		return nil
+	}
+	var prefix int
+	for prefix < len(start) && prefix < len(end) && start[prefix] == end[prefix] {
+		prefix++
+	}
+	lo, hi := keyWindow(start, prefix), keyWindow(end, prefix)
+	if hi <= lo || (hi-lo)/uint64(n) == 0 {
+		return nil
+	}
+	step := (hi - lo) / uint64(n)
+	splits := make([]roachpb.Key, 0, n-1)
+	for i := 1; i < n; i++ {
+		split := make(roachpb.Key, prefix+8)
+		copy(split, start[:prefix])
+		binary.BigEndian.PutUint64(split[prefix:], lo+step*uint64(i))
+		splits = append(splits, split)
+	}
+	return splits
+}
+
+// keyWindow returns the eight bytes of the key starting at the given offset as
+// a big-endian integer. Missing bytes are treated as zeros.
+func keyWindow(key roachpb.Key, offset int) uint64 {
+	var buf [8]byte
+	if offset < len(key) {
+		copy(buf[:], key[offset:])
+	}
+	return binary.BigEndian.Uint64(buf[:])
+}
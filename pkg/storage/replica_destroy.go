@@ -40,6 +40,9 @@ const (
 	// The replica has been merged into its left-hand neighbor, but its left-hand
 	// neighbor hasn't yet subsumed it.
 	destroyReasonMergePending
+	// The replica has been quarantined after violating an apply-time invariant.
+	// It is left in place for investigation, but its Raft group is stalled.
+	destroyReasonQuarantined
 )
 
 type destroyStatus struct {
@@ -66,6 +69,11 @@ func (s destroyStatus) Removed() bool {
 	return s.reason == destroyReasonRemoved
 }
 
+// Quarantined returns whether the replica has been quarantined.
+func (s destroyStatus) Quarantined() bool {
+	return s.reason == destroyReasonQuarantined
+}
+
 func (r *Replica) preDestroyRaftMuLocked(
 	ctx context.Context,
 	reader engine.Reader,
@@ -27,6 +27,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/storage/spanset"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
 	"github.com/cockroachdb/cockroach/pkg/storage/txnwait"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
@@ -174,11 +175,23 @@ func (rec SpanSetReplicaEvalContext) GetTxnSpanGCThreshold() hlc.Timestamp {
 	return rec.i.GetTxnSpanGCThreshold()
 }
 
+// GetProtectedTimestamp returns the earliest protected timestamp overlapping
+// the Range.
+func (rec SpanSetReplicaEvalContext) GetProtectedTimestamp() hlc.Timestamp {
+	return rec.i.GetProtectedTimestamp()
+}
+
 // String implements Stringer.
 func (rec SpanSetReplicaEvalContext) String() string {
 	return rec.i.String()
 }
 
+// GetLeaseTransferSummary returns the state the Replica accumulated while
+// serving requests as the leaseholder.
+func (rec SpanSetReplicaEvalContext) GetLeaseTransferSummary() *storagepb.LeaseTransferSummary {
+	return rec.i.GetLeaseTransferSummary()
+}
+
 // GetLastReplicaGCTimestamp returns the last time the Replica was
 // considered for GC.
 func (rec SpanSetReplicaEvalContext) GetLastReplicaGCTimestamp(
@@ -156,6 +156,9 @@ func evaluateBatch(
 		// remaining keys we can touch.
 		maxKeys = ba.Header.MaxSpanRequestKeys
 	}
+	// If the batch has a byte target, we also keep track of how many bytes the
+	// remaining scans can return.
+	targetBytes := ba.Header.TargetBytes
 
 	// Optimize any contiguous sequences of put and conditional put ops.
 	if len(ba.Requests) >= optimizePutThreshold && !readOnly {
@@ -218,7 +221,9 @@ func evaluateBatch(
 		// Note that responses are populated even when an error is returned.
 		// TODO(tschottdorf): Change that. IIRC there is nontrivial use of it currently.
 		reply := br.Responses[index].GetInner()
-		curResult, pErr := evaluateCommand(ctx, idKey, index, batch, rec, ms, ba.Header, maxKeys, args, reply)
+		curResult, pErr := evaluateCommand(
+			ctx, idKey, index, batch, rec, ms, ba.Header, maxKeys, targetBytes, args, reply,
+		)
 
 		if err := result.MergeAndDestroy(curResult); err != nil {
 			// TODO(tschottdorf): see whether we really need to pass nontrivial
@@ -304,6 +309,22 @@ func evaluateBatch(
 			maxKeys -= retResults
 		}
 
+		if ba.Header.TargetBytes > 0 {
+			if targetBytes <= 0 {
+				// The byte target was reached by an earlier request.
+				if h := reply.Header(); h.ResumeSpan != nil {
+					h.ResumeReason = roachpb.RESUME_BYTE_LIMIT
+					reply.SetHeader(h)
+				}
+			}
+			targetBytes -= reply.Header().NumBytes
+			if targetBytes <= 0 {
+				// Once the byte target has been reached, the remaining requests
+				// return their spans as resume spans, as when the key limit is hit.
+				maxKeys = 0
+			}
+		}
+
 		// If transactional, we use ba.Txn for each individual command and
 		// accumulate updates to it.
 		// TODO(spencer,tschottdorf): need copy-on-write behavior for the
@@ -343,7 +364,8 @@ func evaluateBatch(
 // evaluateCommand delegates to the eval method for the given
 // roachpb.Request. The returned Result may be partially valid
 // even if an error is returned. maxKeys is the number of scan results
-// remaining for this batch (MaxInt64 for no limit).
+// remaining for this batch (MaxInt64 for no limit), and targetBytes the
+// number of bytes they may add up to (0 for no limit).
 func evaluateCommand(
 	ctx context.Context,
 	raftCmdID storagebase.CmdIDKey,
@@ -353,6 +375,7 @@ func evaluateCommand(
 	ms *enginepb.MVCCStats,
 	h roachpb.Header,
 	maxKeys int64,
+	targetBytes int64,
 	args roachpb.Request,
 	reply roachpb.Response,
 ) (result.Result, *roachpb.Error) {
@@ -377,11 +400,12 @@ func evaluateCommand(
 
 	if cmd, ok := batcheval.LookupCommand(args.Method()); ok {
 		cArgs := batcheval.CommandArgs{
-			EvalCtx: rec,
-			Header:  h,
-			Args:    args,
-			MaxKeys: maxKeys,
-			Stats:   ms,
+			EvalCtx:     rec,
+			Header:      h,
+			Args:        args,
+			MaxKeys:     maxKeys,
+			TargetBytes: targetBytes,
+			Stats:       ms,
 		}
 		pd, err = cmd.Eval(ctx, batch, cArgs, reply)
 	} else {
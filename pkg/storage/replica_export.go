@@ -0,0 +1,257 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/storage/spanset"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/pkg/errors"
+)
+
+// exportSnapshotTargetSSTSize is the size of the data at which ExportSnapshot
+// finishes an SST and starts the next one.
+const exportSnapshotTargetSSTSize = 64 << 20 // 64 MB
+
+// ExportedSST is one of the SSTs produced by Replica.ExportSnapshot.
+type ExportedSST struct {
+	// Span covers the keys in the SST. The spans of the SSTs produced by one
+	// export don't overlap and together cover the exported span.
+	Span roachpb.Span
+	// Data is the contents of the SST.
+	Data []byte
+	// DataSize is the size of the keys and values in the SST.
+	DataSize int64
+}
+
+// ExportSnapshot exports all MVCC versions of the keys in the given span with
+// timestamps in (startTime, endTime] into SSTs. A zero startTime exports all
+// versions at or below endTime. Deletion tombstones are exported too, so that
+// incremental exports capture deletions.
+//
+// Unlike an ExportRequest, ExportSnapshot doesn't go through command
+// evaluation. It holds read latches on the span at endTime only for as long as
+// it takes to open an engine snapshot, and records the read in the timestamp
+// cache so that no write can later land at or below endTime. The SSTs are then
+// built from the engine snapshot with a time-bound iterator. The replica must
+// hold (or be able to acquire) the lease, and an intent within the time range
+// results in a WriteIntentError.
+func (r *Replica) ExportSnapshot(
+	ctx context.Context, span roachpb.Span, startTime, endTime hlc.Timestamp,
+) ([]ExportedSST, error) {
+	if endTime == (hlc.Timestamp{}) {
+		return nil, errors.New("export end time must be set")
+	}
+	if !startTime.Less(endTime) {
+		return nil, errors.Errorf("export start time %s must precede end time %s", startTime, endTime)
+	}
+	if now := r.store.Clock().Now(); now.Less(endTime) {
+		return nil, errors.Errorf("export end time %s is in the future (now %s)", endTime, now)
+	}
+	if keys.IsLocal(span.Key) {
+		return nil, errors.Errorf("cannot export range-local span %s", span)
+	}
+	rSpan := roachpb.RSpan{Key: roachpb.RKey(span.Key), EndKey: roachpb.RKey(span.EndKey)}
+
+	if err := r.store.limiters.ConcurrentExportRequests.Begin(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.limiters.ConcurrentExportRequests.Finish()
+
+	snap, err := r.openExportSnapshot(ctx, span, rSpan, startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+	defer snap.Close()
+	log.Eventf(ctx, "exporting %s in (%s, %s]", span, startTime, endTime)
+
+	return exportSnapshotSSTs(snap, span, startTime, endTime, func(size int) error {
+		// Account the data read against the store's background IO budget.
+		return r.store.limiters.ExportReadRate.WaitN(ctx, size)
+	})
+}
+
+// openExportSnapshot opens the engine snapshot for an export of the given
+// span at endTime under read latches.
+func (r *Replica) openExportSnapshot(
+	ctx context.Context, span roachpb.Span, rSpan roachpb.RSpan, startTime, endTime hlc.Timestamp,
+) (engine.Reader, error) {
+	var spans spanset.SpanSet
+	spans.Add(spanset.SpanReadOnly, span)
+	lg, err := r.latchMgr.Acquire(ctx, &spans, endTime, roachpb.NORMAL_PRIORITY)
+	if err != nil {
+		return nil, err
+	}
+	defer r.latchMgr.Release(lg)
+	if r.getMergeCompleteCh() != nil {
+		// See beginCmds for why we can't wait for the merge while holding
+		// latches. The caller is expected to retry.
+		return nil, &roachpb.MergeInProgressError{}
+	}
+	// The lease is checked only once the latches are held. Waiting for them can
+	// take arbitrarily long, during which the lease may have expired or been
+	// transferred away.
+	if _, pErr := r.redirectOnOrAcquireLease(ctx); pErr != nil {
+		return nil, pErr.GoError()
+	}
+
+	r.readOnlyCmdMu.RLock()
+	defer r.readOnlyCmdMu.RUnlock()
+	if _, err := r.IsDestroyed(); err != nil {
+		return nil, err
+	}
+	// The versions above the GC threshold must all still be there; for an
+	// incremental export this includes the deletion tombstones after startTime.
+	ts := endTime
+	if !startTime.IsEmpty() {
+		ts = startTime
+	}
+	if err := r.requestCanProceed(rSpan, ts); err != nil {
+		return nil, err
+	}
+
+	snap := r.store.Engine().NewSnapshot()
+	r.store.tsCache.Add(span.Key, span.EndKey, endTime, uuid.UUID{}, true /* readCache */)
+	return snap, nil
+}
+
+// exportSnapshotSSTs builds the SSTs for an export from the given reader,
+// which must be a consistent snapshot. waitN is called with the size of each
+// SST before it is finished.
+func exportSnapshotSSTs(
+	snap engine.Reader, span roachpb.Span, startTime, endTime hlc.Timestamp, waitN func(int) error,
+) ([]ExportedSST, error) {
+	iterOpts := engine.IterOptions{UpperBound: span.EndKey}
+	// A time-bound iterator only pays off for incremental exports. The call to
+	// Next converts the exclusive start time into the inclusive hint.
+	timeBound := !startTime.IsEmpty()
+	if timeBound {
+		iterOpts.MinTimestampHint = startTime.Next()
+		iterOpts.MaxTimestampHint = endTime
+	}
+	iter := snap.NewIterator(iterOpts)
+	defer iter.Close()
+	// A time-bound iterator may surface intents that aren't there (#28358),
+	// so metadata keys are double checked with a regular iterator.
+	var sanityIter engine.Iterator
+	if timeBound {
+		sanityIter = snap.NewIterator(engine.IterOptions{UpperBound: span.EndKey})
+		defer sanityIter.Close()
+	}
+
+	var ssts []ExportedSST
+	var sst *engine.RocksDBSstFileWriter
+	defer func() {
+		if sst != nil {
+			sst.Close()
+		}
+	}()
+	start := span.Key
+	var last roachpb.Key
+	finish := func(end roachpb.Key) error {
+		if err := waitN(int(sst.DataSize)); err != nil {
+			return err
+		}
+		data, err := sst.Finish()
+		if err != nil {
+			return err
+		}
+		ssts = append(ssts, ExportedSST{
+			Span:     roachpb.Span{Key: start, EndKey: end},
+			Data:     data,
+			DataSize: sst.DataSize,
+		})
+		sst.Close()
+		sst = nil
+		start = end
+		return nil
+	}
+
+	var meta enginepb.MVCCMetadata
+	for iter.Seek(engine.MakeMVCCMetadataKey(span.Key)); ; iter.Next() {
+		if ok, err := iter.Valid(); err != nil {
+			return nil, err
+		} else if !ok {
+			break
+		}
+		unsafeKey := iter.UnsafeKey()
+		if !unsafeKey.IsValue() {
+			value := iter.UnsafeValue()
+			if sanityIter != nil {
+				sanityIter.Seek(unsafeKey)
+				if ok, err := sanityIter.Valid(); err != nil {
+					return nil, err
+				} else if !ok || !sanityIter.UnsafeKey().Equal(unsafeKey) {
+					continue
+				}
+				value = sanityIter.UnsafeValue()
+			}
+			if err := protoutil.Unmarshal(value, &meta); err != nil {
+				return nil, errors.Wrapf(err, "unmarshaling metadata of %s", unsafeKey)
+			}
+			if meta.IsInline() {
+				// Inline values are only used for non-user data, which isn't
+				// meant to be exported.
+				return nil, errors.Errorf("cannot export inline value at %s", unsafeKey.Key)
+			}
+			if ts := hlc.Timestamp(meta.Timestamp); meta.Txn != nil &&
+				startTime.Less(ts) && !endTime.Less(ts) {
+				return nil, &roachpb.WriteIntentError{
+					Intents: []roachpb.Intent{{
+						Span:   roachpb.Span{Key: iter.Key().Key},
+						Status: roachpb.PENDING,
+						Txn:    *meta.Txn,
+					}},
+				}
+			}
+			continue
+		}
+		if endTime.Less(unsafeKey.Timestamp) || !startTime.Less(unsafeKey.Timestamp) {
+			continue
+		}
+
+		// Only split between keys, never between the versions of one key.
+		if sst != nil && sst.DataSize >= exportSnapshotTargetSSTSize && !unsafeKey.Key.Equal(last) {
+			if err := finish(append(roachpb.Key(nil), unsafeKey.Key...)); err != nil {
+				return nil, err
+			}
+		}
+		if sst == nil {
+			w, err := engine.MakeRocksDBSstFileWriter()
+			if err != nil {
+				return nil, err
+			}
+			sst = &w
+		}
+		if err := sst.Add(engine.MVCCKeyValue{Key: unsafeKey, Value: iter.UnsafeValue()}); err != nil {
+			return nil, errors.Wrapf(err, "adding key %s", unsafeKey)
+		}
+		last = append(last[:0], unsafeKey.Key...)
+	}
+	if sst != nil {
+		if err := finish(span.EndKey); err != nil {
+			return nil, err
+		}
+	}
+	return ssts, nil
+}
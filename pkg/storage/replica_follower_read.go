@@ -17,10 +17,15 @@ package storage
 import (
 	"context"
 
+	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/storage/closedts/ctpb"
 	ctstorage "github.com/cockroachdb/cockroach/pkg/storage/closedts/storage"
+	"github.com/cockroachdb/cockroach/pkg/storage/metering"
+	"github.com/cockroachdb/cockroach/pkg/storage/spanset"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
+	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 )
@@ -35,6 +40,106 @@ var FollowerReadsEnabled = settings.RegisterBoolSetting(
 	true,
 )
 
+// latchlessReadsEnabled controls whether replicas serve historical,
+// non-transactional reads below the closed timestamp without acquiring
+// latches or consulting the lease.
+var latchlessReadsEnabled = settings.RegisterBoolSetting(
+	"kv.closed_timestamp.latchless_reads_enabled",
+	"allow replicas to serve non-transactional reads below the closed timestamp without acquiring latches",
+	true,
+)
+
+// canServeLatchlessRead tests whether the batch can be evaluated without
+// acquiring latches or a lease. This is the case for consistent,
+// non-transactional batches of plain reads whose timestamp is at or below the
+// replica's closed timestamp: no write at or below that timestamp can be
+// proposed anymore, and all the writes which were proposed have been applied
+// to this replica, so the data the reads observe can't change under them and
+// there is no conflicting request which latches would have to wait for. For
+// the same reason, such reads don't need to be recorded in the timestamp
+// cache.
+func (r *Replica) canServeLatchlessRead(ctx context.Context, ba *roachpb.BatchRequest) bool {
+	if ba.Txn != nil || ba.ReadConsistency != roachpb.CONSISTENT || ba.Timestamp == (hlc.Timestamp{}) {
+		return false
+	}
+	for _, union := range ba.Requests {
+		switch union.GetInner().(type) {
+		case *roachpb.GetRequest, *roachpb.ScanRequest, *roachpb.ReverseScanRequest:
+		default:
+			return false
+		}
+	}
+	if !FollowerReadsEnabled.Get(&r.store.cfg.Settings.SV) ||
+		!latchlessReadsEnabled.Get(&r.store.cfg.Settings.SV) {
+		return false
+	}
+	// A replica which is being merged away must block all traffic until the
+	// merge completes; leave that to the regular path.
+	if r.getMergeCompleteCh() != nil {
+		return false
+	}
+	r.mu.RLock()
+	hasEpochLease := r.mu.state.Lease != nil && r.mu.state.Lease.Type() == roachpb.LeaseEpoch
+	r.mu.RUnlock()
+	return hasEpochLease && !r.maxClosed(ctx).Less(ba.Timestamp)
+}
+
+// executeLatchlessReadOnlyBatch evaluates a batch for which
+// canServeLatchlessRead returned true.
+func (r *Replica) executeLatchlessReadOnlyBatch(
+	ctx context.Context, ba roachpb.BatchRequest,
+) (*roachpb.BatchResponse, *roachpb.Error) {
+	log.Event(ctx, "serving latchless read below closed timestamp")
+
+	release, err := r.admitReadOnlyBatch(ctx, &ba)
+	if err != nil {
+		return nil, roachpb.NewError(err)
+	}
+	defer release()
+
+	spans, err := r.collectSpans(&ba)
+	if err != nil {
+		return nil, roachpb.NewError(err)
+	}
+
+	// Latches aren't needed, but readOnlyCmdMu still is, to prevent reads from
+	// the "wrong" key range after a split or merge.
+	r.readOnlyCmdMu.RLock()
+	defer r.readOnlyCmdMu.RUnlock()
+
+	if _, err := r.IsDestroyed(); err != nil {
+		return nil, roachpb.NewError(err)
+	}
+	rSpan, err := keys.Range(ba)
+	if err != nil {
+		return nil, roachpb.NewError(err)
+	}
+	if err := r.requestCanProceed(rSpan, ba.Timestamp); err != nil {
+		return nil, roachpb.NewError(err)
+	}
+
+	rec := NewReplicaEvalContext(r, spans)
+	readOnly := r.store.Engine().NewReadOnly()
+	if util.RaceEnabled {
+		readOnly = spanset.NewReadWriter(readOnly, spans)
+	}
+	defer readOnly.Close()
+	// Consistent plain reads produce no local side effects; any intents they
+	// encounter are returned as a WriteIntentError, which the Store handles
+	// just as it does for reads on the regular path.
+	br, _, pErr := evaluateBatch(ctx, storagebase.CmdIDKey(""), readOnly, rec, nil, ba, true /* readOnly */)
+	if pErr != nil {
+		log.VErrEvent(ctx, 3, pErr.String())
+		return nil, pErr
+	}
+	r.store.metrics.LatchlessReadsCount.Inc(1)
+	if r.store.cfg.RequestMeter != nil {
+		r.meterRequest(ctx, metering.Read, &ba, rSpan, int64(br.Size()))
+	}
+	log.Event(ctx, "read completed")
+	return br, nil
+}
+
 // canServeFollowerRead tests, when a range lease could not be
 // acquired, whether the read only batch can be served as a follower
 // read despite the error.
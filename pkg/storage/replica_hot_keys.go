@@ -0,0 +1,242 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+)
+
+// HotKeySamplingEnabled controls whether replicas sample the keys of the
+// requests they serve, so that the hottest keys of each range can be
+// reported. Sampling takes a mutex and copies the sampled keys on every
+// batch, so it is off by default and meant to be enabled while investigating
+// contention.
+var HotKeySamplingEnabled = settings.RegisterBoolSetting(
+	"kv.hot_key_sampling.enabled",
+	"if enabled, replicas sample the keys of the requests they serve to report the hottest keys of each range",
+	false,
+)
+
+const (
+	// hotKeySampleSize is the number of requests kept in the sample of each
+	// sampling period.
+	hotKeySampleSize = 64
+	// hotKeySamplePeriod is the duration of a sampling period. The sample of
+	// the previous period is kept alongside the sample of the current one, so
+	// reports cover between one and two periods of requests.
+	hotKeySamplePeriod = time.Minute
+)
+
+// hotKeySample is a request sampled by a hotKeySampler.
+type hotKeySample struct {
+	key    roachpb.Key
+	method roachpb.Method
+}
+
+// hotKeyWindow is a uniform sample of the requests received during a sampling
+// period, built using reservoir sampling.
+type hotKeyWindow struct {
+	start   time.Time
+	count   int
+	samples []hotKeySample
+}
+
+// hotKeySampler samples the keys of the requests served by a replica.
+type hotKeySampler struct {
+	intn func(int) int
+
+	mu struct {
+		syncutil.Mutex
+		prev, cur hotKeyWindow
+	}
+}
+
+// HotKeyMethod is the estimated rate of the requests of a given method
+// addressed to a hot key.
+type HotKeyMethod struct {
+	Method roachpb.Method
+	QPS    float64
+}
+
+// HotKey is a key along with the estimated rate of the requests addressed to
+// it, broken down by method.
+type HotKey struct {
+	Key     roachpb.Key
+	QPS     float64
+	Methods []HotKeyMethod
+}
+
+// RangeHotKeys are the hottest keys of a range.
+type RangeHotKeys struct {
+	RangeID roachpb.RangeID
+	// QPS is the estimated rate of the sampled requests over the whole range.
+	QPS  float64
+	Keys []HotKey
+}
+
+// rotateLocked starts a new sampling period if the current one is over.
+// Periods which ended more than a period ago are discarded.
+func (s *hotKeySampler) rotateLocked(now time.Time) {
+	age := now.Sub(s.mu.cur.start)
+	if age < hotKeySamplePeriod {
+		return
+	}
+	if age < 2*hotKeySamplePeriod {
+		s.mu.prev = s.mu.cur
+	} else {
+		s.mu.prev = hotKeyWindow{}
+	}
+	s.mu.cur = hotKeyWindow{start: now}
+}
+
+// record adds the requests of the batch to the sample of the current period.
+func (s *hotKeySampler) record(now time.Time, ba *roachpb.BatchRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rotateLocked(now)
+	w := &s.mu.cur
+	for _, union := range ba.Requests {
+		req := union.GetInner()
+		idx := w.count
+		w.count++
+		if idx >= hotKeySampleSize {
+			if idx = s.intn(w.count); idx >= hotKeySampleSize {
+				continue
+			}
+		}
+		// The key is copied because it outlives the request.
+		sample := hotKeySample{
+			key:    append(roachpb.Key(nil), req.Header().Key...),
+			method: req.Method(),
+		}
+		if idx < len(w.samples) {
+			w.samples[idx] = sample
+		} else {
+			w.samples = append(w.samples, sample)
+		}
+	}
+}
+
+// top returns the given number of keys with the highest estimated request
+// rates, ordered by decreasing rate, along with the estimated rate of all of
+// the sampled requests. Each sample stands for count/len(samples) of the
+// requests of its period.
+func (s *hotKeySampler) top(now time.Time, limit int) (float64, []HotKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rotateLocked(now)
+
+	var start time.Time
+	var total float64
+	byKey := map[string]*HotKey{}
+	for _, w := range []*hotKeyWindow{&s.mu.prev, &s.mu.cur} {
+		if len(w.samples) == 0 {
+			continue
+		}
+		if start.IsZero() {
+			start = w.start
+		}
+		weight := float64(w.count) / float64(len(w.samples))
+		total += float64(w.count)
+		for _, sample := range w.samples {
+			hk, ok := byKey[string(sample.key)]
+			if !ok {
+				hk = &HotKey{Key: sample.key}
+				byKey[string(sample.key)] = hk
+			}
+			hk.QPS += weight
+			addHotKeyMethod(hk, sample.method, weight)
+		}
+	}
+	if len(byKey) == 0 {
+		return 0, nil
+	}
+
+	// Rates are computed over at least a second so that a handful of requests
+	// at the start of a period don't show up as a burst.
+	secs := now.Sub(start).Seconds()
+	if secs < 1 {
+		secs = 1
+	}
+	keys := make([]HotKey, 0, len(byKey))
+	for _, hk := range byKey {
+		hk.QPS /= secs
+		for i := range hk.Methods {
+			hk.Methods[i].QPS /= secs
+		}
+		sort.Slice(hk.Methods, func(i, j int) bool {
+			return hk.Methods[i].QPS > hk.Methods[j].QPS
+		})
+		keys = append(keys, *hk)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].QPS != keys[j].QPS {
+			return keys[i].QPS > keys[j].QPS
+		}
+		return bytes.Compare(keys[i].Key, keys[j].Key) < 0
+	})
+	if len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return total / secs, keys
+}
+
+// addHotKeyMethod adds weight to the rate of the given method of the key.
+func addHotKeyMethod(hk *HotKey, method roachpb.Method, weight float64) {
+	for i := range hk.Methods {
+		if hk.Methods[i].Method == method {
+			hk.Methods[i].QPS += weight
+			return
+		}
+	}
+	hk.Methods = append(hk.Methods, HotKeyMethod{Method: method, QPS: weight})
+}
+
+// HotKeys returns the given number of keys of the replica with the highest
+// estimated request rates, ordered by decreasing rate, and the estimated rate
+// of all of the requests served by the replica. No keys are returned if no
+// request was sampled during the last two sampling periods.
+func (r *Replica) HotKeys(now time.Time, limit int) RangeHotKeys {
+	qps, keys := r.hotKeys.top(now, limit)
+	return RangeHotKeys{RangeID: r.RangeID, QPS: qps, Keys: keys}
+}
+
+// HotKeys returns the hottest keys of the ranges of the store with the highest
+// estimated rates of sampled requests, ordered by decreasing rate. At most
+// rangeLimit ranges are returned, each with at most keyLimit keys.
+func (s *Store) HotKeys(rangeLimit, keyLimit int) []RangeHotKeys {
+	now := timeutil.Now()
+	var ranges []RangeHotKeys
+	newStoreReplicaVisitor(s).Visit(func(repl *Replica) bool {
+		if hk := repl.HotKeys(now, keyLimit); len(hk.Keys) > 0 {
+			ranges = append(ranges, hk)
+		}
+		return true // more
+	})
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].QPS > ranges[j].QPS
+	})
+	if len(ranges) > rangeLimit {
+		ranges = ranges[:rangeLimit]
+	}
+	return ranges
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestHotKeySampler(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	s := hotKeySampler{intn: rand.New(rand.NewSource(1)).Intn}
+	start := time.Unix(1000, 0)
+
+	if qps, keys := s.top(start, 10); qps != 0 || len(keys) != 0 {
+		t.Fatalf("expected no hot keys, got %f %+v", qps, keys)
+	}
+
+	// Three quarters of the requests read key a, the rest write key b.
+	const numBatches = 1000
+	for i := 0; i < numBatches; i++ {
+		var ba roachpb.BatchRequest
+		if i%4 == 0 {
+			ba.Add(roachpb.NewPut(roachpb.Key("b"), roachpb.MakeValueFromString("v")))
+		} else {
+			ba.Add(roachpb.NewGet(roachpb.Key("a")))
+		}
+		s.record(start.Add(time.Duration(i)*time.Millisecond), &ba)
+	}
+
+	now := start.Add(10 * time.Second)
+	qps, keys := s.top(now, 10)
+	if expected := numBatches / 10.0; qps != expected {
+		t.Fatalf("expected %f qps, got %f", expected, qps)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("expected two hot keys, got %+v", keys)
+	}
+	if !keys[0].Key.Equal(roachpb.Key("a")) || !keys[1].Key.Equal(roachpb.Key("b")) {
+		t.Fatalf("expected keys a and b, got %+v", keys)
+	}
+	if m := keys[0].Methods; len(m) != 1 || m[0].Method != roachpb.Get || m[0].QPS != keys[0].QPS {
+		t.Fatalf("expected only gets on key a, got %+v", m)
+	}
+	if m := keys[1].Methods; len(m) != 1 || m[0].Method != roachpb.Put || m[0].QPS != keys[1].QPS {
+		t.Fatalf("expected only puts on key b, got %+v", m)
+	}
+	// Each sample stands for the same number of requests, so the rates of the
+	// keys add up to the rate of the range.
+	if sum := keys[0].QPS + keys[1].QPS; math.Abs(sum-qps) > 1e-6 {
+		t.Fatalf("expected key rates to add up to %f, got %f", qps, sum)
+	}
+
+	if _, keys := s.top(now, 1); len(keys) != 1 || !keys[0].Key.Equal(roachpb.Key("a")) {
+		t.Fatalf("expected only key a, got %+v", keys)
+	}
+
+	// The sample of the previous period is still reported after a rotation.
+	now = start.Add(hotKeySamplePeriod + time.Second)
+	if _, keys := s.top(now, 10); len(keys) != 2 {
+		t.Fatalf("expected two hot keys, got %+v", keys)
+	}
+
+	// But not once the previous period is over as well.
+	now = now.Add(hotKeySamplePeriod)
+	if qps, keys := s.top(now, 10); qps != 0 || len(keys) != 0 {
+		t.Fatalf("expected no hot keys, got %f %+v", qps, keys)
+	}
+}
@@ -52,9 +52,11 @@ func newReplica(rangeID roachpb.RangeID, store *Store) *Replica {
 	r.mu.stateLoader = stateloader.Make(rangeID)
 	r.mu.quiescent = true
 	r.mu.zone = store.cfg.DefaultZoneConfig
+	r.mu.zoneIsDefault = true
 	split.Init(&r.loadBasedSplitter, rand.Intn, func() float64 {
 		return float64(SplitByLoadQPSThreshold.Get(&store.cfg.Settings.SV))
 	})
+	r.hotKeys.intn = rand.Intn
 
 	if leaseHistoryMaxEntries > 0 {
 		r.leaseHistory = newLeaseHistory()
@@ -205,7 +207,7 @@ func (r *Replica) setReplicaIDRaftMuLockedMuLocked(replicaID roachpb.ReplicaID)
 	// and this is under raftMu.
 	ssBase := r.store.Engine().GetAuxiliaryDir()
 	rangeID := r.mu.state.Desc.RangeID
-	if err := moveSideloadedData(r.raftMu.sideloaded, ssBase, rangeID, replicaID); err != nil {
+	if err := moveSideloadedData(r.raftMu.sideloaded, ssBase, rangeID, replicaID, r.store.engine); err != nil {
 		return err
 	}
 
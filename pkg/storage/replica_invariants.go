@@ -0,0 +1,92 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/pkg/errors"
+)
+
+// invariantViolationAction is the action taken when the application of a Raft
+// command leaves a field of its LocalEvalResult unhandled, which a node may do
+// for commands proposed by a node running a newer version during a rolling
+// upgrade. The LocalEvalResult only affects the proposing replica, so it is
+// safe to carry on without applying it. An unhandled field of the
+// ReplicatedEvalResult, on the other hand, would make the replicas diverge and
+// is always fatal.
+type invariantViolationAction int64
+
+const (
+	// invariantViolationFatal crashes the node.
+	invariantViolationFatal invariantViolationAction = iota
+	// invariantViolationLog logs the violation and carries on.
+	invariantViolationLog
+	// invariantViolationQuarantine stops the replica from serving requests and
+	// from participating in Raft, leaving the rest of the node running.
+	invariantViolationQuarantine
+)
+
+var invariantViolationActionSetting = settings.RegisterEnumSetting(
+	"kv.replica.invariant_violation_action",
+	"action taken when applying a Raft command leaves fields of its local result unhandled: "+
+		"fatal crashes the node, log logs the violation, quarantine stops the affected replica "+
+		"from serving requests and participating in Raft",
+	"fatal",
+	map[int64]string{
+		int64(invariantViolationFatal):      "fatal",
+		int64(invariantViolationLog):        "log",
+		int64(invariantViolationQuarantine): "quarantine",
+	},
+)
+
+// reportInvariantViolation handles an unhandled field of the LocalEvalResult
+// of an applied command according to the kv.replica.invariant_violation_action
+// setting. The caller must not hold mu.
+func (r *Replica) reportInvariantViolation(
+	ctx context.Context, format string, args ...interface{},
+) {
+	r.store.metrics.ReplicaInvariantViolations.Inc(1)
+	switch invariantViolationAction(invariantViolationActionSetting.Get(&r.store.cfg.Settings.SV)) {
+	case invariantViolationLog:
+		log.Errorf(ctx, format, args...)
+	case invariantViolationQuarantine:
+		r.quarantine(ctx, errors.Errorf(format, args...))
+	default:
+		log.Fatalf(ctx, format, args...)
+	}
+}
+
+// quarantine stalls the replica the same way a corrupted replica is stalled:
+// its pending commands are canceled, requests are rejected with a
+// ReplicaCorruptionError and, once the current Raft ready has been handled, its
+// Raft group stops making progress. The replica is left in place for
+// investigation; restarting the node brings it back.
+func (r *Replica) quarantine(ctx context.Context, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.mu.destroyStatus.IsAlive() {
+		return
+	}
+	log.Errorf(ctx, "quarantining replica: %s", err)
+	cErr := roachpb.NewReplicaCorruptionError(err)
+	cErr.Processed = true
+	r.mu.destroyStatus.Set(cErr, destroyReasonQuarantined)
+	r.cancelPendingCommandsLocked()
+	r.store.metrics.QuarantinedCount.Inc(1)
+}
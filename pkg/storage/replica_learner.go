@@ -0,0 +1,121 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// learnerReplicasEnabled controls whether replicas are added to a range as
+// Raft learners, which receive the log but don't vote, and only promoted to
+// voters once they have caught up. Without learners, a replica is added as a
+// voter right after being sent a preemptive snapshot, and the range's quorum
+// grows before the new replica is able to take part in it. Preemptive
+// snapshots are only used until VersionLearnerReplicas is active, or if the
+// setting is turned off.
+var learnerReplicasEnabled = settings.RegisterBoolSetting(
+	"kv.learner_replicas.enabled",
+	"use learner replicas for replica addition",
+	true,
+)
+
+// useLearnerReplicas returns whether replicas are added as learners.
+func useLearnerReplicas(st *cluster.Settings) bool {
+	return learnerReplicasEnabled.Get(&st.SV) && st.Version.IsActive(cluster.VersionLearnerReplicas)
+}
+
+// addReplicaViaLearner adds the given replica to the range as a learner,
+// sends it a snapshot and then promotes it to a voter. Should any step after
+// the addition of the learner fail, the learner is removed again.
+func (r *Replica) addReplicaViaLearner(
+	ctx context.Context,
+	repDesc roachpb.ReplicaDescriptor,
+	desc *roachpb.RangeDescriptor,
+	priority SnapshotRequest_Priority,
+	reason storagepb.RangeLogEventReason,
+	details string,
+) (*roachpb.RangeDescriptor, error) {
+	learnerDesc := *desc
+	learnerDesc.SetReplicas(desc.Replicas().DeepCopy())
+	repDesc.ReplicaID = learnerDesc.NextReplicaID
+	repDesc.Type = roachpb.LEARNER.Enum()
+	learnerDesc.NextReplicaID++
+	learnerDesc.AddReplica(repDesc)
+
+	desc, err := r.execChangeReplicasTxn(
+		ctx, desc, &learnerDesc, roachpb.ADD_REPLICA, repDesc, reason, details,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The learner is now part of the Raft group, but it has no data. Unlike a
+	// preemptive snapshot, this snapshot is addressed to the learner's replica
+	// ID, and a learner receiving it doesn't affect the range's quorum.
+	if err := r.sendSnapshot(ctx, repDesc, snapTypeRaft, priority); err != nil {
+		r.rollbackLearnerReplica(ctx, desc, repDesc, reason, details)
+		return nil, err
+	}
+
+	if fn := r.store.TestingKnobs().ReplicaAddStopAfterLearnerSnapshot; fn != nil && fn() {
+		return desc, nil
+	}
+
+	voterDesc := repDesc
+	voterDesc.Type = nil
+	updatedDesc := *desc
+	updatedDesc.SetReplicas(desc.Replicas().DeepCopy())
+	updatedDesc.RemoveReplica(repDesc)
+	updatedDesc.AddReplica(voterDesc)
+
+	promotedDesc, err := r.execChangeReplicasTxn(
+		ctx, desc, &updatedDesc, roachpb.ADD_REPLICA, voterDesc, reason, details,
+	)
+	if err != nil {
+		r.rollbackLearnerReplica(ctx, desc, repDesc, reason, details)
+		return nil, err
+	}
+	return promotedDesc, nil
+}
+
+// rollbackLearnerReplica removes a learner whose promotion to a voter failed.
+// This is best effort. A learner which is left behind never catches up on its
+// own, as the Raft snapshot queue doesn't send snapshots to learners; the
+// replicate queue removes it (see AllocatorRemoveLearner).
+func (r *Replica) rollbackLearnerReplica(
+	ctx context.Context,
+	desc *roachpb.RangeDescriptor,
+	repDesc roachpb.ReplicaDescriptor,
+	reason storagepb.RangeLogEventReason,
+	details string,
+) {
+	updatedDesc := *desc
+	updatedDesc.SetReplicas(desc.Replicas().DeepCopy())
+	if !updatedDesc.RemoveReplica(repDesc) {
+		return
+	}
+	if _, err := r.execChangeReplicasTxn(
+		ctx, desc, &updatedDesc, roachpb.REMOVE_REPLICA, repDesc, reason, details,
+	); err != nil {
+		log.Infof(ctx, "failed to roll back learner %s, abandoning it for the replicate queue: %s",
+			repDesc, err)
+	}
+}
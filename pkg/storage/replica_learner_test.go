@@ -0,0 +1,82 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/testcluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// TestAddReplicaViaLearner verifies that, with learner replicas enabled, a
+// replica is first added as a learner and then promoted to a voter.
+func TestAddReplicaViaLearner(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var stopAfterLearnerSnapshot int32
+	knobs := base.TestingKnobs{
+		Store: &storage.StoreTestingKnobs{
+			ReplicaAddStopAfterLearnerSnapshot: func() bool {
+				return atomic.LoadInt32(&stopAfterLearnerSnapshot) == 1
+			},
+		},
+	}
+	ctx := context.Background()
+	tc := testcluster.StartTestCluster(t, 3, base.TestClusterArgs{
+		ReplicationMode: base.ReplicationManual,
+		ServerArgs:      base.TestServerArgs{Knobs: knobs},
+	})
+	defer tc.Stopper().Stop(ctx)
+	sqlutils.MakeSQLRunner(tc.ServerConn(0)).Exec(t, `SET CLUSTER SETTING kv.learner_replicas.enabled = true`)
+
+	key := roachpb.Key("a")
+	if _, _, err := tc.SplitRange(key); err != nil {
+		t.Fatal(err)
+	}
+
+	// Leave the first added replica a learner.
+	atomic.StoreInt32(&stopAfterLearnerSnapshot, 1)
+	desc, err := tc.AddReplicas(key, tc.Target(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if learners := desc.Replicas().Learners(); len(learners) != 1 || learners[0].StoreID != tc.Target(1).StoreID {
+		t.Fatalf("expected s%d to be a learner in %s", tc.Target(1).StoreID, desc)
+	}
+	if voters := desc.Replicas().Voters(); len(voters) != 1 {
+		t.Fatalf("expected a single voter in %s", desc)
+	}
+
+	// Let the second one be promoted.
+	atomic.StoreInt32(&stopAfterLearnerSnapshot, 0)
+	desc, err = tc.AddReplicas(key, tc.Target(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repDesc, ok := desc.GetReplicaDescriptor(tc.Target(2).StoreID)
+	if !ok || repDesc.GetType() != roachpb.VOTER {
+		t.Fatalf("expected s%d to be a voter in %s", tc.Target(2).StoreID, desc)
+	}
+	if voters := desc.Replicas().Voters(); len(voters) != 2 {
+		t.Fatalf("expected two voters in %s", desc)
+	}
+}
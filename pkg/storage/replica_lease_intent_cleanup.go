@@ -0,0 +1,162 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"golang.org/x/time/rate"
+)
+
+// leaseIntentCleanupEnabled controls whether a replica which acquires the
+// lease scans its range for intents and resolves those left behind by
+// abandoned transactions. Such intents otherwise linger until a reader or
+// writer runs into them, or until the GC queue considers them old enough.
+var leaseIntentCleanupEnabled = settings.RegisterBoolSetting(
+	"kv.lease.intent_cleanup.enabled",
+	"if set, replicas acquiring a range lease resolve the intents of abandoned "+
+		"transactions in the range in the background",
+	false,
+)
+
+// leaseIntentCleanupMaxRate is the rate limit (intents/sec) for the intents
+// considered for resolution after lease acquisitions on a store.
+var leaseIntentCleanupMaxRate = settings.RegisterNonNegativeIntSetting(
+	"kv.lease.intent_cleanup.max_intents_per_second",
+	"the rate limit (intents/sec) for the intents considered for resolution after "+
+		"lease acquisitions on a store; 0 disables the limit",
+	1000,
+)
+
+const (
+	// leaseIntentCleanupBatchSize is the number of intents scanned and cleaned
+	// up at a time.
+	leaseIntentCleanupBatchSize = 100
+	// leaseIntentCleanupRateBurst is the burst (in intents) of the store's
+	// lease intent cleanup rate limiter.
+	leaseIntentCleanupRateBurst = leaseIntentCleanupBatchSize
+)
+
+// leaseIntentCleanupRate returns the rate limit for the store's lease intent
+// cleanup rate limiter.
+func leaseIntentCleanupRate(sv *settings.Values) rate.Limit {
+	if n := leaseIntentCleanupMaxRate.Get(sv); n > 0 {
+		return rate.Limit(n)
+	}
+	return rate.Inf
+}
+
+// maybeCleanupIntentsAsync starts an async task which resolves the intents of
+// abandoned transactions in the range, if enabled. It is called when the
+// replica has acquired the lease.
+//
+// The intents are found through the lock table, so the cost of the scan only
+// depends on the number of intents in the range. They are pushed with
+// PUSH_TOUCH, which only succeeds if the transaction has already finished or
+// its coordinator has stopped heartbeating it, so live transactions are left
+// alone.
+func (r *Replica) maybeCleanupIntentsAsync(ctx context.Context) {
+	if !leaseIntentCleanupEnabled.Get(&r.store.cfg.Settings.SV) {
+		return
+	}
+	taskCtx := r.AnnotateCtx(context.Background())
+	if err := r.store.Stopper().RunAsyncTask(
+		taskCtx, "storage.Replica: cleaning up intents after lease acquisition",
+		func(ctx context.Context) {
+			if err := r.cleanupIntentsAfterLeaseAcquisition(ctx); err != nil {
+				log.Warningf(ctx, "unable to clean up intents after lease acquisition: %s", err)
+			}
+		}); err != nil {
+		log.VEventf(ctx, 1, "unable to clean up intents after lease acquisition: %s", err)
+	}
+}
+
+// cleanupIntentsAfterLeaseAcquisition scans the range's lock table and cleans
+// up the intents it finds, in batches. The transactions of each batch are
+// pushed one at a time, and those which can't be pushed, most commonly because
+// they're still alive, are skipped. It stops early if the replica loses the
+// lease.
+func (r *Replica) cleanupIntentsAfterLeaseAcquisition(ctx context.Context) error {
+	desc := r.Desc()
+	start := desc.StartKey.AsRawKey()
+	if desc.StartKey.Equal(roachpb.RKeyMin) {
+		start = keys.LocalMax
+	}
+	end := desc.EndKey.AsRawKey()
+
+	var resolved int
+	for {
+		// Each batch is scanned from the engine directly rather than from a
+		// snapshot held across the whole pass, which would pin the data the
+		// pass has yet to get to while the rate limiter holds it back.
+		intents, err := engine.MVCCScanLockTable(
+			r.store.Engine(), start, end, leaseIntentCleanupBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(intents) == 0 {
+			break
+		}
+		now := r.store.Clock().Now()
+		if !r.OwnsValidLease(now) {
+			log.VEventf(ctx, 1, "lost lease; stopping intent cleanup after %d intents", resolved)
+			return nil
+		}
+		if err := r.store.limiters.LeaseIntentCleanupRate.WaitN(ctx, len(intents)); err != nil {
+			return err
+		}
+		for _, txnIntents := range groupIntentsByTxn(intents) {
+			n, err := r.store.intentResolver.CleanupIntents(ctx, txnIntents, now, roachpb.PUSH_TOUCH)
+			if err != nil {
+				if ctxErr := ctx.Err(); ctxErr != nil {
+					return ctxErr
+				}
+				log.VEventf(ctx, 2, "skipping intents of txn %s: %s", txnIntents[0].Txn.ID.Short(), err)
+				continue
+			}
+			resolved += n
+			r.store.metrics.LeaseIntentCleanupResolved.Inc(int64(n))
+		}
+		if len(intents) < leaseIntentCleanupBatchSize {
+			break
+		}
+		start = intents[len(intents)-1].Key.Next()
+	}
+	log.VEventf(ctx, 1, "resolved %d intents after lease acquisition", resolved)
+	return nil
+}
+
+// groupIntentsByTxn splits the given intents up by their transaction, in the
+// order in which the transactions first appear.
+func groupIntentsByTxn(intents []roachpb.Intent) [][]roachpb.Intent {
+	var groups [][]roachpb.Intent
+	idx := make(map[uuid.UUID]int)
+	for _, intent := range intents {
+		i, ok := idx[intent.Txn.ID]
+		if !ok {
+			i = len(groups)
+			idx[intent.Txn.ID] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], intent)
+	}
+	return groups
+}
@@ -0,0 +1,113 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/txnwait"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/pkg/errors"
+)
+
+// TestReplicaLeaseIntentCleanup verifies that a replica acquiring the lease
+// resolves the intents of abandoned transactions in its range.
+func TestReplicaLeaseIntentCleanup(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc := testContext{}
+	tc.Start(t, stopper)
+	leaseIntentCleanupEnabled.Override(&tc.store.cfg.Settings.SV, true)
+
+	// Write an intent with a transaction which is never heartbeat or finished.
+	key := roachpb.Key("a")
+	txn := newTransaction("test", key, 1, tc.Clock())
+	pArgs := putArgs(key, []byte("value"))
+	if _, pErr := tc.SendWrappedWith(roachpb.Header{Txn: txn}, &pArgs); pErr != nil {
+		t.Fatal(pErr)
+	}
+
+	// Let the transaction expire along with the lease, and then have a request
+	// acquire a new one.
+	tc.manualClock.Increment(txnwait.TxnLivenessThreshold.Nanoseconds())
+	tc.manualClock.Increment(leaseExpiry(tc.repl))
+	gArgs := getArgs(roachpb.Key("b"))
+	if _, pErr := tc.SendWrapped(&gArgs); pErr != nil {
+		t.Fatal(pErr)
+	}
+
+	testutils.SucceedsSoon(t, func() error {
+		intents, err := engine.MVCCScanLockTable(tc.engine, key, key.Next(), 0)
+		if err != nil {
+			return err
+		}
+		if len(intents) != 0 {
+			return errors.Errorf("intents not resolved yet: %v", intents)
+		}
+		return nil
+	})
+	if n := tc.store.metrics.LeaseIntentCleanupResolved.Count(); n != 1 {
+		t.Fatalf("expected 1 intent to be resolved, found %d", n)
+	}
+}
+
+// TestReplicaLeaseIntentCleanupSkipsLiveTxns verifies that the intents of a
+// transaction which can't be pushed don't keep those of other transactions
+// from being cleaned up.
+func TestReplicaLeaseIntentCleanupSkipsLiveTxns(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc := testContext{}
+	tc.Start(t, stopper)
+
+	// Write an intent with a transaction which is abandoned, and once it has
+	// expired, one with a transaction which is still alive.
+	keyA, keyB := roachpb.Key("a"), roachpb.Key("b")
+	abandoned := newTransaction("abandoned", keyA, 1, tc.Clock())
+	pArgs := putArgs(keyA, []byte("value"))
+	if _, pErr := tc.SendWrappedWith(roachpb.Header{Txn: abandoned}, &pArgs); pErr != nil {
+		t.Fatal(pErr)
+	}
+	tc.manualClock.Increment(txnwait.TxnLivenessThreshold.Nanoseconds())
+	tc.manualClock.Increment(leaseExpiry(tc.repl))
+	live := newTransaction("live", keyB, 1, tc.Clock())
+	pArgs = putArgs(keyB, []byte("value"))
+	if _, pErr := tc.SendWrappedWith(roachpb.Header{Txn: live}, &pArgs); pErr != nil {
+		t.Fatal(pErr)
+	}
+
+	if err := tc.repl.cleanupIntentsAfterLeaseAcquisition(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	intents, err := engine.MVCCScanLockTable(tc.engine, keyA, keyB.Next(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(intents) != 1 || intents[0].Txn.ID != live.ID {
+		t.Fatalf("expected only the intent of the live txn to remain, found %v", intents)
+	}
+	if n := tc.store.metrics.LeaseIntentCleanupResolved.Count(); n != 1 {
+		t.Fatalf("expected 1 intent to be resolved, found %d", n)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/metering"
+)
+
+// meterRequest reports the work done to serve the given batch to the store's
+// RequestMeter, if one is configured. bytes is the size of the data read or
+// written (see metering.Event).
+func (r *Replica) meterRequest(
+	ctx context.Context, op metering.Op, ba *roachpb.BatchRequest, rSpan roachpb.RSpan, bytes int64,
+) {
+	meter := r.store.cfg.RequestMeter
+	if meter == nil {
+		return
+	}
+	meter.Record(ctx, metering.Event{
+		Op:           op,
+		StoreID:      r.store.StoreID(),
+		RangeID:      r.RangeID,
+		Span:         rSpan,
+		Requests:     len(ba.Requests),
+		RequestBytes: int64(ba.Size()),
+		Bytes:        bytes,
+	})
+}
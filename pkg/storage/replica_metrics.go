@@ -38,6 +38,11 @@ type ReplicaMetrics struct {
 	// Ticking indicates whether the store is ticking the replica. It should be
 	// the opposite of Quiescent.
 	Ticking bool
+	// UnquiesceReason is the reason the replica was last woken from
+	// quiescence, if it ever was.
+	UnquiesceReason string
+	// RaftTicks is the number of times the replica's Raft group was ticked.
+	RaftTicks int64
 
 	// Is this the replica which collects per-range metrics? This is done either
 	// on the leader or, if there is no leader, on the largest live replica ID.
@@ -49,6 +54,9 @@ type ReplicaMetrics struct {
 	LatchInfoLocal  storagepb.LatchManagerInfo
 	LatchInfoGlobal storagepb.LatchManagerInfo
 	RaftLogTooLarge bool
+	// PausedFollowerCount is the number of followers to which the leader
+	// doesn't replicate because their stores are overloaded.
+	PausedFollowerCount int64
 }
 
 // Metrics returns the current metrics for the replica.
@@ -62,6 +70,9 @@ func (r *Replica) Metrics(
 	desc := r.mu.state.Desc
 	zone := r.mu.zone
 	raftLogSize := r.mu.raftLogSize
+	unquiesceReason := r.mu.unquiesceReason
+	raftTicks := r.mu.ticks
+	pausedFollowerCount := len(r.mu.pausedFollowers)
 	r.mu.RUnlock()
 
 	r.store.unquiescedReplicas.Lock()
@@ -70,7 +81,7 @@ func (r *Replica) Metrics(
 
 	latchInfoGlobal, latchInfoLocal := r.latchMgr.Info()
 
-	return calcReplicaMetrics(
+	m := calcReplicaMetrics(
 		ctx,
 		now,
 		&r.store.cfg.RaftConfig,
@@ -87,6 +98,10 @@ func (r *Replica) Metrics(
 		latchInfoGlobal,
 		raftLogSize,
 	)
+	m.UnquiesceReason = string(unquiesceReason)
+	m.RaftTicks = int64(raftTicks)
+	m.PausedFollowerCount = int64(pausedFollowerCount)
+	return m
 }
 
 func calcReplicaMetrics(
@@ -0,0 +1,287 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/config"
+	"github.com/cockroachdb/cockroach/pkg/gossip"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+const (
+	// replicaMigrationQueueTimerDuration is the duration between the
+	// migrations of queued replicas.
+	replicaMigrationQueueTimerDuration = 0
+)
+
+// A replicaMigration is a change to the replicated state of ranges which
+// becomes possible once a cluster version is active, and which needs to be
+// applied to every range before the code supporting the previous state can
+// be removed. Replica migrations are run by the replica migration queue on
+// the leaseholders of the ranges that need them, and their progress is
+// tracked and persisted by each store.
+type replicaMigration struct {
+	// name identifies the migration. It keys its persisted progress, so it
+	// must never change.
+	name string
+	// version is the cluster version which enables the migration.
+	version cluster.VersionKey
+	// pending returns whether the replica still needs the migration.
+	pending func(r *Replica) bool
+	// migrate migrates the range of the replica, which holds the lease. It
+	// must be idempotent.
+	migrate func(ctx context.Context, r *Replica) error
+}
+
+// replicaMigrations is the list of the known replica migrations.
+var replicaMigrations = []replicaMigration{
+	{
+		name:    "applied state key",
+		version: cluster.VersionRangeAppliedStateKey,
+		pending: func(r *Replica) bool {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return !r.mu.state.UsingAppliedStateKey
+		},
+		migrate: migrateToAppliedStateKey,
+	},
+}
+
+// migrateToAppliedStateKey migrates the range to the RangeAppliedState key.
+// Any command proposed by a range that hasn't been migrated yet carries the
+// migration flag (see evaluateProposal), so this sends a no-op command through
+// Raft: truncating the Raft log at index zero has no effect. Such a command is
+// only proposed if the range still needs the migration; see
+// isAppliedStateKeyMigration.
+func migrateToAppliedStateKey(ctx context.Context, r *Replica) error {
+	desc := r.Desc()
+	var ba roachpb.BatchRequest
+	ba.RangeID = desc.RangeID
+	ba.Timestamp = r.Clock().Now()
+	ba.Add(&roachpb.TruncateLogRequest{
+		RequestHeader: roachpb.RequestHeader{Key: desc.StartKey.AsRawKey()},
+		Index:         0,
+		RangeID:       desc.RangeID,
+	})
+	_, pErr := r.Send(ctx, ba)
+	return pErr.GoError()
+}
+
+// isAppliedStateKeyMigration returns whether the batch is the no-op command
+// sent by migrateToAppliedStateKey. Unlike other commands which don't write
+// anything, it's proposed to Raft on a range that hasn't been migrated yet.
+func isAppliedStateKeyMigration(ba *roachpb.BatchRequest) bool {
+	if !ba.IsSingleRequest() {
+		return false
+	}
+	tl, ok := ba.Requests[0].GetInner().(*roachpb.TruncateLogRequest)
+	return ok && tl.Index == 0
+}
+
+// replicaMigrationTracker tracks the progress of the replica migrations on a
+// store.
+type replicaMigrationTracker struct {
+	syncutil.Mutex
+	// migrations is the list of the replica migrations run by the store. It's
+	// only overridden by tests.
+	migrations []replicaMigration
+	// progress is the progress of the migrations, by name. A migration has no
+	// progress until the store first finds it active.
+	progress map[string]*storagepb.ReplicaMigrationProgress
+}
+
+// loadReplicaMigrationProgress loads the persisted progress of the replica
+// migrations of the store.
+func (s *Store) loadReplicaMigrationProgress(ctx context.Context) error {
+	s.replicaMigrations.Lock()
+	defer s.replicaMigrations.Unlock()
+	s.replicaMigrations.progress = make(map[string]*storagepb.ReplicaMigrationProgress)
+	for _, m := range s.replicaMigrations.migrations {
+		var progress storagepb.ReplicaMigrationProgress
+		ok, err := engine.MVCCGetProto(ctx, s.engine, keys.StoreMigrationProgressKey(m.name),
+			hlc.Timestamp{}, &progress, engine.MVCCGetOptions{})
+		if err != nil {
+			return err
+		}
+		if ok {
+			s.replicaMigrations.progress[m.name] = &progress
+		}
+	}
+	return nil
+}
+
+// activeReplicaMigrations returns the replica migrations enabled by the
+// cluster version which haven't completed on the store.
+func (s *Store) activeReplicaMigrations() []replicaMigration {
+	s.replicaMigrations.Lock()
+	defer s.replicaMigrations.Unlock()
+	var active []replicaMigration
+	for _, m := range s.replicaMigrations.migrations {
+		if !s.cfg.Settings.Version.IsActive(m.version) {
+			continue
+		}
+		if p, ok := s.replicaMigrations.progress[m.name]; ok && p.Completed {
+			continue
+		}
+		active = append(active, m)
+	}
+	return active
+}
+
+// recordReplicaMigrated records that the store migrated a range.
+func (s *Store) recordReplicaMigrated(name string) {
+	s.replicaMigrations.Lock()
+	defer s.replicaMigrations.Unlock()
+	if p, ok := s.replicaMigrations.progress[name]; ok {
+		p.ReplicasMigrated++
+	}
+}
+
+// updateReplicaMigrationProgress counts the replicas which still need each of
+// the active replica migrations, and persists the progress of the migrations.
+// A migration is completed once none of the replicas of the store need it.
+func (s *Store) updateReplicaMigrationProgress(ctx context.Context) error {
+	active := s.activeReplicaMigrations()
+	if len(active) == 0 {
+		s.metrics.ReplicaMigrationsPending.Update(0)
+		return nil
+	}
+	pending := make([]int64, len(active))
+	newStoreReplicaVisitor(s).Visit(func(repl *Replica) bool {
+		for i, m := range active {
+			if m.pending(repl) {
+				pending[i]++
+			}
+		}
+		return true // more
+	})
+
+	now := s.Clock().Now()
+	var totalPending int64
+	s.replicaMigrations.Lock()
+	defer s.replicaMigrations.Unlock()
+	for i, m := range active {
+		p, ok := s.replicaMigrations.progress[m.name]
+		if !ok {
+			p = &storagepb.ReplicaMigrationProgress{Name: m.name, Started: now}
+			s.replicaMigrations.progress[m.name] = p
+			log.Infof(ctx, "started replica migration %q: %d replicas pending", m.name, pending[i])
+		}
+		p.Modified = now
+		p.ReplicasPending = pending[i]
+		if p.ReplicasPending > p.ReplicasTotal {
+			p.ReplicasTotal = p.ReplicasPending
+		}
+		if p.ReplicasPending == 0 {
+			p.Completed = true
+			log.Infof(ctx, "completed replica migration %q: migrated %d ranges in %s",
+				m.name, p.ReplicasMigrated, time.Duration(now.WallTime-p.Started.WallTime))
+		}
+		totalPending += p.ReplicasPending
+		if err := engine.MVCCPutProto(
+			ctx, s.engine, nil, keys.StoreMigrationProgressKey(m.name), hlc.Timestamp{}, nil, p,
+		); err != nil {
+			return err
+		}
+	}
+	s.metrics.ReplicaMigrationsPending.Update(totalPending)
+	return nil
+}
+
+// ReplicaMigrationProgress returns the progress of the replica migrations
+// tracked by the store.
+func (s *Store) ReplicaMigrationProgress() []storagepb.ReplicaMigrationProgress {
+	s.replicaMigrations.Lock()
+	defer s.replicaMigrations.Unlock()
+	var progress []storagepb.ReplicaMigrationProgress
+	for _, m := range s.replicaMigrations.migrations {
+		if p, ok := s.replicaMigrations.progress[m.name]; ok {
+			progress = append(progress, *p)
+		}
+	}
+	return progress
+}
+
+// replicaMigrationQueue runs the active replica migrations on the replicas
+// that need them. The queue only processes the replicas for which the store
+// holds the lease; the other replicas are migrated through Raft when the
+// leaseholders of their ranges migrate them.
+type replicaMigrationQueue struct {
+	*baseQueue
+}
+
+// newReplicaMigrationQueue returns a new instance of replicaMigrationQueue.
+func newReplicaMigrationQueue(store *Store, g *gossip.Gossip) *replicaMigrationQueue {
+	q := &replicaMigrationQueue{}
+	q.baseQueue = newBaseQueue(
+		"replicaMigration", q, store, g,
+		queueConfig{
+			maxSize:              defaultQueueMaxSize,
+			needsLease:           true,
+			needsSystemConfig:    false,
+			acceptsUnsplitRanges: true,
+			successes:            store.metrics.ReplicaMigrationQueueSuccesses,
+			failures:             store.metrics.ReplicaMigrationQueueFailures,
+			pending:              store.metrics.ReplicaMigrationQueuePending,
+			processingNanos:      store.metrics.ReplicaMigrationQueueProcessingNanos,
+		},
+	)
+	return q
+}
+
+func (q *replicaMigrationQueue) shouldQueue(
+	ctx context.Context, now hlc.Timestamp, repl *Replica, _ *config.SystemConfig,
+) (shouldQ bool, priority float64) {
+	for _, m := range repl.store.activeReplicaMigrations() {
+		if m.pending(repl) {
+			return true, 1.0
+		}
+	}
+	return false, 0
+}
+
+func (q *replicaMigrationQueue) process(
+	ctx context.Context, repl *Replica, _ *config.SystemConfig,
+) error {
+	for _, m := range repl.store.activeReplicaMigrations() {
+		if !m.pending(repl) {
+			continue
+		}
+		log.VEventf(ctx, 2, "running replica migration %q", m.name)
+		if err := m.migrate(ctx, repl); err != nil {
+			return err
+		}
+		repl.store.recordReplicaMigrated(m.name)
+	}
+	return nil
+}
+
+func (*replicaMigrationQueue) timer(_ time.Duration) time.Duration {
+	return replicaMigrationQueueTimerDuration
+}
+
+func (*replicaMigrationQueue) purgatoryChan() <-chan time.Time {
+	return nil
+}
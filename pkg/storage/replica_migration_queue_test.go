@@ -0,0 +1,116 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// TestReplicaMigrationProgress verifies that the replica migration queue
+// migrates the replicas of a store, and that the progress of the migration is
+// tracked and persisted across restarts.
+func TestReplicaMigrationProgress(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	store, _ := createTestStore(t, testStoreOpts{createSystemRanges: true}, stopper)
+
+	var mu struct {
+		syncutil.Mutex
+		migrated map[roachpb.RangeID]bool
+	}
+	mu.migrated = make(map[roachpb.RangeID]bool)
+	const name = "test migration"
+	store.replicaMigrations.migrations = []replicaMigration{{
+		name:    name,
+		version: cluster.VersionRangeAppliedStateKey,
+		pending: func(r *Replica) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return !mu.migrated[r.RangeID]
+		},
+		migrate: func(_ context.Context, r *Replica) error {
+			mu.Lock()
+			defer mu.Unlock()
+			mu.migrated[r.RangeID] = true
+			return nil
+		},
+	}}
+	if err := store.loadReplicaMigrationProgress(ctx); err != nil {
+		t.Fatal(err)
+	}
+	replicas := int64(store.ReplicaCount())
+
+	type expProgress struct {
+		total, pending, migrated int64
+		completed                bool
+	}
+	checkProgress := func(exp expProgress) {
+		t.Helper()
+		progress := store.ReplicaMigrationProgress()
+		if len(progress) != 1 {
+			t.Fatalf("expected the progress of 1 migration, got %+v", progress)
+		}
+		p := progress[0]
+		if p.Name != name || p.ReplicasTotal != exp.total || p.ReplicasPending != exp.pending ||
+			p.ReplicasMigrated != exp.migrated || p.Completed != exp.completed {
+			t.Fatalf("expected progress %+v, got %+v", exp, p)
+		}
+	}
+
+	if err := store.updateReplicaMigrationProgress(ctx); err != nil {
+		t.Fatal(err)
+	}
+	checkProgress(expProgress{total: replicas, pending: replicas})
+
+	// Migrate all the replicas.
+	q := store.replicaMigrationQueue
+	newStoreReplicaVisitor(store).Visit(func(repl *Replica) bool {
+		if shouldQ, _ := q.shouldQueue(ctx, store.Clock().Now(), repl, nil); !shouldQ {
+			t.Errorf("expected r%d to be queued", repl.RangeID)
+		}
+		if err := q.process(ctx, repl, nil); err != nil {
+			t.Error(err)
+		}
+		return true
+	})
+	if err := store.updateReplicaMigrationProgress(ctx); err != nil {
+		t.Fatal(err)
+	}
+	completed := expProgress{
+		total: replicas, migrated: replicas, completed: true,
+	}
+	checkProgress(completed)
+	if active := store.activeReplicaMigrations(); len(active) != 0 {
+		t.Fatalf("expected no active migrations, got %d", len(active))
+	}
+
+	// The progress is reloaded from the engine when the store restarts.
+	if err := store.loadReplicaMigrationProgress(ctx); err != nil {
+		t.Fatal(err)
+	}
+	checkProgress(completed)
+	if active := store.activeReplicaMigrations(); len(active) != 0 {
+		t.Fatalf("expected no active migrations after reload, got %d", len(active))
+	}
+}
@@ -30,20 +30,22 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/batcheval/result"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
+	"github.com/cockroachdb/cockroach/pkg/storage/metering"
+	"github.com/cockroachdb/cockroach/pkg/storage/rditer"
 	"github.com/cockroachdb/cockroach/pkg/storage/spanset"
 	"github.com/cockroachdb/cockroach/pkg/storage/stateloader"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
 	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/limit"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
-	"github.com/cockroachdb/cockroach/pkg/util/sysutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	"github.com/kr/pretty"
 	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
-	"golang.org/x/time/rate"
 )
 
 // ProposalData is data about a command which allows it to be
@@ -71,6 +73,19 @@ type ProposalData struct {
 	// last (re-)proposed.
 	proposedAtTicks int
 
+	// reproposals is the number of times this command was reproposed because
+	// it hadn't applied within the reproposal period and may have been
+	// dropped. It determines how long to wait before the next such
+	// reproposal; see reproposalBackoffTicks.
+	reproposals int
+
+	// deadline is the deadline of the caller's context at the time the
+	// proposal was created, or the zero value if the context had none. Once
+	// it has passed, the proposal is no longer reproposed with a new lease
+	// index after it failed to apply; instead, it is finished with an error
+	// and its latches are released.
+	deadline time.Time
+
 	// command is serialized and proposed to raft. In the event of
 	// reproposals its MaxLeaseIndex field is mutated.
 	command *storagepb.RaftCommand
@@ -124,6 +139,45 @@ func (proposal *ProposalData) finishApplication(pr proposalResult) {
 	proposal.signalProposalResult(pr)
 }
 
+// maxReproposalBackoffShift caps the exponential backoff of the reproposals
+// of a proposal which doesn't apply: a proposal is reproposed at least every
+// 1<<maxReproposalBackoffShift reproposal periods.
+const maxReproposalBackoffShift = 4
+
+// reproposalBackoffTicks returns the number of ticks the proposal must have
+// been pending since it was last (re-)proposed before it is reproposed again,
+// given the base reproposal period. The period doubles with every reproposal,
+// up to a cap, so that a range whose proposals repeatedly fail to apply
+// doesn't spin the Raft scheduler.
+func (proposal *ProposalData) reproposalBackoffTicks(refreshAtDelta int) int {
+	shift := proposal.reproposals
+	if shift > maxReproposalBackoffShift {
+		shift = maxReproposalBackoffShift
+	}
+	return refreshAtDelta << uint(shift)
+}
+
+// deadlineExceeded returns whether the proposal has a deadline which has
+// passed at the given time.
+func (proposal *ProposalData) deadlineExceeded(now time.Time) bool {
+	return !proposal.deadline.IsZero() && !now.Before(proposal.deadline)
+}
+
+// newProposalDeadlineExceededError returns the error with which a proposal
+// whose deadline has passed is finished instead of being reproposed. The
+// error is an AmbiguousResultError, like the one the client receives when it
+// gives up on the proposal itself; the wrapped error indicates that the
+// deadline was the cause.
+func newProposalDeadlineExceededError(
+	proposal *ProposalData, now time.Time, reason string,
+) *roachpb.Error {
+	err := roachpb.NewAmbiguousResultError(fmt.Sprintf(
+		"abandoning proposal %x with deadline %s exceeded by %s (%s)",
+		proposal.idKey, proposal.deadline, now.Sub(proposal.deadline), reason))
+	err.WrappedErr = roachpb.NewError(context.DeadlineExceeded)
+	return roachpb.NewError(err)
+}
+
 // returnProposalResult signals proposal.doneCh with the proposal result if it
 // has not already been signaled. The method can be called even before the
 // proposal has finished replication and command application, and does not
@@ -183,7 +237,7 @@ func (r *Replica) computeChecksumPostApply(ctx context.Context, cc storagepb.Com
 	snap := r.store.engine.NewSnapshot()
 	if cc.Checkpoint {
 		checkpointBase := filepath.Join(r.store.engine.GetAuxiliaryDir(), "checkpoints")
-		_ = os.MkdirAll(checkpointBase, 0700)
+		_ = r.store.engine.MkdirAll(checkpointBase)
 		sl := stateloader.Make(r.RangeID)
 		rai, _, err := sl.LoadAppliedIndex(ctx, snap)
 		if err != nil {
@@ -191,7 +245,13 @@ func (r *Replica) computeChecksumPostApply(ctx context.Context, cc storagepb.Com
 		}
 		// NB: the names here will match on all nodes, which is nice for debugging.
 		checkpointDir := filepath.Join(checkpointBase, fmt.Sprintf("r%d_at_%d", r.RangeID, rai))
-		if err := r.store.engine.CreateCheckpoint(checkpointDir); err != nil {
+		// Only retain the SSTs overlapping the range's data, so that the
+		// checkpoint doesn't hold on to the disk space of the whole store.
+		var spans []roachpb.Span
+		for _, keyRange := range rditer.MakeReplicatedKeyRanges(&desc) {
+			spans = append(spans, roachpb.Span{Key: keyRange.Start.Key, EndKey: keyRange.End.Key})
+		}
+		if err := r.store.engine.CreateCheckpoint(checkpointDir, spans); err != nil {
 			log.Warningf(ctx, "unable to create checkpoint %s: %s", checkpointDir, err)
 		} else {
 			log.Infof(ctx, "created checkpoint %s", checkpointDir)
@@ -205,7 +265,7 @@ func (r *Replica) computeChecksumPostApply(ctx context.Context, cc storagepb.Com
 		if cc.SaveSnapshot {
 			snapshot = &roachpb.RaftSnapshotData{}
 		}
-		result, err := r.sha512(ctx, desc, snap, snapshot, cc.Mode)
+		result, err := r.sha512(ctx, desc, snap, snapshot, cc.Mode, int(cc.Shards))
 		if err != nil {
 			log.Errorf(ctx, "%v", err)
 			result = nil
@@ -226,7 +286,12 @@ func (r *Replica) computeChecksumPostApply(ctx context.Context, cc storagepb.Com
 // default, the method will also panic if passed a lease that indicates a
 // forward sequence number jump (i.e. a skipped lease). This behavior can
 // be disabled by passing permitJump as true.
-func (r *Replica) leasePostApply(ctx context.Context, newLease roachpb.Lease, permitJump bool) {
+func (r *Replica) leasePostApply(
+	ctx context.Context,
+	newLease roachpb.Lease,
+	summary *storagepb.LeaseTransferSummary,
+	permitJump bool,
+) {
 	r.mu.Lock()
 	replicaID := r.mu.replicaID
 	// Pull out the last lease known to this Replica. It's possible that this is
@@ -276,12 +341,25 @@ func (r *Replica) leasePostApply(ctx context.Context, newLease roachpb.Lease, pe
 		// requests, this is kosher). This means that we don't use the old
 		// lease's expiration but instead use the new lease's start to initialize
 		// the timestamp cache low water.
+		log.VEventf(ctx, 1, "raising timestamp cache low water mark to %s for new lease", newLease.Start)
 		setTimestampCacheLowWaterMark(r.store.tsCache, r.Desc(), newLease.Start)
+		// A lease transfer hands over the highest timestamp at which the
+		// previous leaseholder served reads, which the timestamp cache also
+		// has to cover.
+		if summary != nil && newLease.Start.Less(summary.ReadTimestamp) {
+			log.VEventf(ctx, 1, "raising timestamp cache low water mark to %s for reads of previous lease",
+				summary.ReadTimestamp)
+			setTimestampCacheLowWaterMark(r.store.tsCache, r.Desc(), summary.ReadTimestamp)
+		}
 
 		// Reset the request counts used to make lease placement decisions whenever
-		// starting a new lease.
+		// starting a new lease, unless they were handed over by a lease transfer.
 		if r.leaseholderStats != nil {
-			r.leaseholderStats.resetRequestCounts()
+			if summary != nil {
+				r.leaseholderStats.installRequestCounts(summary)
+			} else {
+				r.leaseholderStats.resetRequestCounts()
+			}
 		}
 	}
 
@@ -310,6 +388,17 @@ func (r *Replica) leasePostApply(ctx context.Context, newLease roachpb.Lease, pe
 		}
 	}
 
+	// A lease taken over from a store whose store liveness support was
+	// withdrawn follows the previous lease in the sequence like any other, so
+	// the commands the previous leaseholder proposed under its lease fail to
+	// apply from here on although its node is still live.
+	if leaseChangingHands && iAmTheLeaseHolder {
+		if withdrawn, _ := r.leaseWithdrawnByStoreLiveness(prevLease, r.Desc()); withdrawn {
+			log.VEventf(ctx, 1, "took over lease %s whose support was withdrawn", prevLease)
+			r.store.metrics.StoreLivenessLeaseTakeovers.Inc(1)
+		}
+	}
+
 	// Ordering is critical here. We only install the new lease after we've
 	// checked for an in-progress merge and updated the timestamp cache. If the
 	// ordering were reversed, it would be possible for requests to see the new
@@ -327,6 +416,13 @@ func (r *Replica) leasePostApply(ctx context.Context, newLease roachpb.Lease, pe
 		r.gossipFirstRange(ctx)
 	}
 
+	// Clean up the intents abandoned by dead coordinators in the background
+	// whenever we acquire the lease, so that they don't have to be discovered
+	// and pushed by the range's readers and writers.
+	if leaseChangingHands && iAmTheLeaseHolder && r.IsLeaseValid(newLease, r.store.Clock().Now()) {
+		r.maybeCleanupIntentsAsync(ctx)
+	}
+
 	// Whenever we first acquire an expiration-based lease, notify the lease
 	// renewer worker that we want it to keep proactively renewing the lease
 	// before it expires.
@@ -359,7 +455,7 @@ func (r *Replica) leasePostApply(ctx context.Context, newLease roachpb.Lease, pe
 		} else if prevOwner {
 			r.store.maybeGossipOnCapacityChange(ctx, leaseRemoveEvent)
 		}
-		if r.leaseholderStats != nil {
+		if r.leaseholderStats != nil && !(currentOwner && summary != nil) {
 			r.leaseholderStats.resetRequestCounts()
 		}
 	}
@@ -400,7 +496,7 @@ func addSSTablePreApply(
 	sideloaded SideloadStorage,
 	term, index uint64,
 	sst storagepb.ReplicatedEvalResult_AddSSTable,
-	limiter *rate.Limiter,
+	limiter *limit.RateLimiter,
 ) bool {
 	checksum := util.CRC32(sst.Data)
 
@@ -427,107 +523,102 @@ func addSSTablePreApply(
 	// to avoid needing the global seq_no edits and the copies they required.
 	canSkipSeqNo := st.Version.IsActive(cluster.VersionUnreplicatedRaftTruncatedState)
 
-	copied := false
-	if inmem, ok := eng.(engine.InMem); ok {
-		path = fmt.Sprintf("%x", checksum)
-		if err := inmem.WriteFile(path, sst.Data); err != nil {
-			panic(err)
-		}
-	} else {
-		ingestPath := path + ".ingested"
-
-		canLinkToRaftFile := false
-		// The SST may already be on disk, thanks to the sideloading mechanism.  If
-		// so we can try to add that file directly, via a new hardlink if the file-
-		// system support it, rather than writing a new copy of it. However, this is
-		// only safe if we can do so without modifying the file since it is still
-		// part of an immutable raft log message, but in some cases, described in
-		// DBIngestExternalFile, RocksDB would modify the file. Fortunately we can
-		// tell Rocks that it is not allowed to modify the file, in which case it
-		// will return and error if it would have tried to do so, at which point we
-		// can fall back to writing a new copy for Rocks to ingest.
-		if _, links, err := sysutil.StatAndLinkCount(path); err == nil {
-			// HACK: RocksDB does not like ingesting the same file (by inode) twice.
-			// See facebook/rocksdb#5133. We can tell that we have tried to ingest
-			// this file already if it has more than one link – one from the file raft
-			// wrote and one from rocks. In that case, we should not try to give
-			// rocks a link to the same file again.
-			if links == 1 {
-				canLinkToRaftFile = true
-			} else {
-				log.Warningf(ctx, "SSTable at index %d term %d may have already been ingested (link count %d) -- falling back to ingesting a copy",
-					index, term, links)
-			}
+	ingestPath := path + ".ingested"
+
+	canLinkToRaftFile := false
+	// The SST may already be on disk, thanks to the sideloading mechanism.  If
+	// so we can try to add that file directly, via a new hardlink if the file-
+	// system support it, rather than writing a new copy of it. However, this is
+	// only safe if we can do so without modifying the file since it is still
+	// part of an immutable raft log message, but in some cases, described in
+	// DBIngestExternalFile, RocksDB would modify the file. Fortunately we can
+	// tell Rocks that it is not allowed to modify the file, in which case it
+	// will return and error if it would have tried to do so, at which point we
+	// can fall back to writing a new copy for Rocks to ingest.
+	if links, err := eng.NumFileLinks(path); err == nil {
+		// HACK: RocksDB does not like ingesting the same file (by inode) twice.
+		// See facebook/rocksdb#5133. We can tell that we have tried to ingest
+		// this file already if it has more than one link – one from the file raft
+		// wrote and one from rocks. In that case, we should not try to give
+		// rocks a link to the same file again.
+		if links == 1 {
+			canLinkToRaftFile = true
+		} else {
+			log.Warningf(ctx, "SSTable at index %d term %d may have already been ingested (link count %d) -- falling back to ingesting a copy",
+				index, term, links)
 		}
+	}
 
-		if canLinkToRaftFile {
-			// If the fs supports it, make a hard-link for rocks to ingest. We cannot
-			// pass it the path in the sideload store as it deletes the passed path on
-			// success.
-			if linkErr := eng.LinkFile(path, ingestPath); linkErr == nil {
-				ingestErr := eng.IngestExternalFiles(ctx, []string{ingestPath}, canSkipSeqNo, noModify)
-				if ingestErr == nil {
-					// Adding without modification succeeded, no copy necessary.
-					log.Eventf(ctx, "ingested SSTable at index %d, term %d: %s", index, term, ingestPath)
-					return false
-				}
-				if rmErr := eng.DeleteFile(ingestPath); rmErr != nil {
-					log.Fatalf(ctx, "failed to move ingest sst: %v", rmErr)
-				}
-				const seqNoMsg = "Global seqno is required, but disabled"
-				const seqNoOnReIngest = "external file have non zero sequence number"
-				// Repeated ingestion is still possible even with the link count checked
-				// above, since rocks might have already compacted away the file.
-				// However it does not flush compacted files from its cache, so it can
-				// still react poorly to attempting to ingest again. If we get an error
-				// that indicates we can't ingest, we'll make a copy and try again. That
-				// attempt must succeed or we'll fatal, so any persistent error is still
-				// going to be surfaced.
-				ingestErrMsg := ingestErr.Error()
-				isSeqNoErr := strings.Contains(ingestErrMsg, seqNoMsg) || strings.Contains(ingestErrMsg, seqNoOnReIngest)
-				if _, ok := ingestErr.(*engine.RocksDBError); !ok || !isSeqNoErr {
-					log.Fatalf(ctx, "while ingesting %s: %s", ingestPath, ingestErr)
-				}
+	if canLinkToRaftFile {
+		// If the fs supports it, make a hard-link for rocks to ingest. We cannot
+		// pass it the path in the sideload store as it deletes the passed path on
+		// success.
+		if linkErr := eng.LinkFile(path, ingestPath); linkErr == nil {
+			ingestErr := eng.IngestExternalFiles(ctx, []string{ingestPath}, canSkipSeqNo, noModify)
+			if ingestErr == nil {
+				// Adding without modification succeeded, no copy necessary.
+				log.Eventf(ctx, "ingested SSTable at index %d, term %d: %s", index, term, ingestPath)
+				return false
+			}
+			if rmErr := eng.DeleteFile(ingestPath); rmErr != nil {
+				log.Fatalf(ctx, "failed to move ingest sst: %v", rmErr)
+			}
+			const seqNoMsg = "Global seqno is required, but disabled"
+			const seqNoOnReIngest = "external file have non zero sequence number"
+			// Repeated ingestion is still possible even with the link count checked
+			// above, since rocks might have already compacted away the file.
+			// However it does not flush compacted files from its cache, so it can
+			// still react poorly to attempting to ingest again. If we get an error
+			// that indicates we can't ingest, we'll make a copy and try again. That
+			// attempt must succeed or we'll fatal, so any persistent error is still
+			// going to be surfaced.
+			ingestErrMsg := ingestErr.Error()
+			isSeqNoErr := strings.Contains(ingestErrMsg, seqNoMsg) || strings.Contains(ingestErrMsg, seqNoOnReIngest)
+			if _, ok := ingestErr.(*engine.RocksDBError); !ok || !isSeqNoErr {
+				log.Fatalf(ctx, "while ingesting %s: %s", ingestPath, ingestErr)
 			}
 		}
+	}
 
-		path = ingestPath
+	path = ingestPath
 
-		log.Eventf(ctx, "copying SSTable for ingestion at index %d, term %d: %s", index, term, path)
+	log.Eventf(ctx, "copying SSTable for ingestion at index %d, term %d: %s", index, term, path)
 
-		// TODO(tschottdorf): remove this once sideloaded storage guarantees its
-		// existence.
-		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
-			panic(err)
-		}
-		if _, err := os.Stat(path); err == nil {
-			// The file we want to ingest exists. This can happen since the
-			// ingestion may apply twice (we ingest before we mark the Raft
-			// command as committed). Just unlink the file (RocksDB created a
-			// hard link); after that we're free to write it again.
-			if err := os.Remove(path); err != nil {
-				log.Fatalf(ctx, "while removing existing file during ingestion of %s: %s", path, err)
-			}
-		}
+	// TODO(tschottdorf): remove this once sideloaded storage guarantees its
+	// existence.
+	if err := eng.MkdirAll(filepath.Dir(path)); err != nil {
+		panic(err)
+	}
+	// The file we want to ingest may exist. This can happen since the
+	// ingestion may apply twice (we ingest before we mark the Raft command as
+	// committed). Just unlink the file (RocksDB created a hard link); after
+	// that we're free to write it again.
+	if err := eng.DeleteFile(path); err != nil && !os.IsNotExist(err) {
+		log.Fatalf(ctx, "while removing existing file during ingestion of %s: %s", path, err)
+	}
 
-		if err := writeFileSyncing(ctx, path, sst.Data, eng, 0600, st, limiter); err != nil {
-			log.Fatalf(ctx, "while ingesting %s: %s", path, err)
-		}
-		copied = true
+	if err := writeFileSyncing(ctx, path, sst.Data, eng, 0600, st, limiter); err != nil {
+		log.Fatalf(ctx, "while ingesting %s: %s", path, err)
 	}
 
 	if err := eng.IngestExternalFiles(ctx, []string{path}, canSkipSeqNo, modify); err != nil {
 		log.Fatalf(ctx, "while ingesting %s: %s", path, err)
 	}
 	log.Eventf(ctx, "ingested SSTable at index %d, term %d: %s", index, term, path)
-	return copied
+	// We don't count writes to the in-memory env as copies.
+	_, inMem := eng.(engine.InMem)
+	return !inMem
 }
 
 func (r *Replica) handleReplicatedEvalResult(
 	ctx context.Context,
+	ba *roachpb.BatchRequest,
 	rResult storagepb.ReplicatedEvalResult,
 	raftAppliedIndex, leaseAppliedIndex uint64,
 ) (shouldAssert bool) {
+	t := makeApplyTimer(slowApplyThreshold.Get(&r.store.cfg.Settings.SV))
+	defer r.maybeLogSlowApply(ctx, &t, ba, raftAppliedIndex, leaseAppliedIndex)
+
 	// Fields for which no action is taken in this method are zeroed so that
 	// they don't trigger an assertion at the end of the method (which checks
 	// that all fields were handled).
@@ -541,12 +632,22 @@ func (r *Replica) handleReplicatedEvalResult(
 		r.readOnlyCmdMu.Lock()
 		defer r.readOnlyCmdMu.Unlock()
 		rResult.BlockReads = false
+		t.step("block_reads")
 	}
 
+	// Any applied command may have changed the data cached results were read
+	// from.
+	r.readCache.clear()
+
 	// Update MVCC stats and Raft portion of ReplicaState.
 	deltaStats := rResult.Delta.ToStats()
 	r.mu.Lock()
 	r.mu.state.Stats.Add(deltaStats)
+	if rResult.ClearEstimates {
+		// The stats were recomputed while no other command was in flight, so
+		// they no longer contain estimates.
+		r.mu.state.Stats.ContainsEstimates = false
+	}
 	if raftAppliedIndex != 0 {
 		r.mu.state.RaftAppliedIndex = raftAppliedIndex
 	}
@@ -555,10 +656,19 @@ func (r *Replica) handleReplicatedEvalResult(
 	}
 	needsSplitBySize := r.needsSplitBySizeRLocked()
 	needsMergeBySize := r.needsMergeBySizeRLocked()
+	zoneIsDefault := r.mu.zoneIsDefault
+	desc := r.mu.state.Desc
 	r.mu.Unlock()
 
+	// Decide whether to split by the range_max_bytes of the zone of the range,
+	// and not the one of the default zone config of the store.
+	if zoneIsDefault && r.maybeResolveZoneConfig(desc) {
+		needsSplitBySize = r.needsSplitBySize()
+	}
+
 	r.store.metrics.addMVCCStats(deltaStats)
 	rResult.Delta = enginepb.MVCCStatsDelta{}
+	rResult.ClearEstimates = false
 
 	// NB: the bootstrap store has a nil split queue.
 	// TODO(tbg): the above is probably a lie now.
@@ -576,6 +686,7 @@ func (r *Replica) handleReplicatedEvalResult(
 		// bothersome) less aggressive.
 		r.store.mergeQueue.MaybeAddAsync(ctx, r, r.store.Clock().Now())
 	}
+	t.step("stats")
 
 	// The above are always present. The following are not always present but
 	// should not trigger a ReplicaState assertion because they are either too
@@ -608,6 +719,7 @@ func (r *Replica) handleReplicatedEvalResult(
 					rResult.RaftLogDelta -= size
 				}
 			}
+			t.step("truncate")
 		}
 
 		// ReplicaState.Stats was previously non-nullable which caused nodes to
@@ -669,9 +781,13 @@ func (r *Replica) handleReplicatedEvalResult(
 			r.store.raftLogQueue.MaybeAddAsync(ctx, r, r.store.Clock().Now())
 		}
 	}
+	t.step("raft_log")
 
-	for _, sc := range rResult.SuggestedCompactions {
-		r.store.compactor.Suggest(ctx, sc)
+	if len(rResult.SuggestedCompactions) > 0 {
+		for _, sc := range rResult.SuggestedCompactions {
+			r.store.compactor.Suggest(ctx, sc)
+		}
+		t.step("compactions")
 	}
 	rResult.SuggestedCompactions = nil
 
@@ -691,6 +807,7 @@ func (r *Replica) handleReplicatedEvalResult(
 			r,
 		)
 		rResult.Split = nil
+		t.step("split")
 	}
 
 	if rResult.Merge != nil {
@@ -701,6 +818,7 @@ func (r *Replica) handleReplicatedEvalResult(
 			log.Fatalf(ctx, "failed to update store after merging range: %s", err)
 		}
 		rResult.Merge = nil
+		t.step("merge")
 	}
 
 	// Update the remaining ReplicaState.
@@ -709,20 +827,34 @@ func (r *Replica) handleReplicatedEvalResult(
 		if newDesc := rResult.State.Desc; newDesc != nil {
 			r.setDesc(ctx, newDesc)
 			rResult.State.Desc = nil
+			t.step("desc")
 		}
 
 		if newLease := rResult.State.Lease; newLease != nil {
-			r.leasePostApply(ctx, *newLease, false /* permitJump */)
+			r.leasePostApply(ctx, *newLease, rResult.LeaseTransferSummary, false /* permitJump */)
 			rResult.State.Lease = nil
+			rResult.LeaseTransferSummary = nil
+			t.step("lease")
 		}
 
 		if newThresh := rResult.State.GCThreshold; newThresh != nil {
 			if (*newThresh != hlc.Timestamp{}) {
+				// Protected timestamps are enforced when the GC request is
+				// evaluated; application must be deterministic, so all we can
+				// do here is report a threshold which has passed a protection
+				// this store knows about.
+				if protected := r.GetProtectedTimestamp(); (protected != hlc.Timestamp{}) &&
+					!newThresh.Less(protected) {
+					log.Errorf(ctx, "applied GC threshold %s is not below protected timestamp %s",
+						newThresh, protected)
+					r.store.metrics.GCProtectedTSViolations.Inc(1)
+				}
 				r.mu.Lock()
 				r.mu.state.GCThreshold = newThresh
 				r.mu.Unlock()
 			}
 			rResult.State.GCThreshold = nil
+			t.step("gc_threshold")
 		}
 
 		if newThresh := rResult.State.TxnSpanGCThreshold; newThresh != nil {
@@ -749,20 +881,30 @@ func (r *Replica) handleReplicatedEvalResult(
 	if change := rResult.ChangeReplicas; change != nil {
 		if change.ChangeType == roachpb.REMOVE_REPLICA &&
 			r.store.StoreID() == change.Replica.StoreID {
-			// This wants to run as late as possible, maximizing the chances
-			// that the other nodes have finished this command as well (since
-			// processing the removal from the queue looks up the Range at the
-			// lease holder, being too early here turns this into a no-op).
-			// Lock ordering dictates that we don't hold any mutexes when adding,
-			// so we fire it off in a task.
-			r.store.replicaGCQueue.AddAsync(ctx, r, replicaGCPriorityRemoved)
+			// We applied our own removal, which is conclusive evidence that the
+			// replica can go. Unless it holds the lease, remove it as soon as
+			// the current Raft ready iteration is done instead of waiting for
+			// the replica GC queue, so that it stops serving stale RangeInfos.
+			if nextReplicaID, ok := r.canRemoveEagerly(); ok {
+				r.raftMu.removedNextReplicaID = nextReplicaID
+			} else {
+				// This wants to run as late as possible, maximizing the chances
+				// that the other nodes have finished this command as well (since
+				// processing the removal from the queue looks up the Range at the
+				// lease holder, being too early here turns this into a no-op).
+				// Lock ordering dictates that we don't hold any mutexes when adding,
+				// so we fire it off in a task.
+				r.store.replicaGCQueue.AddAsync(ctx, r, replicaGCPriorityRemoved)
+			}
 		}
 		rResult.ChangeReplicas = nil
+		t.step("change_replicas")
 	}
 
 	if rResult.ComputeChecksum != nil {
 		r.computeChecksumPostApply(ctx, *rResult.ComputeChecksum)
 		rResult.ComputeChecksum = nil
+		t.step("compute_checksum")
 	}
 
 	if !rResult.Equal(storagepb.ReplicatedEvalResult{}) {
@@ -846,17 +988,19 @@ func (r *Replica) handleLocalEvalResult(ctx context.Context, lResult result.Loca
 	}
 
 	if (lResult != result.LocalResult{}) {
-		log.Fatalf(ctx, "unhandled field in LocalEvalResult: %s", pretty.Diff(lResult, result.LocalResult{}))
+		r.reportInvariantViolation(ctx, "unhandled field in LocalEvalResult: %s",
+			pretty.Diff(lResult, result.LocalResult{}))
 	}
 }
 
 func (r *Replica) handleEvalResultRaftMuLocked(
 	ctx context.Context,
+	ba *roachpb.BatchRequest,
 	lResult *result.LocalResult,
 	rResult storagepb.ReplicatedEvalResult,
 	raftAppliedIndex, leaseAppliedIndex uint64,
 ) {
-	shouldAssert := r.handleReplicatedEvalResult(ctx, rResult, raftAppliedIndex, leaseAppliedIndex)
+	shouldAssert := r.handleReplicatedEvalResult(ctx, ba, rResult, raftAppliedIndex, leaseAppliedIndex)
 	if lResult != nil {
 		r.handleLocalEvalResult(ctx, *lResult)
 	}
@@ -936,6 +1080,20 @@ func (r *Replica) evaluateProposal(
 	// replication is not necessary.
 	res.Local.Reply = br
 
+	// If the RangeAppliedState key is not being used and the cluster version is
+	// high enough to guarantee that all current and future binaries will
+	// understand the key, we send the migration flag through Raft. Because
+	// there is a delay between command proposal and application, we may end up
+	// setting this migration flag multiple times. This is ok, because the
+	// migration is idempotent.
+	// TODO(nvanbenschoten): This will be baked in to 2.1, so it can be removed
+	// in the 2.2 release.
+	r.mu.RLock()
+	usingAppliedStateKey := r.mu.state.UsingAppliedStateKey
+	r.mu.RUnlock()
+	migrateAppliedStateKey := !usingAppliedStateKey &&
+		r.ClusterSettings().Version.IsActive(cluster.VersionRangeAppliedStateKey)
+
 	// needConsensus determines if the result needs to be replicated and
 	// proposed through Raft. This is necessary if at least one of the
 	// following conditions is true:
@@ -945,10 +1103,14 @@ func (r *Replica) evaluateProposal(
 	// 3. the request has replicated side-effects.
 	// 4. the cluster is in "clockless" mode, in which case consensus is
 	//    used to enforce a linearization of all reads and writes.
+	// 5. the range needs to be migrated to the RangeAppliedState key and the
+	//    request is the no-op sent by the replica migration queue to carry
+	//    the migration flag. This is how idle ranges are migrated.
 	needConsensus := !batch.Empty() ||
 		ms != (enginepb.MVCCStats{}) ||
 		!res.Replicated.Equal(storagepb.ReplicatedEvalResult{}) ||
-		r.store.Clock().MaxOffset() == timeutil.ClocklessMaxOffset
+		r.store.Clock().MaxOffset() == timeutil.ClocklessMaxOffset ||
+		(migrateAppliedStateKey && isAppliedStateKeyMigration(&ba))
 
 	if needConsensus {
 		// Set the proposal's WriteBatch, which is the serialized representation of
@@ -956,6 +1118,12 @@ func (r *Replica) evaluateProposal(
 		res.WriteBatch = &storagepb.WriteBatch{
 			Data: batch.Repr(),
 		}
+		r.recordWriteBatchSize(ctx, &ba, int64(len(res.WriteBatch.Data)))
+		if r.store.cfg.RequestMeter != nil {
+			if rSpan, err := keys.Range(ba); err == nil {
+				r.meterRequest(ctx, metering.Write, &ba, rSpan, int64(len(res.WriteBatch.Data)))
+			}
+		}
 
 		// Set the proposal's replicated result, which contains metadata and
 		// side-effects that are to be replicated to all replicas.
@@ -966,19 +1134,7 @@ func (r *Replica) evaluateProposal(
 		} else {
 			res.Replicated.DeprecatedDelta = &ms
 		}
-		// If the RangeAppliedState key is not being used and the cluster version is
-		// high enough to guarantee that all current and future binaries will
-		// understand the key, we send the migration flag through Raft. Because
-		// there is a delay between command proposal and application, we may end up
-		// setting this migration flag multiple times. This is ok, because the
-		// migration is idempotent.
-		// TODO(nvanbenschoten): This will be baked in to 2.1, so it can be removed
-		// in the 2.2 release.
-		r.mu.RLock()
-		usingAppliedStateKey := r.mu.state.UsingAppliedStateKey
-		r.mu.RUnlock()
-		if !usingAppliedStateKey &&
-			r.ClusterSettings().Version.IsActive(cluster.VersionRangeAppliedStateKey) {
+		if migrateAppliedStateKey {
 			if res.Replicated.State == nil {
 				res.Replicated.State = &storagepb.ReplicaState{}
 			}
@@ -989,6 +1145,40 @@ func (r *Replica) evaluateProposal(
 	return &res, needConsensus, nil
 }
 
+// maxRecordedWriteBatchSize is the largest write batch size recorded by the
+// RaftCommandWriteBatchSize histogram.
+const maxRecordedWriteBatchSize = 256 << 20 // 256MB
+
+// largeWriteBatchLogLimiter rate limits the logging of large write batches.
+var largeWriteBatchLogLimiter = log.Every(10 * time.Second)
+
+// recordWriteBatchSize records the size of the write batch of a proposed
+// command. The commands with a write batch larger than
+// kv.raft.command.large_write_batch_threshold are reported in the trace of
+// the request and logged, along with the fingerprint of the SQL statement
+// which sent them, if known.
+func (r *Replica) recordWriteBatchSize(
+	ctx context.Context, ba *roachpb.BatchRequest, size int64,
+) {
+	r.store.metrics.RaftCommandWriteBatchSize.RecordValue(size)
+	threshold := storagebase.LargeWriteBatchThreshold.Get(&r.store.cfg.Settings.SV)
+	if threshold == 0 || size < threshold {
+		return
+	}
+	r.store.metrics.RaftCommandsLargeWriteBatch.Inc(1)
+	stmt := ba.StatementFingerprint
+	if stmt == "" {
+		stmt = "<unknown>"
+	}
+	if largeWriteBatchLogLimiter.ShouldLog() {
+		log.Warningf(ctx, "proposing a command with a %s write batch (%s from n%d) for statement: %s",
+			humanizeutil.IBytes(size), ba.Summary(), ba.GatewayNodeID, stmt)
+	} else {
+		log.Eventf(ctx, "proposing a command with a %s write batch for statement: %s",
+			humanizeutil.IBytes(size), stmt)
+	}
+}
+
 // requestToProposal converts a BatchRequest into a ProposalData, by
 // evaluating it. The returned ProposalData is partially valid even
 // on a non-nil *roachpb.Error and should be proposed through Raft
@@ -1011,6 +1201,9 @@ func (r *Replica) requestToProposal(
 		Local:   &res.Local,
 		Request: &ba,
 	}
+	if deadline, ok := ctx.Deadline(); ok {
+		proposal.deadline = deadline
+	}
 
 	if needConsensus {
 		proposal.command = &storagepb.RaftCommand{
@@ -96,7 +96,7 @@ func (r *Replica) updateProposalQuotaRaftMuLocked(
 	// replica can get destroyed is an option, alternatively we can clear
 	// our leader status and close the proposalQuota whenever the replica is
 	// destroyed.
-	if r.mu.destroyStatus.Removed() {
+	if r.mu.destroyStatus.Removed() || r.mu.destroyStatus.Quarantined() {
 		if r.mu.proposalQuota != nil {
 			r.mu.proposalQuota.close()
 		}
@@ -129,7 +129,7 @@ func (r *Replica) updateProposalQuotaRaftMuLocked(
 			// hands.
 			r.mu.proposalQuota = newQuotaPool(r.store.cfg.RaftProposalQuota)
 			r.mu.lastUpdateTimes = make(map[roachpb.ReplicaID]time.Time)
-			r.mu.lastUpdateTimes.updateOnBecomeLeader(r.mu.state.Desc.Replicas().Unwrap(), timeutil.Now())
+			r.mu.lastUpdateTimes.updateOnBecomeLeader(r.mu.state.Desc.Replicas().Voters(), timeutil.Now())
 			r.mu.commandSizes = make(map[storagebase.CmdIDKey]int)
 		} else if r.mu.proposalQuota != nil {
 			// We're becoming a follower.
@@ -163,6 +163,12 @@ func (r *Replica) updateProposalQuotaRaftMuLocked(
 			return
 		}
 
+		// Learners don't count towards quorum, and a learner catching up from its
+		// initial snapshot must not hold up proposals.
+		if rep.GetType() == roachpb.LEARNER {
+			return
+		}
+
 		// Only consider followers that are active.
 		if !r.mu.lastUpdateTimes.isFollowerActive(ctx, rep.ReplicaID, now) {
 			return
@@ -173,6 +179,12 @@ func (r *Replica) updateProposalQuotaRaftMuLocked(
 			return
 		}
 
+		// Don't wait for followers to which replication is paused because
+		// their stores are overloaded.
+		if _, paused := r.mu.pausedFollowers[rep.ReplicaID]; paused {
+			return
+		}
+
 		// Note that the Match field has different semantics depending on
 		// the State.
 		//
@@ -42,6 +42,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/kr/pretty"
+	opentracing "github.com/opentracing/opentracing-go"
 	"github.com/pkg/errors"
 	"go.etcd.io/etcd/raft"
 	"go.etcd.io/etcd/raft/raftpb"
@@ -202,6 +203,8 @@ func (r *Replica) evalAndPropose(
 		// Continue with proposal...
 	}
 
+	r.maybeTraceRaftApplication(proposal.ctx, idKey, proposal.command)
+
 	// TODO(irfansharif): This int cast indicates that if someone configures a
 	// very large max proposal size, there is weird overflow behavior and it
 	// will not work the way it should.
@@ -223,8 +226,15 @@ func (r *Replica) evalAndPropose(
 	// closed timestamp tracker is acquired. This is better anyway; right now many
 	// commands can evaluate but then be blocked on quota, which has worse memory
 	// behavior.
-	if err := r.maybeAcquireProposalQuota(ctx, int64(proposalSize)); err != nil {
-		return nil, nil, 0, roachpb.NewError(err)
+	//
+	// High priority proposals bypass the quota pool, so that system-critical
+	// requests aren't held up by a backlog of bulk writes waiting for slow
+	// followers to catch up.
+	acquireQuota := ba.PriorityClass != roachpb.HIGH_PRIORITY
+	if acquireQuota {
+		if err := r.maybeAcquireProposalQuota(ctx, int64(proposalSize)); err != nil {
+			return nil, nil, 0, roachpb.NewError(err)
+		}
 	}
 
 	if filter := r.store.TestingKnobs().TestingProposalFilter; filter != nil {
@@ -262,7 +272,7 @@ func (r *Replica) evalAndPropose(
 	}
 
 	// Add size of proposal to commandSizes map.
-	if r.mu.commandSizes != nil {
+	if r.mu.commandSizes != nil && acquireQuota {
 		r.mu.commandSizes[proposal.idKey] = proposalSize
 	}
 
@@ -419,13 +429,20 @@ func defaultSubmitProposalLocked(r *Replica, p *ProposalData) error {
 			return err
 		}
 
+		// Adding a learner, as opposed to adding a voter or promoting a
+		// learner to a voter, needs a ConfChange of its own type.
+		confChangeType := changeTypeInternalToRaft[crt.ChangeType]
+		if crt.ChangeType == roachpb.ADD_REPLICA && crt.Replica.GetType() == roachpb.LEARNER {
+			confChangeType = raftpb.ConfChangeAddLearnerNode
+		}
+
 		return r.withRaftGroupLocked(true, func(raftGroup *raft.RawNode) (bool, error) {
 			// We're proposing a command here so there is no need to wake the
 			// leader if we were quiesced.
-			r.unquiesceLocked()
+			r.unquiesceLocked(unquiesceReasonProposal)
 			return false, /* unquiesceAndWakeLeader */
 				raftGroup.ProposeConfChange(raftpb.ConfChange{
-					Type:    changeTypeInternalToRaft[crt.ChangeType],
+					Type:    confChangeType,
 					NodeID:  uint64(crt.Replica.ReplicaID),
 					Context: encodedCtx,
 				})
@@ -450,7 +467,7 @@ func defaultSubmitProposalLocked(r *Replica, p *ProposalData) error {
 	return r.withRaftGroupLocked(true, func(raftGroup *raft.RawNode) (bool, error) {
 		// We're proposing a command so there is no need to wake the leader if
 		// we're quiesced.
-		r.unquiesceLocked()
+		r.unquiesceLocked(unquiesceReasonProposal)
 		return false /* unquiesceAndWakeLeader */, raftGroup.Propose(data)
 	})
 }
@@ -467,7 +484,7 @@ func (r *Replica) stepRaftGroup(req *RaftMessageRequest) error {
 		// other replica is not quiesced, so we don't need to wake the leader.
 		// Note that we avoid campaigning when receiving raft messages, because
 		// we expect the originator to campaign instead.
-		r.unquiesceWithOptionsLocked(false /* campaignOnWake */)
+		r.unquiesceWithOptionsLocked(false /* campaignOnWake */, unquiesceReasonRaftMessage)
 		r.mu.lastUpdateTimes.update(req.FromReplica.ReplicaID, timeutil.Now())
 		err := raftGroup.Step(req.Message)
 		if err == raft.ErrProposalDropped {
@@ -718,17 +735,53 @@ func (r *Replica) handleRaftReadyRaftMuLocked(
 	// uncommitted log entries, and even if they did include log entries that
 	// were not persisted to disk, it wouldn't be a problem because raft does not
 	// infer the that entries are persisted on the node that sends a snapshot.
+	//
+	// If the batch needs to be synced, committed entries may be applied while
+	// the sync is in progress (see commitWhileApplyingRaftMuLocked) to overlap
+	// its latency with the application of the commands. The entries which
+	// aren't applied here are applied once the sync has completed.
+	sync := rd.MustSync && !disableSyncRaftLog.Get(&r.store.cfg.Settings.SV)
+	committedEntries := rd.CommittedEntries
+	var pipelined []pipelinedEntry
+	if sync && pipelinedApplyEnabled.Get(&r.store.cfg.Settings.SV) {
+		// Only the entries which were synced by previous Readies, and aren't
+		// overwritten by this one, can be applied before the sync completes.
+		syncedIndex := prevLastIndex
+		if len(rd.Entries) > 0 && rd.Entries[0].Index <= syncedIndex {
+			syncedIndex = rd.Entries[0].Index - 1
+		}
+		var err error
+		if pipelined, err = pipelinedEntries(committedEntries, syncedIndex); err != nil {
+			const expl = "while unmarshalling entry"
+			return stats, expl, errors.Wrap(err, expl)
+		}
+		committedEntries = committedEntries[len(pipelined):]
+	}
 	commitStart := timeutil.Now()
-	if err := batch.Commit(rd.MustSync && !disableSyncRaftLog.Get(&r.store.cfg.Settings.SV)); err != nil {
+	var commitErr error
+	if len(pipelined) > 0 {
+		r.traceEntries(rd.CommittedEntries[:len(pipelined)], "committed, applying while syncing")
+		commitErr = r.commitWhileApplyingRaftMuLocked(ctx, batch, pipelined, &stats)
+	} else {
+		commitErr = batch.Commit(sync)
+	}
+	if commitErr != nil {
 		const expl = "while committing batch"
-		return stats, expl, errors.Wrap(err, expl)
+		return stats, expl, errors.Wrap(commitErr, expl)
 	}
+	elapsed := timeutil.Since(commitStart)
 	if rd.MustSync {
-		elapsed := timeutil.Since(commitStart)
 		r.store.metrics.RaftLogCommitLatency.RecordValue(elapsed.Nanoseconds())
 	}
 
 	if len(rd.Entries) > 0 {
+		r.raftMu.logAppends.record(raftLogAppend{
+			first: rd.Entries[0].Index,
+			last:  rd.Entries[len(rd.Entries)-1].Index,
+			sync:  sync,
+			dur:   elapsed,
+		})
+
 		// We may have just overwritten parts of the log which contain
 		// sideloaded SSTables from a previous term (and perhaps discarded some
 		// entries that we didn't overwrite). Remove any such leftover on-disk
@@ -776,18 +829,15 @@ func (r *Replica) handleRaftReadyRaftMuLocked(
 	// and cache the latest ones.
 	r.store.raftEntryCache.Add(r.RangeID, rd.Entries, true /* truncate */)
 	r.sendRaftMessages(ctx, otherMsgs)
-	r.traceEntries(rd.CommittedEntries, "committed, before applying any entries")
+	r.traceEntries(committedEntries, "committed, before applying any entries")
 	applicationStart := timeutil.Now()
-	for _, e := range rd.CommittedEntries {
+	for _, e := range committedEntries {
 		switch e.Type {
 		case raftpb.EntryNormal:
 			// NB: Committed entries are handed to us by Raft. Raft does not
 			// know about sideloading. Consequently the entries here are all
 			// already inlined.
-
-			var commandID storagebase.CmdIDKey
-			var command storagepb.RaftCommand
-
+			//
 			// Process committed entries. etcd raft occasionally adds a nil entry
 			// (our own commands are never empty). This happens in two situations:
 			// When a new leader is elected, and when a config change is dropped due
@@ -804,39 +854,13 @@ func (r *Replica) handleRaftReadyRaftMuLocked(
 				if !r.store.TestingKnobs().DisableRefreshReasonNewLeaderOrConfigChange {
 					refreshReason = reasonNewLeaderOrConfigChange
 				}
-				commandID = "" // special-cased value, command isn't used
-			} else {
-				var encodedCommand []byte
-				commandID, encodedCommand = DecodeRaftCommand(e.Data)
-				// An empty command is used to unquiesce a range and wake the
-				// leader. Clear commandID so it's ignored for processing.
-				if len(encodedCommand) == 0 {
-					commandID = ""
-				} else if err := protoutil.Unmarshal(encodedCommand, &command); err != nil {
-					const expl = "while unmarshalling entry"
-					return stats, expl, errors.Wrap(err, expl)
-				}
-			}
-
-			if changedRepl := r.processRaftCommand(ctx, commandID, e.Term, e.Index, command); changedRepl {
-				log.Fatalf(ctx, "unexpected replication change from command %s", &command)
 			}
-			r.store.metrics.RaftCommandsApplied.Inc(1)
-			stats.processed++
-
-			r.mu.Lock()
-			if r.mu.replicaID == r.mu.leaderID {
-				// At this point we're not guaranteed to have proposalQuota
-				// initialized, the same is true for quotaReleaseQueue and
-				// commandSizes. By checking if the specified commandID is
-				// present in commandSizes, we'll only queue the cmdSize if
-				// they're all initialized.
-				if cmdSize, ok := r.mu.commandSizes[commandID]; ok {
-					r.mu.quotaReleaseQueue = append(r.mu.quotaReleaseQueue, cmdSize)
-					delete(r.mu.commandSizes, commandID)
-				}
+			commandID, command, err := decodeRaftEntry(e)
+			if err != nil {
+				const expl = "while unmarshalling entry"
+				return stats, expl, errors.Wrap(err, expl)
 			}
-			r.mu.Unlock()
+			r.applyNormalEntryRaftMuLocked(ctx, e, commandID, command, &stats)
 
 		case raftpb.EntryConfChange:
 			var cc raftpb.ConfChange
@@ -910,9 +934,98 @@ func (r *Replica) handleRaftReadyRaftMuLocked(
 	}); err != nil {
 		return stats, expl, errors.Wrap(err, expl)
 	}
+
+	if nextReplicaID := r.raftMu.removedNextReplicaID; nextReplicaID != 0 {
+		r.raftMu.removedNextReplicaID = 0
+		r.removeEagerlyRaftMuLocked(ctx, nextReplicaID)
+	}
 	return stats, "", nil
 }
 
+// decodeRaftEntry decodes the command carried by a committed EntryNormal. The
+// returned commandID is empty for entries which don't carry a command, which
+// is the case for the empty entries appended by etcd/raft and the empty
+// commands used to unquiesce a range and wake the leader.
+func decodeRaftEntry(
+	e raftpb.Entry,
+) (commandID storagebase.CmdIDKey, command storagepb.RaftCommand, _ error) {
+	if len(e.Data) == 0 {
+		return "", command, nil
+	}
+	commandID, encodedCommand := DecodeRaftCommand(e.Data)
+	if len(encodedCommand) == 0 {
+		return "", command, nil
+	}
+	if err := protoutil.Unmarshal(encodedCommand, &command); err != nil {
+		return "", command, err
+	}
+	return commandID, command, nil
+}
+
+// applyNormalEntryRaftMuLocked applies a committed EntryNormal decoded by
+// decodeRaftEntry and queues the quota of the command for release.
+func (r *Replica) applyNormalEntryRaftMuLocked(
+	ctx context.Context,
+	e raftpb.Entry,
+	commandID storagebase.CmdIDKey,
+	command storagepb.RaftCommand,
+	stats *handleRaftReadyStats,
+) {
+	if changedRepl := r.processRaftCommand(ctx, commandID, e.Term, e.Index, command); changedRepl {
+		log.Fatalf(ctx, "unexpected replication change from command %s", &command)
+	}
+	r.store.metrics.RaftCommandsApplied.Inc(1)
+	stats.processed++
+
+	r.mu.Lock()
+	if r.mu.replicaID == r.mu.leaderID {
+		// At this point we're not guaranteed to have proposalQuota
+		// initialized, the same is true for quotaReleaseQueue and
+		// commandSizes. By checking if the specified commandID is
+		// present in commandSizes, we'll only queue the cmdSize if
+		// they're all initialized.
+		if cmdSize, ok := r.mu.commandSizes[commandID]; ok {
+			r.mu.quotaReleaseQueue = append(r.mu.quotaReleaseQueue, cmdSize)
+			delete(r.mu.commandSizes, commandID)
+		}
+	}
+	r.mu.Unlock()
+}
+
+// canRemoveEagerly returns whether a replica which applied a replication
+// change removing it can be removed without going through the replica GC
+// queue, along with the NextReplicaID of its range descriptor. Replicas
+// which still hold the lease are left to the queue: removing them would
+// leave the range without a leaseholder until the lease expires.
+func (r *Replica) canRemoveEagerly() (roachpb.ReplicaID, bool) {
+	if r.store.TestingKnobs().DisableEagerReplicaRemoval || r.store.replicaGCQueue.Disabled() {
+		return 0, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	desc := r.mu.state.Desc
+	if _, ok := desc.GetReplicaDescriptor(r.store.StoreID()); ok {
+		return 0, false
+	}
+	if l := r.mu.state.Lease; l != nil && l.OwnedBy(r.store.StoreID()) {
+		return 0, false
+	}
+	return desc.NextReplicaID, true
+}
+
+// removeEagerlyRaftMuLocked removes a replica which applied its own removal.
+// If that fails, the replica is handed to the replica GC queue instead.
+func (r *Replica) removeEagerlyRaftMuLocked(ctx context.Context, nextReplicaID roachpb.ReplicaID) {
+	if err := r.store.removeReplicaImpl(ctx, r, nextReplicaID, RemoveOptions{
+		DestroyData: true,
+	}); err != nil {
+		log.Infof(ctx, "unable to remove replica eagerly: %s; queueing for replica GC", err)
+		r.store.replicaGCQueue.AddAsync(ctx, r, replicaGCPriorityRemoved)
+		return
+	}
+	r.store.replicaGCQueue.metrics.RemoveReplicaCount.Inc(1)
+}
+
 // splitMsgApps splits the Raft message slice into two slices, one containing
 // MsgApps and one containing all other message types. Each slice retains the
 // relative ordering between messages in the original slice.
@@ -964,6 +1077,7 @@ func (r *Replica) tick(livenessMap IsLiveMap) (bool, error) {
 	}
 
 	r.maybeTransferRaftLeadershipLocked(ctx)
+	r.updatePausedFollowersLocked(ctx, timeutil.Now())
 
 	r.mu.ticks++
 	r.mu.internalRaftGroup.Tick()
@@ -978,7 +1092,8 @@ func (r *Replica) tick(livenessMap IsLiveMap) (bool, error) {
 		// through. Note that the combination of the above condition and passing
 		// RaftElectionTimeoutTicks to refreshProposalsLocked means that commands
 		// will be refreshed when they have been pending for 1 to 2 election
-		// cycles.
+		// cycles. Commands which were already reproposed back off
+		// exponentially (see ProposalData.reproposalBackoffTicks).
 		r.refreshProposalsLocked(refreshAtDelta, reasonTicks)
 	}
 	return true, nil
@@ -1051,10 +1166,24 @@ func (r *Replica) refreshProposalsLocked(refreshAtDelta int, reason refreshRaftR
 			continue
 
 		case reasonTicks:
-			if p.proposedAtTicks <= r.mu.ticks-refreshAtDelta {
-				// The command was proposed a while ago and may have been dropped. Try it again.
-				reproposals = append(reproposals, p)
+			if p.proposedAtTicks > r.mu.ticks-refreshAtDelta {
+				continue
+			}
+			if p.proposedAtTicks > r.mu.ticks-p.reproposalBackoffTicks(refreshAtDelta) {
+				// The command has been reproposed before and is backing off.
+				r.store.metrics.RaftCommandsReproposalsDeferred.Inc(1)
+				continue
 			}
+			// The command was proposed a while ago and may have been dropped.
+			// Try it again.
+			//
+			// This is done even if the deadline of the proposal has passed: a
+			// previous copy of the command may still apply, so the proposal's
+			// latches can only be released once the command has applied or has
+			// been rejected, which requires it to make it into the log. It's
+			// abandoned once it is rejected (see processRaftCommand).
+			p.reproposals++
+			reproposals = append(reproposals, p)
 
 		default:
 			// We have reason to believe that all pending proposals were
@@ -1081,6 +1210,7 @@ func (r *Replica) refreshProposalsLocked(refreshAtDelta int, reason refreshRaftR
 	// TODO(tschottdorf): evaluate whether `r.mu.proposals` should
 	// be a list/slice.
 	sort.Sort(reproposals)
+	r.store.metrics.RaftCommandsReproposed.Inc(int64(len(reproposals)))
 	for _, p := range reproposals {
 		log.Eventf(p.ctx, "re-submitting command %x to Raft: %s", p.idKey, reason)
 		if err := r.submitProposalLocked(p); err == raft.ErrProposalDropped {
@@ -1191,6 +1321,7 @@ func (r *Replica) sendRaftMessage(ctx context.Context, msg raftpb.Message) {
 	fromReplica, fromErr := r.getReplicaDescriptorByIDRLocked(roachpb.ReplicaID(msg.From), r.mu.lastToReplica)
 	toReplica, toErr := r.getReplicaDescriptorByIDRLocked(roachpb.ReplicaID(msg.To), r.mu.lastFromReplica)
 	var startKey roachpb.RKey
+	var paused bool
 	if msg.Type == raftpb.MsgHeartbeat {
 		if r.mu.replicaID == 0 {
 			log.Fatalf(ctx, "preemptive snapshot attempted to send a heartbeat: %+v", msg)
@@ -1205,6 +1336,7 @@ func (r *Replica) sendRaftMessage(ctx context.Context, msg raftpb.Message) {
 		// a split trigger, send the replica's StartKey along. See the method
 		// below for more context:
 		_ = maybeDropMsgApp
+		_, paused = r.mu.pausedFollowers[roachpb.ReplicaID(msg.To)]
 		// NB: this code is allocation free.
 		r.mu.internalRaftGroup.WithProgress(func(id uint64, _ raft.ProgressType, pr raft.Progress) {
 			if id == msg.To && pr.State == raft.ProgressStateProbe {
@@ -1240,6 +1372,21 @@ func (r *Replica) sendRaftMessage(ctx context.Context, msg raftpb.Message) {
 		return
 	}
 
+	if paused {
+		// The recipient's store is overloaded. Instead of sending it more log
+		// entries, report it as unreachable so that Raft moves it to probing
+		// state, in which it is only appended to once per heartbeat interval.
+		// The heartbeats themselves keep flowing.
+		r.store.metrics.RaftPausedDropped.Inc(1)
+		if err := r.withRaftGroup(true, func(raftGroup *raft.RawNode) (bool, error) {
+			raftGroup.ReportUnreachable(msg.To)
+			return true, nil
+		}); err != nil {
+			log.Fatal(ctx, err)
+		}
+		return
+	}
+
 	if !r.sendRaftMessageRequest(ctx, &RaftMessageRequest{
 		RangeID:       r.RangeID,
 		ToReplica:     toReplica,
@@ -1538,9 +1685,10 @@ func (s pendingCmdSlice) Less(i, j int) bool {
 func (r *Replica) withRaftGroupLocked(
 	mayCampaignOnWake bool, f func(r *raft.RawNode) (unquiesceAndWakeLeader bool, _ error),
 ) error {
-	if r.mu.destroyStatus.Removed() {
-		// Silently ignore all operations on destroyed replicas. We can't return an
-		// error here as all errors returned from this method are considered fatal.
+	if r.mu.destroyStatus.Removed() || r.mu.destroyStatus.Quarantined() {
+		// Silently ignore all operations on destroyed or quarantined replicas. We
+		// can't return an error here as all errors returned from this method are
+		// considered fatal.
 		return nil
 	}
 
@@ -1577,7 +1725,7 @@ func (r *Replica) withRaftGroupLocked(
 		return f(raftGroup)
 	}(r.RangeID, r.mu.internalRaftGroup)
 	if unquiesce {
-		r.unquiesceAndWakeLeaderLocked()
+		r.unquiesceAndWakeLeaderLocked(unquiesceReasonRaftGroup)
 	}
 	return err
 }
@@ -1768,6 +1916,17 @@ func (r *Replica) processRaftCommand(
 
 	r.mu.Unlock()
 
+	if !proposedLocally && len(raftCmd.TraceData) > 0 &&
+		raftCmd.ProposerReplica.StoreID != r.store.StoreID() {
+		// The command was proposed from a traced context on another replica.
+		// Record its application here and send the recording back.
+		var sp opentracing.Span
+		ctx, sp = r.startRaftApplicationSpanRaftMuLocked(ctx, raftIndex, raftCmd.TraceData)
+		if sp != nil {
+			defer r.finishRaftApplicationSpan(sp, idKey, raftCmd.ProposerReplica)
+		}
+	}
+
 	if forcedErr == nil {
 		// Verify that the batch timestamp is after the GC threshold. This is
 		// necessary because not all commands declare read access on the GC
@@ -2006,7 +2165,11 @@ func (r *Replica) processRaftCommand(
 		//
 		// Note that this must happen after committing (the engine.Batch), but
 		// before notifying a potentially waiting client.
-		r.handleEvalResultRaftMuLocked(ctx, lResult,
+		var ba *roachpb.BatchRequest
+		if proposedLocally {
+			ba = proposal.Request
+		}
+		r.handleEvalResultRaftMuLocked(ctx, ba, lResult,
 			raftCmd.ReplicatedEvalResult, raftIndex, leaseIndex)
 
 		// Provide the command's corresponding logical operations to the
@@ -2059,8 +2222,17 @@ func (r *Replica) processRaftCommand(
 		// eventually apply the proposal would be a user-visible error.
 		// TODO(nvanbenschoten): This reproposal is not tracked by the
 		// quota pool. We should fix that.
-		if proposalRetry == proposalIllegalLeaseIndex && r.tryReproposeWithNewLeaseIndex(proposal) {
-			return false
+		if proposalRetry == proposalIllegalLeaseIndex {
+			if now := timeutil.Now(); proposal.deadlineExceeded(now) {
+				// Don't repropose a command whose client has given up on it.
+				// This copy of the command carries the proposal's current lease
+				// index, so no other copy can apply anymore and its latches can
+				// be released.
+				r.store.metrics.RaftCommandsAbandoned.Inc(1)
+				response.Err = newProposalDeadlineExceededError(proposal, now, "illegal lease index")
+			} else if r.tryReproposeWithNewLeaseIndex(proposal) {
+				return false
+			}
 		}
 		// Otherwise, signal the command's status to the client.
 		proposal.finishApplication(response)
@@ -2344,6 +2516,9 @@ func (r *Replica) applyRaftCommand(
 		// decreasing (and thus LastUpdateNanos tracks the maximum LastUpdateNanos
 		// across all deltaStats).
 		ms.Add(deltaStats)
+		if rResult.ClearEstimates {
+			ms.ContainsEstimates = false
+		}
 
 		// Set the range applied state, which includes the last applied raft and
 		// lease index along with the mvcc stats, all in one key.
@@ -2367,6 +2542,9 @@ func (r *Replica) applyRaftCommand(
 		// decreasing (and thus LastUpdateNanos tracks the maximum LastUpdateNanos
 		// across all deltaStats).
 		ms.Add(deltaStats)
+		if rResult.ClearEstimates {
+			ms.ContainsEstimates = false
+		}
 		if err := r.raftMu.stateLoader.SetMVCCStats(ctx, writer, &ms); err != nil {
 			return storagepb.ReplicatedEvalResult{}, errors.Wrap(err, "unable to update MVCCStats")
 		}
@@ -0,0 +1,148 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/pkg/errors"
+	"go.etcd.io/etcd/raft/raftpb"
+)
+
+// MaxDebugRaftLogEntries is the maximum number of entries returned by
+// Replica.DebugRaftLog.
+const MaxDebugRaftLogEntries = 100
+
+// DebugRaftLogEntry is a decoded entry of a Raft log.
+type DebugRaftLogEntry struct {
+	Index, Term uint64
+	Type        raftpb.EntryType
+	CommandID   storagebase.CmdIDKey
+	// Command is the command held by the entry, without its write batch and
+	// logical operations.
+	Command string
+	// WriteBatch summarizes the writes made by the command.
+	WriteBatch string
+	Size       int
+}
+
+// DebugRaftLog holds a window of entries of a replica's Raft log, along with
+// the indexes describing the state of the log.
+type DebugRaftLog struct {
+	FirstIndex, LastIndex     uint64
+	AppliedIndex, CommitIndex uint64
+	Entries                   []DebugRaftLogEntry
+}
+
+// DebugRaftLog returns the decoded entries of the replica's Raft log with
+// indexes in [lo, hi), limited to MaxDebugRaftLogEntries. A zero hi selects
+// the end of the log, and a zero lo the entries right before hi. The
+// window is clamped to the entries present in the log. The log is read
+// without blocking the application of commands, so it may be truncated or
+// appended to concurrently.
+func (r *Replica) DebugRaftLog(ctx context.Context, lo, hi uint64) (DebugRaftLog, error) {
+	firstIndex, err := r.GetFirstIndex()
+	if err != nil {
+		return DebugRaftLog{}, err
+	}
+	r.mu.RLock()
+	res := DebugRaftLog{
+		FirstIndex:   firstIndex,
+		LastIndex:    r.mu.lastIndex,
+		AppliedIndex: r.mu.state.RaftAppliedIndex,
+	}
+	if status := r.raftStatusRLocked(); status != nil {
+		res.CommitIndex = status.Commit
+	}
+	r.mu.RUnlock()
+
+	if end := res.LastIndex + 1; hi == 0 || hi > end {
+		hi = end
+	}
+	if lo == 0 && hi > MaxDebugRaftLogEntries {
+		lo = hi - MaxDebugRaftLogEntries
+	}
+	if lo < res.FirstIndex {
+		lo = res.FirstIndex
+	}
+	if hi > lo+MaxDebugRaftLogEntries {
+		hi = lo + MaxDebugRaftLogEntries
+	}
+	if lo >= hi {
+		return res, nil
+	}
+
+	if err := iterateEntries(ctx, r.store.Engine(), r.RangeID, lo, hi, func(kv roachpb.KeyValue) (bool, error) {
+		var ent raftpb.Entry
+		if err := kv.Value.GetProto(&ent); err != nil {
+			return false, err
+		}
+		e, err := decodeDebugRaftLogEntry(ent)
+		if err != nil {
+			return false, errors.Wrapf(err, "while decoding entry %d", ent.Index)
+		}
+		res.Entries = append(res.Entries, e)
+		return false, nil
+	}); err != nil {
+		return DebugRaftLog{}, err
+	}
+	return res, nil
+}
+
+// decodeDebugRaftLogEntry decodes the command held by a Raft log entry.
+func decodeDebugRaftLogEntry(ent raftpb.Entry) (DebugRaftLogEntry, error) {
+	e := DebugRaftLogEntry{
+		Index: ent.Index,
+		Term:  ent.Term,
+		Type:  ent.Type,
+		Size:  ent.Size(),
+	}
+	var command storagepb.RaftCommand
+	switch ent.Type {
+	case raftpb.EntryNormal:
+		var err error
+		if e.CommandID, command, err = decodeRaftEntry(ent); err != nil {
+			return DebugRaftLogEntry{}, err
+		}
+		if e.CommandID == "" {
+			// An empty entry, as appended by new leaders.
+			return e, nil
+		}
+	case raftpb.EntryConfChange:
+		var cc raftpb.ConfChange
+		if err := protoutil.Unmarshal(ent.Data, &cc); err != nil {
+			return DebugRaftLogEntry{}, err
+		}
+		var ccCtx ConfChangeContext
+		if err := protoutil.Unmarshal(cc.Context, &ccCtx); err != nil {
+			return DebugRaftLogEntry{}, err
+		}
+		if err := protoutil.Unmarshal(ccCtx.Payload, &command); err != nil {
+			return DebugRaftLogEntry{}, err
+		}
+		e.CommandID = storagebase.CmdIDKey(ccCtx.CommandID)
+	default:
+		return DebugRaftLogEntry{}, errors.Errorf("unknown entry type %s", ent.Type)
+	}
+	e.WriteBatch = summarizeWriteBatch(command.WriteBatch)
+	command.WriteBatch = nil
+	command.LogicalOpLog = nil
+	e.Command = command.String()
+	return e, nil
+}
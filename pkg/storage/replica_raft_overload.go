@@ -0,0 +1,128 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// pauseReplicationToOverloadedFollowers controls whether Raft leaders stop
+// sending log entries to followers whose stores report (via gossip) that their
+// storage engine is severely overloaded. A follower which can't keep up with
+// the incoming writes otherwise slows down every range it is part of, since its
+// Raft messages queue up and, through the proposal quota pool, throttle the
+// proposals on the leader.
+var pauseReplicationToOverloadedFollowers = settings.RegisterBoolSetting(
+	"kv.raft.pause_replication_to_overloaded_followers.enabled",
+	"if enabled, Raft leaders stop replicating to followers on stores with an "+
+		"overloaded storage engine, as long as the range keeps a quorum without them",
+	false,
+)
+
+// updateOverloaded recomputes whether the store's engine is overloaded from
+// the given LSM health, using the same signals (L0 file count, pending
+// compaction bytes and read amplification) that backpressure SST ingestions. A change is gossiped
+// right away so that leaders learn about it without waiting for the next
+// periodic gossip of the store descriptor.
+func (s *Store) updateOverloaded(ctx context.Context, health *engine.LSMHealth) {
+	overloaded := engine.IsOverloaded(s.cfg.Settings, health)
+	var val int32
+	if overloaded {
+		val = 1
+	}
+	s.metrics.RaftStoreOverloaded.Update(int64(val))
+	if atomic.SwapInt32(&s.overloaded, val) == val {
+		return
+	}
+	if overloaded {
+		log.Warningf(ctx, "storage engine is overloaded; asking leaders to pause replication to this store")
+	} else {
+		log.Infof(ctx, "storage engine is no longer overloaded")
+	}
+	s.asyncGossipStore(ctx, "overload change", true /* useCached */)
+}
+
+// IsOverloaded returns whether the store's engine was found to be overloaded
+// the last time the store's metrics were computed.
+func (s *Store) IsOverloaded() bool {
+	return atomic.LoadInt32(&s.overloaded) == 1
+}
+
+// updatePausedFollowersLocked recomputes the set of followers to which the
+// replica, if it is the Raft leader, doesn't send log entries because their
+// stores are overloaded. It is called on every Raft tick.
+func (r *Replica) updatePausedFollowersLocked(ctx context.Context, now time.Time) {
+	var overloaded map[roachpb.StoreID]struct{}
+	if r.mu.replicaID == r.mu.leaderID {
+		overloaded, _ = r.store.overloadedStores.Load().(map[roachpb.StoreID]struct{})
+	}
+	paused := computePausedFollowers(r.mu.replicaID, r.descRLocked(), overloaded,
+		func(replicaID roachpb.ReplicaID) bool {
+			return r.mu.lastUpdateTimes.isFollowerActive(ctx, replicaID, now)
+		})
+	if len(paused) != len(r.mu.pausedFollowers) {
+		log.VEventf(ctx, 1, "pausing replication to %d followers on overloaded stores", len(paused))
+	}
+	r.mu.pausedFollowers = paused
+}
+
+// computePausedFollowers returns the followers of the range to which the leader
+// should stop sending log entries because their stores are overloaded.
+//
+// Replication is never paused for the ranges below the user table data, which
+// hold the meta ranges, node liveness and the system tables; the cluster
+// can't afford any of those to slow down. Neither is it paused if the leader
+// and the remaining active followers don't form a quorum on their own, in
+// which case pausing would make the range unavailable rather than faster.
+func computePausedFollowers(
+	leaderID roachpb.ReplicaID,
+	desc *roachpb.RangeDescriptor,
+	overloaded map[roachpb.StoreID]struct{},
+	isActive func(roachpb.ReplicaID) bool,
+) map[roachpb.ReplicaID]struct{} {
+	if len(overloaded) == 0 || desc.StartKey.Less(roachpb.RKey(keys.UserTableDataMin)) {
+		return nil
+	}
+	var paused map[roachpb.ReplicaID]struct{}
+	healthy := 0
+	for _, rep := range desc.Replicas().Voters() {
+		if rep.ReplicaID == leaderID {
+			healthy++
+			continue
+		}
+		if _, ok := overloaded[rep.StoreID]; ok {
+			if paused == nil {
+				paused = make(map[roachpb.ReplicaID]struct{})
+			}
+			paused[rep.ReplicaID] = struct{}{}
+			continue
+		}
+		if isActive(rep.ReplicaID) {
+			healthy++
+		}
+	}
+	if healthy < desc.Replicas().QuorumSize() {
+		return nil
+	}
+	return paused
+}
@@ -0,0 +1,128 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"go.etcd.io/etcd/raft/raftpb"
+)
+
+// pipelinedApplyEnabled controls whether the committed entries of a Raft
+// Ready are applied while the log entries and HardState of the same Ready are
+// synced to disk, instead of after the sync completes. This overlaps the
+// latency of the fsync with the CPU spent applying commands.
+var pipelinedApplyEnabled = settings.RegisterBoolSetting(
+	"kv.raft.pipelined_apply.enabled",
+	"if enabled, committed Raft commands are applied while the Raft log entries "+
+		"and state written alongside them are synced to disk",
+	false,
+)
+
+// pipelinedEntry is a committed Raft entry which is applied concurrently with
+// the sync of the Raft log batch, along with its decoded command.
+type pipelinedEntry struct {
+	e         raftpb.Entry
+	commandID storagebase.CmdIDKey
+	command   storagepb.RaftCommand
+}
+
+// pipelinedEntries returns the decoded prefix of the committed entries which
+// can be applied while the Raft log batch of the same Ready is being synced.
+// An entry qualifies if it was already synced to the Raft log by a previous
+// Ready, i.e. its index is at most syncedIndex, and its application leaves the
+// Raft log and the replica's membership alone. Committed entries are applied
+// in index order, so the prefix ends at the first entry which doesn't
+// qualify; the remaining entries are applied once the sync has completed.
+//
+// An entry appended by the same Ready may have been committed with the vote
+// of the local replica, which counts its own log before it is synced. Such an
+// entry must not be applied, and its proposer acknowledged, until the sync
+// completes: a crash could otherwise lose an entry that a client was told was
+// committed.
+func pipelinedEntries(ents []raftpb.Entry, syncedIndex uint64) ([]pipelinedEntry, error) {
+	var pipelined []pipelinedEntry
+	for _, e := range ents {
+		if e.Index > syncedIndex {
+			break
+		}
+		// Empty entries may require pending proposals to be refreshed, which
+		// is left to the regular application loop.
+		if e.Type != raftpb.EntryNormal || len(e.Data) == 0 {
+			break
+		}
+		commandID, command, err := decodeRaftEntry(e)
+		if err != nil {
+			return nil, err
+		}
+		if !canApplyPipelined(&command.ReplicatedEvalResult) {
+			break
+		}
+		pipelined = append(pipelined, pipelinedEntry{e: e, commandID: commandID, command: command})
+	}
+	return pipelined, nil
+}
+
+// canApplyPipelined returns whether a command with the given replicated
+// result can be applied concurrently with the sync of a Raft log append.
+// Commands which truncate the log or touch sideloaded files race with the
+// purging of sideloaded files and the accounting of the log size that follow
+// the sync, and commands which change the range's bounds or membership may
+// destroy or subsume the replica.
+func canApplyPipelined(res *storagepb.ReplicatedEvalResult) bool {
+	if res.State != nil && res.State.TruncatedState != nil {
+		return false
+	}
+	return res.RaftLogDelta == 0 &&
+		res.AddSSTable == nil &&
+		res.Split == nil &&
+		res.Merge == nil &&
+		res.ChangeReplicas == nil
+}
+
+// commitWhileApplyingRaftMuLocked synchronously commits the batch holding the
+// Raft log entries and HardState of a Ready, applying the given committed
+// entries while the batch is being synced to disk.
+//
+// The batch is written to the engine before any of the entries is applied, so
+// the writes of their application are ordered after the Raft log entries and
+// HardState: whenever an applied index survives a crash, so do the log
+// entries and the commit index it refers to. The applied index check in
+// applyRaftCommand guarantees that no entry is skipped.
+func (r *Replica) commitWhileApplyingRaftMuLocked(
+	ctx context.Context, batch engine.Batch, pipelined []pipelinedEntry, stats *handleRaftReadyStats,
+) error {
+	if err := batch.CommitNoSyncWait(); err != nil {
+		return err
+	}
+	for i := range pipelined {
+		p := &pipelined[i]
+		r.applyNormalEntryRaftMuLocked(ctx, p.e, p.commandID, p.command, stats)
+	}
+	last := pipelined[len(pipelined)-1].e.Index
+	r.mu.RLock()
+	appliedIndex := r.mu.state.RaftAppliedIndex
+	r.mu.RUnlock()
+	if appliedIndex != last {
+		log.Fatalf(ctx, "applied index %d after pipelined application of entries up to %d",
+			appliedIndex, last)
+	}
+	return batch.SyncWait()
+}
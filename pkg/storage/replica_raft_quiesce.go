@@ -18,6 +18,7 @@ import (
 	"context"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
@@ -55,23 +56,58 @@ func (r *Replica) quiesceLocked() bool {
 	return true
 }
 
-func (r *Replica) unquiesce() {
+// unquiesceReason describes why a replica was woken from quiescence. The
+// reason a replica was last woken is exposed through the ranges status
+// endpoint to help explain why ranges which ought to be cold keep ticking.
+type unquiesceReason string
+
+const (
+	// unquiesceReasonProposal indicates the replica proposed a command.
+	unquiesceReasonProposal unquiesceReason = "proposal"
+	// unquiesceReasonRaftMessage indicates the replica received a Raft
+	// message from another replica.
+	unquiesceReasonRaftMessage unquiesceReason = "raft message"
+	// unquiesceReasonRaftGroup indicates an operation on the replica's Raft
+	// group requested that the range be woken up.
+	unquiesceReasonRaftGroup unquiesceReason = "raft group operation"
+	// unquiesceReasonNodeLiveness indicates a node with a replica of the range
+	// became live.
+	unquiesceReasonNodeLiveness unquiesceReason = "node became live"
+	// unquiesceReasonMerge indicates the range is being merged away.
+	unquiesceReasonMerge unquiesceReason = "merge in progress"
+	// unquiesceReasonQuiesceFailed indicates the leader failed to notify its
+	// followers of quiescence.
+	unquiesceReasonQuiesceFailed unquiesceReason = "quiesce failed"
+)
+
+// unquiesceOnNodeLiveness controls whether a node becoming live wakes up the
+// quiesced ranges which have a replica on it. Waking them up lets those
+// replicas be caught up promptly, but on large clusters the resulting burst of
+// Raft ticks for otherwise cold ranges can be expensive.
+var unquiesceOnNodeLiveness = settings.RegisterBoolSetting(
+	"kv.raft.unquiesce_on_node_liveness.enabled",
+	"wake up quiesced ranges which have a replica on a node that becomes live",
+	true,
+)
+
+func (r *Replica) unquiesce(reason unquiesceReason) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
-	r.unquiesceLocked()
+	r.unquiesceLocked(reason)
 }
 
-func (r *Replica) unquiesceLocked() {
-	r.unquiesceWithOptionsLocked(true /* campaignOnWake */)
+func (r *Replica) unquiesceLocked(reason unquiesceReason) {
+	r.unquiesceWithOptionsLocked(true /* campaignOnWake */, reason)
 }
 
-func (r *Replica) unquiesceWithOptionsLocked(campaignOnWake bool) {
+func (r *Replica) unquiesceWithOptionsLocked(campaignOnWake bool, reason unquiesceReason) {
 	if r.mu.quiescent && r.mu.internalRaftGroup != nil {
 		ctx := r.AnnotateCtx(context.TODO())
 		if log.V(3) {
-			log.Infof(ctx, "unquiescing %d", r.RangeID)
+			log.Infof(ctx, "unquiescing %d: %s", r.RangeID, reason)
 		}
 		r.mu.quiescent = false
+		r.mu.unquiesceReason = reason
 		r.store.unquiescedReplicas.Lock()
 		r.store.unquiescedReplicas.m[r.RangeID] = struct{}{}
 		r.store.unquiescedReplicas.Unlock()
@@ -85,13 +121,14 @@ func (r *Replica) unquiesceWithOptionsLocked(campaignOnWake bool) {
 	}
 }
 
-func (r *Replica) unquiesceAndWakeLeaderLocked() {
+func (r *Replica) unquiesceAndWakeLeaderLocked(reason unquiesceReason) {
 	if r.mu.quiescent && r.mu.internalRaftGroup != nil {
 		ctx := r.AnnotateCtx(context.TODO())
 		if log.V(3) {
-			log.Infof(ctx, "unquiescing %d: waking leader", r.RangeID)
+			log.Infof(ctx, "unquiescing %d: %s, waking leader", r.RangeID, reason)
 		}
 		r.mu.quiescent = false
+		r.mu.unquiesceReason = reason
 		r.store.unquiescedReplicas.Lock()
 		r.store.unquiescedReplicas.m[r.RangeID] = struct{}{}
 		r.store.unquiescedReplicas.Unlock()
@@ -323,7 +360,7 @@ func (r *Replica) quiesceAndNotifyLocked(ctx context.Context, status *raft.Statu
 			if log.V(4) {
 				log.Infof(ctx, "failed to quiesce: cannot find to replica (%d)", id)
 			}
-			r.unquiesceLocked()
+			r.unquiesceLocked(unquiesceReasonQuiesceFailed)
 			return false
 		}
 
@@ -18,10 +18,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
 	"github.com/stretchr/testify/assert"
 	"go.etcd.io/etcd/raft"
+	"go.etcd.io/etcd/raft/raftpb"
 )
 
 func TestLastUpdateTimesMap(t *testing.T) {
@@ -59,3 +64,116 @@ func TestLastUpdateTimesMap(t *testing.T) {
 		6: t4,
 	}, m)
 }
+
+func TestComputePausedFollowers(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	userKey := roachpb.RKey(keys.MakeTablePrefix(keys.MinUserDescID + 1))
+	systemKey := roachpb.RKey(keys.SystemConfigSpan.Key)
+	makeDesc := func(startKey roachpb.RKey, numReplicas int) *roachpb.RangeDescriptor {
+		desc := &roachpb.RangeDescriptor{StartKey: startKey}
+		for i := 1; i <= numReplicas; i++ {
+			desc.InternalReplicas = append(desc.InternalReplicas, roachpb.ReplicaDescriptor{
+				NodeID:    roachpb.NodeID(i),
+				StoreID:   roachpb.StoreID(i),
+				ReplicaID: roachpb.ReplicaID(i),
+			})
+		}
+		return desc
+	}
+	stores := func(ids ...roachpb.StoreID) map[roachpb.StoreID]struct{} {
+		m := make(map[roachpb.StoreID]struct{})
+		for _, id := range ids {
+			m[id] = struct{}{}
+		}
+		return m
+	}
+
+	testCases := []struct {
+		name       string
+		desc       *roachpb.RangeDescriptor
+		overloaded map[roachpb.StoreID]struct{}
+		inactive   []roachpb.ReplicaID
+		exp        []roachpb.ReplicaID
+	}{
+		{"none overloaded", makeDesc(userKey, 3), nil, nil, nil},
+		{"one of three", makeDesc(userKey, 3), stores(3), nil, []roachpb.ReplicaID{3}},
+		{"leader overloaded", makeDesc(userKey, 3), stores(1), nil, nil},
+		{"system range", makeDesc(systemKey, 3), stores(3), nil, nil},
+		{"two of three", makeDesc(userKey, 3), stores(2, 3), nil, nil},
+		{"one of three, other inactive", makeDesc(userKey, 3), stores(3), []roachpb.ReplicaID{2}, nil},
+		{"two of five", makeDesc(userKey, 5), stores(2, 5), nil, []roachpb.ReplicaID{2, 5}},
+		{"two of five, other inactive", makeDesc(userKey, 5), stores(2, 5), []roachpb.ReplicaID{3}, nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			isActive := func(replicaID roachpb.ReplicaID) bool {
+				for _, id := range tc.inactive {
+					if id == replicaID {
+						return false
+					}
+				}
+				return true
+			}
+			paused := computePausedFollowers(1, tc.desc, tc.overloaded, isActive)
+			var exp map[roachpb.ReplicaID]struct{}
+			for _, id := range tc.exp {
+				if exp == nil {
+					exp = make(map[roachpb.ReplicaID]struct{})
+				}
+				exp[id] = struct{}{}
+			}
+			assert.Equal(t, exp, paused)
+		})
+	}
+}
+
+func TestPipelinedEntries(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	entry := func(index uint64, res storagepb.ReplicatedEvalResult) raftpb.Entry {
+		data, err := protoutil.Marshal(&storagepb.RaftCommand{ReplicatedEvalResult: res})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raftpb.Entry{
+			Index: index,
+			Type:  raftpb.EntryNormal,
+			Data:  encodeRaftCommand(raftVersionStandard, makeIDKey(), data),
+		}
+	}
+	write := storagepb.ReplicatedEvalResult{Timestamp: hlc.Timestamp{WallTime: 1}}
+	truncate := storagepb.ReplicatedEvalResult{
+		State: &storagepb.ReplicaState{TruncatedState: &roachpb.RaftTruncatedState{Index: 1}},
+	}
+	split := storagepb.ReplicatedEvalResult{Split: &storagepb.Split{}}
+	empty := raftpb.Entry{Index: 2, Type: raftpb.EntryNormal}
+	confChange := raftpb.Entry{Index: 2, Type: raftpb.EntryConfChange}
+
+	testCases := []struct {
+		name        string
+		ents        []raftpb.Entry
+		syncedIndex uint64
+		exp         int
+	}{
+		{"none", nil, 3, 0},
+		{"writes", []raftpb.Entry{entry(1, write), entry(2, write), entry(3, write)}, 3, 3},
+		{"unsynced writes", []raftpb.Entry{entry(1, write), entry(2, write), entry(3, write)}, 1, 1},
+		{"truncation", []raftpb.Entry{entry(1, write), entry(2, truncate), entry(3, write)}, 3, 1},
+		{"split", []raftpb.Entry{entry(1, split), entry(2, write)}, 3, 0},
+		{"empty entry", []raftpb.Entry{entry(1, write), empty, entry(3, write)}, 3, 1},
+		{"conf change", []raftpb.Entry{entry(1, write), confChange, entry(3, write)}, 3, 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pipelined, err := pipelinedEntries(tc.ents, tc.syncedIndex)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Len(t, pipelined, tc.exp)
+			for i := range pipelined {
+				assert.Equal(t, tc.ents[i].Index, pipelined[i].e.Index)
+			}
+		})
+	}
+}
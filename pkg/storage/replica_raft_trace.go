@@ -0,0 +1,223 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+)
+
+// raftApplicationTraceSampleRate is the fraction of the Raft commands proposed
+// from a recording trace whose application on the other replicas is recorded
+// and imported into that trace.
+var raftApplicationTraceSampleRate = settings.RegisterValidatedFloatSetting(
+	"kv.trace.raft_application.sample_rate",
+	"fraction of traced Raft commands whose application on followers is recorded "+
+		"into the trace of the proposer",
+	0,
+	func(v float64) error {
+		if v < 0 || v > 1 {
+			return errors.Errorf("sample rate must be in [0, 1]: %f", v)
+		}
+		return nil
+	},
+)
+
+// raftApplicationTraceTTL is how long a proposer accepts the recordings of the
+// application of one of its traced commands on other replicas.
+const raftApplicationTraceTTL = 10 * time.Second
+
+type raftApplicationTraceKey struct {
+	rangeID roachpb.RangeID
+	idKey   storagebase.CmdIDKey
+}
+
+type raftApplicationTrace struct {
+	sp      opentracing.Span
+	expires time.Time
+}
+
+// raftApplicationTraceRegistry keeps track of the spans of the commands
+// proposed by a store whose application on other replicas is traced, so that
+// the recordings sent back by these replicas can be imported into them.
+type raftApplicationTraceRegistry struct {
+	syncutil.Mutex
+	traces map[raftApplicationTraceKey]raftApplicationTrace
+}
+
+// register adds the span of the command with the given ID. Expired entries are
+// dropped along the way.
+func (reg *raftApplicationTraceRegistry) register(
+	rangeID roachpb.RangeID, idKey storagebase.CmdIDKey, sp opentracing.Span, now time.Time,
+) {
+	reg.Lock()
+	defer reg.Unlock()
+	if reg.traces == nil {
+		reg.traces = map[raftApplicationTraceKey]raftApplicationTrace{}
+	}
+	for k, t := range reg.traces {
+		if now.After(t.expires) {
+			delete(reg.traces, k)
+		}
+	}
+	reg.traces[raftApplicationTraceKey{rangeID, idKey}] = raftApplicationTrace{
+		sp:      sp,
+		expires: now.Add(raftApplicationTraceTTL),
+	}
+}
+
+// lookup returns the span of the command with the given ID, or nil if it is
+// unknown or has expired.
+func (reg *raftApplicationTraceRegistry) lookup(
+	rangeID roachpb.RangeID, idKey storagebase.CmdIDKey, now time.Time,
+) opentracing.Span {
+	reg.Lock()
+	defer reg.Unlock()
+	t, ok := reg.traces[raftApplicationTraceKey{rangeID, idKey}]
+	if !ok || now.After(t.expires) {
+		return nil
+	}
+	return t.sp
+}
+
+// raftLogAppend describes a write of a run of entries to the Raft log.
+type raftLogAppend struct {
+	first, last uint64
+	sync        bool
+	dur         time.Duration
+}
+
+// raftLogAppendHistory is a ring buffer of the most recent writes to the Raft
+// log of a replica. It lets the application of a traced command report how
+// long the log entry took to be (synchronously) written.
+type raftLogAppendHistory struct {
+	appends [8]raftLogAppend
+	next    int
+}
+
+func (h *raftLogAppendHistory) record(a raftLogAppend) {
+	h.appends[h.next] = a
+	h.next = (h.next + 1) % len(h.appends)
+}
+
+// lookup returns the most recent write which included the entry at the given
+// index.
+func (h *raftLogAppendHistory) lookup(index uint64) (raftLogAppend, bool) {
+	for i := 1; i <= len(h.appends); i++ {
+		a := h.appends[(h.next-i+len(h.appends))%len(h.appends)]
+		if a.first <= index && index <= a.last {
+			return a, true
+		}
+	}
+	return raftLogAppend{}, false
+}
+
+// maybeTraceRaftApplication samples the command about to be proposed for the
+// tracing of its application on the other replicas if it is proposed from a
+// recording trace. The span context of the trace is attached to the command,
+// and the span is registered so that the recordings sent back via
+// CollectRaftApplicationTrace can be imported into it.
+func (r *Replica) maybeTraceRaftApplication(
+	ctx context.Context, idKey storagebase.CmdIDKey, cmd *storagepb.RaftCommand,
+) {
+	sp := opentracing.SpanFromContext(ctx)
+	if sp == nil || !tracing.IsRecording(sp) {
+		return
+	}
+	rate := raftApplicationTraceSampleRate.Get(&r.store.cfg.Settings.SV)
+	if rate == 0 || rand.Float64() >= rate {
+		return
+	}
+	traceData := make(map[string]string)
+	if err := sp.Tracer().Inject(
+		sp.Context(), opentracing.TextMap, opentracing.TextMapCarrier(traceData),
+	); err != nil {
+		log.VEventf(ctx, 2, "unable to propagate trace to followers: %s", err)
+		return
+	}
+	cmd.TraceData = traceData
+	r.store.raftAppTraces.register(r.RangeID, idKey, sp, timeutil.Now())
+	log.Event(ctx, "tracing application on followers")
+}
+
+// startRaftApplicationSpanRaftMuLocked starts the recording of the application
+// of a command which was proposed from a traced context on another replica. It
+// returns a nil span if the trace data cannot be decoded.
+func (r *Replica) startRaftApplicationSpanRaftMuLocked(
+	ctx context.Context, raftIndex uint64, traceData map[string]string,
+) (context.Context, opentracing.Span) {
+	tr := r.AmbientContext.Tracer
+	spanCtx, err := tr.Extract(opentracing.TextMap, opentracing.TextMapCarrier(traceData))
+	if err != nil {
+		log.VEventf(ctx, 2, "unable to decode the trace of command at index %d: %s", raftIndex, err)
+		return ctx, nil
+	}
+	sp := tr.StartSpan(
+		"raft application", opentracing.FollowsFrom(spanCtx), tracing.Recordable,
+		tracing.LogTagsFromCtx(ctx),
+	)
+	if !tracing.IsRecording(sp) {
+		tracing.StartRecording(sp, tracing.SingleNodeRecording)
+	}
+	ctx = opentracing.ContextWithSpan(ctx, sp)
+	if a, ok := r.raftMu.logAppends.lookup(raftIndex); ok {
+		log.Eventf(ctx, "log entry %d written in %s (sync=%t) with entries [%d, %d]",
+			raftIndex, a.dur, a.sync, a.first, a.last)
+	}
+	return ctx, sp
+}
+
+// finishRaftApplicationSpan finishes the span started by
+// startRaftApplicationSpanRaftMuLocked and asynchronously sends its recording
+// to the store which proposed the command.
+func (r *Replica) finishRaftApplicationSpan(
+	sp opentracing.Span, idKey storagebase.CmdIDKey, proposer roachpb.ReplicaDescriptor,
+) {
+	sp.Finish()
+	req := &CollectRaftApplicationTraceRequest{
+		StoreRequestHeader: StoreRequestHeader{NodeID: proposer.NodeID, StoreID: proposer.StoreID},
+		RangeID:            r.RangeID,
+		CommandID:          []byte(idKey),
+		Spans:              tracing.GetRecording(sp),
+	}
+	ctx := r.AnnotateCtx(context.Background())
+	_ = r.store.stopper.RunAsyncTask(ctx, "storage.Replica: sending raft application trace",
+		func(ctx context.Context) {
+			ctx, cancel := context.WithTimeout(ctx, raftApplicationTraceTTL)
+			defer cancel()
+			conn, err := r.store.cfg.NodeDialer.Dial(ctx, proposer.NodeID)
+			if err != nil {
+				log.VEventf(ctx, 2, "unable to send raft application trace to n%d: %s",
+					proposer.NodeID, err)
+				return
+			}
+			if _, err := NewPerReplicaClient(conn).CollectRaftApplicationTrace(ctx, req); err != nil {
+				log.VEventf(ctx, 2, "unable to send raft application trace to n%d: %s",
+					proposer.NodeID, err)
+			}
+		})
+}
@@ -69,12 +69,20 @@ func (r *replicaRaftStorage) InitialState() (raftpb.HardState, raftpb.ConfState,
 	if raft.IsEmptyHardState(hs) || err != nil {
 		return raftpb.HardState{}, raftpb.ConfState{}, err
 	}
+	return hs, confStateFromDesc(r.mu.state.Desc), nil
+}
+
+// confStateFromDesc synthesizes the raftpb.ConfState of a range from its
+// descriptor.
+func confStateFromDesc(desc *roachpb.RangeDescriptor) raftpb.ConfState {
 	var cs raftpb.ConfState
-	for _, rep := range r.mu.state.Desc.Replicas().Unwrap() {
+	for _, rep := range desc.Replicas().Voters() {
 		cs.Nodes = append(cs.Nodes, uint64(rep.ReplicaID))
 	}
-
-	return hs, cs, nil
+	for _, rep := range desc.Replicas().Learners() {
+		cs.Learners = append(cs.Learners, uint64(rep.ReplicaID))
+	}
+	return cs
 }
 
 // Entries implements the raft.Storage interface. Note that maxBytes is advisory
@@ -471,6 +479,13 @@ func (s *OutgoingSnapshot) String() string {
 	return fmt.Sprintf("%s snapshot %s at applied index %d", s.snapType, s.SnapUUID.Short(), s.State.RaftAppliedIndex)
 }
 
+// resetIter repositions the snapshot's iterator at the start of the range's
+// data so that the snapshot can be streamed again.
+func (s *OutgoingSnapshot) resetIter() {
+	s.Iter.Close()
+	s.Iter = rditer.NewReplicaDataIterator(s.State.Desc, s.EngineSnap, true /* replicatedOnly */)
+}
+
 // Close releases the resources associated with the snapshot.
 func (s *OutgoingSnapshot) Close() {
 	s.Iter.Close()
@@ -499,6 +514,9 @@ type IncomingSnapshot struct {
 	// See the comment on VersionUnreplicatedRaftTruncatedState for details.
 	UsesUnreplicatedTruncatedState bool
 	snapType                       string
+	// ssts are the SSTables holding the snapshot's data, if it was written out
+	// to be ingested. See maybeWriteSnapshotSSTs.
+	ssts *snapshotSSTs
 }
 
 // snapshot creates an OutgoingSnapshot containing a rocksdb snapshot for the
@@ -539,10 +557,7 @@ func snapshot(
 	}
 
 	// Synthesize our raftpb.ConfState from desc.
-	var cs raftpb.ConfState
-	for _, rep := range desc.Replicas().Unwrap() {
-		cs.Nodes = append(cs.Nodes, uint64(rep.ReplicaID))
-	}
+	cs := confStateFromDesc(&desc)
 
 	term, err := term(ctx, rsl, snap, rangeID, eCache, appliedIndex)
 	if err != nil {
@@ -677,6 +692,20 @@ func clearRangeData(
 	eng engine.Reader,
 	batch engine.Batch,
 	destroyData bool,
+) error {
+	keyRanges := rditer.MakeAllKeyRanges(desc)
+	if !destroyData {
+		// TODO(benesch): The fact that we hardcode the number of
+		// "metadata" ranges (i.e. non-user-keyspace) suggests that
+		// rditer.MakeAllKeyRanges has the wrong API.
+		keyRanges = keyRanges[:1]
+	}
+	return clearKeyRanges(desc, eng, batch, keyRanges)
+}
+
+// clearKeyRanges clears the given key ranges of the range in the batch.
+func clearKeyRanges(
+	desc *roachpb.RangeDescriptor, eng engine.Reader, batch engine.Batch, keyRanges []rditer.KeyRange,
 ) error {
 	iter := eng.NewIterator(engine.IterOptions{UpperBound: desc.EndKey.AsRawKey()})
 	defer iter.Close()
@@ -689,13 +718,6 @@ func clearRangeData(
 	// perhaps we should fix RocksDB to handle large numbers of tombstones in an
 	// sstable better.
 	const clearRangeMinKeys = 64
-	keyRanges := rditer.MakeAllKeyRanges(desc)
-	if !destroyData {
-		// TODO(benesch): The fact that we hardcode the number of
-		// "metadata" ranges (i.e. non-user-keyspace) suggests that
-		// rditer.MakeAllKeyRanges has the wrong API.
-		keyRanges = keyRanges[:1]
-	}
 	for _, keyRange := range keyRanges {
 		// Peek into the range to see whether it's large enough to justify
 		// ClearRange. Note that the work done here is bounded by
@@ -811,10 +833,15 @@ func (r *Replica) applySnapshot(
 		size += len(e)
 	}
 
+	// The data of large snapshots may have been written to SSTables as the
+	// snapshot was received, which are then ingested rather than committed
+	// through a batch, which would write all of the data through the memtable.
+	ingest := r.shouldIngestSnapshot(inSnap, subsumedRepls)
+
 	log.Infof(ctx, "applying %s snapshot at index %d "+
-		"(id=%s, encoded size=%d, %d rocksdb batches, %d log entries)",
+		"(id=%s, encoded size=%d, %d rocksdb batches, %d log entries, ingest=%t)",
 		snapType, snap.Metadata.Index, inSnap.SnapUUID.Short(),
-		size, len(inSnap.Batches), len(inSnap.LogEntries))
+		size, len(inSnap.Batches), len(inSnap.LogEntries), ingest)
 	defer func(start time.Time) {
 		now := timeutil.Now()
 		log.Infof(ctx, "applied %s snapshot in %0.0fms [clear=%0.0fms batch=%0.0fms entries=%0.0fms commit=%0.0fms]",
@@ -853,8 +880,18 @@ func (r *Replica) applySnapshot(
 	// Delete everything in the range and recreate it from the snapshot.
 	// We need to delete any old Raft log entries here because any log entries
 	// that predate the snapshot will be orphaned and never truncated or GC'd.
-	if err := clearRangeData(ctx, s.Desc, r.store.Engine(), batch, true /* destroyData */); err != nil {
-		return err
+	// When the snapshot is ingested, the ingested SSTables clear the key ranges
+	// they cover instead.
+	if ingest {
+		if err := clearKeyRanges(
+			s.Desc, r.store.Engine(), batch, snapshotMetadataKeyRanges(s.Desc),
+		); err != nil {
+			return err
+		}
+	} else {
+		if err := clearRangeData(ctx, s.Desc, r.store.Engine(), batch, true /* destroyData */); err != nil {
+			return err
+		}
 	}
 	// Clear the cached raft log entries to ensure that old or uncommitted
 	// entries don't impact the in-memory state.
@@ -862,10 +899,16 @@ func (r *Replica) applySnapshot(
 	stats.clear = timeutil.Now()
 
 	// Write the snapshot into the range.
-	for _, batchRepr := range inSnap.Batches {
-		if err := batch.ApplyBatchRepr(batchRepr, false); err != nil {
+	if ingest {
+		if err := batch.ApplyBatchRepr(inSnap.ssts.metadata, false); err != nil {
 			return err
 		}
+	} else {
+		for _, batchRepr := range inSnap.Batches {
+			if err := batch.ApplyBatchRepr(batchRepr, false); err != nil {
+				return err
+			}
+		}
 	}
 
 	// The log entries are all written to distinct keys so we can use a
@@ -935,6 +978,16 @@ func (r *Replica) applySnapshot(
 			s.RaftAppliedIndex, snap.Metadata.Index)
 	}
 
+	if ingest {
+		// The SSTables are ingested before the batch holding the replica's
+		// state, including its descriptor, is committed. Until then the ingested
+		// data isn't part of any replica, and a crash in between leaves it to be
+		// cleared by the next snapshot.
+		if err := r.ingestSnapshotSSTs(ctx, inSnap.ssts); err != nil {
+			return err
+		}
+		r.store.metrics.RangeSnapshotsIngested.Inc(1)
+	}
 	// We've written Raft log entries, so we need to sync the WAL.
 	if err := batch.Commit(!disableSyncRaftLog.Get(&r.store.cfg.Settings.SV)); err != nil {
 		return err
@@ -981,7 +1034,7 @@ func (r *Replica) applySnapshot(
 	// replica according to whether it holds the lease. We allow jumps in the
 	// lease sequence because there may be multiple lease changes accounted for
 	// in the snapshot.
-	r.leasePostApply(ctx, *s.Lease, true /* permitJump */)
+	r.leasePostApply(ctx, *s.Lease, nil /* summary */, true /* permitJump */)
 
 	r.mu.Lock()
 	// We set the persisted last index to the last applied index. This is
@@ -1002,6 +1055,7 @@ func (r *Replica) applySnapshot(
 	// by r.leasePostApply, but we called those above, so now it's safe to
 	// wholesale replace r.mu.state.
 	r.mu.state = s
+	r.readCache.clear()
 	// Snapshots typically have fewer log entries than the leaseholder. The next
 	// time we hold the lease, recompute the log size before making decisions.
 	r.mu.raftLogSizeTrusted = false
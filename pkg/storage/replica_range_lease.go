@@ -54,6 +54,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/rditer"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
@@ -238,6 +239,7 @@ func (p *pendingLeaseRequest) InitOrJoinRequest(
 			return llHandle
 		}
 		reqLease.Epoch = liveness.Epoch
+		reqLease.StoreLiveness = storeLivenessActive(p.repl.store.ClusterSettings())
 	}
 
 	if transfer {
@@ -248,6 +250,14 @@ func (p *pendingLeaseRequest) InitOrJoinRequest(
 		}
 	} else {
 		minProposedTS := p.repl.mu.minLeaseProposedTS
+		if status.State == storagepb.LeaseState_PROSCRIBED &&
+			!leaseProposedTS(status.Lease).Less(minProposedTS) {
+			// The lease was proscribed because the store liveness support for
+			// it was interrupted, not because of a restart. Move the start of the
+			// new lease forward so that it isn't equivalent to the old one, which
+			// would allow a takeover of the old lease to apply after it.
+			minProposedTS = status.Timestamp
+		}
 		leaseReq = &roachpb.RequestLeaseRequest{
 			RequestHeader: reqHeader,
 			Lease:         reqLease,
@@ -342,6 +352,12 @@ func (p *pendingLeaseRequest) requestLeaseAsync(
 					if err = p.repl.store.cfg.NodeLiveness.Heartbeat(ctx, status.Liveness); err != nil {
 						log.Error(ctx, err)
 					}
+				} else if withdrawn, _ := p.repl.leaseWithdrawnByStoreLiveness(status.Lease, p.repl.Desc()); withdrawn &&
+					status.Liveness.Epoch == status.Lease.Epoch {
+					// The voters have withdrawn their support for the owner's store,
+					// which can therefore no longer use the lease although its node
+					// is live. There's no need to increment the epoch.
+					log.VEventf(ctx, 1, "taking over lease %s whose support was withdrawn", status.Lease)
 				} else if status.Liveness.Epoch == status.Lease.Epoch {
 					// If not owner, increment epoch if necessary to invalidate lease.
 					// However, we only do so in the event that the next leaseholder is
@@ -401,6 +417,9 @@ func (p *pendingLeaseRequest) requestLeaseAsync(
 				ba := roachpb.BatchRequest{}
 				ba.Timestamp = p.repl.store.Clock().Now()
 				ba.RangeID = p.repl.RangeID
+				// The range can't serve any other requests until it has a lease,
+				// so get ahead of them.
+				ba.PriorityClass = roachpb.HIGH_PRIORITY
 				ba.Add(leaseReq)
 				_, pErr = p.repl.Send(ctx, ba)
 			}
@@ -526,6 +545,11 @@ func (p *pendingLeaseRequest) newResolvedHandle(pErr *roachpb.Error) *leaseReque
 //
 // - The lease is considered expired in all other cases.
 //
+// If Lease.StoreLiveness is set, an epoch-based lease is additionally only
+// valid while the leaseholder's store is supported by a quorum of the range's
+// voters, and is considered expired by the other replicas once that is no
+// longer possible (see store_liveness.go).
+//
 // The maximum clock offset must always be taken into consideration to
 // avoid a failure of linearizability on a single register during
 // lease changes. Without that stasis period, the following could
@@ -572,6 +596,35 @@ func (r *Replica) leaseStatus(
 		// No stasis when using clockless reads.
 		maxOffset = 0
 	}
+	if lease.Type() == roachpb.LeaseEpoch && lease.StoreLiveness {
+		voters := r.mu.state.Desc.Replicas().Voters()
+		if lease.OwnedBy(r.store.StoreID()) {
+			// Our lease is only valid while a quorum of the voters supports our
+			// store with support that started no later than the lease was
+			// proposed. If the support has lapsed, we can't tell whether the
+			// lease has been taken over. If it was merely interrupted since the
+			// lease was proposed, the lease needs to be reacquired, which fails
+			// if it has been taken over.
+			sl := r.store.storeLiveness
+			exp := sl.supportExpiration(lease.Epoch, voters, leaseProposedTS(lease))
+			if !timestamp.Less(exp.Add(-int64(maxOffset), 0)) {
+				exp = sl.supportExpiration(lease.Epoch, voters, hlc.MaxTimestamp)
+				if timestamp.Less(exp.Add(-int64(maxOffset), 0)) {
+					status.State = storagepb.LeaseState_PROSCRIBED
+				} else {
+					status.State = storagepb.LeaseState_ERROR
+				}
+				return status
+			}
+		} else if withdrawn, ts := r.leaseWithdrawnByStoreLiveness(lease, r.mu.state.Desc); withdrawn {
+			// A new lease must start after the support for the old one was
+			// withdrawn, as that's when the old leaseholder is known to have
+			// stopped using it.
+			status.Timestamp.Forward(ts)
+			status.State = storagepb.LeaseState_EXPIRED
+			return status
+		}
+	}
 	stasis := expiration.Add(-int64(maxOffset), 0)
 	if timestamp.Less(stasis) {
 		status.State = storagepb.LeaseState_VALID
@@ -744,6 +797,25 @@ func (r *Replica) GetLease() (roachpb.Lease, roachpb.Lease) {
 	return r.getLeaseRLocked()
 }
 
+// GetLeaseTransferSummary returns the state the replica accumulated while
+// serving requests as the leaseholder, which a lease transfer hands over to
+// the incoming leaseholder: the highest timestamp at which reads were served
+// and the request counts that load-based decisions are based on. Transfers
+// are evaluated once AdminTransferLease stopped the use of the current lease,
+// which prevents the summary from missing requests. See leasePostApply for
+// its installation.
+func (r *Replica) GetLeaseTransferSummary() *storagepb.LeaseTransferSummary {
+	var summary storagepb.LeaseTransferSummary
+	for _, keyRange := range rditer.MakeReplicatedKeyRanges(r.Desc()) {
+		ts, _ := r.store.tsCache.GetMaxRead(keyRange.Start.Key, keyRange.End.Key)
+		summary.ReadTimestamp.Forward(ts)
+	}
+	if r.leaseholderStats != nil {
+		r.leaseholderStats.summarizeRequestCounts(&summary)
+	}
+	return &summary
+}
+
 func (r *Replica) getLeaseRLocked() (roachpb.Lease, roachpb.Lease) {
 	if nextLease, ok := r.mu.pendingLeaseRequest.RequestPending(); ok {
 		return *r.mu.state.Lease, nextLease
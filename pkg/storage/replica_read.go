@@ -20,6 +20,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/storage/batcheval/result"
+	"github.com/cockroachdb/cockroach/pkg/storage/metering"
 	"github.com/cockroachdb/cockroach/pkg/storage/spanset"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
 	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
@@ -33,6 +34,12 @@ import (
 func (r *Replica) executeReadOnlyBatch(
 	ctx context.Context, ba roachpb.BatchRequest,
 ) (br *roachpb.BatchResponse, pErr *roachpb.Error) {
+	// Historical non-transactional reads below the closed timestamp need
+	// neither the lease nor latches.
+	if r.canServeLatchlessRead(ctx, &ba) {
+		return r.executeLatchlessReadOnlyBatch(ctx, ba)
+	}
+
 	// If the read is not inconsistent, the read requires the range lease or
 	// permission to serve via follower reads.
 	var status storagepb.LeaseStatus
@@ -42,10 +49,23 @@ func (r *Replica) executeReadOnlyBatch(
 				return nil, nErr
 			}
 			r.store.metrics.FollowerReadsCount.Inc(1)
+			defer func() {
+				if br != nil {
+					br.FollowerReads = 1
+				}
+			}()
 		}
 	}
 	r.limitTxnMaxTimestamp(ctx, &ba, status)
 
+	// Wait for the store to admit the evaluation before acquiring latches, so
+	// that queued reads don't hold up writes.
+	release, err := r.admitReadOnlyBatch(ctx, &ba)
+	if err != nil {
+		return nil, roachpb.NewError(err)
+	}
+	defer release()
+
 	spans, err := r.collectSpans(&ba)
 	if err != nil {
 		return nil, roachpb.NewError(err)
@@ -85,6 +105,21 @@ func (r *Replica) executeReadOnlyBatch(
 		return nil, roachpb.NewError(err)
 	}
 
+	// Serve point reads from the read cache if possible. The response still
+	// updates the timestamp cache when the latches are released.
+	cacheKey, cacheable := makeReadCacheKey(&r.store.cfg.Settings.SV, &ba, status.Lease.Sequence)
+	if cacheable {
+		if br = r.readCache.get(cacheKey, &ba); br != nil {
+			r.store.metrics.ReadCacheHits.Inc(1)
+			if r.store.cfg.RequestMeter != nil {
+				r.meterRequest(ctx, metering.Read, &ba, rSpan, int64(br.Size()))
+			}
+			log.Event(ctx, "read served from read cache")
+			return br, nil
+		}
+		r.store.metrics.ReadCacheMisses.Inc(1)
+	}
+
 	// Evaluate read-only batch command. It checks for matching key range; note
 	// that holding readOnlyCmdMu throughout is important to avoid reads from the
 	// "wrong" key range being served after the range has been split.
@@ -96,6 +131,14 @@ func (r *Replica) executeReadOnlyBatch(
 	}
 	defer readOnly.Close()
 	br, result, pErr = evaluateBatch(ctx, storagebase.CmdIDKey(""), readOnly, rec, nil, ba, true /* readOnly */)
+	if pErr == nil {
+		if r.store.cfg.RequestMeter != nil {
+			r.meterRequest(ctx, metering.Read, &ba, rSpan, int64(br.Size()))
+		}
+		if cacheable && result.Local.Intents == nil && !result.Local.MaybeWatchForMerge {
+			r.readCache.add(cacheKey, br)
+		}
+	}
 
 	// A merge is (likely) about to be carried out, and this replica
 	// needs to block all traffic until the merge either commits or
@@ -0,0 +1,146 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+)
+
+// readCacheEnabled controls whether replicas cache the results of point reads.
+var readCacheEnabled = settings.RegisterBoolSetting(
+	"kv.replica.read_cache.enabled",
+	"if set, replicas cache the results of recent point reads until the next write "+
+		"to the range is applied, which speeds up repeated reads of static rows",
+	false,
+)
+
+const (
+	// readCacheMaxEntries is the maximum number of results cached per replica.
+	readCacheMaxEntries = 32
+	// readCacheMaxValueBytes is the size above which values aren't cached.
+	readCacheMaxValueBytes = 4 << 10
+)
+
+// readCacheKey identifies a point read. Two reads with the same key evaluate
+// to the same result as long as no write has been applied to the range in
+// the meantime: the timestamp cache prevents writes at or below the read
+// timestamp from being evaluated after the first read, and latches prevent
+// them from being in flight during it.
+type readCacheKey struct {
+	key string
+	ts  hlc.Timestamp
+	// maxTS is the upper bound of the transaction's uncertainty interval, or
+	// zero for non-transactional reads.
+	maxTS    hlc.Timestamp
+	leaseSeq roachpb.LeaseSequence
+}
+
+// replicaReadCache is a small cache of the results of consistent point reads
+// served by a replica. Any write applied to the replica clears the cache, so
+// it only helps ranges which are mostly idle, such as those holding rarely
+// changing configuration.
+type replicaReadCache struct {
+	mu struct {
+		syncutil.Mutex
+		// entries maps reads to their results. A nil value means that no value
+		// was found.
+		entries map[readCacheKey]*roachpb.Value
+	}
+}
+
+// makeReadCacheKey returns the cache key for the batch, which must have been
+// evaluated under the lease with the given sequence. It returns false if the
+// batch is not eligible for caching: only consistent batches consisting of a
+// single Get are, and transactional ones only if the transaction hasn't
+// written anything yet, as it could otherwise read its own intents.
+func makeReadCacheKey(
+	st *settings.Values, ba *roachpb.BatchRequest, leaseSeq roachpb.LeaseSequence,
+) (readCacheKey, bool) {
+	if !readCacheEnabled.Get(st) || leaseSeq == 0 ||
+		ba.ReadConsistency != roachpb.CONSISTENT || !ba.IsSingleRequest() {
+		return readCacheKey{}, false
+	}
+	get, ok := ba.Requests[0].GetInner().(*roachpb.GetRequest)
+	if !ok {
+		return readCacheKey{}, false
+	}
+	key := readCacheKey{key: string(get.Key), ts: ba.Timestamp, leaseSeq: leaseSeq}
+	if ba.Txn != nil {
+		if ba.Txn.Sequence != 0 || get.Sequence != 0 {
+			return readCacheKey{}, false
+		}
+		key.maxTS = ba.Txn.MaxTimestamp
+	}
+	return key, true
+}
+
+// get returns a response to the batch from the cache, if present.
+func (c *replicaReadCache) get(key readCacheKey, ba *roachpb.BatchRequest) *roachpb.BatchResponse {
+	c.mu.Lock()
+	v, ok := c.mu.entries[key]
+	c.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	br := ba.CreateReply()
+	if v != nil {
+		value := *v
+		value.RawBytes = append([]byte(nil), v.RawBytes...)
+		br.Responses[0].GetGet().Value = &value
+	}
+	br.Txn = ba.Txn
+	br.Timestamp.Forward(ba.Timestamp)
+	return br
+}
+
+// add caches the response to the batch identified by key.
+func (c *replicaReadCache) add(key readCacheKey, br *roachpb.BatchResponse) {
+	resp := br.Responses[0].GetGet()
+	var v *roachpb.Value
+	if resp.Value != nil {
+		if len(resp.Value.RawBytes) > readCacheMaxValueBytes {
+			return
+		}
+		value := *resp.Value
+		value.RawBytes = append([]byte(nil), resp.Value.RawBytes...)
+		v = &value
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.mu.entries == nil {
+		c.mu.entries = make(map[readCacheKey]*roachpb.Value)
+	}
+	if _, ok := c.mu.entries[key]; !ok && len(c.mu.entries) >= readCacheMaxEntries {
+		// Evict an arbitrary entry.
+		for k := range c.mu.entries {
+			delete(c.mu.entries, k)
+			break
+		}
+	}
+	c.mu.entries[key] = v
+}
+
+// clear removes all entries. It is called whenever a command is applied to the
+// replica or its state is replaced by a snapshot.
+func (c *replicaReadCache) clear() {
+	c.mu.Lock()
+	if len(c.mu.entries) > 0 {
+		c.mu.entries = nil
+	}
+	c.mu.Unlock()
+}
@@ -0,0 +1,94 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+)
+
+func TestReplicaReadCache(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc := testContext{}
+	tc.Start(t, stopper)
+	readCacheEnabled.Override(&tc.store.cfg.Settings.SV, true)
+
+	key := roachpb.Key("a")
+	put := func(value string) {
+		t.Helper()
+		pArgs := putArgs(key, []byte(value))
+		if _, pErr := tc.SendWrapped(&pArgs); pErr != nil {
+			t.Fatal(pErr)
+		}
+	}
+	get := func(ts hlc.Timestamp, expValue string) {
+		t.Helper()
+		gArgs := getArgs(key)
+		resp, pErr := tc.SendWrappedWith(roachpb.Header{Timestamp: ts}, &gArgs)
+		if pErr != nil {
+			t.Fatal(pErr)
+		}
+		value := resp.(*roachpb.GetResponse).Value
+		if value == nil {
+			t.Fatalf("expected %q, found no value", expValue)
+		}
+		if b, err := value.GetBytes(); err != nil {
+			t.Fatal(err)
+		} else if string(b) != expValue {
+			t.Fatalf("expected %q, got %q", expValue, b)
+		}
+	}
+	checkMetrics := func(expHits, expMisses int64) {
+		t.Helper()
+		if hits := tc.store.metrics.ReadCacheHits.Count(); hits != expHits {
+			t.Fatalf("expected %d hits, got %d", expHits, hits)
+		}
+		if misses := tc.store.metrics.ReadCacheMisses.Count(); misses != expMisses {
+			t.Fatalf("expected %d misses, got %d", expMisses, misses)
+		}
+	}
+
+	put("1")
+	ts := tc.Clock().Now()
+	get(ts, "1")
+	checkMetrics(0, 1)
+	get(ts, "1")
+	checkMetrics(1, 1)
+
+	// A read at a different timestamp doesn't hit the cache.
+	get(ts.Next(), "1")
+	checkMetrics(1, 2)
+
+	// Applying a write clears the cache, although the read at the old timestamp
+	// still returns the old value.
+	put("2")
+	get(ts, "1")
+	checkMetrics(1, 3)
+	get(tc.Clock().Now(), "2")
+	checkMetrics(1, 4)
+
+	// Nothing is cached while the cache is disabled.
+	readCacheEnabled.Override(&tc.store.cfg.Settings.SV, false)
+	get(ts, "1")
+	checkMetrics(1, 4)
+}
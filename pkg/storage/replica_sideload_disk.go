@@ -19,21 +19,22 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/limit"
 	"github.com/pkg/errors"
-	"golang.org/x/time/rate"
 )
 
 var _ SideloadStorage = &diskSideloadStorage{}
 
 type diskSideloadStorage struct {
 	st         *cluster.Settings
-	limiter    *rate.Limiter
+	limiter    *limit.RateLimiter
 	dir        string
 	dirCreated bool
 	eng        engine.Engine
@@ -65,8 +66,10 @@ func sideloadedPath(baseDir string, rangeID roachpb.RangeID) string {
 	)
 }
 
-func exists(path string) (bool, error) {
-	_, err := os.Stat(path)
+// exists returns whether the file or directory at the given path exists in the
+// given engine's env.
+func exists(eng engine.FS, path string) (bool, error) {
+	_, err := eng.FileSize(path)
 	if err == nil {
 		return true, nil
 	}
@@ -84,7 +87,11 @@ func exists(path string) (bool, error) {
 // The method is aware of the "new" naming scheme that is not dependent on the
 // replicaID (see sideloadedPath) and will not touch them.
 func moveSideloadedData(
-	prevSideloaded SideloadStorage, base string, rangeID roachpb.RangeID, replicaID roachpb.ReplicaID,
+	prevSideloaded SideloadStorage,
+	base string,
+	rangeID roachpb.RangeID,
+	replicaID roachpb.ReplicaID,
+	eng engine.Engine,
 ) error {
 	if prevSideloaded == nil || prevSideloaded.Dir() == "" {
 		// No storage or in-memory storage.
@@ -101,7 +108,7 @@ func moveSideloadedData(
 	// period post the upgrade from 2.1). See the migration notes on the cluster
 	// associated version VersionSideloadedStorageNoReplicaID for details on
 	// when it is actually safe to remove this.
-	ex, err := exists(prevSideloadedDir)
+	ex, err := exists(eng, prevSideloadedDir)
 	if err != nil {
 		return errors.Wrap(err, "looking up previous sideloaded directory")
 	}
@@ -115,7 +122,7 @@ func moveSideloadedData(
 	// equality above will always hold.
 	_ = cluster.VersionSideloadedStorageNoReplicaID
 
-	if err := os.Rename(prevSideloadedDir, deprecatedSideloadedPath(base, rangeID, replicaID)); err != nil {
+	if err := eng.RenameFile(prevSideloadedDir, deprecatedSideloadedPath(base, rangeID, replicaID)); err != nil {
 		return errors.Wrap(err, "moving sideloaded directory")
 	}
 	return nil
@@ -126,7 +133,7 @@ func newDiskSideloadStorage(
 	rangeID roachpb.RangeID,
 	replicaID roachpb.ReplicaID,
 	baseDir string,
-	limiter *rate.Limiter,
+	limiter *limit.RateLimiter,
 	eng engine.Engine,
 ) (*diskSideloadStorage, error) {
 	path := deprecatedSideloadedPath(baseDir, rangeID, replicaID)
@@ -146,15 +153,15 @@ func newDiskSideloadStorage(
 		// ns on my laptop, but only around 2.2k ns on the gceworker. Still,
 		// even on the laptop, 50k replicas would only add 1.2s which is also
 		// acceptable given that it'll happen only once.
-		exists, err := exists(path)
+		exists, err := exists(eng, path)
 		if err != nil {
 			return nil, errors.Wrap(err, "checking pre-migration sideloaded directory")
 		}
 		if exists {
-			if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+			if err := eng.MkdirAll(filepath.Dir(newPath)); err != nil {
 				return nil, errors.Wrap(err, "creating migrated sideloaded directory")
 			}
-			if err := os.Rename(path, newPath); err != nil {
+			if err := eng.RenameFile(path, newPath); err != nil {
 				return nil, errors.Wrap(err, "while migrating sideloaded directory")
 			}
 		}
@@ -171,7 +178,7 @@ func newDiskSideloadStorage(
 }
 
 func (ss *diskSideloadStorage) createDir() error {
-	err := os.MkdirAll(ss.dir, 0755)
+	err := ss.eng.MkdirAll(ss.dir)
 	ss.dirCreated = ss.dirCreated || err == nil
 	return err
 }
@@ -228,19 +235,14 @@ func (ss *diskSideloadStorage) Purge(ctx context.Context, index, term uint64) (i
 }
 
 func (ss *diskSideloadStorage) fileSize(filename string) (int64, error) {
-	// TODO(tschottdorf): this should all be done through the env. As written,
-	// the sizes returned here will be wrong if encryption is on. We want the
-	// size of the unencrypted payload.
-	//
-	// See #31913.
-	info, err := os.Stat(filename)
+	size, err := ss.eng.FileSize(filename)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return 0, errSideloadedFileNotFound
 		}
 		return 0, err
 	}
-	return info.Size(), nil
+	return size, nil
 }
 
 func (ss *diskSideloadStorage) purgeFile(ctx context.Context, filename string) (int64, error) {
@@ -292,7 +294,7 @@ func (ss *diskSideloadStorage) TruncateTo(
 	if deletedAll {
 		// The directory may not exist, or it may exist and have been empty.
 		// Not worth trying to figure out which one, just try to delete.
-		err := os.Remove(ss.dir)
+		err := ss.eng.DeleteDir(ss.dir)
 		if !os.IsNotExist(err) {
 			return bytesFreed, 0, errors.Wrapf(err, "while purging %q", ss.dir)
 		}
@@ -303,15 +305,16 @@ func (ss *diskSideloadStorage) TruncateTo(
 func (ss *diskSideloadStorage) forEach(
 	ctx context.Context, visit func(index uint64, filename string) error,
 ) error {
-	matches, err := filepath.Glob(filepath.Join(ss.dir, "i*.t*"))
+	names, err := ss.eng.ListDir(ss.dir)
 	if err != nil {
 		return err
 	}
-	for _, match := range matches {
-		base := filepath.Base(match)
-		if len(base) < 1 || base[0] != 'i' {
+	sort.Strings(names)
+	for _, base := range names {
+		if ok, _ := filepath.Match("i*.t*", base); !ok {
 			continue
 		}
+		match := filepath.Join(ss.dir, base)
 		base = base[1:]
 		upToDot := strings.SplitN(base, ".", 2)
 		logIdx, err := strconv.ParseUint(upToDot[0], 10, 64)
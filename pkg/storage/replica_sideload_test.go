@@ -40,6 +40,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/limit"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
@@ -95,7 +96,7 @@ func TestSideloadingSideloadedStorage(t *testing.T) {
 		maker := func(
 			s *cluster.Settings, rangeID roachpb.RangeID, rep roachpb.ReplicaID, name string, eng engine.Engine,
 		) (SideloadStorage, error) {
-			return newDiskSideloadStorage(s, rangeID, rep, name, rate.NewLimiter(rate.Inf, math.MaxInt64), eng)
+			return newDiskSideloadStorage(s, rangeID, rep, name, limit.NewRateLimiter("test", rate.Inf, math.MaxInt64), eng)
 		}
 		testSideloadingSideloadedStorage(t, maker)
 	})
@@ -409,12 +410,12 @@ func TestSideloadedStorageReplicaIDMigration(t *testing.T) {
 	defer cleanup()
 	defer cache.Release()
 	defer eng.Close()
-	limiter := rate.NewLimiter(rate.Inf, 1<<9)
+	limiter := limit.NewRateLimiter("test", rate.Inf, 1<<9)
 
 	var ss SideloadStorage
 	create := func(st *cluster.Settings, replicaID roachpb.ReplicaID) *diskSideloadStorage {
 		t.Helper()
-		if err := moveSideloadedData(ss, dir, rangeID, replicaID); err != nil {
+		if err := moveSideloadedData(ss, dir, rangeID, replicaID, eng); err != nil {
 			t.Fatal(err)
 		}
 		ss, err := newDiskSideloadStorage(st, rangeID, replicaID, dir, limiter, eng)
@@ -895,6 +896,7 @@ func TestRaftSSTableSideloadingSnapshot(t *testing.T) {
 			os,
 			tc.repl.store.Engine().NewBatch,
 			func() {},
+			tc.store.limiters.SnapshotSendRate,
 		); err != nil {
 			t.Fatal(err)
 		}
@@ -1017,6 +1019,7 @@ func TestRaftSSTableSideloadingSnapshot(t *testing.T) {
 			failingOS,
 			tc.repl.store.Engine().NewBatch,
 			func() {},
+			tc.store.limiters.SnapshotSendRate,
 		)
 		if _, ok := errors.Cause(err).(*errMustRetrySnapshotDueToTruncation); !ok {
 			t.Fatal(err)
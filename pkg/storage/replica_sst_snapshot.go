@@ -0,0 +1,308 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/rditer"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/pkg/errors"
+)
+
+// snapshotIngestEnabled controls whether large snapshots are applied by
+// ingesting SSTables. Writing the millions of keys of a large snapshot through
+// the memtable stalls the foreground writes to the store while the memtable is
+// flushed, which ingestion avoids.
+var snapshotIngestEnabled = settings.RegisterBoolSetting(
+	"kv.snapshot_ingest.enabled",
+	"if enabled, large snapshots are applied by ingesting SSTables instead of writing their data through the memtable",
+	false,
+)
+
+// snapshotIngestThreshold is the encoded size of a snapshot past which it is
+// ingested. Ingesting SSTables which overlap the memtable flushes it, which
+// costs more than writing small snapshots through it.
+const snapshotIngestThreshold = 1 << 20 // 1MiB
+
+// snapshotSSTTargetSize is the size of the data at which the SSTable being
+// built for a snapshot is written out and the next one started. Only one
+// SSTable is held in memory at a time.
+const snapshotSSTTargetSize = 16 << 20 // 16MiB
+
+// snapshotSSTs are the SSTables to which the data of a large snapshot is
+// written before the snapshot is handed to its replica.
+type snapshotSSTs struct {
+	// paths are the SSTable files, which are moved into the engine when they
+	// are ingested and removed once the snapshot has been processed otherwise.
+	paths []string
+	// metadata is the batch repr of the writes of the snapshot to the key
+	// ranges of the replica which aren't ingested.
+	metadata []byte
+}
+
+// snapshotIngestKeyRanges returns the key ranges of a replica whose snapshot
+// data is ingested: the lock table and global keys. The range-ID local keys
+// are written through a batch instead, as the replica's Raft log and
+// HardState keep them in the memtable and an overlapping SSTable would flush
+// it. So are the range-local keys, so that the range descriptor is only
+// written together with the replica's state.
+func snapshotIngestKeyRanges(desc *roachpb.RangeDescriptor) []rditer.KeyRange {
+	keyRanges := rditer.MakeAllKeyRanges(desc)[2:]
+	sort.Slice(keyRanges, func(i, j int) bool {
+		return keyRanges[i].Start.Less(keyRanges[j].Start)
+	})
+	return keyRanges
+}
+
+// snapshotMetadataKeyRanges returns the key ranges of a replica whose snapshot
+// data is written through a batch when the rest is ingested. See
+// snapshotIngestKeyRanges.
+func snapshotMetadataKeyRanges(desc *roachpb.RangeDescriptor) []rditer.KeyRange {
+	return rditer.MakeAllKeyRanges(desc)[:2]
+}
+
+// maybeWriteSnapshotSSTs writes the data of a large snapshot to SSTables if
+// snapshot ingestion is enabled. This happens as the snapshot is received,
+// before the replica's raftMu is acquired to apply it, as does the delay
+// which ingestion is subject to while the engine is unhealthy. The caller is
+// responsible for removing the SSTables once the snapshot is processed.
+func (s *Store) maybeWriteSnapshotSSTs(ctx context.Context, inSnap *IncomingSnapshot) error {
+	var size int
+	for _, b := range inSnap.Batches {
+		size += len(b)
+	}
+	for _, e := range inSnap.LogEntries {
+		size += len(e)
+	}
+	if size < snapshotIngestThreshold || !snapshotIngestEnabled.Get(&s.cfg.Settings.SV) {
+		return nil
+	}
+
+	desc := inSnap.State.Desc
+	metadata := s.engine.NewWriteOnlyBatch()
+	defer metadata.Close()
+	kvs, err := splitSnapshotBatches(desc, inSnap.Batches, metadata)
+	if err != nil {
+		return err
+	}
+	paths, err := writeSnapshotSSTs(s.engine, desc, inSnap.SnapUUID, kvs, snapshotSSTTargetSize)
+	if err != nil {
+		return err
+	}
+	inSnap.ssts = &snapshotSSTs{paths: paths, metadata: metadata.Repr()}
+	s.engine.PreIngestDelay(ctx)
+	return nil
+}
+
+// removeSnapshotSSTs removes the given SSTables written for a snapshot.
+// SSTables which were ingested have already been moved into the engine.
+func removeSnapshotSSTs(eng engine.Engine, paths []string) {
+	for _, path := range paths {
+		_ = eng.DeleteFile(path)
+	}
+}
+
+// splitSnapshotBatches sorts the writes of the batches of a snapshot of the
+// given range into those to the key ranges which are ingested and the others,
+// which are added to the given writer. The former are returned ordered by
+// key, keeping only the last write to each key. An error is returned if the
+// batches hold anything but puts to the key ranges of the range.
+func splitSnapshotBatches(
+	desc *roachpb.RangeDescriptor, reprs [][]byte, metadata engine.Writer,
+) ([]engine.MVCCKeyValue, error) {
+	ingestKeyRanges := snapshotIngestKeyRanges(desc)
+	metadataKeyRanges := snapshotMetadataKeyRanges(desc)
+	var kvs []engine.MVCCKeyValue
+	for _, repr := range reprs {
+		reader, err := engine.NewRocksDBBatchReader(repr)
+		if err != nil {
+			return nil, err
+		}
+		for reader.Next() {
+			if typ := reader.BatchType(); typ != engine.BatchTypeValue {
+				return nil, errors.Errorf("unexpected batch entry of type %d in snapshot", typ)
+			}
+			key, err := reader.MVCCKey()
+			if err != nil {
+				return nil, err
+			}
+			switch {
+			case keyRangesContain(ingestKeyRanges, key):
+				kvs = append(kvs, engine.MVCCKeyValue{Key: key, Value: reader.Value()})
+			case keyRangesContain(metadataKeyRanges, key):
+				if err := metadata.Put(key, reader.Value()); err != nil {
+					return nil, err
+				}
+			default:
+				return nil, errors.Errorf("snapshot key %s outside of the key ranges of r%d",
+					key, desc.RangeID)
+			}
+		}
+		if err := reader.Error(); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(kvs, func(i, j int) bool {
+		return kvs[i].Key.Less(kvs[j].Key)
+	})
+	deduped := kvs[:0]
+	for _, kv := range kvs {
+		if n := len(deduped); n > 0 && deduped[n-1].Key.Equal(kv.Key) {
+			deduped[n-1] = kv
+			continue
+		}
+		deduped = append(deduped, kv)
+	}
+	return deduped, nil
+}
+
+// keyRangesContain returns whether the key falls in one of the key ranges.
+func keyRangesContain(keyRanges []rditer.KeyRange, key engine.MVCCKey) bool {
+	for _, keyRange := range keyRanges {
+		if !key.Less(keyRange.Start) && key.Less(keyRange.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeSnapshotSSTs writes the given writes, which must be ordered by key and
+// fall in the ingested key ranges of the range, to SSTables in the auxiliary
+// directory of the engine and returns their paths. Each ingested key range is
+// covered by one or more SSTables, each holding a range deletion tombstone
+// which clears the previous data of the span it covers and the writes to that
+// span, which the tombstone doesn't cover. A key range is split across
+// SSTables between keys once the data of an SSTable reaches targetSize. The
+// spans of the SSTables don't overlap, so they can be ingested together.
+func writeSnapshotSSTs(
+	eng engine.Engine,
+	desc *roachpb.RangeDescriptor,
+	snapUUID uuid.UUID,
+	kvs []engine.MVCCKeyValue,
+	targetSize int64,
+) (paths []string, err error) {
+	defer func() {
+		if err != nil {
+			removeSnapshotSSTs(eng, paths)
+			paths = nil
+		}
+	}()
+
+	var sst *engine.RocksDBSstFileWriter
+	defer func() {
+		if sst != nil {
+			sst.Close()
+		}
+	}()
+	newSST := func() error {
+		w, err := engine.MakeRocksDBSstFileWriter()
+		if err != nil {
+			return err
+		}
+		sst = &w
+		return nil
+	}
+	var start engine.MVCCKey
+	// finish writes out the SSTable being built, covering [start, end).
+	finish := func(end engine.MVCCKey) error {
+		if err := sst.ClearRange(start, end); err != nil {
+			return err
+		}
+		data, err := sst.Finish()
+		if err != nil {
+			return err
+		}
+		sst.Close()
+		sst = nil
+		path := filepath.Join(eng.GetAuxiliaryDir(),
+			fmt.Sprintf("snapshot.r%d.%s.%d.sst", desc.RangeID, snapUUID, len(paths)))
+		paths = append(paths, path)
+		if err := eng.WriteFile(path, data); err != nil {
+			return errors.Wrapf(err, "while writing snapshot SSTable %s", path)
+		}
+		start = end
+		return nil
+	}
+
+	for _, keyRange := range snapshotIngestKeyRanges(desc) {
+		if len(kvs) > 0 && kvs[0].Key.Less(keyRange.Start) {
+			return paths, errors.Errorf("snapshot key %s outside of the ingested key ranges of r%d",
+				kvs[0].Key, desc.RangeID)
+		}
+		start = keyRange.Start
+		if err := newSST(); err != nil {
+			return paths, err
+		}
+		var last roachpb.Key
+		for ; len(kvs) > 0 && kvs[0].Key.Less(keyRange.End); kvs = kvs[1:] {
+			kv := kvs[0]
+			// Only split between keys, never between the versions of one key.
+			if sst.DataSize >= targetSize && !kv.Key.Key.Equal(last) {
+				if err := finish(engine.MakeMVCCMetadataKey(kv.Key.Key)); err != nil {
+					return paths, err
+				}
+				if err := newSST(); err != nil {
+					return paths, err
+				}
+			}
+			if err := sst.Add(kv); err != nil {
+				return paths, errors.Wrapf(err, "while adding key %s", kv.Key)
+			}
+			last = kv.Key.Key
+		}
+		if err := finish(keyRange.End); err != nil {
+			return paths, err
+		}
+	}
+	if len(kvs) > 0 {
+		return paths, errors.Errorf("snapshot key %s outside of the ingested key ranges of r%d",
+			kvs[0].Key, desc.RangeID)
+	}
+	return paths, nil
+}
+
+// shouldIngestSnapshot returns whether the SSTables written for a snapshot are
+// to be ingested. Snapshots subsuming other replicas are always written
+// through a batch, as the destruction of the subsumed replicas isn't expressed
+// as SSTables. So are snapshots to initialized replicas: the SSTables are
+// ingested separately from the commit of the batch holding the replica's new
+// state, and a crash in between must not leave an initialized replica with
+// data that doesn't match its state.
+func (r *Replica) shouldIngestSnapshot(inSnap IncomingSnapshot, subsumedRepls []*Replica) bool {
+	return inSnap.ssts != nil && len(subsumedRepls) == 0 && !r.IsInitialized()
+}
+
+// ingestSnapshotSSTs ingests the SSTables written for a snapshot in a single
+// call, atomically replacing the data of the ingested key ranges.
+func (r *Replica) ingestSnapshotSSTs(ctx context.Context, ssts *snapshotSSTs) error {
+	// See addSSTablePreApply.
+	st := r.store.cfg.Settings
+	canSkipSeqNo := st.Version.IsActive(cluster.VersionUnreplicatedRaftTruncatedState)
+	if err := r.store.Engine().IngestExternalFiles(
+		ctx, ssts.paths, canSkipSeqNo, true, /* allowFileModifications */
+	); err != nil {
+		return errors.Wrap(err, "while ingesting snapshot SSTables")
+	}
+	return nil
+}
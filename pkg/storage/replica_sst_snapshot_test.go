@@ -0,0 +1,188 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+)
+
+func TestSplitSnapshotBatches(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	eng := engine.NewInMem(roachpb.Attributes{}, 1<<20)
+	defer eng.Close()
+	batch := eng.NewWriteOnlyBatch()
+	defer batch.Close()
+
+	desc := &roachpb.RangeDescriptor{
+		RangeID:  999,
+		StartKey: roachpb.RKey("a"),
+		EndKey:   roachpb.RKey("c"),
+	}
+	ts := hlc.Timestamp{WallTime: 1}
+	for _, kv := range []engine.MVCCKeyValue{
+		{Key: engine.MVCCKey{Key: roachpb.Key("b"), Timestamp: ts}, Value: []byte("1")},
+		{Key: engine.MakeMVCCMetadataKey(roachpb.Key("b")), Value: []byte("2")},
+		{Key: engine.MakeMVCCMetadataKey(keys.RaftTruncatedStateKey(desc.RangeID)), Value: []byte("3")},
+		{Key: engine.MakeMVCCMetadataKey(roachpb.Key("a")), Value: []byte("4")},
+		{Key: engine.MVCCKey{Key: roachpb.Key("b"), Timestamp: ts}, Value: []byte("5")},
+	} {
+		if err := batch.Put(kv.Key, kv.Value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	metadata := eng.NewWriteOnlyBatch()
+	defer metadata.Close()
+	kvs, err := splitSnapshotBatches(desc, [][]byte{batch.Repr()}, metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The ingested writes are ordered by key, and the last write to a key wins.
+	expected := []engine.MVCCKeyValue{
+		{Key: engine.MakeMVCCMetadataKey(roachpb.Key("a")), Value: []byte("4")},
+		{Key: engine.MakeMVCCMetadataKey(roachpb.Key("b")), Value: []byte("2")},
+		{Key: engine.MVCCKey{Key: roachpb.Key("b"), Timestamp: ts}, Value: []byte("5")},
+	}
+	if len(kvs) != len(expected) {
+		t.Fatalf("expected %d writes, got %+v", len(expected), kvs)
+	}
+	for i, kv := range kvs {
+		if !kv.Key.Equal(expected[i].Key) || !bytes.Equal(kv.Value, expected[i].Value) {
+			t.Errorf("%d: expected %s=%q, got %s=%q",
+				i, expected[i].Key, expected[i].Value, kv.Key, kv.Value)
+		}
+	}
+	// The write to the range-ID local key goes through the batch.
+	reader, err := engine.NewRocksDBBatchReader(metadata.Repr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reader.Count() != 1 {
+		t.Fatalf("expected a single metadata write, got %d", reader.Count())
+	}
+
+	outside := eng.NewWriteOnlyBatch()
+	defer outside.Close()
+	if err := outside.Put(engine.MakeMVCCMetadataKey(roachpb.Key("d")), []byte("6")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := splitSnapshotBatches(
+		desc, [][]byte{outside.Repr()}, metadata,
+	); !testutils.IsError(err, "outside of the key ranges") {
+		t.Fatalf("expected an error for a key outside of the range, got %v", err)
+	}
+
+	if err := batch.Clear(engine.MakeMVCCMetadataKey(roachpb.Key("c"))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := splitSnapshotBatches(
+		desc, [][]byte{batch.Repr()}, metadata,
+	); !testutils.IsError(err, "unexpected batch entry") {
+		t.Fatalf("expected an error for a deletion, got %v", err)
+	}
+}
+
+func TestWriteSnapshotSSTs(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc.Start(t, stopper)
+
+	// A range distinct from the one of the test replica, whose data is laid
+	// down directly.
+	desc := &roachpb.RangeDescriptor{
+		RangeID:  999,
+		StartKey: roachpb.RKey("a"),
+		EndKey:   roachpb.RKey("c"),
+	}
+	eng := tc.store.Engine()
+	put := func(key roachpb.Key, value string) {
+		t.Helper()
+		if err := eng.Put(engine.MakeMVCCMetadataKey(key), []byte(value)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	put(roachpb.Key("a1"), "old")
+	put(roachpb.Key("d"), "outside")
+	put(keys.RaftHardStateKey(desc.RangeID), "old")
+
+	var kvs []engine.MVCCKeyValue
+	for _, key := range []string{"a2", "b", "b1"} {
+		kvs = append(kvs, engine.MVCCKeyValue{
+			Key: engine.MakeMVCCMetadataKey(roachpb.Key(key)), Value: []byte("new"),
+		})
+	}
+	// With a target size of a single byte, every key of the global key range
+	// ends up in its own SSTable, next to the SSTables clearing the two lock
+	// table key ranges.
+	paths, err := writeSnapshotSSTs(eng, desc, uuid.MakeV4(), kvs, 1 /* targetSize */)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 5 {
+		t.Fatalf("expected 5 SSTables, got %v", paths)
+	}
+	if err := tc.repl.ingestSnapshotSSTs(context.TODO(), &snapshotSSTs{paths: paths}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		key      roachpb.Key
+		expected string
+	}{
+		{roachpb.Key("a1"), ""},
+		{roachpb.Key("a2"), "new"},
+		{roachpb.Key("b"), "new"},
+		{roachpb.Key("b1"), "new"},
+		{roachpb.Key("d"), "outside"},
+		// The range-ID local keys aren't ingested.
+		{keys.RaftHardStateKey(desc.RangeID), "old"},
+	} {
+		val, err := eng.Get(engine.MakeMVCCMetadataKey(c.key))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(val) != c.expected {
+			t.Errorf("%s: expected %q, got %q", c.key, c.expected, val)
+		}
+	}
+
+	// Writes outside of the ingested key ranges are rejected, and the SSTables
+	// written so far are removed.
+	kvs = []engine.MVCCKeyValue{
+		{Key: engine.MakeMVCCMetadataKey(roachpb.Key("d")), Value: []byte("new")},
+	}
+	if paths, err := writeSnapshotSSTs(
+		eng, desc, uuid.MakeV4(), kvs, snapshotSSTTargetSize,
+	); !testutils.IsError(err, "outside of the ingested key ranges") {
+		t.Fatalf("expected an error for a key outside of the range, got %v", err)
+	} else if len(paths) != 0 {
+		t.Fatalf("expected no SSTables, got %v", paths)
+	}
+}
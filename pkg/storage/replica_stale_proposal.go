@@ -0,0 +1,86 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// StaleProposalPolicyMode controls what happens to writes which were
+// evaluated and proposed under a lease which was transferred away before they
+// applied. Such writes are rejected below Raft by the lease sequence check
+// (see checkForcedErrLocked) and are never applied.
+type StaleProposalPolicyMode int64
+
+const (
+	// StaleProposalReject returns a NotLeaseHolderError to the client, which
+	// retries the write on the new leaseholder.
+	StaleProposalReject StaleProposalPolicyMode = iota
+	// StaleProposalForward holds on to the client and forwards the write to the
+	// new leaseholder, which re-evaluates it. This saves the client a round
+	// trip and a refresh of its range cache for every write caught by a lease
+	// transfer, which smooths out the latency of write-heavy workloads.
+	StaleProposalForward
+)
+
+// StaleProposalPolicy is the policy applied to writes proposed under a lease
+// which was transferred before they applied.
+var StaleProposalPolicy = settings.RegisterEnumSetting(
+	"kv.lease_transfer.stale_proposal_policy",
+	"what to do with writes proposed under a lease which was transferred before they applied: "+
+		"reject returns a NotLeaseHolderError to the client, forward re-evaluates them on the new leaseholder",
+	"reject",
+	map[int64]string{
+		int64(StaleProposalReject):  "reject",
+		int64(StaleProposalForward): "forward",
+	},
+)
+
+// maybeForwardStaleProposal forwards a write whose proposal under the given
+// lease was rejected with pErr to the new leaseholder, if the proposal was
+// rejected because the lease was transferred before it applied and
+// StaleProposalPolicy says so. The batch must be the one received by the
+// replica, before any modification made during its evaluation. The returned
+// boolean is false if the write wasn't forwarded, in which case pErr is to be
+// returned to the client.
+func (r *Replica) maybeForwardStaleProposal(
+	ctx context.Context, ba roachpb.BatchRequest, lease roachpb.Lease, pErr *roachpb.Error,
+) (*roachpb.BatchResponse, *roachpb.Error, bool) {
+	if _, ok := pErr.GetDetail().(*roachpb.NotLeaseHolderError); !ok {
+		return nil, nil, false
+	}
+	if StaleProposalPolicyMode(StaleProposalPolicy.Get(&r.store.cfg.Settings.SV)) != StaleProposalForward {
+		return nil, nil, false
+	}
+	r.mu.RLock()
+	curLease := *r.mu.state.Lease
+	r.mu.RUnlock()
+	// Only proposals rejected because the lease sequence moved on since they
+	// were proposed are known not to have applied. If the lease came back to
+	// this replica, the client might as well retry here.
+	if curLease.Sequence == lease.Sequence || curLease.OwnedBy(r.store.StoreID()) {
+		return nil, nil, false
+	}
+
+	log.VEventf(ctx, 2, "forwarding write proposed under lease #%d to new leaseholder %s",
+		lease.Sequence, curLease.Replica)
+	r.store.metrics.RangeStaleProposalsForwarded.Inc(1)
+	br, fErr := r.store.DB().GetFactory().NonTransactionalSender().Send(ctx, ba)
+	return br, fErr, true
+}
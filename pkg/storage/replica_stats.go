@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
@@ -228,3 +229,68 @@ func (rs *replicaStats) resetRequestCounts() {
 	rs.mu.lastRotate = timeutil.Unix(0, rs.clock.PhysicalNow())
 	rs.mu.lastReset = rs.mu.lastRotate
 }
+
+// summarizeRequestCounts adds the request counts to the summary handed over
+// to the incoming leaseholder of a lease transfer.
+func (rs *replicaStats) summarizeRequestCounts(summary *storagepb.LeaseTransferSummary) {
+	now := timeutil.Unix(0, rs.clock.PhysicalNow())
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.maybeRotateLocked(now)
+
+	for i := range rs.mu.requests {
+		// We have to add len(rs.mu.requests) to the numerator to avoid getting a
+		// negative result from the modulus operation when rs.mu.idx is small.
+		requestsIdx := (rs.mu.idx + len(rs.mu.requests) - i) % len(rs.mu.requests)
+		cur := rs.mu.requests[requestsIdx]
+		if cur == nil {
+			// The windows in use since the last reset are contiguous.
+			break
+		}
+		summary.RequestWindows++
+		for locality, count := range cur {
+			summary.RequestCounts = append(summary.RequestCounts, storagepb.LocalityRequestCount{
+				Window:   int32(i),
+				Locality: locality,
+				Count:    count,
+			})
+		}
+	}
+	summary.SinceRotateNanos = now.Sub(rs.mu.lastRotate).Nanoseconds()
+	summary.SinceResetNanos = now.Sub(rs.mu.lastReset).Nanoseconds()
+}
+
+// installRequestCounts replaces the request counts by the ones handed over by
+// the outgoing leaseholder of a lease transfer, as if the requests had been
+// received by this replica. This saves the incoming leaseholder from waiting
+// for MinStatsDuration before load-based decisions can be made again.
+func (rs *replicaStats) installRequestCounts(summary *storagepb.LeaseTransferSummary) {
+	now := timeutil.Unix(0, rs.clock.PhysicalNow())
+
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	n := len(rs.mu.requests)
+	windows := int(summary.RequestWindows)
+	if windows < 1 {
+		windows = 1
+	} else if windows > n {
+		windows = n
+	}
+	for i := range rs.mu.requests {
+		rs.mu.requests[(rs.mu.idx+n-i)%n] = nil
+		if i < windows {
+			rs.mu.requests[(rs.mu.idx+n-i)%n] = make(perLocalityCounts)
+		}
+	}
+	for _, c := range summary.RequestCounts {
+		if int(c.Window) < 0 || int(c.Window) >= windows {
+			continue
+		}
+		rs.mu.requests[(rs.mu.idx+n-int(c.Window))%n][c.Locality] += c.Count
+	}
+	rs.mu.lastRotate = now.Add(-time.Duration(summary.SinceRotateNanos))
+	rs.mu.lastReset = now.Add(-time.Duration(summary.SinceResetNanos))
+}
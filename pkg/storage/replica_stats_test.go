@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagepb"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/kr/pretty"
@@ -373,3 +374,54 @@ func TestReplicaStatsDecaySmoothing(t *testing.T) {
 		}
 	}
 }
+
+func TestReplicaStatsLeaseTransferSummary(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	manual := hlc.NewManualClock(123)
+	clock := hlc.NewClock(manual.UnixNano, time.Nanosecond)
+	awsLocalities := map[roachpb.NodeID]string{
+		1: "region=us-east-1,zone=us-east-1a",
+		2: "region=us-east-1,zone=us-east-1b",
+		3: "region=us-west-1,zone=us-west-1a",
+	}
+	getNodeLocality := func(nodeID roachpb.NodeID) string {
+		return awsLocalities[nodeID]
+	}
+	rs := newReplicaStats(clock, getNodeLocality)
+	other := newReplicaStats(clock, getNodeLocality)
+	for i := 0; i < 10; i++ {
+		other.record(1)
+	}
+	rs.record(1)
+	rs.record(2)
+	manual.Increment(int64(replStatsRotateInterval))
+	rs.record(2)
+	rs.record(3)
+	manual.Increment(int64(replStatsRotateInterval / 2))
+
+	var summary storagepb.LeaseTransferSummary
+	rs.summarizeRequestCounts(&summary)
+	if summary.RequestWindows != 2 {
+		t.Fatalf("expected 2 request windows, got %d", summary.RequestWindows)
+	}
+
+	// The stats installed from the summary match the original ones, regardless
+	// of the state of the stats they replace.
+	other.installRequestCounts(&summary)
+
+	expQPS, expDur := rs.avgQPS()
+	if qps, dur := other.avgQPS(); !floatsEqual(expQPS, qps) || expDur != dur {
+		t.Errorf("expected avgQPS() = %f, %s, got %f, %s", expQPS, expDur, qps, dur)
+	}
+	expCounts, expDur := rs.perLocalityDecayingQPS()
+	if counts, dur := other.perLocalityDecayingQPS(); !floatMapsEqual(expCounts, counts) || expDur != dur {
+		t.Errorf("expected perLocalityDecayingQPS() = %v, %s, got %v, %s", expCounts, expDur, counts, dur)
+	}
+
+	// Installing an empty summary amounts to a reset.
+	other.installRequestCounts(&storagepb.LeaseTransferSummary{})
+	if qps, dur := other.avgQPS(); qps != 0 || dur != 0 {
+		t.Errorf("expected avgQPS() = 0, 0s, got %f, %s", qps, dur)
+	}
+}
@@ -42,6 +42,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/storage/intentresolver"
+	"github.com/cockroachdb/cockroach/pkg/storage/metering"
 	"github.com/cockroachdb/cockroach/pkg/storage/rditer"
 	"github.com/cockroachdb/cockroach/pkg/storage/spanset"
 	"github.com/cockroachdb/cockroach/pkg/storage/stateloader"
@@ -59,6 +60,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/gogo/protobuf/proto"
@@ -188,7 +190,7 @@ func (tc *testContext) StartWithStoreConfig(t testing.TB, stopper *stop.Stopper,
 	config.TestingSetupZoneConfigHook(stopper)
 	if tc.gossip == nil {
 		rpcContext := rpc.NewContext(
-			cfg.AmbientCtx, &base.Config{Insecure: true}, cfg.Clock, stopper, &cfg.Settings.Version)
+			cfg.AmbientCtx, &base.Config{Insecure: true}, cfg.Clock, stopper, cfg.Settings)
 		server := rpc.NewServer(rpcContext) // never started
 		tc.gossip = gossip.NewTest(1, rpcContext, server, stopper, metric.NewRegistry(), cfg.DefaultZoneConfig)
 	}
@@ -3733,6 +3735,71 @@ func TestEndTransactionAfterHeartbeat(t *testing.T) {
 	})
 }
 
+// TestCoalescedHeartbeats verifies that HeartbeatTxn requests which name
+// their transaction can heartbeat different transactions from the same
+// non-transactional batch, and that a transaction which can't be heartbeat
+// is reported as aborted in its response instead of failing the batch.
+func TestCoalescedHeartbeats(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc.Start(t, stopper)
+
+	txn1 := newTransaction("txn1", roachpb.Key("a"), 1, tc.Clock())
+	txn2 := newTransaction("txn2", roachpb.Key("b"), 1, tc.Clock())
+	txn3 := newTransaction("txn3", roachpb.Key("c"), 1, tc.Clock())
+
+	// Roll back txn3 before it has a transaction record, which prevents the
+	// record from being created later.
+	et, etH := endTxnArgs(txn3, false /* commit */)
+	if _, pErr := tc.SendWrappedWith(etH, &et); pErr != nil {
+		t.Fatal(pErr)
+	}
+
+	heartbeat := func() []*roachpb.Transaction {
+		t.Helper()
+		now := tc.Clock().Now()
+		var ba roachpb.BatchRequest
+		for _, txn := range []*roachpb.Transaction{txn1, txn2, txn3} {
+			hb, _ := heartbeatArgs(txn, now)
+			hb.Txn = txn
+			ba.Add(&hb)
+		}
+		br, pErr := tc.Sender().Send(context.Background(), ba)
+		if pErr != nil {
+			t.Fatal(pErr)
+		}
+		var txns []*roachpb.Transaction
+		for i, ru := range br.Responses {
+			txn := ru.GetInner().(*roachpb.HeartbeatTxnResponse).Txn
+			if txn.Status == roachpb.PENDING && txn.LastHeartbeat != now {
+				t.Errorf("%d: expected heartbeat at %s, got %s", i, now, txn.LastHeartbeat)
+			}
+			txns = append(txns, txn)
+		}
+		return txns
+	}
+
+	// The first heartbeats create the transaction records of txn1 and txn2,
+	// the second ones update them.
+	for i := 0; i < 2; i++ {
+		for j, txn := range heartbeat() {
+			exp := []*roachpb.Transaction{txn1, txn2, txn3}[j]
+			if txn.ID != exp.ID {
+				t.Errorf("%d: expected transaction %s, got %s", j, exp.ID, txn.ID)
+			}
+			expStatus := roachpb.PENDING
+			if exp == txn3 {
+				expStatus = roachpb.ABORTED
+			}
+			if txn.Status != expStatus {
+				t.Errorf("%d: expected %s, got %s", j, expStatus, txn.Status)
+			}
+		}
+	}
+}
+
 // TestEndTransactionWithPushedTimestamp verifies that txn can be
 // ended (both commit or abort) correctly when the commit timestamp is
 // greater than the transaction timestamp, depending on the isolation
@@ -5962,6 +6029,69 @@ func TestReplicaCorruption(t *testing.T) {
 	}
 }
 
+// TestReplicaInvariantViolation verifies that an unhandled field in the
+// LocalEvalResult of an applied command is handled according to
+// kv.replica.invariant_violation_action.
+func TestReplicaInvariantViolation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var exitStatus int
+	log.SetExitFunc(true /* hideStack */, func(i int) {
+		exitStatus = i
+	})
+	defer log.ResetExitFunc()
+
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc.Start(t, stopper)
+	ctx := context.Background()
+	sv := &tc.store.cfg.Settings.SV
+	metrics := tc.store.metrics
+
+	// By default, violations are fatal.
+	tc.repl.reportInvariantViolation(ctx, "violation %d", 1)
+	if exitStatus != 255 {
+		t.Fatalf("unexpected exit status %d", exitStatus)
+	}
+
+	exitStatus = 0
+	invariantViolationActionSetting.Override(sv, int64(invariantViolationLog))
+	tc.repl.reportInvariantViolation(ctx, "violation %d", 2)
+	if exitStatus != 0 {
+		t.Fatalf("unexpected exit status %d", exitStatus)
+	}
+	if _, err := tc.repl.IsDestroyed(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	args := putArgs(roachpb.Key("a"), []byte("value"))
+	if _, pErr := tc.SendWrapped(&args); pErr != nil {
+		t.Fatal(pErr)
+	}
+
+	invariantViolationActionSetting.Override(sv, int64(invariantViolationQuarantine))
+	tc.repl.reportInvariantViolation(ctx, "violation %d", 3)
+	tc.repl.reportInvariantViolation(ctx, "violation %d", 4)
+	if exitStatus != 0 {
+		t.Fatalf("unexpected exit status %d", exitStatus)
+	}
+	if reason, err := tc.repl.IsDestroyed(); !testutils.IsError(err, "replica corruption \\(processed=true\\): violation 3") {
+		t.Fatalf("unexpected error: %v", err)
+	} else if reason != destroyReasonQuarantined {
+		t.Fatalf("unexpected destroy reason %d", reason)
+	}
+	if _, pErr := tc.SendWrapped(&args); !testutils.IsPError(pErr, "violation 3") {
+		t.Fatalf("unexpected error: %v", pErr)
+	}
+
+	if c := metrics.ReplicaInvariantViolations.Count(); c != 4 {
+		t.Errorf("expected 4 invariant violations, got %d", c)
+	}
+	if c := metrics.QuarantinedCount.Count(); c != 1 {
+		t.Errorf("expected 1 quarantined replica, got %d", c)
+	}
+}
+
 // TestChangeReplicasDuplicateError tests that a replica change that would
 // use a NodeID twice in the replica configuration fails.
 func TestChangeReplicasDuplicateError(t *testing.T) {
@@ -7674,6 +7804,10 @@ func TestReplicaRefreshPendingCommandsTicks(t *testing.T) {
 
 	// We tick the replica 2*RaftElectionTimeoutTicks. RaftElectionTimeoutTicks
 	// is special in that it controls how often pending commands are reproposed.
+	//
+	// Commands which were reproposed in the previous cycle back off, i.e.
+	// they're not reproposed again in the following one.
+	var prevReproposed int
 	for i := 0; i < 2*electionTicks; i++ {
 		// Add another pending command on each iteration.
 		id := fmt.Sprintf("%08d", i)
@@ -7727,9 +7861,10 @@ func TestReplicaRefreshPendingCommandsTicks(t *testing.T) {
 		// Reproposals are only performed every electionTicks. We'll need
 		// to fix this test if that changes.
 		if (ticks % electionTicks) == 0 {
-			if len(reproposed) != i-1 {
-				t.Fatalf("%d: expected %d reproposed commands, but found %d", i, i-1, len(reproposed))
+			if exp := i - 1 - prevReproposed; len(reproposed) != exp {
+				t.Fatalf("%d: expected %d reproposed commands, but found %d", i, exp, len(reproposed))
 			}
+			prevReproposed = len(reproposed)
 		} else {
 			if len(reproposed) != 0 {
 				t.Fatalf("%d: expected no reproposed commands, but found %+v", i, reproposed)
@@ -7738,6 +7873,139 @@ func TestReplicaRefreshPendingCommandsTicks(t *testing.T) {
 	}
 }
 
+// TestReplicaRefreshReproposalBackoff verifies that a proposal which doesn't
+// apply is reproposed with exponential backoff, up to a cap.
+func TestReplicaRefreshReproposalBackoff(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var tc testContext
+	cfg := TestStoreConfig(nil)
+	// Disable ticks which would interfere with the manual refreshes below.
+	cfg.RaftTickInterval = math.MaxInt32
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc.StartWithStoreConfig(t, stopper, cfg)
+	r := tc.repl
+
+	repDesc, err := r.GetReplicaDescriptor()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ba roachpb.BatchRequest
+	ba.Timestamp = tc.Clock().Now()
+	put := putArgs(roachpb.Key("a"), []byte("value"))
+	ba.Add(&put)
+	proposal, pErr := r.requestToProposal(context.Background(), makeIDKey(), ba, nil, &allSpans)
+	if pErr != nil {
+		t.Fatal(pErr)
+	}
+
+	const refreshAtDelta = 10
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Pretend to propose all commands while silently dropping them.
+	r.mu.submitProposalFn = func(pd *ProposalData) error {
+		return nil
+	}
+	lease := *r.mu.state.Lease
+	proposal.command.ProposerReplica = repDesc
+	proposal.command.ProposerLeaseSequence = lease.Sequence
+	r.insertProposalLocked(proposal)
+	if err := r.submitProposalLocked(proposal); err != nil {
+		t.Fatal(err)
+	}
+
+	// Refresh every refreshAtDelta ticks and record which refreshes repropose
+	// the command.
+	reproposed := tc.store.metrics.RaftCommandsReproposed.Count()
+	deferred := tc.store.metrics.RaftCommandsReproposalsDeferred.Count()
+	var reproposedAt []int
+	start := r.mu.ticks
+	for i := 1; i <= 64; i++ {
+		r.mu.ticks = start + i*refreshAtDelta
+		r.refreshProposalsLocked(refreshAtDelta, reasonTicks)
+		if proposal.proposedAtTicks == r.mu.ticks {
+			reproposedAt = append(reproposedAt, i)
+		}
+	}
+	// The wait doubles with each reproposal until it reaches 16 periods.
+	exp := []int{1, 3, 7, 15, 31, 47, 63}
+	if !reflect.DeepEqual(exp, reproposedAt) {
+		t.Fatalf("expected reproposals at %v, got %v", exp, reproposedAt)
+	}
+	if c := tc.store.metrics.RaftCommandsReproposed.Count() - reproposed; c != int64(len(exp)) {
+		t.Fatalf("expected %d reproposals, got %d", len(exp), c)
+	}
+	if c := tc.store.metrics.RaftCommandsReproposalsDeferred.Count() - deferred; c != int64(64-len(exp)) {
+		t.Fatalf("expected %d deferred reproposals, got %d", 64-len(exp), c)
+	}
+}
+
+// TestReplicaRefreshKeepsExpiredProposals verifies that proposals whose
+// deadline has passed are still reproposed rather than finished by a refresh,
+// since a previous copy of the command may still apply and their latches
+// must be held until it does or is rejected.
+func TestReplicaRefreshKeepsExpiredProposals(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var tc testContext
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc.Start(t, stopper)
+	repl := tc.repl
+
+	repDesc, err := repl.GetReplicaDescriptor()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := timeutil.Now().Add(time.Hour)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	var ba roachpb.BatchRequest
+	ba.Timestamp = tc.Clock().Now()
+	put := putArgs(roachpb.Key("a"), []byte("value"))
+	ba.Add(&put)
+	proposal, pErr := repl.requestToProposal(ctx, makeIDKey(), ba, nil, &allSpans)
+	if pErr != nil {
+		t.Fatal(pErr)
+	}
+	if !proposal.deadline.Equal(deadline) {
+		t.Fatalf("expected proposal deadline %s, got %s", deadline, proposal.deadline)
+	}
+	// Pretend that the deadline has passed while the proposal was pending.
+	proposal.deadline = timeutil.Now().Add(-time.Second)
+
+	abandoned := tc.store.metrics.RaftCommandsAbandoned.Count()
+	reproposed := tc.store.metrics.RaftCommandsReproposed.Count()
+	func() {
+		repl.mu.Lock()
+		defer repl.mu.Unlock()
+		lease := *repl.mu.state.Lease
+		proposal.command.ProposerReplica = repDesc
+		proposal.command.ProposerLeaseSequence = lease.Sequence
+		// Insert the proposal without submitting it, as if it had been dropped.
+		repl.insertProposalLocked(proposal)
+		repl.refreshProposalsLocked(0, reasonNewLeader)
+		if _, ok := repl.mu.proposals[proposal.idKey]; !ok {
+			t.Fatal("expected expired proposal to remain pending")
+		}
+	}()
+
+	select {
+	case res := <-proposal.doneCh:
+		t.Fatalf("expected expired proposal not to be finished, got %+v", res)
+	default:
+	}
+	if r := tc.store.metrics.RaftCommandsReproposed.Count(); r != reproposed+1 {
+		t.Fatalf("expected %d reproposed commands, got %d", reproposed+1, r)
+	}
+	if a := tc.store.metrics.RaftCommandsAbandoned.Count(); a != abandoned {
+		t.Fatalf("expected %d abandoned commands, got %d", abandoned, a)
+	}
+}
+
 // TestReplicaRefreshMultiple tests an interaction between refreshing
 // proposals after a new leader or ticks (which results in multiple
 // copies in the log with the same lease index) and refreshing after
@@ -9311,6 +9579,49 @@ func TestReplicaRecomputeStats(t *testing.T) {
 	}
 }
 
+// TestReplicaRecomputeStatsClearEstimates verifies that RecomputeStats clears
+// the ContainsEstimates flag of the range's stats only when asked to.
+func TestReplicaRecomputeStatsClearEstimates(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc.Start(t, stopper)
+
+	ctx := context.Background()
+	repl := tc.store.LookupReplica(roachpb.RKey("a"))
+	sKey := repl.Desc().StartKey.AsRawKey()
+
+	repl.raftMu.Lock()
+	repl.mu.Lock()
+	ms := repl.mu.state.Stats // intentionally mutated below
+	ms.ContainsEstimates = true
+	err := repl.raftMu.stateLoader.SetMVCCStats(ctx, tc.engine, ms)
+	repl.mu.Unlock()
+	repl.raftMu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, clearEstimates := range []bool{false, true} {
+		args := &roachpb.RecomputeStatsRequest{
+			RequestHeader:  roachpb.RequestHeader{Key: sKey},
+			ClearEstimates: clearEstimates,
+		}
+		if _, pErr := tc.SendWrapped(args); pErr != nil {
+			t.Fatal(pErr)
+		}
+		if ms := repl.GetMVCCStats(); ms.ContainsEstimates == clearEstimates {
+			t.Fatalf("clearEstimates=%t: unexpected ContainsEstimates=%t", clearEstimates, ms.ContainsEstimates)
+		}
+		repl.raftMu.Lock()
+		repl.mu.Lock()
+		repl.assertStateLocked(ctx, tc.engine)
+		repl.mu.Unlock()
+		repl.raftMu.Unlock()
+	}
+}
+
 // TestConsistencyQueueErrorFromCheckConsistency exercises the case in which
 // the queue receives an error from CheckConsistency.
 func TestConsistenctQueueErrorFromCheckConsistency(t *testing.T) {
@@ -11631,3 +11942,164 @@ func TestSplitSnapshotWarningStr(t *testing.T) {
 		splitSnapshotWarningStr(12, status),
 	)
 }
+
+// TestReplicaRequestMetering verifies that reads and writes are reported to
+// the store's RequestMeter and attributed to the keyspace they address.
+func TestReplicaRequestMetering(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+
+	tc := testContext{manualClock: hlc.NewManualClock(123)}
+	cfg := TestStoreConfig(hlc.NewClock(tc.manualClock.UnixNano, time.Nanosecond))
+	meter := metering.NewAggregator(metering.TableKeyspace)
+	cfg.RequestMeter = meter
+	tc.StartWithStoreConfig(t, stopper, cfg)
+
+	key := roachpb.Key(append(keys.MakeTablePrefix(53), "a"...))
+	pArgs := putArgs(key, []byte("value"))
+	if _, pErr := tc.SendWrapped(&pArgs); pErr != nil {
+		t.Fatal(pErr)
+	}
+	gArgs := getArgs(key)
+	if _, pErr := tc.SendWrapped(&gArgs); pErr != nil {
+		t.Fatal(pErr)
+	}
+
+	totals, ok := meter.Totals()["/Table/53"]
+	if !ok {
+		t.Fatalf("expected work to be attributed to /Table/53, got %+v", meter.Totals())
+	}
+	if totals.WriteBatches != 1 || totals.WriteRequests != 1 || totals.WriteBytes == 0 {
+		t.Errorf("unexpected write totals %+v", totals)
+	}
+	if totals.ReadBatches != 1 || totals.ReadRequests != 1 || totals.ReadBytes == 0 {
+		t.Errorf("unexpected read totals %+v", totals)
+	}
+}
+
+// TestReplicaExportSnapshot verifies that ExportSnapshot exports the MVCC
+// versions in the requested time range, including deletion tombstones, and
+// refuses to export past an unresolved intent.
+func TestReplicaExportSnapshot(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+	tc := testContext{}
+	tc.Start(t, stopper)
+
+	keyA, keyB := roachpb.Key("a"), roachpb.Key("b")
+	span := roachpb.Span{Key: keyA, EndKey: roachpb.Key("c")}
+	write := func(args roachpb.Request) hlc.Timestamp {
+		tc.manualClock.Increment(1)
+		ts := tc.Clock().Now()
+		if _, pErr := tc.SendWrappedWith(roachpb.Header{Timestamp: ts}, args); pErr != nil {
+			t.Fatal(pErr)
+		}
+		return ts
+	}
+	pArgs := putArgs(keyA, []byte("value"))
+	ts1 := write(&pArgs)
+	pArgs = putArgs(keyB, []byte("value"))
+	ts2 := write(&pArgs)
+	dArgs := deleteArgs(keyA)
+	ts3 := write(&dArgs)
+
+	exported := func(startTime, endTime hlc.Timestamp) []engine.MVCCKey {
+		ssts, err := tc.repl.ExportSnapshot(ctx, span, startTime, endTime)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var mvccKeys []engine.MVCCKey
+		for _, sst := range ssts {
+			iter, err := engine.NewMemSSTIterator(sst.Data, false /* verify */)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for iter.Seek(engine.MVCCKey{Key: keys.MinKey}); ; iter.Next() {
+				if ok, err := iter.Valid(); err != nil {
+					t.Fatal(err)
+				} else if !ok {
+					break
+				}
+				mvccKeys = append(mvccKeys, iter.Key())
+			}
+			iter.Close()
+		}
+		return mvccKeys
+	}
+
+	now := tc.Clock().Now()
+	if got, expected := exported(hlc.Timestamp{}, now), []engine.MVCCKey{
+		{Key: keyA, Timestamp: ts3}, {Key: keyA, Timestamp: ts1}, {Key: keyB, Timestamp: ts2},
+	}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("full export: expected %s, got %s", expected, got)
+	}
+	if got, expected := exported(ts1, now), []engine.MVCCKey{
+		{Key: keyA, Timestamp: ts3}, {Key: keyB, Timestamp: ts2},
+	}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("incremental export: expected %s, got %s", expected, got)
+	}
+	if got, expected := exported(ts1, ts2), []engine.MVCCKey{
+		{Key: keyB, Timestamp: ts2},
+	}; !reflect.DeepEqual(got, expected) {
+		t.Errorf("bounded export: expected %s, got %s", expected, got)
+	}
+
+	// An intent in the time range prevents the export.
+	txn := newTransaction("test", keyB, 1, tc.Clock())
+	pArgs = putArgs(keyB, []byte("intent"))
+	assignSeqNumsForReqs(txn, &pArgs)
+	if _, pErr := tc.SendWrappedWith(roachpb.Header{Txn: txn}, &pArgs); pErr != nil {
+		t.Fatal(pErr)
+	}
+	tc.manualClock.Increment(1)
+	_, err := tc.repl.ExportSnapshot(ctx, span, ts3, tc.Clock().Now())
+	if _, ok := err.(*roachpb.WriteIntentError); !ok {
+		t.Fatalf("expected WriteIntentError, got %v", err)
+	}
+
+	// So does an end time in the future.
+	if _, err := tc.repl.ExportSnapshot(ctx, span, hlc.Timestamp{}, tc.Clock().Now().Add(1e9, 0)); !testutils.IsError(err, "in the future") {
+		t.Fatalf("expected error about the future end time, got %v", err)
+	}
+}
+
+// TestReplicaLargeWriteBatch verifies that the sizes of the write batches of
+// proposed commands are recorded, and that the commands with a write batch
+// above the large write batch threshold are counted.
+func TestReplicaLargeWriteBatch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	tc := testContext{}
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc.Start(t, stopper)
+	ctx := tc.store.AnnotateCtx(context.TODO())
+	storagebase.LargeWriteBatchThreshold.Override(&tc.store.cfg.Settings.SV, 1<<10)
+
+	metrics := tc.store.metrics
+	sizesBefore := metrics.RaftCommandWriteBatchSize.TotalCount()
+	for i, c := range []struct {
+		value    []byte
+		expLarge int64
+	}{
+		{value: []byte("small"), expLarge: 0},
+		{value: bytes.Repeat([]byte("x"), 4<<10), expLarge: 1},
+	} {
+		var ba roachpb.BatchRequest
+		ba.StatementFingerprint = "UPSERT INTO t VALUES (_)"
+		put := putArgs(roachpb.Key(fmt.Sprintf("key%d", i)), c.value)
+		ba.Add(&put)
+		if _, pErr := tc.Sender().Send(ctx, ba); pErr != nil {
+			t.Fatal(pErr)
+		}
+		if a, e := metrics.RaftCommandsLargeWriteBatch.Count(), c.expLarge; a != e {
+			t.Errorf("%d: expected %d large write batches, got %d", i, e, a)
+		}
+	}
+	if a := metrics.RaftCommandWriteBatchSize.TotalCount() - sizesBefore; a < 2 {
+		t.Errorf("expected at least 2 recorded write batch sizes, got %d", a)
+	}
+}
@@ -20,13 +20,80 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/storage/rditer"
 	"github.com/cockroachdb/cockroach/pkg/storage/tscache"
 	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/pkg/errors"
 )
 
+// timestampCacheImpl selects the implementation of each store's timestamp
+// cache. Changing it replaces the cache with an empty one whose low water mark
+// is the current time, which pushes the writes that are in flight.
+var timestampCacheImpl = settings.RegisterEnumSetting(
+	"kv.timestamp_cache.implementation",
+	"the implementation of the timestamp cache of each store; changing it resets the cache",
+	"skiplist",
+	map[int64]string{
+		int64(tscache.SklImpl):  "skiplist",
+		int64(tscache.TreeImpl): "tree",
+	},
+)
+
+// maxTimestampCacheSize bounds kv.timestamp_cache.size; the pages of the
+// skiplist implementation are limited to 4 GB by their arena.
+const maxTimestampCacheSize = 1 << 30 // 1 GB
+
+// timestampCacheSize controls the memory used by each store's timestamp cache.
+// A larger cache rotates out its entries less often, and so pushes fewer
+// writes to the timestamp of the entries it evicts.
+var timestampCacheSize = settings.RegisterValidatedByteSizeSetting(
+	"kv.timestamp_cache.size",
+	"the size of each page of the timestamp cache of each store for the skiplist implementation, "+
+		"or its total size for the tree implementation (0 uses the default); "+
+		"changing it resets the cache",
+	0,
+	func(size int64) error {
+		if size < 0 || size > maxTimestampCacheSize {
+			return errors.Errorf("size must be between 0 and %s",
+				humanizeutil.IBytes(maxTimestampCacheSize))
+		}
+		return nil
+	},
+)
+
+// newTimestampCache returns the timestamp cache of a store, configured
+// according to the cluster settings.
+func newTimestampCache(cfg *StoreConfig) *tscache.Switchable {
+	impl, size := timestampCacheConfig(cfg)
+	return tscache.NewSwitchable(cfg.Clock, impl, size)
+}
+
+// timestampCacheConfig returns the implementation and size to use for the
+// timestamp cache of a store.
+func timestampCacheConfig(cfg *StoreConfig) (tscache.Impl, uint32) {
+	impl := tscache.Impl(timestampCacheImpl.Get(&cfg.Settings.SV))
+	size := uint32(timestampCacheSize.Get(&cfg.Settings.SV))
+	if size == 0 {
+		size = cfg.TimestampCachePageSize
+	}
+	return impl, size
+}
+
+// updateTimestampCacheConfig switches the store's timestamp cache to the
+// implementation and size given by the cluster settings.
+func (s *Store) updateTimestampCacheConfig(ctx context.Context) {
+	impl, size := timestampCacheConfig(&s.cfg)
+	if s.tsCache.Switch(impl, size) {
+		log.Infof(ctx, "reset timestamp cache to %s implementation (size %s)",
+			impl, humanizeutil.IBytes(int64(size)))
+	}
+}
+
 // setTimestampCacheLowWaterMark updates the low water mark of the timestamp
 // cache to the provided timestamp for all key ranges owned by the provided
 // Range descriptor. This ensures that no future writes in either the local or
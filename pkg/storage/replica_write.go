@@ -67,6 +67,9 @@ func (r *Replica) executeWriteBatch(
 	ctx context.Context, ba roachpb.BatchRequest,
 ) (br *roachpb.BatchResponse, pErr *roachpb.Error) {
 	startTime := timeutil.Now()
+	// Keep the batch as received, in case it needs to be forwarded to a new
+	// leaseholder (see maybeForwardStaleProposal).
+	origBa := ba
 
 	if err := r.maybeBackpressureWriteBatch(ctx, ba); err != nil {
 		return nil, roachpb.NewError(err)
@@ -202,6 +205,11 @@ func (r *Replica) executeWriteBatch(
 					log.Warning(ctx, err)
 				}
 			}
+			if propResult.Err != nil {
+				if br, pErr, ok := r.maybeForwardStaleProposal(ctx, origBa, lease, propResult.Err); ok {
+					return br, pErr
+				}
+			}
 			return propResult.Reply, propResult.Err
 		case <-slowTimer.C:
 			slowTimer.Read = true
@@ -231,7 +231,7 @@ func (rq *replicateQueue) shouldQueue(
 	if lease, _ := repl.GetLease(); repl.IsLeaseValid(lease, now) {
 		if rq.canTransferLease() &&
 			rq.allocator.ShouldTransferLease(
-				ctx, zone, desc.Replicas().Unwrap(), lease.Replica.StoreID, desc.RangeID, repl.leaseholderStats) {
+				ctx, zone, desc.Replicas().Voters(), lease.Replica.StoreID, desc.RangeID, repl.leaseholderStats) {
 			log.VEventf(ctx, 2, "lease transfer needed, enqueuing")
 			return true, 0
 		}
@@ -296,7 +296,7 @@ func (rq *replicateQueue) processOneChange(
 	// Avoid taking action if the range has too many dead replicas to make
 	// quorum.
 	liveReplicas, deadReplicas := rq.allocator.storePool.liveAndDeadReplicas(
-		desc.RangeID, desc.Replicas().Unwrap())
+		desc.RangeID, desc.Replicas().Voters())
 	{
 		quorum := desc.Replicas().QuorumSize()
 		if lr := len(liveReplicas); lr < quorum {
@@ -328,7 +328,7 @@ func (rq *replicateQueue) processOneChange(
 
 		clusterNodes := rq.allocator.storePool.ClusterNodeCount()
 		need := GetNeededReplicas(*zone.NumReplicas, clusterNodes)
-		willHave := len(desc.Replicas().Unwrap()) + 1
+		willHave := len(desc.Replicas().Voters()) + 1
 
 		// Only up-replicate if there are suitable allocation targets such
 		// that, either the replication goal is met, or it is possible to get to the
@@ -401,7 +401,7 @@ func (rq *replicateQueue) processOneChange(
 				// If we've lost raft leadership, we're unlikely to regain it so give up immediately.
 				return false, &benignError{errors.Errorf("not raft leader while range needs removal")}
 			}
-			candidates = filterUnremovableReplicas(raftStatus, desc.Replicas().Unwrap(), lastReplAdded)
+			candidates = filterUnremovableReplicas(raftStatus, desc.Replicas().Voters(), lastReplAdded)
 			log.VEventf(ctx, 3, "filtered unremovable replicas from %v to get %v as candidates for removal: %s",
 				desc.Replicas(), candidates, rangeRaftProgress(raftStatus, desc.Replicas().Unwrap()))
 			if len(candidates) > 0 {
@@ -481,7 +481,7 @@ func (rq *replicateQueue) processOneChange(
 		}
 	case AllocatorRemoveDecommissioning:
 		decommissioningReplicas := rq.allocator.storePool.decommissioningReplicas(
-			desc.RangeID, desc.Replicas().Unwrap())
+			desc.RangeID, desc.Replicas().Voters())
 		if len(decommissioningReplicas) == 0 {
 			log.VEventf(ctx, 1, "range of replica %s was identified as having decommissioning replicas, "+
 				"but no decommissioning replicas were found", repl)
@@ -540,6 +540,24 @@ func (rq *replicateQueue) processOneChange(
 		); err != nil {
 			return false, err
 		}
+	case AllocatorRemoveLearner:
+		learners := desc.Replicas().Learners()
+		if len(learners) == 0 {
+			log.VEventf(ctx, 1, "range of replica %s was identified as having learner replicas, but no learner replicas were found", repl)
+			break
+		}
+		learner := learners[0]
+		rq.metrics.RemoveReplicaCount.Inc(1)
+		log.VEventf(ctx, 1, "removing learner replica %+v from store", learner)
+		target := roachpb.ReplicationTarget{
+			NodeID:  learner.NodeID,
+			StoreID: learner.StoreID,
+		}
+		if err := rq.removeReplica(
+			ctx, repl, target, desc, storagepb.ReasonAbandonedLearner, "", dryRun,
+		); err != nil {
+			return false, err
+		}
 	case AllocatorConsiderRebalance:
 		// The Noop case will result if this replica was queued in order to
 		// rebalance. Attempt to find a rebalancing target.
@@ -616,7 +634,8 @@ func (rq *replicateQueue) findTargetAndTransferLease(
 	zone *config.ZoneConfig,
 	opts transferLeaseOptions,
 ) (bool, error) {
-	candidates := filterBehindReplicas(repl.RaftStatus(), desc.Replicas().Unwrap())
+	// Learners can't hold the lease.
+	candidates := filterBehindReplicas(repl.RaftStatus(), desc.Replicas().Voters())
 	target := rq.allocator.TransferLeaseTarget(
 		ctx,
 		zone,
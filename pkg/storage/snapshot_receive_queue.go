@@ -0,0 +1,192 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/util/limit"
+	"github.com/cockroachdb/cockroach/pkg/util/metric"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/pkg/errors"
+)
+
+// snapshotReceivePriority is the priority with which an incoming snapshot is
+// admitted by a snapshotReceiveQueue. Lower values are admitted first.
+type snapshotReceivePriority int
+
+const (
+	snapshotReceiveRecovery snapshotReceivePriority = iota
+	snapshotReceiveRebalance
+	numSnapshotReceivePriorities
+)
+
+// snapshotReceivePriorityOf maps the priority of a snapshot request to the
+// priority with which it is admitted.
+func snapshotReceivePriorityOf(priority SnapshotRequest_Priority) snapshotReceivePriority {
+	if priority == SnapshotRequest_RECOVERY {
+		return snapshotReceiveRecovery
+	}
+	// Snapshots of unknown priority (e.g. sent by nodes which predate
+	// priorities) are treated like rebalancing snapshots.
+	return snapshotReceiveRebalance
+}
+
+// snapshotReceiveQueue limits the number of non-empty snapshots a store
+// receives and applies concurrently. Snapshots which can't be admitted right
+// away wait in a queue per priority, and a freed slot always goes to the
+// oldest waiter of the highest priority. This ensures that after a node
+// failure, the recovery snapshots which up-replicate the affected ranges
+// aren't stuck behind a backlog of rebalancing snapshots.
+//
+// The queue also holds a rate limiter per priority, which bounds the rate at
+// which snapshot data of that priority is received.
+type snapshotReceiveQueue struct {
+	limiters [numSnapshotReceivePriorities]*limit.RateLimiter
+	waiting  [numSnapshotReceivePriorities]*metric.Gauge
+	admitted [numSnapshotReceivePriorities]*metric.Counter
+	declined *metric.Counter
+
+	mu struct {
+		syncutil.Mutex
+		capacity int
+		inUse    int
+		// waiters holds, for each priority, the channels of the waiting
+		// snapshots in the order in which they arrived. A waiter is admitted by
+		// closing its channel, at which point the slot has been handed over to
+		// it.
+		waiters [numSnapshotReceivePriorities][]chan struct{}
+	}
+}
+
+func newSnapshotReceiveQueue(
+	capacity int, metrics *StoreMetrics, recoveryLimiter, rebalanceLimiter *limit.RateLimiter,
+) *snapshotReceiveQueue {
+	q := &snapshotReceiveQueue{
+		limiters: [numSnapshotReceivePriorities]*limit.RateLimiter{
+			snapshotReceiveRecovery:  recoveryLimiter,
+			snapshotReceiveRebalance: rebalanceLimiter,
+		},
+		waiting: [numSnapshotReceivePriorities]*metric.Gauge{
+			snapshotReceiveRecovery:  metrics.RangeSnapshotRecvQueueRecoveryWaiting,
+			snapshotReceiveRebalance: metrics.RangeSnapshotRecvQueueRebalanceWaiting,
+		},
+		admitted: [numSnapshotReceivePriorities]*metric.Counter{
+			snapshotReceiveRecovery:  metrics.RangeSnapshotRecvQueueRecoveryAdmitted,
+			snapshotReceiveRebalance: metrics.RangeSnapshotRecvQueueRebalanceAdmitted,
+		},
+		declined: metrics.RangeSnapshotRecvQueueDeclined,
+	}
+	q.mu.capacity = capacity
+	return q
+}
+
+// limiter returns the rate limiter for snapshots of the given priority.
+func (q *snapshotReceiveQueue) limiter(priority SnapshotRequest_Priority) *limit.RateLimiter {
+	return q.limiters[snapshotReceivePriorityOf(priority)]
+}
+
+// inUse returns the number of admitted snapshots which haven't been released.
+func (q *snapshotReceiveQueue) inUse() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.mu.inUse
+}
+
+// acquire admits a snapshot of the given priority, waiting for a slot if
+// necessary. A snapshot which can be declined isn't queued; instead, a
+// non-empty rejection message is returned if it can't be admitted right away.
+// Each successful call must be followed by a call to release.
+func (q *snapshotReceiveQueue) acquire(
+	ctx context.Context, stopper *stop.Stopper, priority SnapshotRequest_Priority, canDecline bool,
+) (rejectionMsg string, _ error) {
+	p := snapshotReceivePriorityOf(priority)
+
+	q.mu.Lock()
+	if q.mu.inUse < q.mu.capacity && !q.hasWaitersLocked(p) {
+		q.mu.inUse++
+		q.mu.Unlock()
+		q.admitted[p].Inc(1)
+		return "", nil
+	}
+	if canDecline {
+		q.mu.Unlock()
+		q.declined.Inc(1)
+		return snapshotApplySemBusyMsg, nil
+	}
+	ch := make(chan struct{})
+	q.mu.waiters[p] = append(q.mu.waiters[p], ch)
+	q.mu.Unlock()
+
+	q.waiting[p].Inc(1)
+	defer q.waiting[p].Dec(1)
+	select {
+	case <-ch:
+		q.admitted[p].Inc(1)
+		return "", nil
+	case <-ctx.Done():
+		q.abandon(p, ch)
+		return "", ctx.Err()
+	case <-stopper.ShouldStop():
+		q.abandon(p, ch)
+		return "", errors.Errorf("stopped")
+	}
+}
+
+// release returns the slot of an admitted snapshot, handing it to the next
+// waiter if there is one.
+func (q *snapshotReceiveQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.releaseLocked()
+}
+
+func (q *snapshotReceiveQueue) releaseLocked() {
+	for p := range q.mu.waiters {
+		if waiters := q.mu.waiters[p]; len(waiters) > 0 {
+			close(waiters[0])
+			q.mu.waiters[p] = waiters[1:]
+			return
+		}
+	}
+	q.mu.inUse--
+}
+
+// hasWaitersLocked returns whether any snapshot of the given or a higher
+// priority is waiting, in which case a newly arrived snapshot of the given
+// priority must not jump ahead of it.
+func (q *snapshotReceiveQueue) hasWaitersLocked(p snapshotReceivePriority) bool {
+	for i := snapshotReceivePriority(0); i <= p; i++ {
+		if len(q.mu.waiters[i]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// abandon removes a waiter which gave up. If the waiter was admitted
+// concurrently, the slot it was handed is released.
+func (q *snapshotReceiveQueue) abandon(p snapshotReceivePriority, ch chan struct{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, waiter := range q.mu.waiters[p] {
+		if waiter == ch {
+			q.mu.waiters[p] = append(q.mu.waiters[p][:i], q.mu.waiters[p][i+1:]...)
+			return
+		}
+	}
+	q.releaseLocked()
+}
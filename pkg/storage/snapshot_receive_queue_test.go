@@ -0,0 +1,93 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/pkg/errors"
+)
+
+// TestSnapshotReceiveQueuePriority verifies that waiting recovery snapshots
+// are admitted before rebalancing snapshots which arrived earlier.
+func TestSnapshotReceiveQueuePriority(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+
+	metrics := newStoreMetrics(time.Minute)
+	q := newSnapshotReceiveQueue(1 /* capacity */, metrics, nil, nil)
+
+	if msg, err := q.acquire(ctx, stopper, SnapshotRequest_REBALANCE, false /* canDecline */); err != nil || msg != "" {
+		t.Fatalf("unexpected rejection %q: %v", msg, err)
+	}
+	if msg, err := q.acquire(ctx, stopper, SnapshotRequest_RECOVERY, true /* canDecline */); err != nil {
+		t.Fatal(err)
+	} else if msg != snapshotApplySemBusyMsg {
+		t.Fatalf("expected rejection message %q, got %q", snapshotApplySemBusyMsg, msg)
+	}
+
+	admitted := make(chan SnapshotRequest_Priority, 2)
+	waitFor := func(priority SnapshotRequest_Priority) {
+		if msg, err := q.acquire(ctx, stopper, priority, false /* canDecline */); err != nil || msg != "" {
+			t.Errorf("unexpected rejection %q: %v", msg, err)
+		}
+		admitted <- priority
+	}
+	go waitFor(SnapshotRequest_REBALANCE)
+	testutils.SucceedsSoon(t, func() error {
+		if n := metrics.RangeSnapshotRecvQueueRebalanceWaiting.Value(); n != 1 {
+			return errors.Errorf("expected 1 waiting rebalance snapshot, got %d", n)
+		}
+		return nil
+	})
+	go waitFor(SnapshotRequest_RECOVERY)
+	testutils.SucceedsSoon(t, func() error {
+		if n := metrics.RangeSnapshotRecvQueueRecoveryWaiting.Value(); n != 1 {
+			return errors.Errorf("expected 1 waiting recovery snapshot, got %d", n)
+		}
+		return nil
+	})
+
+	for _, expected := range []SnapshotRequest_Priority{
+		SnapshotRequest_RECOVERY, SnapshotRequest_REBALANCE,
+	} {
+		q.release()
+		if p := <-admitted; p != expected {
+			t.Fatalf("expected %s snapshot to be admitted, got %s", expected, p)
+		}
+	}
+	q.release()
+
+	if n := q.inUse(); n != 0 {
+		t.Fatalf("expected no snapshots in use, got %d", n)
+	}
+	if n := metrics.RangeSnapshotRecvQueueRecoveryAdmitted.Count(); n != 1 {
+		t.Fatalf("expected 1 admitted recovery snapshot, got %d", n)
+	}
+	if n := metrics.RangeSnapshotRecvQueueRebalanceAdmitted.Count(); n != 2 {
+		t.Fatalf("expected 2 admitted rebalance snapshots, got %d", n)
+	}
+	if n := metrics.RangeSnapshotRecvQueueDeclined.Count(); n != 1 {
+		t.Fatalf("expected 1 declined snapshot, got %d", n)
+	}
+}
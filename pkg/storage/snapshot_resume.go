@@ -0,0 +1,161 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+)
+
+// snapshotResumeTimeout is the amount of time for which a store retains the
+// KV batches received on an interrupted snapshot stream. A sender which
+// reconnects within this time only needs to send the remainder of the
+// snapshot.
+var snapshotResumeTimeout = settings.RegisterDurationSetting(
+	"kv.snapshot_recv.resume_timeout",
+	"the amount of time for which a store retains the data of an interrupted incoming snapshot "+
+		"so that the sender can resume it (0 disables resumption)",
+	30*time.Second,
+)
+
+// maxSnapshotResumeAttempts is the number of times a sender reconnects to
+// resume a snapshot whose stream broke before giving up on it.
+const maxSnapshotResumeAttempts = 3
+
+// snapshotResumeMaxBytes bounds the memory held by a store's
+// snapshotResumeCache.
+var snapshotResumeMaxBytes = settings.RegisterByteSizeSetting(
+	"kv.snapshot_recv.resume_max_bytes",
+	"the maximum total size of the data of interrupted incoming snapshots "+
+		"that a store retains for resumption",
+	512<<20,
+)
+
+// errSnapshotStreamInterrupted is returned when the stream broke while the
+// data of a snapshot was being sent. The snapshot can be resumed on a new
+// stream if the header marks it as resumable.
+type errSnapshotStreamInterrupted struct {
+	batches int64
+	cause   error
+}
+
+func (e *errSnapshotStreamInterrupted) Error() string {
+	return fmt.Sprintf("snapshot stream interrupted after %d kv batches: %s", e.batches, e.cause)
+}
+
+// Cause implements the causer interface.
+func (e *errSnapshotStreamInterrupted) Cause() error {
+	return e.cause
+}
+
+type retainedSnapshot struct {
+	batches    [][]byte
+	bytes      int64
+	expiration time.Time
+}
+
+// snapshotResumeCache holds the KV batches received on interrupted snapshot
+// streams, keyed by the UUID of the snapshot. A sender that reconnects for
+// the same snapshot is told how many batches the store already has, and
+// continues from there instead of starting over.
+//
+// The cache only lives in memory: a snapshot interrupted by a restart of the
+// recipient is sent again in full, which is no worse than before.
+type snapshotResumeCache struct {
+	// maxBytes returns the current memory limit of the cache.
+	maxBytes func() int64
+
+	mu struct {
+		syncutil.Mutex
+		snaps map[uuid.UUID]*retainedSnapshot
+		bytes int64
+	}
+}
+
+func newSnapshotResumeCache(maxBytes func() int64) *snapshotResumeCache {
+	c := &snapshotResumeCache{maxBytes: maxBytes}
+	c.mu.snaps = make(map[uuid.UUID]*retainedSnapshot)
+	return c
+}
+
+// retain stores the batches received for the given snapshot until the given
+// expiration. If that would push the cache over its memory limit, the
+// retained snapshots which expire first are evicted; a snapshot which doesn't
+// fit at all isn't retained.
+func (c *snapshotResumeCache) retain(
+	id uuid.UUID, batches [][]byte, now, expiration time.Time,
+) {
+	if len(batches) == 0 {
+		return
+	}
+	snap := &retainedSnapshot{batches: batches, expiration: expiration}
+	for _, b := range batches {
+		snap.bytes += int64(len(b))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeExpiredLocked(now)
+	c.removeLocked(id)
+	maxBytes := c.maxBytes()
+	if snap.bytes > maxBytes {
+		return
+	}
+	for c.mu.bytes+snap.bytes > maxBytes {
+		var oldest uuid.UUID
+		var oldestExp time.Time
+		for otherID, s := range c.mu.snaps {
+			if oldestExp.IsZero() || s.expiration.Before(oldestExp) {
+				oldest, oldestExp = otherID, s.expiration
+			}
+		}
+		c.removeLocked(oldest)
+	}
+	c.mu.snaps[id] = snap
+	c.mu.bytes += snap.bytes
+}
+
+// claim removes the batches retained for the given snapshot from the cache
+// and returns them, or nil if there are none.
+func (c *snapshotResumeCache) claim(id uuid.UUID, now time.Time) [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeExpiredLocked(now)
+	snap, ok := c.mu.snaps[id]
+	if !ok {
+		return nil
+	}
+	c.removeLocked(id)
+	return snap.batches
+}
+
+func (c *snapshotResumeCache) removeExpiredLocked(now time.Time) {
+	for id, s := range c.mu.snaps {
+		if !now.Before(s.expiration) {
+			c.removeLocked(id)
+		}
+	}
+}
+
+func (c *snapshotResumeCache) removeLocked(id uuid.UUID) {
+	if s, ok := c.mu.snaps[id]; ok {
+		c.mu.bytes -= s.bytes
+		delete(c.mu.snaps, id)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+)
+
+func TestSnapshotResumeCache(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	const maxBytes = 1 << 10
+	c := newSnapshotResumeCache(func() int64 { return maxBytes })
+	now := time.Unix(0, 0)
+	a, b := uuid.MakeV4(), uuid.MakeV4()
+	batches := [][]byte{[]byte("foo"), []byte("bar")}
+
+	// Claiming a snapshot removes it from the cache.
+	c.retain(a, batches, now, now.Add(time.Second))
+	if got := c.claim(a, now); !reflect.DeepEqual(got, batches) {
+		t.Fatalf("expected %q, got %q", batches, got)
+	}
+	if got := c.claim(a, now); got != nil {
+		t.Fatalf("expected nothing after claim, got %q", got)
+	}
+
+	// Retained snapshots expire.
+	c.retain(a, batches, now, now.Add(time.Second))
+	c.retain(b, batches, now, now.Add(2*time.Second))
+	if got := c.claim(a, now.Add(time.Second)); got != nil {
+		t.Fatalf("expected expired snapshot to be gone, got %q", got)
+	}
+	if got := c.claim(b, now.Add(time.Second)); !reflect.DeepEqual(got, batches) {
+		t.Fatalf("expected %q, got %q", batches, got)
+	}
+	if c.mu.bytes != 0 {
+		t.Fatalf("expected empty cache to hold 0 bytes, got %d", c.mu.bytes)
+	}
+
+	// Retaining a snapshot which doesn't fit evicts the snapshots which expire
+	// first.
+	big := [][]byte{make([]byte, maxBytes/2)}
+	c.retain(a, big, now, now.Add(time.Second))
+	c.retain(b, big, now, now.Add(2*time.Second))
+	c.retain(uuid.MakeV4(), batches, now, now.Add(3*time.Second))
+	if got := c.claim(a, now); got != nil {
+		t.Fatal("expected snapshot expiring first to be evicted")
+	}
+	if got := c.claim(b, now); got == nil {
+		t.Fatal("expected snapshot to be retained")
+	}
+
+	// A snapshot which exceeds the limit on its own isn't retained.
+	c.retain(a, [][]byte{make([]byte, maxBytes+1)}, now, now.Add(time.Second))
+	if got := c.claim(a, now); got != nil {
+		t.Fatal("expected oversized snapshot not to be retained")
+	}
+}
@@ -37,6 +37,10 @@ following progression:
       longer waited for writes at higher timestamps and writes no longer waited
       for reads at lower timestamps. Conceptually, the structure became an interval
       tree of timestamp-aware sync.RWMutexes.
+    * The structure became priority-aware and high priority access was permitted
+      to jump ahead of normal priority access which was still waiting. The effect
+      of this was that system-critical requests no longer queued up behind a
+      backlog of bulk requests.
 
 */
 package spanlatch
@@ -48,6 +48,14 @@ import (
 // dependent latch acquisitions that they no longer need to wait on the released
 // latches.
 //
+// Latch acquisitions are performed with a priority class. A high priority
+// acquisition doesn't wait for the latches of normal priority acquisitions
+// which are themselves still waiting for their prerequisites. Instead, those
+// acquisitions wait for the high priority acquisition once they're done
+// waiting, so that the high priority acquisition effectively jumps ahead of
+// them. This lets system-critical requests, like lease requests and node
+// liveness heartbeats, be serviced ahead of a backlog of bulk requests.
+//
 // Manager is safe for concurrent use by multiple goroutines. Concurrent access
 // is made efficient using a copy-on-write technique to capture immutable
 // snapshots of the type's inner btree structures. Using this strategy, tasks
@@ -90,7 +98,7 @@ type latch struct {
 	id         uint64
 	span       roachpb.Span
 	ts         hlc.Timestamp
-	done       *signal
+	g          *Guard
 	next, prev *latch // readSet linked-list.
 }
 
@@ -106,9 +114,31 @@ func (la *latch) inReadSet() bool {
 // Manager.Acquire and accepted by Manager.Release.
 type Guard struct {
 	done signal
+	high bool
 	// latches [spanset.NumSpanScope][spanset.NumSpanAccess][]latch, but half the size.
 	latchesPtrs [spanset.NumSpanScope][spanset.NumSpanAccess]unsafe.Pointer
 	latchesLens [spanset.NumSpanScope][spanset.NumSpanAccess]int32
+
+	// mu is only used by normal priority acquisitions, to coordinate with the
+	// high priority acquisitions which jump ahead of them.
+	mu struct {
+		syncutil.Mutex
+		// acquired is set once the acquisition is done waiting for the latches
+		// in its snapshot and for all high priority acquisitions which jumped
+		// ahead of it. From then on, high priority acquisitions can no longer
+		// jump ahead of it and wait for it instead.
+		acquired bool
+		// bypassed holds the latches of high priority acquisitions which
+		// jumped ahead of the acquisition's latches, and which it has yet to
+		// wait for.
+		bypassed []bypass
+	}
+}
+
+// bypass records that a latch of a high priority acquisition jumped ahead of
+// an overlapping latch of a normal priority acquisition.
+type bypass struct {
+	latch, by *latch
 }
 
 func (lg *Guard) latches(s spanset.SpanScope, a spanset.SpanAccess) []latch {
@@ -159,7 +189,7 @@ func allocGuardAndLatches(nLatches int) (*Guard, []latch) {
 	return new(Guard), make([]latch, nLatches)
 }
 
-func newGuard(spans *spanset.SpanSet, ts hlc.Timestamp) *Guard {
+func newGuard(spans *spanset.SpanSet, ts hlc.Timestamp, pri roachpb.PriorityClass) *Guard {
 	nLatches := 0
 	for s := spanset.SpanScope(0); s < spanset.NumSpanScope; s++ {
 		for a := spanset.SpanAccess(0); a < spanset.NumSpanAccess; a++ {
@@ -168,6 +198,7 @@ func newGuard(spans *spanset.SpanSet, ts hlc.Timestamp) *Guard {
 	}
 
 	guard, latches := allocGuardAndLatches(nLatches)
+	guard.high = pri == roachpb.HIGH_PRIORITY
 	for s := spanset.SpanScope(0); s < spanset.NumSpanScope; s++ {
 		for a := spanset.SpanAccess(0); a < spanset.NumSpanAccess; a++ {
 			ss := spans.GetSpans(a, s)
@@ -181,7 +212,7 @@ func newGuard(spans *spanset.SpanSet, ts hlc.Timestamp) *Guard {
 				latch := &latches[i]
 				latch.span = ss[i]
 				latch.ts = ifGlobal(ts, s)
-				latch.done = &guard.done
+				latch.g = guard
 				// latch.setID() in Manager.insert, under lock.
 			}
 			guard.setLatches(s, a, ssLatches)
@@ -195,17 +226,18 @@ func newGuard(spans *spanset.SpanSet, ts hlc.Timestamp) *Guard {
 }
 
 // Acquire acquires latches from the Manager for each of the provided spans, at
-// the specified timestamp. In doing so, it waits for latches over all
-// overlapping spans to be released before returning. If the provided context
+// the specified timestamp and with the specified priority class. In doing so,
+// it waits for latches over all overlapping spans to be released before
+// returning. If the provided context
 // is canceled before the method is done waiting for overlapping latches to
 // be released, it stops waiting and releases all latches that it has already
 // acquired.
 //
 // It returns a Guard which must be provided to Release.
 func (m *Manager) Acquire(
-	ctx context.Context, spans *spanset.SpanSet, ts hlc.Timestamp,
+	ctx context.Context, spans *spanset.SpanSet, ts hlc.Timestamp, pri roachpb.PriorityClass,
 ) (*Guard, error) {
-	lg, snap := m.sequence(spans, ts)
+	lg, snap := m.sequence(spans, ts, pri)
 	defer snap.close()
 
 	err := m.wait(ctx, lg, snap)
@@ -220,8 +252,10 @@ func (m *Manager) Acquire(
 // for each of the specified spans into the manager's interval trees, and
 // unlocks the manager. The role of the method is to sequence latch acquisition
 // attempts.
-func (m *Manager) sequence(spans *spanset.SpanSet, ts hlc.Timestamp) (*Guard, snapshot) {
-	lg := newGuard(spans, ts)
+func (m *Manager) sequence(
+	spans *spanset.SpanSet, ts hlc.Timestamp, pri roachpb.PriorityClass,
+) (*Guard, snapshot) {
+	lg := newGuard(spans, ts, pri)
 
 	m.mu.Lock()
 	snap := m.snapshotLocked(spans)
@@ -350,7 +384,7 @@ func (m *Manager) wait(ctx context.Context, lg *Guard, snap snapshot) error {
 				case spanset.SpanReadOnly:
 					// Wait for writes at equal or lower timestamps.
 					it := tr[spanset.SpanReadWrite].MakeIter()
-					if err := m.iterAndWait(ctx, timer, &it, latch, ignoreLater); err != nil {
+					if err := m.iterAndWait(ctx, timer, &it, lg, latch, ignoreLater); err != nil {
 						return err
 					}
 				case spanset.SpanReadWrite:
@@ -361,12 +395,12 @@ func (m *Manager) wait(ctx context.Context, lg *Guard, snap snapshot) error {
 					// latches first. We expect writes to take longer than reads
 					// to release their latches, so we wait on them first.
 					it := tr[spanset.SpanReadWrite].MakeIter()
-					if err := m.iterAndWait(ctx, timer, &it, latch, ignoreNothing); err != nil {
+					if err := m.iterAndWait(ctx, timer, &it, lg, latch, ignoreNothing); err != nil {
 						return err
 					}
 					// Wait for reads at equal or higher timestamps.
 					it = tr[spanset.SpanReadOnly].MakeIter()
-					if err := m.iterAndWait(ctx, timer, &it, latch, ignoreEarlier); err != nil {
+					if err := m.iterAndWait(ctx, timer, &it, lg, latch, ignoreEarlier); err != nil {
 						return err
 					}
 				default:
@@ -375,23 +409,49 @@ func (m *Manager) wait(ctx context.Context, lg *Guard, snap snapshot) error {
 			}
 		}
 	}
-	return nil
+	if lg.high {
+		return nil
+	}
+
+	// Wait for the high priority acquisitions which jumped ahead of us. More
+	// of them may do so while we wait, so we only consider the latches to be
+	// acquired once there are none left to wait for. High priority
+	// acquisitions wait for acquired latches only, which means that they never
+	// end up waiting for us while we wait for them.
+	for {
+		lg.mu.Lock()
+		bypassed := lg.mu.bypassed
+		lg.mu.bypassed = nil
+		lg.mu.acquired = len(bypassed) == 0
+		lg.mu.Unlock()
+		if len(bypassed) == 0 {
+			return nil
+		}
+		for _, b := range bypassed {
+			if err := m.waitForSignal(ctx, timer, b.latch, b.by); err != nil {
+				return err
+			}
+		}
+	}
 }
 
 // iterAndWait uses the provided iterator to wait on all latches that overlap
 // with the search latch and which should not be ignored given their timestamp
-// and the supplied ignoreFn.
+// and the supplied ignoreFn. The search latch belongs to the provided Guard.
 func (m *Manager) iterAndWait(
-	ctx context.Context, t *timeutil.Timer, it *iterator, wait *latch, ignore ignoreFn,
+	ctx context.Context, t *timeutil.Timer, it *iterator, lg *Guard, wait *latch, ignore ignoreFn,
 ) error {
 	for it.FirstOverlap(wait); it.Valid(); it.NextOverlap() {
 		held := it.Cur()
-		if held.done.signaled() {
+		if held.g.done.signaled() {
 			continue
 		}
 		if ignore(wait.ts, held.ts) {
 			continue
 		}
+		if lg.high && held.g.tryBypass(held, wait) {
+			continue
+		}
 		if err := m.waitForSignal(ctx, t, wait, held); err != nil {
 			return err
 		}
@@ -399,11 +459,29 @@ func (m *Manager) iterAndWait(
 	return nil
 }
 
+// tryBypass lets the given latch of a high priority acquisition jump ahead of
+// the held latch, which belongs to the receiver. This is only possible if the
+// receiver is a normal priority acquisition which hasn't acquired its latches
+// yet, in which case it will wait for the bypassing latch before doing so.
+// Returns whether the latch jumped ahead.
+func (lg *Guard) tryBypass(held, by *latch) bool {
+	if lg.high {
+		return false
+	}
+	lg.mu.Lock()
+	defer lg.mu.Unlock()
+	if lg.mu.acquired {
+		return false
+	}
+	lg.mu.bypassed = append(lg.mu.bypassed, bypass{latch: held, by: by})
+	return true
+}
+
 // waitForSignal waits for the latch that is currently held to be signaled.
 func (m *Manager) waitForSignal(ctx context.Context, t *timeutil.Timer, wait, held *latch) error {
 	for {
 		select {
-		case <-held.done.signalChan():
+		case <-held.g.done.signalChan():
 			return nil
 		case <-t.C:
 			t.Read = true
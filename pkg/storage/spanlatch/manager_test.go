@@ -85,7 +85,7 @@ func testLatchBlocks(t *testing.T, lgC <-chan *Guard) {
 // MustAcquire is like Acquire, except it can't return context cancellation
 // errors.
 func (m *Manager) MustAcquire(spans *spanset.SpanSet, ts hlc.Timestamp) *Guard {
-	lg, err := m.Acquire(context.Background(), spans, ts)
+	lg, err := m.Acquire(context.Background(), spans, ts, roachpb.NORMAL_PRIORITY)
 	if err != nil {
 		panic(err)
 	}
@@ -104,9 +104,23 @@ func (m *Manager) MustAcquireCh(spans *spanset.SpanSet, ts hlc.Timestamp) <-chan
 // MustAcquireChCtx is like MustAcquireCh, except it accepts a context.
 func (m *Manager) MustAcquireChCtx(
 	ctx context.Context, spans *spanset.SpanSet, ts hlc.Timestamp,
+) <-chan *Guard {
+	return m.MustAcquireChCtxPri(ctx, spans, ts, roachpb.NORMAL_PRIORITY)
+}
+
+// MustAcquireHighCh is like MustAcquireCh, except it acquires the latches with
+// high priority.
+func (m *Manager) MustAcquireHighCh(spans *spanset.SpanSet, ts hlc.Timestamp) <-chan *Guard {
+	return m.MustAcquireChCtxPri(context.Background(), spans, ts, roachpb.HIGH_PRIORITY)
+}
+
+// MustAcquireChCtxPri is like MustAcquireChCtx, except it accepts a priority
+// class.
+func (m *Manager) MustAcquireChCtxPri(
+	ctx context.Context, spans *spanset.SpanSet, ts hlc.Timestamp, pri roachpb.PriorityClass,
 ) <-chan *Guard {
 	ch := make(chan *Guard)
-	lg, snap := m.sequence(spans, ts)
+	lg, snap := m.sequence(spans, ts, pri)
 	go func() {
 		err := m.wait(ctx, lg, snap)
 		if err != nil {
@@ -471,6 +485,60 @@ func TestLatchManagerContextCancellation(t *testing.T) {
 	testLatchSucceeds(t, lg3C)
 }
 
+func TestLatchManagerHighPriority(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+
+	// A normal priority write blocks on a held latch.
+	lg1 := m.MustAcquire(spans("a", "", write), zeroTS)
+	lg2C := m.MustAcquireCh(spans("a", "c", write), zeroTS)
+	testLatchBlocks(t, lg2C)
+
+	// A high priority write overlapping only with the waiting write jumps
+	// ahead of it.
+	lg3 := testLatchSucceeds(t, m.MustAcquireHighCh(spans("b", "", write), zeroTS))
+
+	// A normal priority write sequenced after the high priority write waits
+	// for it.
+	lg4C := m.MustAcquireCh(spans("b", "", write), zeroTS)
+	testLatchBlocks(t, lg4C)
+
+	// The waiting write now waits for the high priority write too.
+	m.Release(lg1)
+	testLatchBlocks(t, lg2C)
+	m.Release(lg3)
+	lg2 := testLatchSucceeds(t, lg2C)
+	testLatchBlocks(t, lg4C)
+
+	// A high priority write doesn't jump ahead of acquired latches.
+	lg5C := m.MustAcquireHighCh(spans("a", "", write), zeroTS)
+	testLatchBlocks(t, lg5C)
+	m.Release(lg2)
+	testLatchSucceeds(t, lg4C)
+	testLatchSucceeds(t, lg5C)
+}
+
+func TestLatchManagerHighPriorityContextCancellation(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	var m Manager
+
+	lg1 := m.MustAcquire(spans("a", "", write), zeroTS)
+	lg2C := m.MustAcquireCh(spans("a", "c", write), zeroTS)
+
+	// The high priority write blocks on the held latch.
+	ctx3, cancel3 := context.WithCancel(context.Background())
+	lg3C := m.MustAcquireChCtxPri(ctx3, spans("a", "c", write), zeroTS, roachpb.HIGH_PRIORITY)
+	testLatchBlocks(t, lg3C)
+
+	// Cancel the high priority acquisition. The waiting write no longer waits
+	// for it.
+	cancel3()
+	require.Nil(t, <-lg3C)
+	testLatchBlocks(t, lg2C)
+	m.Release(lg1)
+	testLatchSucceeds(t, lg2C)
+}
+
 func BenchmarkLatchManagerReadOnlyMix(b *testing.B) {
 	for _, size := range []int{1, 4, 16, 64, 128, 256} {
 		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
@@ -515,7 +583,7 @@ func BenchmarkLatchManagerReadWriteMix(b *testing.B) {
 
 			b.ResetTimer()
 			for i := range spans {
-				lg, snap := m.sequence(&spans[i], zeroTS)
+				lg, snap := m.sequence(&spans[i], zeroTS, roachpb.NORMAL_PRIORITY)
 				snap.close()
 				if len(lgBuf) == cap(lgBuf) {
 					m.Release(<-lgBuf)
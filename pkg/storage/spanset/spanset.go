@@ -16,6 +16,7 @@
 package spanset
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"strings"
@@ -154,8 +155,11 @@ func (ss *SpanSet) AssertAllowed(access SpanAccess, span roachpb.Span) {
 	}
 }
 
-// CheckAllowed returns an error if the access is not allowed.
+// CheckAllowed returns an error if the access is not allowed. An access to the
+// lock table entries for a span of keys is allowed if the same access to the
+// keys themselves is.
 func (ss *SpanSet) CheckAllowed(access SpanAccess, span roachpb.Span) error {
+	span = lockedSpan(span)
 	scope := SpanGlobal
 	if keys.IsLocal(span.Key) {
 		scope = SpanLocal
@@ -171,6 +175,25 @@ func (ss *SpanSet) CheckAllowed(access SpanAccess, span roachpb.Span) error {
 	return errors.Errorf("cannot %s undeclared span %s\ndeclared:\n%s", access, span, ss)
 }
 
+// lockedSpan translates a span of lock table keys into the span of the keys
+// they lock. Other spans are returned unchanged.
+func lockedSpan(span roachpb.Span) roachpb.Span {
+	if !bytes.HasPrefix(span.Key, keys.LocalRangeLockTablePrefix) {
+		return span
+	}
+	key, err := keys.DecodeLockTableSingleKey(span.Key)
+	if err != nil {
+		return span
+	}
+	var endKey roachpb.Key
+	if len(span.EndKey) > 0 {
+		if endKey, err = keys.DecodeLockTableSingleKey(span.EndKey); err != nil {
+			return span
+		}
+	}
+	return roachpb.Span{Key: key, EndKey: endKey}
+}
+
 // Validate returns an error if any spans that have been added to the set
 // are invalid.
 func (ss *SpanSet) Validate() error {
@@ -72,6 +72,11 @@ func TestSpanSetCheckAllowedBoundaries(t *testing.T) {
 		{Key: roachpb.Key("b"), EndKey: roachpb.Key("c")},
 		{Key: roachpb.Key("c"), EndKey: roachpb.Key("d")},
 		{Key: roachpb.Key("l"), EndKey: roachpb.Key("m")},
+
+		// Lock table entries of keys within the declared spans.
+		{Key: keys.LockTableSingleKey(roachpb.Key("c"))},
+		{Key: keys.LockTableSingleKey(roachpb.Key("g"))},
+		{Key: keys.LockTableSingleKey(roachpb.Key("k")), EndKey: keys.LockTableSingleKey(roachpb.Key("q"))},
 	}
 	for _, span := range allowed {
 		if err := ss.CheckAllowed(SpanReadOnly, span); err != nil {
@@ -101,6 +106,10 @@ func TestSpanSetCheckAllowedBoundaries(t *testing.T) {
 		{Key: roachpb.Key("b"), EndKey: roachpb.Key("d").Next()},
 		{Key: roachpb.Key("g"), EndKey: roachpb.Key("g").Next()},
 		{Key: roachpb.Key("k"), EndKey: roachpb.Key("q").Next()},
+
+		// Lock table entries of keys outside the declared spans.
+		{Key: keys.LockTableSingleKey(roachpb.Key("a"))},
+		{Key: keys.LockTableSingleKey(roachpb.Key("c")), EndKey: keys.LockTableSingleKey(roachpb.Key("m"))},
 	}
 	for _, span := range disallowed {
 		if err := ss.CheckAllowed(SpanReadOnly, span); err == nil {
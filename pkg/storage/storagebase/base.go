@@ -34,6 +34,17 @@ var MergeQueueEnabled = settings.RegisterBoolSetting(
 	true,
 )
 
+// LargeWriteBatchThreshold is the size of the write batch of a Raft command
+// above which the command is logged, along with the fingerprint of the SQL
+// statement which generated it. The gateways only send the fingerprints of
+// the statements when the setting is enabled.
+var LargeWriteBatchThreshold = settings.RegisterByteSizeSetting(
+	"kv.raft.command.large_write_batch_threshold",
+	"the size of the write batch of a Raft command above which the command is logged "+
+		"and traced, along with the SQL statement that generated it (0 to disable)",
+	0,
+)
+
 // TxnCleanupThreshold is the threshold after which a transaction is
 // considered abandoned and fit for removal, as measured by the
 // maximum of its last heartbeat and timestamp. Abort spans for the
@@ -47,6 +47,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/engine/enginepb"
 	"github.com/cockroachdb/cockroach/pkg/storage/idalloc"
 	"github.com/cockroachdb/cockroach/pkg/storage/intentresolver"
+	"github.com/cockroachdb/cockroach/pkg/storage/metering"
+	"github.com/cockroachdb/cockroach/pkg/storage/protectedts"
 	"github.com/cockroachdb/cockroach/pkg/storage/raftentry"
 	"github.com/cockroachdb/cockroach/pkg/storage/stateloader"
 	"github.com/cockroachdb/cockroach/pkg/storage/tscache"
@@ -111,18 +113,39 @@ var logSSTInfoTicks = envutil.EnvOrDefaultInt(
 )
 
 // bulkIOWriteLimit is defined here because it is used by BulkIOWriteLimiter.
-var bulkIOWriteLimit = settings.RegisterByteSizeSetting(
-	"kv.bulk_io_write.max_rate",
-	"the rate limit (bytes/sec) to use for writes to disk on behalf of bulk io ops",
-	1<<40,
-)
+var bulkIOWriteLimit = func() *settings.ByteSizeSetting {
+	s := settings.RegisterByteSizeSetting(
+		"kv.bulk_io_write.max_rate",
+		"the rate limit (bytes/sec) to use for writes to disk on behalf of bulk io ops",
+		1<<40,
+	)
+	s.SetNodeOverridable()
+	return s
+}()
+
+// backgroundIOLimit is the store-wide budget from which bulkIOWriteLimit,
+// snapshot sends and receives, export reads and garbage collection all draw.
+var backgroundIOLimit = func() *settings.ByteSizeSetting {
+	s := settings.RegisterByteSizeSetting(
+		"kv.store.background_io.max_rate",
+		"the aggregate rate limit (bytes/sec) for background disk IO on a store, "+
+			"including bulk io writes, snapshot sends and receives, export reads and garbage collection",
+		1<<40,
+	)
+	s.SetNodeOverridable()
+	return s
+}()
 
 // importRequestsLimit limits concurrent import requests.
-var importRequestsLimit = settings.RegisterPositiveIntSetting(
-	"kv.bulk_io_write.concurrent_import_requests",
-	"number of import requests a store will handle concurrently before queuing",
-	1,
-)
+var importRequestsLimit = func() *settings.IntSetting {
+	s := settings.RegisterPositiveIntSetting(
+		"kv.bulk_io_write.concurrent_import_requests",
+		"number of import requests a store will handle concurrently before queuing",
+		1,
+	)
+	s.SetNodeOverridable()
+	return s
+}()
 
 // addSSTableRequestMaxRate is the maximum number of AddSSTable requests per second.
 var addSSTableRequestMaxRate = settings.RegisterNonNegativeFloatSetting(
@@ -154,11 +177,15 @@ var concurrentRangefeedItersLimit = settings.RegisterPositiveIntSetting(
 // by a guessing - it could be improved by more measured heuristics. Exported
 // here since we check it in in the caller to limit generated requests as well
 // to prevent excessive queuing.
-var ExportRequestsLimit = settings.RegisterPositiveIntSetting(
-	"kv.bulk_io_write.concurrent_export_requests",
-	"number of export requests a store will handle concurrently before queuing",
-	3,
-)
+var ExportRequestsLimit = func() *settings.IntSetting {
+	s := settings.RegisterPositiveIntSetting(
+		"kv.bulk_io_write.concurrent_export_requests",
+		"number of export requests a store will handle concurrently before queuing",
+		3,
+	)
+	s.SetNodeOverridable()
+	return s
+}()
 
 // TestStoreConfig has some fields initialized with values relevant in tests.
 func TestStoreConfig(clock *hlc.Clock) StoreConfig {
@@ -391,7 +418,7 @@ type Store struct {
 	db                 *client.DB
 	engine             engine.Engine        // The underlying key-value store
 	compactor          *compactor.Compactor // Schedules compaction of the engine
-	tsCache            tscache.Cache        // Most recent timestamps for keys / key ranges
+	tsCache            *tscache.Switchable  // Most recent timestamps for keys / key ranges
 	allocator          Allocator            // Makes allocation decisions
 	replRankings       *replicaRankings
 	storeRebalancer    *StoreRebalancer
@@ -412,6 +439,7 @@ type Store struct {
 	raftEntryCache     *raftentry.Cache
 	limiters           batcheval.Limiters
 	txnWaitMetrics     *txnwait.Metrics
+	storeLiveness      *storeLiveness
 
 	// gossipRangeCountdown and leaseRangeCountdown are countdowns of
 	// changes to range and leaseholder counts, after which the store
@@ -425,6 +453,10 @@ type Store struct {
 	// re-gossiping the store.
 	gossipQueriesPerSecondVal syncutil.AtomicFloat64
 	gossipWritesPerSecondVal  syncutil.AtomicFloat64
+	// overloaded is 1 if the store's engine was found to be overloaded the
+	// last time the store's metrics were computed, and 0 otherwise. It is
+	// gossiped as part of the store's capacity. Updated atomically.
+	overloaded int32
 
 	coalescedMu struct {
 		syncutil.Mutex
@@ -440,8 +472,22 @@ type Store struct {
 	nodeDesc     *roachpb.NodeDescriptor
 	initComplete sync.WaitGroup // Signaled by async init tasks
 
-	// Semaphore to limit concurrent non-empty snapshot application.
-	snapshotApplySem chan struct{}
+	// Queue to limit and prioritize concurrent non-empty snapshot application.
+	snapshotRecvQueue *snapshotReceiveQueue
+	readOnlyQueue     *readOnlyQueue
+	// replicaMigrationQueue runs the replica migrations, and replicaMigrations
+	// tracks their progress.
+	replicaMigrationQueue *replicaMigrationQueue
+	replicaMigrations     replicaMigrationTracker
+	// consistencyTriageMu serializes the updates of the index of the
+	// consistency triage bundles collected by the store.
+	consistencyTriageMu syncutil.Mutex
+	// raftAppTraces holds the spans of the commands proposed by the store
+	// whose application on other replicas is traced.
+	raftAppTraces raftApplicationTraceRegistry
+	// The data received on interrupted snapshot streams, retained so that the
+	// senders can resume the snapshots.
+	snapshotResumeCache *snapshotResumeCache
 
 	// Track newly-acquired expiration-based leases that we want to proactively
 	// renew. An object is sent on the signal whenever a new entry is added to
@@ -449,6 +495,14 @@ type Store struct {
 	renewableLeases       syncutil.IntMap // map[roachpb.RangeID]*Replica
 	renewableLeasesSignal chan struct{}
 
+	// raftLeadershipColocation tracks since when the leaseholder replicas of
+	// the store which are not the Raft leader of their range have been so. See
+	// repairRaftLeadershipColocation.
+	raftLeadershipColocation struct {
+		syncutil.Mutex
+		since map[roachpb.RangeID]time.Time
+	}
+
 	// draining holds a bool which indicates whether this store is draining. See
 	// SetDraining() for a more detailed explanation of behavior changes.
 	//
@@ -590,6 +644,12 @@ type Store struct {
 	// liveness. It is updated periodically in raftTickLoop().
 	livenessMap atomic.Value
 
+	// overloadedStores is the set (a map[roachpb.StoreID]struct{}) of stores
+	// which gossiped that they're overloaded. It is updated periodically in
+	// raftTickLoop() and empty unless pausing replication to overloaded
+	// followers is enabled.
+	overloadedStores atomic.Value
+
 	// cachedCapacity caches information on store capacity to prevent
 	// expensive recomputations in case leases or replicas are rapidly
 	// rebalancing.
@@ -643,6 +703,14 @@ type StoreConfig struct {
 	// maintenance queue to dispatch individual maintenance tasks.
 	TimeSeriesDataStore TimeSeriesDataStore
 
+	// RequestMeter, if set, is notified of the work done by the store's
+	// replicas to serve requests. See the metering package.
+	RequestMeter metering.Meter
+
+	// ProtectedTimestampCache, if set, holds the protected timestamp records
+	// consulted by the GC queue and by the evaluation of GC requests.
+	ProtectedTimestampCache *protectedts.Cache
+
 	// CoalescedHeartbeatsInterval is the interval for which heartbeat messages
 	// are queued and then sent as a single coalesced heartbeat; it is a
 	// fraction of the RaftTickInterval so that heartbeats don't get delayed by
@@ -829,8 +897,14 @@ func NewStore(
 	s.rangefeedReplicas.m = map[roachpb.RangeID]struct{}{}
 	s.rangefeedReplicas.Unlock()
 
-	s.tsCache = tscache.New(cfg.Clock, cfg.TimestampCachePageSize)
+	s.tsCache = newTimestampCache(&s.cfg)
 	s.metrics.registry.AddMetricStruct(s.tsCache.Metrics())
+	timestampCacheImpl.SetOnChange(&cfg.Settings.SV, func() {
+		s.updateTimestampCacheConfig(s.AnnotateCtx(context.Background()))
+	})
+	timestampCacheSize.SetOnChange(&cfg.Settings.SV, func() {
+		s.updateTimestampCacheConfig(s.AnnotateCtx(context.Background()))
+	})
 
 	s.txnWaitMetrics = txnwait.NewMetrics(cfg.HistogramWindowInterval)
 	s.metrics.registry.AddMetricStruct(s.txnWaitMetrics)
@@ -847,14 +921,76 @@ func NewStore(
 	)
 	s.metrics.registry.AddMetricStruct(s.compactor.Metrics)
 
-	s.snapshotApplySem = make(chan struct{}, cfg.concurrentSnapshotApplyLimit)
-
 	s.renewableLeasesSignal = make(chan struct{})
 
-	s.limiters.BulkIOWriteRate = rate.NewLimiter(rate.Limit(bulkIOWriteLimit.Get(&cfg.Settings.SV)), bulkIOWriteBurst)
+	s.limiters.BackgroundIORate = limit.NewRateLimiter(
+		"backgroundIO", rate.Limit(backgroundIOLimit.Get(&cfg.Settings.SV)), bulkIOWriteBurst,
+	)
+	backgroundIOLimit.SetOnChange(&cfg.Settings.SV, func() {
+		s.limiters.BackgroundIORate.SetLimit(rate.Limit(backgroundIOLimit.Get(&cfg.Settings.SV)))
+	})
+	s.limiters.BulkIOWriteRate = s.limiters.BackgroundIORate.NewChild(
+		"bulkIOWrite", rate.Limit(bulkIOWriteLimit.Get(&cfg.Settings.SV)), bulkIOWriteBurst,
+	)
 	bulkIOWriteLimit.SetOnChange(&cfg.Settings.SV, func() {
 		s.limiters.BulkIOWriteRate.SetLimit(rate.Limit(bulkIOWriteLimit.Get(&cfg.Settings.SV)))
 	})
+	// Snapshot sends and export reads are limited individually elsewhere (by
+	// per-snapshot children and by ConcurrentExportRequests, respectively);
+	// these limiters only account them against the store-wide budget.
+	s.limiters.SnapshotSendRate = s.limiters.BackgroundIORate.NewChild(
+		"snapshotSend", rate.Inf, bulkIOWriteBurst,
+	)
+	s.limiters.ExportReadRate = s.limiters.BackgroundIORate.NewChild(
+		"exportRead", rate.Inf, bulkIOWriteBurst,
+	)
+	s.limiters.GCByteRate = s.limiters.BackgroundIORate.NewChild(
+		"gcBytes", rate.Limit(gcMaxRate.Get(&cfg.Settings.SV)), gcKeyVersionChunkBytes,
+	)
+	gcMaxRate.SetOnChange(&cfg.Settings.SV, func() {
+		s.limiters.GCByteRate.SetLimit(rate.Limit(gcMaxRate.Get(&cfg.Settings.SV)))
+	})
+	s.limiters.GCKeyRate = limit.NewRateLimiter(
+		"gcKeys", gcKeyRate(&cfg.Settings.SV), gcKeyRateBurst,
+	)
+	gcMaxKeysPerSecond.SetOnChange(&cfg.Settings.SV, func() {
+		s.limiters.GCKeyRate.SetLimit(gcKeyRate(&cfg.Settings.SV))
+	})
+	s.limiters.LeaseIntentCleanupRate = limit.NewRateLimiter(
+		"leaseIntentCleanup", leaseIntentCleanupRate(&cfg.Settings.SV), leaseIntentCleanupRateBurst,
+	)
+	leaseIntentCleanupMaxRate.SetOnChange(&cfg.Settings.SV, func() {
+		s.limiters.LeaseIntentCleanupRate.SetLimit(leaseIntentCleanupRate(&cfg.Settings.SV))
+	})
+	recoveryRecvLimiter := s.limiters.BackgroundIORate.NewChild(
+		"snapshotRecvRecovery", rate.Limit(recoverySnapshotRecvRate.Get(&cfg.Settings.SV)), bulkIOWriteBurst,
+	)
+	recoverySnapshotRecvRate.SetOnChange(&cfg.Settings.SV, func() {
+		recoveryRecvLimiter.SetLimit(rate.Limit(recoverySnapshotRecvRate.Get(&cfg.Settings.SV)))
+	})
+	rebalanceRecvLimiter := s.limiters.BackgroundIORate.NewChild(
+		"snapshotRecvRebalance", rate.Limit(rebalanceSnapshotRecvRate.Get(&cfg.Settings.SV)), bulkIOWriteBurst,
+	)
+	rebalanceSnapshotRecvRate.SetOnChange(&cfg.Settings.SV, func() {
+		rebalanceRecvLimiter.SetLimit(rate.Limit(rebalanceSnapshotRecvRate.Get(&cfg.Settings.SV)))
+	})
+	s.snapshotRecvQueue = newSnapshotReceiveQueue(
+		cfg.concurrentSnapshotApplyLimit, s.metrics, recoveryRecvLimiter, rebalanceRecvLimiter,
+	)
+	s.snapshotResumeCache = newSnapshotResumeCache(func() int64 {
+		return snapshotResumeMaxBytes.Get(&cfg.Settings.SV)
+	})
+	s.replicaMigrations.migrations = replicaMigrations
+	s.readOnlyQueue = newReadOnlyQueue(
+		int(readOnlyRequestsLimit.Get(&cfg.Settings.SV)),
+		readOnlyRequestsReservedFraction.Get(&cfg.Settings.SV), s.metrics,
+	)
+	updateReadOnlyQueue := func() {
+		s.readOnlyQueue.setCapacity(int(readOnlyRequestsLimit.Get(&cfg.Settings.SV)),
+			readOnlyRequestsReservedFraction.Get(&cfg.Settings.SV))
+	}
+	readOnlyRequestsLimit.SetOnChange(&cfg.Settings.SV, updateReadOnlyQueue)
+	readOnlyRequestsReservedFraction.SetOnChange(&cfg.Settings.SV, updateReadOnlyQueue)
 	s.limiters.ConcurrentImportRequests = limit.MakeConcurrentRequestLimiter(
 		"importRequestLimiter", int(importRequestsLimit.Get(&cfg.Settings.SV)),
 	)
@@ -915,12 +1051,13 @@ func NewStore(
 		s.raftLogQueue = newRaftLogQueue(s, s.db, s.cfg.Gossip)
 		s.raftSnapshotQueue = newRaftSnapshotQueue(s, s.cfg.Gossip)
 		s.consistencyQueue = newConsistencyQueue(s, s.cfg.Gossip)
+		s.replicaMigrationQueue = newReplicaMigrationQueue(s, s.cfg.Gossip)
 		// NOTE: If more queue types are added, please also add them to the list of
 		// queues on the EnqueueRange debug page as defined in
 		// pkg/ui/src/views/reports/containers/enqueueRange/index.tsx
 		s.scanner.AddQueues(
 			s.gcQueue, s.mergeQueue, s.splitQueue, s.replicateQueue, s.replicaGCQueue,
-			s.raftLogQueue, s.raftSnapshotQueue, s.consistencyQueue)
+			s.raftLogQueue, s.raftSnapshotQueue, s.consistencyQueue, s.replicaMigrationQueue)
 
 		if s.cfg.TimeSeriesDataStore != nil {
 			s.tsMaintenanceQueue = newTimeSeriesMaintenanceQueue(
@@ -1273,12 +1410,18 @@ func (s *Store) Start(ctx context.Context, stopper *stop.Stopper) error {
 		return err
 	}
 	s.Ident = &ident
+	s.storeLiveness = newStoreLiveness(ident.StoreID, s.cfg.Clock)
 
 	// Set the store ID for logging.
 	s.cfg.AmbientCtx.AddLogTag("s", s.StoreID())
 	ctx = s.AnnotateCtx(ctx)
 	log.Event(ctx, "read store identity")
 
+	// Resume tracking the replica migrations where the store left off.
+	if err := s.loadReplicaMigrationProgress(ctx); err != nil {
+		return err
+	}
+
 	// Add the store ID to the scanner's AmbientContext before starting it, since
 	// the AmbientContext provided during construction did not include it.
 	// Note that this is just a hacky way of getting around that without
@@ -1435,6 +1578,10 @@ func (s *Store) Start(ctx context.Context, stopper *stop.Stopper) error {
 		s.startLeaseRenewer(ctx)
 	}
 
+	// Colocate Raft leadership with leases which the leaders failed to hand
+	// leadership over to.
+	s.startRaftLeadershipColocationRepairer(ctx)
+
 	// Connect rangefeeds to closed timestamp updates.
 	s.startClosedTimestampRangefeedSubscriber(ctx)
 
@@ -1690,10 +1837,14 @@ func (s *Store) systemGossipUpdate(sysCfg *config.SystemConfig) {
 	// careful about not spawning too many individual goroutines.
 
 	// For every range, update its zone config and check if it needs to
-	// be split or merged.
+	// be split or merged. Ranges whose placement changed are also offered to
+	// the replicate queue, so that they move to the stores matching their new
+	// constraints (for example when a partition is moved to cold storage)
+	// without waiting for the replica scanner.
 	now := s.cfg.Clock.Now()
 	newStoreReplicaVisitor(s).Visit(func(repl *Replica) bool {
-		key := repl.Desc().StartKey
+		desc, oldZone := repl.DescAndZone()
+		key := desc.StartKey
 		zone, err := sysCfg.GetZoneConfigForKey(key)
 		if err != nil {
 			if log.V(1) {
@@ -1708,6 +1859,11 @@ func (s *Store) systemGossipUpdate(sysCfg *config.SystemConfig) {
 		s.mergeQueue.Async(ctx, "gossip update", true /* wait */, func(ctx context.Context, h queueHelper) {
 			h.MaybeAdd(ctx, repl, now)
 		})
+		if !oldZone.PlacementEqual(zone) {
+			s.replicateQueue.Async(ctx, "zone placement change", true /* wait */, func(ctx context.Context, h queueHelper) {
+				h.MaybeAdd(ctx, repl, now)
+			})
+		}
 		return true // more
 	})
 }
@@ -2167,7 +2323,7 @@ func splitPostApply(
 	// Invoke the leasePostApply method to ensure we properly initialize
 	// the replica according to whether it holds the lease. This enables
 	// the txnWaitQueue.
-	rightRng.leasePostApply(ctx, rightLease, false /* permitJump */)
+	rightRng.leasePostApply(ctx, rightLease, nil /* summary */, false /* permitJump */)
 
 	// Add the RHS replica to the store. This step atomically updates
 	// the EndKey of the LHS replica and also adds the RHS replica
@@ -2747,6 +2903,8 @@ func (s *Store) Descriptor(useCached bool) (*roachpb.StoreDescriptor, error) {
 		return nil, err
 	}
 
+	capacity.Overloaded = s.IsOverloaded()
+
 	// Initialize the store descriptor.
 	return &roachpb.StoreDescriptor{
 		StoreID:  s.Ident.StoreID,
@@ -2789,8 +2947,12 @@ func (s *Store) Send(
 	}
 
 	// Limit the number of concurrent AddSSTable requests, since they're expensive
-	// and block all other writes to the same span.
+	// and block all other writes to the same span. They're also rejected
+	// outright when the store is running out of disk space.
 	if ba.IsSingleAddSSTableRequest() {
+		if err := s.checkDiskFull(ctx, "bulk ingestion", diskFullIngestThreshold); err != nil {
+			return nil, roachpb.NewError(err)
+		}
 		if err := s.limiters.ConcurrentAddSSTableRequests.Begin(ctx); err != nil {
 			return nil, roachpb.NewError(err)
 		}
@@ -3251,6 +3413,13 @@ func (s *Store) uncoalesceBeats(
 func (s *Store) HandleRaftRequest(
 	ctx context.Context, req *RaftMessageRequest, respStream RaftMessageResponseStream,
 ) *roachpb.Error {
+	if req.StoreLiveness != nil {
+		if req.RangeID != 0 {
+			log.Fatalf(ctx, "store liveness messages must have rangeID == 0")
+		}
+		s.handleStoreLivenessMessage(ctx, req)
+		return nil
+	}
 	if len(req.Heartbeats)+len(req.HeartbeatResps) > 0 {
 		if req.RangeID != 0 {
 			log.Fatalf(ctx, "coalesced heartbeats must have rangeID == 0")
@@ -3783,16 +3952,20 @@ func (s *Store) processTick(ctx context.Context, rangeID roachpb.RangeID) bool {
 // invocations of processTick, which may have a copy of the previous
 // livenessMap where the now-live node is down. Those instances should
 // be rare, however, and we expect the newly live node to eventually
-// unquiesce the range.
+// unquiesce the range. The replicas are left alone if
+// kv.raft.unquiesce_on_node_liveness.enabled is false.
 func (s *Store) nodeIsLiveCallback(nodeID roachpb.NodeID) {
 	// Update the liveness map.
 	s.livenessMap.Store(s.cfg.NodeLiveness.GetIsLiveMap())
 
+	if !unquiesceOnNodeLiveness.Get(&s.cfg.Settings.SV) {
+		return
+	}
 	s.mu.replicas.Range(func(k int64, v unsafe.Pointer) bool {
 		r := (*Replica)(v)
 		for _, rep := range r.Desc().Replicas().Unwrap() {
 			if rep.NodeID == nodeID {
-				r.unquiesce()
+				r.unquiesce(unquiesceReasonNodeLiveness)
 			}
 		}
 		return true
@@ -3810,6 +3983,7 @@ func (s *Store) processRaft(ctx context.Context) {
 
 	s.stopper.RunWorker(ctx, s.raftTickLoop)
 	s.stopper.RunWorker(ctx, s.coalescedHeartbeatsLoop)
+	s.stopper.RunWorker(ctx, s.storeLivenessLoop)
 	s.stopper.AddCloser(stop.CloserFn(func() {
 		s.cfg.Transport.Stop(s.StoreID())
 	}))
@@ -3850,6 +4024,14 @@ func (s *Store) raftTickLoop(ctx context.Context) {
 				s.livenessMap.Store(nextMap)
 			}
 
+			// Update the set of overloaded stores.
+			var overloadedStores map[roachpb.StoreID]struct{}
+			if s.cfg.StorePool != nil &&
+				pauseReplicationToOverloadedFollowers.Get(&s.cfg.Settings.SV) {
+				overloadedStores = s.cfg.StorePool.overloadedStores()
+			}
+			s.overloadedStores.Store(overloadedStores)
+
 			s.unquiescedReplicas.Lock()
 			// Why do we bother to ever queue a Replica on the Raft scheduler for
 			// tick processing? Couldn't we just call Replica.tick() here? Yes, but
@@ -4137,6 +4319,10 @@ func (s *Store) updateReplicationGauges(ctx context.Context) error {
 		underreplicatedRangeCount int64
 		overreplicatedRangeCount  int64
 		behindCount               int64
+		pausedFollowerCount       int64
+
+		laggingCount int64
+		worstLag     ClosedTimestampLag
 	)
 
 	timestamp := s.cfg.Clock.Now()
@@ -4145,6 +4331,7 @@ func (s *Store) updateReplicationGauges(ctx context.Context) error {
 		livenessMap = s.cfg.NodeLiveness.GetIsLiveMap()
 	}
 	clusterNodes := s.ClusterNodeCount()
+	lagThreshold := ClosedTimestampLagAlertThreshold.Get(&s.cfg.Settings.SV)
 
 	var minMaxClosedTS hlc.Timestamp
 	newStoreReplicaVisitor(s).Visit(func(rep *Replica) bool {
@@ -4181,15 +4368,28 @@ func (s *Store) updateReplicationGauges(ctx context.Context) error {
 			}
 		}
 		behindCount += metrics.BehindCount
+		pausedFollowerCount += metrics.PausedFollowerCount
 		if qps, dur := rep.leaseholderStats.avgQPS(); dur >= MinStatsDuration {
 			averageQueriesPerSecond += qps
 		}
 		if wps, dur := rep.writeStats.avgQPS(); dur >= MinStatsDuration {
 			averageWritesPerSecond += wps
 		}
-		if mc := rep.maxClosed(ctx); minMaxClosedTS.IsEmpty() || mc.Less(minMaxClosedTS) {
+		// maxClosed consults the closed timestamp provider, so compute it only
+		// once per replica.
+		mc := rep.maxClosed(ctx)
+		if minMaxClosedTS.IsEmpty() || mc.Less(minMaxClosedTS) {
 			minMaxClosedTS = mc
 		}
+		if lag, ok := rep.closedTimestampLag(timestamp, mc); ok {
+			s.metrics.ClosedTimestampLag.RecordValue(lag.Lag.Nanoseconds())
+			if lagThreshold > 0 && lag.Lag > lagThreshold {
+				laggingCount++
+				if lag.Lag > worstLag.Lag {
+					worstLag = lag
+				}
+			}
+		}
 		return true // more
 	})
 
@@ -4208,11 +4408,17 @@ func (s *Store) updateReplicationGauges(ctx context.Context) error {
 	s.metrics.UnderReplicatedRangeCount.Update(underreplicatedRangeCount)
 	s.metrics.OverReplicatedRangeCount.Update(overreplicatedRangeCount)
 	s.metrics.RaftLogFollowerBehindCount.Update(behindCount)
+	s.metrics.RaftPausedFollowerCount.Update(pausedFollowerCount)
 
 	if !minMaxClosedTS.IsEmpty() {
 		nanos := timeutil.Since(minMaxClosedTS.GoTime()).Nanoseconds()
 		s.metrics.ClosedTimestampMaxBehindNanos.Update(nanos)
 	}
+	s.metrics.ClosedTimestampLaggingReplicas.Update(laggingCount)
+	if laggingCount > 0 && closedTimestampLagLogLimiter.ShouldLog() {
+		log.Warningf(ctx, "closed timestamps of %d replicas lag by more than %s, the most behind being r%d at %s (%s)",
+			laggingCount, lagThreshold, worstLag.RangeID, worstLag.ClosedTimestamp, worstLag.Lag)
+	}
 
 	return nil
 }
@@ -4228,6 +4434,9 @@ func (s *Store) ComputeMetrics(ctx context.Context, tick int) error {
 	if err := s.updateReplicationGauges(ctx); err != nil {
 		return err
 	}
+	if err := s.updateReplicaMigrationProgress(ctx); err != nil {
+		return err
+	}
 
 	// Get the latest RocksDB stats.
 	stats, err := s.engine.GetStats()
@@ -4236,6 +4445,15 @@ func (s *Store) ComputeMetrics(ctx context.Context, tick int) error {
 	}
 	s.metrics.updateRocksDBStats(*stats)
 
+	// Get the latest sample of the shape of the LSM, which determines whether
+	// the store is overloaded.
+	health, err := s.engine.GetLSMHealth()
+	if err != nil {
+		return err
+	}
+	s.metrics.updateLSMHealth(health)
+	s.updateOverloaded(ctx, &health)
+
 	// Get engine Env stats.
 	envStats, err := s.engine.GetEnvStats()
 	if err != nil {
@@ -4247,11 +4465,10 @@ func (s *Store) ComputeMetrics(ctx context.Context, tick int) error {
 	if rocksdb, ok := s.engine.(*engine.RocksDB); ok {
 		sstables := rocksdb.GetSSTables()
 		s.metrics.RdbNumSSTables.Update(int64(sstables.Len()))
-		readAmp := sstables.ReadAmplification()
-		s.metrics.RdbReadAmplification.Update(int64(readAmp))
 		// Log this metric infrequently.
 		if tick%logSSTInfoTicks == 0 /* every 10m */ {
-			log.Infof(ctx, "sstables (read amplification = %d):\n%s", readAmp, sstables)
+			log.Infof(ctx, "sstables (read amplification = %d):\n%s",
+				sstables.ReadAmplification(), sstables)
 			log.Infof(ctx, "%sestimated_pending_compaction_bytes: %s",
 				rocksdb.GetCompactionStats(), humanizeutil.IBytes(stats.PendingCompactionBytesEstimate))
 		}
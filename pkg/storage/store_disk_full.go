@@ -0,0 +1,94 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/pkg/errors"
+)
+
+// A store whose available disk space falls below a threshold rejects writes
+// which aren't required to keep its existing replicas working, so that it
+// doesn't run out of space entirely, at which point RocksDB fails with ENOSPC
+// and the node crashes. Bulk ingestion (AddSSTable) and inbound rebalancing
+// snapshots are rejected; regular writes, deletes, GC, and Raft traffic
+// (including snapshots catching up existing replicas) are not.
+//
+// The available space is taken from the store's cached capacity, which is
+// refreshed whenever the store's metrics are computed.
+
+var diskFullLogLimiter = log.Every(10 * time.Second)
+
+func validateDiskFullThreshold(v float64) error {
+	if v < 0 || v > 1 {
+		return errors.Errorf("disk full threshold must be between 0 and 1: %f", v)
+	}
+	return nil
+}
+
+// diskFullIngestThreshold is the fraction of a store's capacity below which
+// its available space causes bulk ingestion requests to be rejected.
+var diskFullIngestThreshold = settings.RegisterValidatedFloatSetting(
+	"kv.store.disk_full.ingest_threshold",
+	"fraction of a store's capacity below which its available disk space causes "+
+		"bulk ingestion requests to be rejected, or 0 to disable",
+	0.05,
+	validateDiskFullThreshold,
+)
+
+// diskFullRebalanceThreshold is the fraction of a store's capacity below which
+// its available space causes inbound rebalancing snapshots to be declined.
+var diskFullRebalanceThreshold = settings.RegisterValidatedFloatSetting(
+	"kv.store.disk_full.rebalance_threshold",
+	"fraction of a store's capacity below which its available disk space causes "+
+		"inbound rebalancing snapshots to be declined, or 0 to disable",
+	0.05,
+	validateDiskFullThreshold,
+)
+
+// checkDiskFull returns a *roachpb.DiskFullError if the fraction of the
+// store's capacity which is available is below the threshold setting.
+func (s *Store) checkDiskFull(
+	ctx context.Context, op string, threshold *settings.FloatSetting,
+) error {
+	t := threshold.Get(&s.cfg.Settings.SV)
+	if t == 0 {
+		return nil
+	}
+	capacity, err := s.Capacity(true /* useCached */)
+	if err != nil {
+		// Don't reject writes because we can't tell how full the disk is.
+		log.Warningf(ctx, "unable to determine store capacity: %v", err)
+		return nil
+	}
+	if capacity.Capacity == 0 || float64(capacity.Available) >= t*float64(capacity.Capacity) {
+		return nil
+	}
+	err = &roachpb.DiskFullError{
+		StoreID:   s.StoreID(),
+		Op:        op,
+		Available: capacity.Available,
+		Capacity:  capacity.Capacity,
+	}
+	if diskFullLogLimiter.ShouldLog() {
+		log.Warning(ctx, err)
+	}
+	return err
+}
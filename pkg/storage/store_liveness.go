@@ -0,0 +1,442 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"time"
+	"unsafe"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/pkg/errors"
+)
+
+// Store liveness shortens the time for which the ranges whose leases are held
+// by a failed node are unavailable. An epoch-based lease normally remains
+// valid until the liveness record of the leaseholder's node expires, after
+// which another replica increments the node's epoch and acquires the lease.
+// With store liveness enabled, each store also requests support for its
+// leases from the stores it shares ranges with, which grant it for a limited
+// time at a time:
+//
+// - A store only considers its epoch-based lease valid while a quorum of the
+//   range's voters (itself included) supports it with support which began no
+//   later than the lease was proposed.
+// - A store which stops hearing from another lets its support lapse and then
+//   withdraws it. Support which began before the withdrawal is never extended
+//   again, and the withdrawal is passed on to the other stores.
+// - Once so many of a range's voters have withdrawn their support for the
+//   leaseholder's store that the others don't constitute a quorum, the lease
+//   is considered expired and another replica acquires it without
+//   incrementing the epoch of the leaseholder's node, which is still live.
+//
+// A store whose support is interrupted and then resumes considers its leases
+// proscribed and reacquires them with a new start timestamp, so that the new
+// lease isn't equivalent to the old one and a takeover of the old lease by
+// another replica can't apply after it.
+
+// storeLivenessEnabled controls whether new epoch-based leases rely on store
+// liveness. Whether an existing lease does is recorded in the lease itself
+// (Lease.StoreLiveness), so that replicas which haven't yet heard of a change
+// to the setting don't disagree about its validity.
+var storeLivenessEnabled = settings.RegisterBoolSetting(
+	"kv.store_liveness.enabled",
+	"if set, stores grant each other time-limited support for their leases, which allows "+
+		"the leases of a failed store to be taken over before its node liveness record "+
+		"expires (experimental)",
+	false,
+)
+
+// storeLivenessActive returns whether new epoch-based leases should rely on
+// store liveness.
+func storeLivenessActive(st *cluster.Settings) bool {
+	return storeLivenessEnabled.Get(&st.SV) && st.Version.IsActive(cluster.VersionStoreLiveness)
+}
+
+// storeLivenessSupportDuration is the duration for which support is granted
+// in response to each request.
+var storeLivenessSupportDuration = settings.RegisterValidatedDurationSetting(
+	"kv.store_liveness.support_duration",
+	"the duration for which a store supports the leases of another store after hearing from it",
+	3*time.Second,
+	func(v time.Duration) error {
+		if v <= 0 {
+			return errors.Errorf("cannot set kv.store_liveness.support_duration to a non-positive duration: %s", v)
+		}
+		return nil
+	},
+)
+
+// storeLivenessHeartbeatsPerSupportDuration is the number of times a store
+// requests support from each of its peers per support duration.
+const storeLivenessHeartbeatsPerSupportDuration = 3
+
+// storeSupport is the support a store has received from another store.
+type storeSupport struct {
+	epoch      int64
+	start      hlc.Timestamp
+	expiration hlc.Timestamp
+}
+
+// storeSupportGrant is the support a store has granted to another store.
+type storeSupportGrant struct {
+	epoch      int64
+	start      hlc.Timestamp
+	expiration hlc.Timestamp
+	// withdrawn is the time at which the support was last withdrawn. The
+	// support is active if it started after that.
+	withdrawn hlc.Timestamp
+}
+
+func (g storeSupportGrant) active() bool {
+	return g.withdrawn.Less(g.start)
+}
+
+// storeLiveness tracks the support a store has received from and granted to
+// other stores.
+type storeLiveness struct {
+	storeID roachpb.StoreID
+	clock   *hlc.Clock
+
+	mu struct {
+		syncutil.RWMutex
+		// supportFrom is the support received from other stores, by supporter.
+		supportFrom map[roachpb.StoreID]storeSupport
+		// supportFor is the support granted to other stores, by supported
+		// store.
+		supportFor map[roachpb.StoreID]storeSupportGrant
+		// withdrawals are the latest withdrawals of support made by other
+		// stores, by supporter and supported store.
+		withdrawals map[roachpb.StoreID]map[roachpb.StoreID]StoreLivenessWithdrawal
+	}
+}
+
+func newStoreLiveness(storeID roachpb.StoreID, clock *hlc.Clock) *storeLiveness {
+	sl := &storeLiveness{storeID: storeID, clock: clock}
+	sl.mu.supportFrom = make(map[roachpb.StoreID]storeSupport)
+	sl.mu.supportFor = make(map[roachpb.StoreID]storeSupportGrant)
+	sl.mu.withdrawals = make(map[roachpb.StoreID]map[roachpb.StoreID]StoreLivenessWithdrawal)
+	return sl
+}
+
+// request returns a request for support of the store's leases at the given
+// epoch for the given duration. It first withdraws any support granted by
+// the store which has lapsed, so that the request carries the withdrawal.
+func (sl *storeLiveness) request(epoch int64, duration time.Duration) StoreLivenessMessage {
+	now := sl.clock.Now()
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	sl.withdrawLapsedLocked(now)
+	return StoreLivenessMessage{
+		Epoch:       epoch,
+		Timestamp:   now,
+		Expiration:  now.Add(duration.Nanoseconds(), 0),
+		Withdrawals: sl.withdrawalsLocked(),
+	}
+}
+
+// handleRequest grants the support requested by the given store, whose node's
+// liveness epoch is known to be at least minEpoch, for at most the given
+// duration and returns the response.
+func (sl *storeLiveness) handleRequest(
+	from roachpb.StoreID, req StoreLivenessMessage, minEpoch int64, duration time.Duration,
+) StoreLivenessMessage {
+	now := sl.clock.Update(req.Timestamp)
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	sl.recordWithdrawalsLocked(from, req.Withdrawals)
+	resp := StoreLivenessMessage{Epoch: req.Epoch, Timestamp: now, Response: true}
+
+	g, ok := sl.mu.supportFor[from]
+	switch {
+	case req.Epoch < minEpoch || (ok && req.Epoch < g.epoch):
+		// The epoch has been incremented since. Refuse the request.
+		resp.Withdrawals = sl.withdrawalsLocked()
+		return resp
+	case !ok || g.epoch < req.Epoch:
+		g = storeSupportGrant{epoch: req.Epoch, start: now}
+	case !g.active() || !now.Less(g.expiration):
+		// The support has lapsed. Withdraw it if that hasn't happened yet and
+		// start supporting the store anew.
+		if g.active() {
+			g.withdrawn = now
+		}
+		g.start = sl.clock.Now()
+		g.expiration = hlc.Timestamp{}
+	}
+	exp := req.Expiration
+	if max := now.Add(duration.Nanoseconds(), 0); max.Less(exp) {
+		exp = max
+	}
+	g.expiration.Forward(exp)
+	sl.mu.supportFor[from] = g
+
+	resp.Expiration = g.expiration
+	resp.SupportStart = g.start
+	resp.Withdrawals = sl.withdrawalsLocked()
+	return resp
+}
+
+// handleResponse records the support granted by the given store.
+func (sl *storeLiveness) handleResponse(from roachpb.StoreID, resp StoreLivenessMessage) {
+	sl.clock.Update(resp.Timestamp)
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+	sl.recordWithdrawalsLocked(from, resp.Withdrawals)
+	if resp.Expiration == (hlc.Timestamp{}) {
+		// Support was refused.
+		return
+	}
+	s := sl.mu.supportFrom[from]
+	if resp.Epoch < s.epoch || (resp.Epoch == s.epoch && resp.SupportStart.Less(s.start)) {
+		// The response is stale.
+		return
+	}
+	if resp.Epoch != s.epoch || resp.SupportStart != s.start {
+		s = storeSupport{epoch: resp.Epoch, start: resp.SupportStart}
+	}
+	s.expiration.Forward(resp.Expiration)
+	sl.mu.supportFrom[from] = s
+}
+
+// withdrawLapsedLocked withdraws the support granted by the store whose
+// expiration has passed.
+func (sl *storeLiveness) withdrawLapsedLocked(now hlc.Timestamp) {
+	for storeID, g := range sl.mu.supportFor {
+		if g.active() && !now.Less(g.expiration) {
+			g.withdrawn = now
+			sl.mu.supportFor[storeID] = g
+		}
+	}
+}
+
+// withdrawalsLocked returns the withdrawals of support made by the store.
+func (sl *storeLiveness) withdrawalsLocked() []StoreLivenessWithdrawal {
+	var ws []StoreLivenessWithdrawal
+	for storeID, g := range sl.mu.supportFor {
+		if g.withdrawn != (hlc.Timestamp{}) {
+			ws = append(ws, StoreLivenessWithdrawal{StoreID: storeID, Epoch: g.epoch, Timestamp: g.withdrawn})
+		}
+	}
+	return ws
+}
+
+// recordWithdrawalsLocked records the withdrawals of support made by the
+// given store, keeping only the latest one for each supported store.
+func (sl *storeLiveness) recordWithdrawalsLocked(
+	from roachpb.StoreID, ws []StoreLivenessWithdrawal,
+) {
+	if len(ws) == 0 {
+		return
+	}
+	m, ok := sl.mu.withdrawals[from]
+	if !ok {
+		m = make(map[roachpb.StoreID]StoreLivenessWithdrawal)
+		sl.mu.withdrawals[from] = m
+	}
+	for _, w := range ws {
+		if prev, ok := m[w.StoreID]; !ok || prev.Epoch < w.Epoch ||
+			(prev.Epoch == w.Epoch && prev.Timestamp.Less(w.Timestamp)) {
+			m[w.StoreID] = w
+		}
+	}
+}
+
+// supportExpiration returns the time until which the store's leases at the
+// given epoch are supported by a quorum of the given voters, counting only
+// support which started at or before startedBy. The store counts as a
+// supporter of itself, so the returned timestamp is hlc.MaxTimestamp if it
+// constitutes a quorum on its own.
+func (sl *storeLiveness) supportExpiration(
+	epoch int64, voters []roachpb.ReplicaDescriptor, startedBy hlc.Timestamp,
+) hlc.Timestamp {
+	quorum := len(voters)/2 + 1
+	// Collect the expirations in descending order. Ranges have few voters, so
+	// an insertion sort does.
+	var buf [7]hlc.Timestamp
+	exps := buf[:0]
+	sl.mu.RLock()
+	for _, rd := range voters {
+		exp := hlc.MaxTimestamp
+		if rd.StoreID != sl.storeID {
+			s, ok := sl.mu.supportFrom[rd.StoreID]
+			if !ok || s.epoch != epoch || startedBy.Less(s.start) {
+				continue
+			}
+			exp = s.expiration
+		}
+		exps = append(exps, exp)
+		for i := len(exps) - 1; i > 0 && exps[i-1].Less(exps[i]); i-- {
+			exps[i-1], exps[i] = exps[i], exps[i-1]
+		}
+	}
+	sl.mu.RUnlock()
+	if len(exps) < quorum {
+		return hlc.Timestamp{}
+	}
+	return exps[quorum-1]
+}
+
+// withdrawnByQuorum returns whether so many of the given voters other than
+// the given store have withdrawn their support for its leases at the given
+// epoch since proposedTS that the rest can't constitute a quorum. If so, it
+// also returns the time of the latest of these withdrawals, after which any
+// lease taking over from the store must start.
+func (sl *storeLiveness) withdrawnByQuorum(
+	storeID roachpb.StoreID, epoch int64, voters []roachpb.ReplicaDescriptor, proposedTS hlc.Timestamp,
+) (bool, hlc.Timestamp) {
+	quorum := len(voters)/2 + 1
+	var withdrawn int
+	var latest hlc.Timestamp
+	sl.mu.RLock()
+	defer sl.mu.RUnlock()
+	for _, rd := range voters {
+		if rd.StoreID == storeID {
+			continue
+		}
+		var w StoreLivenessWithdrawal
+		if rd.StoreID == sl.storeID {
+			g := sl.mu.supportFor[storeID]
+			w = StoreLivenessWithdrawal{Epoch: g.epoch, Timestamp: g.withdrawn}
+		} else {
+			w = sl.mu.withdrawals[rd.StoreID][storeID]
+		}
+		if w.Epoch == epoch && proposedTS.Less(w.Timestamp) {
+			withdrawn++
+			latest.Forward(w.Timestamp)
+		}
+	}
+	if withdrawn < len(voters)-quorum+1 {
+		return false, hlc.Timestamp{}
+	}
+	return true, latest
+}
+
+// leaseProposedTS returns the proposed timestamp of the lease, or zero if it
+// doesn't have one.
+func leaseProposedTS(lease roachpb.Lease) hlc.Timestamp {
+	if lease.ProposedTS == nil {
+		return hlc.Timestamp{}
+	}
+	return *lease.ProposedTS
+}
+
+// leaseWithdrawnByStoreLiveness returns whether the epoch-based lease, which
+// is held by another store, has been invalidated by the withdrawal of support
+// for that store by the range's voters, and if so, the time of the latest
+// withdrawal (see withdrawnByQuorum).
+func (r *Replica) leaseWithdrawnByStoreLiveness(
+	lease roachpb.Lease, desc *roachpb.RangeDescriptor,
+) (bool, hlc.Timestamp) {
+	if !lease.StoreLiveness || lease.Type() != roachpb.LeaseEpoch ||
+		lease.OwnedBy(r.store.StoreID()) {
+		return false, hlc.Timestamp{}
+	}
+	return r.store.storeLiveness.withdrawnByQuorum(
+		lease.Replica.StoreID, lease.Epoch, desc.Replicas().Voters(), leaseProposedTS(lease))
+}
+
+// storeLivenessLoop periodically requests support from the stores which
+// share ranges with this store.
+func (s *Store) storeLivenessLoop(ctx context.Context) {
+	var timer timeutil.Timer
+	defer timer.Stop()
+	for {
+		duration := storeLivenessSupportDuration.Get(&s.cfg.Settings.SV)
+		timer.Reset(duration / storeLivenessHeartbeatsPerSupportDuration)
+		select {
+		case <-timer.C:
+			timer.Read = true
+			if s.cfg.Settings.Version.IsActive(cluster.VersionStoreLiveness) {
+				s.requestStoreLivenessSupport(ctx, duration)
+			}
+		case <-s.stopper.ShouldStop():
+			return
+		}
+	}
+}
+
+// requestStoreLivenessSupport sends a request for support to each store with
+// which this store shares a range. With kv.store_liveness.enabled unset, only
+// the ranges whose leases this store holds with store liveness are taken into
+// account, so that these leases remain valid until they change hands.
+func (s *Store) requestStoreLivenessSupport(ctx context.Context, duration time.Duration) {
+	if s.cfg.NodeLiveness == nil {
+		return
+	}
+	enabled := storeLivenessEnabled.Get(&s.cfg.Settings.SV)
+	peers := make(map[roachpb.StoreID]roachpb.NodeID)
+	s.mu.replicas.Range(func(k int64, v unsafe.Pointer) bool {
+		r := (*Replica)(v)
+		if !enabled {
+			if lease, _ := r.GetLease(); !lease.StoreLiveness || !lease.OwnedBy(s.StoreID()) {
+				return true
+			}
+		}
+		for _, rd := range r.Desc().Replicas().Voters() {
+			if rd.StoreID != s.StoreID() {
+				peers[rd.StoreID] = rd.NodeID
+			}
+		}
+		return true
+	})
+	if len(peers) == 0 {
+		return
+	}
+	liveness, err := s.cfg.NodeLiveness.Self()
+	if err != nil {
+		log.VEventf(ctx, 2, "not requesting store liveness support: %v", err)
+		return
+	}
+	req := s.storeLiveness.request(liveness.Epoch, duration)
+	for storeID, nodeID := range peers {
+		s.cfg.Transport.SendAsync(&RaftMessageRequest{
+			ToReplica:     roachpb.ReplicaDescriptor{NodeID: nodeID, StoreID: storeID},
+			FromReplica:   roachpb.ReplicaDescriptor{NodeID: s.Ident.NodeID, StoreID: s.Ident.StoreID},
+			StoreLiveness: &req,
+		})
+	}
+}
+
+// handleStoreLivenessMessage handles a request for support from another
+// store, or the response to a request made by this store.
+func (s *Store) handleStoreLivenessMessage(ctx context.Context, req *RaftMessageRequest) {
+	msg := req.StoreLiveness
+	if msg.Response {
+		s.storeLiveness.handleResponse(req.FromReplica.StoreID, *msg)
+		return
+	}
+	var minEpoch int64
+	if s.cfg.NodeLiveness != nil {
+		if liveness, err := s.cfg.NodeLiveness.GetLiveness(req.FromReplica.NodeID); err == nil {
+			minEpoch = liveness.Epoch
+		}
+	}
+	resp := s.storeLiveness.handleRequest(
+		req.FromReplica.StoreID, *msg, minEpoch, storeLivenessSupportDuration.Get(&s.cfg.Settings.SV))
+	if !s.cfg.Transport.SendAsync(&RaftMessageRequest{
+		ToReplica:     req.FromReplica,
+		FromReplica:   req.ToReplica,
+		StoreLiveness: &resp,
+	}) {
+		log.VEventf(ctx, 2, "unable to send store liveness response to s%d", req.FromReplica.StoreID)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+func TestStoreLiveness(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	manual := hlc.NewManualClock(123)
+	clock := hlc.NewClock(manual.UnixNano, time.Nanosecond)
+	const epoch = 1
+	const duration = 3 * time.Second
+	voters := []roachpb.ReplicaDescriptor{
+		{NodeID: 1, StoreID: 1, ReplicaID: 1},
+		{NodeID: 2, StoreID: 2, ReplicaID: 2},
+		{NodeID: 3, StoreID: 3, ReplicaID: 3},
+	}
+	sls := map[roachpb.StoreID]*storeLiveness{}
+	for _, rd := range voters {
+		sls[rd.StoreID] = newStoreLiveness(rd.StoreID, clock)
+	}
+	// heartbeat has store s request support from all other stores.
+	heartbeat := func(s roachpb.StoreID) {
+		req := sls[s].request(epoch, duration)
+		for _, rd := range voters {
+			if rd.StoreID != s {
+				resp := sls[rd.StoreID].handleRequest(s, req, epoch, duration)
+				sls[s].handleResponse(rd.StoreID, resp)
+			}
+		}
+	}
+
+	heartbeat(1)
+	proposedTS := clock.Now()
+	exp := sls[1].supportExpiration(epoch, voters, proposedTS)
+	if exp == (hlc.Timestamp{}) || exp == hlc.MaxTimestamp {
+		t.Fatalf("expected finite support expiration, got %s", exp)
+	}
+	// Support which started after the lease was proposed doesn't count.
+	if exp := sls[1].supportExpiration(epoch, voters, hlc.Timestamp{}); exp != (hlc.Timestamp{}) {
+		t.Fatalf("expected no support, got %s", exp)
+	}
+	// A single voter is a quorum on its own.
+	if exp := sls[1].supportExpiration(epoch, voters[:1], hlc.Timestamp{}); exp != hlc.MaxTimestamp {
+		t.Fatalf("expected unlimited support, got %s", exp)
+	}
+	if withdrawn, _ := sls[2].withdrawnByQuorum(1, epoch, voters, proposedTS); withdrawn {
+		t.Fatal("unexpected withdrawal")
+	}
+
+	// Store 1 stops heartbeating while the others carry on. Once its support
+	// has lapsed, the others withdraw it and learn of each other's withdrawals.
+	manual.Increment(duration.Nanoseconds())
+	heartbeat(2)
+	heartbeat(3)
+	for _, s := range []roachpb.StoreID{2, 3} {
+		withdrawn, ts := sls[s].withdrawnByQuorum(1, epoch, voters, proposedTS)
+		if !withdrawn {
+			t.Fatalf("s%d: expected support for s1 to be withdrawn", s)
+		}
+		if ts.Less(exp) {
+			t.Fatalf("s%d: withdrawal at %s precedes the expiration of the support at %s", s, ts, exp)
+		}
+	}
+	// Store 1's support for the others is unaffected.
+	if withdrawn, _ := sls[1].withdrawnByQuorum(2, epoch, voters, proposedTS); withdrawn {
+		t.Fatal("unexpected withdrawal")
+	}
+
+	// Store 1 regains support, but not for the lease proposed before the
+	// withdrawals, which needs to be reacquired.
+	heartbeat(1)
+	if exp := sls[1].supportExpiration(epoch, voters, proposedTS); exp != (hlc.Timestamp{}) {
+		t.Fatalf("expected no support, got %s", exp)
+	}
+	newProposedTS := clock.Now()
+	if exp := sls[1].supportExpiration(epoch, voters, newProposedTS); !clock.Now().Less(exp) {
+		t.Fatalf("expected support, got %s", exp)
+	}
+	if withdrawn, _ := sls[2].withdrawnByQuorum(1, epoch, voters, newProposedTS); withdrawn {
+		t.Fatal("unexpected withdrawal of new lease")
+	}
+
+	// Support is refused for an epoch which has been incremented.
+	req := sls[1].request(epoch, duration)
+	if resp := sls[2].handleRequest(1, req, epoch+1, duration); resp.Expiration != (hlc.Timestamp{}) {
+		t.Fatalf("expected support to be refused, got %+v", resp)
+	}
+}
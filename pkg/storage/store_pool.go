@@ -408,6 +408,25 @@ func (sp *StorePool) getStoreDescriptor(storeID roachpb.StoreID) (roachpb.StoreD
 	return roachpb.StoreDescriptor{}, false
 }
 
+// overloadedStores returns the set of stores whose most recently gossiped
+// descriptor indicates that their storage engine is overloaded.
+func (sp *StorePool) overloadedStores() map[roachpb.StoreID]struct{} {
+	sp.detailsMu.RLock()
+	defer sp.detailsMu.RUnlock()
+
+	var overloaded map[roachpb.StoreID]struct{}
+	for storeID, detail := range sp.detailsMu.storeDetails {
+		if detail.desc == nil || !detail.desc.Capacity.Overloaded {
+			continue
+		}
+		if overloaded == nil {
+			overloaded = make(map[roachpb.StoreID]struct{})
+		}
+		overloaded[storeID] = struct{}{}
+	}
+	return overloaded
+}
+
 // decommissioningReplicas filters out replicas on decommissioning node/store
 // from the provided repls and returns them in a slice.
 func (sp *StorePool) decommissioningReplicas(
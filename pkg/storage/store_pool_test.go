@@ -105,7 +105,7 @@ func createTestStorePool(
 	st := cluster.MakeTestingClusterSettings()
 	rpcContext := rpc.NewContext(
 		log.AmbientContext{Tracer: st.Tracer}, &base.Config{Insecure: true}, clock, stopper,
-		&st.Version)
+		st)
 	server := rpc.NewServer(rpcContext) // never started
 	g := gossip.NewTest(1, rpcContext, server, stopper, metric.NewRegistry(), config.DefaultZoneConfigRef())
 	mnl := newMockNodeLiveness(defaultNodeStatus)
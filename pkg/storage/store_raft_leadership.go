@@ -0,0 +1,139 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"go.etcd.io/etcd/raft"
+)
+
+// RaftLeadershipColocationThreshold is the duration past which a leaseholder
+// which is not the Raft leader of its range asks the leader to transfer
+// leadership to it. The leader usually hands leadership over to the
+// leaseholder on its own (see maybeTransferRaftLeadershipLocked), but only
+// does so once the leaseholder is caught up, which may never happen on busy
+// ranges. Until leadership is colocated with the lease, every write incurs an
+// extra round trip between the two.
+var RaftLeadershipColocationThreshold = settings.RegisterNonNegativeDurationSetting(
+	"kv.raft.leadership_colocation.repair_threshold",
+	"if nonzero, leaseholders which have not been the Raft leader of their range for longer than "+
+		"this duration ask the leader to transfer leadership to them",
+	time.Minute,
+)
+
+// raftLeadershipColocationScanInterval is the interval at which a store scans
+// its replicas for leaseholders which are not the Raft leader.
+const raftLeadershipColocationScanInterval = 10 * time.Second
+
+// leaseholderNotLeader returns whether the replica holds a valid lease while
+// another replica is the Raft leader of the range.
+func (r *Replica) leaseholderNotLeader() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	lease := *r.mu.state.Lease
+	if !lease.OwnedBy(r.StoreID()) || !r.isLeaseValidRLocked(lease, r.Clock().Now()) {
+		return false
+	}
+	raftStatus := r.raftStatusRLocked()
+	// Leadership can't be requested while the leader is unknown, and elections
+	// sort themselves out.
+	return raftStatus != nil && raftStatus.RaftState == raft.StateFollower &&
+		raftStatus.Lead != 0 && raftStatus.Lead != uint64(r.mu.replicaID)
+}
+
+// requestRaftLeadership asks the Raft leader of the range to transfer
+// leadership to the replica. Followers forward leadership transfer requests to
+// the leader, which catches the replica up before handing leadership over.
+func (r *Replica) requestRaftLeadership(ctx context.Context) {
+	r.raftMu.Lock()
+	defer r.raftMu.Unlock()
+	if err := r.withRaftGroup(true, func(raftGroup *raft.RawNode) (bool, error) {
+		raftGroup.TransferLeader(uint64(r.mu.replicaID))
+		return true, nil
+	}); err != nil {
+		log.Errorf(ctx, "unable to request Raft leadership: %s", err)
+		return
+	}
+	r.store.enqueueRaftUpdateCheck(r.RangeID)
+}
+
+// startRaftLeadershipColocationRepairer starts a goroutine which periodically
+// looks for leaseholders which have not been the Raft leader of their range
+// for longer than RaftLeadershipColocationThreshold and repairs them.
+func (s *Store) startRaftLeadershipColocationRepairer(ctx context.Context) {
+	s.stopper.RunWorker(ctx, func(ctx context.Context) {
+		ticker := time.NewTicker(raftLeadershipColocationScanInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.repairRaftLeadershipColocation(ctx)
+			case <-s.stopper.ShouldStop():
+				return
+			}
+		}
+	})
+}
+
+// repairRaftLeadershipColocation scans the replicas of the store for
+// leaseholders which are not the Raft leader of their range, and asks the
+// leaders of those which have been so for longer than the threshold to
+// transfer leadership to them.
+func (s *Store) repairRaftLeadershipColocation(ctx context.Context) {
+	s.raftLeadershipColocation.Lock()
+	defer s.raftLeadershipColocation.Unlock()
+
+	threshold := RaftLeadershipColocationThreshold.Get(&s.cfg.Settings.SV)
+	if threshold == 0 {
+		s.raftLeadershipColocation.since = nil
+		s.metrics.LeaseHolderNotRaftLeaderCount.Update(0)
+		return
+	}
+
+	now := timeutil.Now()
+	since := make(map[roachpb.RangeID]time.Time, len(s.raftLeadershipColocation.since))
+	var count int64
+	newStoreReplicaVisitor(s).Visit(func(repl *Replica) bool {
+		if !repl.leaseholderNotLeader() {
+			return true // more
+		}
+		start, ok := s.raftLeadershipColocation.since[repl.RangeID]
+		if !ok {
+			start = now
+		}
+		since[repl.RangeID] = start
+		if now.Sub(start) < threshold {
+			return true // more
+		}
+		count++
+		if s.TestingKnobs().DisableRaftLeadershipColocationRepair {
+			return true // more
+		}
+		replCtx := repl.AnnotateCtx(ctx)
+		log.VEventf(replCtx, 1, "leaseholder not Raft leader for %s; requesting leadership",
+			now.Sub(start))
+		s.metrics.RangeRaftLeadershipRequests.Inc(1)
+		repl.requestRaftLeadership(replCtx)
+		return true // more
+	})
+	s.raftLeadershipColocation.since = since
+	s.metrics.LeaseHolderNotRaftLeaderCount.Update(count)
+}
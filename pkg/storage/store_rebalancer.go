@@ -37,13 +37,6 @@ const (
 	// storeRebalancerTimerDuration is how frequently to check the store-level
 	// balance of the cluster.
 	storeRebalancerTimerDuration = time.Minute
-
-	// minQPSThresholdDifference is the minimum QPS difference from the cluster
-	// mean that this system should care about. In other words, we won't worry
-	// about rebalancing for QPS reasons if a store's QPS differs from the mean
-	// by less than this amount even if the amount is greater than the percentage
-	// threshold. This avoids too many lease transfers in lightly loaded clusters.
-	minQPSThresholdDifference = 100
 )
 
 var (
@@ -59,18 +52,34 @@ var (
 		Measurement: "Range Rebalances",
 		Unit:        metric.Unit_COUNT,
 	}
+	metaStoreRebalancerLeaseTransferDryRunCount = metric.Metadata{
+		Name:        "rebalancing.lease.transfers.dryrun",
+		Help:        "Number of lease transfers motivated by store-level load imbalances which were skipped because of the dry-run mode",
+		Measurement: "Lease Transfers",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaStoreRebalancerRangeRebalanceDryRunCount = metric.Metadata{
+		Name:        "rebalancing.range.rebalances.dryrun",
+		Help:        "Number of range rebalance operations motivated by store-level load imbalances which were skipped because of the dry-run mode",
+		Measurement: "Range Rebalances",
+		Unit:        metric.Unit_COUNT,
+	}
 )
 
 // StoreRebalancerMetrics is the set of metrics for the store-level rebalancer.
 type StoreRebalancerMetrics struct {
-	LeaseTransferCount  *metric.Counter
-	RangeRebalanceCount *metric.Counter
+	LeaseTransferCount        *metric.Counter
+	RangeRebalanceCount       *metric.Counter
+	LeaseTransferDryRunCount  *metric.Counter
+	RangeRebalanceDryRunCount *metric.Counter
 }
 
 func makeStoreRebalancerMetrics() StoreRebalancerMetrics {
 	return StoreRebalancerMetrics{
-		LeaseTransferCount:  metric.NewCounter(metaStoreRebalancerLeaseTransferCount),
-		RangeRebalanceCount: metric.NewCounter(metaStoreRebalancerRangeRebalanceCount),
+		LeaseTransferCount:        metric.NewCounter(metaStoreRebalancerLeaseTransferCount),
+		RangeRebalanceCount:       metric.NewCounter(metaStoreRebalancerRangeRebalanceCount),
+		LeaseTransferDryRunCount:  metric.NewCounter(metaStoreRebalancerLeaseTransferDryRunCount),
+		RangeRebalanceDryRunCount: metric.NewCounter(metaStoreRebalancerRangeRebalanceDryRunCount),
 	}
 }
 
@@ -99,6 +108,28 @@ var qpsRebalanceThreshold = settings.RegisterNonNegativeFloatSetting(
 	0.25,
 )
 
+// minQPSRebalanceDifference is the minimum QPS difference from the cluster
+// mean that the store rebalancer should care about. In other words, we won't
+// worry about rebalancing for QPS reasons if a store's QPS differs from the
+// mean by less than this amount even if the amount is greater than the
+// percentage threshold. This avoids too many lease transfers in lightly loaded
+// clusters.
+var minQPSRebalanceDifference = settings.RegisterNonNegativeFloatSetting(
+	"kv.allocator.min_qps_rebalance_difference",
+	"minimum difference from the mean in QPS (such as queries per second) a store must have before it is considered overfull or underfull by the store rebalancer",
+	100,
+)
+
+// loadBasedRebalancingDryRun, if set, makes the store rebalancer log the
+// lease transfers and replica rebalances it would perform instead of actually
+// performing them. This allows the effect of load-based rebalancing on a
+// cluster to be assessed before it is enabled.
+var loadBasedRebalancingDryRun = settings.RegisterBoolSetting(
+	"kv.allocator.load_based_rebalancing_dry_run.enabled",
+	"if set, load-based rebalancing only logs the lease transfers and replica rebalances it would perform",
+	false,
+)
+
 // LBRebalancingMode controls if and when we do store-level rebalancing
 // based on load.
 type LBRebalancingMode int64
@@ -210,6 +241,8 @@ func (sr *StoreRebalancer) rebalanceStore(
 	ctx context.Context, mode LBRebalancingMode, storeList StoreList,
 ) {
 	qpsThresholdFraction := qpsRebalanceThreshold.Get(&sr.st.SV)
+	minQPSThresholdDifference := minQPSRebalanceDifference.Get(&sr.st.SV)
+	dryRun := loadBasedRebalancingDryRun.Get(&sr.st.SV)
 
 	// First check if we should transfer leases away to better balance QPS.
 	qpsMinThreshold := math.Min(storeList.candidateQueriesPerSecond.mean*(1-qpsThresholdFraction),
@@ -250,19 +283,27 @@ func (sr *StoreRebalancer) rebalanceStore(
 			break
 		}
 
-		log.VEventf(ctx, 1, "transferring r%d (%.2f qps) to s%d to better balance load",
-			replWithStats.repl.RangeID, replWithStats.qps, target.StoreID)
-		if err := contextutil.RunWithTimeout(ctx, "transfer lease", sr.rq.processTimeout, func(ctx context.Context) error {
-			return sr.rq.transferLease(ctx, replWithStats.repl, target, replWithStats.qps)
-		}); err != nil {
-			log.Errorf(ctx, "unable to transfer lease to s%d: %v", target.StoreID, err)
-			continue
+		if dryRun {
+			log.Infof(ctx, "dry run: would transfer r%d (%.2f qps) to s%d to better balance load",
+				replWithStats.repl.RangeID, replWithStats.qps, target.StoreID)
+			sr.metrics.LeaseTransferDryRunCount.Inc(1)
+		} else {
+			log.VEventf(ctx, 1, "transferring r%d (%.2f qps) to s%d to better balance load",
+				replWithStats.repl.RangeID, replWithStats.qps, target.StoreID)
+			if err := contextutil.RunWithTimeout(ctx, "transfer lease", sr.rq.processTimeout, func(ctx context.Context) error {
+				return sr.rq.transferLease(ctx, replWithStats.repl, target, replWithStats.qps)
+			}); err != nil {
+				log.Errorf(ctx, "unable to transfer lease to s%d: %v", target.StoreID, err)
+				continue
+			}
+			sr.metrics.LeaseTransferCount.Inc(1)
 		}
-		sr.metrics.LeaseTransferCount.Inc(1)
 
 		// Finally, update our local copies of the descriptors so that if
 		// additional transfers are needed we'll be making the decisions with more
-		// up-to-date info. The StorePool copies are updated by transferLease.
+		// up-to-date info. The StorePool copies are updated by transferLease. In
+		// dry-run mode, only the local copies are updated so that the rest of
+		// this pass shows the decisions which would follow the transfer.
 		localDesc.Capacity.LeaseCount--
 		localDesc.Capacity.QueriesPerSecond -= replWithStats.qps
 		if otherDesc := storeMap[target.StoreID]; otherDesc != nil {
@@ -309,15 +350,21 @@ func (sr *StoreRebalancer) rebalanceStore(
 		}
 
 		descBeforeRebalance := replWithStats.repl.Desc()
-		log.VEventf(ctx, 1, "rebalancing r%d (%.2f qps) from %v to %v to better balance load",
-			replWithStats.repl.RangeID, replWithStats.qps, descBeforeRebalance.Replicas(), targets)
-		if err := contextutil.RunWithTimeout(ctx, "relocate range", sr.rq.processTimeout, func(ctx context.Context) error {
-			return sr.rq.store.AdminRelocateRange(ctx, *descBeforeRebalance, targets)
-		}); err != nil {
-			log.Errorf(ctx, "unable to relocate range to %v: %v", targets, err)
-			continue
+		if dryRun {
+			log.Infof(ctx, "dry run: would rebalance r%d (%.2f qps) from %v to %v to better balance load",
+				replWithStats.repl.RangeID, replWithStats.qps, descBeforeRebalance.Replicas(), targets)
+			sr.metrics.RangeRebalanceDryRunCount.Inc(1)
+		} else {
+			log.VEventf(ctx, 1, "rebalancing r%d (%.2f qps) from %v to %v to better balance load",
+				replWithStats.repl.RangeID, replWithStats.qps, descBeforeRebalance.Replicas(), targets)
+			if err := contextutil.RunWithTimeout(ctx, "relocate range", sr.rq.processTimeout, func(ctx context.Context) error {
+				return sr.rq.store.AdminRelocateRange(ctx, *descBeforeRebalance, targets)
+			}); err != nil {
+				log.Errorf(ctx, "unable to relocate range to %v: %v", targets, err)
+				continue
+			}
+			sr.metrics.RangeRebalanceCount.Inc(1)
 		}
-		sr.metrics.RangeRebalanceCount.Inc(1)
 
 		// Finally, update our local copies of the descriptors so that if
 		// additional transfers are needed we'll be making the decisions with more
@@ -192,6 +192,78 @@ func TestChooseLeaseToTransfer(t *testing.T) {
 	}
 }
 
+func TestStoreRebalancerDryRun(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+	stopper, g, _, a, _ := createTestAllocator(10, false /* deterministic */)
+	defer stopper.Stop(ctx)
+	gossiputil.NewStoreGossiper(g).GossipStores(noLocalityStores, t)
+
+	localDesc := *noLocalityStores[0]
+	cfg := TestStoreConfig(nil)
+	s := createTestStoreWithoutStart(t, stopper, testStoreOpts{createSystemRanges: true}, &cfg)
+	s.Ident = &roachpb.StoreIdent{StoreID: localDesc.StoreID}
+	rq := newReplicateQueue(s, g, a)
+	rr := newReplicaRankings()
+
+	sr := NewStoreRebalancer(cfg.AmbientCtx, cfg.Settings, rq, rr)
+	sr.getRaftStatusFn = func(r *Replica) *raft.Status {
+		status := &raft.Status{
+			Progress: make(map[uint64]raft.Progress),
+		}
+		status.Lead = uint64(r.ReplicaID())
+		status.Commit = 1
+		for _, replica := range r.Desc().InternalReplicas {
+			status.Progress[uint64(replica.ReplicaID)] = raft.Progress{
+				Match: 1,
+				State: raft.ProgressStateReplicate,
+			}
+		}
+		return status
+	}
+	loadBasedRebalancingDryRun.Override(&cfg.Settings.SV, true)
+
+	// In dry-run mode, the store rebalancer goes through the motions without
+	// transferring leases or relocating ranges, which would fail since none of
+	// the test replicas are backed by a raft group.
+	testCases := []struct {
+		storeIDs           []roachpb.StoreID
+		qps                float64
+		expectLeaseDryRuns int64
+		expectRangeDryRuns int64
+	}{
+		// s1 can get rid of the load by transferring the lease to s5.
+		{[]roachpb.StoreID{1, 5}, 300, 1, 0},
+		// s1 has to move its only replica to s5 to get rid of the load.
+		{[]roachpb.StoreID{1}, 500, 0, 1},
+	}
+	for _, tc := range testCases {
+		t.Run("", func(t *testing.T) {
+			s.cfg.DefaultZoneConfig.NumReplicas = proto.Int32(int32(len(tc.storeIDs)))
+			loadRanges(rr, s, []testRange{{storeIDs: tc.storeIDs, qps: tc.qps}})
+			storeList, _, _ := a.storePool.getStoreList(firstRange, storeFilterThrottled)
+
+			leaseDryRuns := sr.metrics.LeaseTransferDryRunCount.Count()
+			rangeDryRuns := sr.metrics.RangeRebalanceDryRunCount.Count()
+			sr.rebalanceStore(ctx, LBRebalancingLeasesAndReplicas, storeList)
+
+			if a, e := sr.metrics.LeaseTransferDryRunCount.Count()-leaseDryRuns, tc.expectLeaseDryRuns; a != e {
+				t.Errorf("expected %d dry-run lease transfers, got %d", e, a)
+			}
+			if a, e := sr.metrics.RangeRebalanceDryRunCount.Count()-rangeDryRuns, tc.expectRangeDryRuns; a != e {
+				t.Errorf("expected %d dry-run range rebalances, got %d", e, a)
+			}
+			if a := sr.metrics.LeaseTransferCount.Count(); a != 0 {
+				t.Errorf("expected no lease transfers, got %d", a)
+			}
+			if a := sr.metrics.RangeRebalanceCount.Count(); a != 0 {
+				t.Errorf("expected no range rebalances, got %d", a)
+			}
+		})
+	}
+}
+
 func TestChooseReplicaToRebalance(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -28,6 +28,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
 	"github.com/cockroachdb/cockroach/pkg/util/envutil"
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/limit"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
@@ -101,11 +102,23 @@ func assertStrategy(
 type kvBatchSnapshotStrategy struct {
 	raftCfg *base.RaftConfig
 	status  string
+	// limiter paces the batches sent or received.
+	limiter *limit.RateLimiter
 
 	// Fields used when sending snapshots.
 	batchSize int64
-	limiter   *rate.Limiter
 	newBatch  func() engine.Batch
+	// skip is the number of leading KV batches which the recipient retained
+	// from an interrupted stream for the same snapshot and which aren't sent
+	// again. batches counts the KV batches produced so far.
+	skip    int64
+	batches int64
+
+	// Fields used when receiving snapshots.
+	//
+	// received holds the KV batches received so far, starting with those
+	// retained from an interrupted stream for the same snapshot.
+	received [][]byte
 }
 
 // Send implements the snapshotStrategy interface.
@@ -114,7 +127,6 @@ func (kvSS *kvBatchSnapshotStrategy) Receive(
 ) (IncomingSnapshot, error) {
 	assertStrategy(ctx, header, SnapshotRequest_KV_BATCH)
 
-	var batches [][]byte
 	var logEntries [][]byte
 	for {
 		req, err := stream.Recv()
@@ -127,7 +139,10 @@ func (kvSS *kvBatchSnapshotStrategy) Receive(
 		}
 
 		if req.KVBatch != nil {
-			batches = append(batches, req.KVBatch)
+			if err := kvSS.limiter.WaitN(ctx, len(req.KVBatch)); err != nil {
+				return IncomingSnapshot{}, err
+			}
+			kvSS.received = append(kvSS.received, req.KVBatch)
 		}
 		if req.LogEntries != nil {
 			logEntries = append(logEntries, req.LogEntries...)
@@ -142,7 +157,7 @@ func (kvSS *kvBatchSnapshotStrategy) Receive(
 			inSnap := IncomingSnapshot{
 				UsesUnreplicatedTruncatedState: header.UnreplicatedTruncatedState,
 				SnapUUID:                       snapUUID,
-				Batches:                        batches,
+				Batches:                        kvSS.received,
 				LogEntries:                     logEntries,
 				State:                          &header.State,
 				snapType:                       snapTypeRaft,
@@ -150,7 +165,7 @@ func (kvSS *kvBatchSnapshotStrategy) Receive(
 			if header.RaftMessageRequest.ToReplica.ReplicaID == 0 {
 				inSnap.snapType = snapTypePreemptive
 			}
-			kvSS.status = fmt.Sprintf("kv batches: %d, log entries: %d", len(batches), len(logEntries))
+			kvSS.status = fmt.Sprintf("kv batches: %d, log entries: %d", len(kvSS.received), len(logEntries))
 			return inSnap, nil
 		}
 	}
@@ -187,10 +202,7 @@ func (kvSS *kvBatchSnapshotStrategy) Send(
 		}
 
 		if int64(b.Len()) >= kvSS.batchSize {
-			if err := kvSS.limiter.WaitN(ctx, 1); err != nil {
-				return err
-			}
-			if err := kvSS.sendBatch(stream, b); err != nil {
+			if err := kvSS.sendBatch(ctx, stream, b); err != nil {
 				return err
 			}
 			b = nil
@@ -201,10 +213,7 @@ func (kvSS *kvBatchSnapshotStrategy) Send(
 		}
 	}
 	if b != nil {
-		if err := kvSS.limiter.WaitN(ctx, 1); err != nil {
-			return err
-		}
-		if err := kvSS.sendBatch(stream, b); err != nil {
+		if err := kvSS.sendBatch(ctx, stream, b); err != nil {
 			return err
 		}
 	}
@@ -323,15 +332,32 @@ func (kvSS *kvBatchSnapshotStrategy) Send(
 		}
 	}
 	kvSS.status = fmt.Sprintf("kv pairs: %d, log entries: %d", n, len(logEntries))
-	return stream.Send(&SnapshotRequest{LogEntries: logEntries})
+	if kvSS.skip > 0 {
+		kvSS.status += fmt.Sprintf(", resumed after %d kv batches", kvSS.skip)
+	}
+	if err := stream.Send(&SnapshotRequest{LogEntries: logEntries}); err != nil {
+		return &errSnapshotStreamInterrupted{batches: kvSS.batches, cause: err}
+	}
+	return nil
 }
 
+// sendBatch sends the given batch to the recipient, unless the recipient
+// retained it from an interrupted stream. The batch is closed in either case.
 func (kvSS *kvBatchSnapshotStrategy) sendBatch(
-	stream outgoingSnapshotStream, batch engine.Batch,
+	ctx context.Context, stream outgoingSnapshotStream, batch engine.Batch,
 ) error {
-	repr := batch.Repr()
-	batch.Close()
-	return stream.Send(&SnapshotRequest{KVBatch: repr})
+	defer batch.Close()
+	kvSS.batches++
+	if kvSS.batches <= kvSS.skip {
+		return nil
+	}
+	if err := kvSS.limiter.WaitN(ctx, batch.Len()); err != nil {
+		return err
+	}
+	if err := stream.Send(&SnapshotRequest{KVBatch: batch.Repr()}); err != nil {
+		return &errSnapshotStreamInterrupted{batches: kvSS.batches - 1, cause: err}
+	}
+	return nil
 }
 
 // Status implements the snapshotStrategy interface.
@@ -349,27 +375,21 @@ func (s *Store) reserveSnapshot(
 		// apply. This vastly speeds up rebalancing any empty ranges created by a
 		// RESTORE or manual SPLIT AT, since it prevents these empty snapshots from
 		// getting stuck behind large snapshots managed by the replicate queue.
-	} else if header.CanDecline {
-		storeDesc, ok := s.cfg.StorePool.getStoreDescriptor(s.StoreID())
-		if ok && (!maxCapacityCheck(storeDesc) || header.RangeSize > storeDesc.Capacity.Available) {
-			return nil, snapshotStoreTooFullMsg, nil
-		}
-		select {
-		case s.snapshotApplySem <- struct{}{}:
-		case <-ctx.Done():
-			return nil, "", ctx.Err()
-		case <-s.stopper.ShouldStop():
-			return nil, "", errors.Errorf("stopped")
-		default:
-			return nil, snapshotApplySemBusyMsg, nil
-		}
 	} else {
-		select {
-		case s.snapshotApplySem <- struct{}{}:
-		case <-ctx.Done():
-			return nil, "", ctx.Err()
-		case <-s.stopper.ShouldStop():
-			return nil, "", errors.Errorf("stopped")
+		if header.CanDecline {
+			storeDesc, ok := s.cfg.StorePool.getStoreDescriptor(s.StoreID())
+			if ok && (!maxCapacityCheck(storeDesc) || header.RangeSize > storeDesc.Capacity.Available) {
+				return nil, snapshotStoreTooFullMsg, nil
+			}
+			if header.Priority == SnapshotRequest_REBALANCE {
+				if err := s.checkDiskFull(ctx, "rebalancing snapshots", diskFullRebalanceThreshold); err != nil {
+					return nil, err.Error(), nil
+				}
+			}
+		}
+		rejectionMsg, err := s.snapshotRecvQueue.acquire(ctx, s.stopper, header.Priority, header.CanDecline)
+		if err != nil || rejectionMsg != "" {
+			return nil, rejectionMsg, err
 		}
 	}
 
@@ -396,7 +416,7 @@ func (s *Store) reserveSnapshot(
 		s.metrics.ReservedReplicaCount.Dec(1)
 		s.metrics.Reserved.Dec(header.RangeSize)
 		if header.RangeSize != 0 {
-			<-s.snapshotApplySem
+			s.snapshotRecvQueue.release()
 		}
 	}, "", nil
 }
@@ -631,6 +651,17 @@ func (s *Store) receiveSnapshot(
 		)
 	}
 
+	// A resumable snapshot picks up the KV batches retained from an earlier
+	// stream for the same snapshot which was interrupted, if there is one.
+	var snapUUID uuid.UUID
+	var resumed [][]byte
+	if header.Resumable {
+		if snapUUID, err = uuid.FromBytes(header.RaftMessageRequest.Message.Snapshot.Data); err != nil {
+			return sendSnapshotError(stream, errors.Wrap(err, "invalid snapshot"))
+		}
+		resumed = s.snapshotResumeCache.claim(snapUUID, timeutil.Now())
+	}
+
 	// Determine which snapshot strategy the sender is using to send this
 	// snapshot. If we don't know how to handle the specified strategy, return
 	// an error.
@@ -638,7 +669,9 @@ func (s *Store) receiveSnapshot(
 	switch header.Strategy {
 	case SnapshotRequest_KV_BATCH:
 		ss = &kvBatchSnapshotStrategy{
-			raftCfg: &s.cfg.RaftConfig,
+			raftCfg:  &s.cfg.RaftConfig,
+			limiter:  s.snapshotRecvQueue.limiter(header.Priority),
+			received: resumed,
 		}
 	default:
 		return sendSnapshotError(stream,
@@ -647,17 +680,38 @@ func (s *Store) receiveSnapshot(
 		)
 	}
 
-	if err := stream.Send(&SnapshotResponse{Status: SnapshotResponse_ACCEPTED}); err != nil {
+	if err := stream.Send(&SnapshotResponse{
+		Status:          SnapshotResponse_ACCEPTED,
+		ResumeKVBatches: int64(len(resumed)),
+	}); err != nil {
 		return err
 	}
 	if log.V(2) {
 		log.Infof(ctx, "accepted snapshot reservation for r%d", header.State.Desc.RangeID)
 	}
+	if len(resumed) > 0 {
+		s.metrics.RangeSnapshotsRecvResumed.Inc(1)
+		log.Infof(ctx, "resuming snapshot %s for r%d after %d kv batches",
+			snapUUID.Short(), header.State.Desc.RangeID, len(resumed))
+	}
 
 	inSnap, err := ss.Receive(ctx, stream, *header)
 	if err != nil {
+		// Hold on to what was received so far in case the sender reconnects
+		// to resume the snapshot.
+		kvSS, ok := ss.(*kvBatchSnapshotStrategy)
+		if timeout := snapshotResumeTimeout.Get(&s.cfg.Settings.SV); ok && header.Resumable && timeout > 0 {
+			now := timeutil.Now()
+			s.snapshotResumeCache.retain(snapUUID, kvSS.received, now, now.Add(timeout))
+		}
 		return err
 	}
+	if err := s.maybeWriteSnapshotSSTs(ctx, &inSnap); err != nil {
+		return sendSnapshotError(stream, errors.Wrap(err, "failed to write snapshot SSTables"))
+	}
+	if inSnap.ssts != nil {
+		defer removeSnapshotSSTs(s.engine, inSnap.ssts.paths)
+	}
 	if err := s.processRaftSnapshotRequest(ctx, header, inSnap); err != nil {
 		return sendSnapshotError(stream, errors.Wrap(err.GoError(), "failed to apply snapshot"))
 	}
@@ -679,11 +733,15 @@ type SnapshotStorePool interface {
 
 // rebalanceSnapshotRate is the rate at which preemptive snapshots can be sent.
 // This includes snapshots generated for upreplication or for rebalancing.
-var rebalanceSnapshotRate = settings.RegisterByteSizeSetting(
-	"kv.snapshot_rebalance.max_rate",
-	"the rate limit (bytes/sec) to use for rebalance and upreplication snapshots",
-	envutil.EnvOrDefaultBytes("COCKROACH_PREEMPTIVE_SNAPSHOT_RATE", 8<<20),
-)
+var rebalanceSnapshotRate = func() *settings.ByteSizeSetting {
+	s := settings.RegisterByteSizeSetting(
+		"kv.snapshot_rebalance.max_rate",
+		"the rate limit (bytes/sec) to use for rebalance and upreplication snapshots",
+		envutil.EnvOrDefaultBytes("COCKROACH_PREEMPTIVE_SNAPSHOT_RATE", 8<<20),
+	)
+	s.SetNodeOverridable()
+	return s
+}()
 
 // recoverySnapshotRate is the rate at which Raft-initiated spanshots can be
 // sent. Ideally, one would never see a Raft-initiated snapshot; we'd like all
@@ -691,11 +749,40 @@ var rebalanceSnapshotRate = settings.RegisterByteSizeSetting(
 // completely get rid of them.
 // TODO(tbg): The existence of this rate, separate from rebalanceSnapshotRate,
 // does not make a whole lot of sense.
-var recoverySnapshotRate = settings.RegisterByteSizeSetting(
-	"kv.snapshot_recovery.max_rate",
-	"the rate limit (bytes/sec) to use for recovery snapshots",
-	envutil.EnvOrDefaultBytes("COCKROACH_RAFT_SNAPSHOT_RATE", 8<<20),
-)
+var recoverySnapshotRate = func() *settings.ByteSizeSetting {
+	s := settings.RegisterByteSizeSetting(
+		"kv.snapshot_recovery.max_rate",
+		"the rate limit (bytes/sec) to use for recovery snapshots",
+		envutil.EnvOrDefaultBytes("COCKROACH_RAFT_SNAPSHOT_RATE", 8<<20),
+	)
+	s.SetNodeOverridable()
+	return s
+}()
+
+// recoverySnapshotRecvRate is the rate at which a store receives the data of
+// recovery snapshots.
+var recoverySnapshotRecvRate = func() *settings.ByteSizeSetting {
+	s := settings.RegisterByteSizeSetting(
+		"kv.snapshot_recovery.max_recv_rate",
+		"the rate limit (bytes/sec) at which a store receives recovery snapshots",
+		1<<40,
+	)
+	s.SetNodeOverridable()
+	return s
+}()
+
+// rebalanceSnapshotRecvRate is the rate at which a store receives the data of
+// rebalancing snapshots. Setting it below recoverySnapshotRecvRate keeps
+// rebalancing from crowding out recovery.
+var rebalanceSnapshotRecvRate = func() *settings.ByteSizeSetting {
+	s := settings.RegisterByteSizeSetting(
+		"kv.snapshot_rebalance.max_recv_rate",
+		"the rate limit (bytes/sec) at which a store receives rebalance and upreplication snapshots",
+		1<<40,
+	)
+	s.SetNodeOverridable()
+	return s
+}()
 
 func snapshotRateLimit(
 	st *cluster.Settings, priority SnapshotRequest_Priority,
@@ -732,6 +819,7 @@ func sendSnapshot(
 	snap *OutgoingSnapshot,
 	newBatch func() engine.Batch,
 	sent func(),
+	storeLimiter *limit.RateLimiter,
 ) error {
 	start := timeutil.Now()
 	to := header.RaftMessageRequest.ToReplica
@@ -772,7 +860,17 @@ func sendSnapshot(
 		return err
 	}
 
-	log.Infof(ctx, "sending %s", snap)
+	// The recipient tells us how many KV batches it retained from an earlier,
+	// interrupted stream for this snapshot. Those don't need to be sent again.
+	var skip int64
+	if header.Resumable {
+		skip = resp.ResumeKVBatches
+	}
+	if skip > 0 {
+		log.Infof(ctx, "resuming %s after %d kv batches", snap, skip)
+	} else {
+		log.Infof(ctx, "sending %s", snap)
+	}
 
 	// The size of batches to send. This is the granularity of rate limiting.
 	const batchSize = 256 << 10 // 256 KB
@@ -781,14 +879,15 @@ func sendSnapshot(
 		return errors.Wrapf(err, "%s", to)
 	}
 
-	// Convert the bytes/sec rate limit to batches/sec.
+	// The limiter is a child of the store's snapshot send limiter so that the
+	// snapshot also draws from the store-wide background IO budget. Its burst
+	// is a single batch, which preserves the batches/sec behavior of rate
+	// limiting at batch granularity.
 	//
-	// TODO(peter): Using bytes/sec for rate limiting seems more natural but has
-	// practical difficulties. We either need to use a very large burst size
-	// which seems to disable the rate limiting, or call WaitN in smaller than
-	// burst size chunks which caused excessive slowness in testing. Would be
-	// nice to figure this out, but the batches/sec rate limit works for now.
-	limiter := rate.NewLimiter(targetRate/batchSize, 1 /* burst size */)
+	// TODO(peter): Using bytes/sec for rate limiting with a burst size smaller
+	// than a batch caused excessive slowness in testing. Would be nice to
+	// figure this out, but batch-granular rate limiting works for now.
+	limiter := storeLimiter.NewChild("snapshot", targetRate, batchSize)
 
 	// Create a snapshotStrategy based on the desired snapshot strategy.
 	var ss snapshotStrategy
@@ -799,6 +898,7 @@ func sendSnapshot(
 			batchSize: batchSize,
 			limiter:   limiter,
 			newBatch:  newBatch,
+			skip:      skip,
 		}
 	default:
 		log.Fatalf(ctx, "unknown snapshot strategy: %s", header.Strategy)
@@ -17,6 +17,8 @@ package storage
 
 import (
 	"context"
+	"fmt"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -25,7 +27,9 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/storage/rditer"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/limit"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/pkg/errors"
 	"go.etcd.io/etcd/raft/raftpb"
 	"golang.org/x/time/rate"
 )
@@ -72,7 +76,7 @@ func TestSnapshotRaftLogLimit(t *testing.T) {
 
 			ss := kvBatchSnapshotStrategy{
 				raftCfg:  &store.cfg.RaftConfig,
-				limiter:  rate.NewLimiter(1<<10, 1),
+				limiter:  limit.NewRateLimiter("test", rate.Inf, 1),
 				newBatch: eng.NewBatch,
 			}
 			iter := rditer.NewReplicaDataIterator(repl.Desc(), snap, true /* replicatedOnly */)
@@ -146,3 +150,107 @@ func TestSnapshotPreemptiveOnUninitializedReplica(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// batchRecordingSnapshotStream records the KV batches sent on it and breaks
+// after failAfter of them if failAfter is positive.
+type batchRecordingSnapshotStream struct {
+	failAfter int
+	batches   [][]byte
+}
+
+func (s *batchRecordingSnapshotStream) Recv() (*SnapshotResponse, error) {
+	return nil, errors.New("unexpected Recv")
+}
+
+func (s *batchRecordingSnapshotStream) Send(req *SnapshotRequest) error {
+	if req.KVBatch == nil {
+		return nil
+	}
+	if s.failAfter > 0 && len(s.batches) == s.failAfter {
+		return errors.New("connection reset by peer")
+	}
+	s.batches = append(s.batches, append([]byte(nil), req.KVBatch...))
+	return nil
+}
+
+// TestSnapshotResume verifies that a snapshot whose stream broke can be
+// resumed by skipping the KV batches the recipient already has, and that the
+// batches received across both streams are exactly those of an uninterrupted
+// snapshot.
+func TestSnapshotResume(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	ctx := context.Background()
+	stopper := stop.NewStopper()
+	defer stopper.Stop(ctx)
+
+	store, _ := createTestStore(t, testStoreOpts{createSystemRanges: false}, stopper)
+	repl, err := store.GetReplica(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob := []byte(strings.Repeat("a", 1024))
+	for i := 0; i < 100; i++ {
+		pArgs := putArgs(roachpb.Key(fmt.Sprintf("a%03d", i)), blob)
+		if _, pErr := client.SendWrappedWith(ctx, store, roachpb.Header{RangeID: 1}, &pArgs); pErr != nil {
+			t.Fatal(pErr)
+		}
+	}
+
+	eng := store.Engine()
+	snap := eng.NewSnapshot()
+	defer snap.Close()
+	header := SnapshotRequest_Header{
+		State:     repl.State().ReplicaState,
+		Resumable: true,
+	}
+	// Don't send any log entries.
+	header.State.TruncatedState = &roachpb.RaftTruncatedState{Index: header.State.RaftAppliedIndex}
+
+	send := func(stream outgoingSnapshotStream, skip int64) error {
+		ss := kvBatchSnapshotStrategy{
+			raftCfg:   &store.cfg.RaftConfig,
+			limiter:   limit.NewRateLimiter("test", rate.Inf, 1),
+			batchSize: 8 << 10,
+			newBatch:  eng.NewBatch,
+			skip:      skip,
+		}
+		outSnap := &OutgoingSnapshot{
+			Iter:       rditer.NewReplicaDataIterator(repl.Desc(), snap, true /* replicatedOnly */),
+			EngineSnap: snap,
+			State:      header.State,
+			snapType:   snapTypeRaft,
+			RaftSnap: raftpb.Snapshot{
+				Metadata: raftpb.SnapshotMetadata{
+					Index: header.State.RaftAppliedIndex,
+				},
+			},
+		}
+		defer outSnap.Iter.Close()
+		return ss.Send(ctx, stream, header, outSnap)
+	}
+
+	var full batchRecordingSnapshotStream
+	if err := send(&full, 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(full.batches) < 4 {
+		t.Fatalf("expected at least 4 kv batches, got %d", len(full.batches))
+	}
+
+	interrupted := batchRecordingSnapshotStream{failAfter: len(full.batches) / 2}
+	err = send(&interrupted, 0)
+	if e, ok := err.(*errSnapshotStreamInterrupted); !ok {
+		t.Fatalf("expected stream interruption, got %v", err)
+	} else if e.batches != int64(len(interrupted.batches)) {
+		t.Fatalf("expected interruption after %d kv batches, got %d", len(interrupted.batches), e.batches)
+	}
+
+	var resumed batchRecordingSnapshotStream
+	if err := send(&resumed, int64(len(interrupted.batches))); err != nil {
+		t.Fatal(err)
+	}
+	if got := append(interrupted.batches, resumed.batches...); !reflect.DeepEqual(got, full.batches) {
+		t.Fatalf("expected %d kv batches equal to those of the full snapshot, got %d",
+			len(full.batches), len(got))
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"math/rand"
 	"reflect"
 	"sort"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -199,7 +200,7 @@ func createTestStoreWithoutStart(
 
 	rpcContext := rpc.NewContext(
 		cfg.AmbientCtx, &base.Config{Insecure: true}, cfg.Clock,
-		stopper, &cfg.Settings.Version)
+		stopper, cfg.Settings)
 	server := rpc.NewServer(rpcContext) // never started
 	cfg.Gossip = gossip.NewTest(1, rpcContext, server, stopper, metric.NewRegistry(), cfg.DefaultZoneConfig)
 	cfg.StorePool = NewTestStorePool(*cfg)
@@ -3114,7 +3115,7 @@ func TestSendSnapshotThrottling(t *testing.T) {
 		sp := &fakeStorePool{}
 		expectedErr := errors.New("")
 		c := fakeSnapshotStream{nil, expectedErr}
-		err := sendSnapshot(ctx, &cfg, st, c, sp, header, nil, newBatch, nil)
+		err := sendSnapshot(ctx, &cfg, st, c, sp, header, nil, newBatch, nil, nil)
 		if sp.failedThrottles != 1 {
 			t.Fatalf("expected 1 failed throttle, but found %d", sp.failedThrottles)
 		}
@@ -3130,7 +3131,7 @@ func TestSendSnapshotThrottling(t *testing.T) {
 			Status: SnapshotResponse_DECLINED,
 		}
 		c := fakeSnapshotStream{resp, nil}
-		err := sendSnapshot(ctx, &cfg, st, c, sp, header, nil, newBatch, nil)
+		err := sendSnapshot(ctx, &cfg, st, c, sp, header, nil, newBatch, nil, nil)
 		if sp.declinedThrottles != 1 {
 			t.Fatalf("expected 1 declined throttle, but found %d", sp.declinedThrottles)
 		}
@@ -3147,7 +3148,7 @@ func TestSendSnapshotThrottling(t *testing.T) {
 			Status: SnapshotResponse_DECLINED,
 		}
 		c := fakeSnapshotStream{resp, nil}
-		err := sendSnapshot(ctx, &cfg, st, c, sp, header, nil, newBatch, nil)
+		err := sendSnapshot(ctx, &cfg, st, c, sp, header, nil, newBatch, nil, nil)
 		if sp.failedThrottles != 1 {
 			t.Fatalf("expected 1 failed throttle, but found %d", sp.failedThrottles)
 		}
@@ -3163,7 +3164,7 @@ func TestSendSnapshotThrottling(t *testing.T) {
 			Status: SnapshotResponse_ERROR,
 		}
 		c := fakeSnapshotStream{resp, nil}
-		err := sendSnapshot(ctx, &cfg, st, c, sp, header, nil, newBatch, nil)
+		err := sendSnapshot(ctx, &cfg, st, c, sp, header, nil, newBatch, nil, nil)
 		if sp.failedThrottles != 1 {
 			t.Fatalf("expected 1 failed throttle, but found %d", sp.failedThrottles)
 		}
@@ -3344,6 +3345,80 @@ func TestReserveSnapshotFullnessLimit(t *testing.T) {
 	}
 }
 
+// TestStoreDiskFull verifies that a store which is nearly out of disk space
+// rejects bulk ingestion and declines inbound rebalancing snapshots, but still
+// accepts other snapshots.
+func TestStoreDiskFull(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	stopper := stop.NewStopper()
+	defer stopper.Stop(context.TODO())
+	tc := testContext{}
+	tc.Start(t, stopper)
+	s := tc.store
+
+	ctx := context.Background()
+
+	setAvailable := func(available int64) {
+		s.cachedCapacity.Lock()
+		s.cachedCapacity.StoreCapacity = roachpb.StoreCapacity{Capacity: 1000, Available: available}
+		s.cachedCapacity.Unlock()
+	}
+	reserve := func(priority SnapshotRequest_Priority) string {
+		t.Helper()
+		cleanup, rejectionMsg, err := s.reserveSnapshot(ctx, &SnapshotRequest_Header{
+			RangeSize:  1,
+			CanDecline: true,
+			Priority:   priority,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cleanup != nil {
+			cleanup()
+		}
+		return rejectionMsg
+	}
+	const expMsg = "nearly out of disk space"
+
+	setAvailable(10)
+	var ba roachpb.BatchRequest
+	ba.RangeID = 1
+	ba.Add(&roachpb.AddSSTableRequest{
+		RequestHeader: roachpb.RequestHeader{Key: roachpb.Key("a"), EndKey: roachpb.Key("b")},
+	})
+	_, pErr := s.Send(ctx, ba)
+	if _, ok := pErr.GetDetail().(*roachpb.DiskFullError); !ok || !testutils.IsPError(pErr, expMsg) {
+		t.Fatalf("expected AddSSTable to be rejected with a DiskFullError, got %v", pErr)
+	}
+	if msg := reserve(SnapshotRequest_REBALANCE); !strings.Contains(msg, expMsg) {
+		t.Fatalf("expected rebalancing snapshot to be declined, got %q", msg)
+	}
+	if msg := reserve(SnapshotRequest_RECOVERY); msg != "" {
+		t.Fatalf("expected recovery snapshot to be accepted, got %q", msg)
+	}
+
+	// Above the thresholds, nothing is rejected.
+	setAvailable(100)
+	if err := s.checkDiskFull(ctx, "bulk ingestion", diskFullIngestThreshold); err != nil {
+		t.Fatal(err)
+	}
+	if msg := reserve(SnapshotRequest_REBALANCE); msg != "" {
+		t.Fatalf("expected rebalancing snapshot to be accepted, got %q", msg)
+	}
+
+	// Neither is anything rejected when the thresholds are disabled.
+	setAvailable(10)
+	diskFullIngestThreshold.Override(&s.cfg.Settings.SV, 0)
+	diskFullRebalanceThreshold.Override(&s.cfg.Settings.SV, 0)
+	if err := s.checkDiskFull(ctx, "bulk ingestion", diskFullIngestThreshold); err != nil {
+		t.Fatal(err)
+	}
+	if msg := reserve(SnapshotRequest_REBALANCE); msg != "" {
+		t.Fatalf("expected rebalancing snapshot to be accepted, got %q", msg)
+	}
+}
+
 func TestSnapshotRateLimit(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 
@@ -21,8 +21,11 @@ import (
 
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/storage/storagebase"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
 )
 
 // Server implements PerReplicaServer.
@@ -149,3 +152,20 @@ func (is Server) WaitForReplicaInit(
 	})
 	return resp, err
 }
+
+// CollectRaftApplicationTrace implements PerReplicaServer.
+func (is Server) CollectRaftApplicationTrace(
+	ctx context.Context, req *CollectRaftApplicationTraceRequest,
+) (*CollectRaftApplicationTraceResponse, error) {
+	resp := &CollectRaftApplicationTraceResponse{}
+	err := is.execStoreCommand(req.StoreRequestHeader, func(s *Store) error {
+		sp := s.raftAppTraces.lookup(req.RangeID, storagebase.CmdIDKey(req.CommandID), timeutil.Now())
+		if sp == nil || !tracing.IsRecording(sp) {
+			// The trace is gone; there is nothing left to import the recording
+			// into.
+			return nil
+		}
+		return tracing.ImportRemoteSpans(sp, req.Spans)
+	})
+	return resp, err
+}
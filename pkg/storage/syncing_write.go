@@ -24,9 +24,9 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
 	"github.com/cockroachdb/cockroach/pkg/storage/engine"
+	"github.com/cockroachdb/cockroach/pkg/util/limit"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
-	"golang.org/x/time/rate"
 )
 
 // bulkIOWriteBurst is the burst for the BulkIOWriteLimiter.
@@ -34,9 +34,9 @@ const bulkIOWriteBurst = 2 * 1024 * 1024 // 2MB
 
 const bulkIOWriteLimiterLongWait = 500 * time.Millisecond
 
-func limitBulkIOWrite(ctx context.Context, limiter *rate.Limiter, cost int) {
-	// The limiter disallows anything greater than its burst (set to
-	// BulkIOWriteLimiterBurst), so cap the batch size if it would overflow.
+func limitBulkIOWrite(ctx context.Context, limiter *limit.RateLimiter, cost int) {
+	// Cap the cost at the burst (set to bulkIOWriteBurst) so that a single
+	// write never waits on more than one acquisition.
 	//
 	// TODO(dan): This obviously means the limiter is no longer accounting for the
 	// full cost. I've tried calling WaitN in a loop to fully cover the cost, but
@@ -79,7 +79,7 @@ func writeFileSyncing(
 	eng engine.Engine,
 	perm os.FileMode,
 	settings *cluster.Settings,
-	limiter *rate.Limiter,
+	limiter *limit.RateLimiter,
 ) error {
 	chunkSize := sstWriteSyncRate.Get(&settings.SV)
 	sync := true
@@ -107,8 +107,13 @@ type StoreTestingKnobs struct {
 	DisableGCQueue bool
 	// DisableMergeQueue disables the merge queue.
 	DisableMergeQueue bool
-	// DisableReplicaGCQueue disables the replica GC queue.
+	// DisableReplicaGCQueue disables the replica GC queue, including the
+	// eager removal of replicas which applied their own removal.
 	DisableReplicaGCQueue bool
+	// DisableEagerReplicaRemoval makes replicas which applied their own
+	// removal wait for the replica GC queue instead of being removed at the
+	// end of the Raft ready iteration.
+	DisableEagerReplicaRemoval bool
 	// DisableReplicateQueue disables the replication queue.
 	DisableReplicateQueue bool
 	// DisableReplicaRebalancing disables rebalancing of replicas but otherwise
@@ -132,6 +137,10 @@ type StoreTestingKnobs struct {
 	// DisableLeaderFollowsLeaseholder disables attempts to transfer raft
 	// leadership when it diverges from the range's leaseholder.
 	DisableLeaderFollowsLeaseholder bool
+	// DisableRaftLeadershipColocationRepair disables the requests for Raft
+	// leadership made by leaseholders which have not been the Raft leader of
+	// their range for an extended period.
+	DisableRaftLeadershipColocationRepair bool
 	// DisableRefreshReasonNewLeader disables refreshing pending commands when a new
 	// leader is discovered.
 	DisableRefreshReasonNewLeader bool
@@ -157,6 +166,10 @@ type StoreTestingKnobs struct {
 	// process ranges that need to be split, for use in tests that use
 	// the replication queue but disable the split queue.
 	ReplicateQueueAcceptsUnsplit bool
+	// ReplicaAddStopAfterLearnerSnapshot, if set and returning true, makes the
+	// addition of a replica via a learner return once the learner has received
+	// its snapshot, leaving it unpromoted.
+	ReplicaAddStopAfterLearnerSnapshot func() bool
 	// SplitQueuePurgatoryChan allows a test to control the channel used to
 	// trigger split queue purgatory processing.
 	SplitQueuePurgatoryChan <-chan time.Time
@@ -86,13 +86,48 @@ type Cache interface {
 	getLowWater(readCache bool) hlc.Timestamp
 }
 
+// Impl identifies one of the Cache implementations.
+type Impl int64
+
+const (
+	// SklImpl is the Cache implementation backed by a pair of interval
+	// skiplists made up of fixed-size pages, which are rotated out as they
+	// fill up. It is the default.
+	SklImpl Impl = iota
+	// TreeImpl is the Cache implementation backed by a pair of interval trees
+	// with FIFO eviction once they exceed their size.
+	TreeImpl
+)
+
+func (i Impl) String() string {
+	switch i {
+	case SklImpl:
+		return "skiplist"
+	case TreeImpl:
+		return "tree"
+	default:
+		return fmt.Sprintf("Impl(%d)", int64(i))
+	}
+}
+
+// useTreeImpl forces the use of the TreeImpl, regardless of the
+// implementation asked for.
+var useTreeImpl = envutil.EnvOrDefaultBool("COCKROACH_USE_TREE_TSCACHE", false)
+
 // New returns a new timestamp cache with the supplied hybrid clock. If the
 // pageSize is provided, it will override the default page size.
 func New(clock *hlc.Clock, pageSize uint32) Cache {
-	if envutil.EnvOrDefaultBool("COCKROACH_USE_TREE_TSCACHE", false) {
-		return newTreeImpl(clock)
+	return newCache(clock, SklImpl, pageSize, makeMetrics())
+}
+
+// newCache returns a new Cache of the given implementation which reports to
+// the given metrics. A non-zero size overrides the page size of a SklImpl or
+// the maximum size of a TreeImpl.
+func newCache(clock *hlc.Clock, impl Impl, size uint32, metrics Metrics) Cache {
+	if useTreeImpl || impl == TreeImpl {
+		return newTreeImplWithMetrics(clock, uint64(size), metrics)
 	}
-	return newSklImpl(clock, pageSize)
+	return newSklImplWithMetrics(clock, size, metrics)
 }
 
 // cacheValue combines a timestamp with an optional txnID. It is shared between
@@ -38,6 +38,7 @@ import (
 var cacheImplConstrs = []func(clock *hlc.Clock) Cache{
 	func(clock *hlc.Clock) Cache { return newTreeImpl(clock) },
 	func(clock *hlc.Clock) Cache { return newSklImpl(clock, TestSklPageSize) },
+	func(clock *hlc.Clock) Cache { return NewSwitchable(clock, SklImpl, TestSklPageSize) },
 }
 
 func forEachCacheImpl(
@@ -461,6 +461,16 @@ func (s *intervalSkl) LookupTimestamp(key []byte) cacheValue {
 // specified range. If this operation is repeated with the same range, it will
 // always result in an equal or greater timestamp.
 func (s *intervalSkl) LookupTimestampRange(from, to []byte, opt rangeOptions) cacheValue {
+	val, _ := s.lookupTimestampRangeWithFloor(from, to, opt)
+	return val
+}
+
+// lookupTimestampRangeWithFloor is like LookupTimestampRange, but also returns
+// whether the value came from the floor timestamp rather than from any of the
+// pages, i.e. whether the lookup missed the cache.
+func (s *intervalSkl) lookupTimestampRangeWithFloor(
+	from, to []byte, opt rangeOptions,
+) (cacheValue, bool) {
 	if from == nil && to == nil {
 		panic("from and to keys cannot be nil")
 	}
@@ -503,9 +513,10 @@ func (s *intervalSkl) LookupTimestampRange(from, to []byte, opt rangeOptions) ca
 	// Return the higher value from the the page lookups and the floor
 	// timestamp.
 	floorVal := cacheValue{ts: s.floorTS, txnID: noTxnID}
+	floor := !floorVal.ts.Less(val.ts)
 	val, _ = ratchetValue(val, floorVal)
 
-	return val
+	return val, floor
 }
 
 // FloorTS returns the receiver's floor timestamp.
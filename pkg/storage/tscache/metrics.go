@@ -19,6 +19,21 @@ import "github.com/cockroachdb/cockroach/pkg/util/metric"
 // Metrics holds all metrics relating to a Cache.
 type Metrics struct {
 	Skl sklImplMetrics
+
+	Lookups         *metric.Counter
+	LowWaterLookups *metric.Counter
+	LowWaterBumps   *metric.Counter
+}
+
+// recordLookup records a lookup in the Cache. floor indicates whether the
+// lookup didn't find an entry above the low water mark left behind by evicted
+// entries. A high rate of such lookups after rotations indicates that the
+// Cache is too small for the workload.
+func (m Metrics) recordLookup(floor bool) {
+	m.Lookups.Inc(1)
+	if floor {
+		m.LowWaterLookups.Inc(1)
+	}
 }
 
 // sklImplMetrics holds all metrics relating to an sklImpl Cache implementation.
@@ -40,6 +55,24 @@ var _ metric.Struct = sklImplMetrics{}
 var _ metric.Struct = sklMetrics{}
 
 var (
+	metaLookups = metric.Metadata{
+		Name:        "tscache.lookups",
+		Help:        "Number of lookups in the timestamp cache",
+		Measurement: "Lookups",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaLowWaterLookups = metric.Metadata{
+		Name:        "tscache.lookups.lowwater",
+		Help:        "Number of lookups in the timestamp cache which found no entry and fell back to the low water mark",
+		Measurement: "Lookups",
+		Unit:        metric.Unit_COUNT,
+	}
+	metaLowWaterBumps = metric.Metadata{
+		Name:        "tscache.lowwater.bumps",
+		Help:        "Number of times the low water mark of a key span in the timestamp cache was raised",
+		Measurement: "Bumps",
+		Unit:        metric.Unit_COUNT,
+	}
 	metaSklReadPages = metric.Metadata{
 		Name:        "tscache.skl.read.pages",
 		Help:        "Number of pages in the read timestamp cache",
@@ -78,5 +111,8 @@ func makeMetrics() Metrics {
 				PageRotations: metric.NewCounter(metaSklWriteRotations),
 			},
 		},
+		Lookups:         metric.NewCounter(metaLookups),
+		LowWaterLookups: metric.NewCounter(metaLowWaterLookups),
+		LowWaterBumps:   metric.NewCounter(metaLowWaterBumps),
 	}
 }
@@ -48,12 +48,18 @@ type sklImpl struct {
 
 var _ Cache = &sklImpl{}
 
-// newSklImpl returns a new treeImpl with the supplied hybrid clock.
+// newSklImpl returns a new sklImpl with the supplied hybrid clock.
 func newSklImpl(clock *hlc.Clock, pageSize uint32) *sklImpl {
+	return newSklImplWithMetrics(clock, pageSize, makeMetrics())
+}
+
+// newSklImplWithMetrics returns a new sklImpl with the supplied hybrid clock
+// which reports to the given metrics.
+func newSklImplWithMetrics(clock *hlc.Clock, pageSize uint32, metrics Metrics) *sklImpl {
 	if pageSize == 0 {
 		pageSize = defaultSklPageSize
 	}
-	tc := sklImpl{clock: clock, pageSize: pageSize, metrics: makeMetrics()}
+	tc := sklImpl{clock: clock, pageSize: pageSize, metrics: metrics}
 	tc.clear(clock.Now())
 	return &tc
 }
@@ -89,6 +95,7 @@ func (tc *sklImpl) Add(start, end roachpb.Key, ts hlc.Timestamp, txnID uuid.UUID
 
 // SetLowWater implements the Cache interface.
 func (tc *sklImpl) SetLowWater(start, end roachpb.Key, ts hlc.Timestamp) {
+	tc.metrics.LowWaterBumps.Inc(1)
 	tc.Add(start, end, ts, noTxnID, false /* readCache */)
 	tc.Add(start, end, ts, noTxnID, true /* readCache */)
 }
@@ -112,11 +119,13 @@ func (tc *sklImpl) getMax(start, end roachpb.Key, readCache bool) (hlc.Timestamp
 	skl := tc.getSkl(readCache)
 
 	var val cacheValue
+	var floor bool
 	if len(end) == 0 {
-		val = skl.LookupTimestamp(nonNil(start))
+		val, floor = skl.lookupTimestampRangeWithFloor(nil, nonNil(start), 0)
 	} else {
-		val = skl.LookupTimestampRange(nonNil(start), end, excludeTo)
+		val, floor = skl.lookupTimestampRangeWithFloor(nonNil(start), end, excludeTo)
 	}
+	tc.metrics.recordLookup(floor)
 	return val.ts, val.txnID
 }
 
@@ -0,0 +1,150 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tscache
+
+import (
+	"sync/atomic"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+)
+
+// Switchable is a Cache whose implementation and size can be changed while it
+// is in use. All of the underlying Caches report to the same metrics.
+//
+// The underlying Cache is swapped atomically, so that the accesses to it don't
+// synchronize with each other beyond what the Cache itself does.
+type Switchable struct {
+	clock   *hlc.Clock
+	metrics Metrics
+
+	// switchMu serializes calls to Switch.
+	switchMu syncutil.Mutex
+	// cur holds the current *switchableCache.
+	cur atomic.Value
+}
+
+// switchableCache is the Cache underlying a Switchable at some point in time,
+// along with the implementation and size it was created with.
+type switchableCache struct {
+	impl  Impl
+	size  uint32
+	cache Cache
+}
+
+var _ Cache = &Switchable{}
+
+// NewSwitchable returns a new Switchable Cache with the supplied hybrid clock,
+// starting out with the given implementation. A non-zero size overrides the
+// page size of a SklImpl or the maximum size of a TreeImpl.
+func NewSwitchable(clock *hlc.Clock, impl Impl, size uint32) *Switchable {
+	tc := &Switchable{clock: clock, metrics: makeMetrics()}
+	tc.cur.Store(&switchableCache{
+		impl:  impl,
+		size:  size,
+		cache: newCache(clock, impl, size, tc.metrics),
+	})
+	return tc
+}
+
+func (tc *Switchable) load() *switchableCache {
+	return tc.cur.Load().(*switchableCache)
+}
+
+// Switch replaces the underlying Cache with a new one of the given
+// implementation and size, unless those haven't changed. It returns whether
+// the Cache was replaced.
+//
+// The new Cache starts out empty, with a low water mark of the current time
+// plus the maximum clock offset. Every timestamp added to the old Cache is at
+// or below that: requests advance the clock to their timestamp before they
+// are evaluated, and leases can't start further in the future than the
+// maximum clock offset. Writes at lower timestamps are pushed, just like
+// after a restart of the store. The low water mark is raised once more after
+// the new Cache is installed, to cover the requests that advanced the clock
+// in the meantime; Add takes care of those that still added to the old Cache.
+func (tc *Switchable) Switch(impl Impl, size uint32) bool {
+	tc.switchMu.Lock()
+	defer tc.switchMu.Unlock()
+	if cur := tc.load(); impl == cur.impl && size == cur.size {
+		return false
+	}
+	cache := newCache(tc.clock, impl, size, tc.metrics)
+	cache.clear(tc.maxLowWater())
+	tc.cur.Store(&switchableCache{impl: impl, size: size, cache: cache})
+	cache.SetLowWater(roachpb.KeyMin, roachpb.KeyMax, tc.maxLowWater())
+	return true
+}
+
+// maxLowWater returns a timestamp at or above every timestamp added to the
+// Cache so far.
+func (tc *Switchable) maxLowWater() hlc.Timestamp {
+	return tc.clock.Now().Add(tc.clock.MaxOffset().Nanoseconds(), 0)
+}
+
+// Impl returns the implementation of the underlying Cache.
+func (tc *Switchable) Impl() Impl {
+	return tc.load().impl
+}
+
+// Add implements the Cache interface.
+func (tc *Switchable) Add(
+	start, end roachpb.Key, ts hlc.Timestamp, txnID uuid.UUID, readCache bool,
+) {
+	cur := tc.load()
+	cur.cache.Add(start, end, ts, txnID, readCache)
+	// If the Cache was switched concurrently, the timestamp may have been
+	// added to the old Cache after the low water mark of the new one was
+	// determined, so it is added to the new one as well.
+	for next := tc.load(); next != cur; cur, next = next, tc.load() {
+		next.cache.Add(start, end, ts, txnID, readCache)
+	}
+}
+
+// SetLowWater implements the Cache interface.
+func (tc *Switchable) SetLowWater(start, end roachpb.Key, ts hlc.Timestamp) {
+	cur := tc.load()
+	cur.cache.SetLowWater(start, end, ts)
+	for next := tc.load(); next != cur; cur, next = next, tc.load() {
+		next.cache.SetLowWater(start, end, ts)
+	}
+}
+
+// GetMaxRead implements the Cache interface.
+func (tc *Switchable) GetMaxRead(start, end roachpb.Key) (hlc.Timestamp, uuid.UUID) {
+	return tc.load().cache.GetMaxRead(start, end)
+}
+
+// GetMaxWrite implements the Cache interface.
+func (tc *Switchable) GetMaxWrite(start, end roachpb.Key) (hlc.Timestamp, uuid.UUID) {
+	return tc.load().cache.GetMaxWrite(start, end)
+}
+
+// Metrics implements the Cache interface.
+func (tc *Switchable) Metrics() Metrics {
+	return tc.metrics
+}
+
+// clear implements the Cache interface.
+func (tc *Switchable) clear(lowWater hlc.Timestamp) {
+	tc.load().cache.clear(lowWater)
+}
+
+// getLowWater implements the Cache interface.
+func (tc *Switchable) getLowWater(readCache bool) hlc.Timestamp {
+	return tc.load().cache.getLowWater(readCache)
+}
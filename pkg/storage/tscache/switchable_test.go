@@ -0,0 +1,76 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package tscache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+)
+
+// TestSwitchableSwitch verifies that switching the implementation of a
+// Switchable Cache never lowers the timestamps it returns.
+func TestSwitchableSwitch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	manual := hlc.NewManualClock(123)
+	clock := hlc.NewClock(manual.UnixNano, 5*time.Nanosecond)
+	tc := NewSwitchable(clock, SklImpl, TestSklPageSize)
+
+	manual.Increment(1)
+	aTS := clock.Now()
+	tc.Add(roachpb.Key("a"), nil, aTS, noTxnID, true /* readCache */)
+	if rTS, _ := tc.GetMaxRead(roachpb.Key("a"), nil); rTS != aTS {
+		t.Fatalf("expected %s, got %s", aTS, rTS)
+	}
+	if rTS, _ := tc.GetMaxRead(roachpb.Key("b"), nil); !rTS.Less(aTS) {
+		t.Fatalf("expected low water mark below %s, got %s", aTS, rTS)
+	}
+
+	if tc.Switch(SklImpl, TestSklPageSize) {
+		t.Fatal("expected no switch without a change")
+	}
+	minLowWater := clock.Now().Add(clock.MaxOffset().Nanoseconds(), 0)
+	if !tc.Switch(TreeImpl, 0) {
+		t.Fatal("expected switch to tree implementation")
+	}
+	if impl := tc.Impl(); impl != TreeImpl {
+		t.Fatalf("expected %s, got %s", TreeImpl, impl)
+	}
+
+	// The new cache doesn't know about "a", but its low water mark covers the
+	// maximum clock offset.
+	for _, key := range []string{"a", "b"} {
+		if rTS, _ := tc.GetMaxRead(roachpb.Key(key), nil); rTS.Less(minLowWater) {
+			t.Errorf("%s: expected read low water mark above %s, got %s", key, minLowWater, rTS)
+		}
+		if wTS, _ := tc.GetMaxWrite(roachpb.Key(key), nil); wTS.Less(minLowWater) {
+			t.Errorf("%s: expected write low water mark above %s, got %s", key, minLowWater, wTS)
+		}
+	}
+
+	m := tc.Metrics()
+	if l := m.Lookups.Count(); l != 6 {
+		t.Errorf("expected 6 lookups, got %d", l)
+	}
+	if l := m.LowWaterLookups.Count(); l != 1 {
+		t.Errorf("expected 1 low water lookup, got %d", l)
+	}
+	if b := m.LowWaterBumps.Count(); b != 1 {
+		t.Errorf("expected 1 low water bump, got %d", b)
+	}
+}
@@ -82,11 +82,20 @@ var _ Cache = &treeImpl{}
 
 // newTreeImpl returns a new treeImpl with the supplied hybrid clock.
 func newTreeImpl(clock *hlc.Clock) *treeImpl {
+	return newTreeImplWithMetrics(clock, 0 /* maxBytes */, makeMetrics())
+}
+
+// newTreeImplWithMetrics returns a new treeImpl with the supplied hybrid clock
+// which reports to the given metrics. A zero maxBytes uses the default size.
+func newTreeImplWithMetrics(clock *hlc.Clock, maxBytes uint64, metrics Metrics) *treeImpl {
+	if maxBytes == 0 {
+		maxBytes = defaultTreeImplSize
+	}
 	tc := &treeImpl{
 		rCache:   cache.NewIntervalCache(cache.Config{Policy: cache.CacheFIFO}),
 		wCache:   cache.NewIntervalCache(cache.Config{Policy: cache.CacheFIFO}),
-		maxBytes: uint64(defaultTreeImplSize),
-		metrics:  makeMetrics(),
+		maxBytes: maxBytes,
+		metrics:  metrics,
 	}
 	tc.clear(clock.Now())
 	tc.rCache.Config.ShouldEvict = tc.shouldEvict
@@ -460,6 +469,7 @@ func (tc *treeImpl) Add(start, end roachpb.Key, ts hlc.Timestamp, txnID uuid.UUI
 
 // SetLowWater implements the Cache interface.
 func (tc *treeImpl) SetLowWater(start, end roachpb.Key, ts hlc.Timestamp) {
+	tc.metrics.LowWaterBumps.Inc(1)
 	tc.Add(start, end, ts, noTxnID, false)
 	tc.Add(start, end, ts, noTxnID, true)
 }
@@ -491,6 +501,7 @@ func (tc *treeImpl) getMax(start, end roachpb.Key, readCache bool) (hlc.Timestam
 	}
 	maxTS := tc.lowWater
 	maxTxnID := noTxnID
+	floor := true
 	cache := tc.wCache
 	if readCache {
 		cache = tc.rCache
@@ -500,10 +511,12 @@ func (tc *treeImpl) getMax(start, end roachpb.Key, readCache bool) (hlc.Timestam
 		if maxTS.Less(ce.ts) {
 			maxTS = ce.ts
 			maxTxnID = ce.txnID
+			floor = false
 		} else if maxTS == ce.ts && maxTxnID != ce.txnID {
 			maxTxnID = noTxnID
 		}
 	}
+	tc.metrics.recordLookup(floor)
 	return maxTS, maxTxnID
 }
 
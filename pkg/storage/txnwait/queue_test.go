@@ -24,6 +24,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
 )
 
 func TestShouldPushImmediately(t *testing.T) {
@@ -174,3 +175,47 @@ func TestMaybeWaitForQueryWithContextCancellation(t *testing.T) {
 		t.Errorf("expected all metric gauges to be zero, got some that aren't")
 	}
 }
+
+// TestWaitingPushes verifies that the pushes waiting in the queue are reported
+// as edges of the waits-for graph.
+func TestWaitingPushes(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	q := NewQueue(mockStore{metrics: NewMetrics(time.Minute)})
+	if pushes := q.WaitingPushes(); len(pushes) != 0 {
+		t.Fatalf("expected no waiting pushes in disabled queue, found %v", pushes)
+	}
+	q.Enable()
+
+	pusher := roachpb.MakeTransaction("pusher", roachpb.Key("a"), 0, hlc.Timestamp{WallTime: 1}, 0)
+	pushee := roachpb.MakeTransaction("pushee", roachpb.Key("b"), 0, hlc.Timestamp{WallTime: 1}, 0)
+	q.Enqueue(&pushee)
+	q.mu.Lock()
+	q.mu.txns[pushee.ID].waitingPushes = append(q.mu.txns[pushee.ID].waitingPushes, &waitingPush{
+		req: &roachpb.PushTxnRequest{
+			PusherTxn: pusher,
+			PusheeTxn: pushee.TxnMeta,
+			PushType:  roachpb.PUSH_ABORT,
+		},
+		start:   timeutil.Now().Add(-time.Second),
+		pending: make(chan *roachpb.Transaction, 1),
+	})
+	q.mu.Unlock()
+
+	pushes := q.WaitingPushes()
+	if len(pushes) != 1 {
+		t.Fatalf("expected 1 waiting push, found %v", pushes)
+	}
+	p := pushes[0]
+	if p.Pusher != pusher.ID || p.Pushee != pushee.ID || !p.Key.Equal(pushee.Key) ||
+		p.PushType != roachpb.PUSH_ABORT {
+		t.Errorf("unexpected waiting push %+v", p)
+	}
+	if p.Duration < time.Second {
+		t.Errorf("expected push to have waited at least 1s, found %s", p.Duration)
+	}
+
+	q.Clear(true /* disable */)
+	if pushes := q.WaitingPushes(); len(pushes) != 0 {
+		t.Fatalf("expected no waiting pushes after clearing queue, found %v", pushes)
+	}
+}
@@ -91,6 +91,8 @@ func createPushTxnResponse(txn *roachpb.Transaction) *roachpb.PushTxnResponse {
 // dependency cycles.
 type waitingPush struct {
 	req *roachpb.PushTxnRequest
+	// start is the time at which the push started waiting.
+	start time.Time
 	// pending channel receives updated, pushed txn or nil if queue is cleared.
 	pending chan *roachpb.Transaction
 	mu      struct {
@@ -344,6 +346,42 @@ func (q *Queue) GetDependents(txnID uuid.UUID) []uuid.UUID {
 	return nil
 }
 
+// WaitingPush describes a PushTxn request waiting in a Queue, which is an edge
+// of the waits-for graph between transactions.
+type WaitingPush struct {
+	// Pusher is the ID of the pushing transaction, or uuid.Nil if the pusher
+	// is non-transactional.
+	Pusher uuid.UUID
+	// Pushee is the ID of the transaction being pushed.
+	Pushee uuid.UUID
+	// Key is the key of the pushee's transaction record.
+	Key roachpb.Key
+	// PushType is the type of the push.
+	PushType roachpb.PushTxnType
+	// Duration is how long the push has been waiting.
+	Duration time.Duration
+}
+
+// WaitingPushes returns the PushTxn requests currently waiting in the queue.
+func (q *Queue) WaitingPushes() []WaitingPush {
+	now := timeutil.Now()
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var pushes []WaitingPush
+	for pusheeID, pending := range q.mu.txns {
+		for _, push := range pending.waitingPushes {
+			pushes = append(pushes, WaitingPush{
+				Pusher:   push.req.PusherTxn.ID,
+				Pushee:   pusheeID,
+				Key:      push.req.PusheeTxn.Key,
+				PushType: push.req.PushType,
+				Duration: now.Sub(push.start),
+			})
+		}
+	}
+	return pushes
+}
+
 // isTxnUpdated returns whether the transaction specified in
 // the QueryTxnRequest has had its status or priority updated
 // or whether the known set of dependent transactions has
@@ -424,6 +462,7 @@ func (q *Queue) MaybeWaitForPush(
 
 	push := &waitingPush{
 		req:     req,
+		start:   timeutil.Now(),
 		pending: make(chan *roachpb.Transaction, 1),
 	}
 	pending.waitingPushes = append(pending.waitingPushes, push)
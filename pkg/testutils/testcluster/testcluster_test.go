@@ -209,7 +209,7 @@ func TestStopServer(t *testing.T) {
 	rpcContext := rpc.NewContext(
 		log.AmbientContext{Tracer: tc.Server(0).ClusterSettings().Tracer},
 		tc.Server(1).RPCContext().Config, tc.Server(1).Clock(), tc.Stopper(),
-		&tc.Server(1).ClusterSettings().Version,
+		tc.Server(1).ClusterSettings(),
 	)
 	conn, err := rpcContext.GRPCDialNode(server1.ServingAddr(), server1.NodeID()).Connect(context.Background())
 	if err != nil {
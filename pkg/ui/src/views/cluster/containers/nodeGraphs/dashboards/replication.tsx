@@ -30,6 +30,7 @@ export default function (props: GraphDashboardProps) {
         <Metric name="cr.store.replicas.leaders" title="Leaders" />
         <Metric name="cr.store.replicas.leaseholders" title="Lease Holders" />
         <Metric name="cr.store.replicas.leaders_not_leaseholders" title="Leaders w/o Lease" />
+        <Metric name="cr.store.replicas.leaseholders_not_leaders" title="Lease Holders w/o Leadership" />
         <Metric name="cr.store.ranges.unavailable" title="Unavailable" />
         <Metric name="cr.store.ranges.underreplicated" title="Under-replicated" />
         <Metric name="cr.store.ranges.overreplicated" title="Over-replicated" />
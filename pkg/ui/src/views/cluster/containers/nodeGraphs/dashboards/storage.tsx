@@ -145,6 +145,32 @@ export default function (props: GraphDashboardProps) {
       </Axis>
     </LineGraph>,
 
+    <LineGraph
+      title="RocksDB Write Amplification"
+      sources={storeSources}
+      tooltip={
+        `RocksDB write amplification estimate; measures the number of bytes written by flushes
+           and compactions per byte flushed from the memtables ${tooltipSelection}.`
+      }
+    >
+      <Axis label="factor">
+        <Metric name="cr.store.rocksdb.write-amplification" title="Write Amplification" aggregateAvg />
+      </Axis>
+    </LineGraph>,
+
+    <LineGraph
+      title="RocksDB L0 Sublevels"
+      sources={storeSources}
+      tooltip={
+        `The largest number of RocksDB level 0 SSTables overlapping a single key, which
+           reads may have to consult ${tooltipSelection}.`
+      }
+    >
+      <Axis label="sstables">
+        <Metric name="cr.store.rocksdb.l0-sublevels" title="L0 Sublevels" aggregateMax />
+      </Axis>
+    </LineGraph>,
+
     <LineGraph
       title="RocksDB SSTables"
       sources={storeSources}
@@ -36,6 +36,7 @@ const QUEUES = [
   "raftsnapshot",
   "consistencyChecker",
   "timeSeriesMaintenance",
+  "replicaMigration",
 ];
 
 interface EnqueueRangeProps {
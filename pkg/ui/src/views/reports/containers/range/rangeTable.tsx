@@ -55,6 +55,8 @@ const rangeTableDisplayList: RangeTableRow[] = [
   { variable: "raftState", display: "Raft State", compareToLeader: false },
   { variable: "quiescent", display: "Quiescent", compareToLeader: true },
   { variable: "ticking", display: "Ticking", compareToLeader: true },
+  { variable: "unquiesceReason", display: "Last Unquiesce Reason", compareToLeader: false },
+  { variable: "raftTicks", display: "Raft Ticks", compareToLeader: false },
   { variable: "leaseType", display: "Lease Type", compareToLeader: true },
   { variable: "leaseState", display: "Lease State", compareToLeader: true },
   { variable: "leaseHolder", display: "Lease Holder", compareToLeader: true },
@@ -451,6 +453,8 @@ export default class RangeTable extends React.Component<RangeTableProps, {}> {
         raftState: raftState,
         quiescent: info.quiescent ? rangeTableQuiescent : rangeTableEmptyContent,
         ticking: this.createContent(info.ticking.toString()),
+        unquiesceReason: info.unquiesce_reason ? this.createContent(info.unquiesce_reason) : rangeTableEmptyContent,
+        raftTicks: this.createContent(FixLong(info.raft_ticks)),
         leaseState: leaseState,
         leaseHolder: this.createContent(
           Print.ReplicaID(rangeID, lease.replica),
@@ -1288,7 +1288,7 @@ func GetMultiVarintLen(b []byte, num int) (int, error) {
 func getMultiNonsortingVarintLen(b []byte, num int) (int, error) {
 	p := 0
 	for i := 0; i < num && p < len(b); i++ {
-		_, len, _, err := DecodeNonsortingStdlibVarint(b[p:])
+		len, err := getNonsortingVarintLen(b[p:])
 		if err != nil {
 			return 0, err
 		}
@@ -1297,6 +1297,20 @@ func getMultiNonsortingVarintLen(b []byte, num int) (int, error) {
 	return p, nil
 }
 
+// getNonsortingVarintLen finds the length of an encoded nonsorting varint
+// without decoding it. Signed and unsigned nonsorting varints share the same
+// framing, so this works for both. Like DecodeNonsortingStdlibVarint, it
+// rejects varints which overflow 64 bits: those longer than
+// binary.MaxVarintLen64 bytes, and those of that length whose last byte holds
+// more than the one remaining bit.
+func getNonsortingVarintLen(b []byte) (int, error) {
+	n := PeekLengthNonsortingUvarint(b)
+	if n <= 0 || n > binary.MaxVarintLen64 || (n == binary.MaxVarintLen64 && b[n-1] > 1) {
+		return 0, errors.New("int64 varint decoding failed")
+	}
+	return n, nil
+}
+
 // PeekLength returns the length of the encoded value at the start of b.  Note:
 // if this function succeeds, it's not a guarantee that decoding the value will
 // succeed.
@@ -2235,7 +2249,7 @@ func PeekValueLengthWithOffsetsAndType(b []byte, dataOffset int, typ Type) (leng
 	case True, False:
 		return dataOffset, nil
 	case Int:
-		_, n, _, err := DecodeNonsortingStdlibVarint(b)
+		n, err := getNonsortingVarintLen(b)
 		return dataOffset + n, err
 	case Float:
 		return dataOffset + floatValueEncodedLength, nil
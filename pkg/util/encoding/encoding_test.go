@@ -16,6 +16,7 @@ package encoding
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"math"
 	"math/rand"
@@ -1834,6 +1835,60 @@ func TestPeekLengthNonsortingUVarint(t *testing.T) {
 	}
 }
 
+// TestGetNonsortingVarintLen verifies that getNonsortingVarintLen finds the
+// same length as decoding the varint does, and that it fails on the same
+// malformed input.
+func TestGetNonsortingVarintLen(t *testing.T) {
+	rng, seed := randutil.NewPseudoRand()
+
+	check := func(b []byte) {
+		t.Helper()
+		_, expLen, _, expErr := DecodeNonsortingStdlibVarint(b)
+		n, err := getNonsortingVarintLen(b)
+		if (err != nil) != (expErr != nil) {
+			t.Fatalf("seed %d: %x: expected error %v, got %v", seed, b, expErr, err)
+		}
+		if err == nil && n != expLen {
+			t.Fatalf("seed %d: %x: expected length %d, got %d", seed, b, expLen, n)
+		}
+	}
+
+	values := randPowDistributedInt63s(rng, 1000)
+	for _, v := range edgeCaseUint64s() {
+		values = append(values, int64(v))
+	}
+	buf := make([]byte, binary.MaxVarintLen64)
+	for _, v := range values {
+		for _, x := range []int64{v, -v} {
+			n := binary.PutVarint(buf, x)
+			check(buf[:n])
+			// Bytes following the varint don't change its length.
+			check(append(buf[:n:n], 0xff, 0x01))
+			// A truncated varint is malformed.
+			check(buf[:n-1])
+		}
+	}
+
+	for _, b := range [][]byte{
+		{},
+		// Without a terminating byte.
+		{0x80},
+		bytes.Repeat([]byte{0xff}, 11),
+		// The longest valid varints.
+		append(bytes.Repeat([]byte{0xff}, 9), 0x01),
+		append(bytes.Repeat([]byte{0x80}, 9), 0x00),
+		// Overflowing varints.
+		append(bytes.Repeat([]byte{0xff}, 9), 0x02),
+		append(bytes.Repeat([]byte{0x80}, 9), 0x7f),
+		append(bytes.Repeat([]byte{0x80}, 10), 0x00),
+	} {
+		check(b)
+	}
+	for i := 0; i < 1000; i++ {
+		check(randutil.RandBytes(rng, rng.Intn(2*binary.MaxVarintLen64)))
+	}
+}
+
 func BenchmarkEncodeNonsortingUvarint(b *testing.B) {
 	buf := make([]byte, 0, b.N*NonsortingUvarintMaxLen)
 	rng, _ := randutil.NewPseudoRand()
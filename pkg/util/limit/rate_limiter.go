@@ -0,0 +1,100 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package limit
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+// RateLimiter is a token bucket rate limiter which can be arranged into a
+// hierarchy. Tokens acquired from a child are also acquired from each of its
+// ancestors, so the limit on a parent caps the aggregate rate of all of its
+// children regardless of which of them happens to be active. This is used to
+// put a single per-store budget on background disk bandwidth (ingestion,
+// snapshots, backup reads) while still allowing each subsystem its own limit.
+//
+// A nil *RateLimiter imposes no limit.
+type RateLimiter struct {
+	name    string
+	parent  *RateLimiter
+	limiter *rate.Limiter
+}
+
+// NewRateLimiter creates a root RateLimiter with the given rate and burst. A
+// burst smaller than one is treated as one.
+func NewRateLimiter(name string, r rate.Limit, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{name: name, limiter: rate.NewLimiter(r, burst)}
+}
+
+// NewChild creates a RateLimiter with the given rate and burst which, in
+// addition to its own limit, draws from l.
+func (l *RateLimiter) NewChild(name string, r rate.Limit, burst int) *RateLimiter {
+	c := NewRateLimiter(name, r, burst)
+	c.parent = l
+	return c
+}
+
+// Name returns the name of the limiter.
+func (l *RateLimiter) Name() string {
+	return l.name
+}
+
+// Parent returns the parent of the limiter, or nil if it is a root.
+func (l *RateLimiter) Parent() *RateLimiter {
+	return l.parent
+}
+
+// Limit returns the limiter's own rate, ignoring its ancestors.
+func (l *RateLimiter) Limit() rate.Limit {
+	return l.limiter.Limit()
+}
+
+// SetLimit adjusts the limiter's own rate. Children are unaffected, though
+// they continue to draw from it.
+func (l *RateLimiter) SetLimit(r rate.Limit) {
+	l.limiter.SetLimit(r)
+}
+
+// WaitN blocks until n tokens are available from the limiter and from each of
+// its ancestors, or until the context is canceled. Unlike rate.Limiter, a cost
+// larger than the burst is permitted and is acquired in burst-sized chunks.
+func (l *RateLimiter) WaitN(ctx context.Context, n int) error {
+	for lim := l; lim != nil; lim = lim.parent {
+		if err := lim.waitN(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *RateLimiter) waitN(ctx context.Context, n int) error {
+	burst := l.limiter.Burst()
+	for n > 0 {
+		chunk := n
+		if chunk > burst {
+			chunk = burst
+		}
+		if err := l.limiter.WaitN(ctx, chunk); err != nil {
+			return err
+		}
+		n -= chunk
+	}
+	return nil
+}
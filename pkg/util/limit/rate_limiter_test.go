@@ -0,0 +1,70 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package limit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"golang.org/x/time/rate"
+)
+
+func TestRateLimiterHierarchy(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	ctx := context.Background()
+
+	// The parent only has enough burst for a single child acquisition, so a
+	// second acquisition from a sibling must be denied by the parent even
+	// though the sibling's own budget is untouched.
+	parent := NewRateLimiter("parent", rate.Every(time.Hour), 10)
+	a := parent.NewChild("a", rate.Inf, 10)
+	b := parent.NewChild("b", rate.Inf, 10)
+
+	if err := a.WaitN(ctx, 10); err != nil {
+		t.Fatal(err)
+	}
+	shortCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := b.WaitN(shortCtx, 1); err == nil {
+		t.Fatal("expected parent budget to be exhausted")
+	}
+
+	// Lifting the parent's limit unblocks the children.
+	parent.SetLimit(rate.Inf)
+	if err := b.WaitN(ctx, 10); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRateLimiterCostExceedsBurst(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	l := NewRateLimiter("test", 1<<20, 1<<10)
+	if err := l.WaitN(context.Background(), 4<<10); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRateLimiterNil(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+
+	var l *RateLimiter
+	if err := l.WaitN(context.Background(), 1<<30); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -404,6 +404,7 @@ func (d *EntryDecoder) Decode(entry *Entry) error {
 		}
 		entry.Line = int64(line)
 		entry.Message = strings.TrimSpace(string(b[len(m[0]):]))
+		entry.StoreID, entry.RangeID = parseRangeTagIDs(entry.Message)
 		return nil
 	}
 }
@@ -761,7 +762,7 @@ func SetClusterID(clusterID string) {
 	// new log files, even on the first log file. This ensures that grep
 	// will always find it.
 	file, line, _ := caller.Lookup(1)
-	logging.outputLogEntry(Severity_INFO, file, line,
+	logging.outputLogEntry(context.Background(), Severity_INFO, file, line,
 		fmt.Sprintf("[config] clusterID: %s", clusterID))
 
 	// Perform the change proper.
@@ -846,11 +847,14 @@ func (l *loggingT) writeToFile(data []byte) error {
 
 // outputLogEntry marshals a log entry proto into bytes, and writes
 // the data to the log files. If a trace location is set, stack traces
-// are added to the entry before marshaling.
-func (l *loggingT) outputLogEntry(s Severity, file string, line int, msg string) {
+// are added to the entry before marshaling. The context is only used for
+// its log tags, which msg is expected to include already.
+func (l *loggingT) outputLogEntry(
+	ctx context.Context, s Severity, file string, line int, msg string,
+) {
 	// Set additional details in log entry.
 	now := timeutil.Now()
-	entry := MakeEntry(s, now.UnixNano(), file, line, msg)
+	entry := MakeEntry(ctx, s, now.UnixNano(), file, line, msg)
 
 	if f, ok := l.interceptor.Load().(InterceptorFn); ok && f != nil {
 		f(entry)
@@ -1377,7 +1381,7 @@ func (lb logBridge) Write(b []byte) (n int, err error) {
 			line = 1
 		}
 	}
-	logging.outputLogEntry(Severity(lb), file, line, text)
+	logging.outputLogEntry(context.Background(), Severity(lb), file, line, text)
 	return len(b), nil
 }
 
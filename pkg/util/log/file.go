@@ -425,6 +425,21 @@ func selectFiles(logFiles []FileInfo, endTimestamp int64) []FileInfo {
 // chronological order.
 func FetchEntriesFromFiles(
 	startTimestamp, endTimestamp int64, maxEntries int, pattern *regexp.Regexp,
+) ([]Entry, error) {
+	return fetchEntriesFromFiles(startTimestamp, endTimestamp, maxEntries, pattern, 0 /* rangeID */)
+}
+
+// FetchRangeEntriesFromFiles is like FetchEntriesFromFiles, but only returns
+// the log entries tagged with the given range ID. This allows assembling a
+// timeline of the events which happened to a range on this node.
+func FetchRangeEntriesFromFiles(
+	rangeID, startTimestamp, endTimestamp int64, maxEntries int, pattern *regexp.Regexp,
+) ([]Entry, error) {
+	return fetchEntriesFromFiles(startTimestamp, endTimestamp, maxEntries, pattern, rangeID)
+}
+
+func fetchEntriesFromFiles(
+	startTimestamp, endTimestamp int64, maxEntries int, pattern *regexp.Regexp, rangeID int64,
 ) ([]Entry, error) {
 	logFiles, err := ListLogFiles()
 	if err != nil {
@@ -440,7 +455,8 @@ func FetchEntriesFromFiles(
 			startTimestamp,
 			endTimestamp,
 			maxEntries-len(entries),
-			pattern)
+			pattern,
+			rangeID)
 		if err != nil {
 			return nil, err
 		}
@@ -458,14 +474,18 @@ func FetchEntriesFromFiles(
 }
 
 // readAllEntriesFromFile reads in all log entries from a given file that are
-// between the 'startTimestamp' and 'endTimestamp' and match the 'pattern' if it
-// exists. It returns the entries in the reverse chronological order. It also
+// between the 'startTimestamp' and 'endTimestamp' and match the 'pattern' and
+// 'rangeID' if they are set. It returns the entries in the reverse chronological order. It also
 // returns a flag that denotes if any timestamp occurred before the
 // 'startTimestamp' to inform the caller that no more log files need to be
 // processed. If the number of entries returned exceeds 'maxEntries' then
 // processing of new entries is stopped immediately.
 func readAllEntriesFromFile(
-	file FileInfo, startTimestamp, endTimestamp int64, maxEntries int, pattern *regexp.Regexp,
+	file FileInfo,
+	startTimestamp, endTimestamp int64,
+	maxEntries int,
+	pattern *regexp.Regexp,
+	rangeID int64,
 ) ([]Entry, bool, error) {
 	reader, err := GetLogReader(file.Name, true /* restricted */)
 	if reader == nil || err != nil {
@@ -490,6 +510,9 @@ func readAllEntriesFromFile(
 			match = pattern.MatchString(entry.Message) ||
 				pattern.MatchString(entry.File)
 		}
+		if rangeID != 0 && entry.RangeID != rangeID {
+			match = false
+		}
 		if match && entry.Time >= startTimestamp && entry.Time <= endTimestamp {
 			entries = append([]Entry{entry}, entries...)
 			if len(entries) >= maxEntries {
@@ -244,8 +244,12 @@ func ExpensiveLogEnabled(ctx context.Context, level int32) bool {
 	return false
 }
 
-// MakeEntry creates an Entry.
-func MakeEntry(s Severity, t int64, file string, line int, msg string) Entry {
+// MakeEntry creates an Entry. The store and range IDs of the entry are taken
+// from the log tags of the context, if any.
+func MakeEntry(
+	ctx context.Context, s Severity, t int64, file string, line int, msg string,
+) Entry {
+	storeID, rangeID := rangeTagIDs(ctx)
 	return Entry{
 		Severity:  s,
 		Time:      t,
@@ -253,6 +257,8 @@ func MakeEntry(s Severity, t int64, file string, line int, msg string) Entry {
 		File:      file,
 		Line:      int64(line),
 		Message:   msg,
+		StoreID:   storeID,
+		RangeID:   rangeID,
 	}
 }
 
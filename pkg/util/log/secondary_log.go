@@ -97,5 +97,5 @@ func (l *SecondaryLogger) Logf(ctx context.Context, format string, args ...inter
 	fmt.Fprintf(&buf, "%d ", counter)
 
 	fmt.Fprintf(&buf, format, args...)
-	l.logger.outputLogEntry(Severity_INFO, file, line, buf.String())
+	l.logger.outputLogEntry(ctx, Severity_INFO, file, line, buf.String())
 }
@@ -17,6 +17,7 @@ package log
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/cockroachdb/cockroach/pkg/settings"
@@ -52,6 +53,78 @@ func formatTags(ctx context.Context, buf *strings.Builder) bool {
 	return true
 }
 
+// rangeTagIDs returns the store and range IDs of the "s" and "r" log tags of
+// the context, or zero for the tags the context doesn't have. The value of the
+// "r" tag starts with the range ID, which may be followed by the replica ID
+// and the key span of the range.
+func rangeTagIDs(ctx context.Context) (storeID, rangeID int64) {
+	tags := logtags.FromContext(ctx)
+	if tags == nil {
+		return 0, 0
+	}
+	for _, t := range tags.Get() {
+		switch t.Key() {
+		case "s":
+			storeID = leadingID(fmt.Sprint(t.Value()))
+		case "r":
+			rangeID = leadingID(fmt.Sprint(t.Value()))
+		}
+	}
+	return storeID, rangeID
+}
+
+// parseRangeTagIDs is like rangeTagIDs, but for the tags which prefix a
+// message formatted by MakeMessage. It is used for the entries decoded from
+// log files, which only hold the formatted message.
+func parseRangeTagIDs(msg string) (storeID, rangeID int64) {
+	if !strings.HasPrefix(msg, "[") {
+		return 0, 0
+	}
+	end := strings.Index(msg, "] ")
+	if end < 0 {
+		return 0, 0
+	}
+	tags := msg[1:end]
+	for len(tags) > 0 {
+		tag := tags
+		if i := strings.IndexByte(tags, ','); i >= 0 {
+			tag, tags = tags[:i], tags[i+1:]
+		} else {
+			tags = ""
+		}
+		if len(tag) < 2 {
+			continue
+		}
+		switch tag[0] {
+		case 's':
+			if id, err := strconv.ParseInt(tag[1:], 10, 64); err == nil {
+				storeID = id
+			}
+		case 'r':
+			if id := leadingID(tag[1:]); id != 0 {
+				// The key span of the range may contain commas, so stop here:
+				// the store tag comes before the range tag.
+				return storeID, id
+			}
+		}
+	}
+	return storeID, 0
+}
+
+// leadingID returns the decimal ID at the start of s, or zero if s doesn't
+// start with one.
+func leadingID(s string) int64 {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	id, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
 // MakeMessage creates a structured log entry.
 func MakeMessage(ctx context.Context, format string, args []interface{}) string {
 	var buf strings.Builder
@@ -82,5 +155,5 @@ func addStructured(ctx context.Context, s Severity, depth int, format string, ar
 	// MakeMessage already added the tags when forming msg, we don't want
 	// eventInternal to prepend them again.
 	eventInternal(ctx, (s >= Severity_ERROR), false /*withTags*/, "%s:%d %s", file, line, msg)
-	logging.outputLogEntry(s, file, line, msg)
+	logging.outputLogEntry(ctx, s, file, line, msg)
 }
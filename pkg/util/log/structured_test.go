@@ -0,0 +1,64 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package log
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/log/logtags"
+)
+
+func TestRangeTagIDs(t *testing.T) {
+	ctx := context.Background()
+	if storeID, rangeID := rangeTagIDs(ctx); storeID != 0 || rangeID != 0 {
+		t.Errorf("expected no IDs without tags, got s%d,r%d", storeID, rangeID)
+	}
+	ctx = logtags.AddTag(ctx, "n", 1)
+	ctx = logtags.AddTag(ctx, "s", 2)
+	if storeID, rangeID := rangeTagIDs(ctx); storeID != 2 || rangeID != 0 {
+		t.Errorf("expected s2,r0, got s%d,r%d", storeID, rangeID)
+	}
+	ctx = logtags.AddTag(ctx, "r", "3/1:/M{in-ax}")
+	entry := MakeEntry(ctx, Severity_INFO, 0, "file", 1, MakeMessage(ctx, "test", nil))
+	if entry.StoreID != 2 || entry.RangeID != 3 {
+		t.Errorf("expected s2,r3, got s%d,r%d", entry.StoreID, entry.RangeID)
+	}
+}
+
+func TestParseRangeTagIDs(t *testing.T) {
+	testCases := []struct {
+		msg              string
+		storeID, rangeID int64
+	}{
+		{"test", 0, 0},
+		{"[n1] test", 0, 0},
+		{"[n1,s2] test", 2, 0},
+		{"[n1,s2,r3/1:/M{in-ax}] test", 2, 3},
+		{"[n1,s2,r3/?:{-}] test", 2, 3},
+		{"[n1,s2,r3] test", 2, 3},
+		{"[n1,split,s2,r34/2:/Table/53/1/\"a,s9\"] test", 2, 34},
+		{"[n1,sql,r] test", 0, 0},
+		{"[n1] [s2,r3] test", 0, 0},
+		{"[s2,r3", 0, 0},
+	}
+	for _, tc := range testCases {
+		storeID, rangeID := parseRangeTagIDs(tc.msg)
+		if storeID != tc.storeID || rangeID != tc.rangeID {
+			t.Errorf("%q: expected s%d,r%d, got s%d,r%d",
+				tc.msg, tc.storeID, tc.rangeID, storeID, rangeID)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package retry
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	circuit "github.com/cockroachdb/circuitbreaker"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/pkg/errors"
+)
+
+// ErrBudgetExhausted is returned (annotated with the last error) by Policy.Do
+// when a retry was denied by the policy's Budget.
+var ErrBudgetExhausted = errors.New("retry budget exhausted")
+
+// Budget caps the aggregate number of retries issued for an operation across
+// all of its callers. Every retry consumes a token and every success returns
+// a fraction of one, so that during a partial outage, when most attempts
+// fail, retries quickly become rare instead of multiplying the load on the
+// unhealthy destinations.
+//
+// A nil *Budget permits every retry.
+type Budget struct {
+	maxTokens  float64
+	tokenRatio float64
+	mu         syncutil.Mutex
+	tokens     float64
+}
+
+// NewBudget creates a Budget holding at most maxTokens retries. Each
+// successful attempt adds tokenRatio tokens back to the budget.
+func NewBudget(maxTokens int, tokenRatio float64) *Budget {
+	return &Budget{
+		maxTokens:  float64(maxTokens),
+		tokenRatio: tokenRatio,
+		tokens:     float64(maxTokens),
+	}
+}
+
+// tryAcquire consumes a token if one is available.
+func (b *Budget) tryAcquire() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// recordSuccess returns a fraction of a token to the budget.
+func (b *Budget) recordSuccess() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += b.tokenRatio
+	if b.tokens > b.maxTokens {
+		b.tokens = b.maxTokens
+	}
+}
+
+// Breakers is a collection of circuit breakers keyed by destination. A
+// breaker trips after a number of consecutive retryable failures to its
+// destination, after which attempts to it fail fast until the breaker's
+// backoff allows a probe through.
+//
+// A nil *Breakers never trips.
+type Breakers struct {
+	name      string
+	threshold int64
+	mu        struct {
+		syncutil.Mutex
+		breakers map[string]*circuit.Breaker
+	}
+}
+
+// NewBreakers creates a collection of circuit breakers which trip after
+// threshold consecutive failures.
+func NewBreakers(name string, threshold int64) *Breakers {
+	b := &Breakers{name: name, threshold: threshold}
+	b.mu.breakers = make(map[string]*circuit.Breaker)
+	return b
+}
+
+// Get returns the breaker for the given destination, creating it if
+// necessary.
+func (b *Breakers) Get(dest string) *circuit.Breaker {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	br, ok := b.mu.breakers[dest]
+	if !ok {
+		br = circuit.NewBreakerWithOptions(&circuit.Options{
+			Name:       b.name + ":" + dest,
+			ShouldTrip: circuit.ConsecutiveTripFunc(b.threshold),
+		})
+		b.mu.breakers[dest] = br
+	}
+	return br
+}
+
+// Policy describes how an operation is retried: the backoff bounds and
+// retry limit come from the embedded Options, the Budget (if any) caps the
+// retries issued across all invocations of the operation, and Breakers (if
+// any) stops attempts to destinations which keep failing.
+//
+// Unlike Retry, backoffs use decorrelated jitter: each backoff is chosen
+// uniformly between InitialBackoff and three times the previous backoff
+// (capped at MaxBackoff). This spreads out the retries of callers which
+// failed at the same moment better than a fixed randomization factor does.
+// Multiplier and RandomizationFactor are ignored.
+type Policy struct {
+	Options
+	Budget   *Budget
+	Breakers *Breakers
+}
+
+// Do invokes fn until it succeeds, returns an error for which retryable
+// returns false, or the policy denies a retry, returning the last error in
+// the latter cases. Retryable errors count as failures against dest's
+// circuit breaker; an open breaker fails the operation immediately with an
+// error wrapping circuit.ErrBreakerOpen. An empty dest bypasses the breakers.
+func (p *Policy) Do(
+	ctx context.Context, dest string, retryable func(error) bool, fn func(context.Context) error,
+) error {
+	initialBackoff, maxBackoff := p.InitialBackoff, p.MaxBackoff
+	if initialBackoff == 0 {
+		initialBackoff = 50 * time.Millisecond
+	}
+	if maxBackoff == 0 {
+		maxBackoff = 2 * time.Second
+	}
+	var br *circuit.Breaker
+	if dest != "" {
+		br = p.Breakers.Get(dest)
+	}
+
+	backoff := initialBackoff
+	for retries := 0; ; retries++ {
+		if br != nil && !br.Ready() {
+			return errors.Wrapf(circuit.ErrBreakerOpen, "%s", dest)
+		}
+		err := fn(ctx)
+		if err == nil {
+			if br != nil {
+				br.Success()
+			}
+			p.Budget.recordSuccess()
+			return nil
+		}
+		if !retryable(err) {
+			return err
+		}
+		if br != nil {
+			br.Fail()
+		}
+		if p.MaxRetries > 0 && retries >= p.MaxRetries {
+			return err
+		}
+		if !p.Budget.tryAcquire() {
+			return errors.Wrapf(ErrBudgetExhausted, "%s", err)
+		}
+
+		backoff = decorrelatedJitter(initialBackoff, maxBackoff, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-p.Closer:
+			return err
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// decorrelatedJitter returns the backoff following prev, chosen uniformly
+// from [base, 3*prev) and capped at max.
+func decorrelatedJitter(base, max, prev time.Duration) time.Duration {
+	upper := 3 * prev
+	if upper <= base {
+		return base
+	}
+	d := base + time.Duration(rand.Int63n(int64(upper-base)))
+	if d > max {
+		d = max
+	}
+	return d
+}
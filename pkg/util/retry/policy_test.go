@@ -0,0 +1,153 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	circuit "github.com/cockroachdb/circuitbreaker"
+	pkgerrors "github.com/pkg/errors"
+)
+
+func alwaysRetryable(error) bool { return true }
+
+func TestPolicyMaxRetries(t *testing.T) {
+	p := Policy{Options: Options{
+		InitialBackoff: time.Microsecond,
+		MaxBackoff:     10 * time.Microsecond,
+		MaxRetries:     3,
+	}}
+	attempts := 0
+	err := p.Do(context.Background(), "", alwaysRetryable, func(context.Context) error {
+		attempts++
+		return errors.New("boom")
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if expAttempts := p.MaxRetries + 1; attempts != expAttempts {
+		t.Errorf("expected %d attempts, got %d", expAttempts, attempts)
+	}
+}
+
+func TestPolicyNonRetryable(t *testing.T) {
+	var p Policy
+	attempts := 0
+	expErr := errors.New("boom")
+	err := p.Do(context.Background(), "", func(error) bool { return false }, func(context.Context) error {
+		attempts++
+		return expErr
+	})
+	if err != expErr {
+		t.Fatalf("expected %v, got %v", expErr, err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 attempt, got %d", attempts)
+	}
+}
+
+func TestPolicyBudget(t *testing.T) {
+	p := Policy{
+		Options: Options{InitialBackoff: time.Microsecond, MaxBackoff: 10 * time.Microsecond},
+		Budget:  NewBudget(2, 0.5),
+	}
+	attempts := 0
+	fail := func(context.Context) error {
+		attempts++
+		return errors.New("boom")
+	}
+	// Without a retry limit, the budget alone bounds the retries.
+	if err := p.Do(context.Background(), "", alwaysRetryable, fail); err == nil {
+		t.Fatal("expected error")
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+
+	// With the budget exhausted, failures are not retried at all...
+	attempts = 0
+	if err := p.Do(context.Background(), "", alwaysRetryable, fail); pkgerrors.Cause(err) != ErrBudgetExhausted {
+		t.Fatalf("expected %v, got %v", ErrBudgetExhausted, err)
+	}
+	if attempts != 1 {
+		t.Fatalf("expected 1 attempt, got %d", attempts)
+	}
+
+	// ...until enough successes have replenished it.
+	for i := 0; i < 2; i++ {
+		if err := p.Do(context.Background(), "", alwaysRetryable, func(context.Context) error {
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	attempts = 0
+	if err := p.Do(context.Background(), "", alwaysRetryable, fail); err == nil {
+		t.Fatal("expected error")
+	}
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts, got %d", attempts)
+	}
+}
+
+func TestPolicyBreaker(t *testing.T) {
+	p := Policy{
+		Options:  Options{InitialBackoff: time.Microsecond, MaxBackoff: 10 * time.Microsecond, MaxRetries: 1},
+		Breakers: NewBreakers("test", 2),
+	}
+	attempts := 0
+	fail := func(context.Context) error {
+		attempts++
+		return errors.New("boom")
+	}
+	if err := p.Do(context.Background(), "a", alwaysRetryable, fail); err == nil {
+		t.Fatal("expected error")
+	}
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts, got %d", attempts)
+	}
+
+	// The breaker for "a" has tripped, so the next operation fails fast.
+	attempts = 0
+	err := p.Do(context.Background(), "a", alwaysRetryable, fail)
+	if pkgerrors.Cause(err) != circuit.ErrBreakerOpen {
+		t.Fatalf("expected open breaker, got %v", err)
+	}
+	if attempts != 0 {
+		t.Fatalf("expected no attempts, got %d", attempts)
+	}
+
+	// Other destinations are unaffected.
+	if err := p.Do(context.Background(), "b", alwaysRetryable, func(context.Context) error {
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDecorrelatedJitter(t *testing.T) {
+	base, max := 10*time.Millisecond, time.Second
+	prev := base
+	for i := 0; i < 100; i++ {
+		d := decorrelatedJitter(base, max, prev)
+		if d < base || d > max || d > 3*prev {
+			t.Fatalf("backoff %s outside of [%s, min(%s, %s)]", d, base, 3*prev, max)
+		}
+		prev = d
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+// +build linux
+
+package timeutil
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// ThreadCPUTimeSupported is true if ThreadCPUTime measures the CPU time of
+// the calling thread on this platform.
+const ThreadCPUTimeSupported = true
+
+// ThreadCPUTime returns the CPU time consumed by the calling OS thread. The
+// difference between two calls only measures the CPU time of a goroutine if
+// the goroutine is locked to its thread (see runtime.LockOSThread) in
+// between; otherwise, the goroutine may be moved to another thread and the
+// thread may run other goroutines.
+//
+// On Linux, it uses clock_gettime(CLOCK_THREAD_CPUTIME_ID). On other
+// platforms, it always returns zero.
+func ThreadCPUTime() time.Duration {
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_THREAD_CPUTIME_ID, &ts); err != nil {
+		return 0
+	}
+	return time.Duration(ts.Nano())
+}
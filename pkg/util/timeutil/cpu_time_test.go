@@ -0,0 +1,50 @@
+// Copyright 2019 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package timeutil
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestThreadCPUTime(t *testing.T) {
+	if !ThreadCPUTimeSupported {
+		t.Skip("thread CPU time is not supported on this platform")
+	}
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	// Sleeping doesn't consume CPU time.
+	start := ThreadCPUTime()
+	time.Sleep(50 * time.Millisecond)
+	if d := ThreadCPUTime() - start; d >= 25*time.Millisecond {
+		t.Errorf("expected sleeping not to consume CPU time, got %s", d)
+	}
+
+	// Spinning does.
+	start = ThreadCPUTime()
+	for deadline := Now().Add(50 * time.Millisecond); Now().Before(deadline); {
+	}
+	if d := ThreadCPUTime() - start; d < 10*time.Millisecond {
+		t.Errorf("expected spinning to consume CPU time, got %s", d)
+	}
+}
+
+func BenchmarkThreadCPUTime(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		ThreadCPUTime()
+	}
+}